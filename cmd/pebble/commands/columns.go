@@ -0,0 +1,216 @@
+package commands
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/marshallshelly/pebble-orm/cmd/pebble/output"
+	"github.com/marshallshelly/pebble-orm/pkg/loader"
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+	"github.com/spf13/cobra"
+)
+
+var (
+	columnsScanDir string
+	columnsOutput  string
+)
+
+// columnsCmd generates column name constants and TableName methods
+var columnsCmd = &cobra.Command{
+	Use:   "columns",
+	Short: "Generate column name constants and TableName methods for models",
+	Long: `Scan Go source files for models and generate, for each model:
+
+  - a package of column name constants (User → usercols.Email), so a typo in a
+    column name is a compile error instead of a string passed to builder.Col
+  - a TableName method, so the table name is known at compile time and the
+    runtime source-file lookup for // table_name: comments is skipped
+
+Examples:
+  pebble generate columns --scan ./internal/models
+  pebble generate columns --scan ./internal/models --output ./internal/cols`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runGenerateColumns()
+	},
+}
+
+func init() {
+	generateCmd.AddCommand(columnsCmd)
+
+	columnsCmd.Flags().StringVar(&columnsScanDir, "scan", "", "Directory to scan for model definitions (required)")
+	columnsCmd.Flags().StringVarP(&columnsOutput, "output", "o", "", "Directory for the column packages (default: <scan-dir>)")
+	_ = columnsCmd.MarkFlagRequired("scan")
+}
+
+func runGenerateColumns() error {
+	models, err := loader.ScanModels(columnsScanDir)
+	if err != nil {
+		return err
+	}
+	if len(models) == 0 {
+		output.Warning("No models found in %s", columnsScanDir)
+		return nil
+	}
+	if columnsOutput == "" {
+		columnsOutput = columnsScanDir
+	}
+
+	output.Section("Generating column constants")
+	files, err := generateColumnFiles(models, columnsOutput)
+	if err != nil {
+		return err
+	}
+	for _, path := range files {
+		output.Success("Generated: %s", path)
+	}
+	fmt.Println()
+	output.Info("Commit these files and re-run this command when models change.")
+	return nil
+}
+
+// generateColumnFiles writes a <model>cols package per model under outputDir
+// and a table_name_methods.gen.go file next to each group of models. It
+// returns the paths written.
+func generateColumnFiles(models []loader.Model, outputDir string) ([]string, error) {
+	var written []string
+	byDir := make(map[string][]loader.Model)
+	packages := make(map[string]string) // column package -> struct
+	for _, model := range models {
+		pkg := columnsPackageName(model.StructName)
+		if other, ok := packages[pkg]; ok {
+			return nil, fmt.Errorf("models %s and %s both map to column package %s", other, model.StructName, pkg)
+		}
+		packages[pkg] = model.StructName
+
+		dir := filepath.Join(outputDir, pkg)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+		path := filepath.Join(dir, pkg+".gen.go")
+		if err := writeGoFile(path, columnsSource(pkg, model)); err != nil {
+			return nil, fmt.Errorf("failed to generate %s: %w", path, err)
+		}
+		written = append(written, path)
+
+		modelDir := filepath.Dir(model.File)
+		byDir[modelDir] = append(byDir[modelDir], model)
+	}
+
+	dirs := make([]string, 0, len(byDir))
+	for dir := range byDir {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	for _, dir := range dirs {
+		declared, err := declaredTableNameMethods(dir)
+		if err != nil {
+			return nil, err
+		}
+		var pending []loader.Model
+		for _, model := range byDir[dir] {
+			if !declared[model.StructName] {
+				pending = append(pending, model)
+			}
+		}
+		if len(pending) == 0 {
+			continue
+		}
+		path := filepath.Join(dir, "table_name_methods.gen.go")
+		if err := writeGoFile(path, tableNameMethodsSource(pending)); err != nil {
+			return nil, fmt.Errorf("failed to generate %s: %w", path, err)
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
+
+// columnsPackageName returns the column package name of a model (User → usercols).
+func columnsPackageName(structName string) string {
+	return strings.ToLower(structName) + "cols"
+}
+
+// columnsSource returns the source of a model's column constants package.
+func columnsSource(pkg string, model loader.Model) string {
+	var sb strings.Builder
+	writeGeneratedHeader(&sb)
+	fmt.Fprintf(&sb, "// Package %s holds the column names of %s.%s.\n", pkg, model.Package, model.StructName)
+	fmt.Fprintf(&sb, "package %s\n\n", pkg)
+	fmt.Fprintf(&sb, "// Column names of the %s table, quoted where PostgreSQL requires it.\n", model.Table.QualifiedName())
+	sb.WriteString("const (\n")
+	for _, col := range model.Table.Columns {
+		fmt.Fprintf(&sb, "\t%s = %q\n", strings.ReplaceAll(col.GoField, ".", ""), schema.QuoteReservedIdent(col.Name))
+	}
+	sb.WriteString(")\n")
+	return sb.String()
+}
+
+// tableNameMethodsSource returns the source of the TableName methods of
+// models declared in one package.
+func tableNameMethodsSource(models []loader.Model) string {
+	var sb strings.Builder
+	writeGeneratedHeader(&sb)
+	fmt.Fprintf(&sb, "package %s\n", models[0].Package)
+	for _, model := range models {
+		fmt.Fprintf(&sb, "\n// TableName returns the table name of %s.\n", model.StructName)
+		fmt.Fprintf(&sb, "func (%s) TableName() string { return %q }\n", model.StructName, model.Table.Name)
+	}
+	return sb.String()
+}
+
+// writeGeneratedHeader writes the "Code generated" header of generated files.
+func writeGeneratedHeader(sb *strings.Builder) {
+	sb.WriteString("// Code generated by pebble-orm. DO NOT EDIT.\n")
+	fmt.Fprintf(sb, "// pebble %s\n\n", strings.Join(os.Args[1:], " "))
+}
+
+// writeGoFile gofmts src and writes it to path.
+func writeGoFile(path, src string) error {
+	formatted, err := format.Source([]byte(src))
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, formatted, 0644)
+}
+
+// declaredTableNameMethods returns the structs in dir that already declare a
+// TableName method by hand. Generated files are ignored.
+func declaredTableNameMethods(dir string) (map[string]bool, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	declared := make(map[string]bool)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, ".gen.go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(token.NewFileSet(), filepath.Join(dir, name), nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv == nil || fn.Name.Name != "TableName" || len(fn.Recv.List) == 0 {
+				continue
+			}
+			recv := fn.Recv.List[0].Type
+			if star, ok := recv.(*ast.StarExpr); ok {
+				recv = star.X
+			}
+			if ident, ok := recv.(*ast.Ident); ok {
+				declared[ident.Name] = true
+			}
+		}
+	}
+	return declared, nil
+}
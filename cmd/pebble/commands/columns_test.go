@@ -0,0 +1,88 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/marshallshelly/pebble-orm/pkg/loader"
+)
+
+const columnsModelsSource = `package models
+
+// table_name: accounts
+type User struct {
+	ID      int64   ` + "`po:\"id,primaryKey,bigint\"`" + `
+	Email   string  ` + "`po:\"email,text\"`" + `
+	Order   int     ` + "`po:\"order,integer\"`" + `
+	Address Address ` + "`po:\"embedded,prefix(addr_)\"`" + `
+}
+
+type Address struct {
+	City string ` + "`po:\"city,text\"`" + `
+}
+
+type Post struct {
+	ID int64 ` + "`po:\"id,primaryKey,bigint\"`" + `
+}
+
+func (Post) TableName() string { return "articles" }
+`
+
+func TestGenerateColumnFiles(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "models.go"), []byte(columnsModelsSource), 0644); err != nil {
+		t.Fatal(err)
+	}
+	models, err := loader.ScanModels(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := generateColumnFiles(models, dir); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+
+	read := func(path string) string {
+		t.Helper()
+		b, err := os.ReadFile(filepath.Join(dir, path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+
+	cols := read("usercols/usercols.gen.go")
+	for _, want := range []string{
+		"package usercols",
+		`ID          = "id"`,
+		`Email       = "email"`,
+		`Order       = "\"order\""`,
+		`AddressCity = "addr_city"`,
+	} {
+		if !strings.Contains(cols, want) {
+			t.Errorf("usercols missing %q:\n%s", want, cols)
+		}
+	}
+
+	methods := read("table_name_methods.gen.go")
+	if !strings.Contains(methods, `func (User) TableName() string { return "accounts" }`) {
+		t.Errorf("missing User.TableName:\n%s", methods)
+	}
+	if strings.Contains(methods, "func (Post)") {
+		t.Errorf("Post declares TableName by hand, got:\n%s", methods)
+	}
+
+	// Re-running sees its own output only as generated code.
+	models, err = loader.ScanModels(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := generateColumnFiles(models, dir); err != nil {
+		t.Fatalf("regenerate: %v", err)
+	}
+	if got := read("table_name_methods.gen.go"); got != methods {
+		t.Errorf("regenerated file differs:\n%s", got)
+	}
+}
@@ -0,0 +1,710 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/marshallshelly/pebble-orm/pkg/loader"
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+	"github.com/spf13/cobra"
+)
+
+var (
+	consoleModelsPath string
+	consoleWrite      bool
+	consoleExecute    []string
+	consoleRowLimit   int
+)
+
+// consoleCmd runs an interactive query console
+var consoleCmd = &cobra.Command{
+	Use:   "console",
+	Short: "Run ad hoc queries against models in an interactive console",
+	Long: `Connect to the database and run queries interactively, with results shown
+as tables. Enter either:
+
+  - a builder-style query on a model loaded with --models, written like the
+    query builder with the model in place of builder.Select[Model](db):
+
+      User.Where(Eq("email", "alice@example.com")).First()
+      Order.Where(Gte("total", 100), IsNull("shipped_at")).OrderByDesc("created_at").Limit(10)
+      Order.Where(In("status", "pending", "failed")).Count()
+      User.Find(42)
+
+  - raw SQL ended with a semicolon, which may span several lines
+
+  - a backslash command: \models, \d MODEL, \schema NAME, \limit N, \help, \q
+
+The session is read-only unless --write is given, so exploring production data
+can't change it. \schema sets the search_path, e.g. to a tenant's schema.
+
+Examples:
+  pebble console --models ./internal/models
+  pebble console --models ./internal/models -e 'User.Where(Eq("id", 1)).First()'
+  pebble console -e 'SELECT count(*) FROM users;'`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runConsole()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(consoleCmd)
+
+	consoleCmd.Flags().StringVar(&consoleModelsPath, "models", "", "Path to Go file or directory with model definitions")
+	consoleCmd.Flags().BoolVar(&consoleWrite, "write", false, "Allow statements that modify data")
+	consoleCmd.Flags().StringArrayVarP(&consoleExecute, "execute", "e", nil, "Run a query and exit instead of starting the console (repeatable)")
+	consoleCmd.Flags().IntVar(&consoleRowLimit, "limit", 100, "Rows to show per result; builder queries without Limit fetch only this many")
+}
+
+func runConsole() error {
+	if err := requireDB(); err != nil {
+		return err
+	}
+
+	var models []loader.Model
+	consoleModelsPath = configModels(consoleModelsPath)
+	if consoleModelsPath != "" {
+		var err error
+		if models, err = loader.ScanModels(consoleModelsPath); err != nil {
+			return fmt.Errorf("failed to load models: %w", err)
+		}
+	}
+
+	ctx := context.Background()
+
+	// A single connection, so session settings such as the search_path hold
+	// for every query.
+	conn, err := pgx.Connect(ctx, dbURL)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer func() { _ = conn.Close(ctx) }()
+
+	if !consoleWrite {
+		if _, err := conn.Exec(ctx, "SET SESSION CHARACTERISTICS AS TRANSACTION READ ONLY"); err != nil {
+			return fmt.Errorf("failed to make the session read-only: %w", err)
+		}
+	}
+
+	session := newConsoleSession(conn, models, os.Stdout)
+	session.limit = consoleRowLimit
+
+	if len(consoleExecute) > 0 {
+		for _, input := range consoleExecute {
+			if err := session.execute(ctx, input); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	mode := "read-only"
+	if consoleWrite {
+		mode = "read-write"
+	}
+	_, _ = fmt.Fprintf(os.Stdout, "pebble console (%s, %d models). Type \\help for help, \\q to quit.\n", mode, len(models))
+	return session.run(ctx, os.Stdin)
+}
+
+// consoleConn is the connection a console session queries; *pgx.Conn
+// implements it.
+type consoleConn interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+// errConsoleQuit is returned by execute for \q.
+var errConsoleQuit = errors.New("quit")
+
+// consoleSession runs the input of a console.
+type consoleSession struct {
+	conn   consoleConn
+	models map[string]*schema.TableMetadata // by struct name
+	out    io.Writer
+	limit  int // rows shown per result
+}
+
+func newConsoleSession(conn consoleConn, models []loader.Model, out io.Writer) *consoleSession {
+	s := &consoleSession{conn: conn, models: make(map[string]*schema.TableMetadata), out: out, limit: 100}
+	for _, m := range models {
+		s.models[m.StructName] = m.Table
+	}
+	return s
+}
+
+// run reads input until EOF or \q. Errors are printed and the console
+// carries on.
+func (s *consoleSession) run(ctx context.Context, in io.Reader) error {
+	scanner := bufio.NewScanner(in)
+	var pending strings.Builder
+	prompt := func() {
+		if pending.Len() > 0 {
+			_, _ = fmt.Fprint(s.out, "     -> ")
+		} else {
+			_, _ = fmt.Fprint(s.out, "pebble> ")
+		}
+	}
+
+	for prompt(); scanner.Scan(); prompt() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		// Raw SQL runs once a line ends with a semicolon
+		if pending.Len() > 0 || s.isRawSQL(line) && !strings.HasSuffix(line, ";") {
+			pending.WriteString(line)
+			pending.WriteString("\n")
+			if !strings.HasSuffix(line, ";") {
+				continue
+			}
+			line = pending.String()
+			pending.Reset()
+		}
+
+		err := s.execute(ctx, line)
+		if errors.Is(err, errConsoleQuit) {
+			return nil
+		}
+		if err != nil {
+			_, _ = fmt.Fprintf(s.out, "error: %v\n", err)
+		}
+	}
+	_, _ = fmt.Fprintln(s.out)
+	return scanner.Err()
+}
+
+// isRawSQL reports whether line starts SQL rather than a backslash command or
+// a builder-style query, which starts with the name of a loaded model.
+func (s *consoleSession) isRawSQL(line string) bool {
+	if strings.HasPrefix(line, `\`) {
+		return false
+	}
+	name, _, _ := strings.Cut(line, ".")
+	_, ok := s.models[strings.TrimSpace(name)]
+	return !ok
+}
+
+// execute runs one input: a backslash command, a builder-style query or raw
+// SQL.
+func (s *consoleSession) execute(ctx context.Context, input string) error {
+	input = strings.TrimSpace(input)
+	switch {
+	case input == "":
+		return nil
+	case strings.HasPrefix(input, `\`):
+		return s.command(ctx, input)
+	case !s.isRawSQL(input):
+		sql, args, err := s.buildQuery(input)
+		if err != nil {
+			return err
+		}
+		return s.query(ctx, sql, args...)
+	}
+	return s.query(ctx, strings.TrimSuffix(input, ";"))
+}
+
+// command runs a backslash command.
+func (s *consoleSession) command(ctx context.Context, input string) error {
+	fields := strings.Fields(input)
+	switch fields[0] {
+	case `\q`, `\quit`:
+		return errConsoleQuit
+	case `\h`, `\help`, `\?`:
+		_, _ = fmt.Fprint(s.out, consoleHelp)
+		return nil
+	case `\models`:
+		if len(s.models) == 0 {
+			_, _ = fmt.Fprintln(s.out, "No models loaded; start the console with --models.")
+			return nil
+		}
+		w := tabwriter.NewWriter(s.out, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "MODEL\tTABLE\tCOLUMNS")
+		for _, name := range slices.Sorted(maps.Keys(s.models)) {
+			table := s.models[name]
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%d\n", name, table.QualifiedName(), len(table.Columns))
+		}
+		return w.Flush()
+	case `\d`:
+		if len(fields) != 2 {
+			return fmt.Errorf(`usage: \d MODEL`)
+		}
+		table, err := s.model(fields[1])
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(s.out, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintf(w, "%s (%s)\n", fields[1], table.QualifiedName())
+		_, _ = fmt.Fprintln(w, "COLUMN\tTYPE\tNULLABLE\tKEY")
+		for _, col := range table.Columns {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", col.Name, col.SQLType, yesNo(col.Nullable), strings.Join(columnKeys(table, col), ", "))
+		}
+		return w.Flush()
+	case `\schema`:
+		if len(fields) == 1 {
+			return s.query(ctx, "SHOW search_path")
+		}
+		if len(fields) != 2 {
+			return fmt.Errorf(`usage: \schema NAME`)
+		}
+		path := pgx.Identifier{fields[1]}.Sanitize()
+		if fields[1] != schema.DefaultSchema {
+			path += ", " + schema.DefaultSchema
+		}
+		if _, err := s.conn.Exec(ctx, "SET search_path TO "+path); err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(s.out, "search_path is %s\n", path)
+		return nil
+	case `\limit`:
+		if len(fields) != 2 {
+			_, _ = fmt.Fprintf(s.out, "Showing up to %d rows\n", s.limit)
+			return nil
+		}
+		n, err := strconv.Atoi(fields[1])
+		if err != nil || n < 1 {
+			return fmt.Errorf(`usage: \limit N, with N at least 1`)
+		}
+		s.limit = n
+		return nil
+	}
+	return fmt.Errorf(`unknown command %s (try \help)`, fields[0])
+}
+
+const consoleHelp = `Builder-style queries, on a model loaded with --models:
+  User.Where(Eq("email", "a@example.com"), IsNotNull("verified_at")).First()
+  Order.Where(Between("total", 10, 100)).OrderByDesc("created_at").Limit(20).Offset(40)
+  Order.Columns("id", "status").Where(In("status", "pending", "failed")).All()
+  Order.Where(Like("note", "%refund%")).Count()
+  User.Find(42)
+Conditions: Eq, NotEq, Gt, Gte, Lt, Lte, Like, ILike, In, NotIn, IsNull, IsNotNull, Between
+
+Raw SQL ends with a semicolon and may span lines.
+
+Commands:
+  \models        list the loaded models
+  \d MODEL       describe a model's columns
+  \schema [NAME] show the search_path, or set it to NAME, public
+  \limit [N]     show or set the rows shown per result
+  \q             quit
+`
+
+// model returns the table of a loaded model.
+func (s *consoleSession) model(name string) (*schema.TableMetadata, error) {
+	table, ok := s.models[name]
+	if !ok {
+		return nil, fmt.Errorf(`unknown model %s (see \models)`, name)
+	}
+	return table, nil
+}
+
+// query runs sql and prints its rows as a table, or the command tag for
+// statements without rows.
+func (s *consoleSession) query(ctx context.Context, sql string, args ...any) error {
+	start := time.Now()
+	rows, err := s.conn.Query(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var columns []string
+	for _, f := range rows.FieldDescriptions() {
+		columns = append(columns, f.Name)
+	}
+	var values [][]any
+	more := false
+	for rows.Next() {
+		if len(values) == s.limit {
+			more = true
+			break
+		}
+		row, err := rows.Values()
+		if err != nil {
+			return err
+		}
+		values = append(values, row)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if len(columns) == 0 {
+		_, _ = fmt.Fprintf(s.out, "%s (%s)\n", rows.CommandTag(), time.Since(start).Round(time.Microsecond))
+		return nil
+	}
+	if err := renderConsoleRows(s.out, columns, values); err != nil {
+		return err
+	}
+	summary := fmt.Sprintf("(%d rows", len(values))
+	if len(values) == 1 {
+		summary = "(1 row"
+	}
+	if more {
+		summary = fmt.Sprintf("(first %d rows; \\limit N shows more", len(values))
+	}
+	_, _ = fmt.Fprintf(s.out, "%s, %s)\n", summary, time.Since(start).Round(time.Microsecond))
+	return nil
+}
+
+// renderConsoleRows writes rows as a table under a header of columns.
+func renderConsoleRows(out io.Writer, columns []string, rows [][]any) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	rules := make([]string, len(columns))
+	for i, col := range columns {
+		rules[i] = strings.Repeat("-", len(col))
+	}
+	_, _ = fmt.Fprintln(w, strings.Join(columns, "\t"))
+	_, _ = fmt.Fprintln(w, strings.Join(rules, "\t"))
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for i, v := range row {
+			cells[i] = formatConsoleValue(v)
+		}
+		_, _ = fmt.Fprintln(w, strings.Join(cells, "\t"))
+	}
+	return w.Flush()
+}
+
+// formatConsoleValue renders a value from pgx on one line.
+func formatConsoleValue(v any) string {
+	var s string
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case []byte:
+		return `\x` + hex.EncodeToString(v)
+	case time.Time:
+		s = v.Format(time.RFC3339Nano)
+	case [16]byte:
+		s = fmt.Sprintf("%x-%x-%x-%x-%x", v[0:4], v[4:6], v[6:8], v[8:10], v[10:16])
+	case map[string]any, []any:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		s = string(data)
+	default:
+		s = fmt.Sprint(v)
+	}
+	return strings.NewReplacer("\t", `\t`, "\n", `\n`).Replace(s)
+}
+
+// consoleQuery is a builder-style query parsed from the console.
+type consoleQuery struct {
+	table   *schema.TableMetadata
+	columns []string
+	where   []string
+	args    []any
+	orderBy []string
+	limit   int
+	offset  int
+	count   bool
+}
+
+// buildQuery translates a builder-style query on a model to SQL. Queries
+// without a Limit fetch the rows the console shows.
+func (s *consoleSession) buildQuery(input string) (string, []any, error) {
+	expr, err := parser.ParseExpr(strings.TrimSuffix(strings.TrimSpace(input), ";"))
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid query: %w", err)
+	}
+
+	// Unwind the chain of calls, innermost first
+	var calls []*ast.CallExpr
+	for {
+		call, ok := expr.(*ast.CallExpr)
+		if !ok {
+			break
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return "", nil, fmt.Errorf("invalid query: expected Model.Method(...)")
+		}
+		calls = append(calls, call)
+		expr = sel.X
+	}
+	ident, ok := expr.(*ast.Ident)
+	if !ok {
+		return "", nil, fmt.Errorf("invalid query: expected a model name")
+	}
+	table, err := s.model(ident.Name)
+	if err != nil {
+		return "", nil, err
+	}
+
+	q := &consoleQuery{table: table, limit: -1}
+	for i := len(calls) - 1; i >= 0; i-- {
+		if err := q.apply(calls[i]); err != nil {
+			return "", nil, err
+		}
+	}
+	if q.limit < 0 && !q.count {
+		q.limit = s.limit
+	}
+	sql, args := q.sql()
+	return sql, args, nil
+}
+
+// apply adds a method call of the chain to q.
+func (q *consoleQuery) apply(call *ast.CallExpr) error {
+	method := call.Fun.(*ast.SelectorExpr).Sel.Name
+	args := call.Args
+	switch method {
+	case "Where":
+		if len(args) == 0 {
+			return fmt.Errorf("Where needs at least one condition")
+		}
+		for _, arg := range args {
+			if err := q.condition(arg); err != nil {
+				return err
+			}
+		}
+	case "Columns":
+		for _, arg := range args {
+			col, err := q.column(arg)
+			if err != nil {
+				return err
+			}
+			q.columns = append(q.columns, col)
+		}
+	case "OrderByAsc", "OrderByDesc":
+		if len(args) != 1 {
+			return fmt.Errorf("%s takes a column", method)
+		}
+		col, err := q.column(args[0])
+		if err != nil {
+			return err
+		}
+		if method == "OrderByDesc" {
+			col += " DESC"
+		}
+		q.orderBy = append(q.orderBy, col)
+	case "Limit", "Offset":
+		n, err := intArg(method, args)
+		if err != nil {
+			return err
+		}
+		if method == "Limit" {
+			q.limit = n
+		} else {
+			q.offset = n
+		}
+	case "Find":
+		pk := q.table.PrimaryKey
+		if pk == nil || len(pk.Columns) != 1 {
+			return fmt.Errorf("Find needs a single-column primary key on %s", q.table.Name)
+		}
+		if len(args) != 1 {
+			return fmt.Errorf("Find takes the primary key value")
+		}
+		v, err := literal(args[0])
+		if err != nil {
+			return err
+		}
+		q.args = append(q.args, v)
+		q.where = append(q.where, fmt.Sprintf("%s = $%d", schema.QuoteReservedIdent(pk.Columns[0]), len(q.args)))
+		q.limit = 1
+	case "First", "Count", "All":
+		if len(args) > 0 {
+			return fmt.Errorf("%s takes no arguments", method)
+		}
+		switch method {
+		case "First":
+			q.limit = 1
+		case "Count":
+			q.count = true
+		}
+	default:
+		return fmt.Errorf("unsupported method %s (see \\help)", method)
+	}
+	return nil
+}
+
+// condition adds a condition such as Eq("email", "a@example.com") to q.
+func (q *consoleQuery) condition(expr ast.Expr) error {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok {
+		return fmt.Errorf("invalid condition: expected a call such as Eq(column, value)")
+	}
+	fun := call.Fun
+	if sel, ok := fun.(*ast.SelectorExpr); ok {
+		fun = sel.Sel // builder.Eq
+	}
+	name, _ := fun.(*ast.Ident)
+	if name == nil || len(call.Args) == 0 {
+		return fmt.Errorf("invalid condition: expected a call such as Eq(column, value)")
+	}
+	col, err := q.column(call.Args[0])
+	if err != nil {
+		return err
+	}
+	values := make([]any, 0, len(call.Args)-1)
+	for _, arg := range call.Args[1:] {
+		v, err := literal(arg)
+		if err != nil {
+			return err
+		}
+		values = append(values, v)
+	}
+	param := func(v any) string {
+		q.args = append(q.args, v)
+		return fmt.Sprintf("$%d", len(q.args))
+	}
+
+	operators := map[string]string{
+		"Eq": "=", "NotEq": "!=", "Gt": ">", "Gte": ">=", "Lt": "<", "Lte": "<=",
+		"Like": "LIKE", "ILike": "ILIKE",
+	}
+	switch fn := name.Name; {
+	case operators[fn] != "":
+		if len(values) != 1 {
+			return fmt.Errorf("%s takes a column and a value", fn)
+		}
+		q.where = append(q.where, fmt.Sprintf("%s %s %s", col, operators[fn], param(values[0])))
+	case fn == "In" || fn == "NotIn":
+		if len(values) == 0 {
+			return fmt.Errorf("%s takes a column and values", fn)
+		}
+		params := make([]string, len(values))
+		for i, v := range values {
+			params[i] = param(v)
+		}
+		op := "IN"
+		if fn == "NotIn" {
+			op = "NOT IN"
+		}
+		q.where = append(q.where, fmt.Sprintf("%s %s (%s)", col, op, strings.Join(params, ", ")))
+	case fn == "IsNull" || fn == "IsNotNull":
+		if len(values) != 0 {
+			return fmt.Errorf("%s takes a column", fn)
+		}
+		op := "IS NULL"
+		if fn == "IsNotNull" {
+			op = "IS NOT NULL"
+		}
+		q.where = append(q.where, col+" "+op)
+	case fn == "Between":
+		if len(values) != 2 {
+			return fmt.Errorf("Between takes a column, a minimum and a maximum")
+		}
+		q.where = append(q.where, fmt.Sprintf("%s BETWEEN %s AND %s", col, param(values[0]), param(values[1])))
+	default:
+		return fmt.Errorf("unsupported condition %s (see \\help)", fn)
+	}
+	return nil
+}
+
+// column returns the quoted column named by a string literal, checked
+// against the model.
+func (q *consoleQuery) column(expr ast.Expr) (string, error) {
+	v, err := literal(expr)
+	name, ok := v.(string)
+	if err != nil || !ok {
+		return "", fmt.Errorf("expected a column name in quotes")
+	}
+	if q.table.GetColumnByName(name) == nil {
+		return "", fmt.Errorf("%s has no column %q", q.table.Name, name)
+	}
+	return schema.QuoteReservedIdent(name), nil
+}
+
+// sql renders q.
+func (q *consoleQuery) sql() (string, []any) {
+	var b strings.Builder
+	switch {
+	case q.count:
+		b.WriteString("SELECT COUNT(*)")
+	case len(q.columns) > 0:
+		b.WriteString("SELECT " + strings.Join(q.columns, ", "))
+	default:
+		b.WriteString("SELECT *")
+	}
+	b.WriteString(" FROM " + schema.QuoteQualifiedIdent(q.table.QualifiedName()))
+	if len(q.where) > 0 {
+		b.WriteString(" WHERE " + strings.Join(q.where, " AND "))
+	}
+	if q.count {
+		return b.String(), q.args
+	}
+	if len(q.orderBy) > 0 {
+		b.WriteString(" ORDER BY " + strings.Join(q.orderBy, ", "))
+	}
+	if q.limit >= 0 {
+		fmt.Fprintf(&b, " LIMIT %d", q.limit)
+	}
+	if q.offset > 0 {
+		fmt.Fprintf(&b, " OFFSET %d", q.offset)
+	}
+	return b.String(), q.args
+}
+
+// intArg returns the single integer argument of method.
+func intArg(method string, args []ast.Expr) (int, error) {
+	if len(args) == 1 {
+		if v, err := literal(args[0]); err == nil {
+			if n, ok := v.(int64); ok && n >= 0 {
+				return int(n), nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("%s takes a non-negative integer", method)
+}
+
+// literal returns the value of a Go literal: a string, number, true, false
+// or nil.
+func literal(expr ast.Expr) (any, error) {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		switch e.Kind {
+		case token.STRING:
+			return strconv.Unquote(e.Value)
+		case token.INT:
+			return strconv.ParseInt(e.Value, 0, 64)
+		case token.FLOAT:
+			return strconv.ParseFloat(e.Value, 64)
+		}
+	case *ast.Ident:
+		switch e.Name {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "nil":
+			return nil, nil
+		}
+	case *ast.UnaryExpr:
+		if e.Op == token.SUB {
+			v, err := literal(e.X)
+			switch n := v.(type) {
+			case int64:
+				return -n, err
+			case float64:
+				return -n, err
+			}
+		}
+	case *ast.ParenExpr:
+		return literal(e.X)
+	}
+	var b strings.Builder
+	_ = printer.Fprint(&b, token.NewFileSet(), expr)
+	return nil, fmt.Errorf("unsupported value %s: use a string, number, true, false or nil", b.String())
+}
@@ -0,0 +1,176 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/marshallshelly/pebble-orm/pkg/loader"
+)
+
+func consoleModels(t *testing.T) []loader.Model {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "models.go"), []byte(docsModelsSource), 0644); err != nil {
+		t.Fatal(err)
+	}
+	models, err := loader.ScanModels(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return models
+}
+
+func TestConsoleBuildQuery(t *testing.T) {
+	s := newConsoleSession(nil, consoleModels(t), &bytes.Buffer{})
+	s.limit = 50
+
+	tests := []struct {
+		input    string
+		wantSQL  string
+		wantArgs []any
+		wantErr  string
+	}{
+		{
+			input:   "User",
+			wantSQL: "SELECT * FROM users LIMIT 50",
+		},
+		{
+			input:    `User.Where(Eq("email", "a@example.com"), IsNotNull("team_id")).First()`,
+			wantSQL:  "SELECT * FROM users WHERE email = $1 AND team_id IS NOT NULL LIMIT 1",
+			wantArgs: []any{"a@example.com"},
+		},
+		{
+			input:    `User.Columns("id", "email").Where(builder.In("team_id", 1, 2)).OrderByDesc("id").Limit(10).Offset(20)`,
+			wantSQL:  "SELECT id, email FROM users WHERE team_id IN ($1, $2) ORDER BY id DESC LIMIT 10 OFFSET 20",
+			wantArgs: []any{int64(1), int64(2)},
+		},
+		{
+			input:    `Team.Where(Between("id", -1, 2.5)).Count()`,
+			wantSQL:  "SELECT COUNT(*) FROM teams WHERE id BETWEEN $1 AND $2",
+			wantArgs: []any{int64(-1), 2.5},
+		},
+		{
+			input:    "Team.Find(7);",
+			wantSQL:  "SELECT * FROM teams WHERE id = $1 LIMIT 1",
+			wantArgs: []any{int64(7)},
+		},
+		{input: `User.Where(Eq("nope", 1))`, wantErr: `users has no column "nope"`},
+		{input: `User.Where(Eq("id", x))`, wantErr: "unsupported value x"},
+		{input: `User.Delete()`, wantErr: "unsupported method Delete"},
+		{input: `User.First(1)`, wantErr: "First takes no arguments"},
+		{input: `User.Limit("a")`, wantErr: "Limit takes a non-negative integer"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			sql, args, err := s.buildQuery(tt.input)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sql != tt.wantSQL {
+				t.Errorf("got SQL %q, want %q", sql, tt.wantSQL)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("got args %#v, want %#v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+// consoleRows is a pgx.Rows over fixed values.
+type consoleRows struct {
+	pgx.Rows
+	columns []string
+	values  [][]any
+	next    int
+}
+
+func (r *consoleRows) FieldDescriptions() []pgconn.FieldDescription {
+	fields := make([]pgconn.FieldDescription, len(r.columns))
+	for i, c := range r.columns {
+		fields[i].Name = c
+	}
+	return fields
+}
+
+func (r *consoleRows) Next() bool {
+	r.next++
+	return r.next <= len(r.values)
+}
+
+func (r *consoleRows) Values() ([]any, error)        { return r.values[r.next-1], nil }
+func (r *consoleRows) Close()                        {}
+func (r *consoleRows) Err() error                    { return nil }
+func (r *consoleRows) CommandTag() pgconn.CommandTag { return pgconn.NewCommandTag("SELECT") }
+
+// consoleTestConn records statements and answers every query with rows.
+type consoleTestConn struct {
+	statements []string
+	rows       *consoleRows
+}
+
+func (c *consoleTestConn) Query(_ context.Context, sql string, _ ...any) (pgx.Rows, error) {
+	c.statements = append(c.statements, sql)
+	rows := *c.rows
+	return &rows, nil
+}
+
+func (c *consoleTestConn) Exec(_ context.Context, sql string, _ ...any) (pgconn.CommandTag, error) {
+	c.statements = append(c.statements, sql)
+	return pgconn.NewCommandTag("SET"), nil
+}
+
+func TestConsoleRun(t *testing.T) {
+	conn := &consoleTestConn{rows: &consoleRows{
+		columns: []string{"id", "name"},
+		values:  [][]any{{int64(1), "core"}, {int64(2), nil}, {int64(3), "x"}},
+	}}
+	var out bytes.Buffer
+	s := newConsoleSession(conn, consoleModels(t), &out)
+	s.limit = 2
+
+	input := strings.Join([]string{
+		`\models`,
+		`Team.Where(Gt("id", 0))`,
+		"SELECT id, name",
+		"  FROM teams;",
+		`\schema tenant_7`,
+		`\d Nope`,
+		`\q`,
+		"SELECT 'not run';",
+	}, "\n")
+	if err := s.run(context.Background(), strings.NewReader(input)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"SELECT * FROM teams WHERE id > $1 LIMIT 2",
+		"SELECT id, name\nFROM teams",
+		`SET search_path TO "tenant_7", public`,
+	}
+	if !reflect.DeepEqual(conn.statements, want) {
+		t.Errorf("got statements %q, want %q", conn.statements, want)
+	}
+	for _, line := range []string{
+		"Team   teams  2",
+		"id  name\n--  ----\n1   core\n2   NULL\n",
+		"(first 2 rows",
+		"error: unknown model Nope",
+	} {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("output is missing %q:\n%s", line, out.String())
+		}
+	}
+}
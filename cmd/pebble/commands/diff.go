@@ -3,12 +3,14 @@ package commands
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/marshallshelly/pebble-orm/cmd/pebble/output"
+	"github.com/marshallshelly/pebble-orm/pkg/loader"
 	"github.com/marshallshelly/pebble-orm/pkg/migration"
 	"github.com/marshallshelly/pebble-orm/pkg/registry"
 	"github.com/spf13/cobra"
@@ -16,9 +18,15 @@ import (
 
 var (
 	// Diff flags
-	outputFile string
+	outputFile   string
+	diffFormat   string
+	diffExitCode bool
 )
 
+// errSchemaDiffers is returned by diff --exit-code when the database is not
+// in sync with the models.
+var errSchemaDiffers = errors.New("database schema differs from models")
+
 // diffCmd shows schema differences
 var diffCmd = &cobra.Command{
 	Use:   "diff",
@@ -28,10 +36,21 @@ var diffCmd = &cobra.Command{
 This command helps you understand what migrations would be generated
 before actually creating migration files.
 
+Formats:
+  text      Changes and the migration SQL, for reading (default)
+  json      The structured SchemaDiff, for review tooling
+  sql       The UP migration SQL only
+  summary   One line per change: +, - or ~, the kind of object and its name
+
+With --exit-code, the command fails when there are changes, so a CI job can
+check that every model change comes with a migration.
+
 Examples:
-  pebble diff                          # Show schema differences
-  pebble diff --json                   # Output in JSON format
-  pebble diff --output migration.sql   # Save SQL to file`,
+  pebble diff --models ./internal/models                       # Show schema differences
+  pebble diff --models ./internal/models --format json         # Output in JSON format
+  pebble diff --models ./internal/models --format summary --exit-code
+  pebble diff --models ./internal/models --output migration.sql   # Save SQL to file`,
+	SilenceUsage: true, // changes found with --exit-code aren't usage errors
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return runDiff()
 	},
@@ -41,11 +60,30 @@ func init() {
 	rootCmd.AddCommand(diffCmd)
 
 	diffCmd.Flags().StringVarP(&outputFile, "output", "o", "", "Output SQL to file")
+	diffCmd.Flags().StringVar(&modelsPath, "models", "", "Path to Go file or directory with model definitions")
+	diffCmd.Flags().StringVar(&diffFormat, "format", "text", "Output format: text, json, sql or summary")
+	diffCmd.Flags().BoolVar(&diffExitCode, "exit-code", false, "Exit with an error when there are changes")
 }
 
 func runDiff() error {
-	if dbURL == "" {
-		return fmt.Errorf("--db flag is required")
+	format := diffFormat
+	if jsonOutput {
+		format = "json"
+	}
+	switch format {
+	case "text", "json", "sql", "summary":
+	default:
+		return fmt.Errorf("unknown format %q: use text, json, sql or summary", format)
+	}
+
+	if err := requireDB(); err != nil {
+		return err
+	}
+	modelsPath = configModels(modelsPath)
+	if modelsPath != "" {
+		if _, err := loader.LoadModelsFromPath(modelsPath, globalRegistryWrapper{}); err != nil {
+			return fmt.Errorf("failed to load models: %w", err)
+		}
 	}
 
 	ctx := context.Background()
@@ -61,40 +99,99 @@ func runDiff() error {
 	codeSchema := registry.AllTables()
 
 	if len(codeSchema) == 0 {
-		return fmt.Errorf("no models registered - use registry.Register() to register your models")
+		return fmt.Errorf("no models found - pass --models with the path to your model definitions")
 	}
 
 	// Introspect database
-	introspector := migration.NewIntrospector(pool)
+	introspector := migration.NewIntrospector(pool).WithSchemas(migration.SchemasOf(codeSchema)...)
 	dbSchema, err := introspector.IntrospectSchema(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to introspect database: %w", err)
 	}
 
+	extensions, err := introspector.IntrospectExtensions(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to introspect extensions: %w", err)
+	}
+	sequences, err := introspector.IntrospectSequences(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to introspect sequences: %w", err)
+	}
+
 	// Compare schemas
-	differ := migration.NewDiffer()
+	differ := migration.NewDiffer().WithInstalledExtensions(extensions).WithSequences(sequences)
 	diff := differ.Compare(codeSchema, dbSchema)
 
-	// Check if there are changes
-	if !diff.HasChanges() {
-		output.Success("No schema changes detected. Database is in sync with models.")
-		return nil
-	}
+	// Generate SQL
+	planner := migration.NewPlanner()
+	upSQL, downSQL := planner.GenerateMigration(diff)
 
-	// Output JSON
-	if jsonOutput {
+	switch format {
+	case "json":
 		enc := json.NewEncoder(os.Stdout)
 		enc.SetIndent("", "  ")
-		return enc.Encode(diff)
+		if err := enc.Encode(diff); err != nil {
+			return err
+		}
+	case "sql":
+		if diff.HasChanges() {
+			fmt.Println(upSQL)
+		}
+	case "summary":
+		for _, line := range diffSummary(diff) {
+			fmt.Println(line)
+		}
+	default:
+		printDiff(diff, upSQL, downSQL)
 	}
 
-	// Generate SQL
-	planner := migration.NewPlanner()
-	upSQL, downSQL := planner.GenerateMigration(diff)
+	// Save to file if requested
+	if outputFile != "" && diff.HasChanges() {
+		if err := os.WriteFile(outputFile, []byte(upSQL), 0644); err != nil {
+			return fmt.Errorf("failed to write output file: %w", err)
+		}
+		if format == "text" {
+			fmt.Println()
+			output.Success("SQL saved to: %s", outputFile)
+		}
+	}
+
+	if diffExitCode && diff.HasChanges() {
+		return errSchemaDiffers
+	}
+	return nil
+}
+
+// printDiff prints the changes of diff and the migration SQL for reading.
+func printDiff(diff *migration.SchemaDiff, upSQL, downSQL string) {
+	// Check if there are changes
+	if !diff.HasChanges() {
+		output.Success("No schema changes detected. Database is in sync with models.")
+		return
+	}
 
 	// Show summary
 	output.Section("Schema Differences")
 
+	if len(diff.ExtensionsAdded) > 0 {
+		output.Success("Extensions to create (%d):", len(diff.ExtensionsAdded))
+		for _, name := range diff.ExtensionsAdded {
+			fmt.Printf("  + %s\n", name)
+		}
+		fmt.Println()
+	}
+
+	if len(diff.SequencesAdded) > 0 || len(diff.SequencesModified) > 0 {
+		output.Info("Sequences to change (%d):", len(diff.SequencesAdded)+len(diff.SequencesModified))
+		for _, seq := range diff.SequencesAdded {
+			fmt.Printf("  + %s\n", seq.Name)
+		}
+		for _, seqDiff := range diff.SequencesModified {
+			fmt.Printf("  ~ %s\n", seqDiff.Name)
+		}
+		fmt.Println()
+	}
+
 	if len(diff.TablesAdded) > 0 {
 		output.Success("Tables to add (%d):", len(diff.TablesAdded))
 		for _, table := range diff.TablesAdded {
@@ -111,6 +208,20 @@ func runDiff() error {
 		fmt.Println()
 	}
 
+	if len(diff.ViewsAdded) > 0 || len(diff.ViewsModified) > 0 || len(diff.ViewsDropped) > 0 {
+		output.Info("Views to change (%d):", len(diff.ViewsAdded)+len(diff.ViewsModified)+len(diff.ViewsDropped))
+		for _, view := range diff.ViewsAdded {
+			fmt.Printf("  + %s\n", view.QualifiedName())
+		}
+		for _, viewDiff := range diff.ViewsModified {
+			fmt.Printf("  ~ %s (recreate)\n", viewDiff.Name)
+		}
+		for _, view := range diff.ViewsDropped {
+			fmt.Printf("  - %s\n", view.QualifiedName())
+		}
+		fmt.Println()
+	}
+
 	if len(diff.TablesModified) > 0 {
 		output.Info("Tables to modify (%d):", len(diff.TablesModified))
 		for _, tableDiff := range diff.TablesModified {
@@ -179,8 +290,8 @@ func runDiff() error {
 			}
 
 			if len(tableDiff.ForeignKeysDropped) > 0 {
-				for _, fkName := range tableDiff.ForeignKeysDropped {
-					fmt.Printf("      - foreign key: %s\n", fkName)
+				for _, fk := range tableDiff.ForeignKeysDropped {
+					fmt.Printf("      - foreign key: %s\n", fk.Name)
 				}
 			}
 
@@ -199,17 +310,103 @@ func runDiff() error {
 		output.Section("Migration SQL (DOWN)")
 		fmt.Println(downSQL)
 	}
+}
 
-	// Save to file if requested
-	if outputFile != "" {
-		if err := os.WriteFile(outputFile, []byte(upSQL), 0644); err != nil {
-			return fmt.Errorf("failed to write output file: %w", err)
-		}
-		fmt.Println()
-		output.Success("SQL saved to: %s", outputFile)
+// diffSummary describes diff one change per line, for scripts: "+" for
+// objects to create, "-" to drop and "~" to change, then the kind of object
+// and its name, with columns, indexes and constraints named after their
+// table ("~ column users.email").
+func diffSummary(diff *migration.SchemaDiff) []string {
+	var lines []string
+	add := func(op, kind, name string) {
+		lines = append(lines, fmt.Sprintf("%s %s %s", op, kind, name))
 	}
 
-	return nil
+	for _, name := range diff.ExtensionsAdded {
+		add("+", "extension", name)
+	}
+	for _, seq := range diff.SequencesAdded {
+		add("+", "sequence", seq.Name)
+	}
+	for _, seqDiff := range diff.SequencesModified {
+		add("~", "sequence", seqDiff.Name)
+	}
+	for _, enum := range diff.EnumTypesAdded {
+		add("+", "enum", enum.Name)
+	}
+	for _, enumDiff := range diff.EnumTypesModified {
+		add("~", "enum", enumDiff.Name)
+	}
+	for _, enum := range diff.EnumTypesDropped {
+		add("-", "enum", enum.Name)
+	}
+	for _, rename := range diff.TablesRenamed {
+		add("~", "table", rename.Old+" -> "+rename.New)
+	}
+	for _, table := range diff.TablesAdded {
+		add("+", "table", table.QualifiedName())
+	}
+	for _, table := range diff.TablesDropped {
+		add("-", "table", table.QualifiedName())
+	}
+	for _, t := range diff.TablesModified {
+		in := func(name string) string { return t.TableName + "." + name }
+		for _, rename := range t.ColumnsRenamed {
+			add("~", "column", in(rename.Old)+" -> "+in(rename.New))
+		}
+		for _, col := range t.ColumnsAdded {
+			add("+", "column", in(col.Name))
+		}
+		for _, col := range t.ColumnsDropped {
+			add("-", "column", in(col.Name))
+		}
+		for _, colDiff := range t.ColumnsModified {
+			add("~", "column", in(colDiff.ColumnName))
+		}
+		if t.PrimaryKeyChanged != nil {
+			add("~", "primary key", t.TableName)
+		}
+		for _, idx := range t.IndexesAdded {
+			add("+", "index", in(idx.Name))
+		}
+		for _, idx := range t.IndexesDropped {
+			add("-", "index", in(idx.Name))
+		}
+		for _, idxDiff := range t.IndexesModified {
+			add("~", "index", in(idxDiff.New.Name))
+		}
+		for _, fk := range t.ForeignKeysAdded {
+			add("+", "foreign key", in(fk.Name))
+		}
+		for _, fk := range t.ForeignKeysDropped {
+			add("-", "foreign key", in(fk.Name))
+		}
+		for _, c := range t.ConstraintsAdded {
+			add("+", "constraint", in(c.Name))
+		}
+		for _, c := range t.ConstraintsDropped {
+			add("-", "constraint", in(c.Name))
+		}
+		if t.CommentChanged != nil {
+			add("~", "comment", t.TableName)
+		}
+		if t.CDCChanged != nil {
+			add("~", "cdc", t.TableName)
+		}
+		if t.HistoryChanged != nil {
+			add("~", "history", t.TableName)
+		}
+	}
+	for _, view := range diff.ViewsAdded {
+		add("+", "view", view.QualifiedName())
+	}
+	for _, viewDiff := range diff.ViewsModified {
+		add("~", "view", viewDiff.Name)
+	}
+	for _, view := range diff.ViewsDropped {
+		add("-", "view", view.QualifiedName())
+	}
+	return lines
 }
 
 func joinStrings(strs []string, sep string) string {
@@ -0,0 +1,53 @@
+package commands
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/marshallshelly/pebble-orm/pkg/migration"
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+)
+
+func TestDiffSummary(t *testing.T) {
+	diff := &migration.SchemaDiff{
+		ExtensionsAdded: []string{"pg_trgm"},
+		EnumTypesAdded:  []schema.EnumType{{Name: "order_status"}},
+		TablesRenamed:   []migration.TableRename{{Old: "customers", New: "clients"}},
+		TablesAdded:     []schema.TableMetadata{{Name: "invoices", Schema: "billing"}},
+		TablesDropped:   []schema.TableMetadata{{Name: "legacy"}},
+		TablesModified: []migration.TableDiff{{
+			TableName:      "users",
+			ColumnsRenamed: []migration.ColumnRename{{Old: "mail", New: "email"}},
+			ColumnsAdded:   []schema.ColumnMetadata{{Name: "age"}},
+			ColumnsModified: []migration.ColumnDiff{{
+				ColumnName: "name", TypeChanged: true,
+			}},
+			IndexesAdded:       []schema.IndexMetadata{{Name: "idx_users_age"}},
+			ForeignKeysDropped: []schema.ForeignKeyMetadata{{Name: "users_team_id_fkey"}},
+			PrimaryKeyChanged:  &migration.PrimaryKeyChange{},
+		}},
+		ViewsModified: []migration.ViewDiff{{Name: "active_users"}},
+	}
+
+	want := []string{
+		"+ extension pg_trgm",
+		"+ enum order_status",
+		"~ table customers -> clients",
+		"+ table billing.invoices",
+		"- table legacy",
+		"~ column users.mail -> users.email",
+		"+ column users.age",
+		"~ column users.name",
+		"~ primary key users",
+		"+ index users.idx_users_age",
+		"- foreign key users.users_team_id_fkey",
+		"~ view active_users",
+	}
+	if got := diffSummary(diff); !reflect.DeepEqual(got, want) {
+		t.Errorf("got\n%q\nwant\n%q", got, want)
+	}
+
+	if got := diffSummary(&migration.SchemaDiff{}); len(got) != 0 {
+		t.Errorf("expected no lines for an empty diff, got %q", got)
+	}
+}
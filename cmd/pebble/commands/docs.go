@@ -0,0 +1,400 @@
+package commands
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/marshallshelly/pebble-orm/cmd/pebble/output"
+	"github.com/marshallshelly/pebble-orm/pkg/loader"
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+	"github.com/spf13/cobra"
+)
+
+var (
+	docsScanDir string
+	docsOutput  string
+	docsDiagram string
+)
+
+// docsCmd generates schema documentation
+var docsCmd = &cobra.Command{
+	Use:   "docs",
+	Short: "Generate Markdown schema docs and an ER diagram from models",
+	Long: `Scan Go source files for models and write Markdown documentation of the
+schema: a section per table with its columns, indexes, constraints, foreign
+keys and the tables referencing it, and an entity-relationship diagram of the
+foreign keys between tables.
+
+The diagram is a Mermaid block in the Markdown file, which GitHub and GitLab
+render, or with --diagram dot a Graphviz file next to it.
+
+Examples:
+  pebble generate docs --scan ./internal/models
+  pebble generate docs --scan ./internal/models --output ./docs/schema.md --diagram dot`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runGenerateDocs()
+	},
+}
+
+func init() {
+	generateCmd.AddCommand(docsCmd)
+
+	docsCmd.Flags().StringVar(&docsScanDir, "scan", "", "Directory to scan for model definitions (required)")
+	docsCmd.Flags().StringVarP(&docsOutput, "output", "o", "docs/schema.md", "Markdown file to write")
+	docsCmd.Flags().StringVar(&docsDiagram, "diagram", "mermaid", "ER diagram format: mermaid, dot or none")
+	_ = docsCmd.MarkFlagRequired("scan")
+}
+
+func runGenerateDocs() error {
+	switch docsDiagram {
+	case "mermaid", "dot", "none":
+	default:
+		return fmt.Errorf("unknown diagram format %q: use mermaid, dot or none", docsDiagram)
+	}
+
+	models, err := loader.ScanModels(docsScanDir)
+	if err != nil {
+		return err
+	}
+	if len(models) == 0 {
+		output.Warning("No models found in %s", docsScanDir)
+		return nil
+	}
+	tables := make(map[string]*schema.TableMetadata, len(models))
+	for _, m := range models {
+		tables[m.Table.QualifiedName()] = m.Table
+	}
+
+	output.Section("Generating schema docs")
+	if err := os.MkdirAll(filepath.Dir(docsOutput), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(docsOutput, []byte(schemaMarkdown(tables, docsDiagram == "mermaid")), 0644); err != nil {
+		return err
+	}
+	output.Success("Generated: %s", docsOutput)
+
+	if docsDiagram == "dot" {
+		path := strings.TrimSuffix(docsOutput, filepath.Ext(docsOutput)) + ".dot"
+		if err := os.WriteFile(path, []byte(schemaDOT(tables)), 0644); err != nil {
+			return err
+		}
+		output.Success("Generated: %s", path)
+		output.Muted("  Render it with: dot -Tsvg %s -o %s", path, strings.TrimSuffix(path, ".dot")+".svg")
+	}
+	fmt.Println()
+	output.Info("Commit these files and re-run this command when models change.")
+	return nil
+}
+
+// schemaMarkdown renders tables as Markdown, with a Mermaid ER diagram when
+// mermaid is set.
+func schemaMarkdown(tables map[string]*schema.TableMetadata, mermaid bool) string {
+	names := slices.Sorted(maps.Keys(tables))
+	refs := referencedBy(tables)
+
+	var b strings.Builder
+	b.WriteString("<!-- Code generated by pebble-orm. DO NOT EDIT. -->\n\n")
+	b.WriteString("# Database schema\n\n")
+	for _, name := range names {
+		fmt.Fprintf(&b, "- [%s](#%s)\n", name, markdownAnchor(name))
+	}
+	b.WriteString("\n")
+
+	if mermaid {
+		b.WriteString("## Diagram\n\n```mermaid\n")
+		b.WriteString(schemaMermaid(tables))
+		b.WriteString("```\n\n")
+	}
+
+	for _, name := range names {
+		table := tables[name]
+		fmt.Fprintf(&b, "## %s\n\n", name)
+		if table.View != nil {
+			kind := "View"
+			if table.View.Materialized {
+				kind = "Materialized view"
+			}
+			fmt.Fprintf(&b, "%s defined as:\n\n```sql\n%s\n```\n\n", kind, strings.TrimSpace(table.View.Query))
+		}
+		if table.Comment != "" {
+			fmt.Fprintf(&b, "%s\n\n", table.Comment)
+		}
+
+		b.WriteString("| Column | Type | Nullable | Default | Key | Comment |\n")
+		b.WriteString("|--------|------|----------|---------|-----|---------|\n")
+		for _, col := range table.Columns {
+			fmt.Fprintf(&b, "| `%s` | `%s` | %s | %s | %s | %s |\n",
+				col.Name, col.SQLType, yesNo(col.Nullable), markdownCode(columnDefault(col)),
+				strings.Join(columnKeys(table, col), ", "), markdownCell(col.Comment))
+		}
+		b.WriteString("\n")
+
+		if len(table.Indexes) > 0 {
+			b.WriteString("**Indexes**\n\n")
+			for _, idx := range table.Indexes {
+				fmt.Fprintf(&b, "- `%s`: %s\n", idx.Name, describeIndex(idx))
+			}
+			b.WriteString("\n")
+		}
+		if len(table.Constraints) > 0 {
+			b.WriteString("**Constraints**\n\n")
+			for _, c := range table.Constraints {
+				fmt.Fprintf(&b, "- `%s`: %s\n", c.Name, describeConstraint(c))
+			}
+			b.WriteString("\n")
+		}
+		if len(table.ForeignKeys) > 0 {
+			b.WriteString("**Foreign keys**\n\n")
+			for _, fk := range table.ForeignKeys {
+				fmt.Fprintf(&b, "- `%s` → [%s](#%s) (`%s`)%s\n",
+					strings.Join(fk.Columns, ", "), fk.ReferencedTable, markdownAnchor(fk.ReferencedTable),
+					strings.Join(fk.ReferencedColumns, ", "), referenceActions(fk))
+			}
+			b.WriteString("\n")
+		}
+		if len(refs[name]) > 0 {
+			b.WriteString("**Referenced by**\n\n")
+			for _, ref := range refs[name] {
+				fmt.Fprintf(&b, "- [%s](#%s) (`%s`)\n", ref.table, markdownAnchor(ref.table), strings.Join(ref.fk.Columns, ", "))
+			}
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// schemaMermaid renders tables as the body of a Mermaid erDiagram.
+func schemaMermaid(tables map[string]*schema.TableMetadata) string {
+	var b strings.Builder
+	b.WriteString("erDiagram\n")
+	for _, name := range slices.Sorted(maps.Keys(tables)) {
+		table := tables[name]
+		fmt.Fprintf(&b, "    %s {\n", diagramName(name))
+		for _, col := range table.Columns {
+			fmt.Fprintf(&b, "        %s %s", diagramName(col.SQLType), col.Name)
+			if keys := columnKeys(table, col); len(keys) > 0 {
+				fmt.Fprintf(&b, " %s", strings.Join(keys, ", "))
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("    }\n")
+	}
+	for _, e := range schemaEdges(tables) {
+		// The parent side is exactly one, or zero or one for nullable
+		// foreign keys; the child side is zero or more, or zero or one
+		// when the foreign key is unique.
+		child, parent := "}o", "||"
+		if e.unique {
+			child = "|o"
+		}
+		if e.nullable {
+			parent = "o|"
+		}
+		fmt.Fprintf(&b, "    %s %s--%s %s : %q\n", diagramName(e.child), child, parent, diagramName(e.parent), strings.Join(e.fk.Columns, ", "))
+	}
+	return b.String()
+}
+
+// schemaDOT renders tables as a Graphviz digraph, an edge per foreign key.
+func schemaDOT(tables map[string]*schema.TableMetadata) string {
+	var b strings.Builder
+	b.WriteString("// Code generated by pebble-orm. DO NOT EDIT.\n")
+	b.WriteString("digraph schema {\n")
+	b.WriteString("    rankdir=LR;\n")
+	b.WriteString("    node [shape=plaintext, fontname=\"Helvetica\"];\n\n")
+	for _, name := range slices.Sorted(maps.Keys(tables)) {
+		table := tables[name]
+		fmt.Fprintf(&b, "    %q [label=<<table border=\"0\" cellborder=\"1\" cellspacing=\"0\">\n", name)
+		fmt.Fprintf(&b, "        <tr><td colspan=\"2\" bgcolor=\"lightgrey\"><b>%s</b></td></tr>\n", htmlEscape(name))
+		for _, col := range table.Columns {
+			label := htmlEscape(col.Name)
+			if keys := columnKeys(table, col); len(keys) > 0 {
+				label += " (" + strings.Join(keys, ", ") + ")"
+			}
+			fmt.Fprintf(&b, "        <tr><td port=%q align=\"left\">%s</td><td align=\"left\">%s</td></tr>\n", col.Name, label, htmlEscape(col.SQLType))
+		}
+		b.WriteString("    </table>>];\n")
+	}
+	b.WriteString("\n")
+	for _, e := range schemaEdges(tables) {
+		fmt.Fprintf(&b, "    %q:%q -> %q:%q;\n", e.child, e.fk.Columns[0], e.parent, e.fk.ReferencedColumns[0])
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// schemaEdge is a foreign key between two of the documented tables.
+type schemaEdge struct {
+	child, parent string
+	fk            schema.ForeignKeyMetadata
+	unique        bool // the child has at most one row per parent row
+	nullable      bool // a child row need not have a parent
+}
+
+// schemaEdges returns the foreign keys between tables, in table order.
+func schemaEdges(tables map[string]*schema.TableMetadata) []schemaEdge {
+	var edges []schemaEdge
+	for _, name := range slices.Sorted(maps.Keys(tables)) {
+		table := tables[name]
+		for _, fk := range table.ForeignKeys {
+			if _, ok := tables[fk.ReferencedTable]; !ok || len(fk.Columns) == 0 || len(fk.ReferencedColumns) == 0 {
+				continue
+			}
+			e := schemaEdge{child: name, parent: fk.ReferencedTable, fk: fk}
+			for _, c := range table.Constraints {
+				if c.Type == schema.UniqueConstraint && slices.Equal(c.Columns, fk.Columns) {
+					e.unique = true
+				}
+			}
+			if table.PrimaryKey != nil && slices.Equal(table.PrimaryKey.Columns, fk.Columns) {
+				e.unique = true
+			}
+			for _, colName := range fk.Columns {
+				if col := table.GetColumnByName(colName); col != nil && col.Nullable {
+					e.nullable = true
+				}
+			}
+			edges = append(edges, e)
+		}
+	}
+	return edges
+}
+
+// tableRef is a foreign key of table referencing another table.
+type tableRef struct {
+	table string
+	fk    schema.ForeignKeyMetadata
+}
+
+// referencedBy returns the foreign keys referencing each table.
+func referencedBy(tables map[string]*schema.TableMetadata) map[string][]tableRef {
+	refs := make(map[string][]tableRef)
+	for _, e := range schemaEdges(tables) {
+		refs[e.parent] = append(refs[e.parent], tableRef{table: e.child, fk: e.fk})
+	}
+	return refs
+}
+
+// columnKeys returns the PK, FK and UK markers of col.
+func columnKeys(table *schema.TableMetadata, col schema.ColumnMetadata) []string {
+	var keys []string
+	if table.PrimaryKey != nil && slices.Contains(table.PrimaryKey.Columns, col.Name) {
+		keys = append(keys, "PK")
+	}
+	for _, fk := range table.ForeignKeys {
+		if slices.Contains(fk.Columns, col.Name) {
+			keys = append(keys, "FK")
+			break
+		}
+	}
+	if col.Unique {
+		keys = append(keys, "UK")
+	}
+	return keys
+}
+
+// columnDefault describes how col gets a value when none is inserted.
+func columnDefault(col schema.ColumnMetadata) string {
+	switch {
+	case col.Generated != nil:
+		return "generated: " + col.Generated.Expression
+	case col.Identity != nil:
+		return "identity"
+	case col.AutoIncrement && !strings.Contains(col.SQLType, "serial"):
+		return "serial"
+	case col.Default != nil:
+		return *col.Default
+	}
+	return ""
+}
+
+// describeIndex describes an index after its name.
+func describeIndex(idx schema.IndexMetadata) string {
+	on := idx.Expression
+	if on == "" {
+		on = strings.Join(idx.Columns, ", ")
+	}
+	desc := fmt.Sprintf("(`%s`)", on)
+	if idx.Unique {
+		desc = "unique " + desc
+	}
+	if idx.Type != "" && idx.Type != "btree" {
+		desc += " using " + idx.Type
+	}
+	if len(idx.Include) > 0 {
+		desc += fmt.Sprintf(" including `%s`", strings.Join(idx.Include, ", "))
+	}
+	if idx.Where != "" {
+		desc += fmt.Sprintf(" where `%s`", idx.Where)
+	}
+	return desc
+}
+
+// describeConstraint describes a constraint after its name.
+func describeConstraint(c schema.ConstraintMetadata) string {
+	switch c.Type {
+	case schema.UniqueConstraint:
+		return fmt.Sprintf("unique (`%s`)", strings.Join(c.Columns, ", "))
+	case schema.ExclusionConstraint:
+		return fmt.Sprintf("exclude `%s`", c.Expression)
+	}
+	return fmt.Sprintf("`%s`", c.Expression)
+}
+
+// referenceActions describes the non-default actions of fk.
+func referenceActions(fk schema.ForeignKeyMetadata) string {
+	var s string
+	if fk.OnDelete != "" && fk.OnDelete != schema.NoAction {
+		s += ", on delete " + strings.ToLower(string(fk.OnDelete))
+	}
+	if fk.OnUpdate != "" && fk.OnUpdate != schema.NoAction {
+		s += ", on update " + strings.ToLower(string(fk.OnUpdate))
+	}
+	if fk.InitiallyDeferred {
+		s += ", initially deferred"
+	} else if fk.Deferrable {
+		s += ", deferrable"
+	}
+	return s
+}
+
+var nonDiagramChars = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// diagramName turns a table name or SQL type into a Mermaid identifier
+// (billing.invoices → billing_invoices, varchar(255) → varchar_255).
+func diagramName(s string) string {
+	return strings.Trim(nonDiagramChars.ReplaceAllString(s, "_"), "_")
+}
+
+// markdownAnchor returns the anchor GitHub gives a heading.
+func markdownAnchor(heading string) string {
+	return strings.ToLower(strings.NewReplacer(".", "", " ", "-").Replace(heading))
+}
+
+func markdownCode(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "`" + strings.ReplaceAll(s, "|", `\|`) + "`"
+}
+
+func markdownCell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
+
+func htmlEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,84 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/marshallshelly/pebble-orm/pkg/loader"
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+)
+
+const docsModelsSource = `package models
+
+// table_name: teams
+type Team struct {
+	ID   int64  ` + "`po:\"id,primaryKey,bigserial\"`" + `
+	Name string ` + "`po:\"name,text,notNull,unique\"`" + `
+}
+
+// table_name: users
+// table_comment: People who can sign in
+type User struct {
+	ID     int64  ` + "`po:\"id,primaryKey,bigserial\"`" + `
+	Email  string ` + "`po:\"email,varchar(255),notNull,index\"`" + `
+	TeamID *int64 ` + "`po:\"team_id,bigint,fk:teams(id),onDelete:CASCADE\"`" + `
+}
+`
+
+func scanDocsModels(t *testing.T) map[string]*schema.TableMetadata {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "models.go"), []byte(docsModelsSource), 0644); err != nil {
+		t.Fatal(err)
+	}
+	models, err := loader.ScanModels(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tables := make(map[string]*schema.TableMetadata)
+	for _, m := range models {
+		tables[m.Table.QualifiedName()] = m.Table
+	}
+	return tables
+}
+
+func TestSchemaMarkdown(t *testing.T) {
+	md := schemaMarkdown(scanDocsModels(t), true)
+
+	for _, want := range []string{
+		"- [teams](#teams)",
+		"```mermaid\nerDiagram\n",
+		"        varchar_255 email\n",
+		"        bigint team_id FK\n",
+		`    users }o--o| teams : "team_id"`,
+		"## users\n\nPeople who can sign in\n",
+		"| `id` | `bigserial` | no |  | PK |  |",
+		"| `name` | `text` | no |  | UK |  |",
+		"- `team_id` → [teams](#teams) (`id`), on delete cascade",
+		"**Referenced by**\n\n- [users](#users) (`team_id`)",
+		"- `idx_users_email`: (`email`)",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown is missing %q:\n%s", want, md)
+		}
+	}
+
+	if md := schemaMarkdown(scanDocsModels(t), false); strings.Contains(md, "mermaid") {
+		t.Error("expected no diagram without mermaid")
+	}
+}
+
+func TestSchemaDOT(t *testing.T) {
+	dot := schemaDOT(scanDocsModels(t))
+	for _, want := range []string{
+		"digraph schema {",
+		`<tr><td port="team_id" align="left">team_id (FK)</td><td align="left">bigint</td></tr>`,
+		`"users":"team_id" -> "teams":"id";`,
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("dot is missing %q:\n%s", want, dot)
+		}
+	}
+}
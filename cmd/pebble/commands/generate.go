@@ -2,8 +2,10 @@ package commands
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"path/filepath"
+	"strings"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/marshallshelly/pebble-orm/cmd/pebble/output"
@@ -19,6 +21,12 @@ var (
 	migrationName string
 	empty         bool
 	modelsPath    string
+	disallowDrops bool
+	requireDrops  bool
+	confirmDrops  string
+	batchedTypes  bool
+	ifNotExists   = true
+	staged        bool
 )
 
 // globalRegistryWrapper wraps the global registry to implement loader.ModelRegistrar
@@ -45,7 +53,14 @@ Examples:
   pebble generate --name add_users_table --db "postgres://..." --models ./internal/models
   
   # Generate empty migration for manual SQL
-  pebble generate --name custom_sql --empty`,
+  pebble generate --name custom_sql --empty
+
+  # Refuse to drop tables or columns until the drops are confirmed
+  pebble generate --name rename_users --models ./internal/models --require-drop-confirmation
+  pebble generate --name rename_users --models ./internal/models --require-drop-confirmation --confirm-drops 1a2b3c4d
+
+  # Split a risky change into migrations that can be applied without downtime
+  pebble generate --name widen_amount --db "postgres://..." --models ./internal/models --expand-contract`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return runGenerate()
 	},
@@ -57,11 +72,22 @@ func init() {
 	generateCmd.Flags().StringVarP(&migrationName, "name", "n", "", "Migration name (required)")
 	generateCmd.Flags().BoolVar(&empty, "empty", false, "Generate empty migration for manual editing")
 	generateCmd.Flags().StringVar(&modelsPath, "models", "", "Path to Go file with model definitions")
+	generateCmd.Flags().BoolVar(&disallowDrops, "disallow-drops", false, "Comment out DROP TABLE and DROP COLUMN statements")
+	generateCmd.Flags().BoolVar(&requireDrops, "require-drop-confirmation", false, "Fail when the migration drops tables or columns unless --confirm-drops is given")
+	generateCmd.Flags().StringVar(&confirmDrops, "confirm-drops", "", "Token confirming the drops reported by --require-drop-confirmation")
+	generateCmd.Flags().BoolVar(&staged, "expand-contract", false, "Split the migration into expand, backfill, constrain and contract stages")
+	generateCmd.Flags().BoolVar(&batchedTypes, "batched-type-changes", false, "Write column type changes as a batched backfill template")
 	_ = generateCmd.MarkFlagRequired("name")
 }
 
 func runGenerate() error {
-	generator := migration.NewGenerator(migrationsDir)
+	generator := migration.NewGenerator(migrationsDir).WithPlannerOptions(migration.PlannerOptions{
+		IfNotExists:         ifNotExists,
+		DisallowDrops:       disallowDrops,
+		RequireConfirmToken: requireDrops || confirmDrops != "",
+		ConfirmToken:        confirmDrops,
+		BatchedTypeChanges:  batchedTypes,
+	})
 
 	// Generate empty migration
 	if empty {
@@ -79,6 +105,7 @@ func runGenerate() error {
 	}
 
 	// Require models path for schema-based generation
+	modelsPath = configModels(modelsPath)
 	if modelsPath == "" {
 		return fmt.Errorf("--models flag is required to specify model definitions")
 	}
@@ -120,6 +147,8 @@ func runGenerate() error {
 
 	// Determine database schema (empty if no --db provided)
 	var dbSchema map[string]*schema.TableMetadata
+	var installedExtensions []string
+	var sequences []schema.SequenceMetadata
 
 	if dbURL != "" {
 		// Connect to database and introspect
@@ -131,11 +160,19 @@ func runGenerate() error {
 		}
 		defer pool.Close()
 
-		introspector := migration.NewIntrospector(pool)
+		introspector := migration.NewIntrospector(pool).WithSchemas(migration.SchemasOf(codeSchema)...)
 		dbSchema, err = introspector.IntrospectSchema(ctx)
 		if err != nil {
 			return fmt.Errorf("failed to introspect database: %w", err)
 		}
+		installedExtensions, err = introspector.IntrospectExtensions(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to introspect extensions: %w", err)
+		}
+		sequences, err = introspector.IntrospectSequences(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to introspect sequences: %w", err)
+		}
 
 		output.Success("  ✓ Found %d table(s) in database", len(dbSchema))
 		fmt.Println()
@@ -157,10 +194,18 @@ func runGenerate() error {
 			fmt.Println()
 			dbSchema = make(map[string]*schema.TableMetadata)
 		}
+		installedExtensions, rerr = migration.ReconstructExtensionsFromMigrations(migrationsDir)
+		if rerr != nil {
+			output.Warning("Could not reconstruct extensions from migrations: %v", rerr)
+		}
+		sequences, rerr = migration.ReconstructSequencesFromMigrations(migrationsDir)
+		if rerr != nil {
+			output.Warning("Could not reconstruct sequences from migrations: %v", rerr)
+		}
 	}
 
 	// Compare schemas
-	differ := migration.NewDiffer()
+	differ := migration.NewDiffer().WithInstalledExtensions(installedExtensions).WithSequences(sequences)
 	diff := differ.Compare(codeSchema, dbSchema)
 
 	// Check if there are changes
@@ -171,6 +216,15 @@ func runGenerate() error {
 
 	// Show summary of changes
 	output.Section("📋 Schema Changes")
+	for _, name := range diff.ExtensionsAdded {
+		output.Success("  + %s (extension)", name)
+	}
+	for _, seq := range diff.SequencesAdded {
+		output.Success("  + %s (new sequence)", seq.Name)
+	}
+	for _, seqDiff := range diff.SequencesModified {
+		output.Info("  ~ %s (sequence altered)", seqDiff.Name)
+	}
 	if len(diff.TablesAdded) > 0 {
 		for _, table := range diff.TablesAdded {
 			output.Success("  + %s (new table)", table.Name)
@@ -195,18 +249,45 @@ func runGenerate() error {
 			output.Warning("  - %s (dropped)", table.Name)
 		}
 	}
+	for _, view := range diff.ViewsAdded {
+		output.Success("  + %s (new view)", view.QualifiedName())
+	}
+	for _, viewDiff := range diff.ViewsModified {
+		output.Info("  ~ %s (view recreated)", viewDiff.Name)
+	}
+	for _, view := range diff.ViewsDropped {
+		output.Warning("  - %s (view dropped)", view.QualifiedName())
+	}
 	fmt.Println()
 
 	// Generate migration
-	migrationFile, err := generator.Generate(migrationName, diff)
+	var migrationFiles []migration.MigrationFile
+	if staged {
+		migrationFiles, err = generator.GenerateStaged(migrationName, diff)
+	} else {
+		var migrationFile *migration.MigrationFile
+		if migrationFile, err = generator.Generate(migrationName, diff); err == nil {
+			migrationFiles = []migration.MigrationFile{*migrationFile}
+		}
+	}
 	if err != nil {
+		var dropErr *migration.DropConfirmationError
+		if errors.As(err, &dropErr) {
+			output.Warning("This migration drops: %s", strings.Join(dropErr.Drops, ", "))
+			output.Info("If these drops are intended, rerun with --confirm-drops %s", dropErr.Token)
+		}
 		return fmt.Errorf("failed to generate migration: %w", err)
 	}
 
-	output.Success("✅ Generated migration: %s", migrationFile.Version)
-	output.Muted("  ↑ Up:   %s", migrationFile.UpPath)
-	output.Muted("  ↓ Down: %s", migrationFile.DownPath)
+	for _, migrationFile := range migrationFiles {
+		output.Success("✅ Generated migration: %s (%s)", migrationFile.Version, migrationFile.Name)
+		output.Muted("  ↑ Up:   %s", migrationFile.UpPath)
+		output.Muted("  ↓ Down: %s", migrationFile.DownPath)
+	}
 	fmt.Println()
+	if len(migrationFiles) > 1 {
+		output.Info("💡 Apply the stages one at a time; each up file starts with what must be true before it runs.")
+	}
 
 	output.Info("💡 Review the generated SQL files before applying the migration.")
 
@@ -45,8 +45,8 @@ func init() {
 }
 
 func runIntrospect() error {
-	if dbURL == "" {
-		return fmt.Errorf("--db flag is required")
+	if err := requireDB(); err != nil {
+		return err
 	}
 
 	ctx := context.Background()
@@ -24,10 +24,14 @@ var (
 var metadataCmd = &cobra.Command{
 	Use:   "metadata",
 	Short: "Generate table name metadata from source files",
-	Long: `Scan Go source files for // table_name: comments and generate a metadata file.
+	Long: `Scan Go source files for models and their // table_name:, // schema:, // view: and other
+directive comments, and generate a metadata file.
 
-The generated file registers custom table names at compile-time, making them
-available in production builds where source files don't exist.
+The generated file registers the directives at compile-time, making them available in production
+builds where source files don't exist, and stops the runtime from reading source files for those
+models. Run it from go:generate to keep it current:
+
+  //go:generate pebble generate metadata --scan .
 
 Examples:
   pebble generate metadata --scan ./internal/models
@@ -67,22 +71,28 @@ func runGenerateMetadata() error {
 	output.Section("Scanning for table names")
 	output.Info("Directory: %s", absPath)
 
-	// Scan for table names
-	tableNames, err := scanForTableNames(absPath)
+	// Scan for table names, schemas and views
+	directives, err := scanForTableNames(absPath)
 	if err != nil {
 		return fmt.Errorf("failed to scan for table names: %w", err)
 	}
 
-	if len(tableNames) == 0 {
-		output.Warning("No table name directives found in %s", absPath)
+	if directives.empty() {
+		output.Warning("No models or table name directives found in %s", absPath)
 		output.Info("Add comments like: // table_name: your_table_name")
 		return nil
 	}
 
-	output.Success("Found %d table name directive(s)", len(tableNames))
-	for structName, tableName := range tableNames {
+	output.Success("Found %d model(s) and %d table name directive(s)", len(directives.comments), len(directives.tableNames))
+	for structName, tableName := range directives.tableNames {
 		fmt.Printf("  %s → %s\n", structName, tableName)
 	}
+	for structName, schemaName := range directives.tableSchemas {
+		fmt.Printf("  %s → schema %s\n", structName, schemaName)
+	}
+	for structName := range directives.views {
+		fmt.Printf("  %s → view\n", structName)
+	}
 	fmt.Println()
 
 	// Determine output file
@@ -97,7 +107,7 @@ func runGenerateMetadata() error {
 	}
 
 	// Generate the file
-	if err := generateTableNamesFile(metadataOutput, pkgName, tableNames); err != nil {
+	if err := generateTableNamesFile(metadataOutput, pkgName, directives); err != nil {
 		return fmt.Errorf("failed to generate file: %w", err)
 	}
 
@@ -110,9 +120,27 @@ func runGenerateMetadata() error {
 	return nil
 }
 
-// scanForTableNames scans a directory for Go files with table_name comments
-func scanForTableNames(dir string) (map[string]string, error) {
-	tableNames := make(map[string]string)
+// modelDirectives holds the comment directives found on model structs, keyed
+// by struct name.
+type modelDirectives struct {
+	tableNames   map[string]string
+	tableSchemas map[string]string
+	views        map[string]*schema.ViewMetadata
+	comments     map[string][]string // comment lines of every model struct
+}
+
+func (d *modelDirectives) empty() bool {
+	return len(d.tableNames) == 0 && len(d.tableSchemas) == 0 && len(d.views) == 0 && len(d.comments) == 0
+}
+
+// scanForTableNames scans a directory for Go files with table_name, schema and view comments
+func scanForTableNames(dir string) (*modelDirectives, error) {
+	directives := &modelDirectives{
+		tableNames:   make(map[string]string),
+		tableSchemas: make(map[string]string),
+		views:        make(map[string]*schema.ViewMetadata),
+		comments:     make(map[string][]string),
+	}
 
 	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -150,20 +178,42 @@ func scanForTableNames(dir string) (map[string]string, error) {
 				}
 
 				// Check if it's a struct
-				_, ok = typeSpec.Type.(*ast.StructType)
+				structType, ok := typeSpec.Type.(*ast.StructType)
 				if !ok {
 					continue
 				}
 
 				structName := typeSpec.Name.Name
 
+				// Register the comments of every model so the runtime never
+				// needs its source file.
+				if hasPoTags(structType) {
+					var comments []string
+					for _, cg := range []*ast.CommentGroup{genDecl.Doc, typeSpec.Comment} {
+						if cg == nil {
+							continue
+						}
+						for _, comment := range cg.List {
+							comments = append(comments, comment.Text)
+						}
+					}
+					directives.comments[structName] = comments
+				}
+
 				// Check doc comments
 				if genDecl.Doc != nil {
+					var comments []string
 					for _, comment := range genDecl.Doc.List {
+						comments = append(comments, comment.Text)
 						if tableName := schema.ParseTableNameFromComment(comment.Text); tableName != "" {
-							tableNames[structName] = tableName
-							break
+							directives.tableNames[structName] = tableName
 						}
+						if schemaName := schema.ParseSchemaFromComment(comment.Text); schemaName != "" {
+							directives.tableSchemas[structName] = schemaName
+						}
+					}
+					if view := schema.ParseViewFromComments(comments); view != nil {
+						directives.views[structName] = view
 					}
 				}
 			}
@@ -172,7 +222,17 @@ func scanForTableNames(dir string) (map[string]string, error) {
 		return nil
 	})
 
-	return tableNames, err
+	return directives, err
+}
+
+// hasPoTags reports whether any field of a struct has a po tag.
+func hasPoTags(structType *ast.StructType) bool {
+	for _, field := range structType.Fields.List {
+		if field.Tag != nil && strings.Contains(field.Tag.Value, schema.StructTagKey+`:"`) {
+			return true
+		}
+	}
+	return false
 }
 
 // getPackageName extracts the package name from the first Go file in a directory
@@ -205,7 +265,7 @@ func getPackageName(dir string) (string, error) {
 }
 
 // generateTableNamesFile generates the table_names.gen.go file
-func generateTableNamesFile(outputPath, packageName string, tableNames map[string]string) error {
+func generateTableNamesFile(outputPath, packageName string, directives *modelDirectives) error {
 	var sb strings.Builder
 
 	sb.WriteString("// Code generated by pebble-orm. DO NOT EDIT.\n")
@@ -217,16 +277,54 @@ func generateTableNamesFile(outputPath, packageName string, tableNames map[strin
 
 	// Sort for consistent output
 	var structNames []string
-	for k := range tableNames {
+	for k := range directives.tableNames {
 		structNames = append(structNames, k)
 	}
 	sort.Strings(structNames)
 
 	for _, structName := range structNames {
-		tableName := tableNames[structName]
+		tableName := directives.tableNames[structName]
 		sb.WriteString(fmt.Sprintf("\tschema.RegisterTableName(\"%s\", \"%s\")\n", structName, tableName))
 	}
 
+	var schemaStructNames []string
+	for k := range directives.tableSchemas {
+		schemaStructNames = append(schemaStructNames, k)
+	}
+	sort.Strings(schemaStructNames)
+
+	for _, structName := range schemaStructNames {
+		sb.WriteString(fmt.Sprintf("\tschema.RegisterTableSchema(\"%s\", \"%s\")\n", structName, directives.tableSchemas[structName]))
+	}
+
+	var viewStructNames []string
+	for k := range directives.views {
+		viewStructNames = append(viewStructNames, k)
+	}
+	sort.Strings(viewStructNames)
+
+	for _, structName := range viewStructNames {
+		view := directives.views[structName]
+		sb.WriteString(fmt.Sprintf("\tschema.RegisterView(%q, %q, %t)\n", structName, view.Query, view.Materialized))
+	}
+
+	var commentStructNames []string
+	for k := range directives.comments {
+		commentStructNames = append(commentStructNames, k)
+	}
+	sort.Strings(commentStructNames)
+
+	if len(commentStructNames) > 0 {
+		sb.WriteString("\n\t// Register model comments so directives are read without source files\n")
+	}
+	for _, structName := range commentStructNames {
+		sb.WriteString(fmt.Sprintf("\tschema.RegisterStructComments(%q", structName))
+		for _, comment := range directives.comments[structName] {
+			sb.WriteString(fmt.Sprintf(", %q", comment))
+		}
+		sb.WriteString(")\n")
+	}
+
 	sb.WriteString("}\n")
 
 	return os.WriteFile(outputPath, []byte(sb.String()), 0644)
@@ -5,7 +5,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/marshallshelly/pebble-orm/cmd/pebble/output"
@@ -16,11 +18,14 @@ import (
 
 var (
 	// Migrate flags
-	dryRun      bool
-	all         bool
-	steps       int
-	target      string
-	interactive bool
+	dryRun       bool
+	all          bool
+	steps        int
+	target       string
+	interactive  bool
+	lockTimeout  time.Duration
+	explainLocks bool
+	squashTo     string
 )
 
 // migrateCmd represents the migrate command
@@ -30,9 +35,36 @@ var migrateCmd = &cobra.Command{
 	Long: `Run database migrations to keep your database schema in sync with your code.
 
 Subcommands:
+  new     - Create a migration from the model changes
   up      - Apply pending migrations
   down    - Rollback migrations
-  status  - Show migration status`,
+  redo    - Rollback and reapply the last migrations
+  status  - Show migration status
+  squash  - Collapse old migrations into a baseline
+
+The database is given by --db, or the PEBBLE_DATABASE_URL or DATABASE_URL
+environment variable.`,
+}
+
+// migrateNewCmd creates a timestamped migration
+var migrateNewCmd = &cobra.Command{
+	Use:   "new NAME",
+	Short: "Create a migration from the model changes",
+	Long: `Create a timestamped migration with the SQL that brings the database in
+line with the models, like 'pebble generate'. Without --models, or models:
+in pebble.yaml, the migration is empty, for hand-written SQL.
+
+Examples:
+  pebble migrate new add_orders --models ./internal/models   # Diff models against the migrations so far
+  pebble migrate new add_orders --models ./internal/models --db "postgres://..."  # Diff against a database
+  pebble migrate new backfill_totals                          # Empty migration`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		migrationName = args[0]
+		modelsPath = configModels(modelsPath)
+		empty = modelsPath == ""
+		return runGenerate()
+	},
 }
 
 // migrateUpCmd applies pending migrations
@@ -44,7 +76,9 @@ var migrateUpCmd = &cobra.Command{
 Examples:
   pebble migrate up --all              # Apply all pending migrations
   pebble migrate up --steps 1          # Apply next migration
-  pebble migrate up --dry-run --all    # Preview migrations without applying`,
+  pebble migrate up --dry-run --all    # Preview migrations without applying
+  pebble migrate up --all --explain-locks     # Preview with the lock level of every statement
+  pebble migrate up --all --lock-timeout 30s  # Fail if another deploy holds the lock for 30s`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return runMigrateUp()
 	},
@@ -65,6 +99,22 @@ Examples:
 	},
 }
 
+// migrateRedoCmd rolls back and reapplies migrations
+var migrateRedoCmd = &cobra.Command{
+	Use:   "redo",
+	Short: "Rollback and reapply the last migrations",
+	Long: `Roll back the last applied migrations, then apply them again, to check that
+a migration's down SQL undoes its up SQL while developing it.
+
+Examples:
+  pebble migrate redo                  # Redo the last migration
+  pebble migrate redo --steps 2        # Redo the last two migrations
+  pebble migrate redo --dry-run        # Preview without executing`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runMigrateRedo()
+	},
+}
+
 // migrateStatusCmd shows migration status
 var migrateStatusCmd = &cobra.Command{
 	Use:   "status",
@@ -79,21 +129,55 @@ Examples:
 	},
 }
 
+// migrateSquashCmd collapses old migrations into a baseline
+var migrateSquashCmd = &cobra.Command{
+	Use:   "squash",
+	Short: "Collapse old migrations into a baseline",
+	Long: `Collapse the migrations up to a version into a single baseline migration
+and delete the originals. The baseline runs their SQL in order and takes the
+version of the last one, so databases that applied them skip it; the next
+'migrate up' updates their schema_migrations records.
+
+Only squash migrations that every database has applied.
+
+Examples:
+  pebble migrate squash                       # Squash every migration
+  pebble migrate squash --to 20240301090000   # Squash up to and including a version`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runMigrateSquash()
+	},
+}
+
 func init() {
 	rootCmd.AddCommand(migrateCmd)
-	migrateCmd.AddCommand(migrateUpCmd, migrateDownCmd, migrateStatusCmd)
+	migrateCmd.AddCommand(migrateNewCmd, migrateUpCmd, migrateDownCmd, migrateRedoCmd, migrateStatusCmd, migrateSquashCmd)
+
+	// Flags for migrate new
+	migrateNewCmd.Flags().StringVar(&modelsPath, "models", "", "Path to Go file or directory with model definitions")
 
 	// Flags for migrate up
 	migrateUpCmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "Run in interactive mode with TUI")
 	migrateUpCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Preview migrations without applying")
 	migrateUpCmd.Flags().BoolVar(&all, "all", false, "Apply all pending migrations")
 	migrateUpCmd.Flags().IntVar(&steps, "steps", 0, "Number of migrations to apply")
+	migrateUpCmd.Flags().BoolVar(&explainLocks, "explain-locks", false, "Show the table lock each statement takes (implies --dry-run)")
 
 	// Flags for migrate down
 	migrateDownCmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "Run in interactive mode with TUI")
 	migrateDownCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Preview rollback without executing")
 	migrateDownCmd.Flags().IntVar(&steps, "steps", 1, "Number of migrations to rollback")
 	migrateDownCmd.Flags().StringVar(&target, "target", "", "Rollback to specific version")
+
+	// Flags for migrate redo
+	migrateRedoCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Preview without executing")
+	migrateRedoCmd.Flags().IntVar(&steps, "steps", 1, "Number of migrations to redo")
+
+	// Flags for migrate squash
+	migrateSquashCmd.Flags().StringVar(&squashTo, "to", "", "Last version to squash (default: all migrations)")
+
+	for _, cmd := range []*cobra.Command{migrateUpCmd, migrateDownCmd, migrateRedoCmd} {
+		cmd.Flags().DurationVar(&lockTimeout, "lock-timeout", 0, "Give up if another process holds the migration lock this long (e.g. 30s); 0 waits indefinitely")
+	}
 }
 
 // selectPendingMigrations returns the migrations to apply: all pending ones
@@ -114,9 +198,48 @@ func selectPendingMigrations(migrations []migration.Migration, applied map[strin
 	return toApply
 }
 
+// printMigrationPlan prints the pending migrations with their warnings and,
+// when explain is set, every statement with the table lock it takes.
+func printMigrationPlan(plans []migration.PlannedMigration, explain bool) {
+	for _, plan := range plans {
+		fmt.Printf("  %s %s - %s\n", output.StatusIcon("pending"), plan.Version, plan.Name)
+		if !plan.Transactional {
+			output.Muted("      runs outside a transaction")
+		}
+		for _, warning := range plan.Warnings {
+			fmt.Print("      ")
+			output.Warning("%s", warning)
+		}
+		for _, stmt := range plan.Statements {
+			if explain {
+				lock := string(stmt.Lock)
+				if lock == "" {
+					lock = "no table lock"
+				}
+				output.Muted("      [%s] %s", lock, firstLine(stmt.SQL))
+			}
+			for _, warning := range stmt.Warnings {
+				fmt.Print("      ")
+				output.Warning("%s", warning)
+			}
+		}
+	}
+}
+
+// firstLine returns the first line of s, marking truncation.
+func firstLine(s string) string {
+	if line, _, found := strings.Cut(s, "\n"); found {
+		return line + " ..."
+	}
+	return s
+}
+
 func runMigrateUp() error {
-	if dbURL == "" {
-		return fmt.Errorf("--db flag is required")
+	if err := requireDB(); err != nil {
+		return err
+	}
+	if explainLocks {
+		dryRun = true
 	}
 
 	// Run interactive TUI if flag is set
@@ -134,14 +257,10 @@ func runMigrateUp() error {
 	defer pool.Close()
 
 	// Create executor
-	executor := migration.NewExecutor(pool, migrationsDir)
+	executor := migration.NewExecutor(pool, migrationsDir).WithLockTimeout(lockTimeout)
 
-	// Initialize schema_migrations table
-	if err := executor.Initialize(ctx); err != nil {
-		return fmt.Errorf("failed to initialize migrations: %w", err)
-	}
-
-	// Acquire lock
+	// Acquire lock before touching schema_migrations, so replicas starting
+	// together apply migrations one at a time
 	if !dryRun {
 		if err := executor.Lock(ctx); err != nil {
 			return fmt.Errorf("failed to acquire migration lock: %w", err)
@@ -149,6 +268,11 @@ func runMigrateUp() error {
 		defer func() { _ = executor.Unlock(ctx) }()
 	}
 
+	// Initialize schema_migrations table
+	if err := executor.Initialize(ctx); err != nil {
+		return fmt.Errorf("failed to initialize migrations: %w", err)
+	}
+
 	// Load migrations
 	generator := migration.NewGenerator(migrationsDir)
 	migrationFiles, err := generator.ListMigrations()
@@ -175,6 +299,15 @@ func runMigrateUp() error {
 		return fmt.Errorf("must specify --all or --steps")
 	}
 
+	if !dryRun {
+		if err := executor.ReconcileSquashed(ctx, migrations); err != nil {
+			return err
+		}
+	}
+	if err := executor.VerifyChecksums(ctx, migrations); err != nil {
+		return err
+	}
+
 	// Only pending migrations are candidates; Executor.Apply errors on an
 	// already-applied version, so a partially-migrated database must have the
 	// applied ones filtered out here (otherwise --all aborts on the first one).
@@ -197,11 +330,13 @@ func runMigrateUp() error {
 
 	// Preview
 	if dryRun {
+		plans, err := executor.Plan(ctx, toApply)
+		if err != nil {
+			return fmt.Errorf("failed to plan migrations: %w", err)
+		}
 		output.Section("DRY RUN - Preview")
 		output.Info("The following migrations would be applied:")
-		for _, mig := range toApply {
-			fmt.Printf("  %s %s - %s\n", output.StatusIcon("pending"), mig.Version, mig.Name)
-		}
+		printMigrationPlan(plans, explainLocks)
 		return nil
 	}
 
@@ -222,8 +357,8 @@ func runMigrateUp() error {
 }
 
 func runMigrateDown() error {
-	if dbURL == "" {
-		return fmt.Errorf("--db flag is required")
+	if err := requireDB(); err != nil {
+		return err
 	}
 
 	// Run interactive TUI if flag is set
@@ -241,7 +376,7 @@ func runMigrateDown() error {
 	defer pool.Close()
 
 	// Create executor
-	executor := migration.NewExecutor(pool, migrationsDir)
+	executor := migration.NewExecutor(pool, migrationsDir).WithLockTimeout(lockTimeout)
 
 	// Acquire lock
 	if !dryRun {
@@ -337,9 +472,112 @@ func runMigrateDown() error {
 	return nil
 }
 
+// migrationsToRedo returns the last steps applied migrations, oldest first.
+func migrationsToRedo(applied []migration.MigrationRecord, migrations []migration.Migration, steps int) ([]migration.Migration, error) {
+	if steps <= 0 {
+		return nil, fmt.Errorf("--steps must be positive, got %d", steps)
+	}
+	byVersion := make(map[string]migration.Migration, len(migrations))
+	for _, m := range migrations {
+		byVersion[m.Version] = m
+	}
+	var redo []migration.Migration
+	for _, record := range applied[max(len(applied)-steps, 0):] {
+		m, ok := byVersion[record.Version]
+		if !ok {
+			return nil, fmt.Errorf("migration file not found for version %s", record.Version)
+		}
+		redo = append(redo, m)
+	}
+	return redo, nil
+}
+
+func runMigrateRedo() error {
+	if err := requireDB(); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	// Connect to database
+	pool, err := pgxpool.New(ctx, dbURL)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer pool.Close()
+
+	executor := migration.NewExecutor(pool, migrationsDir).WithLockTimeout(lockTimeout)
+	if !dryRun {
+		if err := executor.Lock(ctx); err != nil {
+			return fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+		defer func() { _ = executor.Unlock(ctx) }()
+	}
+	if err := executor.Initialize(ctx); err != nil {
+		return fmt.Errorf("failed to initialize migrations: %w", err)
+	}
+
+	// Load migrations
+	generator := migration.NewGenerator(migrationsDir)
+	migrationFiles, err := generator.ListMigrations()
+	if err != nil {
+		return fmt.Errorf("failed to list migrations: %w", err)
+	}
+	var migrations []migration.Migration
+	for _, file := range migrationFiles {
+		mig, err := generator.ReadMigration(file)
+		if err != nil {
+			return fmt.Errorf("failed to read migration: %w", err)
+		}
+		migrations = append(migrations, *mig)
+	}
+
+	applied, err := executor.GetAppliedMigrations(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get applied migrations: %w", err)
+	}
+	redo, err := migrationsToRedo(applied, migrations, steps)
+	if err != nil {
+		return err
+	}
+	if len(redo) == 0 {
+		output.Info("No migrations to redo")
+		return nil
+	}
+
+	if dryRun {
+		output.Section("DRY RUN - Preview")
+		output.Info("The following migrations would be rolled back and reapplied:")
+		for _, mig := range redo {
+			fmt.Printf("  %s %s - %s\n", output.StatusIcon("applied"), mig.Version, mig.Name)
+		}
+		return nil
+	}
+
+	output.Section("Redoing Migrations")
+	for i := len(redo) - 1; i >= 0; i-- {
+		mig := redo[i]
+		output.Warning("Rolling back %s - %s...", mig.Version, mig.Name)
+		if err := executor.Rollback(ctx, mig, false); err != nil {
+			return fmt.Errorf("failed to rollback migration %s: %w", mig.Version, err)
+		}
+	}
+	for _, mig := range redo {
+		output.Info("Applying %s - %s...", mig.Version, mig.Name)
+		if err := executor.Apply(ctx, mig, false); err != nil {
+			return fmt.Errorf("failed to apply migration %s: %w", mig.Version, err)
+		}
+		output.Success("Redid %s", mig.Version)
+	}
+
+	fmt.Println()
+	output.Success("Successfully redid %d migration(s)", len(redo))
+	return nil
+}
+
 func runMigrateStatus() error {
-	if dbURL == "" {
-		return fmt.Errorf("--db flag is required")
+	if err := requireDB(); err != nil {
+		return err
 	}
 
 	ctx := context.Background()
@@ -422,6 +660,7 @@ func runMigrateStatus() error {
 	pending := 0
 	applied := 0
 	failed := 0
+	modified := 0
 	for _, record := range status {
 		switch record.Status {
 		case migration.StatusPending:
@@ -430,6 +669,9 @@ func runMigrateStatus() error {
 			applied++
 		case migration.StatusFailed:
 			failed++
+		case migration.StatusModified:
+			applied++
+			modified++
 		}
 	}
 
@@ -437,7 +679,30 @@ func runMigrateStatus() error {
 	if failed > 0 {
 		fmt.Printf(", %d failed", failed)
 	}
+	if modified > 0 {
+		fmt.Printf(", %d modified since applied", modified)
+	}
 	fmt.Println()
 
 	return nil
 }
+
+func runMigrateSquash() error {
+	generator := migration.NewGenerator(migrationsDir)
+	baseline, err := generator.Squash(squashTo)
+	if err != nil {
+		return fmt.Errorf("failed to squash migrations: %w", err)
+	}
+	mig, err := generator.ReadMigration(*baseline)
+	if err != nil {
+		return fmt.Errorf("failed to read migration: %w", err)
+	}
+
+	first, last, _ := mig.SquashedRange()
+	output.Success("✅ Squashed migrations %s..%s into %s", first, last, baseline.Version)
+	output.Muted("  ↑ Up:   %s", baseline.UpPath)
+	output.Muted("  ↓ Down: %s", baseline.DownPath)
+	fmt.Println()
+	output.Info("💡 Databases that applied these migrations are updated on their next 'migrate up'.")
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/marshallshelly/pebble-orm/pkg/migration"
+	"github.com/spf13/cobra"
 )
 
 func TestSelectPendingMigrations(t *testing.T) {
@@ -48,3 +49,80 @@ func versions(m []migration.Migration) []string {
 	}
 	return out
 }
+
+func TestMigrateLockTimeoutFlag(t *testing.T) {
+	for _, cmd := range []*cobra.Command{migrateUpCmd, migrateDownCmd, migrateRedoCmd} {
+		flag := cmd.Flags().Lookup("lock-timeout")
+		if flag == nil {
+			t.Fatalf("%s: missing --lock-timeout flag", cmd.Name())
+		}
+		if flag.DefValue != "0s" {
+			t.Errorf("%s: expected --lock-timeout to default to 0s, got %s", cmd.Name(), flag.DefValue)
+		}
+	}
+}
+
+func TestMigrationsToRedo(t *testing.T) {
+	migs := []migration.Migration{{Version: "001"}, {Version: "002"}, {Version: "003"}}
+	applied := []migration.MigrationRecord{{Version: "001"}, {Version: "002"}}
+
+	tests := []struct {
+		name    string
+		applied []migration.MigrationRecord
+		steps   int
+		want    []string
+		wantErr bool
+	}{
+		{"last one", applied, 1, []string{"002"}, false},
+		{"oldest first", applied, 2, []string{"001", "002"}, false},
+		{"steps beyond applied", applied, 5, []string{"001", "002"}, false},
+		{"nothing applied", nil, 1, nil, false},
+		{"zero steps", applied, 0, nil, true},
+		{"missing file", []migration.MigrationRecord{{Version: "009"}}, 1, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := migrationsToRedo(tt.applied, migs, tt.steps)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", versions(got), tt.want)
+			}
+			for i, v := range tt.want {
+				if got[i].Version != v {
+					t.Errorf("index %d: got %s, want %s", i, got[i].Version, v)
+				}
+			}
+		})
+	}
+}
+
+func TestRequireDB(t *testing.T) {
+	defer func(url string) { dbURL = url }(dbURL)
+
+	dbURL = ""
+	t.Setenv("PEBBLE_DATABASE_URL", "")
+	t.Setenv("DATABASE_URL", "postgres://fallback")
+	if err := requireDB(); err != nil || dbURL != "postgres://fallback" {
+		t.Errorf("got %q, %v; want DATABASE_URL", dbURL, err)
+	}
+
+	dbURL = ""
+	t.Setenv("PEBBLE_DATABASE_URL", "postgres://pebble")
+	if err := requireDB(); err != nil || dbURL != "postgres://pebble" {
+		t.Errorf("got %q, %v; want PEBBLE_DATABASE_URL first", dbURL, err)
+	}
+
+	dbURL = "postgres://flag"
+	if err := requireDB(); err != nil || dbURL != "postgres://flag" {
+		t.Errorf("got %q, %v; want --db to win", dbURL, err)
+	}
+
+	dbURL = ""
+	t.Setenv("PEBBLE_DATABASE_URL", "")
+	t.Setenv("DATABASE_URL", "")
+	if err := requireDB(); err == nil {
+		t.Error("expected an error without a database")
+	}
+}
@@ -0,0 +1,652 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/marshallshelly/pebble-orm/cmd/pebble/output"
+	"github.com/marshallshelly/pebble-orm/pkg/migration"
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+	"github.com/spf13/cobra"
+)
+
+var (
+	modelsOutput  string
+	modelsSchemas []string
+	modelsTables  []string
+	modelsForce   bool
+)
+
+// introspectModelsCmd generates Go models from the database schema
+var introspectModelsCmd = &cobra.Command{
+	Use:   "models",
+	Short: "Generate Go models from the database schema",
+	Long: `Introspect the database and write a tagged Go struct per table, so an
+existing database can adopt pebble-orm without hand-written models.
+
+Each struct carries its columns, primary key, foreign keys, indexes and enum
+types, plus belongsTo, hasOne and hasMany relationships inferred from the
+foreign keys between the generated tables. What tags can't express, such as
+multi-column UNIQUE and CHECK constraints, is noted in a comment on the struct.
+
+The files are a starting point to edit: existing files are left alone unless
+--force is given.
+
+Examples:
+  pebble introspect models --output ./internal/models
+  pebble introspect models --output ./internal/models --table users --table orders
+  pebble introspect models --output ./internal/models --schema public --schema billing`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runIntrospectModels()
+	},
+}
+
+func init() {
+	introspectCmd.AddCommand(introspectModelsCmd)
+
+	introspectModelsCmd.Flags().StringVarP(&modelsOutput, "output", "o", "./models", "Directory of the models package")
+	introspectModelsCmd.Flags().StringSliceVar(&modelsSchemas, "schema", nil, "Schemas to read tables from (default: public)")
+	introspectModelsCmd.Flags().StringSliceVarP(&modelsTables, "table", "t", nil, "Tables to generate models for (default: all)")
+	introspectModelsCmd.Flags().BoolVar(&modelsForce, "force", false, "Overwrite existing model files")
+}
+
+func runIntrospectModels() error {
+	if err := requireDB(); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	// Connect to database
+	pool, err := pgxpool.New(ctx, dbURL)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer pool.Close()
+
+	tables, err := migration.NewIntrospector(pool).WithSchemas(modelsSchemas...).IntrospectSchema(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to introspect database: %w", err)
+	}
+	if len(modelsTables) > 0 {
+		for name := range tables {
+			if !slices.Contains(modelsTables, name) {
+				delete(tables, name)
+			}
+		}
+	}
+	if len(tables) == 0 {
+		output.Warning("No tables found")
+		return nil
+	}
+
+	output.Section("Generating models")
+	files := generateModelSources(tables, packageNameFromDir(modelsOutput))
+	if err := os.MkdirAll(modelsOutput, 0755); err != nil {
+		return err
+	}
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		path := filepath.Join(modelsOutput, name)
+		if _, err := os.Stat(path); err == nil && !modelsForce {
+			output.Warning("Skipped %s: file exists (use --force to overwrite)", path)
+			continue
+		} else if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		if err := writeGoFile(path, files[name]); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		output.Success("Generated: %s", path)
+	}
+	fmt.Println()
+	output.Info("Review the models, then run 'pebble vet --models %s'.", modelsOutput)
+	return nil
+}
+
+// modelType is how a column of a PostgreSQL type is declared in a model: the
+// type named in its po tag and the Go type of its field.
+type modelType struct {
+	tag    string
+	goType string
+}
+
+// modelTypes maps introspected PostgreSQL types, without parameters, to the
+// way their columns are declared.
+var modelTypes = map[string]modelType{
+	"smallint":                    {"smallint", "int16"},
+	"int2":                        {"smallint", "int16"},
+	"integer":                     {"integer", "int32"},
+	"int4":                        {"integer", "int32"},
+	"bigint":                      {"bigint", "int64"},
+	"int8":                        {"bigint", "int64"},
+	"real":                        {"real", "float32"},
+	"float4":                      {"real", "float32"},
+	"double precision":            {"double precision", "float64"},
+	"float8":                      {"double precision", "float64"},
+	"numeric":                     {"numeric", "float64"},
+	"decimal":                     {"numeric", "float64"},
+	"boolean":                     {"boolean", "bool"},
+	"bool":                        {"boolean", "bool"},
+	"text":                        {"text", "string"},
+	"varchar":                     {"varchar", "string"},
+	"char":                        {"char", "string"},
+	"bpchar":                      {"char", "string"},
+	"uuid":                        {"uuid", "string"},
+	"date":                        {"date", "time.Time"},
+	"timestamp":                   {"timestamp", "time.Time"},
+	"timestamp without time zone": {"timestamp", "time.Time"},
+	"timestamptz":                 {"timestamptz", "time.Time"},
+	"timestamp with time zone":    {"timestamptz", "time.Time"},
+	"time":                        {"time", "string"},
+	"time without time zone":      {"time", "string"},
+	"interval":                    {"interval", "time.Duration"},
+	"json":                        {"json", "map[string]any"},
+	"jsonb":                       {"jsonb", "map[string]any"},
+	"bytea":                       {"bytea", "[]byte"},
+	"inet":                        {"inet", "netip.Addr"},
+	"cidr":                        {"cidr", "netip.Prefix"},
+	"macaddr":                     {"macaddr", "net.HardwareAddr"},
+	"tsvector":                    {"tsvector", "string"},
+	"tsquery":                     {"tsquery", "string"},
+	"point":                       {"point", "string"},
+	"line":                        {"line", "string"},
+	"lseg":                        {"lseg", "string"},
+	"box":                         {"box", "string"},
+	"path":                        {"path", "string"},
+	"polygon":                     {"polygon", "string"},
+	"circle":                      {"circle", "string"},
+}
+
+// modelSource collects the declarations of one generated file.
+type modelSource struct {
+	imports map[string]bool
+	body    strings.Builder
+}
+
+func (s *modelSource) render(pkg string) string {
+	var b strings.Builder
+	b.WriteString("// Generated by pebble introspect models. Edit freely.\n\n")
+	fmt.Fprintf(&b, "package %s\n\n", pkg)
+	if len(s.imports) > 0 {
+		b.WriteString("import (\n")
+		for _, path := range slices.Sorted(maps.Keys(s.imports)) {
+			fmt.Fprintf(&b, "\t%q\n", path)
+		}
+		b.WriteString(")\n\n")
+	}
+	b.WriteString(s.body.String())
+	return b.String()
+}
+
+// generateModelSources returns the Go source of a model per table, keyed by
+// file name, and of the enum types they use in enums.go.
+func generateModelSources(tables map[string]*schema.TableMetadata, pkg string) map[string]string {
+	names := slices.Sorted(maps.Keys(tables))
+	structNames := modelStructNames(tables, names)
+
+	enums := make(map[string][]string)
+	for _, name := range names {
+		for _, enum := range tables[name].EnumTypes {
+			enums[enum.Name] = enum.Values
+		}
+	}
+
+	files := make(map[string]string)
+	for _, name := range names {
+		src := &modelSource{imports: make(map[string]bool)}
+		writeModel(src, tables[name], tables, structNames, enums)
+		files[strings.ReplaceAll(name, ".", "_")+".go"] = src.render(pkg)
+	}
+	if len(enums) > 0 {
+		src := &modelSource{}
+		for _, name := range slices.Sorted(maps.Keys(enums)) {
+			writeEnum(src, name, enums[name])
+		}
+		files["enums.go"] = src.render(pkg)
+	}
+	return files
+}
+
+// modelStructNames names the struct of each table after its singular name,
+// prefixed with the schema where tables of two schemas would collide.
+func modelStructNames(tables map[string]*schema.TableMetadata, names []string) map[string]string {
+	structNames := make(map[string]string, len(names))
+	count := make(map[string]int)
+	for _, name := range names {
+		n := goName(singular(tables[name].Name))
+		structNames[name] = n
+		count[n]++
+	}
+	for _, name := range names {
+		if t := tables[name]; count[structNames[name]] > 1 && t.Schema != "" {
+			structNames[name] = goName(t.Schema) + structNames[name]
+		}
+	}
+	return structNames
+}
+
+// writeModel writes the struct of table.
+func writeModel(src *modelSource, table *schema.TableMetadata, tables map[string]*schema.TableMetadata, structNames map[string]string, enums map[string][]string) {
+	key := table.QualifiedName()
+	structName := structNames[key]
+	b := &src.body
+
+	fmt.Fprintf(b, "// %s is a row of the %s %s.\n", structName, key, map[bool]string{true: "view", false: "table"}[table.View != nil])
+	b.WriteString("//\n")
+	fmt.Fprintf(b, "// table_name: %s\n", table.Name)
+	if table.Schema != "" {
+		fmt.Fprintf(b, "// schema: %s\n", table.Schema)
+	}
+	if table.Comment != "" {
+		fmt.Fprintf(b, "// table_comment: %s\n", strings.ReplaceAll(table.Comment, "\n", " "))
+	}
+	if table.View != nil {
+		directive := "view"
+		if table.View.Materialized {
+			directive = "materialized_view"
+		}
+		lines := strings.Split(strings.TrimSpace(table.View.Query), "\n")
+		fmt.Fprintf(b, "// %s: %s\n", directive, strings.TrimSpace(lines[0]))
+		for _, line := range lines[1:] {
+			fmt.Fprintf(b, "//   %s\n", strings.TrimSpace(line))
+		}
+	}
+	for _, idx := range table.Indexes {
+		if directive, ok := indexDirective(idx); ok {
+			fmt.Fprintf(b, "// index: %s\n", directive)
+		}
+	}
+	for _, c := range table.Constraints {
+		if c.Type == schema.ExclusionConstraint {
+			fmt.Fprintf(b, "// exclude: %s %s\n", c.Name, c.Expression)
+		}
+	}
+	if notes := tableNotes(table); len(notes) > 0 {
+		b.WriteString("//\n// Not expressed in tags, and kept by the existing schema:\n")
+		for _, note := range notes {
+			fmt.Fprintf(b, "//   - %s\n", note)
+		}
+	}
+
+	fmt.Fprintf(b, "type %s struct {\n", structName)
+	used := make(map[string]bool)
+	for _, col := range table.Columns {
+		field := goName(col.Name)
+		for used[field] {
+			field += "_"
+		}
+		used[field] = true
+		writeColumnField(src, table, col, field, enums)
+	}
+	writeRelationships(src, table, tables, structNames, used)
+	b.WriteString("}\n\n")
+}
+
+// writeColumnField writes the field of col.
+func writeColumnField(src *modelSource, table *schema.TableMetadata, col schema.ColumnMetadata, field string, enums map[string][]string) {
+	tag := []string{col.Name}
+	var notes []string
+	goType := "string"
+
+	base, params, array := splitSQLType(col.SQLType)
+	if values, ok := enums[col.EnumType]; ok && col.EnumType != "" {
+		goType = goName(col.EnumType)
+		if enum := "enum(" + strings.Join(values, ",") + ")"; tagSafe(enum) && schemaEnumName(goType) == col.EnumType {
+			tag = append(tag, enum)
+		} else {
+			notes = append(notes, "enum type "+col.EnumType)
+		}
+	} else if mt, ok := modelTypes[base]; ok {
+		goType = mt.goType
+		sqlType := mt.tag
+		switch {
+		case col.AutoIncrement && col.Identity == nil && sqlType == "integer":
+			sqlType = "serial"
+		case col.AutoIncrement && col.Identity == nil && sqlType == "bigint":
+			sqlType = "bigserial"
+		case params != "" && !array:
+			sqlType += "(" + params + ")"
+		}
+		if array {
+			sqlType += "[]"
+			goType = "[]" + goType
+		}
+		tag = append(tag, sqlType)
+		if col.AutoIncrement && col.Identity == nil && sqlType == "smallint" {
+			notes = append(notes, "smallserial")
+		}
+	} else {
+		notes = append(notes, "database type "+col.SQLType)
+	}
+
+	pk := table.PrimaryKey != nil && slices.Contains(table.PrimaryKey.Columns, col.Name)
+	if pk {
+		tag = append(tag, "primaryKey")
+	} else if !col.Nullable && col.Identity == nil {
+		tag = append(tag, "notNull")
+	}
+	if col.Identity != nil {
+		if col.Identity.Generation == schema.IdentityByDefault {
+			tag = append(tag, "identityByDefault")
+		} else {
+			tag = append(tag, "identityAlways")
+		}
+	}
+	if isUniqueColumn(table, col.Name) {
+		tag = append(tag, "unique")
+	}
+	if col.Default != nil && !col.AutoIncrement && col.Generated == nil {
+		if opt := "default(" + *col.Default + ")"; tagSafe(opt) {
+			tag = append(tag, opt)
+		} else {
+			notes = append(notes, "DEFAULT "+*col.Default)
+		}
+	}
+	if col.Generated != nil {
+		if opt := "generated(" + col.Generated.Expression + ")"; tagSafe(opt) {
+			tag = append(tag, opt)
+			if col.Generated.Type == schema.GeneratedVirtual {
+				tag = append(tag, "virtual")
+			}
+		} else {
+			notes = append(notes, "GENERATED ALWAYS AS ("+col.Generated.Expression+")")
+		}
+	}
+	if fk, ok := columnForeignKey(table, col.Name); ok {
+		tag = append(tag, fmt.Sprintf("fk:%s(%s)", fk.ReferencedTable, fk.ReferencedColumns[0]))
+		if fk.OnDelete != "" && fk.OnDelete != schema.NoAction {
+			tag = append(tag, "onDelete:"+strings.ReplaceAll(string(fk.OnDelete), " ", ""))
+		}
+		if fk.OnUpdate != "" && fk.OnUpdate != schema.NoAction {
+			tag = append(tag, "onUpdate:"+strings.ReplaceAll(string(fk.OnUpdate), " ", ""))
+		}
+		if fk.InitiallyDeferred {
+			tag = append(tag, "initiallyDeferred")
+		} else if fk.Deferrable {
+			tag = append(tag, "deferrable")
+		}
+	}
+	if col.Collation != "" {
+		if opt := "collate(" + col.Collation + ")"; tagSafe(opt) {
+			tag = append(tag, opt)
+		}
+	}
+	if col.Comment != "" {
+		if opt := "comment(" + col.Comment + ")"; tagSafe(opt) {
+			tag = append(tag, opt)
+		} else {
+			notes = append(notes, "comment: "+strings.ReplaceAll(col.Comment, "\n", " "))
+		}
+	}
+
+	if col.Nullable && !strings.HasPrefix(goType, "[]") && !strings.HasPrefix(goType, "map[") {
+		goType = "*" + goType
+	}
+	if pkg, _, ok := strings.Cut(strings.TrimLeft(goType, "*[]"), "."); ok {
+		src.imports[map[string]string{"time": "time", "netip": "net/netip", "net": "net"}[pkg]] = true
+	}
+
+	for _, note := range notes {
+		fmt.Fprintf(&src.body, "\t// %s\n", note)
+	}
+	fmt.Fprintf(&src.body, "\t%s %s `po:%q`\n", field, goType, strings.Join(tag, ","))
+}
+
+// writeRelationships writes a field per relationship inferred from the
+// foreign keys between the generated tables: belongsTo for the table's own
+// foreign keys, and hasMany, or hasOne when the foreign key column is unique,
+// for those referencing it.
+func writeRelationships(src *modelSource, table *schema.TableMetadata, tables map[string]*schema.TableMetadata, structNames map[string]string, used map[string]bool) {
+	key := table.QualifiedName()
+	field := func(name string) string {
+		for used[name] {
+			name += "Rel"
+		}
+		used[name] = true
+		return name
+	}
+
+	for _, fk := range table.ForeignKeys {
+		target, ok := structNames[fk.ReferencedTable]
+		if !ok || len(fk.Columns) != 1 {
+			continue
+		}
+		name := target
+		if base, ok := strings.CutSuffix(fk.Columns[0], "_id"); ok && base != "" {
+			name = goName(base)
+		}
+		fmt.Fprintf(&src.body, "\t%s *%s `po:\"-,belongsTo,foreignKey(%s),references(%s)\"`\n",
+			field(name), target, fk.Columns[0], fk.ReferencedColumns[0])
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(tables)) {
+		child := tables[name]
+		var refs []schema.ForeignKeyMetadata
+		for _, fk := range child.ForeignKeys {
+			if fk.ReferencedTable == key && len(fk.Columns) == 1 {
+				refs = append(refs, fk)
+			}
+		}
+		for _, fk := range refs {
+			kind, typ, fieldName := "hasMany", "[]"+structNames[name], goName(child.Name)
+			if isUniqueColumn(child, fk.Columns[0]) {
+				kind, typ, fieldName = "hasOne", "*"+structNames[name], structNames[name]
+			}
+			if len(refs) > 1 {
+				fieldName += "By" + goName(strings.TrimSuffix(fk.Columns[0], "_id"))
+			}
+			fmt.Fprintf(&src.body, "\t%s %s `po:\"-,%s,foreignKey(%s),references(%s)\"`\n",
+				field(fieldName), typ, kind, fk.Columns[0], fk.ReferencedColumns[0])
+		}
+	}
+}
+
+// writeEnum writes a string type for an enum and a constant per value.
+func writeEnum(src *modelSource, name string, values []string) {
+	typ := goName(name)
+	fmt.Fprintf(&src.body, "// %s is the %s enum type.\n", typ, name)
+	fmt.Fprintf(&src.body, "type %s string\n\n", typ)
+	src.body.WriteString("const (\n")
+	for _, v := range values {
+		fmt.Fprintf(&src.body, "\t%s%s %s = %q\n", typ, goName(v), typ, v)
+	}
+	src.body.WriteString(")\n\n")
+}
+
+// indexDirective renders idx as the body of an index directive. Unique
+// indexes have no directive form.
+func indexDirective(idx schema.IndexMetadata) (string, bool) {
+	if idx.Unique {
+		return "", false
+	}
+	var on string
+	if idx.Expression != "" {
+		on = idx.Expression
+	} else {
+		columns := make([]string, len(idx.Columns))
+		for i, col := range idx.Columns {
+			columns[i] = col
+			for _, order := range idx.ColumnOrdering {
+				if order.Column != col {
+					continue
+				}
+				if order.OpClass != "" {
+					columns[i] += " " + order.OpClass
+				}
+				if order.Collation != "" {
+					columns[i] += fmt.Sprintf(" COLLATE %q", order.Collation)
+				}
+				if order.Direction == schema.Descending {
+					columns[i] += " DESC"
+				}
+				if order.Nulls != "" {
+					columns[i] += " " + string(order.Nulls)
+				}
+			}
+		}
+		on = strings.Join(columns, ", ")
+	}
+	directive := fmt.Sprintf("%s ON (%s)", idx.Name, on)
+	if idx.Type != "" && idx.Type != "btree" {
+		directive += " USING " + idx.Type
+	}
+	if len(idx.Include) > 0 {
+		directive += " INCLUDE (" + strings.Join(idx.Include, ", ") + ")"
+	}
+	if idx.Where != "" {
+		directive += " WHERE " + idx.Where
+	}
+	return directive, true
+}
+
+// tableNotes describes what of table the generated tags and directives leave
+// out.
+func tableNotes(table *schema.TableMetadata) []string {
+	var notes []string
+	for _, idx := range table.Indexes {
+		if idx.Unique {
+			on := idx.Expression
+			if on == "" {
+				on = strings.Join(idx.Columns, ", ")
+			}
+			notes = append(notes, fmt.Sprintf("UNIQUE INDEX %s ON (%s)", idx.Name, on))
+		}
+	}
+	for _, c := range table.Constraints {
+		switch {
+		case c.Type == schema.UniqueConstraint && len(c.Columns) > 1:
+			notes = append(notes, fmt.Sprintf("CONSTRAINT %s UNIQUE (%s)", c.Name, strings.Join(c.Columns, ", ")))
+		case c.Type == schema.CheckConstraint:
+			notes = append(notes, fmt.Sprintf("CONSTRAINT %s %s", c.Name, c.Expression))
+		}
+	}
+	for _, fk := range table.ForeignKeys {
+		if len(fk.Columns) > 1 {
+			notes = append(notes, fmt.Sprintf("CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s (%s)",
+				fk.Name, strings.Join(fk.Columns, ", "), fk.ReferencedTable, strings.Join(fk.ReferencedColumns, ", ")))
+		}
+	}
+	return notes
+}
+
+// isUniqueColumn reports whether table has a UNIQUE constraint on column
+// alone.
+func isUniqueColumn(table *schema.TableMetadata, column string) bool {
+	for _, c := range table.Constraints {
+		if c.Type == schema.UniqueConstraint && len(c.Columns) == 1 && c.Columns[0] == column {
+			return true
+		}
+	}
+	return false
+}
+
+// columnForeignKey returns the single-column foreign key on column.
+func columnForeignKey(table *schema.TableMetadata, column string) (schema.ForeignKeyMetadata, bool) {
+	for _, fk := range table.ForeignKeys {
+		if len(fk.Columns) == 1 && fk.Columns[0] == column && len(fk.ReferencedColumns) == 1 {
+			return fk, true
+		}
+	}
+	return schema.ForeignKeyMetadata{}, false
+}
+
+// splitSQLType splits an introspected type such as varchar(255) or int4[]
+// into its base type, parameters and whether it is an array.
+func splitSQLType(sqlType string) (base, params string, array bool) {
+	base = strings.ToLower(strings.TrimSpace(sqlType))
+	base, array = strings.CutSuffix(base, "[]")
+	if open := strings.Index(base, "("); open > 0 && strings.HasSuffix(base, ")") {
+		base, params = base[:open], base[open+1:len(base)-1]
+	}
+	return base, params, array
+}
+
+// tagSafe reports whether a tag option can be written as is: its
+// parentheses balance, and it has no double quote or backtick.
+func tagSafe(opt string) bool {
+	if strings.ContainsAny(opt, "\"`\n") {
+		return false
+	}
+	depth := 0
+	for _, r := range opt {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return false
+			}
+		}
+	}
+	return depth == 0
+}
+
+// commonInitialisms are written in upper case in Go names.
+var commonInitialisms = map[string]bool{
+	"api": true, "cpu": true, "css": true, "dns": true, "html": true, "http": true,
+	"https": true, "id": true, "ip": true, "json": true, "sql": true, "ssh": true,
+	"tls": true, "ttl": true, "uri": true, "url": true, "utc": true, "uuid": true,
+	"xml": true,
+}
+
+// goName converts a snake_case database name to an exported Go name
+// (user_id → UserID).
+func goName(name string) string {
+	var b strings.Builder
+	for word := range strings.FieldsFuncSeq(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		word = strings.ToLower(word)
+		if commonInitialisms[word] {
+			b.WriteString(strings.ToUpper(word))
+			continue
+		}
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+	s := b.String()
+	if s == "" || unicode.IsDigit(rune(s[0])) {
+		s = "X" + s
+	}
+	return s
+}
+
+// schemaEnumName is the enum type name the schema parser derives from a Go
+// type name, so enum columns can be checked to round-trip.
+func schemaEnumName(typeName string) string {
+	var b strings.Builder
+	for i, r := range typeName {
+		if i > 0 && r >= 'A' && r <= 'Z' {
+			b.WriteRune('_')
+		}
+		b.WriteRune(r)
+	}
+	return strings.ToLower(b.String())
+}
+
+// singular returns the singular of a plural English table name, as far as
+// regular plurals go (users → user, categories → category).
+func singular(name string) string {
+	switch {
+	case strings.HasSuffix(name, "ies") && len(name) > 3:
+		return name[:len(name)-3] + "y"
+	case strings.HasSuffix(name, "sses"), strings.HasSuffix(name, "xes"), strings.HasSuffix(name, "ches"), strings.HasSuffix(name, "shes"):
+		return name[:len(name)-2]
+	case strings.HasSuffix(name, "ss"), strings.HasSuffix(name, "us"), strings.HasSuffix(name, "is"):
+		return name
+	case strings.HasSuffix(name, "s") && len(name) > 1:
+		return name[:len(name)-1]
+	}
+	return name
+}
@@ -0,0 +1,153 @@
+package commands
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/marshallshelly/pebble-orm/pkg/loader"
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+)
+
+func introspectedTables() map[string]*schema.TableMetadata {
+	nextval := "nextval('users_id_seq'::regclass)"
+	now := "now()"
+	pending := "'pending'::order_status"
+	return map[string]*schema.TableMetadata{
+		"users": {
+			Name: "users",
+			Columns: []schema.ColumnMetadata{
+				{Name: "id", SQLType: "bigint", Default: &nextval, AutoIncrement: true},
+				{Name: "email", SQLType: "varchar(255)"},
+				{Name: "avatar_url", SQLType: "text", Nullable: true, Comment: "Profile picture"},
+				{Name: "created_at", SQLType: "timestamp with time zone", Default: &now},
+			},
+			PrimaryKey:  &schema.PrimaryKeyMetadata{Columns: []string{"id"}},
+			Constraints: []schema.ConstraintMetadata{{Name: "users_email_key", Type: schema.UniqueConstraint, Columns: []string{"email"}}},
+			Indexes: []schema.IndexMetadata{{
+				Name: "idx_users_created", Columns: []string{"created_at"}, Type: "btree",
+				ColumnOrdering: []schema.ColumnOrder{{Column: "created_at", Direction: schema.Descending}},
+			}},
+		},
+		"orders": {
+			Name: "orders",
+			Columns: []schema.ColumnMetadata{
+				{Name: "id", SQLType: "uuid", Default: new("gen_random_uuid()")},
+				{Name: "user_id", SQLType: "bigint"},
+				{Name: "status", SQLType: "order_status", EnumType: "order_status", Default: &pending},
+				{Name: "total", SQLType: "numeric(10,2)"},
+				{Name: "tags", SQLType: "text[]", Nullable: true},
+			},
+			PrimaryKey: &schema.PrimaryKeyMetadata{Columns: []string{"id"}},
+			ForeignKeys: []schema.ForeignKeyMetadata{{
+				Name: "orders_user_id_fkey", Columns: []string{"user_id"},
+				ReferencedTable: "users", ReferencedColumns: []string{"id"}, OnDelete: schema.Cascade,
+			}},
+			Constraints: []schema.ConstraintMetadata{{Name: "orders_total_check", Type: schema.CheckConstraint, Expression: "CHECK (total >= 0)"}},
+			EnumTypes:   []schema.EnumType{{Name: "order_status", Values: []string{"pending", "shipped"}}},
+		},
+	}
+}
+
+func TestGenerateModelSources(t *testing.T) {
+	files := generateModelSources(introspectedTables(), "models")
+
+	for name, want := range map[string][]string{
+		"users.go": {
+			"// Generated by pebble introspect models. Edit freely.",
+			"// table_name: users",
+			"// index: idx_users_created ON (created_at DESC)",
+			"type User struct {",
+			"ID int64 `po:\"id,bigserial,primaryKey\"`",
+			"Email string `po:\"email,varchar(255),notNull,unique\"`",
+			"AvatarURL *string `po:\"avatar_url,text,comment(Profile picture)\"`",
+			"CreatedAt time.Time `po:\"created_at,timestamptz,notNull,default(now())\"`",
+			"Orders []Order `po:\"-,hasMany,foreignKey(user_id),references(id)\"`",
+		},
+		"orders.go": {
+			"//   - CONSTRAINT orders_total_check CHECK (total >= 0)",
+			"type Order struct {",
+			"Status OrderStatus `po:\"status,enum(pending,shipped),notNull,default('pending'::order_status)\"`",
+			"Total float64 `po:\"total,numeric(10,2),notNull\"`",
+			"Tags []string `po:\"tags,text[]\"`",
+			"UserID int64 `po:\"user_id,bigint,notNull,fk:users(id),onDelete:CASCADE\"`",
+			"User *User `po:\"-,belongsTo,foreignKey(user_id),references(id)\"`",
+		},
+		"enums.go": {
+			"type OrderStatus string",
+			`OrderStatusPending OrderStatus = "pending"`,
+		},
+	} {
+		src, ok := files[name]
+		if !ok {
+			t.Fatalf("no %s generated; got %d files", name, len(files))
+		}
+		for _, line := range want {
+			if !strings.Contains(src, line) {
+				t.Errorf("%s is missing %q:\n%s", name, line, src)
+			}
+		}
+	}
+}
+
+func TestGeneratedModelsRoundTrip(t *testing.T) {
+	tables := introspectedTables()
+	dir := t.TempDir()
+	for name, src := range generateModelSources(tables, "models") {
+		if err := writeGoFile(filepath.Join(dir, name), src); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+	}
+
+	models, err := loader.ScanModels(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(models) != len(tables) {
+		t.Fatalf("scanned %d models, want %d", len(models), len(tables))
+	}
+	for _, m := range models {
+		want := tables[m.Table.Name]
+		for _, col := range want.Columns {
+			got := m.Table.GetColumnByName(col.Name)
+			if got == nil {
+				t.Errorf("%s.%s: column missing", m.Table.Name, col.Name)
+				continue
+			}
+			if got.Nullable != col.Nullable {
+				t.Errorf("%s.%s: nullable %v, want %v", m.Table.Name, col.Name, got.Nullable, col.Nullable)
+			}
+		}
+	}
+}
+
+func TestGoName(t *testing.T) {
+	tests := map[string]string{
+		"user_id":    "UserID",
+		"avatar_url": "AvatarURL",
+		"api_key":    "APIKey",
+		"order_item": "OrderItem",
+		"2fa_code":   "X2faCode",
+	}
+	for in, want := range tests {
+		if got := goName(in); got != want {
+			t.Errorf("goName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSingular(t *testing.T) {
+	tests := map[string]string{
+		"users":      "user",
+		"categories": "category",
+		"addresses":  "address",
+		"boxes":      "box",
+		"status":     "status",
+		"people":     "people",
+	}
+	for in, want := range tests {
+		if got := singular(in); got != want {
+			t.Errorf("singular(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
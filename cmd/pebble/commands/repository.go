@@ -0,0 +1,538 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/marshallshelly/pebble-orm/cmd/pebble/output"
+	"github.com/marshallshelly/pebble-orm/pkg/loader"
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+	"github.com/spf13/cobra"
+)
+
+var (
+	repositoryScanDir string
+	repositoryOutput  string
+)
+
+// repositoryCmd generates a typed repository per model
+var repositoryCmd = &cobra.Command{
+	Use:   "repository",
+	Short: "Generate typed repository interfaces and implementations for models",
+	Long: `Scan Go source files for models and generate, for each model with a
+single-column primary key, a repository package file holding:
+
+  - a <Model>Repository interface with Get, List, Create, Update, Delete and
+    WithTx, to mock at service boundaries
+  - a <Model>Filter struct for List, with a pointer field per scalar column
+  - an implementation on the query builders, returned by New<Model>Repository
+
+Views and models without a single-column primary key are skipped.
+
+Examples:
+  pebble generate repository --scan ./internal/models
+  pebble generate repository --scan ./internal/models --output ./internal/store`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runGenerateRepository()
+	},
+}
+
+func init() {
+	generateCmd.AddCommand(repositoryCmd)
+
+	repositoryCmd.Flags().StringVar(&repositoryScanDir, "scan", "", "Directory to scan for model definitions (required)")
+	repositoryCmd.Flags().StringVarP(&repositoryOutput, "output", "o", "", "Directory of the repository package (default: repository next to <scan-dir>)")
+	_ = repositoryCmd.MarkFlagRequired("scan")
+}
+
+func runGenerateRepository() error {
+	models, err := loader.ScanModels(repositoryScanDir)
+	if err != nil {
+		return err
+	}
+	if len(models) == 0 {
+		output.Warning("No models found in %s", repositoryScanDir)
+		return nil
+	}
+	if repositoryOutput == "" {
+		repositoryOutput = filepath.Join(filepath.Dir(filepath.Clean(repositoryScanDir)), "repository")
+	}
+
+	output.Section("Generating repositories")
+	files, skipped, err := generateRepositoryFiles(models, repositoryOutput)
+	if err != nil {
+		return err
+	}
+	for _, path := range files {
+		output.Success("Generated: %s", path)
+	}
+	for _, reason := range skipped {
+		output.Warning("Skipped %s", reason)
+	}
+	fmt.Println()
+	output.Info("Commit these files and re-run this command when models change.")
+	return nil
+}
+
+// repositoryModel is the data the repository template renders for a model.
+type repositoryModel struct {
+	Package      string // package of the repository
+	ModelPackage string // name the model package is imported as
+	StdImports   []string
+	Imports      []string
+	Model        string // qualified model type, e.g. models.User
+	Name         string // struct name, e.g. User
+	Impl         string // implementation type, e.g. userRepository
+	Var          string // parameter name of a model value
+	PKColumn     string
+	PKField      string
+	PKType       string
+	Filters      []repositoryField
+	Sets         []repositoryField
+}
+
+type repositoryField struct {
+	Column string
+	Field  string
+	Type   string // filter field type, without the pointer
+}
+
+// generateRepositoryFiles writes a <model>_repository.gen.go file per model
+// into outputDir. It returns the paths written and why models were skipped.
+func generateRepositoryFiles(models []loader.Model, outputDir string) (written, skipped []string, err error) {
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return nil, nil, err
+	}
+	pkg := packageNameFromDir(outputDir)
+	importPaths := make(map[string]string) // model dir -> import path
+	for _, model := range models {
+		dir := filepath.Dir(model.File)
+		if _, ok := importPaths[dir]; !ok {
+			path, err := goImportPath(dir)
+			if err != nil {
+				return nil, nil, err
+			}
+			importPaths[dir] = path
+		}
+
+		data, reason, err := newRepositoryModel(model, pkg, importPaths[dir])
+		if err != nil {
+			return nil, nil, err
+		}
+		if reason != "" {
+			skipped = append(skipped, fmt.Sprintf("%s: %s", model.StructName, reason))
+			continue
+		}
+
+		var src strings.Builder
+		writeGeneratedHeader(&src)
+		if err := repositoryTemplate.Execute(&src, data); err != nil {
+			return nil, nil, err
+		}
+		path := filepath.Join(outputDir, strings.ToLower(model.StructName)+"_repository.gen.go")
+		if err := writeGoFile(path, src.String()); err != nil {
+			return nil, nil, fmt.Errorf("failed to generate %s: %w", path, err)
+		}
+		written = append(written, path)
+	}
+	return written, skipped, nil
+}
+
+// newRepositoryModel collects what the template needs for model, or the
+// reason it gets no repository.
+func newRepositoryModel(model loader.Model, pkg, importPath string) (*repositoryModel, string, error) {
+	table := model.Table
+	if table.View != nil {
+		return nil, "views are read-only", nil
+	}
+	pk := table.PrimaryKeyColumns()
+	if len(pk) != 1 {
+		return nil, "needs a single-column primary key", nil
+	}
+	fields, fileImports, err := structFieldTypes(model.File, model.StructName)
+	if err != nil {
+		return nil, "", err
+	}
+
+	data := &repositoryModel{
+		Package:      pkg,
+		ModelPackage: model.Package,
+		Model:        model.Package + "." + model.StructName,
+		Name:         model.StructName,
+		Impl:         lowerCamel(model.StructName) + "Repository",
+		Var:          lowerCamel(model.StructName),
+		PKColumn:     pk[0],
+	}
+	if token.IsKeyword(data.Var) || slices.Contains([]string{"ctx", "r", "err", "rows", "n", "sets", "filter", "id", "tx", "db", model.Package}, data.Var) {
+		data.Var = "row"
+	}
+	imports := map[string]bool{
+		`"context"`: true,
+		`"github.com/marshallshelly/pebble-orm/pkg/builder"`: true,
+		`"github.com/marshallshelly/pebble-orm/pkg/runtime"`: true,
+	}
+	if model.Package == filepath.Base(importPath) {
+		imports[strconv.Quote(importPath)] = true
+	} else {
+		imports[model.Package+" "+strconv.Quote(importPath)] = true
+	}
+
+	for _, col := range table.Columns {
+		typ, ok := fields[col.GoField]
+		qualified, needs, qualifiable := qualifyType(typ, model.Package, fileImports)
+		if col.Name == pk[0] {
+			if !ok || !qualifiable {
+				return nil, fmt.Sprintf("can't resolve the type of primary key field %s", col.GoField), nil
+			}
+			data.PKField, data.PKType = col.GoField, qualified
+			for _, imp := range needs {
+				imports[imp] = true
+			}
+			continue
+		}
+		if col.Generated == nil && col.Identity == nil && col.Updatable() {
+			data.Sets = append(data.Sets, repositoryField{Column: col.Name, Field: col.GoField})
+		}
+		if ok && qualifiable && filterable(col, typ) && col.GoField != "Limit" && col.GoField != "Offset" {
+			data.Filters = append(data.Filters, repositoryField{Column: col.Name, Field: col.GoField, Type: strings.TrimPrefix(qualified, "*")})
+			for _, imp := range needs {
+				imports[imp] = true
+			}
+		}
+	}
+	data.Filters = append([]repositoryField{{Column: data.PKColumn, Field: data.PKField, Type: strings.TrimPrefix(data.PKType, "*")}}, data.Filters...)
+
+	for imp := range imports {
+		path := imp[strings.Index(imp, `"`)+1:]
+		if strings.Contains(strings.Split(path, "/")[0], ".") {
+			data.Imports = append(data.Imports, imp)
+		} else {
+			data.StdImports = append(data.StdImports, imp)
+		}
+	}
+	slices.Sort(data.StdImports)
+	slices.Sort(data.Imports)
+	return data, "", nil
+}
+
+// filterable reports whether List can filter on col by equality: a scalar
+// column of a plain or named type.
+func filterable(col schema.ColumnMetadata, typ string) bool {
+	sqlType := strings.ToLower(col.SQLType)
+	if col.IsJSONB || strings.HasPrefix(sqlType, "json") || strings.HasSuffix(sqlType, "]") {
+		return false
+	}
+	typ = strings.TrimPrefix(typ, "*")
+	return !strings.ContainsAny(typ, "[]{}() ")
+}
+
+// structFieldTypes returns the type expressions of the named fields of a
+// struct declared in file, and the file's imports by the name they're used
+// under.
+func structFieldTypes(file, structName string) (map[string]string, map[string]string, error) {
+	node, err := parser.ParseFile(token.NewFileSet(), file, nil, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse %s: %w", file, err)
+	}
+	imports := make(map[string]string)
+	for _, imp := range node.Imports {
+		path, _ := strconv.Unquote(imp.Path.Value)
+		name := filepath.Base(path)
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+		imports[name] = path
+	}
+
+	fields := make(map[string]string)
+	ast.Inspect(node, func(n ast.Node) bool {
+		spec, ok := n.(*ast.TypeSpec)
+		if !ok || spec.Name.Name != structName {
+			return true
+		}
+		if st, ok := spec.Type.(*ast.StructType); ok {
+			for _, field := range st.Fields.List {
+				for _, name := range field.Names {
+					fields[name.Name] = types.ExprString(field.Type)
+				}
+			}
+		}
+		return false
+	})
+	return fields, imports, nil
+}
+
+// qualifyType returns typ as written outside the model package: named types
+// of the model package gain its name. It also returns the imports the type
+// needs, and false for types it can't resolve, such as ones of a
+// dot-imported package.
+func qualifyType(typ, modelPackage string, fileImports map[string]string) (string, []string, bool) {
+	pointer := strings.HasPrefix(typ, "*")
+	base := strings.TrimPrefix(typ, "*")
+	if base == "" || strings.ContainsAny(base, "[]{}() ") {
+		return typ, nil, base != ""
+	}
+	prefix := ""
+	if pointer {
+		prefix = "*"
+	}
+	if pkg, name, ok := strings.Cut(base, "."); ok {
+		path, known := fileImports[pkg]
+		if !known {
+			return "", nil, false
+		}
+		imp := strconv.Quote(path)
+		if pkg != filepath.Base(path) {
+			imp = pkg + " " + imp
+		}
+		return prefix + pkg + "." + name, []string{imp}, true
+	}
+	if types.Universe.Lookup(base) != nil {
+		return typ, nil, true
+	}
+	return prefix + modelPackage + "." + base, nil, true
+}
+
+// goImportPath returns the import path of the package in dir, from the
+// module path in the nearest go.mod above it.
+func goImportPath(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for root := abs; ; root = filepath.Dir(root) {
+		f, err := os.Open(filepath.Join(root, "go.mod"))
+		if err == nil {
+			module := ""
+			scanner := bufio.NewScanner(f)
+			for scanner.Scan() {
+				if rest, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), "module "); ok {
+					module = strings.Trim(strings.TrimSpace(rest), `"`)
+					break
+				}
+			}
+			_ = f.Close()
+			if module == "" {
+				return "", fmt.Errorf("no module path in %s", filepath.Join(root, "go.mod"))
+			}
+			rel, err := filepath.Rel(root, abs)
+			if err != nil {
+				return "", err
+			}
+			if rel == "." {
+				return module, nil
+			}
+			return module + "/" + filepath.ToSlash(rel), nil
+		}
+		if filepath.Dir(root) == root {
+			return "", fmt.Errorf("no go.mod found above %s", dir)
+		}
+	}
+}
+
+// packageNameFromDir returns the package name for a directory: its base name
+// with the characters a package name can't hold removed.
+func packageNameFromDir(dir string) string {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		abs = dir
+	}
+	name := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, filepath.Base(abs))
+	if name == "" || unicode.IsDigit(rune(name[0])) {
+		name = "repository"
+	}
+	return name
+}
+
+// lowerCamel lowers the leading initialism or letter of a Go name
+// (User → user, HTTPLog → httpLog).
+func lowerCamel(name string) string {
+	runes := []rune(name)
+	for i := range runes {
+		if !unicode.IsUpper(runes[i]) {
+			break
+		}
+		if i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+			break
+		}
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
+
+var repositoryTemplate = template.Must(template.New("repository").Parse(`package {{.Package}}
+
+import (
+{{- range .StdImports}}
+	{{.}}
+{{- end}}
+{{range .Imports}}
+	{{.}}
+{{- end}}
+)
+
+// {{.Name}}Repository reads and writes {{.Model}} rows.
+type {{.Name}}Repository interface {
+	// Get returns the row whose primary key is id, or runtime.ErrNotFound.
+	Get(ctx context.Context, id {{.PKType}}) (*{{.Model}}, error)
+	// List returns the rows matching filter, in primary key order.
+	List(ctx context.Context, filter {{.Name}}Filter) ([]{{.Model}}, error)
+	// Create inserts {{.Var}} and fills in the values the database generates.
+	Create(ctx context.Context, {{.Var}} *{{.Model}}) error
+	// Update saves every column of {{.Var}}, or returns runtime.ErrNotFound
+	// if no row has its primary key.
+	Update(ctx context.Context, {{.Var}} *{{.Model}}) error
+	// Delete deletes the row whose primary key is id, or returns
+	// runtime.ErrNotFound.
+	Delete(ctx context.Context, id {{.PKType}}) error
+	// WithTx returns a repository running its queries in tx.
+	WithTx(tx *builder.Tx) {{.Name}}Repository
+}
+
+// {{.Name}}Filter selects the rows List returns. Nil fields match every row.
+type {{.Name}}Filter struct {
+{{- range .Filters}}
+	{{.Field}} *{{.Type}}
+{{- end}}
+
+	Limit  int // 0 returns every matching row
+	Offset int
+}
+
+func (f {{.Name}}Filter) conditions() []builder.Condition {
+	var conditions []builder.Condition
+{{- range .Filters}}
+	if f.{{.Field}} != nil {
+		conditions = append(conditions, builder.Eq({{printf "%q" .Column}}, *f.{{.Field}}))
+	}
+{{- end}}
+	return conditions
+}
+
+// New{{.Name}}Repository returns a {{.Name}}Repository on db.
+func New{{.Name}}Repository(db *builder.DB) {{.Name}}Repository {
+	return &{{.Impl}}{db: db}
+}
+
+type {{.Impl}} struct {
+	db *builder.DB
+	tx *builder.Tx // set by WithTx
+}
+
+func (r *{{.Impl}}) Get(ctx context.Context, id {{.PKType}}) (*{{.Model}}, error) {
+	rows, err := r.List(ctx, {{.Name}}Filter{ {{- .PKField}}: &id, Limit: 1})
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, runtime.ErrNotFound
+	}
+	return &rows[0], nil
+}
+
+func (r *{{.Impl}}) List(ctx context.Context, filter {{.Name}}Filter) ([]{{.Model}}, error) {
+	if r.tx != nil {
+		q := builder.TxSelect[{{.Model}}](r.tx).OrderBy({{printf "%q" .PKColumn}}, builder.Asc)
+		for _, c := range filter.conditions() {
+			q.Where(c)
+		}
+		if filter.Limit > 0 {
+			q.Limit(filter.Limit)
+		}
+		if filter.Offset > 0 {
+			q.Offset(filter.Offset)
+		}
+		return q.All()
+	}
+	q := builder.Select[{{.Model}}](r.db).OrderBy({{printf "%q" .PKColumn}}, builder.Asc)
+	for _, c := range filter.conditions() {
+		q.Where(c)
+	}
+	if filter.Limit > 0 {
+		q.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		q.Offset(filter.Offset)
+	}
+	return q.All(ctx)
+}
+
+func (r *{{.Impl}}) Create(ctx context.Context, {{.Var}} *{{.Model}}) error {
+	var rows []{{.Model}}
+	var err error
+	if r.tx != nil {
+		rows, err = builder.TxInsert[{{.Model}}](r.tx).Values(*{{.Var}}).ExecReturning()
+	} else {
+		rows, err = builder.Insert[{{.Model}}](r.db).Values(*{{.Var}}).ExecReturning(ctx)
+	}
+	if err != nil {
+		return err
+	}
+	*{{.Var}} = rows[0]
+	return nil
+}
+
+func (r *{{.Impl}}) Update(ctx context.Context, {{.Var}} *{{.Model}}) error {
+{{- if .Sets}}
+	sets := map[string]interface{}{
+{{- range .Sets}}
+		{{printf "%q" .Column}}: {{$.Var}}.{{.Field}},
+{{- end}}
+	}
+	var n int64
+	var err error
+	if r.tx != nil {
+		n, err = builder.TxUpdate[{{.Model}}](r.tx).SetMap(sets).Where(builder.Eq({{printf "%q" .PKColumn}}, {{.Var}}.{{.PKField}})).Exec()
+	} else {
+		n, err = builder.Update[{{.Model}}](r.db).SetMap(sets).Where(builder.Eq({{printf "%q" .PKColumn}}, {{.Var}}.{{.PKField}})).Exec(ctx)
+	}
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return runtime.ErrNotFound
+	}
+	return nil
+{{- else}}
+	_, err := r.Get(ctx, {{.Var}}.{{.PKField}})
+	return err
+{{- end}}
+}
+
+func (r *{{.Impl}}) Delete(ctx context.Context, id {{.PKType}}) error {
+	var n int64
+	var err error
+	if r.tx != nil {
+		n, err = builder.TxDelete[{{.Model}}](r.tx).Where(builder.Eq({{printf "%q" .PKColumn}}, id)).Exec()
+	} else {
+		n, err = builder.Delete[{{.Model}}](r.db).Where(builder.Eq({{printf "%q" .PKColumn}}, id)).Exec(ctx)
+	}
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return runtime.ErrNotFound
+	}
+	return nil
+}
+
+func (r *{{.Impl}}) WithTx(tx *builder.Tx) {{.Name}}Repository {
+	return &{{.Impl}}{db: r.db, tx: tx}
+}
+`))
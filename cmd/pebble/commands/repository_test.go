@@ -0,0 +1,103 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/marshallshelly/pebble-orm/pkg/loader"
+)
+
+const repositoryModelsSource = `package models
+
+import "time"
+
+type Status string
+
+// table_name: users
+type User struct {
+	ID        int64     ` + "`po:\"id,primaryKey,bigserial\"`" + `
+	Email     string    ` + "`po:\"email,text,notNull\"`" + `
+	Status    Status    ` + "`po:\"status,text\"`" + `
+	Tags      []string  ` + "`po:\"tags,text[]\"`" + `
+	Total     int       ` + "`po:\"total,integer,generated(1)\"`" + `
+	Handle    string    ` + "`po:\"handle,text,immutable\"`" + `
+	Credits   int       ` + "`po:\"credits,integer,readOnly\"`" + `
+	CreatedAt time.Time ` + "`po:\"created_at,timestamptz\"`" + `
+}
+
+// view: SELECT id FROM users
+type ActiveUser struct {
+	ID int64 ` + "`po:\"id,primaryKey,bigint\"`" + `
+}
+
+type Membership struct {
+	UserID  int64 ` + "`po:\"user_id,primaryKey,bigint\"`" + `
+	GroupID int64 ` + "`po:\"group_id,primaryKey,bigint\"`" + `
+}
+`
+
+func TestGenerateRepositoryFiles(t *testing.T) {
+	root := t.TempDir()
+	modelsDir := filepath.Join(root, "internal", "models")
+	if err := os.MkdirAll(modelsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), []byte("module example.com/app\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(modelsDir, "models.go"), []byte(repositoryModelsSource), 0644); err != nil {
+		t.Fatal(err)
+	}
+	models, err := loader.ScanModels(modelsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outDir := filepath.Join(root, "internal", "store")
+	written, skipped, err := generateRepositoryFiles(models, outDir)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if len(written) != 1 || filepath.Base(written[0]) != "user_repository.gen.go" {
+		t.Errorf("written = %v", written)
+	}
+	if len(skipped) != 2 {
+		t.Errorf("skipped = %v, want the view and the composite key model", skipped)
+	}
+
+	b, err := os.ReadFile(written[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := string(b)
+	for _, want := range []string{
+		"package store",
+		`"example.com/app/internal/models"`,
+		"Get(ctx context.Context, id int64) (*models.User, error)",
+		"WithTx(tx *builder.Tx) UserRepository",
+		"Status    *models.Status",
+		"CreatedAt *time.Time",
+		`conditions = append(conditions, builder.Eq("email", *f.Email))`,
+		`"email":      user.Email,`,
+		`builder.TxUpdate[models.User](r.tx).SetMap(sets).Where(builder.Eq("id", user.ID)).Exec()`,
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("repository missing %q:\n%s", want, src)
+		}
+	}
+	for _, unwanted := range []string{"Tags *", `"total":`, `"handle":`, `"credits":`} {
+		if strings.Contains(src, unwanted) {
+			t.Errorf("repository contains %q:\n%s", unwanted, src)
+		}
+	}
+}
+
+func TestLowerCamel(t *testing.T) {
+	for name, want := range map[string]string{"User": "user", "HTTPLog": "httpLog", "ID": "id", "OrderItem": "orderItem"} {
+		if got := lowerCamel(name); got != want {
+			t.Errorf("lowerCamel(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -1,9 +1,12 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
+	"github.com/marshallshelly/pebble-orm/pkg/config"
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
 	"github.com/spf13/cobra"
 )
 
@@ -13,6 +16,11 @@ var (
 	migrationsDir string
 	verbose       bool
 	jsonOutput    bool
+	configPath    string
+	envName       string
+
+	// projectConfig is the pebble.yaml in use, or nil without one.
+	projectConfig *config.Config
 )
 
 // rootCmd represents the base command
@@ -30,6 +38,9 @@ Features:
   - Full transaction support with savepoints
   - Relationship loading (belongsTo, hasOne, hasMany, manyToMany)`,
 	Version: "1.19.0",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return loadProjectConfig(cmd)
+	},
 }
 
 // Execute runs the root command
@@ -42,8 +53,90 @@ func Execute() {
 
 func init() {
 	// Global flags
-	rootCmd.PersistentFlags().StringVar(&dbURL, "db", "", "Database connection URL (required for most commands)")
+	rootCmd.PersistentFlags().StringVar(&dbURL, "db", "", "Database connection URL (default $PEBBLE_DATABASE_URL or $DATABASE_URL)")
 	rootCmd.PersistentFlags().StringVar(&migrationsDir, "migrations-dir", "./migrations", "Directory for migration files")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Verbose output")
 	rootCmd.PersistentFlags().BoolVar(&jsonOutput, "json", false, "Output in JSON format")
+	rootCmd.PersistentFlags().StringVar(&configPath, "config", "", "Config file (default: pebble.yaml in this or a parent directory, or $PEBBLE_CONFIG)")
+	rootCmd.PersistentFlags().StringVar(&envName, "env", "", "Environment of the config file to use (default $PEBBLE_ENV or its default_environment)")
+}
+
+// loadProjectConfig loads pebble.yaml and uses its settings for the flags
+// of cmd that weren't given.
+func loadProjectConfig(cmd *cobra.Command) error {
+	var err error
+	if configPath != "" {
+		projectConfig, err = config.Load(configPath)
+	} else {
+		projectConfig, err = config.LoadDefault()
+		if errors.Is(err, config.ErrNotFound) {
+			return nil
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if projectConfig.Naming.IsSet() {
+		schema.SetNamingStrategy(projectConfig.Naming.Strategy())
+	}
+
+	flags := cmd.Flags()
+	if projectConfig.MigrationsDir != "" && !flags.Changed("migrations-dir") {
+		migrationsDir = projectConfig.MigrationsDir
+	}
+	if projectConfig.LockTimeout > 0 && !flags.Changed("lock-timeout") {
+		lockTimeout = projectConfig.LockTimeout
+	}
+	planner := projectConfig.Planner
+	if !flags.Changed("disallow-drops") {
+		disallowDrops = planner.DisallowDrops
+	}
+	if !flags.Changed("require-drop-confirmation") {
+		requireDrops = planner.RequireDropConfirmation
+	}
+	if !flags.Changed("batched-type-changes") {
+		batchedTypes = planner.BatchedTypeChanges
+	}
+	if planner.IfNotExists != nil {
+		ifNotExists = *planner.IfNotExists
+	}
+	return nil
+}
+
+// configModels returns path, or the models path of the config file when path
+// is empty.
+func configModels(path string) string {
+	if path == "" && projectConfig != nil {
+		return projectConfig.Models
+	}
+	return path
+}
+
+// requireDB sets dbURL when --db isn't given: from the environment named by
+// --env in the config file, else PEBBLE_DATABASE_URL or DATABASE_URL, else
+// the config file's default environment. It fails if none names a database.
+func requireDB() error {
+	if dbURL != "" {
+		return nil
+	}
+	if envName == "" {
+		for _, env := range []string{"PEBBLE_DATABASE_URL", "DATABASE_URL"} {
+			if dbURL = os.Getenv(env); dbURL != "" {
+				return nil
+			}
+		}
+	}
+	if projectConfig != nil {
+		env, err := projectConfig.Environment(envName)
+		if err != nil {
+			return err
+		}
+		dbURL = env.DatabaseURL
+		return nil
+	}
+	if envName != "" {
+		return fmt.Errorf("--env %s given but no %s found", envName, config.FileName)
+	}
+	return fmt.Errorf("no database: pass --db, set PEBBLE_DATABASE_URL or DATABASE_URL, or add %s", config.FileName)
 }
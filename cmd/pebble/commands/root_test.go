@@ -0,0 +1,76 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func TestLoadProjectConfig(t *testing.T) {
+	defer func(url, dir, env string, timeout time.Duration, drops bool) {
+		dbURL, migrationsDir, envName, lockTimeout, disallowDrops = url, dir, env, timeout, drops
+		projectConfig, configPath = nil, ""
+	}(dbURL, migrationsDir, envName, lockTimeout, disallowDrops)
+
+	dir := t.TempDir()
+	configPath = filepath.Join(dir, "pebble.yaml")
+	err := os.WriteFile(configPath, []byte(`
+default_environment: development
+environments:
+  development:
+    database_url: postgres://localhost/dev
+  staging:
+    database_url: postgres://${STAGING_HOST}/app
+migrations_dir: db/migrations
+lock_timeout: 30s
+planner:
+  disallow_drops: true
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("STAGING_HOST", "staging.internal")
+	t.Setenv("PEBBLE_DATABASE_URL", "")
+	t.Setenv("DATABASE_URL", "")
+	t.Setenv("PEBBLE_ENV", "")
+
+	cmd := &cobra.Command{}
+	cmd.Flags().StringVar(&migrationsDir, "migrations-dir", "./migrations", "")
+	cmd.Flags().BoolVar(&disallowDrops, "disallow-drops", false, "")
+	if err := cmd.Flags().Parse([]string{"--migrations-dir", "./flag"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := loadProjectConfig(cmd); err != nil {
+		t.Fatal(err)
+	}
+	if migrationsDir != "./flag" {
+		t.Errorf("migrations dir %q, want the flag to win", migrationsDir)
+	}
+	if lockTimeout != 30*time.Second || !disallowDrops {
+		t.Errorf("got lock timeout %v and disallow drops %v from the config", lockTimeout, disallowDrops)
+	}
+
+	dbURL, envName = "", ""
+	if err := requireDB(); err != nil || dbURL != "postgres://localhost/dev" {
+		t.Errorf("got %q, %v; want the default environment", dbURL, err)
+	}
+
+	// --env picks an environment even when DATABASE_URL is set
+	t.Setenv("DATABASE_URL", "postgres://env")
+	dbURL, envName = "", "staging"
+	if err := requireDB(); err != nil || dbURL != "postgres://staging.internal/app" {
+		t.Errorf("got %q, %v; want the staging environment", dbURL, err)
+	}
+	dbURL, envName = "", ""
+	if err := requireDB(); err != nil || dbURL != "postgres://env" {
+		t.Errorf("got %q, %v; want DATABASE_URL before the config file", dbURL, err)
+	}
+
+	dbURL, envName = "", "production"
+	if err := requireDB(); err == nil {
+		t.Error("expected an error for an unknown environment")
+	}
+}
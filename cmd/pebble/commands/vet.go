@@ -0,0 +1,74 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/marshallshelly/pebble-orm/cmd/pebble/output"
+	"github.com/marshallshelly/pebble-orm/pkg/loader"
+	"github.com/spf13/cobra"
+)
+
+var vetModelsPath string
+
+// vetCmd checks model struct tags
+var vetCmd = &cobra.Command{
+	Use:   "vet",
+	Short: "Check model struct tags for mistakes",
+	Long: `Check model struct tags without touching the database.
+
+Reports unknown tag options, SQL types the Go field can't hold (e.g. an int
+field tagged uuid), tables without a primary key, relationships to structs
+that aren't models, and foreign keys to unknown tables or columns. Exits
+non-zero when problems are found, so it can run in CI.
+
+Examples:
+  pebble vet --models ./internal/models
+  pebble vet --models ./internal/models --json`,
+	SilenceUsage: true, // problems in the models aren't usage errors
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runVet()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(vetCmd)
+
+	vetCmd.Flags().StringVar(&vetModelsPath, "models", "", "Path to Go file or directory with model definitions (default: models of pebble.yaml)")
+}
+
+func runVet() error {
+	vetModelsPath = configModels(vetModelsPath)
+	if vetModelsPath == "" {
+		return fmt.Errorf("--models is required without a models path in pebble.yaml")
+	}
+	problems, err := loader.VetModelsFromPath(vetModelsPath)
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		messages := make([]string, 0, len(problems))
+		for _, p := range problems {
+			messages = append(messages, p.Error())
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(messages); err != nil {
+			return err
+		}
+	} else if len(problems) == 0 {
+		output.Success("No problems found in %s", vetModelsPath)
+	} else {
+		output.Section("Model problems")
+		for _, p := range problems {
+			output.Error("%s", p)
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%d problem(s) found", len(problems))
+	}
+	return nil
+}
@@ -76,6 +76,8 @@ func StatusIcon(status string) string {
 		return warningStyle.Render("○")
 	case "failed":
 		return errorStyle.Render("✗")
+	case "modified":
+		return warningStyle.Render("!")
 	case "running":
 		return infoStyle.Render("◉")
 	default:
@@ -278,11 +278,14 @@ func example3Restrict(ctx context.Context, qb *builder.DB) error {
 		Where(builder.Eq("id", categoryID)).
 		Exec(ctx)
 
-	if err != nil {
+	switch {
+	case builder.IsForeignKeyViolation(err):
 		fmt.Printf("❌ Deletion prevented by RESTRICT constraint!\n")
 		fmt.Printf("Error: %v\n", err)
 		fmt.Printf("✅ This is correct behavior - can't delete category with products\n")
-	} else {
+	case err != nil:
+		return fmt.Errorf("failed to delete category: %w", err)
+	default:
 		fmt.Printf("⚠️  Unexpected: Category was deleted (RESTRICT should have prevented this)\n")
 	}
 
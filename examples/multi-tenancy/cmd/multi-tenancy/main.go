@@ -16,11 +16,21 @@ import (
 	"github.com/marshallshelly/pebble-orm/examples/multi-tenancy/internal/gdpr"
 	"github.com/marshallshelly/pebble-orm/examples/multi-tenancy/internal/models"
 	"github.com/marshallshelly/pebble-orm/pkg/builder"
+	"github.com/marshallshelly/pebble-orm/pkg/queue"
 )
 
 type App struct {
 	db          *builder.DB
 	gdprService *gdpr.Service
+	exports     *queue.Queue[exportJob]
+}
+
+// exportJob asks a worker to build a data export request's file.
+type exportJob struct {
+	RequestID   string `json:"request_id"`
+	TenantID    string `json:"tenant_id"`
+	UserID      string `json:"user_id"`
+	RequestedBy string `json:"requested_by"`
 }
 
 func main() {
@@ -35,12 +45,26 @@ func main() {
 
 	qb := builder.New(db)
 
+	// Background jobs: data exports are built by a worker, not in the request
+	if err := queue.Setup(ctx, db); err != nil {
+		log.Fatalf("Failed to set up job queue: %v", err)
+	}
+
 	// Initialize app
 	app := &App{
 		db:          qb,
 		gdprService: gdpr.NewService(qb),
+		exports:     queue.New[exportJob](db, "gdpr-exports", queue.Options{MaxAttempts: 3}),
 	}
 
+	workerCtx, stopWorker := context.WithCancel(ctx)
+	defer stopWorker()
+	go func() {
+		if err := app.exports.Work(workerCtx, app.processExport); err != nil && workerCtx.Err() == nil {
+			log.Printf("Export worker stopped: %v", err)
+		}
+	}()
+
 	// Create Fiber app
 	fiberApp := fiber.New(fiber.Config{
 		ErrorHandler: customErrorHandler,
@@ -52,7 +76,11 @@ func main() {
 
 	// Health check
 	fiberApp.Get("/health", func(c *fiber.Ctx) error {
-		return c.JSON(fiber.Map{"status": "healthy"})
+		report := qb.Health(c.Context())
+		if !report.OK() {
+			c.Status(fiber.StatusServiceUnavailable)
+		}
+		return c.JSON(report)
 	})
 
 	// API routes
@@ -423,33 +451,54 @@ func (app *App) requestDataExport(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("Failed to create export request: %v", err))
 	}
 
-	// In production, this would trigger a background job to generate the export
-	// For demo, we'll export immediately
-	exportData, err := app.gdprService.ExportUserData(c.Context(), tenantID, userID)
-	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("Failed to export data: %v", err))
+	// Hand the export to the worker; the client polls the request's status
+	job := exportJob{RequestID: request.ID, TenantID: tenantID, UserID: userID, RequestedBy: requestedBy}
+	if _, err := app.exports.Enqueue(c.Context(), job); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("Failed to queue export: %v", err))
 	}
 
-	// Update request status
-	now := time.Now()
-	_, _ = builder.Update[models.DataExportRequest](app.db).
-		Set("status", "completed").
-		Set("completed_at", now).
-		Where(builder.Eq("id", request.ID)).
-		Exec(c.Context())
-
 	// Log export for GDPR audit trail
 	_ = app.gdprService.LogAudit(c.Context(), tenantID, requestedBy, "EXPORT", "users", userID, c.IP(), c.Get("User-Agent"), nil)
 
-	return c.JSON(fiber.Map{
-		"request_id":   request.ID,
-		"status":       "completed",
-		"data":         exportData,
-		"expires_at":   request.ExpiresAt,
-		"completed_at": now,
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"request_id": request.ID,
+		"status":     request.Status,
+		"expires_at": request.ExpiresAt,
 	})
 }
 
+// processExport builds an export in the background. A failed attempt is
+// retried with backoff; after the last one the job is kept as a dead letter.
+func (app *App) processExport(ctx context.Context, job *queue.Job[exportJob]) error {
+	setStatus := func(status string) error {
+		_, err := builder.Update[models.DataExportRequest](app.db).
+			Set("status", status).
+			Where(builder.Eq("id", job.Payload.RequestID)).
+			Exec(ctx)
+		return err
+	}
+	if err := setStatus("processing"); err != nil {
+		return err
+	}
+
+	exportData, err := app.gdprService.ExportUserData(ctx, job.Payload.TenantID, job.Payload.UserID)
+	if err != nil {
+		if job.Attempts >= job.MaxAttempts {
+			_ = setStatus("failed")
+		}
+		return err
+	}
+
+	// In production, upload exportData to object storage and set file_url
+	log.Printf("Export %s ready: %d sections", job.Payload.RequestID, len(exportData))
+	_, err = builder.Update[models.DataExportRequest](app.db).
+		Set("status", "completed").
+		Set("completed_at", time.Now()).
+		Where(builder.Eq("id", job.Payload.RequestID)).
+		Exec(ctx)
+	return err
+}
+
 func (app *App) getExportRequest(c *fiber.Ctx) error {
 	tenantID := c.Params("tenantId")
 	requestID := c.Params("requestId")
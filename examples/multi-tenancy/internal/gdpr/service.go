@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/netip"
 	"time"
 
 	"github.com/marshallshelly/pebble-orm/examples/multi-tenancy/internal/models"
@@ -23,13 +24,18 @@ func NewService(db *builder.DB) *Service {
 
 // LogAudit creates an audit log entry for GDPR compliance
 func (s *Service) LogAudit(ctx context.Context, tenantID, userID, action, resource, resourceID, ipAddress, userAgent string, changes any) error {
+	ip, err := netip.ParseAddr(ipAddress)
+	if err != nil {
+		return fmt.Errorf("invalid IP address %q: %w", ipAddress, err)
+	}
+
 	log := models.AuditLog{
 		TenantID:   tenantID,
 		UserID:     userID,
 		Action:     action,
 		Resource:   resource,
 		ResourceID: resourceID,
-		IPAddress:  ipAddress,
+		IPAddress:  ip,
 		UserAgent:  userAgent,
 	}
 
@@ -46,7 +52,7 @@ func (s *Service) LogAudit(ctx context.Context, tenantID, userID, action, resour
 		log.Changes = schema.JSONB(jsonbData)
 	}
 
-	_, err := builder.Insert[models.AuditLog](s.db).
+	_, err = builder.Insert[models.AuditLog](s.db).
 		Values(log).
 		Exec(ctx)
 
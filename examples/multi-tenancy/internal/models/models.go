@@ -1,6 +1,7 @@
 package models
 
 import (
+	"net/netip"
 	"time"
 
 	"github.com/marshallshelly/pebble-orm/pkg/schema"
@@ -40,7 +41,7 @@ type Tenant struct {
 	DPOEmail          *string `po:"dpo_email,varchar(320)"` // Data Protection Officer
 
 	// GDPR metadata
-	GDPRMetadata
+	GDPRMetadata `po:"embedded"`
 }
 
 // table_name: users
@@ -66,7 +67,7 @@ type User struct {
 	ProcessingBasis string `po:"processing_basis,varchar(50),default('consent'),notNull"` // consent, contract, legitimate_interest
 
 	// GDPR metadata
-	GDPRMetadata
+	GDPRMetadata `po:"embedded"`
 
 	// Relationships
 	Tenant *Tenant `po:"-,belongsTo,foreignKey(tenant_id),references(id)"`
@@ -82,7 +83,7 @@ type Document struct {
 	IsPublic bool   `po:"is_public,boolean,default(false),notNull"`
 
 	// GDPR metadata
-	GDPRMetadata
+	GDPRMetadata `po:"embedded"`
 
 	// Relationships
 	Tenant *Tenant `po:"-,belongsTo,foreignKey(tenant_id),references(id)"`
@@ -102,7 +103,7 @@ type AuditLog struct {
 
 	// Details
 	Changes   schema.JSONB `po:"changes,jsonb"` // Before/after values
-	IPAddress string       `po:"ip_address,varchar(45),notNull"`
+	IPAddress netip.Addr   `po:"ip_address,inet,notNull"`
 	UserAgent string       `po:"user_agent,text"`
 
 	CreatedAt time.Time `po:"created_at,timestamptz,default(NOW()),notNull"`
@@ -127,7 +128,7 @@ type DataExportRequest struct {
 
 	CompletedAt *time.Time `po:"completed_at,timestamptz"`
 
-	GDPRMetadata
+	GDPRMetadata `po:"embedded"`
 
 	Tenant *Tenant `po:"-,belongsTo,foreignKey(tenant_id),references(id)"`
 	User   *User   `po:"-,belongsTo,foreignKey(user_id),references(id)"`
@@ -148,7 +149,7 @@ type DeletionRequest struct {
 
 	CompletedAt *time.Time `po:"completed_at,timestamptz"`
 
-	GDPRMetadata
+	GDPRMetadata `po:"embedded"`
 
 	Tenant *Tenant `po:"-,belongsTo,foreignKey(tenant_id),references(id)"`
 	User   *User   `po:"-,belongsTo,foreignKey(user_id),references(id)"`
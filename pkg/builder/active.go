@@ -0,0 +1,169 @@
+package builder
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ActiveQuery is a statement running on a DB with Config.TrackQueries set.
+type ActiveQuery struct {
+	ID      uint64
+	SQL     string
+	Started time.Time
+	Tags    map[string]string // WithSQLComment tags of its context
+}
+
+// ActiveQueries returns the statements the DB and its transactions are
+// running, oldest first, or nil unless Config.TrackQueries is set.
+// Usage: for _, q := range qb.ActiveQueries() { log.Println(q.ID, time.Since(q.Started), q.SQL) }
+func (d *DB) ActiveQueries() []ActiveQuery {
+	if d.config == nil || d.config.active == nil {
+		return nil
+	}
+	return d.config.active.list()
+}
+
+// CancelQuery cancels the running statement with the given ID, as listed by
+// ActiveQueries: its context is canceled and it fails with context.Canceled.
+// Pools opened by runtime.Connect also send the server a cancel request, as
+// pg_cancel_backend would; others only close the connection unless their
+// ConnConfig.BuildContextWatcherHandler does the same. It reports whether
+// the statement was still running.
+// Usage: qb.CancelQuery(id)
+func (d *DB) CancelQuery(id uint64) bool {
+	if d.config == nil || d.config.active == nil {
+		return false
+	}
+	return d.config.active.cancel(id)
+}
+
+// activeQueries is the registry of running statements shared by a DB, its
+// copies and its transactions.
+type activeQueries struct {
+	mu      sync.Mutex
+	next    uint64
+	running map[uint64]*activeQuery
+}
+
+type activeQuery struct {
+	ActiveQuery
+	cancel context.CancelFunc
+}
+
+func newActiveQueries() *activeQueries {
+	return &activeQueries{running: make(map[uint64]*activeQuery)}
+}
+
+// start registers sql and returns the context to run it with and the
+// function to call once it is done.
+func (a *activeQueries) start(ctx context.Context, sql string) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	a.mu.Lock()
+	a.next++
+	id := a.next
+	a.running[id] = &activeQuery{
+		ActiveQuery: ActiveQuery{ID: id, SQL: sql, Started: time.Now(), Tags: sqlCommentTags(ctx)},
+		cancel:      cancel,
+	}
+	a.mu.Unlock()
+	return ctx, sync.OnceFunc(func() {
+		a.mu.Lock()
+		delete(a.running, id)
+		a.mu.Unlock()
+		cancel()
+	})
+}
+
+func (a *activeQueries) list() []ActiveQuery {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	queries := make([]ActiveQuery, 0, len(a.running))
+	for _, id := range slices.Sorted(maps.Keys(a.running)) {
+		queries = append(queries, a.running[id].ActiveQuery)
+	}
+	return queries
+}
+
+func (a *activeQueries) cancel(id uint64) bool {
+	a.mu.Lock()
+	q, ok := a.running[id]
+	a.mu.Unlock()
+	if ok {
+		q.cancel()
+	}
+	return ok
+}
+
+// withTracking wraps exec so it registers each statement while it runs,
+// unless cfg doesn't set TrackQueries.
+func withTracking(exec Executor, cfg *Config) Executor {
+	if cfg == nil || cfg.active == nil {
+		return exec
+	}
+	return trackingExecutor{next: exec, active: cfg.active}
+}
+
+// trackingExecutor is an Executor that keeps each statement in its registry
+// until it is done: on return for Exec, after Scan for QueryRow and on
+// Close for Query.
+type trackingExecutor struct {
+	next   Executor
+	active *activeQueries
+}
+
+func (t trackingExecutor) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	ctx, done := t.active.start(ctx, sql)
+	rows, err := t.next.Query(ctx, sql, args...)
+	if err != nil {
+		done()
+		return nil, err
+	}
+	return &trackedRows{Rows: rows, done: done}, nil
+}
+
+func (t trackingExecutor) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	ctx, done := t.active.start(ctx, sql)
+	return trackedRow{row: t.next.QueryRow(ctx, sql, args...), done: done}
+}
+
+func (t trackingExecutor) Exec(ctx context.Context, sql string, args ...interface{}) (int64, error) {
+	ctx, done := t.active.start(ctx, sql)
+	defer done()
+	return t.next.Exec(ctx, sql, args...)
+}
+
+// trackedRows leaves the registry when closed, explicitly or by reading the
+// last row.
+type trackedRows struct {
+	pgx.Rows
+	done func()
+}
+
+func (r *trackedRows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+	r.done()
+	return false
+}
+
+func (r *trackedRows) Close() {
+	r.Rows.Close()
+	r.done()
+}
+
+// trackedRow leaves the registry when scanned.
+type trackedRow struct {
+	row  pgx.Row
+	done func()
+}
+
+func (r trackedRow) Scan(dest ...interface{}) error {
+	defer r.done()
+	return r.row.Scan(dest...)
+}
@@ -0,0 +1,56 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// blockingExecutor runs each Exec until its context is done.
+type blockingExecutor struct{ stubExecutor }
+
+func (blockingExecutor) Exec(ctx context.Context, sql string, args ...interface{}) (int64, error) {
+	<-ctx.Done()
+	return 0, ctx.Err()
+}
+
+func TestActiveQueries(t *testing.T) {
+	db := NewWithExecutor(blockingExecutor{}).WithConfig(Config{TrackQueries: true})
+	ctx := WithSQLComment(context.Background(), "route", "purge")
+
+	errc := make(chan error, 1)
+	go func() {
+		_, err := Delete[TestUser](db).Where(Eq("id", 1)).Exec(ctx)
+		errc <- err
+	}()
+
+	var active []ActiveQuery
+	for deadline := time.Now().Add(time.Second); len(active) == 0 && time.Now().Before(deadline); {
+		active = db.ActiveQueries()
+		time.Sleep(time.Millisecond)
+	}
+	if len(active) != 1 {
+		t.Fatalf("active queries = %+v", active)
+	}
+	if q := active[0]; q.SQL != "DELETE FROM test_user WHERE id = $1" || q.Tags["route"] != "purge" || q.Started.IsZero() {
+		t.Errorf("active query = %+v", q)
+	}
+
+	if !db.CancelQuery(active[0].ID) {
+		t.Fatal("CancelQuery found nothing to cancel")
+	}
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	if active := db.ActiveQueries(); len(active) != 0 {
+		t.Errorf("finished query still listed: %+v", active)
+	}
+	if db.CancelQuery(active[0].ID) {
+		t.Error("CancelQuery canceled a finished query")
+	}
+
+	if NewWithExecutor(stubExecutor{}).ActiveQueries() != nil {
+		t.Error("queries tracked without TrackQueries")
+	}
+}
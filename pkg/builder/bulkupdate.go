@@ -0,0 +1,335 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/marshallshelly/pebble-orm/pkg/registry"
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+)
+
+// maxBindParams is the most parameters PostgreSQL accepts in one statement.
+const maxBindParams = 65535
+
+// BulkUpdateQuery updates many rows, each to its own values, in a single
+// UPDATE ... FROM (VALUES ...) statement matched on key columns.
+type BulkUpdateQuery[T any] struct {
+	db        *DB
+	table     *schema.TableMetadata
+	rows      []T
+	key       []string
+	columns   []string
+	returning []string
+	unscoped  bool
+	deadline  time.Time
+	err       error
+}
+
+// BulkUpdate creates an UPDATE that sets each row of Rows to its own values
+// in one round trip. Rows are matched on Key, the primary key by default, and
+// Columns are set, by default every other column.
+// Usage: builder.BulkUpdate[Task](db).Rows(tasks).Key("id").Columns("status", "priority").Exec(ctx)
+func BulkUpdate[T any](d *DB) *BulkUpdateQuery[T] {
+	var model T
+	q := &BulkUpdateQuery[T]{db: d}
+	table, err := registry.GetOrRegister(model)
+	if err != nil {
+		q.err = err
+		return q
+	}
+	q.table = inSchema(table, d.schema)
+	return q
+}
+
+// Rows adds the rows to update.
+func (q *BulkUpdateQuery[T]) Rows(rows []T) *BulkUpdateQuery[T] {
+	q.rows = append(q.rows, rows...)
+	return q
+}
+
+// Key sets the columns that identify each row.
+func (q *BulkUpdateQuery[T]) Key(columns ...string) *BulkUpdateQuery[T] {
+	q.key = q.checkColumns(columns, false)
+	return q
+}
+
+// Columns sets the columns to update.
+func (q *BulkUpdateQuery[T]) Columns(columns ...string) *BulkUpdateQuery[T] {
+	q.columns = q.checkColumns(columns, true)
+	return q
+}
+
+// Unscoped drops the model's default scope (// default_scope: ...) from
+// the query, to reach rows it filters out such as soft-deleted ones.
+// Usage: builder.BulkUpdate[T](qb).Unscoped().Rows(rows)
+func (q *BulkUpdateQuery[T]) Unscoped() *BulkUpdateQuery[T] {
+	q.unscoped = true
+	return q
+}
+
+// Returning specifies columns to return from the updated rows.
+func (q *BulkUpdateQuery[T]) Returning(columns ...string) *BulkUpdateQuery[T] {
+	q.returning = quoteAll(columns, quoteSelectItem, &q.err)
+	return q
+}
+
+// checkColumns records an error for each of columns the table doesn't have
+// and, for columns to be written, each that can't be updated. Key columns are
+// only matched, so immutable and readOnly ones are fine.
+func (q *BulkUpdateQuery[T]) checkColumns(columns []string, written bool) []string {
+	for _, col := range columns {
+		if q.table != nil && q.table.GetColumnByName(col) == nil {
+			keepErr(&q.err, fmt.Errorf("column %s not found in table %s", col, q.table.Name))
+		} else if q.table != nil && written {
+			keepErr(&q.err, checkUpdatable(q.table, col))
+		}
+	}
+	return columns
+}
+
+// ToSQL generates the UPDATE SQL and arguments.
+func (q *BulkUpdateQuery[T]) ToSQL() (string, []interface{}, error) {
+	if q.err != nil {
+		return "", nil, q.err
+	}
+	rows := make([]interface{}, len(q.rows))
+	for i := range q.rows {
+		rows[i] = q.rows[i]
+	}
+	return buildBulkUpdateSQL(bulkUpdateSpec{
+		table:     q.table,
+		rows:      rows,
+		key:       q.key,
+		columns:   q.columns,
+		returning: q.returning,
+		unscoped:  q.unscoped,
+	})
+}
+
+// Exec executes the UPDATE and returns the number of updated rows.
+func (q *BulkUpdateQuery[T]) Exec(ctx context.Context) (int64, error) {
+	q, err := q.resolve(ctx)
+	if err != nil {
+		return 0, err
+	}
+	sql, args, err := q.ToSQL()
+	if err != nil {
+		return 0, err
+	}
+	qctx, cancel := withDeadline(ctx, q.deadline)
+	defer cancel()
+	n, err := execWrite(qctx, q.db.writeExec(), sql, args, len(q.returning) > 0)
+	return afterWrite(ctx, q.db, q.table, n, err)
+}
+
+// ExecReturning executes the UPDATE and returns the updated rows.
+func (q *BulkUpdateQuery[T]) ExecReturning(ctx context.Context) ([]T, error) {
+	q, err := q.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(q.returning) == 0 {
+		q.Returning("*")
+	}
+	sql, args, err := q.ToSQL()
+	if err != nil {
+		return nil, err
+	}
+	qctx, cancel := withDeadline(ctx, q.deadline)
+	defer cancel()
+	rows, err := queryRows[T](qctx, q.db.writeExec(), q.table, sql, args, nil, q.db.relatedTables())
+	return afterWrite(ctx, q.db, q.table, rows, err)
+}
+
+// ExecReturningInto executes the UPDATE and scans the returned rows into
+// dest, a pointer to a slice of structs with some of T's fields or, with one
+// Returning column, of single values. It returns the number of rows.
+// Usage: var ids []int64; n, err := builder.BulkUpdate[User](qb).Rows(users).Returning("id").ExecReturningInto(ctx, &ids)
+func (q *BulkUpdateQuery[T]) ExecReturningInto(ctx context.Context, dest interface{}) (int64, error) {
+	q, err := q.resolve(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if len(q.returning) == 0 {
+		q.Returning("*")
+	}
+	sql, args, err := q.ToSQL()
+	if err != nil {
+		return 0, err
+	}
+	qctx, cancel := withDeadline(ctx, q.deadline)
+	defer cancel()
+	n, err := scanReturning(qctx, q.db.writeExec(), q.table, sql, args, dest)
+	return afterWrite(ctx, q.db, q.table, n, err)
+}
+
+type bulkUpdateSpec struct {
+	table     *schema.TableMetadata
+	rows      []interface{}
+	key       []string
+	columns   []string
+	returning []string
+	unscoped  bool
+}
+
+// buildBulkUpdateSQL assembles
+//
+//	UPDATE t AS t SET c = v.c FROM (VALUES ($1::type, ...), ...) AS v (k, c) WHERE t.k = v.k
+//
+// The first row's placeholders are cast to the column types, which fixes the
+// types of the VALUES list; without them every column would be text.
+func buildBulkUpdateSQL(s bulkUpdateSpec) (string, []interface{}, error) {
+	if s.table == nil {
+		return "", nil, fmt.Errorf("table metadata not available")
+	}
+	if err := checkWritable(s.table); err != nil {
+		return "", nil, err
+	}
+	if len(s.rows) == 0 {
+		return "", nil, fmt.Errorf("no rows to update")
+	}
+	key := s.key
+	if len(key) == 0 {
+		key = s.table.PrimaryKeyColumns()
+	}
+	if len(key) == 0 {
+		return "", nil, fmt.Errorf("bulk update of %s needs key columns", s.table.Name)
+	}
+	columns := s.columns
+	if len(columns) == 0 {
+		columns = bulkUpdateColumns(s.table, key)
+	}
+	if len(columns) == 0 {
+		return "", nil, fmt.Errorf("bulk update of %s has no columns to set", s.table.Name)
+	}
+	valueColumns := append(append([]string(nil), key...), columns...)
+	if n := len(s.rows) * len(valueColumns); n > maxBindParams {
+		return "", nil, fmt.Errorf("bulk update binds %d values, more than PostgreSQL's limit of %d; update fewer rows at a time", n, maxBindParams)
+	}
+
+	var sql strings.Builder
+	args := make([]interface{}, 0, len(s.rows)*len(valueColumns))
+	sql.WriteString("UPDATE ")
+	sql.WriteString(schema.QuoteQualifiedIdent(s.table.QualifiedName()))
+	sql.WriteString(" AS t SET ")
+	for i, col := range columns {
+		if i > 0 {
+			sql.WriteString(", ")
+		}
+		quoted := schema.QuoteReservedIdent(col)
+		sql.WriteString(quoted + " = v." + quoted)
+	}
+
+	sql.WriteString(" FROM (VALUES ")
+	for r, row := range s.rows {
+		values, err := rowValues(row, s.table, valueColumns)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to extract values from row %d: %w", r, err)
+		}
+		if r > 0 {
+			sql.WriteString(", ")
+		}
+		sql.WriteByte('(')
+		for i, value := range values {
+			if i > 0 {
+				sql.WriteString(", ")
+			}
+			args = append(args, value)
+			writePlaceholder(&sql, len(args))
+			if r == 0 {
+				sql.WriteString("::")
+				sql.WriteString(castType(s.table.GetColumnByName(valueColumns[i]).SQLType))
+			}
+		}
+		sql.WriteByte(')')
+	}
+	sql.WriteString(") AS v (")
+	sql.WriteString(strings.Join(schema.QuoteReservedIdents(valueColumns), ", "))
+	sql.WriteString(") WHERE ")
+	for i, col := range key {
+		if i > 0 {
+			sql.WriteString(" AND ")
+		}
+		quoted := schema.QuoteReservedIdent(col)
+		sql.WriteString("t." + quoted + " = v." + quoted)
+	}
+	if !s.unscoped {
+		sql.WriteString(scopeFilter(s.table, "t"))
+	}
+
+	if len(s.returning) > 0 {
+		sql.WriteString(" RETURNING ")
+		for i, col := range returningColumns(s.table, s.returning) {
+			if i > 0 {
+				sql.WriteString(", ")
+			}
+			// Unqualified names would be ambiguous with the VALUES list
+			if !strings.Contains(col, ".") {
+				sql.WriteString("t.")
+			}
+			sql.WriteString(col)
+		}
+	}
+	return sql.String(), args, nil
+}
+
+// bulkUpdateColumns returns the columns a bulk update sets by default: all
+// but the key, the columns the database computes and those the model says
+// are never updated.
+func bulkUpdateColumns(table *schema.TableMetadata, key []string) []string {
+	var columns []string
+	for _, col := range table.Columns {
+		if !col.Updatable() || col.Identity != nil || col.GoField == "" || slices.Contains(key, col.Name) {
+			continue
+		}
+		columns = append(columns, col.Name)
+	}
+	return columns
+}
+
+// rowValues returns the values of the named columns of row, encoded as for
+// an INSERT.
+func rowValues(row interface{}, table *schema.TableMetadata, columns []string) ([]interface{}, error) {
+	modelValue := reflect.ValueOf(row)
+	if modelValue.Kind() == reflect.Pointer {
+		modelValue = modelValue.Elem()
+	}
+	if modelValue.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("model must be a struct")
+	}
+	plan := valuePlanFor(modelValue.Type(), table)
+	if plan.err != nil {
+		return nil, plan.err
+	}
+	values := make([]interface{}, len(columns))
+	for i, name := range columns {
+		c := &plan.columns[plan.byName[name]]
+		if c.index == nil {
+			return nil, fmt.Errorf("field %s not found for column %s", c.col.GoField, name)
+		}
+		value, err := c.value(modelValue.FieldByIndex(c.index))
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+// castType returns the type to cast a value of a column of sqlType to:
+// serial types are integers once created.
+func castType(sqlType string) string {
+	switch strings.ToLower(sqlType) {
+	case "serial":
+		return "integer"
+	case "bigserial":
+		return "bigint"
+	case "smallserial":
+		return "smallint"
+	}
+	return sqlType
+}
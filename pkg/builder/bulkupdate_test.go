@@ -0,0 +1,58 @@
+package builder
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBulkUpdate(t *testing.T) {
+	db := New(nil)
+	users := []TestUser{
+		{ID: "a", Name: "Ann", Email: "ann@example.com", Age: 30},
+		{ID: "b", Name: "Bob", Email: "bob@example.com", Age: 40},
+	}
+
+	sql, args, err := BulkUpdate[TestUser](db).Rows(users).Key("id").Columns("name", "age").ToSQL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "UPDATE test_user AS t SET name = v.name, age = v.age " +
+		"FROM (VALUES ($1::uuid, $2::varchar(255), $3::integer), ($4, $5, $6)) AS v (id, name, age) " +
+		"WHERE t.id = v.id"
+	if sql != want {
+		t.Errorf("SQL =\n%s\nwant\n%s", sql, want)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"a", "Ann", 30, "b", "Bob", 40}) {
+		t.Errorf("args = %v", args)
+	}
+
+	// The key defaults to the primary key and the columns to all others.
+	sql, _, err = BulkUpdate[TestUser](db).Rows(users).Returning("id", "name AS n").ToSQL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(sql, "UPDATE test_user AS t SET name = v.name, email = v.email, age = v.age FROM") ||
+		!strings.HasSuffix(sql, "AS v (id, name, email, age) WHERE t.id = v.id RETURNING t.id, t.name AS n") {
+		t.Errorf("unexpected SQL %s", sql)
+	}
+}
+
+func TestBulkUpdateErrors(t *testing.T) {
+	db := New(nil)
+	rows := []TestUser{{ID: "a"}}
+	tests := []struct {
+		name  string
+		query *BulkUpdateQuery[TestUser]
+	}{
+		{"no rows", BulkUpdate[TestUser](db).Key("id")},
+		{"unknown key", BulkUpdate[TestUser](db).Rows(rows).Key("uuid")},
+		{"unknown column", BulkUpdate[TestUser](db).Rows(rows).Columns("status")},
+		{"too many values", BulkUpdate[TestUser](db).Rows(make([]TestUser, maxBindParams/2+1)).Columns("age")},
+	}
+	for _, tt := range tests {
+		if sql, _, err := tt.query.ToSQL(); err == nil {
+			t.Errorf("%s: expected an error, got %s", tt.name, sql)
+		}
+	}
+}
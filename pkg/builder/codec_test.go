@@ -0,0 +1,107 @@
+package builder
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+)
+
+// upperCode is stored lower-case in the database and upper-case in Go, to
+// prove values pass through the registered codec in both directions.
+type upperCode string
+
+func registerUpperCodeCodec(t *testing.T) {
+	t.Helper()
+	schema.RegisterCodec(
+		func(c upperCode) (any, error) { return strings.ToLower(string(c)), nil },
+		func(src any) (upperCode, error) {
+			s, ok := src.(string)
+			if !ok {
+				return "", fmt.Errorf("unexpected %T", src)
+			}
+			return upperCode(strings.ToUpper(s)), nil
+		},
+	)
+	t.Cleanup(schema.UnregisterCodec[upperCode])
+}
+
+func TestStructToValues_Codec(t *testing.T) {
+	registerUpperCodeCodec(t)
+
+	table := &schema.TableMetadata{
+		Name: "coupons",
+		Columns: []schema.ColumnMetadata{
+			{Name: "code", GoField: "Code", SQLType: "text"},
+			{Name: "parent_code", GoField: "ParentCode", SQLType: "text", Nullable: true},
+		},
+	}
+
+	type Coupon struct {
+		Code       upperCode
+		ParentCode *upperCode
+	}
+
+	parent := upperCode("SPRING")
+	tests := []struct {
+		name   string
+		coupon Coupon
+		want   []interface{}
+	}{
+		{"value and pointer", Coupon{Code: "SAVE10", ParentCode: &parent}, []interface{}{"save10", "spring"}},
+		{"nil pointer binds NULL", Coupon{Code: "SAVE10"}, []interface{}{"save10", nil}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, vals, err := structToValues(tt.coupon, table, false)
+			if err != nil {
+				t.Fatalf("structToValues() error = %v", err)
+			}
+			if !reflect.DeepEqual(vals, tt.want) {
+				t.Errorf("got values %v, want %v", vals, tt.want)
+			}
+		})
+	}
+}
+
+func TestCodecScanTarget(t *testing.T) {
+	registerUpperCodeCodec(t)
+
+	var s struct {
+		Code       upperCode
+		ParentCode *upperCode
+	}
+	v := reflect.ValueOf(&s).Elem()
+
+	codec, viaPtr, ok := codecFor(v.Field(0).Type())
+	if !ok || viaPtr {
+		t.Fatalf("codecFor(upperCode) = ok %v, viaPtr %v", ok, viaPtr)
+	}
+	if err := (&codecScanTarget{field: v.Field(0), codec: codec}).Scan("save10"); err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if s.Code != "SAVE10" {
+		t.Errorf("Code = %q, want %q", s.Code, "SAVE10")
+	}
+
+	codec, viaPtr, ok = codecFor(v.Field(1).Type())
+	if !ok || !viaPtr {
+		t.Fatalf("codecFor(*upperCode) = ok %v, viaPtr %v", ok, viaPtr)
+	}
+	target := &codecScanTarget{field: v.Field(1), codec: codec, viaPtr: viaPtr}
+	if err := target.Scan("spring"); err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if s.ParentCode == nil || *s.ParentCode != "SPRING" {
+		t.Errorf("ParentCode = %v, want SPRING", s.ParentCode)
+	}
+	if err := target.Scan(nil); err != nil {
+		t.Fatalf("Scan(nil) error = %v", err)
+	}
+	if s.ParentCode != nil {
+		t.Errorf("ParentCode = %v, want nil after scanning NULL", *s.ParentCode)
+	}
+}
@@ -0,0 +1,102 @@
+package builder
+
+import (
+	"context"
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// commentTagsKey is the context key of the tags WithSQLComment adds.
+type commentTagsKey struct{}
+
+// WithSQLComment returns a context whose statements carry key=value in
+// their sqlcommenter comment, on DBs with Config.SQLComments set. Later
+// values replace earlier ones for the same key. Keep values low-cardinality:
+// each distinct comment is a distinct statement to pgx's statement cache.
+// Usage: ctx = builder.WithSQLComment(r.Context(), "route", "listUsers")
+func WithSQLComment(ctx context.Context, key, value string) context.Context {
+	tags := maps.Clone(sqlCommentTags(ctx))
+	if tags == nil {
+		tags = make(map[string]string)
+	}
+	tags[key] = value
+	return context.WithValue(ctx, commentTagsKey{}, tags)
+}
+
+// sqlCommentTags returns the tags WithSQLComment put on ctx.
+func sqlCommentTags(ctx context.Context) map[string]string {
+	tags, _ := ctx.Value(commentTagsKey{}).(map[string]string)
+	return tags
+}
+
+// withComments wraps exec so it appends a sqlcommenter comment to each
+// statement, unless cfg doesn't set SQLComments.
+func withComments(exec Executor, cfg *Config) Executor {
+	if cfg == nil || !cfg.SQLComments {
+		return exec
+	}
+	return commentExecutor{next: exec, cfg: cfg}
+}
+
+// commentExecutor is an Executor that tags each statement with a comment
+// built from its Config and context. The comment is part of the SQL pgx
+// caches statements by, so every distinct set of tags is prepared anew.
+type commentExecutor struct {
+	next Executor
+	cfg  *Config
+}
+
+func (c commentExecutor) sql(ctx context.Context, sql string) string {
+	tags := maps.Clone(c.cfg.CommentTags)
+	if tags == nil {
+		tags = make(map[string]string)
+	}
+	if c.cfg.CommentFunc != nil {
+		maps.Copy(tags, c.cfg.CommentFunc(ctx))
+	}
+	maps.Copy(tags, sqlCommentTags(ctx))
+	return appendSQLComment(sql, tags)
+}
+
+func (c commentExecutor) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	return c.next.Query(ctx, c.sql(ctx, sql), args...)
+}
+
+func (c commentExecutor) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	return c.next.QueryRow(ctx, c.sql(ctx, sql), args...)
+}
+
+func (c commentExecutor) Exec(ctx context.Context, sql string, args ...interface{}) (int64, error) {
+	return c.next.Exec(ctx, c.sql(ctx, sql), args...)
+}
+
+// appendSQLComment appends tags to sql as a sqlcommenter comment: keys in
+// order, keys and values URL-encoded and values single-quoted. Empty values
+// are left out, and sql is returned as is when it has a comment already or
+// there is nothing to add.
+func appendSQLComment(sql string, tags map[string]string) string {
+	if strings.Contains(sql, "/*") {
+		return sql
+	}
+	var pairs []string
+	for _, key := range slices.Sorted(maps.Keys(tags)) {
+		if tags[key] == "" {
+			continue
+		}
+		pairs = append(pairs, commentEscape(key)+"='"+commentEscape(tags[key])+"'")
+	}
+	if len(pairs) == 0 {
+		return sql
+	}
+	return sql + " /*" + strings.Join(pairs, ",") + "*/"
+}
+
+// commentEscape URL-encodes s, quotes and asterisks included, so no value
+// can close the comment or its quoting.
+func commentEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
@@ -0,0 +1,60 @@
+package builder
+
+import (
+	"context"
+	"testing"
+)
+
+func TestAppendSQLComment(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		tags map[string]string
+		want string
+	}{
+		{"sorted and quoted", "SELECT 1", map[string]string{"route": "listUsers", "app": "api"}, "SELECT 1 /*app='api',route='listUsers'*/"},
+		{"escaped", "SELECT 1", map[string]string{"route": "/users/{id}", "note": "it's */ done"}, "SELECT 1 /*note='it%27s%20%2A%2F%20done',route='%2Fusers%2F%7Bid%7D'*/"},
+		{"empty values dropped", "SELECT 1", map[string]string{"app": ""}, "SELECT 1"},
+		{"existing comment kept", "SELECT 1 /*x*/", map[string]string{"app": "api"}, "SELECT 1 /*x*/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := appendSQLComment(tt.sql, tt.tags); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSQLComments(t *testing.T) {
+	exec := &returningExecutor{columns: []string{"id"}}
+	var logged string
+	db := NewWithExecutor(exec).WithConfig(Config{
+		Logger:      LoggerFunc(func(ctx context.Context, entry QueryLog) { logged = entry.SQL }),
+		SQLComments: true,
+		CommentTags: map[string]string{"app": "api", "route": "default"},
+		CommentFunc: func(ctx context.Context) map[string]string {
+			return map[string]string{"traceparent": "00-abc-def-01"}
+		},
+	})
+	ctx := WithSQLComment(context.Background(), "route", "listUsers")
+
+	if _, err := Select[TestUser](db).Where(Eq("id", 1)).All(ctx); err != nil {
+		t.Fatal(err)
+	}
+	want := "SELECT * FROM test_user WHERE id = $1 /*app='api',route='listUsers',traceparent='00-abc-def-01'*/"
+	if exec.sql[0] != want {
+		t.Errorf("SQL = %s, want %s", exec.sql[0], want)
+	}
+	if logged != "SELECT * FROM test_user WHERE id = $1" {
+		t.Errorf("logged SQL = %s", logged)
+	}
+
+	exec.sql = nil
+	if _, err := Select[TestUser](NewWithExecutor(exec)).All(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if exec.sql[0] != "SELECT * FROM test_user" {
+		t.Errorf("SQL without SQLComments = %s", exec.sql[0])
+	}
+}
@@ -8,12 +8,27 @@ import (
 
 // CTE represents a Common Table Expression (WITH clause)
 type CTE struct {
-	Name    string
-	Columns []string
-	Query   string
-	Args    []interface{}
+	Name            string
+	Columns         []string
+	Query           string
+	Args            []interface{}
+	Materialization CTEMaterialization
 }
 
+// CTEMaterialization tells PostgreSQL whether to compute a CTE once or fold
+// it into the outer query. By default a CTE referenced once is folded in and
+// one referenced more often is computed once.
+type CTEMaterialization string
+
+const (
+	// Materialized computes the CTE once, keeping the outer query's
+	// conditions out of it.
+	Materialized CTEMaterialization = "MATERIALIZED"
+	// NotMaterialized folds the CTE into the outer query, so conditions on it
+	// can use the underlying table's indexes.
+	NotMaterialized CTEMaterialization = "NOT MATERIALIZED"
+)
+
 // CTEBuilder helps build queries with Common Table Expressions
 type CTEBuilder struct {
 	ctes []CTE
@@ -57,15 +72,20 @@ func (c *CTEBuilder) Build() (string, []interface{}) {
 	var allArgs []interface{}
 
 	for _, cte := range c.ctes {
+		as := "AS"
+		if cte.Materialization != "" {
+			as += " " + string(cte.Materialization)
+		}
 		var ctePart string
 		if len(cte.Columns) > 0 {
-			ctePart = fmt.Sprintf("%s (%s) AS (%s)",
+			ctePart = fmt.Sprintf("%s (%s) %s (%s)",
 				cte.Name,
 				strings.Join(cte.Columns, ", "),
+				as,
 				cte.Query,
 			)
 		} else {
-			ctePart = fmt.Sprintf("%s AS (%s)", cte.Name, cte.Query)
+			ctePart = fmt.Sprintf("%s %s (%s)", cte.Name, as, cte.Query)
 		}
 		parts = append(parts, ctePart)
 		allArgs = append(allArgs, cte.Args...)
@@ -96,6 +116,48 @@ func (q *CTESelect[T]) AndCTE(name, query string, args ...interface{}) *CTESelec
 	return q
 }
 
+// With adds query, such as another builder's Select, as the CTE name,
+// optionally forcing or preventing its materialization. Like WithCTE it
+// ends the chain of SelectQuery methods, so add CTEs last.
+// Usage: builder.Select[Order](qb).With("recent", builder.Select[Order](qb).Where(...), builder.Materialized)
+func (q *SelectQuery[T]) With(name string, query Query, materialization ...CTEMaterialization) *CTESelect[T] {
+	cteSelect := &CTESelect[T]{
+		SelectQuery: q,
+		cteBuilder:  NewCTEBuilder(),
+	}
+	return cteSelect.With(name, query, materialization...)
+}
+
+// With adds another CTE built from query, as SelectQuery.With.
+func (q *CTESelect[T]) With(name string, query Query, materialization ...CTEMaterialization) *CTESelect[T] {
+	if err := checkColumnName(name); err != nil {
+		keepErr(&q.err, err)
+		return q
+	}
+	sql, args, err := query.ToSQL()
+	if err != nil {
+		keepErr(&q.err, err)
+		return q
+	}
+	cte := CTE{Name: name, Args: args}
+	for _, m := range materialization {
+		if m != Materialized && m != NotMaterialized {
+			keepErr(&q.err, fmt.Errorf("invalid CTE materialization %q", m))
+			return q
+		}
+		cte.Materialization = m
+	}
+	// The query numbers its placeholders from $1; they follow the arguments
+	// of the CTEs before it.
+	n := 0
+	for _, prev := range q.cteBuilder.ctes {
+		n += len(prev.Args)
+	}
+	cte.Query = shiftPlaceholders(sql, n)
+	q.cteBuilder.ctes = append(q.cteBuilder.ctes, cte)
+	return q
+}
+
 // ToSQL builds the complete SQL with CTEs
 func (q *CTESelect[T]) ToSQL() (string, []interface{}, error) {
 	// Build CTE clause
@@ -123,39 +185,25 @@ func (q *CTESelect[T]) ToSQL() (string, []interface{}, error) {
 // the WITH clause is included — the promoted SelectQuery.All would use the
 // embedded ToSQL and silently drop the CTEs.
 func (q *CTESelect[T]) All(ctx context.Context) ([]T, error) {
-	sql, args, err := q.ToSQL()
+	main, err := q.SelectQuery.resolve(ctx)
 	if err != nil {
 		return nil, err
 	}
-
-	rows, err := q.db.db.Query(ctx, sql, args...)
+	if main != q.SelectQuery {
+		q = &CTESelect[T]{SelectQuery: main, cteBuilder: q.cteBuilder}
+	}
+	sql, args, err := q.ToSQL()
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
+	// A CTE may write, so the query is never retried.
 	var results []T
-	for rows.Next() {
-		var item T
-		if err := scanIntoStruct(rows, &item, q.table); err != nil {
-			return nil, err
-		}
-		results = append(results, item)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-
-	// Load preloaded relationships
-	if len(q.preloads) > 0 && len(results) > 0 {
-		loader := &relationshipLoader{query: q.db.db.Query, table: q.table, preloads: q.preloads}
-		if err := loader.loadRelationships(ctx, &results); err != nil {
-			return nil, err
-		}
-	}
-
-	return results, nil
+	err = q.readOnce(ctx, true, func(ctx context.Context, exec Executor) error {
+		results, err = queryRows[T](ctx, exec, q.table, sql, args, q.preloads, q.db.relatedTables())
+		return err
+	})
+	return results, err
 }
 
 // First executes the CTE query and returns the first result.
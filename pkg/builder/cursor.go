@@ -0,0 +1,73 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+)
+
+// Cursor reads a query's rows in batches from a server-side cursor, see
+// SelectQuery.DeclareCursor.
+type Cursor[T any] struct {
+	tx        *Tx
+	name      string // quoted
+	fetchSize int
+	table     *schema.TableMetadata
+	preloads  []string
+	done      bool // a short batch was fetched
+}
+
+// DeclareCursor declares a server-side cursor over the query in tx. Each
+// Fetch reads the next fetchSize rows, so the client holds one batch at a
+// time and the server streams from the plan instead of building the whole
+// result, for exports too large for All. The cursor lasts until Close or the
+// end of the transaction; the query's deadline and replicas don't apply.
+// Usage: cur, err := builder.Select[Event](qb).OrderByAsc("id").DeclareCursor(ctx, tx, "events_export", 5000)
+func (q *SelectQuery[T]) DeclareCursor(ctx context.Context, tx *Tx, name string, fetchSize int) (*Cursor[T], error) {
+	if fetchSize <= 0 {
+		return nil, fmt.Errorf("invalid fetch size %d", fetchSize)
+	}
+	quoted, err := quoteWith(name, "cursor", (*refScanner).ident)
+	if err != nil {
+		return nil, err
+	}
+	q, err = q.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sql, args, err := q.ToSQL()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.exec().Exec(ctx, "DECLARE "+quoted+" NO SCROLL CURSOR FOR "+sql, args...); err != nil {
+		return nil, fmt.Errorf("failed to declare cursor %s: %w", name, err)
+	}
+	return &Cursor[T]{tx: tx, name: quoted, fetchSize: fetchSize, table: q.table, preloads: q.preloads}, nil
+}
+
+// Fetch returns the cursor's next batch of up to fetchSize rows, with their
+// preloads, and no rows once it is exhausted.
+// Usage: for { batch, err := cur.Fetch(ctx); if err != nil || len(batch) == 0 { break }; ... }
+func (c *Cursor[T]) Fetch(ctx context.Context) ([]T, error) {
+	if c.done {
+		return nil, nil
+	}
+	sql := "FETCH FORWARD " + strconv.Itoa(c.fetchSize) + " FROM " + c.name
+	rows, err := queryRows[T](ctx, c.tx.exec(), c.table, sql, nil, c.preloads, c.tx.relatedTables())
+	if err != nil {
+		return nil, err
+	}
+	c.done = len(rows) < c.fetchSize
+	return rows, nil
+}
+
+// Close closes the cursor, freeing its resources on the server before the
+// transaction ends.
+func (c *Cursor[T]) Close(ctx context.Context) error {
+	if _, err := c.tx.exec().Exec(ctx, "CLOSE "+c.name); err != nil {
+		return fmt.Errorf("failed to close cursor: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// cursorTx serves a batch of rows per query, after recording it.
+type cursorTx struct {
+	upsertTx
+	batches []*fakeRows
+}
+
+func (t *cursorTx) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	t.e.sql = append(t.e.sql, sql)
+	rows := t.batches[0]
+	t.batches = t.batches[1:]
+	return rows, nil
+}
+
+func TestCursor(t *testing.T) {
+	ctx := context.Background()
+	exec := &upsertExecutor{}
+	columns := []string{"id", "sensor"}
+	tx := WrapTx(ctx, &cursorTx{upsertTx: upsertTx{exec}, batches: []*fakeRows{
+		newFakeRows(columns, []interface{}{int64(1), "t1"}, []interface{}{int64(2), "t2"}),
+		newFakeRows(columns, []interface{}{int64(3), "t3"}),
+	}})
+
+	cur, err := Select[ScanReading](NewWithExecutor(exec)).Columns("id", "sensor").Where(Gt("id", 0)).
+		DeclareCursor(ctx, tx, "readings_export", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []int64
+	for {
+		batch, err := cur.Fetch(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(batch) == 0 {
+			break
+		}
+		for _, r := range batch {
+			ids = append(ids, r.ID)
+		}
+	}
+	if err := cur.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(ids, []int64{1, 2, 3}) {
+		t.Errorf("ids = %v", ids)
+	}
+	want := []string{
+		"DECLARE readings_export NO SCROLL CURSOR FOR SELECT id, sensor FROM scan_reading WHERE id > $1",
+		"FETCH FORWARD 2 FROM readings_export",
+		"FETCH FORWARD 2 FROM readings_export",
+		"CLOSE readings_export",
+	}
+	if !reflect.DeepEqual(exec.sql, want) {
+		t.Errorf("SQL = %q, want %q", exec.sql, want)
+	}
+
+	if _, err := Select[ScanReading](NewWithExecutor(exec)).DeclareCursor(ctx, tx, "x; DROP TABLE t", 10); !errors.Is(err, ErrInvalidIdentifier) {
+		t.Errorf("expected ErrInvalidIdentifier, got %v", err)
+	}
+}
@@ -1,18 +1,73 @@
 package builder
 
 import (
+	"context"
+
+	"github.com/marshallshelly/pebble-orm/pkg/cache"
 	"github.com/marshallshelly/pebble-orm/pkg/registry"
 	"github.com/marshallshelly/pebble-orm/pkg/runtime"
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
 )
 
 // DB wraps runtime.DB and provides query builder methods.
 type DB struct {
-	db *runtime.DB
+	db       *runtime.DB
+	executor Executor // runs every statement instead of db, see NewWithExecutor
+	schema   string   // default schema for models without a // schema: directive
+	config   *Config  // logging and exec mode, see WithConfig
+	replicas []*runtime.DB
+	balancer LoadBalancer  // picks the replica for each read, see WithLoadBalancer
+	cache    cache.Cache   // results of Cached queries, see WithCache
+	readOnly bool          // writes fail with ErrReadOnly, see NewReadOnly
+	resolver TableResolver // physical table of each query, see WithTableResolver
+	related  TableResolver // resolver for preloaded tables, kept once resolver is applied
+}
+
+// New creates a new query builder DB from a runtime DB. SELECTs outside
+// transactions go to replicas, when given, and everything else to db.
+// Usage: qb := builder.New(primary, replica1, replica2)
+func New(db *runtime.DB, replicas ...*runtime.DB) *DB {
+	return &DB{db: db, replicas: replicas, balancer: RoundRobin()}
+}
+
+// NewWithExecutor creates a query builder DB that runs every statement on
+// exec instead of a connection pool, such as a fake in unit tests. It has no
+// runtime DB: planner hints are ignored, PrepareAll does nothing, and Begin
+// works only when exec has a BeginTx method like *runtime.DB.
+// Usage: qb := builder.NewWithExecutor(pebbletest.NewMockDB())
+func NewWithExecutor(exec Executor) *DB {
+	return &DB{executor: exec, balancer: RoundRobin()}
+}
+
+// ForTenant returns a DB for tenantID's database, opened by m.
+// Usage: qb, err := builder.ForTenant(ctx, tenants, tenantID)
+func ForTenant(ctx context.Context, m *runtime.Manager, tenantID string) (*DB, error) {
+	db, err := m.DB(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return New(db), nil
 }
 
-// New creates a new query builder DB from a runtime DB.
-func New(db *runtime.DB) *DB {
-	return &DB{db: db}
+// WithSchema returns a copy of the DB whose queries qualify tables with the
+// given PostgreSQL schema, e.g. analytics.events. Models that declare their
+// own schema with a // schema: directive keep it.
+// Usage: analytics := db.WithSchema("analytics")
+func (d *DB) WithSchema(name string) *DB {
+	c := *d
+	c.schema = name
+	return &c
+}
+
+// inSchema returns table qualified with schemaName when the model does not
+// declare a schema of its own. Registered metadata is never mutated.
+func inSchema(table *schema.TableMetadata, schemaName string) *schema.TableMetadata {
+	if table == nil || schemaName == "" || table.Schema != "" {
+		return table
+	}
+	qualified := *table
+	qualified.Schema = schemaName
+	return &qualified
 }
 
 // Runtime returns the underlying runtime.DB.
@@ -38,8 +93,7 @@ func Select[T any](d *DB) *SelectQuery[T] {
 
 	return &SelectQuery[T]{
 		db:       d,
-		table:    table,
-		columns:  []string{"*"}, // Default to all columns
+		table:    inSchema(table, d.schema),
 		where:    make([]Condition, 0),
 		joins:    make([]Join, 0),
 		groupBy:  make([]string, 0),
@@ -64,7 +118,7 @@ func Insert[T any](d *DB) *InsertQuery[T] {
 
 	return &InsertQuery[T]{
 		db:        d,
-		table:     table,
+		table:     inSchema(table, d.schema),
 		values:    make([]T, 0),
 		returning: make([]string, 0),
 	}
@@ -85,7 +139,7 @@ func Update[T any](d *DB) *UpdateQuery[T] {
 
 	return &UpdateQuery[T]{
 		db:        d,
-		table:     table,
+		table:     inSchema(table, d.schema),
 		sets:      make(map[string]interface{}),
 		where:     make([]Condition, 0),
 		returning: make([]string, 0),
@@ -107,7 +161,7 @@ func Delete[T any](d *DB) *DeleteQuery[T] {
 
 	return &DeleteQuery[T]{
 		db:        d,
-		table:     table,
+		table:     inSchema(table, d.schema),
 		where:     make([]Condition, 0),
 		returning: make([]string, 0),
 	}
@@ -0,0 +1,75 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrTimeout is returned, wrapping the driver's error, when a statement fails
+// because its context's deadline passed or the connection timed out. A
+// canceled context isn't a timeout; errors.Is(err, context.Canceled) reports
+// those.
+var ErrTimeout = errors.New("query timed out")
+
+// timeoutErr marks err as ErrTimeout if it was caused by a timeout.
+func timeoutErr(err error) error {
+	if err == nil || errors.Is(err, ErrTimeout) {
+		return err
+	}
+	if pgconn.Timeout(err) || errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %w", ErrTimeout, err)
+	}
+	return err
+}
+
+// withDeadline returns ctx bounded by deadline, or ctx itself when deadline
+// is zero.
+func withDeadline(ctx context.Context, deadline time.Time) (context.Context, context.CancelFunc) {
+	if deadline.IsZero() {
+		return ctx, func() {}
+	}
+	return context.WithDeadline(ctx, deadline)
+}
+
+// WithDeadline bounds the query by deadline, on top of any deadline of the
+// context it runs with. Once the deadline passes the statement is canceled
+// and returns an error matching ErrTimeout.
+// Usage: builder.Select[User](qb).WithDeadline(time.Now().Add(2 * time.Second)).All(ctx)
+func (q *SelectQuery[T]) WithDeadline(deadline time.Time) *SelectQuery[T] {
+	q.deadline = deadline
+	return q
+}
+
+// WithDeadline bounds the INSERT by deadline, as SelectQuery.WithDeadline.
+func (q *InsertQuery[T]) WithDeadline(deadline time.Time) *InsertQuery[T] {
+	q.deadline = deadline
+	return q
+}
+
+// WithDeadline bounds the UPDATE by deadline, as SelectQuery.WithDeadline.
+func (q *UpdateQuery[T]) WithDeadline(deadline time.Time) *UpdateQuery[T] {
+	q.deadline = deadline
+	return q
+}
+
+// WithDeadline bounds the DELETE by deadline, as SelectQuery.WithDeadline.
+func (q *DeleteQuery[T]) WithDeadline(deadline time.Time) *DeleteQuery[T] {
+	q.deadline = deadline
+	return q
+}
+
+// WithDeadline bounds the UPDATE by deadline, as SelectQuery.WithDeadline.
+func (q *BulkUpdateQuery[T]) WithDeadline(deadline time.Time) *BulkUpdateQuery[T] {
+	q.deadline = deadline
+	return q
+}
+
+// WithDeadline bounds the TRUNCATE by deadline, as SelectQuery.WithDeadline.
+func (q *TruncateQuery[T]) WithDeadline(deadline time.Time) *TruncateQuery[T] {
+	q.deadline = deadline
+	return q
+}
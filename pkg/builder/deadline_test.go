@@ -0,0 +1,62 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestTimeoutErr(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		timeout bool
+	}{
+		{"nil", nil, false},
+		{"deadline exceeded", fmt.Errorf("failed to connect: %w", context.DeadlineExceeded), true},
+		{"canceled", context.Canceled, false},
+		{"other", errors.New("syntax error"), false},
+	}
+	for _, tt := range tests {
+		err := timeoutErr(tt.err)
+		if got := errors.Is(err, ErrTimeout); got != tt.timeout {
+			t.Errorf("%s: errors.Is(err, ErrTimeout) = %v, want %v", tt.name, got, tt.timeout)
+		}
+		if tt.err != nil && !errors.Is(err, tt.err) {
+			t.Errorf("%s: %v no longer wraps %v", tt.name, err, tt.err)
+		}
+	}
+	if err := timeoutErr(timeoutErr(context.DeadlineExceeded)); err.Error() != "query timed out: context deadline exceeded" {
+		t.Errorf("wrapped twice: %v", err)
+	}
+}
+
+func TestExecutorTimeouts(t *testing.T) {
+	ctx := context.Background()
+	exec := withConfig(stubExecutor{err: context.DeadlineExceeded}, nil)
+	if _, err := exec.Exec(ctx, "SELECT pg_sleep(10)"); !errors.Is(err, ErrTimeout) {
+		t.Errorf("Exec: err = %v, want ErrTimeout", err)
+	}
+	if _, err := exec.Query(ctx, "SELECT pg_sleep(10)"); !errors.Is(err, ErrTimeout) {
+		t.Errorf("Query: err = %v, want ErrTimeout", err)
+	}
+	if err := exec.QueryRow(ctx, "SELECT pg_sleep(10)").Scan(); !errors.Is(err, ErrTimeout) {
+		t.Errorf("QueryRow: err = %v, want ErrTimeout", err)
+	}
+}
+
+func TestWithDeadline(t *testing.T) {
+	ctx, cancel := withDeadline(context.Background(), time.Time{})
+	cancel()
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("zero deadline bounded the context")
+	}
+	deadline := time.Now().Add(time.Minute)
+	ctx, cancel = withDeadline(context.Background(), deadline)
+	defer cancel()
+	if got, ok := ctx.Deadline(); !ok || !got.Equal(deadline) {
+		t.Errorf("Deadline() = %v, %v; want %v", got, ok, deadline)
+	}
+}
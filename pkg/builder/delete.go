@@ -4,6 +4,14 @@ import (
 	"context"
 )
 
+// Unscoped drops the model's default scope (// default_scope: ...) from
+// the query, to reach rows it filters out such as soft-deleted ones.
+// Usage: builder.Delete[T](qb).Unscoped().Where(...)
+func (q *DeleteQuery[T]) Unscoped() *DeleteQuery[T] {
+	q.unscoped = true
+	return q
+}
+
 // Where adds a WHERE condition to the DELETE query.
 func (q *DeleteQuery[T]) Where(condition Condition) *DeleteQuery[T] {
 	q.where = append(q.where, condition)
@@ -24,30 +32,44 @@ func (q *DeleteQuery[T]) Or(condition Condition) *DeleteQuery[T] {
 
 // Returning specifies columns to return after delete.
 func (q *DeleteQuery[T]) Returning(columns ...string) *DeleteQuery[T] {
-	q.returning = columns
+	q.returning = quoteAll(columns, quoteSelectItem, &q.err)
 	return q
 }
 
 // ToSQL generates the DELETE SQL and arguments.
 func (q *DeleteQuery[T]) ToSQL() (string, []interface{}, error) {
+	if q.err != nil {
+		return "", nil, q.err
+	}
 	return buildDeleteSQL(deleteSpec{
 		table:     q.table,
-		where:     q.where,
+		where:     scoped(q.table, q.where, q.unscoped),
 		returning: q.returning,
 	})
 }
 
 // Exec executes the DELETE query and returns the number of affected rows.
 func (q *DeleteQuery[T]) Exec(ctx context.Context) (int64, error) {
+	q, err := q.resolve(ctx)
+	if err != nil {
+		return 0, err
+	}
 	sql, args, err := q.ToSQL()
 	if err != nil {
 		return 0, err
 	}
-	return execWrite(ctx, q.db.db, sql, args, len(q.returning) > 0)
+	qctx, cancel := withDeadline(ctx, q.deadline)
+	defer cancel()
+	n, err := execWrite(qctx, q.db.writeExec(), sql, args, len(q.returning) > 0)
+	return afterWrite(ctx, q.db, q.table, n, err)
 }
 
 // ExecReturning executes the DELETE and returns the deleted rows.
 func (q *DeleteQuery[T]) ExecReturning(ctx context.Context) ([]T, error) {
+	q, err := q.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
 	if len(q.returning) == 0 {
 		q.Returning("*")
 	}
@@ -55,5 +77,30 @@ func (q *DeleteQuery[T]) ExecReturning(ctx context.Context) ([]T, error) {
 	if err != nil {
 		return nil, err
 	}
-	return queryRows[T](ctx, q.db.db, q.table, sql, args, nil)
+	qctx, cancel := withDeadline(ctx, q.deadline)
+	defer cancel()
+	rows, err := queryRows[T](qctx, q.db.writeExec(), q.table, sql, args, nil, q.db.relatedTables())
+	return afterWrite(ctx, q.db, q.table, rows, err)
+}
+
+// ExecReturningInto executes the DELETE and scans the returned rows into
+// dest, a pointer to a slice of structs with some of T's fields or, with one
+// Returning column, of single values. It returns the number of rows.
+// Usage: var ids []int64; n, err := builder.Delete[User](qb).Where(...).Returning("id").ExecReturningInto(ctx, &ids)
+func (q *DeleteQuery[T]) ExecReturningInto(ctx context.Context, dest interface{}) (int64, error) {
+	q, err := q.resolve(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if len(q.returning) == 0 {
+		q.Returning("*")
+	}
+	sql, args, err := q.ToSQL()
+	if err != nil {
+		return 0, err
+	}
+	qctx, cancel := withDeadline(ctx, q.deadline)
+	defer cancel()
+	n, err := scanReturning(qctx, q.db.writeExec(), q.table, sql, args, dest)
+	return afterWrite(ctx, q.db, q.table, n, err)
 }
@@ -0,0 +1,103 @@
+package builder
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/marshallshelly/pebble-orm/pkg/registry"
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+)
+
+type EmbedAddress struct {
+	Street string `po:"street,text,notNull"`
+	City   string `po:"city,text"`
+}
+
+type EmbedTimestamps struct {
+	Version int `po:"version,integer,notNull"`
+}
+
+type EmbedOrder struct {
+	ID              int          `po:"id,primaryKey,serial"`
+	Billing         EmbedAddress `po:"embedded,prefix(billing_)"`
+	Shipping        EmbedAddress `po:"embedded,prefix(shipping_)"`
+	EmbedTimestamps `po:"embedded"`
+}
+
+func TestInsertQuery_EmbeddedStructs(t *testing.T) {
+	if err := registry.Register(EmbedOrder{}); err != nil {
+		t.Fatalf("Failed to register model: %v", err)
+	}
+
+	order := EmbedOrder{
+		Billing:         EmbedAddress{Street: "1 Main St", City: "Springfield"},
+		Shipping:        EmbedAddress{Street: "2 Side St", City: "Shelbyville"},
+		EmbedTimestamps: EmbedTimestamps{Version: 3},
+	}
+
+	sql, args, err := Insert[EmbedOrder](New(nil)).Values(order).ToSQL()
+	if err != nil {
+		t.Fatalf("ToSQL() error = %v", err)
+	}
+
+	wantSQL := "INSERT INTO embed_order (billing_street, billing_city, shipping_street, shipping_city, version) VALUES ($1, $2, $3, $4, $5)"
+	if sql != wantSQL {
+		t.Errorf("ToSQL() sql = %v, want %v", sql, wantSQL)
+	}
+	wantArgs := []interface{}{"1 Main St", "Springfield", "2 Side St", "Shelbyville", 3}
+	if !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("ToSQL() args = %v, want %v", args, wantArgs)
+	}
+}
+
+// EmbedOrderRef holds EmbedOrder's embedded columns behind a pointer.
+type EmbedOrderRef struct {
+	ID int
+	*EmbedTimestamps
+}
+
+func TestScanPlan_EmbeddedPointer(t *testing.T) {
+	orders, err := registry.GetOrRegister(EmbedOrder{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	users, err := registry.GetOrRegister(TestUser{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		table *schema.TableMetadata
+		dest  interface{}
+		cols  []string
+	}{
+		{"embedded path", orders, &EmbedOrderRef{}, []string{"id", "version"}},
+		{"promoted field", users, &struct{ *TestUser }{}, []string{"id", "name"}},
+	}
+	for _, tt := range tests {
+		err := scanIntoStruct(newFakeRows(tt.cols), tt.dest, tt.table)
+		if err == nil || !strings.Contains(err.Error(), "struct pointer") {
+			t.Errorf("%s: err = %v, want an error for a field behind a struct pointer", tt.name, err)
+		}
+	}
+}
+
+func TestFieldByPath(t *testing.T) {
+	var order EmbedOrder
+	v := reflect.ValueOf(&order).Elem()
+
+	fieldByPath(v, "Shipping.City").SetString("Shelbyville")
+	if order.Shipping.City != "Shelbyville" {
+		t.Errorf("Shipping.City = %q, want %q", order.Shipping.City, "Shelbyville")
+	}
+
+	fieldByPath(v, "EmbedTimestamps.Version").SetInt(7)
+	if order.Version != 7 {
+		t.Errorf("Version = %d, want 7", order.Version)
+	}
+
+	if fieldByPath(v, "Shipping.Missing").IsValid() {
+		t.Error("expected an invalid value for an unknown field")
+	}
+}
@@ -0,0 +1,191 @@
+package builder
+
+import (
+	"bufio"
+	"context"
+	"database/sql/driver"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"time"
+	"unsafe"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// EncodeJSON runs the query and writes its rows to w as a JSON array, each
+// row encoded as encoding/json encodes T. Rows are written as they arrive
+// and never collected, so exports of any size run in constant memory;
+// preloads and the result cache don't apply, and the query isn't retried.
+// It returns the number of rows written.
+// Usage: n, err := builder.Select[Order](qb).Where(builder.Eq("user_id", id)).EncodeJSON(ctx, w)
+func (q *SelectQuery[T]) EncodeJSON(ctx context.Context, w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	if _, err := bw.WriteString("["); err != nil {
+		return 0, err
+	}
+	var n int64
+	err := q.stream(ctx, nil, func(item *T) error {
+		data, err := json.Marshal(item)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			_ = bw.WriteByte(',')
+		}
+		n++
+		_, err = bw.Write(data)
+		return err
+	})
+	if err != nil {
+		return n, err
+	}
+	if _, err := bw.WriteString("]\n"); err != nil {
+		return n, err
+	}
+	return n, bw.Flush()
+}
+
+// EncodeCSV runs the query and writes its rows to w as CSV: a header of the
+// selected columns that map to fields of T, then a record per row. Nil
+// values are empty, times are RFC 3339 and JSONB, array and struct fields
+// are JSON. Like EncodeJSON it streams, and returns the number of rows
+// written.
+// Usage: n, err := builder.Select[User](qb).Columns("id", "email", "created_at").EncodeCSV(ctx, w)
+func (q *SelectQuery[T]) EncodeCSV(ctx context.Context, w io.Writer) (int64, error) {
+	cw := csv.NewWriter(w)
+	var columns []*columnScan
+	var header, record []string
+	var n int64
+	err := q.stream(ctx, func(plan *scanPlan, fields []pgconn.FieldDescription) error {
+		for i := range plan.columns {
+			if plan.columns[i].mode != scanSkip {
+				columns = append(columns, &plan.columns[i])
+				header = append(header, fields[i].Name)
+			}
+		}
+		record = make([]string, len(columns))
+		return cw.Write(header)
+	}, func(item *T) error {
+		for i, c := range columns {
+			value, err := csvValue(c.field(unsafe.Pointer(item)))
+			if err != nil {
+				return fmt.Errorf("column %s: %w", header[i], err)
+			}
+			record[i] = value
+		}
+		n++
+		return cw.Write(record)
+	})
+	cw.Flush()
+	if err == nil {
+		err = cw.Error()
+	}
+	return n, err
+}
+
+// stream runs the query and scans its rows one at a time into the same
+// value, calling start with the scan plan before the first row and each
+// with every row.
+func (q *SelectQuery[T]) stream(ctx context.Context, start func(plan *scanPlan, fields []pgconn.FieldDescription) error, each func(item *T) error) error {
+	typ := reflect.TypeFor[T]()
+	if typ.Kind() != reflect.Struct {
+		return fmt.Errorf("dest must be a pointer to struct")
+	}
+	q, err := q.resolve(ctx)
+	if err != nil {
+		return err
+	}
+	sql, args, err := q.ToSQL()
+	if err != nil {
+		return err
+	}
+	// Rows already written can't be taken back, so there is no retry.
+	return q.readOnce(ctx, false, func(ctx context.Context, exec Executor) error {
+		rows, err := exec.Query(ctx, sql, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		plan := scanPlanFor(typ, q.table, rows.FieldDescriptions())
+		if plan.err != nil {
+			return plan.err
+		}
+		if start != nil {
+			if err := start(plan, rows.FieldDescriptions()); err != nil {
+				return err
+			}
+		}
+		var item, zero T
+		for rows.Next() {
+			item = zero
+			if err := plan.scan(rows, unsafe.Pointer(&item)); err != nil {
+				return err
+			}
+			if err := each(&item); err != nil {
+				return err
+			}
+		}
+		return rows.Err()
+	})
+}
+
+// csvValue formats a field for a CSV record.
+func csvValue(v reflect.Value) (string, error) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return "", nil
+		}
+		v = v.Elem()
+	}
+	value := v.Interface()
+	if valuer, ok := value.(driver.Valuer); ok {
+		dv, err := valuer.Value()
+		if err != nil {
+			return "", err
+		}
+		switch dv := dv.(type) {
+		case nil:
+			return "", nil
+		case string:
+			return dv, nil
+		case []byte:
+			return string(dv), nil
+		case time.Time:
+			return dv.Format(time.RFC3339Nano), nil
+		default:
+			return fmt.Sprint(dv), nil
+		}
+	}
+	switch value := value.(type) {
+	case time.Time:
+		return value.Format(time.RFC3339Nano), nil
+	case []byte:
+		return string(value), nil
+	case fmt.Stringer:
+		return value.String(), nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), nil
+	case reflect.Map, reflect.Slice:
+		if v.IsNil() {
+			return "", nil
+		}
+	}
+	data, err := json.Marshal(value)
+	return string(data), err
+}
@@ -0,0 +1,65 @@
+package builder
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// rowsExecutor serves rows to every query.
+type rowsExecutor struct {
+	stubExecutor
+	rows *fakeRows
+}
+
+func (e rowsExecutor) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	return e.rows, nil
+}
+
+func TestEncode(t *testing.T) {
+	ctx := context.Background()
+	columns := []string{"id", "sensor", "value", "labels"}
+	readings := func() *fakeRows {
+		return newFakeRows(columns,
+			[]interface{}{int64(1), "t1", 21.5, []byte(`{"room":"lab"}`)},
+			[]interface{}{int64(2), "roof, north", -4.0, []byte(`null`)},
+		)
+	}
+
+	t.Run("json", func(t *testing.T) {
+		var b strings.Builder
+		n, err := Select[ScanReading](NewWithExecutor(rowsExecutor{rows: readings()})).EncodeJSON(ctx, &b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := `[{"ID":1,"Sensor":"t1","Value":21.5,"Labels":{"room":"lab"},"Version":0},` +
+			`{"ID":2,"Sensor":"roof, north","Value":-4,"Labels":null,"Version":0}]` + "\n"
+		if n != 2 || b.String() != want {
+			t.Errorf("n = %d, JSON = %s, want %s", n, b.String(), want)
+		}
+	})
+
+	t.Run("empty json", func(t *testing.T) {
+		var b strings.Builder
+		if _, err := Select[ScanReading](NewWithExecutor(rowsExecutor{rows: newFakeRows(columns)})).EncodeJSON(ctx, &b); err != nil {
+			t.Fatal(err)
+		}
+		if b.String() != "[]\n" {
+			t.Errorf("JSON = %q", b.String())
+		}
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		var b strings.Builder
+		n, err := Select[ScanReading](NewWithExecutor(rowsExecutor{rows: readings()})).EncodeCSV(ctx, &b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := "id,sensor,value,labels\n1,t1,21.5,\"{\"\"room\"\":\"\"lab\"\"}\"\n2,\"roof, north\",-4,\n"
+		if n != 2 || b.String() != want {
+			t.Errorf("n = %d, CSV = %q, want %q", n, b.String(), want)
+		}
+	})
+}
@@ -0,0 +1,178 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// SQLSTATE codes of the constraint violations reported as typed errors.
+const (
+	notNullViolation    = "23502"
+	foreignKeyViolation = "23503"
+	uniqueViolation     = "23505"
+	checkViolation      = "23514"
+)
+
+// ErrUniqueViolation is returned when a write would duplicate a value of a
+// unique index or constraint.
+type ErrUniqueViolation struct {
+	Table      string
+	Constraint string
+	Columns    []string // key columns, from the error detail
+	Err        *pgconn.PgError
+}
+
+func (e *ErrUniqueViolation) Error() string { return e.Err.Error() }
+func (e *ErrUniqueViolation) Unwrap() error { return e.Err }
+
+// ErrForeignKeyViolation is returned when a write references a row that
+// doesn't exist, or deletes or changes a row that is still referenced.
+type ErrForeignKeyViolation struct {
+	Table      string
+	Constraint string
+	Columns    []string // key columns, from the error detail
+	Err        *pgconn.PgError
+}
+
+func (e *ErrForeignKeyViolation) Error() string { return e.Err.Error() }
+func (e *ErrForeignKeyViolation) Unwrap() error { return e.Err }
+
+// ErrCheckViolation is returned when a row fails a CHECK constraint.
+type ErrCheckViolation struct {
+	Table      string
+	Constraint string
+	Err        *pgconn.PgError
+}
+
+func (e *ErrCheckViolation) Error() string { return e.Err.Error() }
+func (e *ErrCheckViolation) Unwrap() error { return e.Err }
+
+// ErrNotNullViolation is returned when a write leaves a NOT NULL column
+// null.
+type ErrNotNullViolation struct {
+	Table  string
+	Column string
+	Err    *pgconn.PgError
+}
+
+func (e *ErrNotNullViolation) Error() string { return e.Err.Error() }
+func (e *ErrNotNullViolation) Unwrap() error { return e.Err }
+
+// IsUniqueViolation reports whether err is a unique violation of one of the
+// named constraints, or of any constraint if none are named.
+// Usage: if builder.IsUniqueViolation(err, "users_email_key") { ... }
+func IsUniqueViolation(err error, constraints ...string) bool {
+	var e *ErrUniqueViolation
+	return errors.As(err, &e) && matchesAny(e.Constraint, constraints)
+}
+
+// IsForeignKeyViolation reports whether err is a foreign key violation of one
+// of the named constraints, or of any constraint if none are named.
+func IsForeignKeyViolation(err error, constraints ...string) bool {
+	var e *ErrForeignKeyViolation
+	return errors.As(err, &e) && matchesAny(e.Constraint, constraints)
+}
+
+// IsCheckViolation reports whether err is a violation of one of the named
+// CHECK constraints, or of any if none are named.
+func IsCheckViolation(err error, constraints ...string) bool {
+	var e *ErrCheckViolation
+	return errors.As(err, &e) && matchesAny(e.Constraint, constraints)
+}
+
+// IsNotNullViolation reports whether err is a NOT NULL violation of one of
+// the named columns, or of any column if none are named.
+func IsNotNullViolation(err error, columns ...string) bool {
+	var e *ErrNotNullViolation
+	return errors.As(err, &e) && matchesAny(e.Column, columns)
+}
+
+func matchesAny(name string, names []string) bool {
+	return len(names) == 0 || slices.Contains(names, name)
+}
+
+// constraintErr returns err as one of the constraint violation types if it
+// is one.
+func constraintErr(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+	switch pgErr.Code {
+	case uniqueViolation:
+		return &ErrUniqueViolation{Table: pgErr.TableName, Constraint: pgErr.ConstraintName, Columns: keyColumns(pgErr.Detail), Err: pgErr}
+	case foreignKeyViolation:
+		return &ErrForeignKeyViolation{Table: pgErr.TableName, Constraint: pgErr.ConstraintName, Columns: keyColumns(pgErr.Detail), Err: pgErr}
+	case checkViolation:
+		return &ErrCheckViolation{Table: pgErr.TableName, Constraint: pgErr.ConstraintName, Err: pgErr}
+	case notNullViolation:
+		return &ErrNotNullViolation{Table: pgErr.TableName, Column: pgErr.ColumnName, Err: pgErr}
+	}
+	return err
+}
+
+// reKeyDetail matches the key in a violation's detail, such as
+// Key (email)=(a@example.com) already exists.
+var reKeyDetail = regexp.MustCompile(`^Key \((.+?)\)=\(`)
+
+// keyColumns returns the key columns named in a violation's detail, nil if
+// it names none.
+func keyColumns(detail string) []string {
+	m := reKeyDetail.FindStringSubmatch(detail)
+	if m == nil {
+		return nil
+	}
+	columns := strings.Split(m[1], ", ")
+	for i, col := range columns {
+		columns[i] = strings.Trim(col, `"`)
+	}
+	return columns
+}
+
+// driverErr returns err as the builder reports it: timeouts match
+// ErrTimeout and constraint violations are typed.
+func driverErr(err error) error {
+	if err == nil {
+		return nil
+	}
+	return constraintErr(timeoutErr(err))
+}
+
+// errorExecutor is an Executor that reports errors as driverErr does,
+// whether they end the statement or a later read of its rows.
+type errorExecutor struct{ next Executor }
+
+func (e errorExecutor) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	rows, err := e.next.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, driverErr(err)
+	}
+	return errorRows{rows}, nil
+}
+
+func (e errorExecutor) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	return errorRow{e.next.QueryRow(ctx, sql, args...)}
+}
+
+func (e errorExecutor) Exec(ctx context.Context, sql string, args ...interface{}) (int64, error) {
+	n, err := e.next.Exec(ctx, sql, args...)
+	return n, driverErr(err)
+}
+
+type errorRows struct{ pgx.Rows }
+
+func (r errorRows) Err() error {
+	return driverErr(r.Rows.Err())
+}
+
+type errorRow struct{ row pgx.Row }
+
+func (r errorRow) Scan(dest ...interface{}) error {
+	return driverErr(r.row.Scan(dest...))
+}
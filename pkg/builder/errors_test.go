@@ -0,0 +1,85 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestConstraintErr(t *testing.T) {
+	unique := &pgconn.PgError{
+		Code: "23505", TableName: "users", ConstraintName: "users_email_key",
+		Detail: "Key (email)=(ann@example.com) already exists.",
+	}
+	err := constraintErr(fmt.Errorf("insert failed: %w", unique))
+	var uv *ErrUniqueViolation
+	if !errors.As(err, &uv) {
+		t.Fatalf("err = %T, want *ErrUniqueViolation", err)
+	}
+	if uv.Table != "users" || uv.Constraint != "users_email_key" || !slices.Equal(uv.Columns, []string{"email"}) {
+		t.Errorf("got %+v", uv)
+	}
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr != unique {
+		t.Error("violation doesn't wrap the PgError")
+	}
+
+	tests := []struct {
+		name string
+		err  *pgconn.PgError
+		is   func(error) bool
+	}{
+		{"unique", unique, func(err error) bool { return IsUniqueViolation(err, "users_email_key") }},
+		{"unique any", unique, func(err error) bool { return IsUniqueViolation(err) }},
+		{"foreign key", &pgconn.PgError{Code: "23503", ConstraintName: "products_category_id_fkey"},
+			func(err error) bool { return IsForeignKeyViolation(err, "products_category_id_fkey") }},
+		{"check", &pgconn.PgError{Code: "23514", ConstraintName: "price_positive"},
+			func(err error) bool { return IsCheckViolation(err, "price_positive") }},
+		{"not null", &pgconn.PgError{Code: "23502", ColumnName: "name"},
+			func(err error) bool { return IsNotNullViolation(err, "name") }},
+	}
+	for _, tt := range tests {
+		if !tt.is(constraintErr(tt.err)) {
+			t.Errorf("%s: not reported", tt.name)
+		}
+	}
+
+	if IsUniqueViolation(constraintErr(unique), "users_name_key") {
+		t.Error("matched another constraint")
+	}
+	if IsForeignKeyViolation(constraintErr(unique)) {
+		t.Error("unique violation reported as a foreign key violation")
+	}
+	other := &pgconn.PgError{Code: "42601"}
+	if err := constraintErr(other); err != other {
+		t.Errorf("syntax error became %T", err)
+	}
+}
+
+func TestKeyColumns(t *testing.T) {
+	tests := []struct {
+		detail string
+		want   []string
+	}{
+		{"Key (email)=(ann@example.com) already exists.", []string{"email"}},
+		{`Key (tenant_id, "order")=(1, 2) already exists.`, []string{"tenant_id", "order"}},
+		{`Key (category_id)=(1) is still referenced from table "products".`, []string{"category_id"}},
+		{"Failing row contains (1, null).", nil},
+	}
+	for _, tt := range tests {
+		if got := keyColumns(tt.detail); !slices.Equal(got, tt.want) {
+			t.Errorf("keyColumns(%q) = %q, want %q", tt.detail, got, tt.want)
+		}
+	}
+}
+
+func TestExecutorConstraintErrors(t *testing.T) {
+	exec := withConfig(stubExecutor{err: &pgconn.PgError{Code: "23505", ConstraintName: "users_email_key"}}, nil)
+	if _, err := exec.Exec(context.Background(), "INSERT INTO users (email) VALUES ($1)", "a"); !IsUniqueViolation(err, "users_email_key") {
+		t.Errorf("Exec: err = %v, want a unique violation", err)
+	}
+}
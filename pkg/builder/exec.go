@@ -3,23 +3,27 @@ package builder
 import (
 	"context"
 	"fmt"
+	"maps"
+	"reflect"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/marshallshelly/pebble-orm/pkg/schema"
 )
 
-// queryExecutor is the shared execution surface used by every query builder.
+// Executor is the shared execution surface used by every query builder.
 // It is satisfied by *runtime.DB (the pool) directly and by pgx.Tx through
 // txExecutor, so the SELECT/INSERT/UPDATE/DELETE build and execution logic is
 // written once and reused by both the connection-pool and transaction paths.
-type queryExecutor interface {
+type Executor interface {
 	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
 	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
 	Exec(ctx context.Context, sql string, args ...interface{}) (int64, error)
 }
 
-// txExecutor adapts a pgx.Tx to queryExecutor, converting the CommandTag from
+// txExecutor adapts a pgx.Tx to Executor, converting the CommandTag from
 // Exec into the affected-row count the builders expose.
 type txExecutor struct{ tx pgx.Tx }
 
@@ -45,6 +49,9 @@ type selectSpec struct {
 	table     *schema.TableMetadata
 	distinct  bool
 	columns   []string
+	colArgs   []interface{} // bound by SelectExpr columns, numbered from $1
+	sample    string        // TABLESAMPLE clause
+	asOf      *time.Time    // read the table's history as of then
 	joins     []Join
 	where     []Condition
 	groupBy   []string
@@ -55,29 +62,46 @@ type selectSpec struct {
 	forUpdate bool
 }
 
-// buildSelectSQL assembles a SELECT statement with sequential placeholder
+// generateSelectSQL assembles a SELECT statement with sequential placeholder
 // numbering across JOIN, WHERE and HAVING clauses.
-func buildSelectSQL(s selectSpec) (string, []interface{}, error) {
+func generateSelectSQL(s selectSpec) (string, []interface{}, error) {
 	if s.table == nil {
 		return "", nil, fmt.Errorf("table metadata not available")
 	}
 
 	var sql strings.Builder
-	var args []interface{}
-	paramNum := 1
+	args := slices.Clone(s.colArgs)
+	paramNum := 1 + len(args)
 
 	sql.WriteString("SELECT ")
 	if s.distinct {
 		sql.WriteString("DISTINCT ")
 	}
 	if len(s.columns) == 0 || (len(s.columns) == 1 && s.columns[0] == "*") {
-		sql.WriteString("*")
+		if len(s.joins) > 0 {
+			sql.WriteString("*")
+		} else {
+			sql.WriteString(selectStar(s.table))
+		}
 	} else {
 		sql.WriteString(strings.Join(s.columns, ", "))
 	}
 
 	sql.WriteString(" FROM ")
-	sql.WriteString(schema.QuoteReservedIdent(s.table.Name))
+	if s.asOf != nil {
+		if s.sample != "" || s.forUpdate {
+			return "", nil, fmt.Errorf("AsOf cannot be combined with TableSample or ForUpdate")
+		}
+		sql.WriteString(historyFrom(s.table, paramNum))
+		args = append(args, *s.asOf)
+		paramNum++
+	} else {
+		sql.WriteString(schema.QuoteQualifiedIdent(s.table.QualifiedName()))
+	}
+	if s.sample != "" {
+		sql.WriteByte(' ')
+		sql.WriteString(s.sample)
+	}
 
 	// JOINs: each join condition's own $1.. are renumbered to the running
 	// parameter position so args never collide across clauses.
@@ -99,18 +123,13 @@ func buildSelectSQL(s selectSpec) (string, []interface{}, error) {
 
 	// WHERE, numbered continuing from join args.
 	if len(s.where) > 0 {
-		wb := NewWhereBuilderWithStart(paramNum)
-		wb.conditions = s.where
-		whereSQL, whereArgs, err := wb.Build()
-		if err != nil {
+		n := len(args)
+		sql.WriteString(" ")
+		var err error
+		if args, err = writeClause(&sql, "WHERE", s.where, paramNum, args); err != nil {
 			return "", nil, fmt.Errorf("failed to build WHERE clause: %w", err)
 		}
-		if whereSQL != "" {
-			sql.WriteString(" ")
-			sql.WriteString(whereSQL)
-			args = append(args, whereArgs...)
-			paramNum += len(whereArgs)
-		}
+		paramNum += len(args) - n
 	}
 
 	if len(s.groupBy) > 0 {
@@ -120,19 +139,11 @@ func buildSelectSQL(s selectSpec) (string, []interface{}, error) {
 
 	// HAVING, numbered continuing from WHERE args.
 	if len(s.having) > 0 {
-		hb := NewWhereBuilderWithStart(paramNum)
-		hb.conditions = s.having
-		havingSQL, havingArgs, err := hb.Build()
-		if err != nil {
+		sql.WriteString(" ")
+		var err error
+		if args, err = writeClause(&sql, "HAVING", s.having, paramNum, args); err != nil {
 			return "", nil, fmt.Errorf("failed to build HAVING clause: %w", err)
 		}
-		if havingSQL != "" {
-			havingSQL = strings.Replace(havingSQL, "WHERE", "HAVING", 1)
-			sql.WriteString(" ")
-			sql.WriteString(havingSQL)
-			args = append(args, havingArgs...)
-			paramNum += len(havingArgs)
-		}
 	}
 
 	if len(s.orderBy) > 0 {
@@ -160,37 +171,118 @@ func buildSelectSQL(s selectSpec) (string, []interface{}, error) {
 	return sql.String(), args, nil
 }
 
-// buildCountSQL assembles a SELECT COUNT(*) statement with an optional WHERE.
-func buildCountSQL(table *schema.TableMetadata, where []Condition) (string, []interface{}, error) {
+// generateCountSQL assembles a SELECT COUNT(*) statement with an optional WHERE.
+func generateCountSQL(table *schema.TableMetadata, where []Condition) (string, []interface{}, error) {
 	if table == nil {
 		return "", nil, fmt.Errorf("table metadata not available")
 	}
 	var sql strings.Builder
 	sql.WriteString("SELECT COUNT(*) FROM ")
-	sql.WriteString(schema.QuoteReservedIdent(table.Name))
+	sql.WriteString(schema.QuoteQualifiedIdent(table.QualifiedName()))
 
 	var args []interface{}
 	if len(where) > 0 {
-		wb := NewWhereBuilder()
-		wb.conditions = where
-		whereSQL, whereArgs, err := wb.Build()
-		if err != nil {
+		sql.WriteString(" ")
+		var err error
+		if args, err = writeClause(&sql, "WHERE", where, 1, nil); err != nil {
 			return "", nil, err
 		}
-		if whereSQL != "" {
-			sql.WriteString(" ")
-			sql.WriteString(whereSQL)
-			args = append(args, whereArgs...)
-		}
 	}
 	return sql.String(), args, nil
 }
 
 type insertSpec struct {
-	table      *schema.TableMetadata
-	rows       []interface{}
-	returning  []string
-	onConflict *OnConflict
+	table       *schema.TableMetadata
+	rows        []interface{}
+	returning   []string
+	onConflict  *OnConflict
+	useDefaults map[string]bool
+}
+
+// checkWritable rejects writes to view models, which are read-only.
+func checkWritable(table *schema.TableMetadata) error {
+	if table.IsView() {
+		return fmt.Errorf("%s is a view and cannot be written to", table.QualifiedName())
+	}
+	return nil
+}
+
+// checkUpdatable returns an error if the model says column is never
+// updated: it is immutable, readOnly or generated. Unknown columns pass.
+func checkUpdatable(table *schema.TableMetadata, column string) error {
+	col := table.GetColumnByName(column)
+	switch {
+	case col == nil || col.Updatable():
+		return nil
+	case col.Generated != nil:
+		return fmt.Errorf("column %s is generated and cannot be updated", column)
+	case col.ReadOnly:
+		return fmt.Errorf("column %s is readOnly and cannot be updated", column)
+	}
+	return fmt.Errorf("column %s is immutable and cannot be updated", column)
+}
+
+// excludedColumns returns the inserted columns an upsert without explicit
+// updates sets from EXCLUDED: all but the conflict target (given quoted) and
+// those the model never updates.
+func excludedColumns(table *schema.TableMetadata, inserted, target []string) []string {
+	var columns []string
+	for _, col := range inserted {
+		if slices.Contains(target, schema.QuoteReservedIdent(col)) || checkUpdatable(table, col) != nil {
+			continue
+		}
+		columns = append(columns, col)
+	}
+	return columns
+}
+
+// selectStar returns the column list of a SELECT *: "*", or the model's
+// columns without its writeOnly ones, which are only read when named.
+func selectStar(table *schema.TableMetadata) string {
+	return strings.Join(returningColumns(table, []string{"*"}), ", ")
+}
+
+// returningColumns returns returning with a bare * replaced by the table's
+// readable columns, so RETURNING * doesn't read writeOnly columns back.
+func returningColumns(table *schema.TableMetadata, returning []string) []string {
+	i := slices.Index(returning, "*")
+	if i < 0 || !slices.ContainsFunc(table.Columns, func(c schema.ColumnMetadata) bool { return c.WriteOnly }) {
+		return returning
+	}
+	var columns []string
+	for _, col := range table.Columns {
+		if !col.WriteOnly {
+			columns = append(columns, schema.QuoteReservedIdent(col.Name))
+		}
+	}
+	return slices.Concat(returning[:i], columns, returning[i+1:])
+}
+
+// groupColumns returns the quoted columns of table in any of groups, in
+// table order. Each group must name at least one column.
+func groupColumns(table *schema.TableMetadata, groups []string) ([]string, error) {
+	if table == nil {
+		return nil, fmt.Errorf("table metadata not available")
+	}
+	var columns []string
+	found := make(map[string]bool, len(groups))
+	for _, col := range table.Columns {
+		in := false
+		for _, group := range col.Groups {
+			if slices.Contains(groups, group) {
+				found[group], in = true, true
+			}
+		}
+		if in {
+			columns = append(columns, schema.QuoteReservedIdent(col.Name))
+		}
+	}
+	for _, group := range groups {
+		if !found[group] {
+			return nil, fmt.Errorf("no columns of %s are in group %q", table.Name, group)
+		}
+	}
+	return columns, nil
 }
 
 // buildInsertSQL assembles a multi-row INSERT. The column list comes from the
@@ -199,42 +291,57 @@ func buildInsertSQL(s insertSpec) (string, []interface{}, error) {
 	if s.table == nil {
 		return "", nil, fmt.Errorf("table metadata not available")
 	}
+	if err := checkWritable(s.table); err != nil {
+		return "", nil, err
+	}
 	if len(s.rows) == 0 {
 		return "", nil, fmt.Errorf("no values to insert")
 	}
+	if err := validateRows(s.rows, s.table); err != nil {
+		return "", nil, err
+	}
 
 	var sql strings.Builder
 	var args []interface{}
 	paramNum := 1
 
 	sql.WriteString("INSERT INTO ")
-	sql.WriteString(schema.QuoteReservedIdent(s.table.Name))
+	sql.WriteString(schema.QuoteQualifiedIdent(s.table.QualifiedName()))
 
-	columns, firstRowValues, err := structToValues(s.rows[0], s.table, true)
+	columns, rows, err := insertRows(s.rows, s.table, true, s.useDefaults)
 	if err != nil {
 		return "", nil, fmt.Errorf("failed to extract values: %w", err)
 	}
 
-	sql.WriteString(" (")
-	sql.WriteString(strings.Join(schema.QuoteReservedIdents(columns), ", "))
-	sql.WriteString(") VALUES ")
-
-	valueClauses := make([]string, len(s.rows))
-	for i, val := range s.rows {
-		var rowValues []interface{}
-		if i == 0 {
-			rowValues = firstRowValues
-		} else {
-			rowValues, err = valuesForColumns(val, s.table, columns)
-			if err != nil {
-				return "", nil, fmt.Errorf("failed to extract values from row %d: %w", i, err)
-			}
+	if len(columns) == 0 && len(rows) > 1 {
+		// DEFAULT VALUES inserts one row; several all-default rows name a
+		// column and insert DEFAULT into it, which any column accepts.
+		columns = []string{s.table.Columns[0].Name}
+		for i := range rows {
+			rows[i] = []interface{}{insertDefault{}}
 		}
+	}
+	if len(columns) == 0 && len(rows) == 1 {
+		// Every column is left to its default
+		sql.WriteString(" DEFAULT VALUES")
+		rows = nil
+	} else {
+		sql.WriteString(" (")
+		sql.WriteString(strings.Join(schema.QuoteReservedIdents(columns), ", "))
+		sql.WriteString(") VALUES ")
+	}
+
+	valueClauses := make([]string, len(rows))
+	for i, rowValues := range rows {
 		placeholders := make([]string, len(rowValues))
-		for j := range rowValues {
+		for j, value := range rowValues {
+			if _, ok := value.(insertDefault); ok {
+				placeholders[j] = "DEFAULT"
+				continue
+			}
 			placeholders[j] = fmt.Sprintf("$%d", paramNum)
 			paramNum++
-			args = append(args, rowValues[j])
+			args = append(args, value)
 		}
 		valueClauses[i] = "(" + strings.Join(placeholders, ", ") + ")"
 	}
@@ -247,27 +354,41 @@ func buildInsertSQL(s insertSpec) (string, []interface{}, error) {
 			sql.WriteString(strings.Join(s.onConflict.Columns, ", "))
 			sql.WriteString(")")
 		}
-		if s.onConflict.Action == DoNothing {
+		excluded := s.onConflict.Excluded
+		if s.onConflict.Action == DoUpdate && len(s.onConflict.Updates) == 0 && len(excluded) == 0 {
+			excluded = excludedColumns(s.table, columns, s.onConflict.Columns)
+		}
+		if s.onConflict.Action == DoNothing || len(s.onConflict.Updates) == 0 && len(excluded) == 0 {
 			sql.WriteString(" DO NOTHING")
 		} else if s.onConflict.Action == DoUpdate {
 			sql.WriteString(" ")
 			sql.WriteString(string(DoUpdate))
-			if len(s.onConflict.Updates) > 0 {
-				updates := make([]string, 0, len(s.onConflict.Updates))
-				for col, val := range s.onConflict.Updates {
-					updates = append(updates, fmt.Sprintf("%s = $%d", schema.QuoteReservedIdent(col), paramNum))
-					paramNum++
-					args = append(args, val)
+			for col := range s.onConflict.Updates {
+				if err := checkUpdatable(s.table, col); err != nil {
+					return "", nil, err
+				}
+			}
+			updates := make([]string, 0, len(excluded)+len(s.onConflict.Updates))
+			for _, col := range excluded {
+				if err := checkUpdatable(s.table, col); err != nil {
+					return "", nil, err
 				}
-				sql.WriteString(" ")
-				sql.WriteString(strings.Join(updates, ", "))
+				quoted := schema.QuoteReservedIdent(col)
+				updates = append(updates, fmt.Sprintf("%s = EXCLUDED.%s", quoted, quoted))
+			}
+			for _, col := range slices.Sorted(maps.Keys(s.onConflict.Updates)) {
+				updates = append(updates, fmt.Sprintf("%s = $%d", schema.QuoteReservedIdent(col), paramNum))
+				paramNum++
+				args = append(args, s.onConflict.Updates[col])
 			}
+			sql.WriteString(" ")
+			sql.WriteString(strings.Join(updates, ", "))
 		}
 	}
 
 	if len(s.returning) > 0 {
 		sql.WriteString(" RETURNING ")
-		sql.WriteString(strings.Join(s.returning, ", "))
+		sql.WriteString(strings.Join(returningColumns(s.table, s.returning), ", "))
 	}
 
 	return sql.String(), args, nil
@@ -285,43 +406,49 @@ func buildUpdateSQL(s updateSpec) (string, []interface{}, error) {
 	if s.table == nil {
 		return "", nil, fmt.Errorf("table metadata not available")
 	}
+	if err := checkWritable(s.table); err != nil {
+		return "", nil, err
+	}
 	if len(s.sets) == 0 {
 		return "", nil, fmt.Errorf("no columns to update")
 	}
+	for col := range s.sets {
+		if err := checkUpdatable(s.table, col); err != nil {
+			return "", nil, err
+		}
+	}
+	if err := validateSets(s.sets, s.table); err != nil {
+		return "", nil, err
+	}
 
 	var sql strings.Builder
 	var args []interface{}
 	paramNum := 1
 
 	sql.WriteString("UPDATE ")
-	sql.WriteString(schema.QuoteReservedIdent(s.table.Name))
+	sql.WriteString(schema.QuoteQualifiedIdent(s.table.QualifiedName()))
 	sql.WriteString(" SET ")
 
+	// Columns in name order, so the same update always produces the same SQL
 	setClauses := make([]string, 0, len(s.sets))
-	for col, val := range s.sets {
+	for _, col := range slices.Sorted(maps.Keys(s.sets)) {
 		setClauses = append(setClauses, fmt.Sprintf("%s = $%d", schema.QuoteReservedIdent(col), paramNum))
-		args = append(args, val)
+		args = append(args, s.sets[col])
 		paramNum++
 	}
 	sql.WriteString(strings.Join(setClauses, ", "))
 
 	if len(s.where) > 0 {
-		wb := NewWhereBuilderWithStart(paramNum)
-		wb.conditions = s.where
-		whereSQL, whereArgs, err := wb.Build()
-		if err != nil {
+		sql.WriteString(" ")
+		var err error
+		if args, err = writeClause(&sql, "WHERE", s.where, paramNum, args); err != nil {
 			return "", nil, fmt.Errorf("failed to build WHERE clause: %w", err)
 		}
-		if whereSQL != "" {
-			sql.WriteString(" ")
-			sql.WriteString(whereSQL)
-			args = append(args, whereArgs...)
-		}
 	}
 
 	if len(s.returning) > 0 {
 		sql.WriteString(" RETURNING ")
-		sql.WriteString(strings.Join(s.returning, ", "))
+		sql.WriteString(strings.Join(returningColumns(s.table, s.returning), ", "))
 	}
 
 	return sql.String(), args, nil
@@ -333,35 +460,32 @@ type deleteSpec struct {
 	returning []string
 }
 
-// buildDeleteSQL assembles a DELETE statement.
-func buildDeleteSQL(s deleteSpec) (string, []interface{}, error) {
+// generateDeleteSQL assembles a DELETE statement.
+func generateDeleteSQL(s deleteSpec) (string, []interface{}, error) {
 	if s.table == nil {
 		return "", nil, fmt.Errorf("table metadata not available")
 	}
+	if err := checkWritable(s.table); err != nil {
+		return "", nil, err
+	}
 
 	var sql strings.Builder
 	var args []interface{}
 
 	sql.WriteString("DELETE FROM ")
-	sql.WriteString(schema.QuoteReservedIdent(s.table.Name))
+	sql.WriteString(schema.QuoteQualifiedIdent(s.table.QualifiedName()))
 
 	if len(s.where) > 0 {
-		wb := NewWhereBuilder()
-		wb.conditions = s.where
-		whereSQL, whereArgs, err := wb.Build()
-		if err != nil {
+		sql.WriteString(" ")
+		var err error
+		if args, err = writeClause(&sql, "WHERE", s.where, 1, args); err != nil {
 			return "", nil, fmt.Errorf("failed to build WHERE clause: %w", err)
 		}
-		if whereSQL != "" {
-			sql.WriteString(" ")
-			sql.WriteString(whereSQL)
-			args = append(args, whereArgs...)
-		}
 	}
 
 	if len(s.returning) > 0 {
 		sql.WriteString(" RETURNING ")
-		sql.WriteString(strings.Join(s.returning, ", "))
+		sql.WriteString(strings.Join(returningColumns(s.table, s.returning), ", "))
 	}
 
 	return sql.String(), args, nil
@@ -381,28 +505,21 @@ func toAnySlice[T any](values []T) []interface{} {
 // queryRows scans every row of the query into a []T, then loads any preloads
 // through the same executor (so it works inside a transaction). Result rows are
 // closed before preload queries, which a single-connection transaction requires.
-func queryRows[T any](ctx context.Context, exec queryExecutor, table *schema.TableMetadata, sqlStr string, args []interface{}, preloads []string) ([]T, error) {
+func queryRows[T any](ctx context.Context, exec Executor, table *schema.TableMetadata, sqlStr string, args []interface{}, preloads []string, related relatedTables) ([]T, error) {
 	rows, err := exec.Query(ctx, sqlStr, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var results []T
-	for rows.Next() {
-		var item T
-		if err := scanIntoStruct(rows, &item, table); err != nil {
-			return nil, err
-		}
-		results = append(results, item)
-	}
-	if err := rows.Err(); err != nil {
+	results, err := scanAll[T](rows, table)
+	if err != nil {
 		return nil, err
 	}
 
 	if len(preloads) > 0 && len(results) > 0 {
 		rows.Close()
-		loader := &relationshipLoader{query: exec.Query, table: table, preloads: preloads}
+		loader := &relationshipLoader{query: exec.Query, table: table, preloads: preloads, relatedTables: related}
 		if err := loader.loadRelationships(ctx, &results); err != nil {
 			return nil, err
 		}
@@ -410,8 +527,47 @@ func queryRows[T any](ctx context.Context, exec queryExecutor, table *schema.Tab
 	return results, nil
 }
 
+// scanReturning runs a RETURNING statement and scans its rows into dest, a
+// pointer to a slice it reuses: of structs, filled by the model's field names
+// as the model itself is, or of single values for a one-column RETURNING.
+func scanReturning(ctx context.Context, exec Executor, table *schema.TableMetadata, sqlStr string, args []interface{}, dest interface{}) (int64, error) {
+	slice := reflect.ValueOf(dest)
+	if slice.Kind() != reflect.Pointer || slice.Elem().Kind() != reflect.Slice {
+		return 0, fmt.Errorf("dest must be a pointer to a slice, got %T", dest)
+	}
+	slice = slice.Elem()
+	slice.SetLen(0)
+	elemType := slice.Type().Elem()
+	asStruct := elemType.Kind() == reflect.Struct && elemType != reflect.TypeFor[time.Time]() && !implementsScanner(elemType)
+
+	rows, err := exec.Query(ctx, sqlStr, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	var plan *scanPlan
+	for rows.Next() {
+		item := reflect.New(elemType)
+		if asStruct {
+			if plan == nil {
+				if plan = scanPlanFor(elemType, table, rows.FieldDescriptions()); plan.err != nil {
+					return 0, plan.err
+				}
+			}
+			err = plan.scan(rows, item.UnsafePointer())
+		} else {
+			err = rows.Scan(item.Interface())
+		}
+		if err != nil {
+			return int64(slice.Len()), err
+		}
+		slice.Set(reflect.Append(slice, item.Elem()))
+	}
+	return int64(slice.Len()), rows.Err()
+}
+
 // execWrite runs a write statement, returning the affected/returned row count.
-func execWrite(ctx context.Context, exec queryExecutor, sqlStr string, args []interface{}, hasReturning bool) (int64, error) {
+func execWrite(ctx context.Context, exec Executor, sqlStr string, args []interface{}, hasReturning bool) (int64, error) {
 	if !hasReturning {
 		return exec.Exec(ctx, sqlStr, args...)
 	}
@@ -428,7 +584,7 @@ func execWrite(ctx context.Context, exec queryExecutor, sqlStr string, args []in
 }
 
 // queryCount runs a COUNT(*) statement.
-func queryCount(ctx context.Context, exec queryExecutor, sqlStr string, args []interface{}) (int64, error) {
+func queryCount(ctx context.Context, exec Executor, sqlStr string, args []interface{}) (int64, error) {
 	var count int64
 	if err := exec.QueryRow(ctx, sqlStr, args...).Scan(&count); err != nil {
 		return 0, err
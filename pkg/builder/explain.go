@@ -0,0 +1,193 @@
+package builder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ExplainFormat is the output format of EXPLAIN.
+type ExplainFormat string
+
+const (
+	// ExplainJSON is parsed into ExplainResult.Plan. It is the default.
+	ExplainJSON ExplainFormat = "JSON"
+	// ExplainText is returned as-is in ExplainResult.Text.
+	ExplainText ExplainFormat = "TEXT"
+)
+
+// ExplainOptions configures EXPLAIN.
+type ExplainOptions struct {
+	// Analyze runs the statement and reports actual times and row counts.
+	Analyze bool
+	// Buffers reports shared/local buffer usage. Needs Analyze for the
+	// execution figures.
+	Buffers bool
+	// Verbose reports output columns and schema-qualified names.
+	Verbose bool
+	Format  ExplainFormat
+}
+
+// ExplainResult is the output of EXPLAIN. Times are in milliseconds, as
+// PostgreSQL reports them.
+type ExplainResult struct {
+	Plan          *PlanNode // nil for ExplainText
+	PlanningTime  float64   // Analyze only
+	ExecutionTime float64   // Analyze only
+	Text          string    // ExplainText only
+}
+
+// PlanNode is one node of a query plan.
+type PlanNode struct {
+	NodeType     string  `json:"Node Type"`
+	RelationName string  `json:"Relation Name"`
+	Schema       string  `json:"Schema"`
+	Alias        string  `json:"Alias"`
+	IndexName    string  `json:"Index Name"`
+	IndexCond    string  `json:"Index Cond"`
+	Filter       string  `json:"Filter"`
+	JoinType     string  `json:"Join Type"`
+	StartupCost  float64 `json:"Startup Cost"`
+	TotalCost    float64 `json:"Total Cost"`
+	PlanRows     float64 `json:"Plan Rows"`
+	PlanWidth    int     `json:"Plan Width"`
+
+	// Analyze only
+	ActualStartupTime   float64 `json:"Actual Startup Time"`
+	ActualTotalTime     float64 `json:"Actual Total Time"`
+	ActualRows          float64 `json:"Actual Rows"`
+	ActualLoops         float64 `json:"Actual Loops"`
+	RowsRemovedByFilter float64 `json:"Rows Removed by Filter"`
+
+	// Buffers only
+	SharedHitBlocks  int64 `json:"Shared Hit Blocks"`
+	SharedReadBlocks int64 `json:"Shared Read Blocks"`
+
+	Plans []PlanNode `json:"Plans"`
+}
+
+// Walk calls fn for the node and each node below it, depth-first.
+func (n *PlanNode) Walk(fn func(node *PlanNode)) {
+	fn(n)
+	for i := range n.Plans {
+		n.Plans[i].Walk(fn)
+	}
+}
+
+// UsesIndex reports whether any node of the plan scans the named index.
+// Usage: if !plan.UsesIndex("idx_users_email") { t.Error("expected an index scan") }
+func (r *ExplainResult) UsesIndex(name string) bool {
+	found := false
+	if r.Plan != nil {
+		r.Plan.Walk(func(node *PlanNode) {
+			found = found || node.IndexName == name
+		})
+	}
+	return found
+}
+
+// SeqScans returns the tables the plan reads with a sequential scan.
+func (r *ExplainResult) SeqScans() []string {
+	var tables []string
+	if r.Plan != nil {
+		r.Plan.Walk(func(node *PlanNode) {
+			if node.NodeType == "Seq Scan" {
+				tables = append(tables, node.RelationName)
+			}
+		})
+	}
+	return tables
+}
+
+// Explain runs EXPLAIN on the query, with its planner hints, and returns its
+// plan. With Analyze the query is executed.
+func (q *SelectQuery[T]) Explain(ctx context.Context, opts ExplainOptions) (result *ExplainResult, err error) {
+	if q, err = q.resolve(ctx); err != nil {
+		return nil, err
+	}
+	sql, args, err := q.ToSQL()
+	if err != nil {
+		return nil, err
+	}
+	err = q.readWith(ctx, false, func(ctx context.Context, exec Executor) error {
+		result, err = explain(ctx, exec, sql, args, opts)
+		return err
+	})
+	return result, err
+}
+
+// Explain runs EXPLAIN on the query and returns its plan. With Analyze the
+// query is executed.
+func (q *TxSelectQuery[T]) Explain(opts ExplainOptions) (*ExplainResult, error) {
+	sql, args, err := q.ToSQL()
+	if err != nil {
+		return nil, err
+	}
+	return explain(q.tx.ctx, q.tx.exec(), sql, args, opts)
+}
+
+// explainSQL prefixes sql with EXPLAIN and its options.
+func explainSQL(sql string, opts ExplainOptions) string {
+	format := opts.Format
+	if format == "" {
+		format = ExplainJSON
+	}
+	options := []string{"FORMAT " + string(format)}
+	if opts.Analyze {
+		options = append(options, "ANALYZE")
+	}
+	if opts.Buffers {
+		options = append(options, "BUFFERS")
+	}
+	if opts.Verbose {
+		options = append(options, "VERBOSE")
+	}
+	return fmt.Sprintf("EXPLAIN (%s) %s", strings.Join(options, ", "), sql)
+}
+
+func explain(ctx context.Context, exec Executor, sql string, args []interface{}, opts ExplainOptions) (*ExplainResult, error) {
+	rows, err := exec.Query(ctx, explainSQL(sql, opts), args...)
+	if err != nil {
+		return nil, fmt.Errorf("explain failed: %w", err)
+	}
+	defer rows.Close()
+
+	var lines []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return nil, fmt.Errorf("explain failed: %w", err)
+		}
+		lines = append(lines, line)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("explain failed: %w", err)
+	}
+
+	output := strings.Join(lines, "\n")
+	if opts.Format == ExplainText {
+		return &ExplainResult{Text: output}, nil
+	}
+	return parseExplainJSON(output)
+}
+
+// parseExplainJSON parses the output of EXPLAIN (FORMAT JSON).
+func parseExplainJSON(output string) (*ExplainResult, error) {
+	var plans []struct {
+		Plan          PlanNode `json:"Plan"`
+		PlanningTime  float64  `json:"Planning Time"`
+		ExecutionTime float64  `json:"Execution Time"`
+	}
+	if err := json.Unmarshal([]byte(output), &plans); err != nil {
+		return nil, fmt.Errorf("failed to parse explain output: %w", err)
+	}
+	if len(plans) == 0 {
+		return nil, fmt.Errorf("failed to parse explain output: no plan")
+	}
+	return &ExplainResult{
+		Plan:          &plans[0].Plan,
+		PlanningTime:  plans[0].PlanningTime,
+		ExecutionTime: plans[0].ExecutionTime,
+	}, nil
+}
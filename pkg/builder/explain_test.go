@@ -0,0 +1,57 @@
+package builder
+
+import "testing"
+
+func TestExplainSQL(t *testing.T) {
+	tests := []struct {
+		name string
+		opts ExplainOptions
+		want string
+	}{
+		{"default", ExplainOptions{}, "EXPLAIN (FORMAT JSON) SELECT 1"},
+		{"analyze buffers", ExplainOptions{Analyze: true, Buffers: true}, "EXPLAIN (FORMAT JSON, ANALYZE, BUFFERS) SELECT 1"},
+		{"text verbose", ExplainOptions{Format: ExplainText, Verbose: true}, "EXPLAIN (FORMAT TEXT, VERBOSE) SELECT 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := explainSQL("SELECT 1", tt.opts); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseExplainJSON(t *testing.T) {
+	output := `[{
+		"Plan": {
+			"Node Type": "Nested Loop", "Join Type": "Inner", "Total Cost": 16.6, "Actual Rows": 2, "Actual Loops": 1,
+			"Plans": [
+				{"Node Type": "Index Scan", "Relation Name": "users", "Index Name": "idx_users_email", "Index Cond": "(email = $1)"},
+				{"Node Type": "Seq Scan", "Relation Name": "posts", "Filter": "(published = true)", "Rows Removed by Filter": 8}
+			]
+		},
+		"Planning Time": 0.12,
+		"Execution Time": 0.34
+	}]`
+
+	result, err := parseExplainJSON(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Plan.NodeType != "Nested Loop" || len(result.Plan.Plans) != 2 || result.Plan.ActualRows != 2 {
+		t.Errorf("unexpected plan %+v", result.Plan)
+	}
+	if result.PlanningTime != 0.12 || result.ExecutionTime != 0.34 {
+		t.Errorf("unexpected times %v, %v", result.PlanningTime, result.ExecutionTime)
+	}
+	if !result.UsesIndex("idx_users_email") || result.UsesIndex("idx_posts_published") {
+		t.Error("expected only idx_users_email to be used")
+	}
+	if scans := result.SeqScans(); len(scans) != 1 || scans[0] != "posts" {
+		t.Errorf("expected a sequential scan of posts, got %v", scans)
+	}
+
+	if _, err := parseExplainJSON("[]"); err == nil {
+		t.Error("expected an error for an empty plan")
+	}
+}
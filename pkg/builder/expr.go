@@ -0,0 +1,218 @@
+package builder
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Expr is a computed value for SelectExpr, built from columns, bound values,
+// function calls, arithmetic and casts. Column names are validated as
+// Columns validates them and values are bound as parameters, so columns and
+// values may come from request data. Function names may not: Func calls any
+// function, such as pg_sleep, so its name must come from code.
+type Expr interface {
+	writeExpr(w *exprWriter) error
+}
+
+// exprWriter renders an Expr, numbering placeholders from next.
+type exprWriter struct {
+	sql  strings.Builder
+	args []interface{}
+	next int
+}
+
+func (w *exprWriter) bind(v interface{}) {
+	writePlaceholder(&w.sql, w.next)
+	w.args = append(w.args, v)
+	w.next++
+}
+
+// renderExpr returns the SQL of e with placeholders numbered from
+// paramStart, and the values they bind.
+func renderExpr(e Expr, paramStart int) (string, []interface{}, error) {
+	if e == nil {
+		return "", nil, fmt.Errorf("nil expression")
+	}
+	w := exprWriter{next: paramStart}
+	if err := e.writeExpr(&w); err != nil {
+		return "", nil, err
+	}
+	return w.sql.String(), w.args, nil
+}
+
+type columnExpr string
+
+// Column refers to a column, validated as Columns validates names.
+// Usage: builder.Column("orders.total")
+func Column(name string) Expr { return columnExpr(name) }
+
+func (c columnExpr) writeExpr(w *exprWriter) error {
+	quoted, err := quoteColumn(string(c))
+	if err != nil {
+		return err
+	}
+	w.sql.WriteString(quoted)
+	return nil
+}
+
+type valueExpr struct{ v interface{} }
+
+// Value binds v as a query parameter.
+func Value(v interface{}) Expr { return valueExpr{v} }
+
+func (v valueExpr) writeExpr(w *exprWriter) error {
+	w.bind(v.v)
+	return nil
+}
+
+type funcExpr struct {
+	name string
+	args []Expr
+}
+
+// Func calls the function name, which must be a plain or schema-qualified
+// function name, with args. The name is checked to be a name, not to be a
+// harmless function: never take it from request data.
+// Usage: builder.Func("date_trunc", builder.Value("day"), builder.Column("created_at"))
+func Func(name string, args ...Expr) Expr { return funcExpr{name, args} }
+
+// Coalesce returns the first of exprs that isn't NULL.
+func Coalesce(exprs ...Expr) Expr { return funcExpr{"COALESCE", exprs} }
+
+func (f funcExpr) writeExpr(w *exprWriter) error {
+	if !isFunctionName(f.name) {
+		return fmt.Errorf("%w: function %q", ErrInvalidIdentifier, f.name)
+	}
+	w.sql.WriteString(f.name)
+	w.sql.WriteByte('(')
+	for i, arg := range f.args {
+		if i > 0 {
+			w.sql.WriteString(", ")
+		}
+		if arg == nil {
+			return fmt.Errorf("nil argument %d to %s", i+1, f.name)
+		}
+		if err := arg.writeExpr(w); err != nil {
+			return err
+		}
+	}
+	w.sql.WriteByte(')')
+	return nil
+}
+
+type binaryExpr struct {
+	op          string
+	left, right Expr
+}
+
+// Add returns left + right.
+func Add(left, right Expr) Expr { return binaryExpr{"+", left, right} }
+
+// Sub returns left - right.
+func Sub(left, right Expr) Expr { return binaryExpr{"-", left, right} }
+
+// Mul returns left * right.
+// Usage: query.SelectExpr(builder.Mul(builder.Column("price"), builder.Column("quantity")), "total")
+func Mul(left, right Expr) Expr { return binaryExpr{"*", left, right} }
+
+// Div returns left / right, which truncates for integer operands.
+func Div(left, right Expr) Expr { return binaryExpr{"/", left, right} }
+
+func (b binaryExpr) writeExpr(w *exprWriter) error {
+	if b.left == nil || b.right == nil {
+		return fmt.Errorf("nil operand to %s", b.op)
+	}
+	w.sql.WriteByte('(')
+	if err := b.left.writeExpr(w); err != nil {
+		return err
+	}
+	w.sql.WriteByte(' ')
+	w.sql.WriteString(b.op)
+	w.sql.WriteByte(' ')
+	if err := b.right.writeExpr(w); err != nil {
+		return err
+	}
+	w.sql.WriteByte(')')
+	return nil
+}
+
+type castExpr struct {
+	e   Expr
+	typ string
+}
+
+// Cast converts e to the PostgreSQL type typ, such as "numeric(10,2)",
+// "text[]" or "timestamp with time zone".
+func Cast(e Expr, typ string) Expr { return castExpr{e, typ} }
+
+func (c castExpr) writeExpr(w *exprWriter) error {
+	if c.e == nil {
+		return fmt.Errorf("nil expression cast to %s", c.typ)
+	}
+	if !isTypeName(c.typ) {
+		return fmt.Errorf("invalid type name %q", c.typ)
+	}
+	w.sql.WriteString("CAST(")
+	if err := c.e.writeExpr(w); err != nil {
+		return err
+	}
+	w.sql.WriteString(" AS ")
+	w.sql.WriteString(c.typ)
+	w.sql.WriteByte(')')
+	return nil
+}
+
+// isFunctionName reports whether name is name or schema.name.
+func isFunctionName(name string) bool {
+	schemaName, fn, qualified := strings.Cut(name, ".")
+	if qualified {
+		return isPlainName(schemaName) && isPlainName(fn)
+	}
+	return isPlainName(name)
+}
+
+func isPlainName(s string) bool {
+	if s == "" || !isNameStart(s[0]) {
+		return false
+	}
+	for i := 1; i < len(s); i++ {
+		if !isNamePart(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// isTypeName reports whether typ is words separated by single spaces,
+// optionally followed by a (n) or (n,m) modifier and [] dimensions.
+func isTypeName(typ string) bool {
+	words, rest := typ, ""
+	if i := strings.IndexAny(typ, "(["); i >= 0 {
+		words, rest = typ[:i], typ[i:]
+	}
+	for _, word := range strings.Split(words, " ") {
+		if !isPlainName(word) {
+			return false
+		}
+	}
+	if strings.HasPrefix(rest, "(") {
+		end := strings.IndexByte(rest, ')')
+		if end < 0 {
+			return false
+		}
+		for _, n := range strings.Split(rest[1:end], ",") {
+			n = strings.TrimSpace(n)
+			if n == "" || strings.TrimLeft(n, "0123456789") != "" {
+				return false
+			}
+		}
+		rest = rest[end+1:]
+	}
+	for rest != "" {
+		if !strings.HasPrefix(rest, "[]") {
+			return false
+		}
+		rest = rest[2:]
+	}
+	return true
+}
@@ -0,0 +1,89 @@
+package builder
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRenderExpr(t *testing.T) {
+	tests := []struct {
+		name     string
+		expr     Expr
+		wantSQL  string
+		wantArgs []interface{}
+	}{
+		{"column", Column("orders.total"), "orders.total", nil},
+		{"reserved column", Column("order"), `"order"`, nil},
+		{"arithmetic", Mul(Column("price"), Column("quantity")), "(price * quantity)", nil},
+		{"nested", Sub(Mul(Column("price"), Column("quantity")), Value(5)), "((price * quantity) - $3)", []interface{}{5}},
+		{"function", Func("date_trunc", Value("day"), Column("created_at")), "date_trunc($3, created_at)", []interface{}{"day"}},
+		{"coalesce", Coalesce(Column("nickname"), Column("name"), Value("anonymous")), "COALESCE(nickname, name, $3)", []interface{}{"anonymous"}},
+		{"cast", Cast(Div(Column("a"), Column("b")), "numeric(10, 2)"), "CAST((a / b) AS numeric(10, 2))", nil},
+		{"cast to array", Cast(Value("{}"), "timestamp with time zone[]"), "CAST($3 AS timestamp with time zone[])", []interface{}{"{}"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := renderExpr(tt.expr, 3)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sql != tt.wantSQL || !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("got %q %v, want %q %v", sql, args, tt.wantSQL, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestRenderExprRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		expr Expr
+	}{
+		{"column", Column("price; DROP TABLE orders")},
+		{"function name", Func("pg_sleep(1); --")},
+		{"function argument", Func("lower", Column("1=1"))},
+		{"type", Cast(Column("a"), "text); DROP TABLE orders; --")},
+		{"nil operand", Add(Column("a"), nil)},
+		{"nil", nil},
+	}
+	for _, tt := range tests {
+		if sql, _, err := renderExpr(tt.expr, 1); err == nil {
+			t.Errorf("%s: rendered %q", tt.name, sql)
+		}
+	}
+}
+
+func TestSelectExpr(t *testing.T) {
+	db := New(nil)
+	sql, args, err := Select[TestUser](db).
+		Columns("id").
+		SelectExpr(Mul(Column("age"), Value(12)), "months").
+		SelectExpr(Coalesce(Column("name"), Value("?")), "user").
+		Where(Gt("age", 18)).
+		ToSQL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `SELECT id, (age * $1) AS months, COALESCE(name, $2) AS "user" FROM test_user WHERE age > $3`
+	if sql != want {
+		t.Errorf("SQL = %q, want %q", sql, want)
+	}
+	if !reflect.DeepEqual(args, []interface{}{12, "?", 18}) {
+		t.Errorf("args = %v", args)
+	}
+
+	// The shape cache must return the same statement with fresh arguments.
+	_, args, _ = Select[TestUser](db).Columns("id").
+		SelectExpr(Mul(Column("age"), Value(1)), "months").
+		SelectExpr(Coalesce(Column("name"), Value("-")), "user").
+		Where(Gt("age", 30)).ToSQL()
+	if !reflect.DeepEqual(args, []interface{}{1, "-", 30}) {
+		t.Errorf("cached args = %v", args)
+	}
+
+	_, _, err = Select[TestUser](db).SelectExpr(Column("age"), "a; DROP TABLE x").ToSQL()
+	if !errors.Is(err, ErrInvalidIdentifier) {
+		t.Errorf("bad alias: err = %v, want ErrInvalidIdentifier", err)
+	}
+}
@@ -0,0 +1,47 @@
+package builder
+
+import (
+	"context"
+	"time"
+
+	"github.com/marshallshelly/pebble-orm/pkg/runtime"
+)
+
+// Health reports the health of the primary and of each replica, checking
+// the replicas' lag when opts set MaxReplicationLag. The report is degraded
+// when the primary's pool is saturated or a replica isn't OK, and down when
+// the primary doesn't answer. A DB made by NewWithExecutor runs SELECT 1 on
+// its executor instead of pinging.
+// Usage: report := qb.Health(ctx, runtime.HealthOptions{MaxReplicationLag: 10 * time.Second})
+func (d *DB) Health(ctx context.Context, opts ...runtime.HealthOptions) runtime.HealthReport {
+	var o runtime.HealthOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if d.db == nil {
+		return d.executorHealth(ctx)
+	}
+	primaryOpts := o
+	primaryOpts.MaxReplicationLag = 0
+	primary := d.db.Health(ctx, primaryOpts)
+	if len(d.replicas) == 0 {
+		return primary
+	}
+	replicas := make([]runtime.HealthReport, len(d.replicas))
+	for i, replica := range d.replicas {
+		replicas[i] = replica.Health(ctx, o)
+	}
+	return runtime.CombineHealth(primary, replicas)
+}
+
+// executorHealth checks a DB without a runtime DB through its executor.
+func (d *DB) executorHealth(ctx context.Context) runtime.HealthReport {
+	start := time.Now()
+	_, err := d.exec().Exec(ctx, "SELECT 1")
+	report := runtime.HealthReport{Status: runtime.HealthOK, Latency: time.Since(start)}
+	if err != nil {
+		report.Status = runtime.HealthDown
+		report.Error = err.Error()
+	}
+	return report
+}
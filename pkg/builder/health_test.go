@@ -0,0 +1,20 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/marshallshelly/pebble-orm/pkg/runtime"
+)
+
+func TestHealth(t *testing.T) {
+	ctx := context.Background()
+	if report := NewWithExecutor(stubExecutor{}).Health(ctx); report.Status != runtime.HealthOK || !report.OK() {
+		t.Errorf("healthy report = %+v", report)
+	}
+	report := NewWithExecutor(stubExecutor{err: errors.New("connection refused")}).Health(ctx)
+	if report.Status != runtime.HealthDown || report.OK() || report.Error == "" {
+		t.Errorf("down report = %+v", report)
+	}
+}
@@ -0,0 +1,98 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/marshallshelly/pebble-orm/pkg/runtime"
+)
+
+// plannerSettings are the parameters PlannerHint accepts besides the
+// enable_* switches. They change how a query is planned or executed, never
+// what it returns.
+var plannerSettings = map[string]bool{
+	"random_page_cost": true, "seq_page_cost": true,
+	"cpu_tuple_cost": true, "cpu_index_tuple_cost": true, "cpu_operator_cost": true,
+	"parallel_setup_cost": true, "parallel_tuple_cost": true,
+	"max_parallel_workers_per_gather": true, "effective_cache_size": true,
+	"work_mem": true, "hash_mem_multiplier": true,
+	"join_collapse_limit": true, "from_collapse_limit": true,
+	"geqo": true, "geqo_threshold": true, "jit": true, "plan_cache_mode": true,
+}
+
+// isPlannerSetting reports whether PlannerHint may set name.
+func isPlannerSetting(name string) bool {
+	return plannerSettings[name] || (strings.HasPrefix(name, "enable_") && isPlainName(name))
+}
+
+// PlannerHint sets a query planner parameter, such as enable_seqscan or
+// random_page_cost, for this query only. A hinted query runs in a
+// transaction of its own that sets the parameters locally, so they never
+// reach other queries on the pooled connection. Only the enable_* switches
+// and the cost, memory and join-order parameters can be set.
+// Usage: query.PlannerHint("enable_seqscan", "off").All(ctx)
+func (q *SelectQuery[T]) PlannerHint(name, value string) *SelectQuery[T] {
+	if !isPlannerSetting(name) {
+		keepErr(&q.err, fmt.Errorf("%q is not a planner setting", name))
+		return q
+	}
+	if q.hints == nil {
+		q.hints = make(map[string]string)
+	}
+	q.hints[name] = value
+	return q
+}
+
+// readWith runs fn as readOnce does, retrying it as the DB's RetryPolicy
+// says unless the query locks rows.
+func (q *SelectQuery[T]) readWith(ctx context.Context, primary bool, fn func(ctx context.Context, exec Executor) error) error {
+	if q.forUpdate {
+		return q.readOnce(ctx, primary, fn)
+	}
+	return q.db.retryPolicy().retry(ctx, func() error { return q.readOnce(ctx, primary, fn) })
+}
+
+// readOnce runs fn with the executor the query reads from, in a transaction
+// carrying its planner hints when it has any, and ctx bounded by the query's
+// deadline.
+func (q *SelectQuery[T]) readOnce(ctx context.Context, primary bool, fn func(ctx context.Context, exec Executor) error) error {
+	ctx, cancel := withDeadline(ctx, q.deadline)
+	defer cancel()
+	primary = primary || q.primary || q.forUpdate
+	if len(q.hints) == 0 || q.db.db == nil {
+		return fn(ctx, q.db.readExec(primary))
+	}
+	return withSettings(ctx, q.db.readDB(primary), q.db.config, q.hints, fn)
+}
+
+// countWith returns a function running the COUNT statement sql for q.
+func countWith[T any](ctx context.Context, q *SelectQuery[T], sql string, args []interface{}) func() (int64, error) {
+	return func() (n int64, err error) {
+		err = q.readWith(ctx, false, func(ctx context.Context, exec Executor) error {
+			n, err = queryCount(ctx, exec, sql, args)
+			return err
+		})
+		return n, err
+	}
+}
+
+// withSettings runs fn in a transaction on db with settings applied as by
+// SET LOCAL.
+func withSettings(ctx context.Context, db *runtime.DB, cfg *Config, settings map[string]string, fn func(ctx context.Context, exec Executor) error) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", timeoutErr(err))
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	exec := withConfig(txExecutor{tx}, cfg)
+	sql, args := buildSettingsSQL(settings)
+	if _, err := exec.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("failed to apply planner hints: %w", err)
+	}
+	if err := fn(ctx, exec); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,30 @@
+package builder
+
+import "testing"
+
+func TestPlannerHint(t *testing.T) {
+	db := New(nil)
+	tests := []struct {
+		name string
+		ok   bool
+	}{
+		{"enable_seqscan", true},
+		{"enable_nestloop", true},
+		{"random_page_cost", true},
+		{"work_mem", true},
+		{"role", false},
+		{"search_path", false},
+		{"statement_timeout", false},
+		{"enable_seqscan = off; DROP TABLE test_user; --", false},
+	}
+	for _, tt := range tests {
+		q := Select[TestUser](db).PlannerHint(tt.name, "off")
+		_, _, err := q.ToSQL()
+		if (err == nil) != tt.ok {
+			t.Errorf("PlannerHint(%q): err = %v, want ok %v", tt.name, err, tt.ok)
+		}
+		if tt.ok && q.hints[tt.name] != "off" {
+			t.Errorf("PlannerHint(%q) not recorded: %v", tt.name, q.hints)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package builder
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+)
+
+// AsOf reads the table as it was at t, from the current rows and the past
+// versions its // history: true directive keeps in <table>_history. Rows
+// inserted after t are left out and rows changed or deleted since are read
+// as they were. It can't be combined with TableSample or ForUpdate.
+// Usage: builder.Select[Price](qb).Where(builder.Eq("sku", sku)).AsOf(lastMonth).All(ctx)
+func (q *SelectQuery[T]) AsOf(t time.Time) *SelectQuery[T] {
+	if q.table != nil && !q.table.History {
+		keepErr(&q.err, fmt.Errorf("%s has no // history: true directive", q.table.QualifiedName()))
+	} else if q.table != nil && len(q.table.PrimaryKeyColumns()) == 0 {
+		keepErr(&q.err, fmt.Errorf("%s has no primary key to read its history by", q.table.QualifiedName()))
+	}
+	q.asOf = &t
+	return q
+}
+
+// historyFrom returns the FROM item standing in for table as of the time
+// bound to $n: its current rows that haven't changed since, and the past
+// versions valid then, aliased as the table so the rest of the query reads
+// the same.
+func historyFrom(table *schema.TableMetadata, n int) string {
+	current := schema.QuoteQualifiedIdent(table.QualifiedName())
+	history := schema.QuoteQualifiedIdent(table.HistoryTable())
+	pk := table.PrimaryKeyColumns()
+	key := make([]string, 0, 2*len(pk))
+	for _, col := range pk {
+		key = append(key, "'"+col+"'", current+"."+schema.QuoteReservedIdent(col))
+	}
+	return fmt.Sprintf("(SELECT * FROM %[1]s WHERE NOT EXISTS "+
+		"(SELECT 1 FROM %[2]s AS h WHERE h.row_key = jsonb_build_object(%[3]s) AND h.valid_to > $%[4]d) "+
+		"UNION ALL SELECT (jsonb_populate_record(NULL::%[1]s, h.row_data)).* FROM %[2]s AS h "+
+		"WHERE h.operation <> 'INSERT' AND h.valid_from <= $%[4]d AND h.valid_to > $%[4]d) AS %[5]s",
+		current, history, strings.Join(key, ", "), n, schema.QuoteReservedIdent(table.Name))
+}
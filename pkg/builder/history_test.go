@@ -0,0 +1,50 @@
+package builder
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marshallshelly/pebble-orm/pkg/registry"
+)
+
+// history: true
+type TestPrice struct {
+	SKU    string `po:"sku,text,primaryKey"`
+	Amount int    `po:"amount,integer"`
+}
+
+func TestAsOf(t *testing.T) {
+	if err := registry.Register(TestPrice{}); err != nil {
+		t.Fatalf("Failed to register model: %v", err)
+	}
+	db := New(nil)
+	at := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	sql, args, err := Select[TestPrice](db).Where(Eq("sku", "A1")).AsOf(at).ToSQL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "SELECT * FROM (SELECT * FROM test_price WHERE NOT EXISTS " +
+		"(SELECT 1 FROM test_price_history AS h WHERE h.row_key = jsonb_build_object('sku', test_price.sku) AND h.valid_to > $1) " +
+		"UNION ALL SELECT (jsonb_populate_record(NULL::test_price, h.row_data)).* FROM test_price_history AS h " +
+		"WHERE h.operation <> 'INSERT' AND h.valid_from <= $1 AND h.valid_to > $1) AS test_price WHERE sku = $2"
+	if sql != want {
+		t.Errorf("SQL = %s\nwant %s", sql, want)
+	}
+	if len(args) != 2 || args[0] != at || args[1] != "A1" {
+		t.Errorf("args = %v", args)
+	}
+
+	// Built again, the SQL comes from the cache with the same arguments.
+	if _, again, _ := Select[TestPrice](db).Where(Eq("sku", "B2")).AsOf(at).ToSQL(); len(again) != 2 || again[0] != at || again[1] != "B2" {
+		t.Errorf("cached args = %v", again)
+	}
+
+	if _, _, err := Select[TestUser](db).AsOf(at).ToSQL(); err == nil || !strings.Contains(err.Error(), "history") {
+		t.Errorf("expected an error for a model without history, got %v", err)
+	}
+	if _, _, err := Select[TestPrice](db).AsOf(at).ForUpdate().ToSQL(); err == nil {
+		t.Error("expected an error for AsOf with ForUpdate")
+	}
+}
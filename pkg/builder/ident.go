@@ -0,0 +1,389 @@
+package builder
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+)
+
+// UnsafeExpr is SQL the caller vouches for, written into a statement as is.
+// The builders accept column and table names only as identifiers (see
+// ErrInvalidIdentifier); anything else has to be converted to an UnsafeExpr
+// explicitly, which marks the places a review should check. Never build one
+// from user input.
+// Usage: builder.Select[User](qb).ColumnExpr("EXTRACT(YEAR FROM created_at) AS year")
+type UnsafeExpr string
+
+// ErrInvalidIdentifier is returned by ToSQL and the execution methods when a
+// column or table name passed to a builder isn't one. Names may be plain or
+// double-quoted identifiers, schema- or table-qualified, with an alias where
+// the clause allows one. Calls of common aggregate and scalar functions over
+// names and literals, such as COUNT(*) or lower(email), and the JSONB path
+// accessors are accepted too, so a name taken from a request, e.g. a sort
+// parameter, can only ever select a column. Reserved words are quoted.
+var ErrInvalidIdentifier = errors.New("invalid identifier")
+
+func invalidIdentifier(kind, name string) error {
+	return fmt.Errorf("%w: %s %q (pass expressions as builder.UnsafeExpr)", ErrInvalidIdentifier, kind, name)
+}
+
+// safeFunctions are the functions a column reference may call. They read
+// their arguments and nothing else, so calling them with attacker-chosen
+// columns can't reach beyond the query's tables.
+var safeFunctions = map[string]bool{
+	"count": true, "sum": true, "avg": true, "min": true, "max": true,
+	"array_agg": true, "string_agg": true, "json_agg": true, "jsonb_agg": true,
+	"jsonb_object_agg": true, "bool_and": true, "bool_or": true, "every": true,
+	"lower": true, "upper": true, "length": true, "char_length": true, "trim": true,
+	"coalesce": true, "nullif": true, "greatest": true, "least": true,
+	"abs": true, "ceil": true, "ceiling": true, "floor": true, "round": true, "trunc": true,
+	"date_trunc": true, "date_part": true, "age": true,
+	"array_length": true, "cardinality": true, "host": true, "masklen": true, "to_tsvector": true,
+}
+
+// isSafeFunction reports whether name, in any case, is in safeFunctions.
+func isSafeFunction(name string) bool {
+	var buf [24]byte
+	if len(name) > len(buf) {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if 'A' <= c && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		buf[i] = c
+	}
+	return safeFunctions[string(buf[:len(name)])]
+}
+
+// quoteColumn returns a column reference as it is written into SQL: column,
+// table.column, table.*, or a safe function call over those.
+func quoteColumn(name string) (string, error) {
+	return quoteWith(name, "column", (*refScanner).expr)
+}
+
+// quoteSelectItem returns a select-list or RETURNING entry: a column
+// reference with an optional AS alias.
+func quoteSelectItem(name string) (string, error) {
+	return quoteWith(name, "column", (*refScanner).item)
+}
+
+// quoteTable returns a join's table: a possibly schema-qualified name with
+// an optional alias.
+func quoteTable(name string) (string, error) {
+	return quoteWith(name, "table", (*refScanner).table)
+}
+
+// quoteIdent returns a single identifier, such as an alias.
+func quoteIdent(name string) (string, error) {
+	return quoteWith(name, "column", (*refScanner).ident)
+}
+
+// checkColumnName validates a bare column name, as SET and ON CONFLICT take.
+func checkColumnName(name string) error {
+	_, err := quoteIdent(name)
+	return err
+}
+
+// quoteAll quotes names with quote, recording the first error in *errp.
+// Invalid names are kept as they are; ToSQL never renders them.
+func quoteAll(names []string, quote func(string) (string, error), errp *error) []string {
+	out := make([]string, len(names))
+	for i, name := range names {
+		quoted, err := quote(name)
+		if err != nil {
+			keepErr(errp, err)
+			quoted = name
+		}
+		out[i] = quoted
+	}
+	return out
+}
+
+// keepErr records err in *errp unless an earlier error is already there.
+func keepErr(errp *error, err error) {
+	if *errp == nil {
+		*errp = err
+	}
+}
+
+func quoteWith(name, kind string, scan func(*refScanner) bool) (string, error) {
+	var out strings.Builder
+	if !scanRef(&out, name, scan) {
+		return "", invalidIdentifier(kind, name)
+	}
+	return out.String(), nil
+}
+
+// writeColumn writes a condition's column reference, validating it as
+// quoteColumn does, without allocating for names that need no quotes.
+func writeColumn(sql *strings.Builder, name string) error {
+	if !scanRef(sql, name, (*refScanner).expr) {
+		return invalidIdentifier("column", name)
+	}
+	return nil
+}
+
+func scanRef(out *strings.Builder, name string, scan func(*refScanner) bool) bool {
+	r := refScanner{s: name, out: out}
+	return scan(&r) && r.i == len(r.s)
+}
+
+// refScanner checks a name against the grammar ErrInvalidIdentifier
+// describes, copying it to out with reserved words quoted.
+type refScanner struct {
+	s   string
+	i   int
+	out *strings.Builder
+}
+
+func (r *refScanner) peek(c byte) bool { return r.i < len(r.s) && r.s[r.i] == c }
+
+// copy writes s[from:r.i] to out.
+func (r *refScanner) copy(from int) { r.out.WriteString(r.s[from:r.i]) }
+
+// item: expr [AS alias]
+func (r *refScanner) item() bool {
+	if !r.expr() {
+		return false
+	}
+	if r.i == len(r.s) {
+		return true
+	}
+	return r.space() && r.keyword("AS") && r.space() && r.ident()
+}
+
+// table: name[.name] [[AS] alias]
+func (r *refScanner) table() bool {
+	if !r.path(false) {
+		return false
+	}
+	if r.i == len(r.s) {
+		return true
+	}
+	if !r.space() {
+		return false
+	}
+	start := r.i
+	if r.keyword("AS") {
+		if !r.space() {
+			return false
+		}
+	} else {
+		r.i = start
+	}
+	return r.ident()
+}
+
+// expr: term, then any JSONB accessors (->'key', ->>'key', ->0)
+func (r *refScanner) expr() bool {
+	if !r.term() {
+		return false
+	}
+	for strings.HasPrefix(r.s[r.i:], "->") {
+		start := r.i
+		r.i += 2
+		if r.peek('>') {
+			r.i++
+		}
+		r.copy(start)
+		if !r.literal() && !r.number() {
+			return false
+		}
+	}
+	return true
+}
+
+// term: * | path[.*] | 'literal' | number | function(args)
+func (r *refScanner) term() bool {
+	switch {
+	case r.peek('*'):
+		r.i++
+		r.out.WriteByte('*')
+		return true
+	case r.peek('\''):
+		return r.literal()
+	case r.i < len(r.s) && isDigit(r.s[r.i]):
+		return r.number()
+	}
+
+	// A function name is an unquoted identifier followed by (
+	start := r.i
+	for r.i < len(r.s) && isNamePart(r.s[r.i]) {
+		r.i++
+	}
+	if r.i > start && isNameStart(r.s[start]) && r.peek('(') {
+		if !isSafeFunction(r.s[start:r.i]) {
+			return false
+		}
+		r.i++
+		r.copy(start)
+		return r.args()
+	}
+	r.i = start
+	return r.path(true)
+}
+
+// args: [DISTINCT ]expr{, expr}) | )
+func (r *refScanner) args() bool {
+	if r.peek(')') {
+		r.i++
+		r.out.WriteByte(')')
+		return true
+	}
+	if r.keyword("DISTINCT") && !r.space() {
+		return false
+	}
+	return r.argList()
+}
+
+func (r *refScanner) argList() bool {
+	for {
+		if !r.expr() {
+			return false
+		}
+		switch {
+		case r.peek(','):
+			r.i++
+			r.out.WriteByte(',')
+			if r.peek(' ') {
+				r.i++
+				r.out.WriteByte(' ')
+			}
+		case r.peek(')'):
+			r.i++
+			r.out.WriteByte(')')
+			return true
+		default:
+			return false
+		}
+	}
+}
+
+// path: ident{.ident}, ending in .* when star is set
+func (r *refScanner) path(star bool) bool {
+	if !r.ident() {
+		return false
+	}
+	for r.peek('.') {
+		r.i++
+		r.out.WriteByte('.')
+		if star && r.peek('*') {
+			r.i++
+			r.out.WriteByte('*')
+			return true
+		}
+		if !r.ident() {
+			return false
+		}
+	}
+	return true
+}
+
+// ident: name or "quoted name", quoting reserved names
+func (r *refScanner) ident() bool {
+	start := r.i
+	if r.peek('"') {
+		for r.i++; r.i < len(r.s); r.i++ {
+			switch r.s[r.i] {
+			case 0:
+				return false
+			case '"':
+				if r.i+1 < len(r.s) && r.s[r.i+1] == '"' {
+					r.i++
+					continue
+				}
+				r.i++
+				if r.i-start == 2 {
+					return false // ""
+				}
+				r.copy(start)
+				return true
+			}
+		}
+		return false
+	}
+	if r.i >= len(r.s) || !isNameStart(r.s[r.i]) {
+		return false
+	}
+	for r.i < len(r.s) && (isNamePart(r.s[r.i]) || r.s[r.i] == '$') {
+		r.i++
+	}
+	name := r.s[start:r.i]
+	if schema.IsReservedIdent(name) {
+		r.out.WriteByte('"')
+		r.out.WriteString(name)
+		r.out.WriteByte('"')
+		return true
+	}
+	r.out.WriteString(name)
+	return true
+}
+
+// literal: 'text', a quote inside written twice. Backslashes are refused, as they
+// escape quotes when standard_conforming_strings is off.
+func (r *refScanner) literal() bool {
+	if !r.peek('\'') {
+		return false
+	}
+	start := r.i
+	for r.i++; r.i < len(r.s); r.i++ {
+		switch r.s[r.i] {
+		case '\\', 0:
+			return false
+		case '\'':
+			if r.i+1 < len(r.s) && r.s[r.i+1] == '\'' {
+				r.i++
+				continue
+			}
+			r.i++
+			r.copy(start)
+			return true
+		}
+	}
+	return false
+}
+
+// number: digits[.digits]
+func (r *refScanner) number() bool {
+	start := r.i
+	for r.i < len(r.s) && isDigit(r.s[r.i]) {
+		r.i++
+	}
+	if r.i == start {
+		return false
+	}
+	if r.peek('.') && r.i+1 < len(r.s) && isDigit(r.s[r.i+1]) {
+		for r.i++; r.i < len(r.s) && isDigit(r.s[r.i]); r.i++ {
+		}
+	}
+	r.copy(start)
+	return true
+}
+
+// space consumes one or more spaces.
+func (r *refScanner) space() bool {
+	start := r.i
+	for r.peek(' ') {
+		r.i++
+	}
+	r.copy(start)
+	return r.i > start
+}
+
+// keyword consumes kw in any case, when a name doesn't continue past it.
+func (r *refScanner) keyword(kw string) bool {
+	end := r.i + len(kw)
+	if end > len(r.s) || !strings.EqualFold(r.s[r.i:end], kw) || (end < len(r.s) && isNamePart(r.s[end])) {
+		return false
+	}
+	start := r.i
+	r.i = end
+	r.copy(start)
+	return true
+}
+
+func isDigit(c byte) bool { return '0' <= c && c <= '9' }
@@ -0,0 +1,139 @@
+package builder
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestQuoteColumn(t *testing.T) {
+	tests := []struct {
+		name string
+		want string // empty when the name is rejected
+	}{
+		{"email", "email"},
+		{"users.email", "users.email"},
+		{"public.users.email", "public.users.email"},
+		{"users.*", "users.*"},
+		{"*", "*"},
+		{`"Mixed Case"`, `"Mixed Case"`},
+		{`"a""b"`, `"a""b"`},
+		{"order", `"order"`},
+		{"users.user", `users."user"`},
+		{"COUNT(*)", "COUNT(*)"},
+		{"count(DISTINCT user_id)", "count(DISTINCT user_id)"},
+		{"lower(email)", "lower(email)"},
+		{"coalesce(nickname, name)", "coalesce(nickname, name)"},
+		{"date_trunc('day', created_at)", "date_trunc('day', created_at)"},
+		{"round(price, 2)", "round(price, 2)"},
+		{"data->'address'->>'city'", "data->'address'->>'city'"},
+		{"tags->0", "tags->0"},
+		{"string_agg(name, ', ')", "string_agg(name, ', ')"},
+
+		{"", ""},
+		{"name; DROP TABLE users", ""},
+		{"name --", ""},
+		{"id = 1 OR 1=1", ""},
+		{"pg_sleep(10)", ""},
+		{"lower(pg_sleep(10))", ""},
+		{"(SELECT password FROM users)", ""},
+		{"id::text", ""},
+		{"data->>'a' || 'b'", ""},
+		{`"unterminated`, ""},
+		{`""`, ""},
+		{`'a\'`, ""},
+		{"users.", ""},
+		{"1abc", ""},
+	}
+	for _, tt := range tests {
+		got, err := quoteColumn(tt.name)
+		if tt.want == "" {
+			if !errors.Is(err, ErrInvalidIdentifier) {
+				t.Errorf("quoteColumn(%q) = %q, %v; want ErrInvalidIdentifier", tt.name, got, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("quoteColumn(%q) = %q, %v; want %q", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestQuoteSelectItemAndTable(t *testing.T) {
+	tests := []struct {
+		quote func(string) (string, error)
+		name  string
+		want  string
+	}{
+		{quoteSelectItem, "COUNT(*) as count", "COUNT(*) as count"},
+		{quoteSelectItem, "email AS user", `email AS "user"`},
+		{quoteSelectItem, "email alias", ""},
+		{quoteSelectItem, "email AS x; DROP TABLE users", ""},
+		{quoteTable, "posts", "posts"},
+		{quoteTable, "blog.posts p", "blog.posts p"},
+		{quoteTable, "posts AS p", "posts AS p"},
+		{quoteTable, "order o", `"order" o`},
+		{quoteTable, "posts.*", ""},
+		{quoteTable, "posts ON true", ""},
+	}
+	for _, tt := range tests {
+		got, err := tt.quote(tt.name)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("%q accepted as %q", tt.name, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%q = %q, %v; want %q", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestBuildersRejectInvalidIdentifiers(t *testing.T) {
+	db := New(nil)
+	bad := "name; DROP TABLE test_user"
+	queries := map[string]Query{
+		"Columns":        Select[TestUser](db).Columns("id", bad),
+		"OrderBy":        Select[TestUser](db).OrderByAsc(bad),
+		"GroupBy":        Select[TestUser](db).GroupBy(bad),
+		"join table":     Select[TestUser](db).InnerJoin(bad, "true"),
+		"Where":          Select[TestUser](db).Where(Eq(bad, 1)),
+		"Set":            Update[TestUser](db).Set(bad, 1).Where(Eq("id", 1)),
+		"SetMap":         Update[TestUser](db).SetMap(map[string]interface{}{bad: 1}).Where(Eq("id", 1)),
+		"Returning":      Delete[TestUser](db).Where(Eq("id", 1)).Returning(bad),
+		"conflict":       Insert[TestUser](db).Values(TestUser{}).OnConflictDoNothing(bad),
+		"conflict set":   Insert[TestUser](db).Values(TestUser{}).OnConflictDoUpdate([]string{"id"}, map[string]interface{}{bad: 1}),
+		"first of many":  Select[TestUser](db).OrderByAsc(bad).OrderByAsc("name"),
+		"insert returns": Insert[TestUser](db).Values(TestUser{}).Returning(bad),
+	}
+	for name, q := range queries {
+		sql, _, err := q.ToSQL()
+		if !errors.Is(err, ErrInvalidIdentifier) {
+			t.Errorf("%s: ToSQL = %q, %v; want ErrInvalidIdentifier", name, sql, err)
+		}
+	}
+}
+
+func TestUnsafeExpr(t *testing.T) {
+	db := New(nil)
+	sql, _, err := Select[TestUser](db).
+		Columns("name", "order").
+		ColumnExpr("EXTRACT(YEAR FROM created_at) AS year").
+		GroupBy("name").
+		GroupByExpr("EXTRACT(YEAR FROM created_at)").
+		LeftJoin("posts p", "p.user_id = test_user.id").
+		ToSQL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`SELECT name, "order", EXTRACT(YEAR FROM created_at) AS year FROM`,
+		"LEFT JOIN posts p ON p.user_id = test_user.id",
+		"GROUP BY name, EXTRACT(YEAR FROM created_at)",
+	} {
+		if !strings.Contains(sql, want) {
+			t.Errorf("SQL %q missing %q", sql, want)
+		}
+	}
+}
@@ -2,6 +2,9 @@ package builder
 
 import (
 	"context"
+	"fmt"
+
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
 )
 
 // Values sets the values to insert (single or multiple rows).
@@ -10,25 +13,69 @@ func (q *InsertQuery[T]) Values(values ...T) *InsertQuery[T] {
 	return q
 }
 
+// UseDefaults leaves the named columns to their database defaults when their
+// fields are zero, as for columns with a default tag. Use it when the default
+// is declared only in the database.
+// Usage: builder.Insert[Post](qb).Values(post).UseDefaults("status", "created_at")
+func (q *InsertQuery[T]) UseDefaults(columns ...string) *InsertQuery[T] {
+	q.useDefaults = addUseDefaults(q.useDefaults, q.table, columns, &q.err)
+	return q
+}
+
+// addUseDefaults adds columns to a UseDefaults set, recording an error for
+// a column table doesn't have.
+func addUseDefaults(set map[string]bool, table *schema.TableMetadata, columns []string, errp *error) map[string]bool {
+	if set == nil {
+		set = make(map[string]bool, len(columns))
+	}
+	for _, col := range columns {
+		if table != nil && table.GetColumnByName(col) == nil {
+			keepErr(errp, fmt.Errorf("column %s not found in table %s", col, table.Name))
+			continue
+		}
+		set[col] = true
+	}
+	return set
+}
+
+// RefreshModel inserts models, as Values does, and has Exec write each
+// inserted row back into its model (RETURNING its readable columns), filling
+// in generated ids, defaults and trigger-set columns; writeOnly fields keep
+// their values. Models are left as they were when
+// OnConflictDoNothing skips any of the rows.
+// Usage: builder.Insert[User](qb).RefreshModel(&user).Exec(ctx)
+func (q *InsertQuery[T]) RefreshModel(models ...*T) *InsertQuery[T] {
+	for _, model := range models {
+		q.values = append(q.values, *model)
+	}
+	q.refresh = append(q.refresh, models...)
+	return q
+}
+
 // Returning specifies columns to return after insert.
 func (q *InsertQuery[T]) Returning(columns ...string) *InsertQuery[T] {
-	q.returning = columns
+	q.returning = quoteAll(columns, quoteSelectItem, &q.err)
 	return q
 }
 
 // OnConflictDoNothing adds ON CONFLICT DO NOTHING clause.
 func (q *InsertQuery[T]) OnConflictDoNothing(columns ...string) *InsertQuery[T] {
 	q.onConflict = &OnConflict{
-		Columns: columns,
+		Columns: quoteAll(columns, quoteColumn, &q.err),
 		Action:  DoNothing,
 	}
 	return q
 }
 
-// OnConflictDoUpdate adds ON CONFLICT DO UPDATE clause.
+// OnConflictDoUpdate adds ON CONFLICT DO UPDATE clause. With no updates,
+// every inserted column but columns is set to the row's new value.
+// Usage: builder.Insert[User](qb).Values(users...).OnConflictDoUpdate([]string{"email"}, nil)
 func (q *InsertQuery[T]) OnConflictDoUpdate(columns []string, updates map[string]interface{}) *InsertQuery[T] {
+	for col := range updates {
+		keepErr(&q.err, checkColumnName(col))
+	}
 	q.onConflict = &OnConflict{
-		Columns: columns,
+		Columns: quoteAll(columns, quoteColumn, &q.err),
 		Action:  DoUpdate,
 		Updates: updates,
 	}
@@ -37,25 +84,49 @@ func (q *InsertQuery[T]) OnConflictDoUpdate(columns []string, updates map[string
 
 // ToSQL generates the INSERT SQL and arguments.
 func (q *InsertQuery[T]) ToSQL() (string, []interface{}, error) {
+	if q.err != nil {
+		return "", nil, q.err
+	}
 	return buildInsertSQL(insertSpec{
-		table:      q.table,
-		rows:       toAnySlice(q.values),
-		returning:  q.returning,
-		onConflict: q.onConflict,
+		table:       q.table,
+		rows:        toAnySlice(q.values),
+		returning:   q.returning,
+		onConflict:  q.onConflict,
+		useDefaults: q.useDefaults,
 	})
 }
 
 // Exec executes the INSERT query and returns the number of inserted rows.
 func (q *InsertQuery[T]) Exec(ctx context.Context) (int64, error) {
+	if len(q.refresh) > 0 {
+		q.Returning("*")
+		rows, err := q.ExecReturning(ctx)
+		if err != nil {
+			return 0, err
+		}
+		refreshModels(q.table, q.refresh, rows)
+		return int64(len(rows)), nil
+	}
+	q, err := q.resolve(ctx)
+	if err != nil {
+		return 0, err
+	}
 	sql, args, err := q.ToSQL()
 	if err != nil {
 		return 0, err
 	}
-	return execWrite(ctx, q.db.db, sql, args, len(q.returning) > 0)
+	qctx, cancel := withDeadline(ctx, q.deadline)
+	defer cancel()
+	n, err := execWrite(qctx, q.db.writeExec(), sql, args, len(q.returning) > 0)
+	return afterWrite(ctx, q.db, q.table, n, err)
 }
 
 // ExecReturning executes the INSERT and returns the inserted rows.
 func (q *InsertQuery[T]) ExecReturning(ctx context.Context) ([]T, error) {
+	q, err := q.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
 	if len(q.returning) == 0 {
 		q.Returning("*")
 	}
@@ -63,5 +134,30 @@ func (q *InsertQuery[T]) ExecReturning(ctx context.Context) ([]T, error) {
 	if err != nil {
 		return nil, err
 	}
-	return queryRows[T](ctx, q.db.db, q.table, sql, args, nil)
+	qctx, cancel := withDeadline(ctx, q.deadline)
+	defer cancel()
+	rows, err := queryRows[T](qctx, q.db.writeExec(), q.table, sql, args, nil, q.db.relatedTables())
+	return afterWrite(ctx, q.db, q.table, rows, err)
+}
+
+// ExecReturningInto executes the INSERT and scans the returned rows into
+// dest, a pointer to a slice of structs with some of T's fields or, with one
+// Returning column, of single values. It returns the number of rows.
+// Usage: var ids []int64; n, err := builder.Insert[User](qb).Values(users...).Returning("id").ExecReturningInto(ctx, &ids)
+func (q *InsertQuery[T]) ExecReturningInto(ctx context.Context, dest interface{}) (int64, error) {
+	q, err := q.resolve(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if len(q.returning) == 0 {
+		q.Returning("*")
+	}
+	sql, args, err := q.ToSQL()
+	if err != nil {
+		return 0, err
+	}
+	qctx, cancel := withDeadline(ctx, q.deadline)
+	defer cancel()
+	n, err := scanReturning(qctx, q.db.writeExec(), q.table, sql, args, dest)
+	return afterWrite(ctx, q.db, q.table, n, err)
 }
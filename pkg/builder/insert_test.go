@@ -1,6 +1,9 @@
 package builder
 
 import (
+	"context"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/marshallshelly/pebble-orm/pkg/registry"
@@ -174,3 +177,122 @@ func TestInsertQuery_Methods(t *testing.T) {
 		}
 	})
 }
+
+func TestInsertQuery_DeterministicOnConflict(t *testing.T) {
+	if err := registry.Register(TestUser{}); err != nil {
+		t.Fatalf("Failed to register model: %v", err)
+	}
+	db := New(nil)
+	user := TestUser{ID: "1", Name: "n", Email: "e", Age: 3}
+
+	for range 20 {
+		sql, _, err := Insert[TestUser](db).
+			Values(user).
+			OnConflictDoUpdate([]string{"id"}, map[string]interface{}{"name": "n", "email": "e", "age": 3}).
+			ToSQL()
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := "INSERT INTO test_user (id, name, email, age) VALUES ($1, $2, $3, $4) ON CONFLICT (id) DO UPDATE SET age = $5, email = $6, name = $7"
+		if sql != want {
+			t.Fatalf("ToSQL() sql = %v, want %v", sql, want)
+		}
+	}
+}
+
+func TestInsertUseDefaults(t *testing.T) {
+	db := New(nil)
+	tests := []struct {
+		name     string
+		query    *InsertQuery[TestUser]
+		wantSQL  string
+		wantArgs int
+	}{
+		{
+			name:     "zero columns left out",
+			query:    Insert[TestUser](db).Values(TestUser{ID: "1", Name: "Ann", Email: "ann@example.com"}).UseDefaults("age"),
+			wantSQL:  "INSERT INTO test_user (id, name, email) VALUES ($1, $2, $3)",
+			wantArgs: 3,
+		},
+		{
+			name:     "set columns still inserted",
+			query:    Insert[TestUser](db).Values(TestUser{ID: "1", Name: "Ann", Email: "ann@example.com", Age: 30}).UseDefaults("age"),
+			wantSQL:  "INSERT INTO test_user (id, name, email, age) VALUES ($1, $2, $3, $4)",
+			wantArgs: 4,
+		},
+		{
+			name: "DEFAULT for rows that leave it zero",
+			query: Insert[TestUser](db).Values(
+				TestUser{ID: "1", Name: "Ann", Email: "ann@example.com"},
+				TestUser{ID: "2", Name: "Bob", Email: "bob@example.com", Age: 40},
+			).UseDefaults("age"),
+			wantSQL:  "INSERT INTO test_user (id, name, email, age) VALUES ($1, $2, $3, DEFAULT), ($4, $5, $6, $7)",
+			wantArgs: 7,
+		},
+		{
+			name:    "every column defaulted",
+			query:   Insert[TestUser](db).Values(TestUser{}).UseDefaults("id", "name", "email", "age"),
+			wantSQL: "INSERT INTO test_user DEFAULT VALUES",
+		},
+		{
+			name:    "every column defaulted in several rows",
+			query:   Insert[TestUser](db).Values(TestUser{}, TestUser{}).UseDefaults("id", "name", "email", "age"),
+			wantSQL: "INSERT INTO test_user (id) VALUES (DEFAULT), (DEFAULT)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := tt.query.ToSQL()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sql != tt.wantSQL || len(args) != tt.wantArgs {
+				t.Errorf("ToSQL() = %q with %d args, want %q with %d", sql, len(args), tt.wantSQL, tt.wantArgs)
+			}
+		})
+	}
+
+	if _, _, err := Insert[TestUser](db).Values(TestUser{}).UseDefaults("status").ToSQL(); err == nil {
+		t.Error("expected an error for an unknown column")
+	}
+}
+
+func TestExecReturningInto(t *testing.T) {
+	exec := &returningExecutor{columns: []string{"id"}, rows: [][]interface{}{{int64(1)}, {int64(2)}}}
+	db := NewWithExecutor(exec)
+	ctx := context.Background()
+	accounts := []TestAccount{{Email: "a@b.c", Name: "Ann"}, {Email: "b@b.c", Name: "Bob"}}
+
+	ids := []int64{99}
+	n, err := Insert[TestAccount](db).Values(accounts...).Returning("id").ExecReturningInto(ctx, &ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 || !reflect.DeepEqual(ids, []int64{1, 2}) {
+		t.Errorf("got %d rows %v, want [1 2]", n, ids)
+	}
+	if !strings.HasSuffix(exec.sql[0], "RETURNING id") {
+		t.Errorf("unexpected SQL %s", exec.sql[0])
+	}
+
+	type accountRef struct {
+		ID   int64
+		Name string
+	}
+	exec.columns = []string{"id", "email", "name"}
+	exec.rows = [][]interface{}{{int64(3), "a@b.c", "Ann"}}
+	var refs []accountRef
+	if _, err := Delete[TestAccount](db).Where(Eq("id", 3)).ExecReturningInto(ctx, &refs); err != nil {
+		t.Fatal(err)
+	}
+	if len(refs) != 1 || refs[0] != (accountRef{ID: 3, Name: "Ann"}) {
+		t.Errorf("refs = %+v", refs)
+	}
+	if !strings.HasSuffix(exec.sql[1], "RETURNING id, email, balance, name") {
+		t.Errorf("unexpected SQL %s", exec.sql[1])
+	}
+
+	if _, err := Update[TestAccount](db).Set("name", "x").ExecReturningInto(ctx, refs); err == nil {
+		t.Error("expected an error for a non-pointer dest")
+	}
+}
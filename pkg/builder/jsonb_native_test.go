@@ -1,15 +1,11 @@
-package builder
+package builder_test
 
 import (
 	"context"
 	"testing"
-	"time"
 
-	"github.com/marshallshelly/pebble-orm/pkg/registry"
-	"github.com/marshallshelly/pebble-orm/pkg/runtime"
-	"github.com/testcontainers/testcontainers-go"
-	"github.com/testcontainers/testcontainers-go/modules/postgres"
-	"github.com/testcontainers/testcontainers-go/wait"
+	"github.com/marshallshelly/pebble-orm/pkg/builder"
+	"github.com/marshallshelly/pebble-orm/pkg/pebbletest"
 )
 
 // Test models for JSONB native scanning
@@ -41,67 +37,11 @@ type DocumentWithJSONBMap struct {
 	Data  map[string]interface{} `po:"data,jsonb"` // Generic map
 }
 
-// Helper to setup test DB
-func setupJSONBTestDB(t *testing.T) (*postgres.PostgresContainer, *runtime.DB, func()) {
-	ctx := context.Background()
-
-	pgContainer, err := postgres.Run(ctx,
-		"postgres:alpine",
-		postgres.WithDatabase("testdb"),
-		postgres.WithUsername("testuser"),
-		postgres.WithPassword("testpass"),
-		testcontainers.WithWaitStrategy(
-			wait.ForLog("database system is ready to accept connections").
-				WithOccurrence(2).
-				WithStartupTimeout(60*time.Second)),
-	)
-	if err != nil {
-		t.Fatalf("failed to start postgres container: %v", err)
-	}
-
-	connStr, err := pgContainer.ConnectionString(ctx, "sslmode=disable")
-	if err != nil {
-		t.Fatalf("failed to get connection string: %v", err)
-	}
-
-	runtimeDB, err := runtime.ConnectWithURL(ctx, connStr)
-	if err != nil {
-		t.Fatalf("failed to connect to database: %v", err)
-	}
-
-	cleanup := func() {
-		runtimeDB.Close()
-		if err := pgContainer.Terminate(ctx); err != nil {
-			t.Errorf("failed to terminate container: %v", err)
-		}
-	}
-
-	return pgContainer, runtimeDB, cleanup
-}
-
 func TestJSONBNativeStructScanning(t *testing.T) {
-	_, runtimeDB, cleanup := setupJSONBTestDB(t)
-	defer cleanup()
-
+	runtimeDB := pebbletest.NewTestDB(t, DocumentWithNativeJSONB{})
 	ctx := context.Background()
 
-	// Register the model
-	reg := registry.NewRegistry()
-	reg.Register(DocumentWithNativeJSONB{})
-
-	// Create table
-	_, err := runtimeDB.Pool().Exec(ctx, `
-		CREATE TABLE document_with_native_jsonb (
-			id SERIAL PRIMARY KEY,
-			title VARCHAR(255) NOT NULL,
-			metadata JSONB
-		)
-	`)
-	if err != nil {
-		t.Fatalf("failed to create table: %v", err)
-	}
-
-	db := New(runtimeDB)
+	db := builder.New(runtimeDB)
 
 	// Test 1: Insert and retrieve a document with JSONB struct
 	t.Run("insert and select JSONB struct", func(t *testing.T) {
@@ -115,7 +55,7 @@ func TestJSONBNativeStructScanning(t *testing.T) {
 		}
 
 		// Insert
-		inserted, err := Insert[DocumentWithNativeJSONB](db).
+		inserted, err := builder.Insert[DocumentWithNativeJSONB](db).
 			Values(doc).
 			Returning("id", "title", "metadata").
 			ExecReturning(ctx)
@@ -141,8 +81,8 @@ func TestJSONBNativeStructScanning(t *testing.T) {
 		}
 
 		// Select back
-		docs, err := Select[DocumentWithNativeJSONB](db).
-			Where(Eq(Col[DocumentWithNativeJSONB]("ID"), inserted[0].ID)).
+		docs, err := builder.Select[DocumentWithNativeJSONB](db).
+			Where(builder.Eq(builder.Col[DocumentWithNativeJSONB]("ID"), inserted[0].ID)).
 			All(ctx)
 		if err != nil {
 			t.Fatalf("failed to select: %v", err)
@@ -178,8 +118,8 @@ func TestJSONBNativeStructScanning(t *testing.T) {
 			t.Fatalf("failed to insert: %v", err)
 		}
 
-		docs, err := Select[DocumentWithNativeJSONB](db).
-			Where(Eq(Col[DocumentWithNativeJSONB]("Title"), "Document with NULL metadata")).
+		docs, err := builder.Select[DocumentWithNativeJSONB](db).
+			Where(builder.Eq(builder.Col[DocumentWithNativeJSONB]("Title"), "Document with NULL metadata")).
 			All(ctx)
 		if err != nil {
 			t.Fatalf("failed to select: %v", err)
@@ -198,28 +138,10 @@ func TestJSONBNativeStructScanning(t *testing.T) {
 }
 
 func TestJSONBNativeSliceScanning(t *testing.T) {
-	_, runtimeDB, cleanup := setupJSONBTestDB(t)
-	defer cleanup()
-
+	runtimeDB := pebbletest.NewTestDB(t, DocumentWithJSONBSlice{})
 	ctx := context.Background()
 
-	// Register the model
-	reg := registry.NewRegistry()
-	reg.Register(DocumentWithJSONBSlice{})
-
-	// Create table
-	_, err := runtimeDB.Pool().Exec(ctx, `
-		CREATE TABLE document_with_jsonb_slice (
-			id SERIAL PRIMARY KEY,
-			title VARCHAR(255) NOT NULL,
-			items JSONB
-		)
-	`)
-	if err != nil {
-		t.Fatalf("failed to create table: %v", err)
-	}
-
-	db := New(runtimeDB)
+	db := builder.New(runtimeDB)
 
 	t.Run("insert and select JSONB array", func(t *testing.T) {
 		doc := DocumentWithJSONBSlice{
@@ -227,7 +149,7 @@ func TestJSONBNativeSliceScanning(t *testing.T) {
 			Items: []string{"milk", "bread", "eggs"},
 		}
 
-		inserted, err := Insert[DocumentWithJSONBSlice](db).
+		inserted, err := builder.Insert[DocumentWithJSONBSlice](db).
 			Values(doc).
 			Returning("id", "title", "items").
 			ExecReturning(ctx)
@@ -244,8 +166,8 @@ func TestJSONBNativeSliceScanning(t *testing.T) {
 		}
 
 		// Select back
-		docs, err := Select[DocumentWithJSONBSlice](db).
-			Where(Eq(Col[DocumentWithJSONBSlice]("ID"), inserted[0].ID)).
+		docs, err := builder.Select[DocumentWithJSONBSlice](db).
+			Where(builder.Eq(builder.Col[DocumentWithJSONBSlice]("ID"), inserted[0].ID)).
 			All(ctx)
 		if err != nil {
 			t.Fatalf("failed to select: %v", err)
@@ -267,28 +189,10 @@ func TestJSONBNativeSliceScanning(t *testing.T) {
 }
 
 func TestJSONBNativeMapScanning(t *testing.T) {
-	_, runtimeDB, cleanup := setupJSONBTestDB(t)
-	defer cleanup()
-
+	runtimeDB := pebbletest.NewTestDB(t, DocumentWithJSONBMap{})
 	ctx := context.Background()
 
-	// Register the model
-	reg := registry.NewRegistry()
-	reg.Register(DocumentWithJSONBMap{})
-
-	// Create table
-	_, err := runtimeDB.Pool().Exec(ctx, `
-		CREATE TABLE document_with_jsonb_map (
-			id SERIAL PRIMARY KEY,
-			title VARCHAR(255) NOT NULL,
-			data JSONB
-		)
-	`)
-	if err != nil {
-		t.Fatalf("failed to create table: %v", err)
-	}
-
-	db := New(runtimeDB)
+	db := builder.New(runtimeDB)
 
 	t.Run("insert and select JSONB map", func(t *testing.T) {
 		doc := DocumentWithJSONBMap{
@@ -300,7 +204,7 @@ func TestJSONBNativeMapScanning(t *testing.T) {
 			},
 		}
 
-		inserted, err := Insert[DocumentWithJSONBMap](db).
+		inserted, err := builder.Insert[DocumentWithJSONBMap](db).
 			Values(doc).
 			Returning("id", "title", "data").
 			ExecReturning(ctx)
@@ -317,8 +221,8 @@ func TestJSONBNativeMapScanning(t *testing.T) {
 		}
 
 		// Select back
-		docs, err := Select[DocumentWithJSONBMap](db).
-			Where(Eq(Col[DocumentWithJSONBMap]("ID"), inserted[0].ID)).
+		docs, err := builder.Select[DocumentWithJSONBMap](db).
+			Where(builder.Eq(builder.Col[DocumentWithJSONBMap]("ID"), inserted[0].ID)).
 			All(ctx)
 		if err != nil {
 			t.Fatalf("failed to select: %v", err)
@@ -363,30 +267,10 @@ type ClientActivityGroupTest struct {
 }
 
 func TestJSONBNativeCustomTypeAlias(t *testing.T) {
-	_, runtimeDB, cleanup := setupJSONBTestDB(t)
-	defer cleanup()
-
+	runtimeDB := pebbletest.NewTestDB(t, ClientActivityGroupTest{})
 	ctx := context.Background()
 
-	// Register the model
-	reg := registry.NewRegistry()
-	reg.Register(ClientActivityGroupTest{})
-
-	// Create table
-	_, err := runtimeDB.Pool().Exec(ctx, `
-		CREATE TABLE client_activity_groups_test (
-			id SERIAL PRIMARY KEY,
-			client_id VARCHAR(100) NOT NULL,
-			group_name VARCHAR(255) NOT NULL,
-			tasks JSONB,
-			is_active BOOLEAN DEFAULT true
-		)
-	`)
-	if err != nil {
-		t.Fatalf("failed to create table: %v", err)
-	}
-
-	db := New(runtimeDB)
+	db := builder.New(runtimeDB)
 
 	t.Run("insert and select custom type alias without Value/Scan", func(t *testing.T) {
 		// This is the exact pattern used in wecare-server's create-client.go
@@ -408,7 +292,7 @@ func TestJSONBNativeCustomTypeAlias(t *testing.T) {
 		}
 
 		// INSERT - this should auto-marshal the ActivityTasks to JSON
-		inserted, err := Insert[ClientActivityGroupTest](db).
+		inserted, err := builder.Insert[ClientActivityGroupTest](db).
 			Values(group).
 			Returning("id", "group_name", "tasks").
 			ExecReturning(ctx)
@@ -432,8 +316,8 @@ func TestJSONBNativeCustomTypeAlias(t *testing.T) {
 		}
 
 		// SELECT - this should auto-unmarshal the JSON back to ActivityTasks
-		groups, err := Select[ClientActivityGroupTest](db).
-			Where(Eq(Col[ClientActivityGroupTest]("GroupName"), "Personal Care")).
+		groups, err := builder.Select[ClientActivityGroupTest](db).
+			Where(builder.Eq(builder.Col[ClientActivityGroupTest]("GroupName"), "Personal Care")).
 			All(ctx)
 		if err != nil {
 			t.Fatalf("failed to select (auto-unmarshal should work): %v", err)
@@ -502,7 +386,7 @@ func TestJSONBNativeCustomTypeAlias(t *testing.T) {
 				IsActive:  true,
 			}
 
-			_, err := Insert[ClientActivityGroupTest](db).
+			_, err := builder.Insert[ClientActivityGroupTest](db).
 				Values(group).
 				Exec(ctx)
 			if err != nil {
@@ -512,8 +396,8 @@ func TestJSONBNativeCustomTypeAlias(t *testing.T) {
 		}
 
 		// Verify all were inserted
-		all, err := Select[ClientActivityGroupTest](db).
-			Where(Eq(Col[ClientActivityGroupTest]("ClientID"), "test-client-456")).
+		all, err := builder.Select[ClientActivityGroupTest](db).
+			Where(builder.Eq(builder.Col[ClientActivityGroupTest]("ClientID"), "test-client-456")).
 			All(ctx)
 		if err != nil {
 			t.Fatalf("failed to select all: %v", err)
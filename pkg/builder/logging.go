@@ -0,0 +1,233 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Config configures query logging and execution for a DB. The zero value
+// logs nothing and sends statements the pool's way.
+type Config struct {
+	// Logger receives every statement the DB and its transactions execute.
+	Logger Logger
+
+	// SlowQueryThreshold flags statements that take at least this long as
+	// slow (QueryLog.Slow). Zero flags none.
+	SlowQueryThreshold time.Duration
+
+	// RedactArgs omits query arguments from logs, for statements that carry
+	// passwords or personal data.
+	RedactArgs bool
+
+	// QueryExecMode overrides the pool's pgx.QueryExecMode for statements
+	// the DB and its transactions execute, e.g. pgx.QueryExecModeExec for a
+	// DB that goes through PgBouncer while others connect directly. Zero
+	// keeps the pool's mode; see runtime.ConnectOptions.QueryExecMode.
+	QueryExecMode pgx.QueryExecMode
+
+	// SQLComments appends a sqlcommenter comment such as
+	// /*app='api',route='listUsers'*/ to every statement, so slow queries in
+	// pg_stat_statements or the server log can be traced to the code that
+	// sent them. Tags come from CommentTags, CommentFunc and WithSQLComment,
+	// later sources winning. Logged statements don't include the comment.
+	// Each distinct comment makes a distinct statement, prepared and cached
+	// by pgx on every connection and kept apart by pg_stat_statements, so
+	// tag with low-cardinality values such as routes; per-request values
+	// such as trace IDs need QueryExecModeExec or QueryExecModeSimpleProtocol
+	// to avoid preparing every statement.
+	SQLComments bool
+
+	// CommentTags are added to every statement's comment, e.g.
+	// map[string]string{"app": "api"}.
+	CommentTags map[string]string
+
+	// CommentFunc returns tags from a statement's context, such as the
+	// W3C traceparent of the current span. Per-request tags defeat the
+	// statement cache; see SQLComments.
+	CommentFunc func(ctx context.Context) map[string]string
+
+	// Retry retries reads that fail on transient connection errors. Off
+	// by default.
+	Retry RetryPolicy
+
+	// TrackQueries keeps a registry of running statements, for
+	// DB.ActiveQueries and DB.CancelQuery.
+	TrackQueries bool
+
+	active *activeQueries // set by WithConfig when TrackQueries is
+}
+
+// QueryLog describes one executed statement.
+type QueryLog struct {
+	SQL          string
+	Args         []interface{} // nil when Config.RedactArgs is set
+	Duration     time.Duration // Until the last row was read, for queries
+	RowsAffected int64         // Rows written or returned
+	Err          error
+	Slow         bool // Duration reached Config.SlowQueryThreshold
+}
+
+// Logger receives query logs.
+type Logger interface {
+	LogQuery(ctx context.Context, entry QueryLog)
+}
+
+// LoggerFunc adapts a function to a Logger.
+type LoggerFunc func(ctx context.Context, entry QueryLog)
+
+// LogQuery calls f.
+func (f LoggerFunc) LogQuery(ctx context.Context, entry QueryLog) {
+	f(ctx, entry)
+}
+
+// NewSlogLogger returns a Logger writing to l: failed statements at error
+// level, slow ones at warn level and the rest at debug level.
+func NewSlogLogger(l *slog.Logger) Logger {
+	return LoggerFunc(func(ctx context.Context, entry QueryLog) {
+		attrs := []slog.Attr{
+			slog.String("sql", entry.SQL),
+			slog.Duration("duration", entry.Duration),
+			slog.Int64("rows", entry.RowsAffected),
+		}
+		if entry.Args != nil {
+			attrs = append(attrs, slog.Any("args", entry.Args))
+		}
+		switch {
+		case entry.Err != nil:
+			l.LogAttrs(ctx, slog.LevelError, "query failed", append(attrs, slog.Any("error", entry.Err))...)
+		case entry.Slow:
+			l.LogAttrs(ctx, slog.LevelWarn, "slow query", attrs...)
+		default:
+			l.LogAttrs(ctx, slog.LevelDebug, "query", attrs...)
+		}
+	})
+}
+
+// WithConfig returns a copy of the DB that logs statements as cfg
+// configures. Transactions begun from it log too.
+// Usage: qb := builder.New(db).WithConfig(builder.Config{Logger: builder.NewSlogLogger(slog.Default()), SlowQueryThreshold: 200 * time.Millisecond})
+func (d *DB) WithConfig(cfg Config) *DB {
+	c := *d
+	if cfg.TrackQueries {
+		cfg.active = newActiveQueries()
+	}
+	c.config = &cfg
+	return &c
+}
+
+// exec returns the connection pool as an Executor, configured by the
+// DB's Config.
+func (d *DB) exec() Executor {
+	if d.executor != nil {
+		return withConfig(d.executor, d.config)
+	}
+	return withConfig(d.db, d.config)
+}
+
+// withConfig wraps exec so it runs, comments, tracks and logs statements as
+// cfg configures, and reports timeouts and constraint violations as typed
+// errors.
+func withConfig(exec Executor, cfg *Config) Executor {
+	return withLogging(withTracking(withComments(withExecMode(errorExecutor{exec}, cfg), cfg), cfg), cfg)
+}
+
+// withLogging wraps exec so it logs statements, unless cfg has no Logger.
+func withLogging(exec Executor, cfg *Config) Executor {
+	if cfg == nil || cfg.Logger == nil {
+		return exec
+	}
+	return loggingExecutor{next: exec, cfg: cfg}
+}
+
+// loggingExecutor is an Executor that logs each statement once it is
+// done: on return for Exec, after Scan for QueryRow and on Close for Query.
+type loggingExecutor struct {
+	next Executor
+	cfg  *Config
+}
+
+func (l loggingExecutor) log(ctx context.Context, sql string, args []interface{}, start time.Time, rows int64, err error) {
+	entry := QueryLog{SQL: sql, Duration: time.Since(start), RowsAffected: rows, Err: err}
+	if !l.cfg.RedactArgs {
+		entry.Args = args
+	}
+	entry.Slow = l.cfg.SlowQueryThreshold > 0 && entry.Duration >= l.cfg.SlowQueryThreshold
+	l.cfg.Logger.LogQuery(ctx, entry)
+}
+
+func (l loggingExecutor) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	start := time.Now()
+	rows, err := l.next.Query(ctx, sql, args...)
+	if err != nil {
+		l.log(ctx, sql, args, start, 0, err)
+		return nil, err
+	}
+	return &loggingRows{Rows: rows, done: func(n int64, err error) { l.log(ctx, sql, args, start, n, err) }}, nil
+}
+
+func (l loggingExecutor) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	start := time.Now()
+	row := l.next.QueryRow(ctx, sql, args...)
+	return loggingRow{row: row, done: func(n int64, err error) { l.log(ctx, sql, args, start, n, err) }}
+}
+
+func (l loggingExecutor) Exec(ctx context.Context, sql string, args ...interface{}) (int64, error) {
+	start := time.Now()
+	n, err := l.next.Exec(ctx, sql, args...)
+	l.log(ctx, sql, args, start, n, err)
+	return n, err
+}
+
+// loggingRows logs its query when closed, explicitly or by reading the last
+// row.
+type loggingRows struct {
+	pgx.Rows
+	done   func(rows int64, err error)
+	count  int64
+	logged bool
+}
+
+func (r *loggingRows) Next() bool {
+	if r.Rows.Next() {
+		r.count++
+		return true
+	}
+	r.finish()
+	return false
+}
+
+func (r *loggingRows) Close() {
+	r.Rows.Close()
+	r.finish()
+}
+
+func (r *loggingRows) finish() {
+	if r.logged {
+		return
+	}
+	r.logged = true
+	r.done(r.count, r.Rows.Err())
+}
+
+// loggingRow logs its query when scanned.
+type loggingRow struct {
+	row  pgx.Row
+	done func(rows int64, err error)
+}
+
+func (r loggingRow) Scan(dest ...interface{}) error {
+	err := r.row.Scan(dest...)
+	switch {
+	case err == nil:
+		r.done(1, nil)
+	case errors.Is(err, pgx.ErrNoRows):
+		r.done(0, nil) // an empty result, not a failure
+	default:
+		r.done(0, err)
+	}
+	return err
+}
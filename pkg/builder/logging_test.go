@@ -0,0 +1,139 @@
+package builder
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// stubExecutor returns canned results without a database.
+type stubExecutor struct {
+	rowsAffected int64
+	err          error
+	delay        time.Duration
+}
+
+func (s stubExecutor) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	return nil, s.err
+}
+
+func (s stubExecutor) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	return stubRow{err: s.err}
+}
+
+func (s stubExecutor) Exec(ctx context.Context, sql string, args ...interface{}) (int64, error) {
+	time.Sleep(s.delay)
+	return s.rowsAffected, s.err
+}
+
+type stubRow struct{ err error }
+
+func (r stubRow) Scan(dest ...interface{}) error { return r.err }
+
+func TestLoggingExecutor(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name  string
+		cfg   Config
+		stub  stubExecutor
+		run   func(exec Executor)
+		check func(t *testing.T, entry QueryLog)
+	}{
+		{
+			name: "exec logs sql, args and rows affected",
+			stub: stubExecutor{rowsAffected: 3},
+			run:  func(exec Executor) { _, _ = exec.Exec(ctx, "UPDATE users SET active = $1", true) },
+			check: func(t *testing.T, entry QueryLog) {
+				if entry.SQL != "UPDATE users SET active = $1" || len(entry.Args) != 1 || entry.RowsAffected != 3 || entry.Slow {
+					t.Errorf("unexpected entry %+v", entry)
+				}
+			},
+		},
+		{
+			name: "redacted args",
+			cfg:  Config{RedactArgs: true},
+			run:  func(exec Executor) { _, _ = exec.Exec(ctx, "UPDATE users SET password = $1", "secret") },
+			check: func(t *testing.T, entry QueryLog) {
+				if entry.Args != nil {
+					t.Errorf("expected args to be redacted, got %v", entry.Args)
+				}
+			},
+		},
+		{
+			name: "slow query",
+			cfg:  Config{SlowQueryThreshold: time.Millisecond},
+			stub: stubExecutor{delay: 2 * time.Millisecond},
+			run:  func(exec Executor) { _, _ = exec.Exec(ctx, "SELECT pg_sleep(1)") },
+			check: func(t *testing.T, entry QueryLog) {
+				if !entry.Slow || entry.Duration < time.Millisecond {
+					t.Errorf("expected a slow query, got %+v", entry)
+				}
+			},
+		},
+		{
+			name: "failed query",
+			stub: stubExecutor{err: errors.New("boom")},
+			run:  func(exec Executor) { _, _ = exec.Query(ctx, "SELECT 1") },
+			check: func(t *testing.T, entry QueryLog) {
+				if entry.Err == nil || entry.Err.Error() != "boom" {
+					t.Errorf("expected the error to be logged, got %+v", entry)
+				}
+			},
+		},
+		{
+			name: "no rows is not a failure",
+			stub: stubExecutor{err: pgx.ErrNoRows},
+			run:  func(exec Executor) { _ = exec.QueryRow(ctx, "SELECT 1 WHERE false").Scan() },
+			check: func(t *testing.T, entry QueryLog) {
+				if entry.Err != nil || entry.RowsAffected != 0 {
+					t.Errorf("expected an empty result, got %+v", entry)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var entries []QueryLog
+			tt.cfg.Logger = LoggerFunc(func(_ context.Context, entry QueryLog) { entries = append(entries, entry) })
+			tt.run(withLogging(tt.stub, &tt.cfg))
+			if len(entries) != 1 {
+				t.Fatalf("expected 1 log entry, got %d", len(entries))
+			}
+			tt.check(t, entries[0])
+		})
+	}
+}
+
+func TestWithLoggingDisabled(t *testing.T) {
+	stub := stubExecutor{}
+	if _, ok := withLogging(stub, nil).(stubExecutor); !ok {
+		t.Error("expected no wrapping without a config")
+	}
+	if _, ok := withLogging(stub, &Config{SlowQueryThreshold: time.Second}).(stubExecutor); !ok {
+		t.Error("expected no wrapping without a logger")
+	}
+}
+
+func TestSlogLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewSlogLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	ctx := context.Background()
+
+	logger.LogQuery(ctx, QueryLog{SQL: "SELECT 1"})
+	logger.LogQuery(ctx, QueryLog{SQL: "SELECT 2", Slow: true})
+	logger.LogQuery(ctx, QueryLog{SQL: "SELECT 3", Err: errors.New("boom")})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	for i, want := range []string{"level=DEBUG msg=query", "level=WARN msg=\"slow query\"", "level=ERROR msg=\"query failed\""} {
+		if i >= len(lines) || !strings.Contains(lines[i], want) {
+			t.Errorf("line %d: expected %q in %q", i, want, buf.String())
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// AllMaps executes the query and returns each row as a map from result
+// column name to value, for reports whose shape isn't known until run time,
+// such as user-defined aggregates. Values are decoded by pgx from their
+// PostgreSQL types (see mapValue), and NULL is nil.
+// Usage: rows, err := builder.Select[Order](qb).Columns("status", "COUNT(*) AS n").GroupBy("status").AllMaps(ctx)
+func (q *SelectQuery[T]) AllMaps(ctx context.Context) ([]map[string]any, error) {
+	q, err := q.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sql, args, err := q.ToSQL()
+	if err != nil {
+		return nil, err
+	}
+	var results []map[string]any
+	err = q.readWith(ctx, false, func(ctx context.Context, exec Executor) error {
+		results, err = queryMaps(ctx, exec, sql, args)
+		return err
+	})
+	return results, err
+}
+
+// AllMaps executes the query and returns each row as a map, as
+// SelectQuery.AllMaps does.
+func (q *TxSelectQuery[T]) AllMaps() ([]map[string]any, error) {
+	sql, args, err := q.ToSQL()
+	if err != nil {
+		return nil, err
+	}
+	return queryMaps(q.tx.ctx, q.tx.exec(), sql, args)
+}
+
+// SelectRows runs a SELECT written by hand and returns each row as a map,
+// as SelectQuery.AllMaps does. It reads from a replica when the DB has
+// them. Pass values as args, never in sql.
+// Usage: rows, err := builder.SelectRows(ctx, qb, "SELECT date_trunc('day', created_at) AS day, sum(total) FROM orders GROUP BY 1")
+func SelectRows(ctx context.Context, d *DB, sql string, args ...any) ([]map[string]any, error) {
+	return queryMaps(ctx, d.readExec(false), sql, args)
+}
+
+// queryMaps runs sql and collects its rows as maps.
+func queryMaps(ctx context.Context, exec Executor, sql string, args []any) ([]map[string]any, error) {
+	rows, err := exec.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return collectMaps(rows)
+}
+
+// collectMaps reads rows into maps keyed by column name. When two columns
+// share a name, the later one wins; alias them apart.
+func collectMaps(rows pgx.Rows) ([]map[string]any, error) {
+	fields := rows.FieldDescriptions()
+	results := []map[string]any{}
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			return nil, err
+		}
+		row := make(map[string]any, len(fields))
+		for i, fd := range fields {
+			if row[fd.Name], err = mapValue(values[i]); err != nil {
+				return nil, fmt.Errorf("column %s: %w", fd.Name, err)
+			}
+		}
+		results = append(results, row)
+	}
+	return results, rows.Err()
+}
+
+// mapValue converts a value as pgx decodes it to the plain Go value AllMaps
+// returns. Most types are already plain: integers, floats, text, bool,
+// timestamps and dates (time.Time), bytea ([]byte), JSON (decoded as by
+// encoding/json) and arrays ([]any). Numeric, which pgx returns as
+// pgtype.Numeric, becomes float64 (cast to text to keep every digit), and
+// uuid becomes its string form.
+func mapValue(v any) (any, error) {
+	switch v := v.(type) {
+	case pgtype.Numeric:
+		if !v.Valid {
+			return nil, nil
+		}
+		f, err := v.Float64Value()
+		if err != nil {
+			return nil, err
+		}
+		return f.Float64, nil
+	case [16]byte:
+		return fmt.Sprintf("%x-%x-%x-%x-%x", v[0:4], v[4:6], v[6:8], v[8:10], v[10:16]), nil
+	case []any:
+		for i := range v {
+			var err error
+			if v[i], err = mapValue(v[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return v, nil
+}
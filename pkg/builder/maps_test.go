@@ -0,0 +1,59 @@
+package builder
+
+import (
+	"context"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// valueRows serves fakeRows through Values, as pgx decodes them.
+type valueRows struct{ *fakeRows }
+
+func (r valueRows) Values() ([]any, error) { return r.rows[r.i-1], nil }
+
+type valueRowsExecutor struct {
+	stubExecutor
+	rows valueRows
+	sql  string
+}
+
+func (e *valueRowsExecutor) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	e.sql = sql
+	return e.rows, nil
+}
+
+func TestAllMaps(t *testing.T) {
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	exec := &valueRowsExecutor{rows: valueRows{newFakeRows([]string{"sensor", "n", "avg", "day", "id", "tags"},
+		[]any{"t1", int64(3), pgtype.Numeric{Int: big.NewInt(215), Exp: -1, Valid: true}, day,
+			[16]byte{0x12, 0x34, 0x56, 0x78, 0x9a, 0xbc, 0xde, 0xf0, 0x12, 0x34, 0x56, 0x78, 0x9a, 0xbc, 0xde, 0xf0},
+			[]any{"a", nil}},
+		[]any{"t2", int64(0), pgtype.Numeric{}, nil, nil, nil},
+	)}}
+	rows, err := Select[ScanReading](NewWithExecutor(exec)).
+		Columns("sensor", "COUNT(*) AS n").GroupBy("sensor").AllMaps(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exec.sql != "SELECT sensor, COUNT(*) AS n FROM scan_reading GROUP BY sensor" {
+		t.Errorf("SQL = %s", exec.sql)
+	}
+	want := []map[string]any{
+		{"sensor": "t1", "n": int64(3), "avg": 21.5, "day": day, "id": "12345678-9abc-def0-1234-56789abcdef0", "tags": []any{"a", nil}},
+		{"sensor": "t2", "n": int64(0), "avg": nil, "day": nil, "id": nil, "tags": nil},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("got %v, want %v", rows, want)
+	}
+
+	exec.rows = valueRows{newFakeRows([]string{"n"})}
+	rows, err = SelectRows(context.Background(), NewWithExecutor(exec), "SELECT 1 AS n WHERE false")
+	if err != nil || rows == nil || len(rows) != 0 {
+		t.Errorf("expected no rows, got %v, %v", rows, err)
+	}
+}
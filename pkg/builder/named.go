@@ -0,0 +1,154 @@
+package builder
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Named converts the :name parameters of a raw SQL statement to positional
+// $n placeholders, returning the statement and its arguments in order. A
+// name used more than once binds one argument. Casts (::type), string
+// literals, quoted identifiers, dollar-quoted bodies and comments are left
+// alone, so a colon inside them is never taken for a parameter.
+// Usage:
+//
+//	sql, args, err := builder.Named("SELECT * FROM users WHERE email = :email AND tenant_id = :tenant",
+//		map[string]interface{}{"email": email, "tenant": tenantID})
+//	rows, err := db.Runtime().Query(ctx, sql, args...)
+func Named(sql string, params map[string]interface{}) (string, []interface{}, error) {
+	var out strings.Builder
+	out.Grow(len(sql))
+	var args []interface{}
+	positions := make(map[string]int)
+	var missing []string
+
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case c == '\'':
+			end := skipQuoted(sql, i, '\'', i > 0 && (sql[i-1] == 'E' || sql[i-1] == 'e'))
+			out.WriteString(sql[i:end])
+			i = end
+		case c == '"':
+			end := skipQuoted(sql, i, '"', false)
+			out.WriteString(sql[i:end])
+			i = end
+		case c == '-' && strings.HasPrefix(sql[i:], "--"):
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				end = len(sql) - i
+			}
+			out.WriteString(sql[i : i+end])
+			i += end
+		case c == '/' && strings.HasPrefix(sql[i:], "/*"):
+			end := skipBlockComment(sql, i)
+			out.WriteString(sql[i:end])
+			i = end
+		case c == '$':
+			end := skipDollarQuoted(sql, i)
+			out.WriteString(sql[i:end])
+			i = end
+		case c == ':' && strings.HasPrefix(sql[i:], "::"):
+			out.WriteString("::")
+			i += 2
+		case c == ':' && i+1 < len(sql) && isNameStart(sql[i+1]):
+			end := i + 2
+			for end < len(sql) && isNamePart(sql[end]) {
+				end++
+			}
+			name := sql[i+1 : end]
+			n, ok := positions[name]
+			if !ok {
+				value, found := params[name]
+				if !found {
+					missing = append(missing, name)
+				}
+				args = append(args, value)
+				n = len(args)
+				positions[name] = n
+			}
+			out.WriteByte('$')
+			out.WriteString(strconv.Itoa(n))
+			i = end
+		default:
+			out.WriteByte(c)
+			i++
+		}
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return "", nil, fmt.Errorf("missing named parameters: %s", strings.Join(missing, ", "))
+	}
+	return out.String(), args, nil
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNamePart(c byte) bool {
+	return isNameStart(c) || (c >= '0' && c <= '9')
+}
+
+// skipQuoted returns the index after the literal or identifier opened by
+// quote at start. A doubled quote is an escaped one, and so is a
+// backslash-escaped one in an E'...' string.
+func skipQuoted(sql string, start int, quote byte, backslashEscapes bool) int {
+	for i := start + 1; i < len(sql); i++ {
+		switch sql[i] {
+		case '\\':
+			if backslashEscapes {
+				i++
+			}
+		case quote:
+			if i+1 < len(sql) && sql[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(sql)
+}
+
+// skipBlockComment returns the index after the /* */ comment at start.
+// PostgreSQL block comments nest.
+func skipBlockComment(sql string, start int) int {
+	depth := 0
+	for i := start; i < len(sql)-1; i++ {
+		switch {
+		case sql[i] == '/' && sql[i+1] == '*':
+			depth++
+			i++
+		case sql[i] == '*' && sql[i+1] == '/':
+			depth--
+			i++
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return len(sql)
+}
+
+// skipDollarQuoted returns the index after the $tag$...$tag$ string at
+// start, or after the $ itself when it doesn't open one, as in $1.
+func skipDollarQuoted(sql string, start int) int {
+	end := start + 1
+	if end < len(sql) && isNameStart(sql[end]) {
+		for end < len(sql) && isNamePart(sql[end]) {
+			end++
+		}
+	}
+	if end >= len(sql) || sql[end] != '$' {
+		return start + 1
+	}
+	tag := sql[start : end+1]
+	if closing := strings.Index(sql[end+1:], tag); closing >= 0 {
+		return end + 1 + closing + len(tag)
+	}
+	return len(sql)
+}
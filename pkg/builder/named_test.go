@@ -0,0 +1,74 @@
+package builder
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNamed(t *testing.T) {
+	params := map[string]interface{}{"email": "a@example.com", "tenant": 7, "unused": true}
+	tests := []struct {
+		name     string
+		sql      string
+		wantSQL  string
+		wantArgs []interface{}
+	}{
+		{
+			name:     "positional in order of first use",
+			sql:      "SELECT * FROM users WHERE email = :email AND tenant_id = :tenant",
+			wantSQL:  "SELECT * FROM users WHERE email = $1 AND tenant_id = $2",
+			wantArgs: []interface{}{"a@example.com", 7},
+		},
+		{
+			name:     "repeated name binds once",
+			sql:      "SELECT * FROM t WHERE a = :tenant OR b = :tenant",
+			wantSQL:  "SELECT * FROM t WHERE a = $1 OR b = $1",
+			wantArgs: []interface{}{7},
+		},
+		{
+			name:     "casts",
+			sql:      "SELECT :tenant::int, created_at::date FROM t",
+			wantSQL:  "SELECT $1::int, created_at::date FROM t",
+			wantArgs: []interface{}{7},
+		},
+		{
+			name:     "literals, identifiers and comments",
+			sql:      `SELECT ':email', 'it''s :x', E'\':y', ":col" FROM t -- :z` + "\n" + `/* :a /* :b */ */ WHERE id = :tenant`,
+			wantSQL:  `SELECT ':email', 'it''s :x', E'\':y', ":col" FROM t -- :z` + "\n" + `/* :a /* :b */ */ WHERE id = $1`,
+			wantArgs: []interface{}{7},
+		},
+		{
+			name:     "dollar quotes",
+			sql:      "SELECT $$ :a $$, $fn$ :b $fn$, :email",
+			wantSQL:  "SELECT $$ :a $$, $fn$ :b $fn$, $1",
+			wantArgs: []interface{}{"a@example.com"},
+		},
+		{
+			name:     "no parameters",
+			sql:      "SELECT 1",
+			wantSQL:  "SELECT 1",
+			wantArgs: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := Named(tt.sql, params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sql != tt.wantSQL {
+				t.Errorf("sql = %q, want %q", sql, tt.wantSQL)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestNamedMissingParameters(t *testing.T) {
+	_, _, err := Named("SELECT * FROM t WHERE a = :b AND c = :a AND d = :b", nil)
+	if err == nil || err.Error() != "missing named parameters: a, b" {
+		t.Errorf("err = %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+)
+
+// Page is one page of a query's results.
+type Page[T any] struct {
+	Items   []T
+	Total   int64 // rows matching the query across all pages
+	HasNext bool  // more rows follow this page
+}
+
+// PageWithTotal returns the page of results at offset, at most limit long,
+// with the total number of matching rows. The total costs a COUNT query only
+// when the page itself can't tell: it is full, or empty past the first page.
+// Usage: page, err := builder.Select[User](qb).OrderByAsc("id").PageWithTotal(ctx, 20, 40)
+func (q *SelectQuery[T]) PageWithTotal(ctx context.Context, limit, offset int) (Page[T], error) {
+	if limit <= 0 || offset < 0 {
+		return Page[T]{}, fmt.Errorf("invalid page: limit %d, offset %d", limit, offset)
+	}
+	q, err := q.resolve(ctx)
+	if err != nil {
+		return Page[T]{}, err
+	}
+	items, err := q.Limit(limit).Offset(offset).All(ctx)
+	if err != nil {
+		return Page[T]{}, err
+	}
+	return newPage(items, limit, offset, func() (int64, error) {
+		sql, args, err := buildPageCountSQL(q.spec())
+		if err != nil {
+			return 0, err
+		}
+		return cachedRead(ctx, q.resultCache(), q.cacheTTL, q.table, sql, args, nil, countWith(ctx, q, sql, args))
+	})
+}
+
+// PageWithTotal returns the page of results at offset, at most limit long,
+// with the total number of matching rows, as SelectQuery.PageWithTotal.
+func (q *TxSelectQuery[T]) PageWithTotal(limit, offset int) (Page[T], error) {
+	if limit <= 0 || offset < 0 {
+		return Page[T]{}, fmt.Errorf("invalid page: limit %d, offset %d", limit, offset)
+	}
+	items, err := q.Limit(limit).Offset(offset).All()
+	if err != nil {
+		return Page[T]{}, err
+	}
+	return newPage(items, limit, offset, func() (int64, error) {
+		sql, args, err := buildPageCountSQL(q.spec())
+		if err != nil {
+			return 0, err
+		}
+		return queryCount(q.tx.ctx, q.tx.exec(), sql, args)
+	})
+}
+
+// newPage completes a page of items, calling count for the total only when
+// the items don't determine it.
+func newPage[T any](items []T, limit, offset int, count func() (int64, error)) (Page[T], error) {
+	end := int64(offset + len(items))
+	total := end
+	if len(items) == limit || (len(items) == 0 && offset > 0) {
+		n, err := count()
+		if err != nil {
+			return Page[T]{}, fmt.Errorf("failed to count rows: %w", err)
+		}
+		total = n
+	}
+	return Page[T]{Items: items, Total: total, HasNext: end < total}, nil
+}
+
+// buildPageCountSQL returns the statement counting every row s matches,
+// ignoring its ordering and paging. Plain filters count the table directly;
+// joins, grouping, DISTINCT, sampling and AsOf change what a row is, so
+// those count the query's own results.
+func buildPageCountSQL(s selectSpec) (string, []interface{}, error) {
+	if len(s.joins) == 0 && len(s.groupBy) == 0 && len(s.having) == 0 && !s.distinct && s.sample == "" && s.asOf == nil {
+		return buildCountSQL(s.table, s.where)
+	}
+	s.orderBy, s.limit, s.offset, s.forUpdate = nil, nil, nil, false
+	sql, args, err := buildSelectSQL(s)
+	if err != nil {
+		return "", nil, err
+	}
+	return "SELECT COUNT(*) FROM (" + sql + ") AS page_total", args, nil
+}
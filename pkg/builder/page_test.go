@@ -0,0 +1,101 @@
+package builder
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/marshallshelly/pebble-orm/pkg/registry"
+	"github.com/marshallshelly/pebble-orm/pkg/runtime"
+)
+
+func TestNewPage(t *testing.T) {
+	tests := []struct {
+		name        string
+		items       int
+		limit       int
+		offset      int
+		count       int64
+		wantTotal   int64
+		wantNext    bool
+		wantCounted bool
+	}{
+		{"short first page", 3, 10, 0, 0, 3, false, false},
+		{"short last page", 3, 10, 20, 0, 23, false, false},
+		{"empty first page", 0, 10, 0, 0, 0, false, false},
+		{"full page with more", 10, 10, 0, 25, 25, true, true},
+		{"full last page", 10, 10, 10, 20, 20, false, true},
+		{"past the end", 0, 10, 30, 25, 25, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counted := false
+			page, err := newPage(make([]int, tt.items), tt.limit, tt.offset, func() (int64, error) {
+				counted = true
+				return tt.count, nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if page.Total != tt.wantTotal || page.HasNext != tt.wantNext || counted != tt.wantCounted {
+				t.Errorf("got total %d, hasNext %v, counted %v; want %d, %v, %v",
+					page.Total, page.HasNext, counted, tt.wantTotal, tt.wantNext, tt.wantCounted)
+			}
+		})
+	}
+
+	boom := errors.New("boom")
+	if _, err := newPage(make([]int, 2), 2, 0, func() (int64, error) { return 0, boom }); !errors.Is(err, boom) {
+		t.Errorf("count error = %v, want %v", err, boom)
+	}
+}
+
+func TestBuildPageCountSQL(t *testing.T) {
+	if _, err := registry.GetOrRegister(TestUser{}); err != nil {
+		t.Fatal(err)
+	}
+	db := New(&runtime.DB{})
+
+	tests := []struct {
+		name     string
+		query    *SelectQuery[TestUser]
+		wantSQL  string
+		wantArgs int
+	}{
+		{
+			name:     "plain filter counts the table",
+			query:    Select[TestUser](db).Where(Gt("age", 18)).OrderByAsc("name").Limit(10).Offset(20),
+			wantSQL:  "SELECT COUNT(*) FROM test_user WHERE age > $1",
+			wantArgs: 1,
+		},
+		{
+			name:     "distinct counts the query's rows",
+			query:    Select[TestUser](db).Columns("name").Distinct().Where(Gt("age", 18)).OrderByAsc("name").Limit(10),
+			wantSQL:  "SELECT COUNT(*) FROM (SELECT DISTINCT name FROM test_user WHERE age > $1) AS page_total",
+			wantArgs: 1,
+		},
+		{
+			name:     "grouping counts the groups",
+			query:    Select[TestUser](db).Columns("age", "COUNT(*)").GroupBy("age").Limit(5),
+			wantSQL:  "SELECT COUNT(*) FROM (SELECT age, COUNT(*) FROM test_user GROUP BY age) AS page_total",
+			wantArgs: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := buildPageCountSQL(tt.query.spec())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sql != tt.wantSQL {
+				t.Errorf("sql = %q, want %q", sql, tt.wantSQL)
+			}
+			if len(args) != tt.wantArgs {
+				t.Errorf("args = %v, want %d", args, tt.wantArgs)
+			}
+		})
+	}
+
+	if _, err := Select[TestUser](db).PageWithTotal(t.Context(), 0, 0); err == nil {
+		t.Error("expected an error for a zero limit")
+	}
+}
@@ -2,7 +2,9 @@ package builder
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
+	"time"
 )
 
 // PostgreSQL-specific functions and operators
@@ -84,6 +86,35 @@ func JSONBPathText(column string, path ...string) string {
 	return result
 }
 
+// Hstore Operators
+
+// HstoreHasKey checks if an hstore contains a specific key
+func HstoreHasKey(column string, key string) Condition {
+	return Condition{
+		Column:   column,
+		Operator: "?",
+		Value:    key,
+	}
+}
+
+// HstoreContains checks if an hstore contains all of the given pairs
+// Usage: builder.HstoreContains("attributes", map[string]string{"color": "red"})
+func HstoreContains(column string, pairs map[string]string) Condition {
+	return Condition{
+		Column:   column,
+		Operator: "@>",
+		Value:    hstoreValue(reflect.ValueOf(pairs)),
+		ValueSQL: "%s::hstore",
+	}
+}
+
+// HstoreGet extracts the value of a key from an hstore
+// Usage: builder.Eq(builder.HstoreGet("attributes", "color"), "red") -> attributes->'color' = $1
+// The key is embedded as a SQL string literal with single quotes escaped.
+func HstoreGet(column string, key string) string {
+	return fmt.Sprintf("%s->'%s'", column, strings.ReplaceAll(key, "'", "''"))
+}
+
 // Array Operators
 
 // ArrayContains checks if array contains value
@@ -118,6 +149,64 @@ func ArrayLength(column string, dimension int) string {
 	return fmt.Sprintf("array_length(%s, %d)", column, dimension)
 }
 
+// Network Address Operators (inet/cidr)
+
+// InetContainedBy checks if address is contained by or equal to subnet (<<=)
+// Usage: InetContainedBy("ip_address", netip.MustParsePrefix("10.0.0.0/8"))
+func InetContainedBy(column string, value interface{}) Condition {
+	return Condition{
+		Column:   column,
+		Operator: "<<=",
+		Value:    value,
+	}
+}
+
+// InetStrictlyContainedBy checks if address is strictly contained by subnet (<<)
+func InetStrictlyContainedBy(column string, value interface{}) Condition {
+	return Condition{
+		Column:   column,
+		Operator: "<<",
+		Value:    value,
+	}
+}
+
+// InetContains checks if subnet contains or equals address (>>=)
+func InetContains(column string, value interface{}) Condition {
+	return Condition{
+		Column:   column,
+		Operator: ">>=",
+		Value:    value,
+	}
+}
+
+// InetStrictlyContains checks if subnet strictly contains address (>>)
+func InetStrictlyContains(column string, value interface{}) Condition {
+	return Condition{
+		Column:   column,
+		Operator: ">>",
+		Value:    value,
+	}
+}
+
+// InetOverlaps checks if either subnet contains or equals the other (&&)
+func InetOverlaps(column string, value interface{}) Condition {
+	return Condition{
+		Column:   column,
+		Operator: "&&",
+		Value:    value,
+	}
+}
+
+// Host extracts the IP address as text
+func Host(column string) string {
+	return fmt.Sprintf("host(%s)", column)
+}
+
+// Masklen returns the netmask length
+func Masklen(column string) string {
+	return fmt.Sprintf("masklen(%s)", column)
+}
+
 // PostgreSQL String Functions
 
 // ILike is case-insensitive LIKE (already defined in where.go, but documented here)
@@ -209,9 +298,31 @@ func Age(timestamp1, timestamp2 string) string {
 	return fmt.Sprintf("age(%s, %s)", timestamp1, timestamp2)
 }
 
-// Extract extracts field from timestamp
-func Extract(field, column string) string {
-	return fmt.Sprintf("extract(%s from %s)", field, column)
+// Extract extracts field from timestamp, for ColumnExpr or GroupByExpr
+func Extract(field, column string) UnsafeExpr {
+	return UnsafeExpr(fmt.Sprintf("extract(%s from %s)", field, column))
+}
+
+// OlderThan checks if timestamp is more than d in the past
+// Usage: OlderThan("created_at", 30*24*time.Hour) -> created_at < now() - $1::interval
+func OlderThan(column string, d time.Duration) Condition {
+	return Condition{
+		Column:   column,
+		Operator: OpLessThan,
+		Value:    d,
+		ValueSQL: "now() - %s::interval",
+	}
+}
+
+// NewerThan checks if timestamp is within the last d
+// Usage: NewerThan("created_at", 24*time.Hour) -> created_at > now() - $1::interval
+func NewerThan(column string, d time.Duration) Condition {
+	return Condition{
+		Column:   column,
+		Operator: OpGreaterThan,
+		Value:    d,
+		ValueSQL: "now() - %s::interval",
+	}
 }
 
 // PostgreSQL Math Functions
@@ -1,7 +1,9 @@
 package builder
 
 import (
+	"net/netip"
 	"testing"
+	"time"
 
 	"github.com/marshallshelly/pebble-orm/pkg/schema"
 )
@@ -38,6 +40,24 @@ func TestJSONBOperators(t *testing.T) {
 	}
 }
 
+func TestHstoreOperators(t *testing.T) {
+	wb := NewWhereBuilder()
+	wb.Add(HstoreHasKey("attributes", "color"))
+	wb.Add(HstoreContains("attributes", map[string]string{"size": "L"}))
+	wb.Add(Eq(HstoreGet("attributes", "it's"), "x"))
+	sql, args, err := wb.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "WHERE attributes ? $1 AND attributes @> $2::hstore AND attributes->'it''s' = $3"
+	if sql != want {
+		t.Errorf("SQL = %s, want %s", sql, want)
+	}
+	if len(args) != 3 {
+		t.Errorf("got %d args, want 3", len(args))
+	}
+}
+
 func TestArrayOperators(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -70,6 +90,68 @@ func TestArrayOperators(t *testing.T) {
 	}
 }
 
+func TestInetOperators(t *testing.T) {
+	subnet := netip.MustParsePrefix("10.0.0.0/8")
+
+	tests := []struct {
+		name        string
+		condition   Condition
+		expectedSQL string
+	}{
+		{"InetContainedBy", InetContainedBy("ip_address", subnet), "WHERE ip_address <<= $1"},
+		{"InetStrictlyContainedBy", InetStrictlyContainedBy("ip_address", subnet), "WHERE ip_address << $1"},
+		{"InetContains", InetContains("network", netip.MustParseAddr("10.1.2.3")), "WHERE network >>= $1"},
+		{"InetStrictlyContains", InetStrictlyContains("network", subnet), "WHERE network >> $1"},
+		{"InetOverlaps", InetOverlaps("network", subnet), "WHERE network && $1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wb := NewWhereBuilder()
+			wb.Add(tt.condition)
+			sql, args, err := wb.Build()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if sql != tt.expectedSQL {
+				t.Errorf("expected %q, got %q", tt.expectedSQL, sql)
+			}
+			if len(args) != 1 || args[0] != tt.condition.Value {
+				t.Errorf("expected the network value to be bound as $1, got %v", args)
+			}
+		})
+	}
+}
+
+func TestIntervalConditions(t *testing.T) {
+	tests := []struct {
+		name        string
+		condition   Condition
+		expectedSQL string
+	}{
+		{"OlderThan", OlderThan("created_at", 30*24*time.Hour), "WHERE active = $1 AND created_at < now() - $2::interval"},
+		{"NewerThan", NewerThan("created_at", time.Hour), "WHERE active = $1 AND created_at > now() - $2::interval"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wb := NewWhereBuilder()
+			wb.Add(Eq("active", true))
+			wb.Add(tt.condition)
+			sql, args, err := wb.Build()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if sql != tt.expectedSQL {
+				t.Errorf("expected %q, got %q", tt.expectedSQL, sql)
+			}
+			if len(args) != 2 || args[1] != tt.condition.Value {
+				t.Errorf("expected the duration to be bound as $2, got %v", args)
+			}
+		})
+	}
+}
+
 func TestSubqueryConditions(t *testing.T) {
 	t.Run("InSubquery", func(t *testing.T) {
 		subquery := NewSubquery("SELECT id FROM users WHERE active = true")
@@ -131,6 +213,37 @@ func TestCTEBuilder(t *testing.T) {
 			t.Errorf("expected %q, got %q", expected, sql)
 		}
 	})
+
+	t.Run("CTEs from builders with materialization", func(t *testing.T) {
+		db := New(nil)
+		sql, args, err := Select[TestUser](db).
+			Where(Eq("email", "ada@example.com")).
+			With("adults", Select[TestUser](db).Where(Gte("age", 18)), Materialized).
+			With("named", Select[TestUser](db).Where(Eq("name", "Ada")), NotMaterialized).
+			ToSQL()
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := "WITH adults AS MATERIALIZED (SELECT * FROM test_user WHERE age >= $1), " +
+			"named AS NOT MATERIALIZED (SELECT * FROM test_user WHERE name = $2) " +
+			"SELECT * FROM test_user WHERE email = $3"
+		if sql != expected {
+			t.Errorf("expected %q, got %q", expected, sql)
+		}
+		if len(args) != 3 || args[0] != 18 || args[1] != "Ada" || args[2] != "ada@example.com" {
+			t.Errorf("args = %v", args)
+		}
+	})
+
+	t.Run("CTE from builder rejects bad names", func(t *testing.T) {
+		db := New(nil)
+		if _, _, err := Select[TestUser](db).With("x AS (SELECT 1)", Select[TestUser](db)).ToSQL(); err == nil {
+			t.Error("expected an invalid CTE name to fail")
+		}
+		if _, _, err := Select[TestUser](db).With("x", Select[TestUser](db), "LATER").ToSQL(); err == nil {
+			t.Error("expected an invalid materialization to fail")
+		}
+	})
 }
 
 func TestRecursiveCTE(t *testing.T) {
@@ -240,6 +353,16 @@ func TestPostgreSQLFunctions(t *testing.T) {
 			function: ArrayLength("tags", 1),
 			expected: "array_length(tags, 1)",
 		},
+		{
+			name:     "Host",
+			function: Host("ip_address"),
+			expected: "host(ip_address)",
+		},
+		{
+			name:     "Masklen",
+			function: Masklen("network"),
+			expected: "masklen(network)",
+		},
 	}
 
 	for _, tt := range tests {
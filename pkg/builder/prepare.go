@@ -0,0 +1,110 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/marshallshelly/pebble-orm/pkg/registry"
+	"github.com/marshallshelly/pebble-orm/pkg/runtime"
+)
+
+// withExecMode wraps exec so it sends every statement with cfg's
+// QueryExecMode, unless cfg sets none.
+func withExecMode(exec Executor, cfg *Config) Executor {
+	if cfg == nil || cfg.QueryExecMode == 0 {
+		return exec
+	}
+	return execModeExecutor{next: exec, mode: cfg.QueryExecMode}
+}
+
+// execModeExecutor is an Executor that passes its mode as the first
+// argument of each statement, which pgx takes as the mode to send it with.
+type execModeExecutor struct {
+	next Executor
+	mode pgx.QueryExecMode
+}
+
+func (e execModeExecutor) args(args []interface{}) []interface{} {
+	return append([]interface{}{e.mode}, args...)
+}
+
+func (e execModeExecutor) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	return e.next.Query(ctx, sql, e.args(args)...)
+}
+
+func (e execModeExecutor) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	return e.next.QueryRow(ctx, sql, e.args(args)...)
+}
+
+func (e execModeExecutor) Exec(ctx context.Context, sql string, args ...interface{}) (int64, error) {
+	return e.next.Exec(ctx, sql, e.args(args)...)
+}
+
+// HotStatements returns the statements the builders generate for the most
+// common lookups of every registered model, in schemaName unless the model
+// sets its own: selecting by primary key (as All and First do), counting,
+// and deleting by primary key. Models without a primary key get only the
+// count. Pass them to runtime.ConnectOptions.Prepare to prepare them on
+// every new connection.
+func HotStatements(schemaName string) ([]string, error) {
+	var statements []string
+	for _, table := range registry.All() {
+		table = inSchema(table, schemaName)
+
+		countSQL, _, err := buildCountSQL(table, scoped(table, nil, false))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", table.Name, err)
+		}
+		statements = append(statements, countSQL)
+
+		pk := table.PrimaryKeyColumns()
+		if len(pk) == 0 {
+			continue
+		}
+		where := make([]Condition, len(pk))
+		for i, column := range pk {
+			where[i] = Eq(column, nil)
+		}
+		one := 1
+		for _, limit := range []*int{nil, &one} {
+			sql, _, err := buildSelectSQL(selectSpec{table: table, columns: []string{"*"}, where: scoped(table, where, false), limit: limit})
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", table.Name, err)
+			}
+			statements = append(statements, sql)
+		}
+		if !table.IsView() {
+			sql, _, err := buildDeleteSQL(deleteSpec{table: table, where: scoped(table, where, false)})
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", table.Name, err)
+			}
+			statements = append(statements, sql)
+		}
+	}
+	return statements, nil
+}
+
+// PrepareAll prepares HotStatements on the idle connections of the primary
+// and every replica, so the first lookups after startup skip the parse and
+// plan round trip. Connections opened later prepare statements as they run
+// them, or up front with runtime.ConnectOptions.Prepare.
+//
+// Don't use it with pgx.QueryExecModeExec or QueryExecModeSimpleProtocol
+// behind PgBouncer: pgx uses a prepared statement whatever the mode.
+// Usage: err := qb.PrepareAll(ctx)
+func (d *DB) PrepareAll(ctx context.Context) error {
+	if d.db == nil {
+		return nil
+	}
+	statements, err := HotStatements(d.schema)
+	if err != nil {
+		return err
+	}
+	for _, db := range append([]*runtime.DB{d.db}, d.replicas...) {
+		if _, err := db.Prepare(ctx, statements); err != nil {
+			return fmt.Errorf("failed to prepare statements: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package builder
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/marshallshelly/pebble-orm/pkg/registry"
+)
+
+// argsExecutor records the arguments of the last statement it ran.
+type argsExecutor struct {
+	stubExecutor
+	args *[]interface{}
+}
+
+func (a argsExecutor) Exec(ctx context.Context, sql string, args ...interface{}) (int64, error) {
+	*a.args = args
+	return 0, nil
+}
+
+func TestWithExecMode(t *testing.T) {
+	var args []interface{}
+	stub := argsExecutor{args: &args}
+
+	if _, ok := withExecMode(stub, &Config{}).(argsExecutor); !ok {
+		t.Error("expected no wrapper without a QueryExecMode")
+	}
+
+	exec := withConfig(stub, &Config{QueryExecMode: pgx.QueryExecModeSimpleProtocol})
+	if _, err := exec.Exec(context.Background(), "DELETE FROM users WHERE id = $1", 7); err != nil {
+		t.Fatal(err)
+	}
+	if len(args) != 2 || args[0] != pgx.QueryExecModeSimpleProtocol || args[1] != 7 {
+		t.Errorf("expected the mode before the query args, got %v", args)
+	}
+}
+
+func TestHotStatements(t *testing.T) {
+	if err := registry.Register(TestUser{}); err != nil {
+		t.Fatal(err)
+	}
+
+	statements, err := HotStatements("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`SELECT COUNT(*) FROM test_user`,
+		`SELECT * FROM test_user WHERE id = $1`,
+		`SELECT * FROM test_user WHERE id = $1 LIMIT 1`,
+		`DELETE FROM test_user WHERE id = $1`,
+	} {
+		if !slices.Contains(statements, want) {
+			t.Errorf("expected %q in %v", want, statements)
+		}
+	}
+
+	// The statements must match what the builders send, or they go unused.
+	db := &DB{}
+	sql, _, err := Select[TestUser](db).Where(Eq("id", "x")).Limit(1).ToSQL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Contains(statements, sql) {
+		t.Errorf("expected %q in %v", sql, statements)
+	}
+
+	statements, err = HotStatements("tenant_a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Contains(statements, `SELECT * FROM tenant_a.test_user WHERE id = $1`) {
+		t.Errorf("expected statements in the tenant_a schema, got %v", statements)
+	}
+}
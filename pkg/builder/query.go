@@ -3,6 +3,7 @@ package builder
 
 import (
 	"context"
+	"time"
 
 	"github.com/marshallshelly/pebble-orm/pkg/schema"
 )
@@ -25,6 +26,9 @@ type SelectQuery[T any] struct {
 	db        *DB
 	table     *schema.TableMetadata
 	columns   []string
+	colArgs   []interface{} // bound by SelectExpr columns
+	sample    string        // TABLESAMPLE clause, see TableSample
+	asOf      *time.Time    // read past versions, see AsOf
 	where     []Condition
 	joins     []Join
 	groupBy   []string
@@ -34,16 +38,26 @@ type SelectQuery[T any] struct {
 	offset    *int
 	distinct  bool
 	forUpdate bool
-	preloads  []string // Relationship fields to eagerly load
+	preloads  []string          // Relationship fields to eagerly load
+	unscoped  bool              // skip the model's default scope, see Unscoped
+	primary   bool              // read from the primary, see UsePrimary
+	cacheTTL  time.Duration     // serve from the DB's cache, see Cached
+	hints     map[string]string // planner settings, see PlannerHint
+	deadline  time.Time         // see WithDeadline
+	err       error             // first invalid argument, returned by ToSQL
 }
 
 // InsertQuery represents an INSERT query.
 type InsertQuery[T any] struct {
-	db         *DB
-	table      *schema.TableMetadata
-	values     []T
-	returning  []string
-	onConflict *OnConflict
+	db          *DB
+	table       *schema.TableMetadata
+	values      []T
+	refresh     []*T // models to write RETURNING rows into, see RefreshModel
+	returning   []string
+	onConflict  *OnConflict
+	useDefaults map[string]bool
+	deadline    time.Time
+	err         error
 }
 
 // UpdateQuery represents an UPDATE query.
@@ -53,6 +67,10 @@ type UpdateQuery[T any] struct {
 	sets      map[string]interface{}
 	where     []Condition
 	returning []string
+	unscoped  bool
+	refresh   *T // model to write the RETURNING row into, see RefreshModel
+	deadline  time.Time
+	err       error
 }
 
 // DeleteQuery represents a DELETE query.
@@ -61,6 +79,9 @@ type DeleteQuery[T any] struct {
 	table     *schema.TableMetadata
 	where     []Condition
 	returning []string
+	unscoped  bool
+	deadline  time.Time
+	err       error
 }
 
 // Condition represents a WHERE/HAVING condition.
@@ -100,11 +121,14 @@ type OrderBy struct {
 	NullsPos  NullsPosition
 }
 
-// OnConflict represents an ON CONFLICT clause for upserts.
+// OnConflict represents an ON CONFLICT clause for upserts. A DO UPDATE with
+// neither Updates nor Excluded sets every inserted column but the conflict
+// target from EXCLUDED.
 type OnConflict struct {
-	Columns []string
-	Action  ConflictAction
-	Updates map[string]interface{}
+	Columns  []string
+	Action   ConflictAction
+	Updates  map[string]interface{}
+	Excluded []string // columns set to the proposed row's value, EXCLUDED.column
 }
 
 // Operator represents a comparison operator.
@@ -133,12 +157,25 @@ const (
 	OpILike Operator = "ILIKE"
 	// OpNotLike represents the NOT LIKE operator.
 	OpNotLike Operator = "NOT LIKE"
+	// OpNotILike represents the NOT ILIKE operator.
+	OpNotILike Operator = "NOT ILIKE"
 	// OpIsNull represents the IS NULL operator.
 	OpIsNull Operator = "IS NULL"
 	// OpIsNotNull represents the IS NOT NULL operator.
 	OpIsNotNull Operator = "IS NOT NULL"
 	// OpBetween represents the BETWEEN operator.
 	OpBetween Operator = "BETWEEN"
+	// OpNotBetween represents the NOT BETWEEN operator.
+	OpNotBetween Operator = "NOT BETWEEN"
+	// OpIsTrue represents the IS TRUE operator.
+	OpIsTrue Operator = "IS TRUE"
+	// OpIsFalse represents the IS FALSE operator.
+	OpIsFalse Operator = "IS FALSE"
+	// OpIsDistinctFrom represents the IS DISTINCT FROM operator, a
+	// comparison that treats NULL as an ordinary value.
+	OpIsDistinctFrom Operator = "IS DISTINCT FROM"
+	// OpIsNotDistinctFrom represents the IS NOT DISTINCT FROM operator.
+	OpIsNotDistinctFrom Operator = "IS NOT DISTINCT FROM"
 	// OpExists represents the EXISTS operator.
 	OpExists Operator = "EXISTS"
 )
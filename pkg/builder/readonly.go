@@ -0,0 +1,72 @@
+package builder
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/marshallshelly/pebble-orm/pkg/runtime"
+)
+
+// ErrReadOnly is returned by writes through a read-only DB, see NewReadOnly.
+var ErrReadOnly = errors.New("database is read-only")
+
+// NewReadOnly creates a query builder DB that only reads. Inserts, updates,
+// deletes, truncates and materialized view refreshes fail with ErrReadOnly
+// before reaching the database, and its transactions are READ ONLY. To have
+// PostgreSQL enforce it for raw SQL too, connect db with
+// runtime.ConnectOptions{ReadOnly: true}.
+// Usage: reports := builder.NewReadOnly(replica)
+func NewReadOnly(db *runtime.DB, replicas ...*runtime.DB) *DB {
+	return New(db, replicas...).ReadOnly()
+}
+
+// ReadOnly returns a copy of the DB that only reads, as NewReadOnly does.
+// Usage: handlerDB := qb.ReadOnly()
+func (d *DB) ReadOnly() *DB {
+	c := *d
+	c.readOnly = true
+	return &c
+}
+
+// IsReadOnly reports whether the DB rejects writes.
+func (d *DB) IsReadOnly() bool {
+	return d.readOnly
+}
+
+// writeExec returns the executor for a write: one that refuses it when the
+// DB is read-only.
+func (d *DB) writeExec() Executor {
+	if d.readOnly {
+		return readOnlyExecutor{}
+	}
+	return d.exec()
+}
+
+// writeExec returns the executor for a write in the transaction, as
+// DB.writeExec does.
+func (t *Tx) writeExec() Executor {
+	if t.readOnly {
+		return readOnlyExecutor{}
+	}
+	return t.exec()
+}
+
+// readOnlyExecutor fails every statement with ErrReadOnly.
+type readOnlyExecutor struct{}
+
+func (readOnlyExecutor) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	return nil, ErrReadOnly
+}
+
+func (readOnlyExecutor) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	return readOnlyRow{}
+}
+
+func (readOnlyExecutor) Exec(ctx context.Context, sql string, args ...interface{}) (int64, error) {
+	return 0, ErrReadOnly
+}
+
+type readOnlyRow struct{}
+
+func (readOnlyRow) Scan(dest ...interface{}) error { return ErrReadOnly }
@@ -0,0 +1,75 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// readOnlyTestExecutor records the options transactions begin with.
+type readOnlyTestExecutor struct {
+	upsertExecutor
+	txOptions pgx.TxOptions
+}
+
+func (e *readOnlyTestExecutor) BeginTx(ctx context.Context, opts pgx.TxOptions) (pgx.Tx, error) {
+	e.txOptions = opts
+	return e.upsertExecutor.BeginTx(ctx, opts)
+}
+
+func TestReadOnly(t *testing.T) {
+	ctx := context.Background()
+	exec := &readOnlyTestExecutor{}
+	db := NewWithExecutor(exec).ReadOnly()
+	if !db.IsReadOnly() || NewWithExecutor(exec).IsReadOnly() {
+		t.Fatal("expected only the ReadOnly copy to be read-only")
+	}
+	setting := UpsertSetting{Key: "theme", Value: "dark"}
+
+	writes := map[string]func() error{
+		"insert": func() error { _, err := Insert[UpsertSetting](db).Values(setting).Exec(ctx); return err },
+		"insert returning": func() error {
+			_, err := Insert[UpsertSetting](db).Values(setting).ExecReturning(ctx)
+			return err
+		},
+		"update": func() error {
+			_, err := Update[UpsertSetting](db).Set("value", "light").Where(Eq("key", "theme")).Exec(ctx)
+			return err
+		},
+		"delete": func() error { _, err := Delete[UpsertSetting](db).Where(Eq("key", "theme")).Exec(ctx); return err },
+		"truncate": func() error {
+			return Truncate[UpsertSetting](db).Confirm("upsert_setting").Exec(ctx)
+		},
+		"refresh": func() error { return db.RefreshMaterializedView(ctx, "daily_sales") },
+		"upsert": func() error {
+			_, err := UpsertMany(ctx, db, []UpsertSetting{setting}, UpsertOptions{})
+			return err
+		},
+	}
+	for name, write := range writes {
+		if err := write(); !errors.Is(err, ErrReadOnly) {
+			t.Errorf("%s: err = %v, want ErrReadOnly", name, err)
+		}
+	}
+	if len(exec.sql) > 0 {
+		t.Errorf("expected no statements to run, got %q", exec.sql)
+	}
+
+	if _, err := Select[UpsertSetting](db).Count(ctx); err != nil {
+		t.Errorf("expected reads to run, got %v", err)
+	}
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	if exec.txOptions.AccessMode != pgx.ReadOnly {
+		t.Errorf("access mode = %q, want read only", exec.txOptions.AccessMode)
+	}
+	if _, err := TxInsert[UpsertSetting](tx).Values(setting).Exec(); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("transaction insert: err = %v, want ErrReadOnly", err)
+	}
+}
@@ -20,6 +20,7 @@ type relationshipLoader struct {
 	query    queryFunc
 	table    *schema.TableMetadata
 	preloads []string
+	relatedTables
 }
 
 // loadRelationships loads all preloaded relationships for a set of results.
@@ -240,14 +241,17 @@ func (q *relationshipLoader) loadRelationshipOnCollection(ctx context.Context, o
 	if err != nil {
 		return fmt.Errorf("target table %s not registered: %w", rel.TargetTable, err)
 	}
+	if targetTable, err = q.relatedTable(ctx, targetTable); err != nil {
+		return err
+	}
 
 	switch rel.Type {
 	case schema.BelongsTo:
-		return q.loadBelongsToOnCollection(ctx, objects, rel, targetTable)
+		return q.loadBelongsToOnCollection(ctx, objects, rel, sourceTable, targetTable)
 	case schema.HasOne:
-		return q.loadHasOneOnCollection(ctx, objects, rel, targetTable)
+		return q.loadHasOneOnCollection(ctx, objects, rel, sourceTable, targetTable)
 	case schema.HasMany:
-		return q.loadHasManyOnCollection(ctx, objects, rel, targetTable)
+		return q.loadHasManyOnCollection(ctx, objects, rel, sourceTable, targetTable)
 	case schema.ManyToMany:
 		// ManyToMany not yet implemented for nested preloads
 		return fmt.Errorf("nested ManyToMany preloads not yet supported")
@@ -272,6 +276,9 @@ func (q *relationshipLoader) loadBelongsTo(ctx context.Context, results reflect.
 	if err != nil {
 		return fmt.Errorf("target table %s not registered: %w", rel.TargetTable, err)
 	}
+	if targetTable, err = q.relatedTable(ctx, targetTable); err != nil {
+		return err
+	}
 
 	// Collect foreign key values from all results
 	foreignKeys := make([]interface{}, 0, results.Len())
@@ -284,7 +291,7 @@ func (q *relationshipLoader) loadBelongsTo(ctx context.Context, results reflect.
 		}
 
 		// Get the foreign key field value
-		fkField := item.FieldByName(toPascalCase(rel.ForeignKey))
+		fkField := fieldByPath(item, columnPath(q.table, rel.ForeignKey))
 		if !fkField.IsValid() {
 			continue
 		}
@@ -318,7 +325,7 @@ func (q *relationshipLoader) loadBelongsTo(ctx context.Context, results reflect.
 	typedKeys := convertToTypedSlice(foreignKeys)
 
 	// Query related records using IN clause
-	sql := fmt.Sprintf("SELECT * FROM %s WHERE %s = ANY($1)", schema.QuoteReservedIdent(targetTable.Name), schema.QuoteReservedIdent(rel.References))
+	sql := fmt.Sprintf("SELECT %s FROM %s WHERE %s = ANY($1)%s", selectStar(targetTable), schema.QuoteQualifiedIdent(targetTable.QualifiedName()), schema.QuoteReservedIdent(rel.References), scopeFilter(targetTable, schema.QuoteReservedIdent(targetTable.Name)))
 	rows, err := q.query(ctx, sql, typedKeys)
 	if err != nil {
 		return fmt.Errorf("failed to query related records: %w", err)
@@ -334,7 +341,7 @@ func (q *relationshipLoader) loadBelongsTo(ctx context.Context, results reflect.
 
 		// Get the ID value from the related record
 		relatedElem := related.Elem()
-		idField := relatedElem.FieldByName(toPascalCase(rel.References))
+		idField := fieldByPath(relatedElem, columnPath(targetTable, rel.References))
 		if !idField.IsValid() {
 			continue
 		}
@@ -380,6 +387,9 @@ func (q *relationshipLoader) loadHasOne(ctx context.Context, results reflect.Val
 	if err != nil {
 		return fmt.Errorf("target table %s not registered: %w", rel.TargetTable, err)
 	}
+	if targetTable, err = q.relatedTable(ctx, targetTable); err != nil {
+		return err
+	}
 
 	// Collect primary key values from all results
 	primaryKeys := make([]interface{}, 0, results.Len())
@@ -392,7 +402,7 @@ func (q *relationshipLoader) loadHasOne(ctx context.Context, results reflect.Val
 		}
 
 		// Get the primary key field value
-		pkField := item.FieldByName(toPascalCase(rel.References))
+		pkField := fieldByPath(item, columnPath(q.table, rel.References))
 		if !pkField.IsValid() {
 			continue
 		}
@@ -416,7 +426,7 @@ func (q *relationshipLoader) loadHasOne(ctx context.Context, results reflect.Val
 	typedKeys := convertToTypedSlice(primaryKeys)
 
 	// Query related records using IN clause
-	sql := fmt.Sprintf("SELECT * FROM %s WHERE %s = ANY($1)", schema.QuoteReservedIdent(targetTable.Name), schema.QuoteReservedIdent(rel.ForeignKey))
+	sql := fmt.Sprintf("SELECT %s FROM %s WHERE %s = ANY($1)%s", selectStar(targetTable), schema.QuoteQualifiedIdent(targetTable.QualifiedName()), schema.QuoteReservedIdent(rel.ForeignKey), scopeFilter(targetTable, schema.QuoteReservedIdent(targetTable.Name)))
 	rows, err := q.query(ctx, sql, typedKeys)
 	if err != nil {
 		return fmt.Errorf("failed to query related records: %w", err)
@@ -432,7 +442,7 @@ func (q *relationshipLoader) loadHasOne(ctx context.Context, results reflect.Val
 
 		// Get the foreign key value from the related record
 		relatedElem := related.Elem()
-		fkField := relatedElem.FieldByName(toPascalCase(rel.ForeignKey))
+		fkField := fieldByPath(relatedElem, columnPath(targetTable, rel.ForeignKey))
 		if !fkField.IsValid() {
 			continue
 		}
@@ -481,6 +491,9 @@ func (q *relationshipLoader) loadHasMany(ctx context.Context, results reflect.Va
 	if err != nil {
 		return fmt.Errorf("target table %s not registered: %w", rel.TargetTable, err)
 	}
+	if targetTable, err = q.relatedTable(ctx, targetTable); err != nil {
+		return err
+	}
 
 	// Collect primary key values from all results
 	primaryKeys := make([]interface{}, 0, results.Len())
@@ -493,7 +506,7 @@ func (q *relationshipLoader) loadHasMany(ctx context.Context, results reflect.Va
 		}
 
 		// Get the primary key field value
-		pkField := item.FieldByName(toPascalCase(rel.References))
+		pkField := fieldByPath(item, columnPath(q.table, rel.References))
 		if !pkField.IsValid() {
 			continue
 		}
@@ -525,7 +538,7 @@ func (q *relationshipLoader) loadHasMany(ctx context.Context, results reflect.Va
 	typedKeys := convertToTypedSlice(primaryKeys)
 
 	// Query related records using IN clause
-	sql := fmt.Sprintf("SELECT * FROM %s WHERE %s = ANY($1)", schema.QuoteReservedIdent(targetTable.Name), schema.QuoteReservedIdent(rel.ForeignKey))
+	sql := fmt.Sprintf("SELECT %s FROM %s WHERE %s = ANY($1)%s", selectStar(targetTable), schema.QuoteQualifiedIdent(targetTable.QualifiedName()), schema.QuoteReservedIdent(rel.ForeignKey), scopeFilter(targetTable, schema.QuoteReservedIdent(targetTable.Name)))
 	rows, err := q.query(ctx, sql, typedKeys)
 	if err != nil {
 		return fmt.Errorf("failed to query related records: %w", err)
@@ -541,7 +554,7 @@ func (q *relationshipLoader) loadHasMany(ctx context.Context, results reflect.Va
 
 		// Get the foreign key value from the related record
 		relatedElem := related.Elem()
-		fkField := relatedElem.FieldByName(toPascalCase(rel.ForeignKey))
+		fkField := fieldByPath(relatedElem, columnPath(targetTable, rel.ForeignKey))
 		if !fkField.IsValid() {
 			continue
 		}
@@ -598,6 +611,9 @@ func (q *relationshipLoader) loadManyToMany(ctx context.Context, results reflect
 	if err != nil {
 		return fmt.Errorf("target table %s not registered: %w", rel.TargetTable, err)
 	}
+	if targetTable, err = q.relatedTable(ctx, targetTable); err != nil {
+		return err
+	}
 
 	// Collect primary key values from all results
 	primaryKeys := make([]interface{}, 0, results.Len())
@@ -610,7 +626,7 @@ func (q *relationshipLoader) loadManyToMany(ctx context.Context, results reflect
 		}
 
 		// Get the primary key field value
-		pkField := item.FieldByName(toPascalCase(rel.References))
+		pkField := fieldByPath(item, columnPath(q.table, rel.References))
 		if !pkField.IsValid() {
 			continue
 		}
@@ -639,21 +655,22 @@ func (q *relationshipLoader) loadManyToMany(ctx context.Context, results reflect
 	}
 
 	// Generate foreign key column names for junction table
-	// Convention: source_table_id and target_table_id
-	sourceFKCol := toSnakeCase(q.table.GoType.Name()) + "_id"
-	targetFKCol := toSnakeCase(targetTable.GoType.Name()) + "_id"
+	// Convention: source_table_id and target_table_id (see schema.NamingStrategy)
+	naming := schema.CurrentNamingStrategy()
+	sourceFKCol := naming.ForeignKey(q.table.GoType.Name())
+	targetFKCol := naming.ForeignKey(targetTable.GoType.Name())
 
 	// Junction PKs must be scanned into the SAME Go type as the struct PK
 	// fields. Scanning into bare interface{} yields pgx's raw decoded types
 	// (int32 for an int field, [16]byte for a uuid string, etc.), which never
 	// compare equal to the struct-derived map keys — silently returning empty
 	// relations. Resolve the source/target PK field types up front.
-	refField := toPascalCase(rel.References)
-	sourceKeyType, err := pkFieldType(q.table.GoType, refField)
+	sourceKeyType, err := pkFieldType(q.table, rel.References)
 	if err != nil {
 		return fmt.Errorf("source %w", err)
 	}
-	targetKeyType, err := pkFieldType(targetTable.GoType, refField)
+	targetPath := columnPath(targetTable, rel.References)
+	targetKeyType, err := pkFieldType(targetTable, rel.References)
 	if err != nil {
 		return fmt.Errorf("target %w", err)
 	}
@@ -661,13 +678,18 @@ func (q *relationshipLoader) loadManyToMany(ctx context.Context, results reflect
 	// Convert []interface{} to typed slice for pgx encoding
 	typedKeys := convertToTypedSlice(primaryKeys)
 
+	joinTable, err := q.joinTable(ctx, *rel.JoinTable)
+	if err != nil {
+		return err
+	}
+
 	// Query the junction table first and drain it fully before the next query,
 	// so the two result sets never overlap on a single (transaction) connection.
 	junctionSQL := fmt.Sprintf(
 		"SELECT %s, %s FROM %s WHERE %s = ANY($1)",
 		schema.QuoteReservedIdent(sourceFKCol),
 		schema.QuoteReservedIdent(targetFKCol),
-		schema.QuoteReservedIdent(*rel.JoinTable),
+		schema.QuoteQualifiedIdent(joinTable),
 		schema.QuoteReservedIdent(sourceFKCol),
 	)
 
@@ -695,12 +717,13 @@ func (q *relationshipLoader) loadManyToMany(ctx context.Context, results reflect
 
 	// Query through the junction with a JOIN to fetch the target records.
 	sql := fmt.Sprintf(
-		"SELECT t.* FROM %s t INNER JOIN %s j ON t.%s = j.%s WHERE j.%s = ANY($1)",
-		schema.QuoteReservedIdent(targetTable.Name),
-		schema.QuoteReservedIdent(*rel.JoinTable),
+		"SELECT t.* FROM %s t INNER JOIN %s j ON t.%s = j.%s WHERE j.%s = ANY($1)%s",
+		schema.QuoteQualifiedIdent(targetTable.QualifiedName()),
+		schema.QuoteQualifiedIdent(joinTable),
 		schema.QuoteReservedIdent(rel.References),
 		schema.QuoteReservedIdent(targetFKCol),
 		schema.QuoteReservedIdent(sourceFKCol),
+		scopeFilter(targetTable, "t"),
 	)
 
 	rows, err := q.query(ctx, sql, typedKeys)
@@ -719,7 +742,7 @@ func (q *relationshipLoader) loadManyToMany(ctx context.Context, results reflect
 
 		// Get the ID value from the related record
 		relatedElem := related.Elem()
-		idField := relatedElem.FieldByName(refField)
+		idField := fieldByPath(relatedElem, targetPath)
 		if !idField.IsValid() {
 			continue
 		}
@@ -771,7 +794,7 @@ func (q *relationshipLoader) loadManyToMany(ctx context.Context, results reflect
 }
 
 // loadBelongsToOnCollection loads belongsTo relationships on a collection of objects.
-func (q *relationshipLoader) loadBelongsToOnCollection(ctx context.Context, objects reflect.Value, rel *schema.RelationshipMetadata, targetTable *schema.TableMetadata) error {
+func (q *relationshipLoader) loadBelongsToOnCollection(ctx context.Context, objects reflect.Value, rel *schema.RelationshipMetadata, sourceTable, targetTable *schema.TableMetadata) error {
 	// Collect foreign key values
 	foreignKeys := make([]interface{}, 0, objects.Len())
 	foreignKeyMap := make(map[interface{}][]int)
@@ -782,7 +805,7 @@ func (q *relationshipLoader) loadBelongsToOnCollection(ctx context.Context, obje
 			item = item.Elem()
 		}
 
-		fkField := item.FieldByName(toPascalCase(rel.ForeignKey))
+		fkField := fieldByPath(item, columnPath(sourceTable, rel.ForeignKey))
 		if !fkField.IsValid() {
 			continue
 		}
@@ -808,7 +831,7 @@ func (q *relationshipLoader) loadBelongsToOnCollection(ctx context.Context, obje
 	}
 
 	typedKeys := convertToTypedSlice(foreignKeys)
-	sql := fmt.Sprintf("SELECT * FROM %s WHERE %s = ANY($1)", schema.QuoteReservedIdent(targetTable.Name), schema.QuoteReservedIdent(rel.References))
+	sql := fmt.Sprintf("SELECT %s FROM %s WHERE %s = ANY($1)%s", selectStar(targetTable), schema.QuoteQualifiedIdent(targetTable.QualifiedName()), schema.QuoteReservedIdent(rel.References), scopeFilter(targetTable, schema.QuoteReservedIdent(targetTable.Name)))
 	rows, err := q.query(ctx, sql, typedKeys)
 	if err != nil {
 		return fmt.Errorf("failed to query related records: %w", err)
@@ -822,7 +845,7 @@ func (q *relationshipLoader) loadBelongsToOnCollection(ctx context.Context, obje
 		}
 
 		relatedElem := related.Elem()
-		idField := relatedElem.FieldByName(toPascalCase(rel.References))
+		idField := fieldByPath(relatedElem, columnPath(targetTable, rel.References))
 		if !idField.IsValid() {
 			continue
 		}
@@ -851,7 +874,7 @@ func (q *relationshipLoader) loadBelongsToOnCollection(ctx context.Context, obje
 }
 
 // loadHasOneOnCollection loads hasOne relationships on a collection of objects.
-func (q *relationshipLoader) loadHasOneOnCollection(ctx context.Context, objects reflect.Value, rel *schema.RelationshipMetadata, targetTable *schema.TableMetadata) error {
+func (q *relationshipLoader) loadHasOneOnCollection(ctx context.Context, objects reflect.Value, rel *schema.RelationshipMetadata, sourceTable, targetTable *schema.TableMetadata) error {
 	primaryKeys := make([]interface{}, 0, objects.Len())
 	pkMap := make(map[interface{}]int)
 
@@ -861,7 +884,7 @@ func (q *relationshipLoader) loadHasOneOnCollection(ctx context.Context, objects
 			item = item.Elem()
 		}
 
-		pkField := item.FieldByName(toPascalCase(rel.References))
+		pkField := fieldByPath(item, columnPath(sourceTable, rel.References))
 		if !pkField.IsValid() {
 			continue
 		}
@@ -880,7 +903,7 @@ func (q *relationshipLoader) loadHasOneOnCollection(ctx context.Context, objects
 	}
 
 	typedKeys := convertToTypedSlice(primaryKeys)
-	sql := fmt.Sprintf("SELECT * FROM %s WHERE %s = ANY($1)", schema.QuoteReservedIdent(targetTable.Name), schema.QuoteReservedIdent(rel.ForeignKey))
+	sql := fmt.Sprintf("SELECT %s FROM %s WHERE %s = ANY($1)%s", selectStar(targetTable), schema.QuoteQualifiedIdent(targetTable.QualifiedName()), schema.QuoteReservedIdent(rel.ForeignKey), scopeFilter(targetTable, schema.QuoteReservedIdent(targetTable.Name)))
 	rows, err := q.query(ctx, sql, typedKeys)
 	if err != nil {
 		return fmt.Errorf("failed to query related records: %w", err)
@@ -894,7 +917,7 @@ func (q *relationshipLoader) loadHasOneOnCollection(ctx context.Context, objects
 		}
 
 		relatedElem := related.Elem()
-		fkField := relatedElem.FieldByName(toPascalCase(rel.ForeignKey))
+		fkField := fieldByPath(relatedElem, columnPath(targetTable, rel.ForeignKey))
 		if !fkField.IsValid() {
 			continue
 		}
@@ -926,7 +949,7 @@ func (q *relationshipLoader) loadHasOneOnCollection(ctx context.Context, objects
 }
 
 // loadHasManyOnCollection loads hasMany relationships on a collection of objects.
-func (q *relationshipLoader) loadHasManyOnCollection(ctx context.Context, objects reflect.Value, rel *schema.RelationshipMetadata, targetTable *schema.TableMetadata) error {
+func (q *relationshipLoader) loadHasManyOnCollection(ctx context.Context, objects reflect.Value, rel *schema.RelationshipMetadata, sourceTable, targetTable *schema.TableMetadata) error {
 	primaryKeys := make([]interface{}, 0, objects.Len())
 	pkMap := make(map[interface{}]int)
 
@@ -936,7 +959,7 @@ func (q *relationshipLoader) loadHasManyOnCollection(ctx context.Context, object
 			item = item.Elem()
 		}
 
-		pkField := item.FieldByName(toPascalCase(rel.References))
+		pkField := fieldByPath(item, columnPath(sourceTable, rel.References))
 		if !pkField.IsValid() {
 			continue
 		}
@@ -963,7 +986,7 @@ func (q *relationshipLoader) loadHasManyOnCollection(ctx context.Context, object
 	}
 
 	typedKeys := convertToTypedSlice(primaryKeys)
-	sql := fmt.Sprintf("SELECT * FROM %s WHERE %s = ANY($1)", schema.QuoteReservedIdent(targetTable.Name), schema.QuoteReservedIdent(rel.ForeignKey))
+	sql := fmt.Sprintf("SELECT %s FROM %s WHERE %s = ANY($1)%s", selectStar(targetTable), schema.QuoteQualifiedIdent(targetTable.QualifiedName()), schema.QuoteReservedIdent(rel.ForeignKey), scopeFilter(targetTable, schema.QuoteReservedIdent(targetTable.Name)))
 	rows, err := q.query(ctx, sql, typedKeys)
 	if err != nil {
 		return fmt.Errorf("failed to query related records: %w", err)
@@ -977,7 +1000,7 @@ func (q *relationshipLoader) loadHasManyOnCollection(ctx context.Context, object
 		}
 
 		relatedElem := related.Elem()
-		fkField := relatedElem.FieldByName(toPascalCase(rel.ForeignKey))
+		fkField := fieldByPath(relatedElem, columnPath(targetTable, rel.ForeignKey))
 		if !fkField.IsValid() {
 			continue
 		}
@@ -1121,26 +1144,6 @@ func toUpper(ch rune) rune {
 	return ch
 }
 
-// toSnakeCase converts PascalCase to snake_case.
-func toSnakeCase(s string) string {
-	var result []rune
-	for i, ch := range s {
-		if i > 0 && ch >= 'A' && ch <= 'Z' {
-			result = append(result, '_')
-		}
-		result = append(result, toLower(ch))
-	}
-	return string(result)
-}
-
-// toLower converts a character to lowercase.
-func toLower(ch rune) rune {
-	if ch >= 'A' && ch <= 'Z' {
-		return ch + 32
-	}
-	return ch
-}
-
 // isZeroValue checks if a value is the zero value for its type.
 func isZeroValue(v interface{}) bool {
 	if v == nil {
@@ -1158,24 +1161,34 @@ func isZeroValue(v interface{}) bool {
 	}
 }
 
-// pkFieldType returns the type of the named struct field, dereferenced if it is
-// a pointer, so junction-table values can be scanned into the same type the
-// struct uses for that primary key.
-func pkFieldType(structType reflect.Type, fieldName string) (reflect.Type, error) {
-	if structType == nil {
-		return nil, fmt.Errorf("primary key field %s: struct type not available", fieldName)
+// pkFieldType returns the type of the field holding column on table,
+// dereferenced if it is a pointer (nullable keys scan as their base type).
+func pkFieldType(table *schema.TableMetadata, column string) (reflect.Type, error) {
+	if table.GoType == nil {
+		return nil, fmt.Errorf("key column %s: struct type not available", column)
+	}
+	index, t, err := fieldIndex(table.GoType, columnPath(table, column))
+	if err != nil {
+		return nil, err
 	}
-	f, ok := structType.FieldByName(fieldName)
-	if !ok {
-		return nil, fmt.Errorf("primary key field %s not found on %s", fieldName, structType.Name())
+	if index == nil {
+		return nil, fmt.Errorf("key column %s not found on %s", column, table.GoType.Name())
 	}
-	t := f.Type
 	if t.Kind() == reflect.Pointer {
 		t = t.Elem()
 	}
 	return t, nil
 }
 
+// columnPath returns the Go field path of column on table, so relationship
+// keys resolve through fields whose names don't follow the column name.
+func columnPath(table *schema.TableMetadata, column string) string {
+	if col := table.GetColumnByName(column); col != nil {
+		return col.GoField
+	}
+	return toPascalCase(column)
+}
+
 // convertToTypedSlice converts []interface{} to a properly typed slice for pgx encoding.
 // pgx cannot encode []interface{} for ANY($1) queries - it needs a typed slice like []string, []int, etc.
 func convertToTypedSlice(values []interface{}) interface{} {
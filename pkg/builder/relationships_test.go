@@ -1,8 +1,10 @@
 package builder
 
 import (
+	"context"
 	"testing"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/marshallshelly/pebble-orm/pkg/registry"
 	"github.com/marshallshelly/pebble-orm/pkg/schema"
 )
@@ -272,22 +274,20 @@ func TestHelperFunctions_ToPascalCase(t *testing.T) {
 	}
 }
 
-func TestHelperFunctions_ToSnakeCase(t *testing.T) {
+func TestJunctionForeignKeyNaming(t *testing.T) {
 	tests := []struct {
 		input    string
 		expected string
 	}{
-		{"AuthorID", "author_i_d"},
-		{"UserProfileID", "user_profile_i_d"},
-		{"ID", "i_d"},
-		{"Name", "name"},
-		{"", ""},
+		{"Author", "author_id"},
+		{"UserProfile", "user_profile_id"},
+		{"Tag", "tag_id"},
 	}
 
 	for _, tt := range tests {
-		result := toSnakeCase(tt.input)
+		result := schema.CurrentNamingStrategy().ForeignKey(tt.input)
 		if result != tt.expected {
-			t.Errorf("toSnakeCase(%s) = %s, expected %s", tt.input, result, tt.expected)
+			t.Errorf("ForeignKey(%s) = %s, expected %s", tt.input, result, tt.expected)
 		}
 	}
 }
@@ -435,3 +435,62 @@ func TestToPascalCase(t *testing.T) {
 		})
 	}
 }
+
+// Writer and Novel relate through columns whose Go fields don't follow the
+// column names, so the loaders must resolve them through the metadata.
+type Writer struct {
+	Ref    string  `po:"uuid,primaryKey,uuid"`
+	Novels []Novel `po:"-,hasMany,fk(writer_id),references(uuid)"`
+}
+
+type Novel struct {
+	ID        int     `po:"id,primaryKey,serial"`
+	WrittenBy string  `po:"writer_id,uuid,notNull"`
+	Writer    *Writer `po:"-,belongsTo,fk(writer_id),references(uuid)"`
+}
+
+func TestRelationshipKeyOverrides(t *testing.T) {
+	var queries []string
+	query := func(columns []string, rows ...[]interface{}) queryFunc {
+		return func(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+			queries = append(queries, sql)
+			return newFakeRows(columns, rows...), nil
+		}
+	}
+	ctx := context.Background()
+
+	writers, err := registry.GetOrRegister(Writer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	novels, err := registry.GetOrRegister(Novel{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rel := writers.GetRelationship("Novels"); rel.ForeignKey != "writer_id" || rel.References != "uuid" {
+		t.Fatalf("relationship = %+v", rel)
+	}
+	ws := []Writer{{Ref: "w1"}, {Ref: "w2"}}
+	l := &relationshipLoader{query: query([]string{"id", "writer_id"}, []interface{}{1, "w1"}, []interface{}{2, "w1"}), table: writers, preloads: []string{"Novels"}}
+	if err := l.loadRelationships(ctx, &ws); err != nil {
+		t.Fatal(err)
+	}
+	if len(ws[0].Novels) != 2 || len(ws[1].Novels) != 0 {
+		t.Errorf("novels = %+v", ws)
+	}
+	if want := "SELECT * FROM novel WHERE writer_id = ANY($1)"; queries[0] != want {
+		t.Errorf("SQL = %s, want %s", queries[0], want)
+	}
+
+	ns := []Novel{{ID: 1, WrittenBy: "w1"}}
+	l = &relationshipLoader{query: query([]string{"uuid"}, []interface{}{"w1"}), table: novels, preloads: []string{"Writer"}}
+	if err := l.loadRelationships(ctx, &ns); err != nil {
+		t.Fatal(err)
+	}
+	if ns[0].Writer == nil || ns[0].Writer.Ref != "w1" {
+		t.Errorf("writer = %+v", ns[0].Writer)
+	}
+	if want := "SELECT * FROM writer WHERE uuid = ANY($1)"; queries[1] != want {
+		t.Errorf("SQL = %s, want %s", queries[1], want)
+	}
+}
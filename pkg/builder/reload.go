@@ -0,0 +1,75 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/marshallshelly/pebble-orm/pkg/runtime"
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+)
+
+// Reload re-selects model by its primary key into the same struct, reading
+// from the primary so it sees the caller's own writes. The default scope is
+// ignored and writeOnly fields keep their values. It returns
+// runtime.ErrNotFound if the row is gone.
+// Usage: err := builder.Reload(ctx, qb, &user)
+func Reload[T any](ctx context.Context, d *DB, model *T) error {
+	q := Select[T](d).UsePrimary().Unscoped()
+	if q.table == nil {
+		return fmt.Errorf("table metadata not available")
+	}
+	pk := q.table.PrimaryKeyColumns()
+	if len(pk) == 0 {
+		return fmt.Errorf("%s: %w", q.table.Name, runtime.ErrNoPrimaryKey)
+	}
+	v := reflect.ValueOf(model).Elem()
+	for _, column := range pk {
+		col := q.table.GetColumnByName(column)
+		if col == nil {
+			return fmt.Errorf("%s: primary key column %s not found", q.table.Name, column)
+		}
+		field := fieldByPath(v, col.GoField)
+		if !field.IsValid() {
+			return fmt.Errorf("%s: no field for primary key column %s", q.table.Name, column)
+		}
+		q.Where(Eq(column, field.Interface()))
+	}
+
+	rows, err := q.Limit(1).All(ctx)
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return runtime.ErrNotFound
+	}
+	refreshModel(q.table, model, rows[0])
+	return nil
+}
+
+// refreshModel writes row into model, keeping the values of model's
+// writeOnly fields, which row wasn't read with.
+func refreshModel[T any](table *schema.TableMetadata, model *T, row T) {
+	current := reflect.ValueOf(model).Elem()
+	fresh := reflect.ValueOf(&row).Elem()
+	for _, col := range table.Columns {
+		if col.WriteOnly {
+			if field := fieldByPath(fresh, col.GoField); field.CanSet() {
+				field.Set(fieldByPath(current, col.GoField))
+			}
+		}
+	}
+	*model = row
+}
+
+// refreshModels writes the rows a write returned back into the models it
+// was given, in order. Nothing is written unless there is a row for every
+// model, since rows skipped by ON CONFLICT DO NOTHING can't be matched up.
+func refreshModels[T any](table *schema.TableMetadata, models []*T, rows []T) {
+	if len(rows) != len(models) {
+		return
+	}
+	for i, model := range models {
+		refreshModel(table, model, rows[i])
+	}
+}
@@ -0,0 +1,82 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/marshallshelly/pebble-orm/pkg/runtime"
+)
+
+// returningExecutor answers every query with rows and records the SQL.
+type returningExecutor struct {
+	stubExecutor
+	columns []string
+	rows    [][]interface{}
+	sql     []string
+}
+
+func (e *returningExecutor) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	e.sql = append(e.sql, sql)
+	return newFakeRows(e.columns, e.rows...), nil
+}
+
+func TestReload(t *testing.T) {
+	exec := &returningExecutor{
+		columns: []string{"id", "email", "balance", "name"},
+		rows:    [][]interface{}{{int64(7), "a@b.c", 42, "Ann Updated"}},
+	}
+	db := NewWithExecutor(exec)
+	account := TestAccount{ID: 7, PasswordHash: "secret", Name: "Ann"}
+
+	if err := Reload(context.Background(), db, &account); err != nil {
+		t.Fatal(err)
+	}
+	if want := "SELECT id, email, balance, name FROM test_account WHERE id = $1 LIMIT 1"; exec.sql[0] != want {
+		t.Errorf("SQL = %s, want %s", exec.sql[0], want)
+	}
+	if account.Name != "Ann Updated" || account.Balance != 42 || account.Email != "a@b.c" {
+		t.Errorf("model not reloaded: %+v", account)
+	}
+	if account.PasswordHash != "secret" {
+		t.Errorf("writeOnly field overwritten: %q", account.PasswordHash)
+	}
+
+	exec.rows = nil
+	if err := Reload(context.Background(), db, &account); !errors.Is(err, runtime.ErrNotFound) {
+		t.Errorf("err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestRefreshModel(t *testing.T) {
+	exec := &returningExecutor{
+		columns: []string{"id", "email", "password_hash", "balance", "name"},
+		rows:    [][]interface{}{{int64(7), "a@b.c", "x", 0, "Ann"}},
+	}
+	db := NewWithExecutor(exec)
+	ctx := context.Background()
+
+	account := TestAccount{Email: "a@b.c", PasswordHash: "x", Name: "Ann"}
+	if n, err := Insert[TestAccount](db).RefreshModel(&account).Exec(ctx); err != nil || n != 1 {
+		t.Fatalf("Exec = %d, %v", n, err)
+	}
+	if account.ID != 7 {
+		t.Errorf("inserted id not written back: %+v", account)
+	}
+	if want := "INSERT INTO test_account (email, password_hash, name) VALUES ($1, $2, $3) RETURNING id, email, balance, name"; exec.sql[0] != want {
+		t.Errorf("SQL = %s, want %s", exec.sql[0], want)
+	}
+
+	exec.columns = []string{"id", "email", "balance", "name"}
+	exec.rows = [][]interface{}{{int64(7), "a@b.c", 5, "Bob"}}
+	if _, err := Update[TestAccount](db).Set("name", "Bob").Where(Eq("id", 7)).RefreshModel(&account).Exec(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if account.Name != "Bob" || account.Balance != 5 || account.PasswordHash != "x" {
+		t.Errorf("updated row not written back, writeOnly field kept: %+v", account)
+	}
+	if want := "UPDATE test_account SET name = $1 WHERE id = $2 RETURNING id, email, balance, name"; exec.sql[1] != want {
+		t.Errorf("SQL = %s, want %s", exec.sql[1], want)
+	}
+}
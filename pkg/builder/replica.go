@@ -0,0 +1,73 @@
+package builder
+
+import (
+	"math/rand/v2"
+	"sync/atomic"
+
+	"github.com/marshallshelly/pebble-orm/pkg/runtime"
+)
+
+// LoadBalancer picks the replica that serves a read.
+type LoadBalancer interface {
+	// Next returns one of replicas, which is never empty.
+	Next(replicas []*runtime.DB) *runtime.DB
+}
+
+// LoadBalancerFunc adapts a function to a LoadBalancer.
+type LoadBalancerFunc func(replicas []*runtime.DB) *runtime.DB
+
+// Next calls f.
+func (f LoadBalancerFunc) Next(replicas []*runtime.DB) *runtime.DB {
+	return f(replicas)
+}
+
+// RoundRobin returns a LoadBalancer that cycles through the replicas in
+// order. It is the default.
+func RoundRobin() LoadBalancer {
+	var n atomic.Uint64
+	return LoadBalancerFunc(func(replicas []*runtime.DB) *runtime.DB {
+		return replicas[(n.Add(1)-1)%uint64(len(replicas))]
+	})
+}
+
+// Random returns a LoadBalancer that picks a replica at random.
+func Random() LoadBalancer {
+	return LoadBalancerFunc(func(replicas []*runtime.DB) *runtime.DB {
+		return replicas[rand.IntN(len(replicas))]
+	})
+}
+
+// WithLoadBalancer returns a copy of the DB that spreads reads across its
+// replicas with b.
+// Usage: qb := builder.New(primary, replicas...).WithLoadBalancer(builder.Random())
+func (d *DB) WithLoadBalancer(b LoadBalancer) *DB {
+	c := *d
+	c.balancer = b
+	return &c
+}
+
+// Replicas returns the replica DBs reads are routed to.
+func (d *DB) Replicas() []*runtime.DB {
+	return d.replicas
+}
+
+// readExec returns the executor for a read: a replica, unless primary is
+// set or the DB has none.
+func (d *DB) readExec(primary bool) Executor {
+	if primary || len(d.replicas) == 0 {
+		return d.exec()
+	}
+	return withConfig(d.readDB(false), d.config)
+}
+
+// readDB returns the database a read goes to, as readExec chooses it.
+func (d *DB) readDB(primary bool) *runtime.DB {
+	if primary || len(d.replicas) == 0 {
+		return d.db
+	}
+	balancer := d.balancer
+	if balancer == nil {
+		balancer = RoundRobin()
+	}
+	return balancer.Next(d.replicas)
+}
@@ -0,0 +1,52 @@
+package builder
+
+import (
+	"testing"
+
+	"github.com/marshallshelly/pebble-orm/pkg/runtime"
+)
+
+func TestReadRouting(t *testing.T) {
+	primary, r1, r2 := &runtime.DB{}, &runtime.DB{}, &runtime.DB{}
+	db := New(primary, r1, r2)
+
+	tests := []struct {
+		name  string
+		query *SelectQuery[TestUser]
+		want  []*runtime.DB
+	}{
+		{"round robin across replicas", Select[TestUser](db), []*runtime.DB{r1, r2, r1}},
+		{"use primary", Select[TestUser](db).UsePrimary(), []*runtime.DB{primary, primary}},
+		{"for update reads the primary", Select[TestUser](db).ForUpdate(), []*runtime.DB{primary}},
+		{"schema copy keeps replicas", Select[TestUser](db.WithSchema("tenant")), []*runtime.DB{r2, r1}},
+		{"no replicas", Select[TestUser](New(primary)), []*runtime.DB{primary}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i, want := range tt.want {
+				if got := tt.query.readExec().(errorExecutor).next; got != want {
+					t.Errorf("read %d: got %p, want %p", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadBalancers(t *testing.T) {
+	replicas := []*runtime.DB{{}, {}, {}}
+
+	rr := RoundRobin()
+	for i := range 6 {
+		if got := rr.Next(replicas); got != replicas[i%3] {
+			t.Errorf("round robin pick %d: got %p, want %p", i, got, replicas[i%3])
+		}
+	}
+
+	random := Random()
+	for range 20 {
+		got := random.Next(replicas)
+		if got != replicas[0] && got != replicas[1] && got != replicas[2] {
+			t.Fatalf("random picked %p, not one of the replicas", got)
+		}
+	}
+}
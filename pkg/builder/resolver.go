@@ -0,0 +1,176 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/marshallshelly/pebble-orm/pkg/registry"
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+)
+
+// TableResolver maps a model's table to the physical table a query runs
+// against, for time-sharded tables (events_2025_06) or schema-per-tenant
+// layouts (tenant_42.users). It is called with the query's context and the
+// table as registered, qualified by WithSchema, and returns a table name,
+// optionally schema-qualified; an unqualified name keeps the table's schema
+// and an empty one keeps the table. The physical table must have the
+// model's columns.
+type TableResolver func(ctx context.Context, table *schema.TableMetadata) (string, error)
+
+// WithTableResolver returns a copy of the DB whose queries, and those of its
+// transactions, run against the tables r resolves. DB queries resolve when
+// they execute, so ToSQL shows the model's table; transaction queries
+// resolve when they are created, with the transaction's context.
+// Usage: qb = qb.WithTableResolver(func(ctx context.Context, t *schema.TableMetadata) (string, error) { ... })
+func (d *DB) WithTableResolver(r TableResolver) *DB {
+	c := *d
+	c.resolver, c.related = r, r
+	return &c
+}
+
+// resolveTable returns table as r maps it for ctx, or table itself when
+// there is no resolver or it keeps the table. Registered metadata is never
+// mutated.
+func resolveTable(ctx context.Context, r TableResolver, table *schema.TableMetadata) (*schema.TableMetadata, error) {
+	if r == nil || table == nil {
+		return table, nil
+	}
+	name, err := r(ctx, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve table %s: %w", table.QualifiedName(), err)
+	}
+	schemaName, tableName := schema.SplitQualifiedName(name)
+	if tableName == "" || (tableName == table.Name && (schemaName == "" || schemaName == table.Schema)) {
+		return table, nil
+	}
+	resolved := *table
+	resolved.Name = tableName
+	if schemaName != "" {
+		resolved.Schema = schemaName
+	}
+	return &resolved, nil
+}
+
+// relatedTables says how preloads find the tables of related models: in the
+// default schema, then routed by the resolver.
+type relatedTables struct {
+	schema   string // default schema, from DB.WithSchema
+	resolver TableResolver
+}
+
+func (d *DB) relatedTables() relatedTables {
+	return relatedTables{schema: d.schema, resolver: d.related}
+}
+
+func (t *Tx) relatedTables() relatedTables {
+	return relatedTables{schema: t.schema, resolver: t.resolver}
+}
+
+// relatedTable returns a related model's table in the default schema,
+// resolved for ctx.
+func (r relatedTables) relatedTable(ctx context.Context, table *schema.TableMetadata) (*schema.TableMetadata, error) {
+	return resolveTable(ctx, r.resolver, inSchema(table, r.schema))
+}
+
+// joinTable returns the qualified name of a many-to-many junction table,
+// which follows the default schema unless qualified, resolved for ctx. A
+// registered junction model is passed to the resolver as registered.
+func (r relatedTables) joinTable(ctx context.Context, name string) (string, error) {
+	table, err := registry.GetByName(name)
+	if err != nil {
+		schemaName, tableName := schema.SplitQualifiedName(name)
+		table = &schema.TableMetadata{Name: tableName, Schema: schemaName}
+	}
+	if !strings.Contains(name, ".") {
+		table = inSchema(table, r.schema)
+	}
+	if table, err = resolveTable(ctx, r.resolver, table); err != nil {
+		return "", err
+	}
+	return table.QualifiedName(), nil
+}
+
+// modelTable returns the table of model, qualified by the transaction's
+// schema and resolved with its context.
+func (t *Tx) modelTable(model interface{}) (*schema.TableMetadata, error) {
+	table, err := registry.GetOrRegister(model)
+	if err != nil {
+		return nil, err
+	}
+	return resolveTable(t.ctx, t.resolver, inSchema(table, t.schema))
+}
+
+// resolved returns a copy of the DB without its resolver, for queries whose
+// table is resolved already. Their preloads still resolve related tables.
+func (d *DB) resolved() *DB {
+	c := *d
+	c.resolver = nil
+	return &c
+}
+
+// resolve returns the query with its table resolved for ctx.
+func (q *SelectQuery[T]) resolve(ctx context.Context) (*SelectQuery[T], error) {
+	table, err := resolveTable(ctx, q.db.resolver, q.table)
+	if err != nil || table == q.table {
+		return q, err
+	}
+	c := *q
+	c.table, c.db = table, q.db.resolved()
+	return &c, nil
+}
+
+// resolve returns the query with its table resolved for ctx.
+func (q *InsertQuery[T]) resolve(ctx context.Context) (*InsertQuery[T], error) {
+	table, err := resolveTable(ctx, q.db.resolver, q.table)
+	if err != nil || table == q.table {
+		return q, err
+	}
+	c := *q
+	c.table, c.db = table, q.db.resolved()
+	return &c, nil
+}
+
+// resolve returns the query with its table resolved for ctx.
+func (q *UpdateQuery[T]) resolve(ctx context.Context) (*UpdateQuery[T], error) {
+	table, err := resolveTable(ctx, q.db.resolver, q.table)
+	if err != nil || table == q.table {
+		return q, err
+	}
+	c := *q
+	c.table, c.db = table, q.db.resolved()
+	return &c, nil
+}
+
+// resolve returns the query with its table resolved for ctx.
+func (q *DeleteQuery[T]) resolve(ctx context.Context) (*DeleteQuery[T], error) {
+	table, err := resolveTable(ctx, q.db.resolver, q.table)
+	if err != nil || table == q.table {
+		return q, err
+	}
+	c := *q
+	c.table, c.db = table, q.db.resolved()
+	return &c, nil
+}
+
+// resolve returns the query with its table resolved for ctx.
+func (q *BulkUpdateQuery[T]) resolve(ctx context.Context) (*BulkUpdateQuery[T], error) {
+	table, err := resolveTable(ctx, q.db.resolver, q.table)
+	if err != nil || table == q.table {
+		return q, err
+	}
+	c := *q
+	c.table, c.db = table, q.db.resolved()
+	return &c, nil
+}
+
+// resolve returns the query with its table resolved for ctx.
+func (q *TruncateQuery[T]) resolve(ctx context.Context) (*TruncateQuery[T], error) {
+	table, err := resolveTable(ctx, q.db.resolver, q.table)
+	if err != nil || table == q.table {
+		return q, err
+	}
+	c := *q
+	c.table, c.db = table, q.db.resolved()
+	return &c, nil
+}
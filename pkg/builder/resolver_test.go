@@ -0,0 +1,157 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/marshallshelly/pebble-orm/pkg/registry"
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+)
+
+type ShardedEvent struct {
+	ID int64     `po:"id,primaryKey,autoIncrement"`
+	At time.Time `po:"at,timestamptz"`
+}
+
+type shardKey struct{}
+
+// shardResolver routes to the shard in the context: a month suffix, or a
+// tenant schema when it contains a dot.
+func shardResolver(ctx context.Context, table *schema.TableMetadata) (string, error) {
+	shard, ok := ctx.Value(shardKey{}).(string)
+	switch {
+	case !ok:
+		return "", errors.New("no shard in context")
+	case strings.HasSuffix(shard, "."):
+		return shard + table.Name, nil
+	}
+	return table.Name + "_" + shard, nil
+}
+
+// resolverExecutor records statements and fails queries, so reads stop
+// after their SQL is seen.
+type resolverExecutor struct {
+	upsertExecutor
+}
+
+var errStopped = errors.New("stopped")
+
+func (e *resolverExecutor) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	e.sql = append(e.sql, sql)
+	return nil, errStopped
+}
+
+func (e *resolverExecutor) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	e.sql = append(e.sql, sql)
+	return stubRow{err: errStopped}
+}
+
+func TestTableResolver(t *testing.T) {
+	exec := &resolverExecutor{}
+	db := NewWithExecutor(exec).WithTableResolver(shardResolver)
+	june := context.WithValue(context.Background(), shardKey{}, "2025_06")
+	tenant := context.WithValue(context.Background(), shardKey{}, "tenant_42.")
+
+	q := Select[ShardedEvent](db).Where(Eq("id", 1))
+	if _, err := q.All(june); !errors.Is(err, errStopped) {
+		t.Fatalf("All: err = %v", err)
+	}
+	if _, err := q.Count(tenant); !errors.Is(err, errStopped) {
+		t.Fatalf("Count: err = %v", err)
+	}
+	if _, err := Select[ShardedEvent](db).PageWithTotal(june, 10, 0); !errors.Is(err, errStopped) {
+		t.Fatalf("PageWithTotal: err = %v", err)
+	}
+	if _, err := Insert[ShardedEvent](db).Values(ShardedEvent{At: time.Now()}).Exec(tenant); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	want := []string{
+		"SELECT * FROM sharded_event_2025_06 WHERE id = $1",
+		"SELECT COUNT(*) FROM tenant_42.sharded_event WHERE id = $1",
+		"SELECT * FROM sharded_event_2025_06 LIMIT 10 OFFSET 0",
+		"INSERT INTO tenant_42.sharded_event (at) VALUES ($1)",
+	}
+	if len(exec.sql) != len(want) {
+		t.Fatalf("SQL = %q", exec.sql)
+	}
+	for i := range want {
+		if exec.sql[i] != want[i] {
+			t.Errorf("SQL %d = %s, want %s", i, exec.sql[i], want[i])
+		}
+	}
+
+	// ToSQL has no context and shows the model's table.
+	if sql, _, _ := q.ToSQL(); sql != "SELECT * FROM sharded_event WHERE id = $1" {
+		t.Errorf("ToSQL = %s", sql)
+	}
+
+	if _, err := Delete[ShardedEvent](db).Where(Eq("id", 1)).Exec(context.Background()); err == nil || !strings.Contains(err.Error(), "no shard in context") {
+		t.Errorf("expected the resolver's error, got %v", err)
+	}
+
+	tx, err := db.Begin(june)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	if sql, _, _ := TxSelect[ShardedEvent](tx).ToSQL(); sql != "SELECT * FROM sharded_event_2025_06" {
+		t.Errorf("transaction SQL = %s", sql)
+	}
+}
+
+// rowsQueue serves one result set per query and records the statements.
+type rowsQueue struct {
+	stubExecutor
+	sql  []string
+	rows []*fakeRows
+}
+
+func (e *rowsQueue) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	e.sql = append(e.sql, sql)
+	rows := e.rows[0]
+	e.rows = e.rows[1:]
+	return rows, nil
+}
+
+func TestTableResolverPreloads(t *testing.T) {
+	tenant := context.WithValue(context.Background(), shardKey{}, "tenant_42.")
+	exec := &rowsQueue{rows: []*fakeRows{
+		newFakeRows([]string{"uuid"}, []interface{}{"w1"}),
+		newFakeRows([]string{"id", "writer_id"}, []interface{}{1, "w1"}),
+	}}
+	db := NewWithExecutor(exec).WithTableResolver(shardResolver)
+	writers, err := Select[Writer](db).Preload("Novels").All(tenant)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(writers) != 1 || len(writers[0].Novels) != 1 {
+		t.Errorf("writers = %+v", writers)
+	}
+
+	if _, err := registry.GetOrRegister(Role{}); err != nil {
+		t.Fatal(err)
+	}
+	exec.rows = []*fakeRows{
+		newFakeRows([]string{"id", "name"}, []interface{}{1, "Ann"}),
+		newFakeRows([]string{"user_id", "role_id"}, []interface{}{1, 2}),
+		newFakeRows([]string{"id", "name"}, []interface{}{2, "admin"}),
+	}
+	if _, err := Select[User](db).Preload("Roles").All(tenant); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"SELECT * FROM tenant_42.writer",
+		"SELECT * FROM tenant_42.novel WHERE writer_id = ANY($1)",
+		`SELECT * FROM tenant_42."user"`,
+		"SELECT user_id, role_id FROM tenant_42.user_roles WHERE user_id = ANY($1)",
+		"SELECT t.* FROM tenant_42.role t INNER JOIN tenant_42.user_roles j ON t.id = j.role_id WHERE j.user_id = ANY($1)",
+	}
+	if strings.Join(exec.sql, "\n") != strings.Join(want, "\n") {
+		t.Errorf("SQL =\n%s\nwant\n%s", strings.Join(exec.sql, "\n"), strings.Join(want, "\n"))
+	}
+}
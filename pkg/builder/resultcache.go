@@ -0,0 +1,127 @@
+package builder
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/marshallshelly/pebble-orm/pkg/cache"
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+)
+
+// WithCache returns a copy of the DB that keeps the results of Cached
+// queries in c. Inserts, updates and deletes through the DB, and commits of
+// transactions begun from it, invalidate the cached results of the table
+// they write.
+//
+// Only a query's own table is tracked: results that depend on joined or
+// preloaded tables, or on writes made outside the builders, stay cached
+// until their TTL runs out.
+// Usage: qb := builder.New(db).WithCache(cache.NewMemory(10_000))
+func (d *DB) WithCache(c cache.Cache) *DB {
+	cp := *d
+	cp.cache = c
+	return &cp
+}
+
+// Cached serves the query's results from the DB's cache for up to ttl,
+// keyed by its SQL, arguments and preloads. Results are stored JSON-encoded,
+// so T must round-trip through encoding/json. It has no effect without
+// WithCache, or on FOR UPDATE and UsePrimary queries.
+// Usage: builder.Select[Country](qb).Cached(time.Hour).All(ctx)
+func (q *SelectQuery[T]) Cached(ttl time.Duration) *SelectQuery[T] {
+	q.cacheTTL = ttl
+	return q
+}
+
+// cachedRead returns load's result from c when present, storing it for ttl
+// otherwise. The relationships load preloads are part of the key, since
+// the same SQL returns rows with and without them. The cache is an
+// optimisation: failing to read, decode or store an entry just falls
+// through to the database.
+func cachedRead[R any](ctx context.Context, c cache.Cache, ttl time.Duration, table *schema.TableMetadata, sql string, args []interface{}, preloads []string, load func() (R, error)) (R, error) {
+	if c == nil || ttl <= 0 || table == nil {
+		return load()
+	}
+	key, ok := resultKey(sql, args, preloads)
+	if !ok {
+		return load()
+	}
+	if data, hit, err := c.Get(ctx, key); err == nil && hit {
+		var result R
+		if json.Unmarshal(data, &result) == nil {
+			return result, nil
+		}
+	}
+
+	result, err := load()
+	if err != nil {
+		return result, err
+	}
+	if data, err := json.Marshal(result); err == nil {
+		_ = c.Set(ctx, key, data, ttl, cacheTag(table))
+	}
+	return result, nil
+}
+
+// resultKey derives a cache key from a statement, its arguments and the
+// relationships preloaded with it, in any order. It reports false for
+// arguments that can't be JSON-encoded.
+func resultKey(sql string, args []interface{}, preloads []string) (string, bool) {
+	encoded, err := json.Marshal(args)
+	if err != nil {
+		return "", false
+	}
+	h := sha256.New()
+	h.Write([]byte(sql))
+	h.Write([]byte{0})
+	h.Write(encoded)
+	if len(preloads) > 0 {
+		sorted := slices.Sorted(slices.Values(preloads))
+		h.Write([]byte{0})
+		h.Write([]byte(strings.Join(slices.Compact(sorted), ",")))
+	}
+	return "pebble:" + hex.EncodeToString(h.Sum(nil)), true
+}
+
+// cacheTag is the tag cached results of table are stored under.
+func cacheTag(table *schema.TableMetadata) string {
+	return table.QualifiedName()
+}
+
+// invalidate drops the cached results of tables.
+func invalidate(ctx context.Context, c cache.Cache, tags ...string) error {
+	if c == nil || len(tags) == 0 {
+		return nil
+	}
+	if err := c.Invalidate(ctx, tags...); err != nil {
+		return fmt.Errorf("cache invalidation failed: %w", err)
+	}
+	return nil
+}
+
+// afterWrite invalidates table's cached results once a write through d has
+// succeeded, passing the write's result through.
+func afterWrite[R any](ctx context.Context, d *DB, table *schema.TableMetadata, result R, err error) (R, error) {
+	if err != nil || d.cache == nil || table == nil {
+		return result, err
+	}
+	return result, invalidate(ctx, d.cache, cacheTag(table))
+}
+
+// markDirty records that the transaction wrote table, to invalidate its
+// cached results on commit.
+func (t *Tx) markDirty(table *schema.TableMetadata) {
+	if t.cache == nil || table == nil {
+		return
+	}
+	if t.dirty == nil {
+		t.dirty = make(map[string]struct{})
+	}
+	t.dirty[cacheTag(table)] = struct{}{}
+}
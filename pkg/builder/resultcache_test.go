@@ -0,0 +1,123 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/marshallshelly/pebble-orm/pkg/cache"
+	"github.com/marshallshelly/pebble-orm/pkg/registry"
+	"github.com/marshallshelly/pebble-orm/pkg/runtime"
+)
+
+func TestCachedRead(t *testing.T) {
+	ctx := context.Background()
+	table, err := registry.GetOrRegister(TestUser{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := cache.NewMemory(0)
+
+	loads := 0
+	load := func() ([]TestUser, error) {
+		loads++
+		return []TestUser{{Name: "Ada", Age: loads}}, nil
+	}
+	read := func(c cache.Cache, ttl time.Duration, args ...interface{}) []TestUser {
+		t.Helper()
+		got, err := cachedRead(ctx, c, ttl, table, "SELECT * FROM test_user WHERE id = $1", args, nil, load)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+
+	first := read(c, time.Minute, 1)
+	if again := read(c, time.Minute, 1); loads != 1 || again[0] != first[0] {
+		t.Errorf("repeat read: loads = %d, got %+v; want 1 load and %+v", loads, again, first)
+	}
+	if read(c, time.Minute, 2); loads != 2 {
+		t.Errorf("different args: loads = %d, want 2", loads)
+	}
+	if read(nil, time.Minute, 1); loads != 3 {
+		t.Errorf("no cache: loads = %d, want 3", loads)
+	}
+	if read(c, 0, 1); loads != 4 {
+		t.Errorf("zero ttl: loads = %d, want 4", loads)
+	}
+
+	if err := invalidate(ctx, c, cacheTag(table)); err != nil {
+		t.Fatal(err)
+	}
+	if read(c, time.Minute, 1); loads != 5 {
+		t.Errorf("after invalidation: loads = %d, want 5", loads)
+	}
+
+	preload := func(preloads ...string) {
+		t.Helper()
+		if _, err := cachedRead(ctx, c, time.Minute, table, "SELECT * FROM test_user WHERE id = $1", []interface{}{1}, preloads, load); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if preload("Posts"); loads != 6 {
+		t.Errorf("preloaded read: loads = %d, want 6, not the unpreloaded entry", loads)
+	}
+	if preload("Posts"); loads != 6 {
+		t.Errorf("repeat preloaded read: loads = %d, want 6", loads)
+	}
+	if preload("Roles", "Posts"); loads != 7 {
+		t.Errorf("other preloads: loads = %d, want 7", loads)
+	}
+	if preload("Posts", "Roles"); loads != 7 {
+		t.Errorf("preloads in another order: loads = %d, want 7", loads)
+	}
+}
+
+func TestCachedReadError(t *testing.T) {
+	ctx := context.Background()
+	table, _ := registry.GetOrRegister(TestUser{})
+	c := cache.NewMemory(0)
+	boom := errors.New("boom")
+
+	_, err := cachedRead(ctx, c, time.Minute, table, "SELECT 1", nil, nil, func() (int64, error) { return 0, boom })
+	if !errors.Is(err, boom) {
+		t.Fatalf("err = %v, want %v", err, boom)
+	}
+	if c.Len() != 0 {
+		t.Errorf("failed read was cached")
+	}
+}
+
+func TestResultCacheWiring(t *testing.T) {
+	c := cache.NewMemory(0)
+	db := New(&runtime.DB{})
+	cached := db.WithCache(c)
+
+	if db.cache != nil || cached.cache != c {
+		t.Fatal("WithCache should set the cache on a copy")
+	}
+	if q := Select[TestUser](cached).Cached(time.Minute); q.resultCache() != c || q.cacheTTL != time.Minute {
+		t.Error("cached select should read through the DB's cache")
+	}
+	if q := Select[TestUser](cached).Cached(time.Minute).ForUpdate(); q.resultCache() != nil {
+		t.Error("FOR UPDATE should bypass the cache")
+	}
+	if q := Select[TestUser](cached).Cached(time.Minute).UsePrimary(); q.resultCache() != nil {
+		t.Error("UsePrimary should bypass the cache")
+	}
+	if got := cacheTag(Select[TestUser](cached.WithSchema("tenant_a")).table); got != "tenant_a.test_user" {
+		t.Errorf("schema-qualified tag = %q", got)
+	}
+
+	tx := &Tx{cache: c}
+	tx.markDirty(Select[TestUser](cached).table)
+	if _, ok := tx.dirty["test_user"]; !ok || len(tx.dirty) != 1 {
+		t.Errorf("dirty = %v, want test_user", tx.dirty)
+	}
+	untracked := &Tx{}
+	untracked.markDirty(Select[TestUser](cached).table)
+	if untracked.dirty != nil {
+		t.Error("transaction without a cache should not track writes")
+	}
+}
@@ -0,0 +1,88 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// RetryPolicy retries SELECTs that fail on a transient connection error,
+// such as a dropped connection during a failover. Only reads outside
+// transactions are retried, and never ForUpdate selects or queries with a
+// WITH clause, which may write. The zero value retries nothing.
+type RetryPolicy struct {
+	// MaxAttempts is the number of tries, the first included. Below 2 turns
+	// retries off.
+	MaxAttempts int
+
+	// Backoff is the wait before the second try, doubled before each one
+	// after up to MaxBackoff. Zero means 50ms.
+	Backoff    time.Duration
+	MaxBackoff time.Duration // Zero means 1s.
+
+	// Retryable reports whether a failed read may be retried. Nil means
+	// IsTransient.
+	Retryable func(err error) bool
+}
+
+// IsTransient reports whether err is a connection failure a retry may get
+// past: the connection failed or was closed, or the server is shutting down
+// or starting up. Timeouts and canceled contexts are not transient.
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, ErrTimeout) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// Class 08 is connection exceptions; 57P01-57P03 are admin and crash
+		// shutdowns and a server that can't take connections yet.
+		return strings.HasPrefix(pgErr.Code, "08") || pgErr.Code == "57P01" || pgErr.Code == "57P02" || pgErr.Code == "57P03"
+	}
+	var connectErr *pgconn.ConnectError
+	var netErr net.Error
+	return pgconn.SafeToRetry(err) || errors.As(err, &connectErr) || errors.As(err, &netErr) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, net.ErrClosed)
+}
+
+// retry runs fn until it succeeds, fails with an error p doesn't retry,
+// has run p.MaxAttempts times or ctx is done.
+func (p RetryPolicy) retry(ctx context.Context, fn func() error) error {
+	err := fn()
+	wait := p.Backoff
+	if wait == 0 {
+		wait = 50 * time.Millisecond
+	}
+	maxWait := p.MaxBackoff
+	if maxWait == 0 {
+		maxWait = time.Second
+	}
+	retryable := p.Retryable
+	if retryable == nil {
+		retryable = IsTransient
+	}
+	for attempt := 1; err != nil && attempt < p.MaxAttempts && retryable(err); attempt++ {
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		wait = min(2*wait, maxWait)
+		err = fn()
+	}
+	return err
+}
+
+// retryPolicy returns the DB's RetryPolicy, the zero one if it has none.
+func (d *DB) retryPolicy() RetryPolicy {
+	if d.config == nil {
+		return RetryPolicy{}
+	}
+	return d.config.Retry
+}
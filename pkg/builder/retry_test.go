@@ -0,0 +1,94 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/marshallshelly/pebble-orm/pkg/runtime"
+)
+
+// flakyExecutor fails its first queries with err, then returns no rows.
+type flakyExecutor struct {
+	stubExecutor
+	failures int
+	err      error
+	calls    int
+}
+
+func (e *flakyExecutor) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	e.calls++
+	if e.calls <= e.failures {
+		return nil, e.err
+	}
+	return newFakeRows([]string{"id"}), nil
+}
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"admin shutdown", &pgconn.PgError{Code: "57P01"}, true},
+		{"connection failure", &pgconn.PgError{Code: "08006"}, true},
+		{"connection closed", fmt.Errorf("read: %w", io.ErrUnexpectedEOF), true},
+		{"unique violation", &pgconn.PgError{Code: "23505"}, false},
+		{"timeout", ErrTimeout, false},
+		{"canceled", context.Canceled, false},
+		{"nil", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsTransient(tt.err); got != tt.want {
+				t.Errorf("IsTransient(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicy(t *testing.T) {
+	ctx := context.Background()
+	policy := RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}
+	shutdown := &pgconn.PgError{Code: "57P01"}
+
+	exec := &flakyExecutor{failures: 2, err: shutdown}
+	db := NewWithExecutor(exec).WithConfig(Config{Retry: policy})
+	if _, err := Select[TestUser](db).All(ctx); err != nil || exec.calls != 3 {
+		t.Errorf("err = %v after %d calls, want success on the 3rd", err, exec.calls)
+	}
+
+	exec = &flakyExecutor{failures: 3, err: shutdown}
+	db = NewWithExecutor(exec).WithConfig(Config{Retry: policy})
+	if _, err := Select[TestUser](db).All(ctx); !errors.As(err, new(*pgconn.PgError)) || exec.calls != 3 {
+		t.Errorf("err = %v after %d calls, want the last error after 3", err, exec.calls)
+	}
+
+	exec = &flakyExecutor{failures: 1, err: &pgconn.PgError{Code: "23505"}}
+	db = NewWithExecutor(exec).WithConfig(Config{Retry: policy})
+	if _, err := Select[TestUser](db).All(ctx); err == nil || exec.calls != 1 {
+		t.Errorf("non-transient error retried: %v after %d calls", err, exec.calls)
+	}
+
+	exec = &flakyExecutor{failures: 1, err: shutdown}
+	db = NewWithExecutor(exec).WithConfig(Config{Retry: policy})
+	if _, err := Select[TestUser](db).ForUpdate().All(ctx); err == nil || exec.calls != 1 {
+		t.Errorf("ForUpdate select retried: %v after %d calls", err, exec.calls)
+	}
+
+	exec = &flakyExecutor{failures: 1, err: shutdown}
+	db = NewWithExecutor(exec).WithConfig(Config{Retry: policy})
+	if _, err := FindByPK[TestUser](NewSession(ctx), db, "u1"); !errors.Is(err, runtime.ErrNotFound) || exec.calls != 2 {
+		t.Errorf("session lookup: err = %v after %d calls, want ErrNotFound on the 2nd", err, exec.calls)
+	}
+
+	exec = &flakyExecutor{failures: 1, err: shutdown}
+	if _, err := Select[TestUser](NewWithExecutor(exec)).All(ctx); err == nil || exec.calls != 1 {
+		t.Errorf("retried without a policy: %v after %d calls", err, exec.calls)
+	}
+}
@@ -0,0 +1,51 @@
+package builder
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Sample is a TABLESAMPLE clause, reading a percentage of a table instead of
+// all of it. Aggregates over a sample are estimates; scale counts and sums by
+// 100/percent.
+type Sample struct {
+	method  string
+	percent float64
+	seed    *int64
+}
+
+// Bernoulli samples each row with the given probability, in percent. It
+// still reads the whole table but returns an even sample.
+// Usage: builder.Select[Event](qb).TableSample(builder.Bernoulli(1)).All(ctx)
+func Bernoulli(percent float64) Sample {
+	return Sample{method: "BERNOULLI", percent: percent}
+}
+
+// System samples whole pages with the given probability, in percent. It
+// reads only the sampled pages, so it is much faster than Bernoulli on large
+// tables, but rows stored together are sampled together.
+func System(percent float64) Sample {
+	return Sample{method: "SYSTEM", percent: percent}
+}
+
+// Repeatable makes the sample the same on every run with seed, as long as
+// the table doesn't change.
+func (s Sample) Repeatable(seed int64) Sample {
+	s.seed = &seed
+	return s
+}
+
+// clause returns the TABLESAMPLE clause for s.
+func (s Sample) clause() (string, error) {
+	if s.method == "" {
+		return "", fmt.Errorf("table sample needs a method, use Bernoulli or System")
+	}
+	if !(s.percent >= 0 && s.percent <= 100) {
+		return "", fmt.Errorf("table sample percentage %v is not between 0 and 100", s.percent)
+	}
+	sql := "TABLESAMPLE " + s.method + " (" + strconv.FormatFloat(s.percent, 'g', -1, 64) + ")"
+	if s.seed != nil {
+		sql += " REPEATABLE (" + strconv.FormatInt(*s.seed, 10) + ")"
+	}
+	return sql, nil
+}
@@ -0,0 +1,61 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestTableSample(t *testing.T) {
+	db := New(nil)
+	tests := []struct {
+		name    string
+		sample  Sample
+		wantSQL string
+	}{
+		{"bernoulli", Bernoulli(1), "SELECT * FROM test_user TABLESAMPLE BERNOULLI (1) WHERE age > $1"},
+		{"system", System(0.25), "SELECT * FROM test_user TABLESAMPLE SYSTEM (0.25) WHERE age > $1"},
+		{"repeatable", System(10).Repeatable(42), "SELECT * FROM test_user TABLESAMPLE SYSTEM (10) REPEATABLE (42) WHERE age > $1"},
+		{"zero value", Sample{}, ""},
+		{"negative", Bernoulli(-1), ""},
+		{"over 100", System(150), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, _, err := Select[TestUser](db).TableSample(tt.sample).Where(Gt("age", 18)).ToSQL()
+			if tt.wantSQL == "" {
+				if err == nil {
+					t.Errorf("expected an error, got %q", sql)
+				}
+				return
+			}
+			if err != nil || sql != tt.wantSQL {
+				t.Errorf("ToSQL() = %q, %v; want %q", sql, err, tt.wantSQL)
+			}
+		})
+	}
+}
+
+func TestTableSamplePageCount(t *testing.T) {
+	db := New(nil)
+	sql, _, err := buildPageCountSQL(Select[TestUser](db).TableSample(Bernoulli(5)).Limit(10).spec())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "SELECT COUNT(*) FROM (SELECT * FROM test_user TABLESAMPLE BERNOULLI (5)) AS page_total"
+	if sql != want {
+		t.Errorf("count SQL = %q, want %q", sql, want)
+	}
+}
+
+func TestTableSampleCount(t *testing.T) {
+	exec := &resolverExecutor{}
+	q := Select[TestUser](NewWithExecutor(exec)).TableSample(Bernoulli(5)).Where(Gt("age", 18))
+	if _, err := q.Count(context.Background()); !errors.Is(err, errStopped) {
+		t.Fatalf("Count: err = %v", err)
+	}
+	want := "SELECT COUNT(*) FROM (SELECT * FROM test_user TABLESAMPLE BERNOULLI (5) WHERE age > $1) AS page_total"
+	if len(exec.sql) != 1 || exec.sql[0] != want {
+		t.Errorf("count SQL = %q, want %q", exec.sql, want)
+	}
+}
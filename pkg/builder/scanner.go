@@ -8,92 +8,34 @@ import (
 	"strings"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/marshallshelly/pebble-orm/pkg/schema"
 )
 
-// scanIntoStruct scans a database row into a struct.
+// scanIntoStruct scans a database row into a struct, through the scan plan
+// for the struct type and the row's columns.
 func scanIntoStruct(rows pgx.Rows, dest interface{}, table *schema.TableMetadata) error {
-	// Get the value and type
 	destValue := reflect.ValueOf(dest)
-	if destValue.Kind() != reflect.Pointer {
+	if destValue.Kind() != reflect.Pointer || destValue.Elem().Kind() != reflect.Struct {
 		return fmt.Errorf("dest must be a pointer to struct")
 	}
-
-	destValue = destValue.Elem()
-	if destValue.Kind() != reflect.Struct {
-		return fmt.Errorf("dest must be a pointer to struct")
-	}
-
-	// Get column descriptions
-	fieldDescriptions := rows.FieldDescriptions()
-
-	// Create scan targets
-	scanTargets := make([]interface{}, len(fieldDescriptions))
-	jsonbTargets := make(map[int]*jsonbScanTarget) // Track JSONB columns for post-processing
-	var arrayTargets []*arrayScanTarget            // Track named-slice array columns for post-processing
-	columnMap := make(map[string]int)              // Map column name to field description index
-
-	for i, fd := range fieldDescriptions {
-		columnMap[fd.Name] = i
-	}
-
-	// Map struct fields to scan targets
-	for _, col := range table.Columns {
-		idx, ok := columnMap[col.Name]
-		if !ok {
-			continue
-		}
-
-		// Get the struct field
-		field := destValue.FieldByName(col.GoField)
-		if !field.IsValid() || !field.CanSet() {
-			continue
-		}
-
-		// For JSONB columns, use intermediate scanning if the type doesn't implement Scanner
-		if col.IsJSONB && !implementsScanner(field.Type()) {
-			target := &jsonbScanTarget{field: field}
-			scanTargets[idx] = target
-			jsonbTargets[idx] = target
-		} else if target := newArrayScanTarget(col, field); target != nil {
-			// Named Scanner slices (schema.StringArray etc.) on array columns:
-			// scan through pgx's native array decoding instead of sql.Scanner,
-			// which would receive raw binary wire bytes under the default
-			// extended protocol.
-			scanTargets[idx] = target.dest.Interface()
-			arrayTargets = append(arrayTargets, target)
-		} else {
-			// Create a pointer to the field for scanning
-			scanTargets[idx] = field.Addr().Interface()
-		}
-	}
-
-	// Fill any nil scan targets with dummy variables
-	var dummy interface{}
-	for i := range scanTargets {
-		if scanTargets[i] == nil {
-			scanTargets[i] = &dummy
-		}
-	}
-
-	// Scan the row
-	if err := rows.Scan(scanTargets...); err != nil {
-		return fmt.Errorf("failed to scan row: %w", err)
+	plan := scanPlanFor(destValue.Type().Elem(), table, rows.FieldDescriptions())
+	if plan.err != nil {
+		return plan.err
 	}
+	return plan.scan(rows, destValue.UnsafePointer())
+}
 
-	// Post-process JSONB targets - unmarshal into actual field types
-	for _, target := range jsonbTargets {
-		if err := target.unmarshalIntoField(); err != nil {
-			return fmt.Errorf("failed to unmarshal JSONB: %w", err)
+// fieldByPath returns the struct field for a column's GoField, following the
+// dotted path of columns flattened from embedded structs (e.g. "Billing.Street").
+func fieldByPath(v reflect.Value, path string) reflect.Value {
+	for name := range strings.SplitSeq(path, ".") {
+		v = v.FieldByName(name)
+		if !v.IsValid() {
+			return v
 		}
 	}
-
-	// Post-process array targets - convert underlying slices to the named types
-	for _, target := range arrayTargets {
-		target.field.Set(target.dest.Elem().Convert(target.field.Type()))
-	}
-
-	return nil
+	return v
 }
 
 // arrayScanTarget scans an array column into the field's underlying slice
@@ -104,24 +46,101 @@ type arrayScanTarget struct {
 	dest  reflect.Value // pointer to a value of the underlying slice type
 }
 
-// newArrayScanTarget returns an intermediate target for array columns whose
-// field is a named slice type implementing sql.Scanner (other than []byte),
-// or nil if direct scanning should be used.
-func newArrayScanTarget(col schema.ColumnMetadata, field reflect.Value) *arrayScanTarget {
-	t := field.Type()
+// arraySliceType returns the underlying slice type to scan an array column
+// into when the field is a named slice type implementing sql.Scanner (other
+// than []byte), []string for a slice of a named string type on an enum array
+// column, or nil if direct scanning should be used.
+func arraySliceType(col schema.ColumnMetadata, t reflect.Type) reflect.Type {
 	if !strings.HasSuffix(col.SQLType, "[]") {
 		return nil
 	}
 	if t.Kind() != reflect.Slice || t.Elem().Kind() == reflect.Uint8 {
 		return nil
 	}
+	if isEnumArray(col, t) {
+		return reflect.TypeFor[[]string]()
+	}
 	if t.Name() == "" || !implementsScanner(t) {
 		return nil // plain slices ([]string etc.) already decode natively
 	}
-	return &arrayScanTarget{
-		field: field,
-		dest:  reflect.New(reflect.SliceOf(t.Elem())),
+	return reflect.SliceOf(t.Elem())
+}
+
+// isEnumArray reports whether a column is an enum array and its field a slice
+// of a named string type, such as []OrderStatus. pgx knows neither the
+// column's type nor the element type, so such values go through []string.
+func isEnumArray(col schema.ColumnMetadata, t reflect.Type) bool {
+	return col.EnumType != "" && strings.HasSuffix(col.SQLType, "[]") &&
+		t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.String &&
+		t.Elem() != reflect.TypeFor[string]() && !implementsScanner(t) && !implementsScanner(t.Elem())
+}
+
+// assign sets the field from the scanned slice, converting it as a whole or,
+// for enum arrays, element by element.
+func (a *arrayScanTarget) assign() {
+	src := a.dest.Elem()
+	if src.Type().ConvertibleTo(a.field.Type()) {
+		a.field.Set(src.Convert(a.field.Type()))
+		return
+	}
+	if src.IsNil() {
+		a.field.SetZero()
+		return
+	}
+	a.field.Set(convertSlice(src, a.field.Type()))
+}
+
+// convertSlice converts each element of src to make a slice of type t.
+func convertSlice(src reflect.Value, t reflect.Type) reflect.Value {
+	out := reflect.MakeSlice(t, src.Len(), src.Len())
+	for i := range src.Len() {
+		out.Index(i).Set(src.Index(i).Convert(t.Elem()))
+	}
+	return out
+}
+
+// codecScanTarget decodes a column through a codec registered with
+// schema.RegisterCodec. pgx hands sql.Scanner targets the database/sql form
+// of the value, which is what Codec.Decode receives.
+type codecScanTarget struct {
+	field  reflect.Value
+	codec  *schema.Codec
+	viaPtr bool // field is *T and the codec is registered for T
+}
+
+// Scan implements sql.Scanner for codec-backed fields.
+func (c *codecScanTarget) Scan(value interface{}) error {
+	if value == nil {
+		c.field.SetZero()
+		return nil
+	}
+
+	decoded, err := c.codec.Decode(value)
+	if err != nil {
+		return fmt.Errorf("failed to decode %s: %w", c.field.Type(), err)
+	}
+
+	v := reflect.ValueOf(decoded)
+	if c.viaPtr {
+		ptr := reflect.New(c.field.Type().Elem())
+		ptr.Elem().Set(v)
+		v = ptr
 	}
+	c.field.Set(v)
+	return nil
+}
+
+// codecFor returns the codec registered for t or, when t is a pointer, for
+// its element type (viaPtr reports the latter).
+func codecFor(t reflect.Type) (codec *schema.Codec, viaPtr bool, ok bool) {
+	if codec, ok := schema.LookupCodec(t); ok {
+		return codec, false, true
+	}
+	if t.Kind() == reflect.Pointer {
+		codec, ok := schema.LookupCodec(t.Elem())
+		return codec, true, ok
+	}
+	return nil, false, false
 }
 
 // jsonbScanTarget is an intermediate scan target for JSONB columns
@@ -164,6 +183,61 @@ func (j *jsonbScanTarget) unmarshalIntoField() error {
 	return json.Unmarshal(j.data, targetPtr)
 }
 
+// hstoreScanTarget scans an hstore column into a map[string]string field.
+// pgx registers no hstore type, its OID varying by database, so the value
+// arrives in text form and is parsed by pgtype.Hstore. NULL values become "".
+type hstoreScanTarget struct {
+	field reflect.Value
+}
+
+// Scan implements sql.Scanner for hstore fields.
+func (h *hstoreScanTarget) Scan(value interface{}) error {
+	if b, ok := value.([]byte); ok {
+		value = string(b)
+	}
+	var hs pgtype.Hstore
+	if err := hs.Scan(value); err != nil {
+		return fmt.Errorf("failed to scan hstore: %w", err)
+	}
+	if hs == nil {
+		h.field.SetZero()
+		return nil
+	}
+	m := reflect.MakeMapWithSize(h.field.Type(), len(hs))
+	elem := h.field.Type().Elem()
+	for k, v := range hs {
+		var s string
+		if v != nil {
+			s = *v
+		}
+		m.SetMapIndex(reflect.ValueOf(k).Convert(h.field.Type().Key()), reflect.ValueOf(s).Convert(elem))
+	}
+	h.field.Set(m)
+	return nil
+}
+
+// isHstoreMap reports whether a column is hstore and its field a string map
+// that doesn't scan itself.
+func isHstoreMap(col schema.ColumnMetadata, t reflect.Type) bool {
+	return strings.EqualFold(col.SQLType, "hstore") && t.Kind() == reflect.Map &&
+		t.Key().Kind() == reflect.String && t.Elem().Kind() == reflect.String &&
+		!implementsScanner(t)
+}
+
+// hstoreValue converts a string map to pgtype.Hstore for binding, which
+// encodes itself as text; nil is NULL.
+func hstoreValue(field reflect.Value) interface{} {
+	if field.IsNil() {
+		return nil
+	}
+	hs := make(pgtype.Hstore, field.Len())
+	for iter := field.MapRange(); iter.Next(); {
+		v := iter.Value().String()
+		hs[iter.Key().String()] = &v
+	}
+	return hs
+}
+
 // implementsScanner checks if a type implements sql.Scanner.
 func implementsScanner(t reflect.Type) bool {
 	scannerType := reflect.TypeOf((*interface{ Scan(interface{}) error })(nil)).Elem()
@@ -204,101 +278,95 @@ func implementsValuer(t reflect.Type) bool {
 // 2. Field has a database Default and the Go value is zero (new smart behavior)
 // 3. For JSONB columns, automatically marshals values to JSON bytes.
 func structToValues(model interface{}, table *schema.TableMetadata, skipPrimaryKey bool) ([]string, []interface{}, error) {
-	modelValue := reflect.ValueOf(model)
-	if modelValue.Kind() == reflect.Pointer {
-		modelValue = modelValue.Elem()
-	}
-
-	if modelValue.Kind() != reflect.Struct {
-		return nil, nil, fmt.Errorf("model must be a struct")
+	columns, rows, err := insertRows([]interface{}{model}, table, skipPrimaryKey, nil)
+	if err != nil {
+		return nil, nil, err
 	}
+	return columns, rows[0], nil
+}
 
-	var columns []string
-	var values []interface{}
-
-	for _, col := range table.Columns {
-		// Skip primary key with auto-increment (existing behavior)
-		if skipPrimaryKey && table.IsPrimaryKey(col.Name) && col.AutoIncrement {
-			continue
-		}
-
-		field := modelValue.FieldByName(col.GoField)
-		if !field.IsValid() {
-			continue
-		}
-
-		// Smart default detection: Skip zero-valued fields that have database defaults
-		// This allows natural non-pointer types like:
-		//   ID string `po:"id,uuid,default(gen_random_uuid())"`
-		// Instead of requiring:
-		//   ID *string `po:"id,uuid,default(gen_random_uuid())"`
-		if col.Default != nil && field.IsZero() {
-			continue
+// insertDefault stands for the DEFAULT keyword in a row of a multi-row
+// INSERT.
+type insertDefault struct{}
+
+// insertRows returns the INSERT column list for rows and each row's values
+// for it. readOnly and generated columns are never written. A column the database can fill (one with a default, an identity,
+// or one named in useDefaults) is left out when it is zero in every row; when
+// only some rows set it, the others insert DEFAULT, marked by insertDefault.
+// An auto-increment primary key skipped by skipPrimaryKey is kept when it
+// is named in useDefaults, so rows that set it can be matched on it.
+// Deciding per column over all rows keeps a later row's value from being
+// dropped because the first row left its column out.
+//
+// This allows natural non-pointer types like:
+//
+//	ID string `po:"id,uuid,default(gen_random_uuid())"`
+//
+// instead of requiring *string to get the database default.
+func insertRows(rows []interface{}, table *schema.TableMetadata, skipPrimaryKey bool, useDefaults map[string]bool) ([]string, [][]interface{}, error) {
+	models := make([]reflect.Value, len(rows))
+	plans := make([]*valuePlan, len(rows))
+	for r, row := range rows {
+		modelValue := reflect.ValueOf(row)
+		if modelValue.Kind() == reflect.Pointer {
+			modelValue = modelValue.Elem()
 		}
-
-		// Skip identity columns when zero (they're auto-generated)
-		if col.Identity != nil && field.IsZero() {
-			continue
+		if modelValue.Kind() != reflect.Struct {
+			return nil, nil, fmt.Errorf("model must be a struct")
 		}
-
-		columns = append(columns, col.Name)
-
-		value, err := columnValue(col, field)
-		if err != nil {
-			return nil, nil, err
+		models[r], plans[r] = modelValue, valuePlanFor(modelValue.Type(), table)
+		if plans[r].err != nil {
+			return nil, nil, plans[r].err
 		}
-		values = append(values, value)
 	}
 
-	return columns, values, nil
-}
-
-// valuesForColumns extracts the values for exactly the named columns, in order,
-// without applying the per-row skip logic. It is used for rows 2..N of a
-// multi-row INSERT so every row emits a value for the same column list that was
-// derived from the first row — otherwise a later row whose zero-value/default
-// skipping differs would misalign values against the column list (silently
-// writing a value into the wrong column, or a placeholder-count mismatch).
-func valuesForColumns(model interface{}, table *schema.TableMetadata, columns []string) ([]interface{}, error) {
-	modelValue := reflect.ValueOf(model)
-	if modelValue.Kind() == reflect.Pointer {
-		modelValue = modelValue.Elem()
-	}
-	if modelValue.Kind() != reflect.Struct {
-		return nil, fmt.Errorf("model must be a struct")
-	}
-
-	values := make([]interface{}, 0, len(columns))
-	for _, name := range columns {
-		col := table.GetColumnByName(name)
-		if col == nil {
-			return nil, fmt.Errorf("column %s not found in table %s", name, table.Name)
+	// Plans list table.Columns in order, so a column has the same index in
+	// every row's plan.
+	first := plans[0]
+	include := make([]bool, len(first.columns))
+	omittable := make([]bool, len(first.columns))
+	var columns []string
+	for i := range first.columns {
+		c := &first.columns[i]
+		if c.index == nil || skipPrimaryKey && c.autoPK && !useDefaults[c.col.Name] || !c.col.Insertable() {
+			continue
 		}
-		field := modelValue.FieldByName(col.GoField)
-		if !field.IsValid() {
-			return nil, fmt.Errorf("field %s not found for column %s", col.GoField, name)
+		omittable[i] = c.omitZero || useDefaults[c.col.Name]
+		include[i] = !omittable[i]
+		for r := 0; r < len(rows) && !include[i]; r++ {
+			rc := &plans[r].columns[i]
+			include[i] = rc.index != nil && !models[r].FieldByIndex(rc.index).IsZero()
 		}
-		value, err := columnValue(*col, field)
-		if err != nil {
-			return nil, err
+		if include[i] {
+			columns = append(columns, c.col.Name)
 		}
-		values = append(values, value)
 	}
-	return values, nil
-}
 
-// columnValue returns the value to bind for a single column, marshaling JSONB
-// columns whose type does not implement driver.Valuer.
-func columnValue(col schema.ColumnMetadata, field reflect.Value) (interface{}, error) {
-	fieldValue := field.Interface()
-	if col.IsJSONB && !implementsValuer(field.Type()) {
-		jsonBytes, err := marshalJSONB(fieldValue)
-		if err != nil {
-			return nil, fmt.Errorf("failed to marshal JSONB field %s: %w", col.GoField, err)
+	values := make([][]interface{}, len(rows))
+	for r := range rows {
+		rowValues := make([]interface{}, 0, len(columns))
+		for i := range plans[r].columns {
+			if !include[i] {
+				continue
+			}
+			c := &plans[r].columns[i]
+			if c.index == nil {
+				return nil, nil, fmt.Errorf("field %s not found for column %s", c.col.GoField, c.col.Name)
+			}
+			field := models[r].FieldByIndex(c.index)
+			if omittable[i] && field.IsZero() {
+				rowValues = append(rowValues, insertDefault{})
+				continue
+			}
+			value, err := c.value(field)
+			if err != nil {
+				return nil, nil, err
+			}
+			rowValues = append(rowValues, value)
 		}
-		return jsonBytes, nil
+		values[r] = rowValues
 	}
-	return fieldValue, nil
+	return columns, values, nil
 }
 
 // marshalJSONB marshals a value for a JSONB column. Returns string because
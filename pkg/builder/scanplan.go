@@ -0,0 +1,229 @@
+package builder
+
+import (
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"sync"
+	"unsafe"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+)
+
+// scanPlan says how to scan each column of a result set into a model type:
+// the offset of the column's field in the struct and whether it goes through
+// a codec, JSONB, array or hstore target. Plans are compiled on first use and cached,
+// so scanning a row does no field lookups, only pointer arithmetic.
+//
+// Codecs are looked up when a plan is compiled; register them before the
+// first query that reads their type.
+type scanPlan struct {
+	names   []string // result column names, to confirm a cache hit
+	columns []columnScan
+	err     error // a column field the plan can't reach
+}
+
+type scanMode uint8
+
+const (
+	scanSkip scanMode = iota // no field for the column
+	scanDirect
+	scanCodec
+	scanJSONB
+	scanArray
+	scanHstore
+)
+
+type columnScan struct {
+	mode   scanMode
+	offset uintptr
+	typ    reflect.Type // field type
+	codec  *schema.Codec
+	viaPtr bool         // codec registered for the field's element type
+	slice  reflect.Type // underlying slice type, for scanArray
+}
+
+// field returns the column's field in the struct at base.
+func (c *columnScan) field(base unsafe.Pointer) reflect.Value {
+	return reflect.NewAt(c.typ, unsafe.Add(base, c.offset)).Elem()
+}
+
+type scanPlanKey struct {
+	typ     reflect.Type
+	table   *schema.ColumnMetadata // as valuePlanKey
+	columns uint64                 // hash of the result column names
+}
+
+var scanPlans sync.Map // scanPlanKey -> *scanPlan
+
+// scanPlanFor returns the plan for scanning rows with columns fields into
+// values of typ, compiling it the first time.
+func scanPlanFor(typ reflect.Type, table *schema.TableMetadata, fields []pgconn.FieldDescription) *scanPlan {
+	h := fnv.New64a()
+	for _, fd := range fields {
+		_, _ = h.Write([]byte(fd.Name))
+		_, _ = h.Write([]byte{0})
+	}
+	key := scanPlanKey{typ: typ, table: unsafe.SliceData(table.Columns), columns: h.Sum64()}
+	if cached, ok := scanPlans.Load(key); ok {
+		if plan := cached.(*scanPlan); plan.matches(fields) {
+			return plan
+		}
+		return compileScanPlan(typ, table, fields) // hash collision
+	}
+	plan := compileScanPlan(typ, table, fields)
+	scanPlans.Store(key, plan)
+	return plan
+}
+
+func (p *scanPlan) matches(fields []pgconn.FieldDescription) bool {
+	if len(fields) != len(p.names) {
+		return false
+	}
+	for i, fd := range fields {
+		if fd.Name != p.names[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func compileScanPlan(typ reflect.Type, table *schema.TableMetadata, fields []pgconn.FieldDescription) *scanPlan {
+	plan := &scanPlan{
+		names:   make([]string, len(fields)),
+		columns: make([]columnScan, len(fields)),
+	}
+	columnIndex := make(map[string]int, len(fields))
+	for i, fd := range fields {
+		plan.names[i] = fd.Name
+		columnIndex[fd.Name] = i
+	}
+
+	for _, col := range table.Columns {
+		idx, ok := columnIndex[col.Name]
+		if !ok {
+			continue
+		}
+		offset, fieldType, err := fieldOffset(typ, col.GoField)
+		if err != nil {
+			plan.err = err
+			return plan
+		}
+		if fieldType == nil {
+			continue
+		}
+
+		c := columnScan{mode: scanDirect, offset: offset, typ: fieldType}
+		// Types with a registered codec decode through it, ahead of any other handling
+		if codec, viaPtr, ok := codecFor(fieldType); ok {
+			c.mode, c.codec, c.viaPtr = scanCodec, codec, viaPtr
+		} else if col.IsJSONB && !implementsScanner(fieldType) {
+			// For JSONB columns, use intermediate scanning if the type doesn't implement Scanner
+			c.mode = scanJSONB
+		} else if slice := arraySliceType(col, fieldType); slice != nil {
+			// Named Scanner slices (schema.StringArray etc.) on array columns:
+			// scan through pgx's native array decoding instead of sql.Scanner,
+			// which would receive raw binary wire bytes under the default
+			// extended protocol.
+			c.mode, c.slice = scanArray, slice
+		} else if isHstoreMap(col, fieldType) {
+			c.mode = scanHstore
+		}
+		plan.columns[idx] = c
+	}
+	return plan
+}
+
+// fieldOffset returns the offset and type of the field at a dotted path of
+// embedded struct fields, as fieldIndex finds it. The type is nil when typ
+// has no such field.
+func fieldOffset(typ reflect.Type, path string) (uintptr, reflect.Type, error) {
+	index, fieldType, err := fieldIndex(typ, path)
+	if index == nil {
+		return 0, nil, err
+	}
+	var offset uintptr
+	for _, i := range index {
+		field := typ.Field(i)
+		offset += field.Offset
+		typ = field.Type
+	}
+	return offset, fieldType, nil
+}
+
+// scan scans the current row into the struct at base.
+func (p *scanPlan) scan(rows pgx.Rows, base unsafe.Pointer) error {
+	targets := make([]interface{}, len(p.columns))
+	var jsonbTargets []*jsonbScanTarget // Track JSONB columns for post-processing
+	var arrayTargets []*arrayScanTarget // Track named-slice array columns for post-processing
+	var dummy interface{}
+
+	for i := range p.columns {
+		c := &p.columns[i]
+		switch c.mode {
+		case scanSkip:
+			targets[i] = &dummy
+		case scanDirect:
+			targets[i] = reflect.NewAt(c.typ, unsafe.Add(base, c.offset)).Interface()
+		case scanCodec:
+			targets[i] = &codecScanTarget{field: c.field(base), codec: c.codec, viaPtr: c.viaPtr}
+		case scanJSONB:
+			target := &jsonbScanTarget{field: c.field(base)}
+			targets[i] = target
+			jsonbTargets = append(jsonbTargets, target)
+		case scanArray:
+			target := &arrayScanTarget{field: c.field(base), dest: reflect.New(c.slice)}
+			targets[i] = target.dest.Interface()
+			arrayTargets = append(arrayTargets, target)
+		case scanHstore:
+			targets[i] = &hstoreScanTarget{field: c.field(base)}
+		}
+	}
+
+	if err := rows.Scan(targets...); err != nil {
+		return fmt.Errorf("failed to scan row: %w", err)
+	}
+
+	// Post-process JSONB targets - unmarshal into actual field types
+	for _, target := range jsonbTargets {
+		if err := target.unmarshalIntoField(); err != nil {
+			return fmt.Errorf("failed to unmarshal JSONB: %w", err)
+		}
+	}
+
+	// Post-process array targets - convert underlying slices to the named types
+	for _, target := range arrayTargets {
+		target.assign()
+	}
+
+	return nil
+}
+
+// scanAll scans the remaining rows into a []T, looking up the scan plan once
+// for the result set rather than per row.
+func scanAll[T any](rows pgx.Rows, table *schema.TableMetadata) ([]T, error) {
+	var plan *scanPlan
+	var results []T
+	for rows.Next() {
+		if plan == nil {
+			typ := reflect.TypeFor[T]()
+			if typ.Kind() != reflect.Struct {
+				return nil, fmt.Errorf("dest must be a pointer to struct")
+			}
+			if plan = scanPlanFor(typ, table, rows.FieldDescriptions()); plan.err != nil {
+				return nil, plan.err
+			}
+		}
+		var item T
+		if err := plan.scan(rows, unsafe.Pointer(&item)); err != nil {
+			return nil, err
+		}
+		results = append(results, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
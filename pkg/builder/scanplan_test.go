@@ -0,0 +1,268 @@
+package builder
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/marshallshelly/pebble-orm/pkg/registry"
+)
+
+// table_name: scan_reading
+type ScanReading struct {
+	ID              int64             `po:"id,primaryKey,bigserial"`
+	Sensor          string            `po:"sensor,text,notNull"`
+	Value           float64           `po:"value,doublePrecision"`
+	Labels          map[string]string `po:"labels,jsonb"`
+	EmbedTimestamps `po:"embedded"`
+}
+
+// fakeRows serves rows of values, assigning them to scan targets the way
+// pgx does for the types these tests use.
+type fakeRows struct {
+	pgx.Rows
+	fields []pgconn.FieldDescription
+	rows   [][]interface{}
+	i      int
+}
+
+func newFakeRows(columns []string, rows ...[]interface{}) *fakeRows {
+	fields := make([]pgconn.FieldDescription, len(columns))
+	for i, name := range columns {
+		fields[i].Name = name
+	}
+	return &fakeRows{fields: fields, rows: rows}
+}
+
+func (f *fakeRows) FieldDescriptions() []pgconn.FieldDescription { return f.fields }
+func (f *fakeRows) Next() bool                                   { f.i++; return f.i <= len(f.rows) }
+func (f *fakeRows) Err() error                                   { return nil }
+func (f *fakeRows) Close()                                       {}
+
+func (f *fakeRows) Scan(dest ...interface{}) error {
+	row := f.rows[f.i-1]
+	if len(dest) != len(row) {
+		return fmt.Errorf("expected %d targets, got %d", len(row), len(dest))
+	}
+	for i, target := range dest {
+		if scanner, ok := target.(sql.Scanner); ok {
+			if err := scanner.Scan(row[i]); err != nil {
+				return err
+			}
+			continue
+		}
+		reflect.ValueOf(target).Elem().Set(reflect.ValueOf(row[i]))
+	}
+	return nil
+}
+
+func TestScanPlan(t *testing.T) {
+	table, err := registry.GetOrRegister(ScanReading{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	columns := []string{"id", "sensor", "extra", "value", "labels", "version"}
+	rows := newFakeRows(columns,
+		[]interface{}{int64(1), "t1", "ignored", 21.5, []byte(`{"room":"lab"}`), 3},
+		[]interface{}{int64(2), "t2", "ignored", -4.0, []byte(`{"room":"roof"}`), 4},
+	)
+
+	got, err := scanAll[ScanReading](rows, table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ScanReading{
+		{ID: 1, Sensor: "t1", Value: 21.5, Labels: map[string]string{"room": "lab"}, EmbedTimestamps: EmbedTimestamps{Version: 3}},
+		{ID: 2, Sensor: "t2", Value: -4.0, Labels: map[string]string{"room": "roof"}, EmbedTimestamps: EmbedTimestamps{Version: 4}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	// The plan is compiled once per type and column list.
+	plan := scanPlanFor(reflect.TypeFor[ScanReading](), table, rows.fields)
+	if again := scanPlanFor(reflect.TypeFor[ScanReading](), table, rows.fields); again != plan {
+		t.Error("expected the cached plan")
+	}
+	other := newFakeRows([]string{"id", "sensor"})
+	if scanPlanFor(reflect.TypeFor[ScanReading](), table, other.fields) == plan {
+		t.Error("expected a different plan for different columns")
+	}
+	if plan.columns[2].mode != scanSkip || plan.columns[4].mode != scanJSONB {
+		t.Errorf("unexpected plan modes %+v", plan.columns)
+	}
+}
+
+func TestScanIntoStruct(t *testing.T) {
+	table, err := registry.GetOrRegister(EmbedOrder{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := newFakeRows([]string{"id", "billing_city", "shipping_city", "version"},
+		[]interface{}{7, "Springfield", "Shelbyville", 2})
+	rows.Next()
+
+	var order EmbedOrder
+	if err := scanIntoStruct(rows, &order, table); err != nil {
+		t.Fatal(err)
+	}
+	if order.ID != 7 || order.Billing.City != "Springfield" || order.Shipping.City != "Shelbyville" || order.Version != 2 {
+		t.Errorf("unexpected scan result %+v", order)
+	}
+	if err := scanIntoStruct(rows, order, table); err == nil {
+		t.Error("expected an error for a non-pointer destination")
+	}
+}
+
+func BenchmarkScanAll(b *testing.B) {
+	table, err := registry.GetOrRegister(ScanReading{})
+	if err != nil {
+		b.Fatal(err)
+	}
+	columns := []string{"id", "sensor", "value", "labels", "version"}
+	data := make([][]interface{}, 1000)
+	for i := range data {
+		data[i] = []interface{}{int64(i), "sensor", float64(i) / 10, []byte(`{}`), i}
+	}
+
+	b.ReportAllocs()
+	for b.Loop() {
+		rows := newFakeRows(columns, data...)
+		if _, err := scanAll[ScanReading](rows, table); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestScanHstore(t *testing.T) {
+	table, err := registry.GetOrRegister(CitextAccount{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := newFakeRows([]string{"id", "attributes"},
+		[]interface{}{int64(1), `"color"=>"red", "size"=>NULL`},
+		[]interface{}{int64(2), nil},
+	)
+	got, err := scanAll[CitextAccount](rows, table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"color": "red", "size": ""}; !reflect.DeepEqual(got[0].Attributes, want) {
+		t.Errorf("Attributes = %v, want %v", got[0].Attributes, want)
+	}
+	if got[1].Attributes != nil {
+		t.Errorf("expected a nil map for NULL, got %v", got[1].Attributes)
+	}
+
+	// Inserts bind the map as hstore
+	columns, values, err := structToValues(&CitextAccount{Email: "a@x.io", Attributes: map[string]string{"color": "red"}}, table, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := slices.Index(columns, "attributes")
+	if hs, ok := values[i].(pgtype.Hstore); !ok || *hs["color"] != "red" {
+		t.Errorf("expected the map bound as pgtype.Hstore, got %#v", values[i])
+	}
+}
+
+type arrayMood string
+
+type arrayDecimal struct{ s string }
+
+func (d *arrayDecimal) Scan(src any) error          { d.s = fmt.Sprint(src); return nil }
+func (d arrayDecimal) Value() (driver.Value, error) { return d.s, nil }
+
+type arrayPayload struct {
+	Kind string `json:"kind"`
+}
+
+// table_name: array_reading
+type ArrayReading struct {
+	ID       int64          `po:"id,primaryKey,bigserial"`
+	Devices  [][16]byte     `po:"devices,uuid[]"`
+	Amounts  []arrayDecimal `po:"amounts,numeric[]"`
+	Payloads []arrayPayload `po:"payloads,jsonb[]"`
+	Moods    []arrayMood    `po:"moods,enum(low,high)"`
+}
+
+// pgxRows serves rows of text-format column values, decoding them into scan
+// targets through pgx's type map as a connection does.
+type pgxRows struct {
+	*fakeRows
+	oids []uint32
+	m    *pgtype.Map
+}
+
+func (r *pgxRows) Scan(dest ...interface{}) error {
+	for i, target := range dest {
+		var src []byte
+		if s, ok := r.rows[r.i-1][i].(string); ok {
+			src = []byte(s)
+		}
+		if err := r.m.Scan(r.oids[i], pgtype.TextFormatCode, src, target); err != nil {
+			return fmt.Errorf("column %s: %w", r.fields[i].Name, err)
+		}
+	}
+	return nil
+}
+
+func TestScanArrays(t *testing.T) {
+	table, err := registry.GetOrRegister(ArrayReading{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if col := table.GetColumnByName("moods"); col.SQLType != "array_mood[]" {
+		t.Fatalf("moods SQLType = %q, want array_mood[]", col.SQLType)
+	}
+
+	const moodArrayOID = 90001 // enum array OIDs vary by database and pgx doesn't know them
+	m := pgtype.NewMap()
+	rows := &pgxRows{
+		fakeRows: newFakeRows([]string{"id", "devices", "amounts", "payloads", "moods"},
+			[]interface{}{"1", "{a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11}", "{1.50,-2}", `{"{\"kind\": \"a\"}"}`, "{low,high}"},
+			[]interface{}{"2", nil, nil, nil, nil},
+		),
+		oids: []uint32{pgtype.Int8OID, pgtype.UUIDArrayOID, pgtype.NumericArrayOID, pgtype.JSONBArrayOID, moodArrayOID},
+		m:    m,
+	}
+	got, err := scanAll[ArrayReading](rows, table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ArrayReading{
+		ID:       1,
+		Devices:  [][16]byte{{0xa0, 0xee, 0xbc, 0x99, 0x9c, 0x0b, 0x4e, 0xf8, 0xbb, 0x6d, 0x6b, 0xb9, 0xbd, 0x38, 0x0a, 0x11}},
+		Amounts:  []arrayDecimal{{"1.50"}, {"-2"}},
+		Payloads: []arrayPayload{{Kind: "a"}},
+		Moods:    []arrayMood{"low", "high"},
+	}
+	if !reflect.DeepEqual(got[0], want) {
+		t.Errorf("got %+v, want %+v", got[0], want)
+	}
+	if !reflect.DeepEqual(got[1], ArrayReading{ID: 2}) {
+		t.Errorf("expected nil slices for NULL, got %+v", got[1])
+	}
+
+	// Inserts bind values pgx can encode, enum arrays as []string
+	columns, values, err := structToValues(&want, table, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oids := map[string]uint32{"devices": pgtype.UUIDArrayOID, "amounts": pgtype.NumericArrayOID, "payloads": pgtype.JSONBArrayOID, "moods": moodArrayOID}
+	for i, column := range columns {
+		data, err := m.Encode(oids[column], pgtype.TextFormatCode, values[i], nil)
+		if err != nil {
+			t.Errorf("%s: %v", column, err)
+			continue
+		}
+		if column == "moods" && string(data) != "{low,high}" {
+			t.Errorf("moods encoded as %s", data)
+		}
+	}
+}
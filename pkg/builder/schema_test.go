@@ -0,0 +1,83 @@
+package builder
+
+import (
+	"testing"
+
+	"github.com/marshallshelly/pebble-orm/pkg/registry"
+)
+
+// schema: billing
+type SchemaInvoice struct {
+	ID     int `po:"id,primaryKey,serial"`
+	Amount int `po:"amount,integer,notNull"`
+}
+
+func TestQueries_SchemaQualifiedTables(t *testing.T) {
+	if err := registry.Register(SchemaInvoice{}); err != nil {
+		t.Fatalf("Failed to register model: %v", err)
+	}
+	if err := registry.Register(TestUser{}); err != nil {
+		t.Fatalf("Failed to register model: %v", err)
+	}
+
+	db := New(nil)
+	analytics := db.WithSchema("analytics")
+
+	tests := []struct {
+		name    string
+		toSQL   func() (string, []interface{}, error)
+		wantSQL string
+	}{
+		{
+			name:    "schema directive qualifies SELECT",
+			toSQL:   Select[SchemaInvoice](db).Where(Eq("id", 1)).ToSQL,
+			wantSQL: "SELECT * FROM billing.schema_invoice WHERE id = $1",
+		},
+		{
+			name:    "schema directive qualifies INSERT",
+			toSQL:   Insert[SchemaInvoice](db).Values(SchemaInvoice{Amount: 5}).ToSQL,
+			wantSQL: "INSERT INTO billing.schema_invoice (amount) VALUES ($1)",
+		},
+		{
+			name:    "WithSchema qualifies unqualified models",
+			toSQL:   Select[TestUser](analytics).ToSQL,
+			wantSQL: "SELECT * FROM analytics.test_user",
+		},
+		{
+			name:    "WithSchema qualifies UPDATE",
+			toSQL:   Update[TestUser](analytics).Set("age", 30).Where(Eq("id", "u1")).ToSQL,
+			wantSQL: "UPDATE analytics.test_user SET age = $1 WHERE id = $2",
+		},
+		{
+			name:    "WithSchema qualifies DELETE",
+			toSQL:   Delete[TestUser](analytics).Where(Eq("id", "u1")).ToSQL,
+			wantSQL: "DELETE FROM analytics.test_user WHERE id = $1",
+		},
+		{
+			name:    "schema directive wins over WithSchema",
+			toSQL:   Select[SchemaInvoice](analytics).ToSQL,
+			wantSQL: "SELECT * FROM billing.schema_invoice",
+		},
+		{
+			name:    "WithSchema leaves the original DB unqualified",
+			toSQL:   Select[TestUser](db).ToSQL,
+			wantSQL: "SELECT * FROM test_user",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, _, err := tt.toSQL()
+			if err != nil {
+				t.Fatalf("ToSQL() error = %v", err)
+			}
+			if sql != tt.wantSQL {
+				t.Errorf("ToSQL() sql = %v, want %v", sql, tt.wantSQL)
+			}
+		})
+	}
+
+	if table, _ := registry.GetByName("test_user"); table.Schema != "" {
+		t.Errorf("WithSchema mutated registered metadata: schema = %q", table.Schema)
+	}
+}
@@ -3,11 +3,63 @@ package builder
 import (
 	"context"
 	"fmt"
+	"strings"
+
+	"github.com/marshallshelly/pebble-orm/pkg/cache"
 )
 
-// Columns specifies which columns to select.
+// Columns specifies which columns to select. Each must be a column
+// reference, optionally with an alias; see ColumnExpr for other expressions.
 func (q *SelectQuery[T]) Columns(cols ...string) *SelectQuery[T] {
-	q.columns = cols
+	q.columns = quoteAll(cols, quoteSelectItem, &q.err)
+	q.colArgs = nil
+	return q
+}
+
+// Fields selects the columns tagged with any of the projection groups
+// (`po:"name,text,group(summary)"`); the other fields of T are left zero.
+// Usage: query.Fields("summary")
+func (q *SelectQuery[T]) Fields(groups ...string) *SelectQuery[T] {
+	columns, err := groupColumns(q.table, groups)
+	keepErr(&q.err, err)
+	q.columns = columns
+	q.colArgs = nil
+	return q
+}
+
+// SelectExpr adds a computed column to the select list, named alias so it
+// scans into the field of T with that column name.
+// Usage: query.Columns("id").SelectExpr(builder.Mul(builder.Column("price"), builder.Column("quantity")), "total")
+func (q *SelectQuery[T]) SelectExpr(expr Expr, alias string) *SelectQuery[T] {
+	sql, args, err := renderExpr(expr, len(q.colArgs)+1)
+	if err != nil {
+		keepErr(&q.err, err)
+		return q
+	}
+	quoted, err := quoteIdent(alias)
+	if err != nil {
+		keepErr(&q.err, err)
+		return q
+	}
+	q.columns = append(q.columns, sql+" AS "+quoted)
+	q.colArgs = append(q.colArgs, args...)
+	return q
+}
+
+// ColumnExpr adds expressions to the select list as written.
+// Usage: query.Columns("id").ColumnExpr("EXTRACT(YEAR FROM created_at) AS year")
+func (q *SelectQuery[T]) ColumnExpr(exprs ...UnsafeExpr) *SelectQuery[T] {
+	for _, expr := range exprs {
+		q.columns = append(q.columns, string(expr))
+	}
+	return q
+}
+
+// Unscoped drops the model's default scope (// default_scope: ...) from
+// the query, to reach rows it filters out such as soft-deleted ones.
+// Usage: builder.Select[T](qb).Unscoped().Where(...)
+func (q *SelectQuery[T]) Unscoped() *SelectQuery[T] {
+	q.unscoped = true
 	return q
 }
 
@@ -31,11 +83,39 @@ func (q *SelectQuery[T]) Or(condition Condition) *SelectQuery[T] {
 
 // OrderBy adds an ORDER BY clause.
 func (q *SelectQuery[T]) OrderBy(column string, direction OrderDirection) *SelectQuery[T] {
-	q.orderBy = append(q.orderBy, OrderBy{
-		Column:    column,
-		Direction: direction,
-		NullsPos:  NullsDefault,
-	})
+	return q.OrderByNulls(column, direction, NullsDefault)
+}
+
+// OrderByNulls adds an ORDER BY clause that puts NULLs first or last.
+// Usage: query.OrderByNulls("last_login_at", builder.Desc, builder.NullsLast)
+func (q *SelectQuery[T]) OrderByNulls(column string, direction OrderDirection, nulls NullsPosition) *SelectQuery[T] {
+	quoted, err := quoteColumn(column)
+	if err != nil {
+		keepErr(&q.err, err)
+		return q
+	}
+	return q.orderByTerm(quoted, direction, nulls)
+}
+
+// OrderByExpr adds an ORDER BY clause on an expression, written as is.
+// Usage: query.OrderByExpr("similarity(name, 'pebble')", builder.Desc)
+func (q *SelectQuery[T]) OrderByExpr(expr UnsafeExpr, direction OrderDirection) *SelectQuery[T] {
+	return q.orderByTerm(string(expr), direction, NullsDefault)
+}
+
+// OrderByRandom orders the rows randomly. It reads and sorts every matching
+// row, so keep it to small tables or filtered sets.
+func (q *SelectQuery[T]) OrderByRandom() *SelectQuery[T] {
+	return q.orderByTerm("random()", Asc, NullsDefault)
+}
+
+func (q *SelectQuery[T]) orderByTerm(sql string, direction OrderDirection, nulls NullsPosition) *SelectQuery[T] {
+	order, err := newOrderBy(sql, direction, nulls)
+	if err != nil {
+		keepErr(&q.err, err)
+		return q
+	}
+	q.orderBy = append(q.orderBy, order)
 	return q
 }
 
@@ -49,6 +129,25 @@ func (q *SelectQuery[T]) OrderByDesc(column string) *SelectQuery[T] {
 	return q.OrderBy(column, Desc)
 }
 
+// newOrderBy returns an ORDER BY term, checking direction and nulls are
+// among the defined constants, since they are written into the SQL.
+func newOrderBy(sql string, direction OrderDirection, nulls NullsPosition) (OrderBy, error) {
+	switch {
+	case strings.EqualFold(string(direction), string(Asc)):
+		direction = Asc
+	case strings.EqualFold(string(direction), string(Desc)):
+		direction = Desc
+	default:
+		return OrderBy{}, fmt.Errorf("invalid order direction %q", direction)
+	}
+	switch nulls {
+	case NullsDefault, NullsFirst, NullsLast:
+	default:
+		return OrderBy{}, fmt.Errorf("invalid nulls position %q", nulls)
+	}
+	return OrderBy{Column: sql, Direction: direction, NullsPos: nulls}, nil
+}
+
 // Limit sets the LIMIT clause.
 func (q *SelectQuery[T]) Limit(limit int) *SelectQuery[T] {
 	q.limit = &limit
@@ -67,12 +166,47 @@ func (q *SelectQuery[T]) Distinct() *SelectQuery[T] {
 	return q
 }
 
+// TableSample reads a random sample of the table instead of all of it.
+// Usage: query.TableSample(builder.System(0.5).Repeatable(42))
+func (q *SelectQuery[T]) TableSample(sample Sample) *SelectQuery[T] {
+	clause, err := sample.clause()
+	if err != nil {
+		keepErr(&q.err, err)
+		return q
+	}
+	q.sample = clause
+	return q
+}
+
 // ForUpdate adds FOR UPDATE lock.
 func (q *SelectQuery[T]) ForUpdate() *SelectQuery[T] {
 	q.forUpdate = true
 	return q
 }
 
+// UsePrimary reads from the primary even when the DB has replicas, for
+// reads that must see the caller's own recent writes.
+func (q *SelectQuery[T]) UsePrimary() *SelectQuery[T] {
+	q.primary = true
+	return q
+}
+
+// readExec returns the executor the query reads from: the primary for
+// UsePrimary and FOR UPDATE, otherwise a replica if the DB has any.
+func (q *SelectQuery[T]) readExec() Executor {
+	return q.db.readExec(q.primary || q.forUpdate)
+}
+
+// resultCache returns the cache the query reads through, nil when it isn't
+// cached. FOR UPDATE queries take locks and UsePrimary ones want the latest
+// rows, so they always reach the database.
+func (q *SelectQuery[T]) resultCache() cache.Cache {
+	if q.forUpdate || q.primary {
+		return nil
+	}
+	return q.db.cache
+}
+
 // Preload specifies relationships to eagerly load.
 // Pass the name of the Go struct field that contains the relationship.
 // Example: query.Preload("Posts").Preload("Comments")
@@ -83,7 +217,15 @@ func (q *SelectQuery[T]) Preload(relationships ...string) *SelectQuery[T] {
 
 // GroupBy adds a GROUP BY clause.
 func (q *SelectQuery[T]) GroupBy(columns ...string) *SelectQuery[T] {
-	q.groupBy = append(q.groupBy, columns...)
+	q.groupBy = append(q.groupBy, quoteAll(columns, quoteColumn, &q.err)...)
+	return q
+}
+
+// GroupByExpr adds GROUP BY expressions as written.
+func (q *SelectQuery[T]) GroupByExpr(exprs ...UnsafeExpr) *SelectQuery[T] {
+	for _, expr := range exprs {
+		q.groupBy = append(q.groupBy, string(expr))
+	}
 	return q
 }
 
@@ -94,56 +236,32 @@ func (q *SelectQuery[T]) Having(condition Condition) *SelectQuery[T] {
 }
 
 // InnerJoin adds an INNER JOIN.
-func (q *SelectQuery[T]) InnerJoin(table string, condition string, args ...interface{}) *SelectQuery[T] {
-	q.joins = append(q.joins, Join{
-		Type:      InnerJoin,
-		Table:     table,
-		Condition: condition,
-		Args:      args,
-	})
-	return q
+func (q *SelectQuery[T]) InnerJoin(table string, condition UnsafeExpr, args ...interface{}) *SelectQuery[T] {
+	return q.join(InnerJoin, table, condition, args)
 }
 
 // LeftJoin adds a LEFT JOIN.
-func (q *SelectQuery[T]) LeftJoin(table string, condition string, args ...interface{}) *SelectQuery[T] {
-	q.joins = append(q.joins, Join{
-		Type:      LeftJoin,
-		Table:     table,
-		Condition: condition,
-		Args:      args,
-	})
-	return q
+func (q *SelectQuery[T]) LeftJoin(table string, condition UnsafeExpr, args ...interface{}) *SelectQuery[T] {
+	return q.join(LeftJoin, table, condition, args)
 }
 
 // RightJoin adds a RIGHT JOIN.
-func (q *SelectQuery[T]) RightJoin(table string, condition string, args ...interface{}) *SelectQuery[T] {
-	q.joins = append(q.joins, Join{
-		Type:      RightJoin,
-		Table:     table,
-		Condition: condition,
-		Args:      args,
-	})
-	return q
+func (q *SelectQuery[T]) RightJoin(table string, condition UnsafeExpr, args ...interface{}) *SelectQuery[T] {
+	return q.join(RightJoin, table, condition, args)
 }
 
 // FullJoin adds a FULL OUTER JOIN.
-func (q *SelectQuery[T]) FullJoin(table string, condition string, args ...interface{}) *SelectQuery[T] {
-	q.joins = append(q.joins, Join{
-		Type:      FullJoin,
-		Table:     table,
-		Condition: condition,
-		Args:      args,
-	})
-	return q
+func (q *SelectQuery[T]) FullJoin(table string, condition UnsafeExpr, args ...interface{}) *SelectQuery[T] {
+	return q.join(FullJoin, table, condition, args)
 }
 
 // LateralJoin adds a LATERAL JOIN clause to the query.
 // LATERAL joins allow subqueries to reference columns from preceding FROM items
-func (q *SelectQuery[T]) LateralJoin(table string, condition string, args ...interface{}) *SelectQuery[T] {
+func (q *SelectQuery[T]) LateralJoin(table UnsafeExpr, condition UnsafeExpr, args ...interface{}) *SelectQuery[T] {
 	q.joins = append(q.joins, Join{
 		Type:      InnerJoin,
-		Table:     table,
-		Condition: condition,
+		Table:     string(table),
+		Condition: string(condition),
 		Args:      args,
 		Lateral:   true,
 	})
@@ -151,33 +269,66 @@ func (q *SelectQuery[T]) LateralJoin(table string, condition string, args ...int
 }
 
 // LeftLateralJoin adds a LEFT LATERAL JOIN clause to the query.
-func (q *SelectQuery[T]) LeftLateralJoin(table string, condition string, args ...interface{}) *SelectQuery[T] {
+func (q *SelectQuery[T]) LeftLateralJoin(table UnsafeExpr, condition UnsafeExpr, args ...interface{}) *SelectQuery[T] {
 	q.joins = append(q.joins, Join{
 		Type:      LeftJoin,
-		Table:     table,
-		Condition: condition,
+		Table:     string(table),
+		Condition: string(condition),
 		Args:      args,
 		Lateral:   true,
 	})
 	return q
 }
 
+// join adds a join on a table name, which may carry an alias.
+func (q *SelectQuery[T]) join(typ JoinType, table string, condition UnsafeExpr, args []interface{}) *SelectQuery[T] {
+	quoted, err := quoteTable(table)
+	if err != nil {
+		keepErr(&q.err, err)
+		return q
+	}
+	q.joins = append(q.joins, Join{
+		Type:      typ,
+		Table:     quoted,
+		Condition: string(condition),
+		Args:      args,
+	})
+	return q
+}
+
 // ToSQL generates the SQL query and arguments.
 func (q *SelectQuery[T]) ToSQL() (string, []interface{}, error) {
-	return buildSelectSQL(selectSpec{
-		table: q.table, distinct: q.distinct, columns: q.columns, joins: q.joins,
-		where: q.where, groupBy: q.groupBy, having: q.having, orderBy: q.orderBy,
+	if q.err != nil {
+		return "", nil, q.err
+	}
+	return buildSelectSQL(q.spec())
+}
+
+func (q *SelectQuery[T]) spec() selectSpec {
+	return selectSpec{
+		table: q.table, distinct: q.distinct, columns: q.columns, colArgs: q.colArgs, sample: q.sample, asOf: q.asOf, joins: q.joins,
+		where: scoped(q.table, q.where, q.unscoped), groupBy: q.groupBy, having: q.having, orderBy: q.orderBy,
 		limit: q.limit, offset: q.offset, forUpdate: q.forUpdate,
-	})
+	}
 }
 
 // All executes the query and returns all results.
 func (q *SelectQuery[T]) All(ctx context.Context) ([]T, error) {
+	q, err := q.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
 	sql, args, err := q.ToSQL()
 	if err != nil {
 		return nil, err
 	}
-	return queryRows[T](ctx, q.db.db, q.table, sql, args, q.preloads)
+	return cachedRead(ctx, q.resultCache(), q.cacheTTL, q.table, sql, args, q.preloads, func() (results []T, err error) {
+		err = q.readWith(ctx, false, func(ctx context.Context, exec Executor) error {
+			results, err = queryRows[T](ctx, exec, q.table, sql, args, q.preloads, q.db.relatedTables())
+			return err
+		})
+		return results, err
+	})
 }
 
 // First executes the query and returns the first result.
@@ -195,11 +346,19 @@ func (q *SelectQuery[T]) First(ctx context.Context) (*T, error) {
 
 // Count executes a COUNT query.
 func (q *SelectQuery[T]) Count(ctx context.Context) (int64, error) {
-	sql, args, err := buildCountSQL(q.table, q.where)
+	q, err := q.resolve(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if q.err != nil {
+		return 0, q.err
+	}
+	// A sampled or historical count needs the FROM clause of the select
+	sql, args, err := buildPageCountSQL(selectSpec{table: q.table, sample: q.sample, asOf: q.asOf, where: scoped(q.table, q.where, q.unscoped)})
 	if err != nil {
 		return 0, err
 	}
-	return queryCount(ctx, q.db.db, sql, args)
+	return cachedRead(ctx, q.resultCache(), q.cacheTTL, q.table, sql, args, nil, countWith(ctx, q, sql, args))
 }
 
 // Exists checks if any rows match the query.
@@ -5,6 +5,7 @@ import (
 
 	"github.com/marshallshelly/pebble-orm/pkg/registry"
 	"github.com/marshallshelly/pebble-orm/pkg/runtime"
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
 )
 
 type TestUser struct {
@@ -81,6 +82,39 @@ func TestSelectQuery_ToSQL(t *testing.T) {
 			wantSQL:    "SELECT * FROM test_user ORDER BY age DESC, name ASC",
 			wantArgLen: 0,
 		},
+		{
+			name: "select with NULLS LAST, expression and random ORDER BY",
+			setupQuery: func() *SelectQuery[TestUser] {
+				return Select[TestUser](db).
+					OrderByNulls("email", Desc, NullsLast).
+					OrderByExpr("length(name) % 2", Asc).
+					OrderByRandom()
+			},
+			wantSQL:    "SELECT * FROM test_user ORDER BY email DESC NULLS LAST, length(name) % 2 ASC, random() ASC",
+			wantArgLen: 0,
+		},
+		{
+			name: "select with lowercase ORDER BY direction",
+			setupQuery: func() *SelectQuery[TestUser] {
+				return Select[TestUser](db).OrderBy("name", "desc")
+			},
+			wantSQL:    "SELECT * FROM test_user ORDER BY name DESC",
+			wantArgLen: 0,
+		},
+		{
+			name: "select with invalid ORDER BY direction",
+			setupQuery: func() *SelectQuery[TestUser] {
+				return Select[TestUser](db).OrderBy("name", "ASC; DROP TABLE test_user")
+			},
+			wantErr: true,
+		},
+		{
+			name: "select with invalid NULLS position",
+			setupQuery: func() *SelectQuery[TestUser] {
+				return Select[TestUser](db).OrderByNulls("name", Asc, "NULLS SOMEWHERE")
+			},
+			wantErr: true,
+		},
 		{
 			name: "select with LIMIT",
 			setupQuery: func() *SelectQuery[TestUser] {
@@ -330,3 +364,105 @@ func TestDB_Select(t *testing.T) {
 		}
 	})
 }
+
+type TestArticle struct {
+	ID     int64  `po:"id,bigserial,primaryKey,group(summary,detail)"`
+	Title  string `po:"title,text,group(summary,detail)"`
+	Body   string `po:"body,text,group(detail)"`
+	Author string `po:"author,text,group(byline)"`
+}
+
+func TestSelectQuery_Fields(t *testing.T) {
+	if err := registry.Register(TestArticle{}); err != nil {
+		t.Fatalf("Failed to register model: %v", err)
+	}
+	db := New(nil)
+
+	tests := []struct {
+		groups  []string
+		wantSQL string
+	}{
+		{[]string{"summary"}, "SELECT id, title FROM test_article"},
+		{[]string{"detail"}, "SELECT id, title, body FROM test_article"},
+		{[]string{"summary", "byline"}, "SELECT id, title, author FROM test_article"},
+	}
+	for _, tt := range tests {
+		sql, _, err := Select[TestArticle](db).Fields(tt.groups...).ToSQL()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sql != tt.wantSQL {
+			t.Errorf("Fields(%v) = %s, want %s", tt.groups, sql, tt.wantSQL)
+		}
+	}
+
+	if _, _, err := Select[TestArticle](db).Fields("summary", "missing").ToSQL(); err == nil {
+		t.Error("expected an error for a group with no columns")
+	}
+}
+
+// default_scope: deleted_at IS NULL
+type TestPost struct {
+	ID        int64   `po:"id,bigserial,primaryKey"`
+	Title     string  `po:"title,text"`
+	DeletedAt *string `po:"deleted_at,timestamptz"`
+}
+
+func TestQualifyScope(t *testing.T) {
+	table := &schema.TableMetadata{Columns: []schema.ColumnMetadata{{Name: "status"}, {Name: "deleted_at"}, {Name: "text"}}}
+	tests := []struct {
+		scope string
+		want  string
+	}{
+		{"deleted_at IS NULL", "t.deleted_at IS NULL"},
+		{"status <> 'status' AND deleted_at IS NULL", "t.status <> 'status' AND t.deleted_at IS NULL"},
+		{"x.status = 'it''s' AND \"status\" = $1", "x.status = 'it''s' AND \"status\" = $1"},
+		{"status::text = lower(status)", "t.status::text = lower(t.status)"},
+		{"text(status) <> ''", "text(t.status) <> ''"},
+	}
+	for _, tt := range tests {
+		table.DefaultScope = tt.scope
+		if got := qualifyScope(table, "t"); got != tt.want {
+			t.Errorf("qualifyScope(%q) = %q, want %q", tt.scope, got, tt.want)
+		}
+	}
+}
+
+func TestDefaultScope(t *testing.T) {
+	if err := registry.Register(TestPost{}); err != nil {
+		t.Fatalf("Failed to register model: %v", err)
+	}
+	db := New(nil)
+
+	tests := []struct {
+		name  string
+		query func() (string, []interface{}, error)
+		want  string
+	}{
+		{"select", Select[TestPost](db).ToSQL, "SELECT * FROM test_post WHERE (test_post.deleted_at IS NULL)"},
+		{"select where", Select[TestPost](db).Where(Eq("id", 1)).Or(Eq("id", 2)).ToSQL,
+			"SELECT * FROM test_post WHERE (test_post.deleted_at IS NULL) AND (id = $1 OR id = $2)"},
+		{"unscoped", Select[TestPost](db).Unscoped().Where(Eq("id", 1)).ToSQL, "SELECT * FROM test_post WHERE id = $1"},
+		{"update", Update[TestPost](db).Set("title", "x").Where(Eq("id", 1)).ToSQL,
+			"UPDATE test_post SET title = $1 WHERE (test_post.deleted_at IS NULL) AND (id = $2)"},
+		{"delete", Delete[TestPost](db).Where(Eq("id", 1)).ToSQL, "DELETE FROM test_post WHERE (test_post.deleted_at IS NULL) AND (id = $1)"},
+		{"delete unscoped", Delete[TestPost](db).Unscoped().Where(Eq("id", 1)).ToSQL, "DELETE FROM test_post WHERE id = $1"},
+		{"join", Select[TestPost](db).Columns("test_post.id").InnerJoin("test_comment c", "c.post_id = test_post.id").ToSQL,
+			"SELECT test_post.id FROM test_post INNER JOIN test_comment c ON c.post_id = test_post.id WHERE (test_post.deleted_at IS NULL)"},
+		{"bulk update", BulkUpdate[TestPost](db).Rows([]TestPost{{ID: 1, Title: "x"}}).Columns("title").ToSQL,
+			"UPDATE test_post AS t SET title = v.title FROM (VALUES ($1::bigint, $2::text)) AS v (id, title) WHERE t.id = v.id AND (t.deleted_at IS NULL)"},
+		{"bulk update unscoped", BulkUpdate[TestPost](db).Unscoped().Rows([]TestPost{{ID: 1, Title: "x"}}).Columns("title").ToSQL,
+			"UPDATE test_post AS t SET title = v.title FROM (VALUES ($1::bigint, $2::text)) AS v (id, title) WHERE t.id = v.id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, _, err := tt.query()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sql != tt.want {
+				t.Errorf("SQL = %s, want %s", sql, tt.want)
+			}
+		})
+	}
+}
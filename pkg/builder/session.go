@@ -0,0 +1,288 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/marshallshelly/pebble-orm/pkg/registry"
+	"github.com/marshallshelly/pebble-orm/pkg/runtime"
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+)
+
+const (
+	// sessionBatchWait is how long a session collects FindByPK calls before
+	// loading them in one query.
+	sessionBatchWait = time.Millisecond
+	// sessionMaxBatch caps the keys loaded by one query.
+	sessionMaxBatch = 500
+)
+
+// Session is a request-scoped identity map. FindByPK calls made with its
+// context share one result per primary key, and concurrent calls for the
+// same model are batched into a single SELECT ... WHERE pk = ANY($1), so
+// handlers that fan out (GraphQL resolvers, per-item lookups) don't issue
+// one query per row.
+//
+// Results stay for the life of the session and are shared between callers;
+// treat them as read-only, and Clear the session after writing rows it may
+// have loaded.
+type Session struct {
+	ctx     context.Context
+	mu      sync.Mutex
+	loaders map[loaderKey]any // *pkLoader[T]
+}
+
+type loaderKey struct {
+	db    *DB
+	typ   reflect.Type
+	table string
+}
+
+type sessionKey struct{}
+
+// NewSession returns a context carrying a new Session. Queries the session
+// batches run with ctx, so it should live no longer than the request.
+// Usage: ctx = builder.NewSession(r.Context())
+func NewSession(ctx context.Context) context.Context {
+	s := &Session{loaders: make(map[loaderKey]any)}
+	ctx = context.WithValue(ctx, sessionKey{}, s)
+	s.ctx = ctx
+	return ctx
+}
+
+// SessionFrom returns the Session carried by ctx, or nil.
+func SessionFrom(ctx context.Context) *Session {
+	s, _ := ctx.Value(sessionKey{}).(*Session)
+	return s
+}
+
+// Clear forgets every row the session has loaded.
+func (s *Session) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clear(s.loaders)
+}
+
+// FindByPK returns the row of T whose primary key is pk, or
+// runtime.ErrNotFound. A composite primary key takes one value per column,
+// in the order the columns are declared. Under a Session (see NewSession)
+// single-column lookups are deduplicated and batched; otherwise it runs a
+// single SELECT.
+// Usage: user, err := builder.FindByPK[User](ctx, qb, id)
+// Usage: m, err := builder.FindByPK[Membership](ctx, qb, userID, groupID)
+func FindByPK[T any](ctx context.Context, d *DB, pk ...interface{}) (*T, error) {
+	var model T
+	table, err := registry.GetOrRegister(model)
+	if err != nil {
+		return nil, err
+	}
+	table = inSchema(table, d.schema)
+	columns, fields, err := primaryKeyFields(reflect.TypeFor[T](), table)
+	if err != nil {
+		return nil, err
+	}
+	if len(pk) != len(columns) {
+		return nil, fmt.Errorf("%s: primary key has %d columns, got %d values", table.Name, len(columns), len(pk))
+	}
+	keys := make([]interface{}, len(pk))
+	for i := range pk {
+		if keys[i], err = normalizeKey(pk[i], fields[i].typ); err != nil {
+			return nil, fmt.Errorf("%s: %w", table.Name, err)
+		}
+	}
+
+	s := SessionFrom(ctx)
+	if s == nil || len(columns) > 1 {
+		q := Select[T](d)
+		for i, column := range columns {
+			q.Where(Eq(column, keys[i]))
+		}
+		rows, err := q.Limit(1).All(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(rows) == 0 {
+			return nil, runtime.ErrNotFound
+		}
+		return &rows[0], nil
+	}
+	if table, err = resolveTable(ctx, d.resolver, table); err != nil {
+		return nil, err
+	}
+	return loaderFor[T](s, d, table, columns[0], fields[0]).load(ctx, keys[0])
+}
+
+// pkField is the primary-key field of a model.
+type pkField struct {
+	index []int
+	typ   reflect.Type
+}
+
+// primaryKeyFields returns the names and fields of table's primary-key
+// columns, in declaration order.
+func primaryKeyFields(typ reflect.Type, table *schema.TableMetadata) ([]string, []pkField, error) {
+	pk := table.PrimaryKeyColumns()
+	if len(pk) == 0 {
+		return nil, nil, fmt.Errorf("%s: %w", table.Name, runtime.ErrNoPrimaryKey)
+	}
+	fields := make([]pkField, len(pk))
+	for i, column := range pk {
+		col := table.GetColumnByName(column)
+		if col == nil {
+			return nil, nil, fmt.Errorf("%s: primary key column %s not found", table.Name, column)
+		}
+		index, fieldType, err := fieldIndex(typ, col.GoField)
+		if err != nil {
+			return nil, nil, err
+		}
+		if index == nil {
+			return nil, nil, fmt.Errorf("%s: no field for primary key column %s", table.Name, column)
+		}
+		fields[i] = pkField{index: index, typ: fieldType}
+	}
+	return pk, fields, nil
+}
+
+// normalizeKey converts pk to the primary-key field's type, so that 7 and
+// int64(7) find the same row.
+func normalizeKey(pk interface{}, typ reflect.Type) (interface{}, error) {
+	v := reflect.ValueOf(pk)
+	if !v.IsValid() {
+		return nil, fmt.Errorf("nil primary key")
+	}
+	if v.Type() != typ {
+		if !v.CanConvert(typ) {
+			return nil, fmt.Errorf("primary key %v is a %s, not %s", pk, v.Type(), typ)
+		}
+		v = v.Convert(typ)
+	}
+	if !v.Comparable() {
+		return nil, fmt.Errorf("primary key type %s is not comparable", typ)
+	}
+	return v.Interface(), nil
+}
+
+// pkLoader loads rows of one model by primary key for a session.
+type pkLoader[T any] struct {
+	s      *Session
+	d      *DB
+	exec   func() Executor // the executor for each batch, a replica if d has any
+	table  *schema.TableMetadata
+	column string
+	field  pkField
+
+	mu      sync.Mutex
+	results map[interface{}]*pkResult[T]
+	pending []interface{} // keys waiting for the next batch
+}
+
+type pkResult[T any] struct {
+	done  chan struct{}
+	value *T
+	err   error
+}
+
+func loaderFor[T any](s *Session, d *DB, table *schema.TableMetadata, column string, field pkField) *pkLoader[T] {
+	key := loaderKey{db: d, typ: reflect.TypeFor[T](), table: table.QualifiedName()}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l, ok := s.loaders[key]; ok {
+		return l.(*pkLoader[T])
+	}
+	l := &pkLoader[T]{
+		s:       s,
+		d:       d,
+		exec:    func() Executor { return d.readExec(false) },
+		table:   table,
+		column:  column,
+		field:   field,
+		results: make(map[interface{}]*pkResult[T]),
+	}
+	s.loaders[key] = l
+	return l
+}
+
+// load returns the row for key, joining a pending or finished lookup when
+// there is one.
+func (l *pkLoader[T]) load(ctx context.Context, key interface{}) (*T, error) {
+	l.mu.Lock()
+	r, ok := l.results[key]
+	if !ok {
+		r = &pkResult[T]{done: make(chan struct{})}
+		l.results[key] = r
+		l.pending = append(l.pending, key)
+		switch len(l.pending) {
+		case sessionMaxBatch:
+			batch := l.pending
+			l.pending = nil
+			go l.fetch(batch)
+		case 1:
+			time.AfterFunc(sessionBatchWait, l.flush)
+		}
+	}
+	l.mu.Unlock()
+
+	select {
+	case <-r.done:
+		return r.value, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// flush loads the keys collected since the batch started.
+func (l *pkLoader[T]) flush() {
+	l.mu.Lock()
+	batch := l.pending
+	l.pending = nil
+	l.mu.Unlock()
+	if len(batch) > 0 {
+		l.fetch(batch)
+	}
+}
+
+// fetch loads batch in one query and completes its results. Rows that don't
+// exist are remembered as not found; failed lookups are forgotten so a later
+// call retries them.
+func (l *pkLoader[T]) fetch(batch []interface{}) {
+	rows, err := l.query(batch)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, key := range batch {
+		r := l.results[key]
+		switch {
+		case err != nil:
+			r.err = err
+			delete(l.results, key)
+		case rows[key] != nil:
+			r.value = rows[key]
+		default:
+			r.err = runtime.ErrNotFound
+		}
+		close(r.done)
+	}
+}
+
+func (l *pkLoader[T]) query(keys []interface{}) (map[interface{}]*T, error) {
+	sql := fmt.Sprintf("SELECT %s FROM %s WHERE %s = ANY($1)%s",
+		selectStar(l.table), schema.QuoteQualifiedIdent(l.table.QualifiedName()), schema.QuoteReservedIdent(l.column), scopeFilter(l.table, schema.QuoteReservedIdent(l.table.Name)))
+	// Retried like FindByPK's own SELECT outside a session
+	var results []T
+	err := l.d.retryPolicy().retry(l.s.ctx, func() (err error) {
+		results, err = queryRows[T](l.s.ctx, l.exec(), l.table, sql, []interface{}{convertToTypedSlice(keys)}, nil, l.d.relatedTables())
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	byKey := make(map[interface{}]*T, len(results))
+	for i := range results {
+		row := &results[i]
+		byKey[reflect.ValueOf(row).Elem().FieldByIndex(l.field.index).Interface()] = row
+	}
+	return byKey, nil
+}
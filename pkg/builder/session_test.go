@@ -0,0 +1,163 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/marshallshelly/pebble-orm/pkg/registry"
+	"github.com/marshallshelly/pebble-orm/pkg/runtime"
+)
+
+// readingsExecutor serves ScanReading rows by id for ANY($1) lookups and
+// records each query's keys.
+type readingsExecutor struct {
+	stubExecutor
+	mu      sync.Mutex
+	batches [][]int64
+}
+
+func (e *readingsExecutor) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	keys := args[0].([]int64)
+	e.mu.Lock()
+	e.batches = append(e.batches, keys)
+	e.mu.Unlock()
+
+	var rows [][]interface{}
+	for _, id := range keys {
+		if id <= 2 {
+			rows = append(rows, []interface{}{id, "t", 1.5, []byte(`{}`), 1})
+		}
+	}
+	return newFakeRows([]string{"id", "sensor", "value", "labels", "version"}, rows...), nil
+}
+
+func TestSessionBatchesLookups(t *testing.T) {
+	table, err := registry.GetOrRegister(ScanReading{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	columns, fields, err := primaryKeyFields(reflect.TypeFor[ScanReading](), table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := NewSession(context.Background())
+	s := SessionFrom(ctx)
+	exec := &readingsExecutor{}
+	l := loaderFor[ScanReading](s, New(&runtime.DB{}), table, columns[0], fields[0])
+	l.exec = func() Executor { return exec }
+
+	keys := []int64{1, 2, 1, 3}
+	got := make([]*ScanReading, len(keys))
+	errs := make([]error, len(keys))
+	var wg sync.WaitGroup
+	for i, key := range keys {
+		wg.Go(func() { got[i], errs[i] = l.load(ctx, key) })
+	}
+	wg.Wait()
+
+	if len(exec.batches) != 1 || len(exec.batches[0]) != 3 {
+		t.Fatalf("batches = %v, want one batch of the 3 distinct keys", exec.batches)
+	}
+	if got[0] == nil || got[0].ID != 1 || got[1] == nil || got[1].ID != 2 {
+		t.Errorf("got %+v, %+v; want rows 1 and 2", got[0], got[1])
+	}
+	if got[0] != got[2] {
+		t.Error("the same key should return the same row")
+	}
+	if !errors.Is(errs[3], runtime.ErrNotFound) {
+		t.Errorf("missing row err = %v, want ErrNotFound", errs[3])
+	}
+
+	// Loaded and missing keys are answered without another query
+	if row, _ := l.load(ctx, int64(1)); row != got[0] {
+		t.Error("repeat lookup should return the loaded row")
+	}
+	if _, err := l.load(ctx, int64(3)); !errors.Is(err, runtime.ErrNotFound) {
+		t.Errorf("repeat missing lookup err = %v", err)
+	}
+	if len(exec.batches) != 1 {
+		t.Errorf("batches = %v, want no further queries", exec.batches)
+	}
+
+	s.Clear()
+	if loaderFor[ScanReading](s, l.d, table, columns[0], fields[0]) == l {
+		t.Error("Clear should drop the session's loaders")
+	}
+}
+
+func TestNormalizeKey(t *testing.T) {
+	int64Type := reflect.TypeFor[int64]()
+	tests := []struct {
+		name    string
+		pk      interface{}
+		want    interface{}
+		wantErr bool
+	}{
+		{"same type", int64(7), int64(7), false},
+		{"converted", 7, int64(7), false},
+		{"not convertible", "7", nil, true},
+		{"nil", nil, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeKey(tt.pk, int64Type)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+// table_name: session_membership
+type SessionMembership struct {
+	UserID  int64 `po:"user_id,primaryKey,bigint"`
+	GroupID int64 `po:"group_id,primaryKey,bigint"`
+}
+
+func TestCompositePrimaryKey(t *testing.T) {
+	exec := &returningExecutor{
+		columns: []string{"user_id", "group_id"},
+		rows:    [][]interface{}{{int64(1), int64(2)}},
+	}
+	db := NewWithExecutor(exec)
+	const want = "SELECT * FROM session_membership WHERE user_id = $1 AND group_id = $2 LIMIT 1"
+
+	for _, ctx := range []context.Context{context.Background(), NewSession(context.Background())} {
+		exec.sql = nil
+		m, err := FindByPK[SessionMembership](ctx, db, 1, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.UserID != 1 || m.GroupID != 2 {
+			t.Errorf("row = %+v", m)
+		}
+		if exec.sql[0] != want {
+			t.Errorf("SQL = %s, want %s", exec.sql[0], want)
+		}
+	}
+
+	if _, err := FindByPK[SessionMembership](context.Background(), db, 1); err == nil {
+		t.Error("expected an error for a missing key value")
+	}
+
+	exec.sql = nil
+	m := SessionMembership{UserID: 1, GroupID: 2}
+	if err := Reload(context.Background(), db, &m); err != nil {
+		t.Fatal(err)
+	}
+	if exec.sql[0] != want {
+		t.Errorf("Reload SQL = %s, want %s", exec.sql[0], want)
+	}
+
+	exec.rows = nil
+	if _, err := FindByPK[SessionMembership](context.Background(), db, 1, 3); !errors.Is(err, runtime.ErrNotFound) {
+		t.Errorf("err = %v, want ErrNotFound", err)
+	}
+}
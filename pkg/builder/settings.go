@@ -0,0 +1,59 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// WithConnectionSettings runs fn in a transaction whose connection has the
+// given run-time parameters set, such as search_path, role or any GUC. They
+// apply like SET LOCAL, so they are reset when the transaction ends and never
+// leak to other users of the pooled connection:
+//
+//	err := db.WithConnectionSettings(ctx, map[string]string{
+//		"search_path": "tenant_42",
+//		"role":        "app_readonly",
+//	}, func(tx *builder.Tx) error {
+//		users, err := builder.TxSelect[User](tx).All()
+//		...
+//	})
+//
+// The transaction commits if fn returns nil and rolls back otherwise.
+func (d *DB) WithConnectionSettings(ctx context.Context, settings map[string]string, fn func(tx *Tx) error) error {
+	tx, err := d.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if len(settings) > 0 {
+		sql, args := buildSettingsSQL(settings)
+		if _, err := tx.exec().Exec(ctx, sql, args...); err != nil {
+			return fmt.Errorf("failed to apply connection settings: %w", err)
+		}
+	}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// buildSettingsSQL sets each parameter with set_config(name, value, true),
+// the parameterized form of SET LOCAL, in name order.
+func buildSettingsSQL(settings map[string]string) (string, []interface{}) {
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	calls := make([]string, len(names))
+	args := make([]interface{}, 0, 2*len(names))
+	for i, name := range names {
+		calls[i] = fmt.Sprintf("set_config($%d, $%d, true)", 2*i+1, 2*i+2)
+		args = append(args, name, settings[name])
+	}
+	return "SELECT " + strings.Join(calls, ", "), args
+}
@@ -0,0 +1,22 @@
+package builder
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuildSettingsSQL(t *testing.T) {
+	sql, args := buildSettingsSQL(map[string]string{
+		"search_path": "tenant_42",
+		"role":        "app_readonly",
+	})
+
+	wantSQL := "SELECT set_config($1, $2, true), set_config($3, $4, true)"
+	if sql != wantSQL {
+		t.Errorf("expected %q, got %q", wantSQL, sql)
+	}
+	wantArgs := []interface{}{"role", "app_readonly", "search_path", "tenant_42"}
+	if !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("expected args %v, got %v", wantArgs, args)
+	}
+}
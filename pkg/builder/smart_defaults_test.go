@@ -362,3 +362,32 @@ func TestFieldWithoutDefault(t *testing.T) {
 func strPtr(s string) *string {
 	return &s
 }
+
+// TestSmartDefaultsMultiRow verifies that a later row's value for a column
+// with a default is kept when the first row leaves the column zero.
+func TestSmartDefaultsMultiRow(t *testing.T) {
+	table := &schema.TableMetadata{
+		Name: "posts",
+		Columns: []schema.ColumnMetadata{
+			{Name: "title", GoField: "Title", SQLType: "text"},
+			{Name: "status", GoField: "Status", SQLType: "text", Default: strPtr("'draft'")},
+		},
+	}
+	type Post struct {
+		Title  string
+		Status string
+	}
+
+	sql, args, err := buildInsertSQL(insertSpec{table: table, rows: []interface{}{
+		Post{Title: "a"}, Post{Title: "b", Status: "published"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "INSERT INTO posts (title, status) VALUES ($1, DEFAULT), ($2, $3)"; sql != want {
+		t.Errorf("sql = %q, want %q", sql, want)
+	}
+	if len(args) != 3 || args[2] != "published" {
+		t.Errorf("args = %v", args)
+	}
+}
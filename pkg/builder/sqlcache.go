@@ -0,0 +1,318 @@
+package builder
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+)
+
+// sqlCache holds generated SELECT, COUNT and DELETE statements by query
+// shape: the table, clauses, column names, operators and number of values,
+// everything that determines the SQL text but not the bound values. A query
+// whose shape has been seen before only collects its arguments.
+//
+// Shapes come from code, so a program has a bounded number of them; IN lists
+// of varying length and raw SQL are the exception, which the entry limit
+// guards against. Once full, a new shape evicts one that has not been read
+// since the clock hand last passed it, so the shapes in use stay cached.
+type sqlCache struct {
+	mu      sync.RWMutex
+	entries map[string]*sqlEntry // shape key -> SQL
+	ring    []string             // keys in clock order
+	hand    int
+	limit   int
+}
+
+type sqlEntry struct {
+	sql  string
+	used atomic.Bool
+}
+
+var statementCache = &sqlCache{limit: 4096}
+
+func (c *sqlCache) get(key []byte) (string, bool) {
+	c.mu.RLock()
+	e, ok := c.entries[string(key)]
+	c.mu.RUnlock()
+	if !ok {
+		return "", false
+	}
+	e.used.Store(true)
+	return e.sql, true
+}
+
+func (c *sqlCache) put(key []byte, sql string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.limit <= 0 {
+		return
+	}
+	if c.entries == nil {
+		c.entries = make(map[string]*sqlEntry)
+	}
+	k := string(key)
+	if e, ok := c.entries[k]; ok {
+		e.sql = sql
+		return
+	}
+	if len(c.ring) < c.limit {
+		c.entries[k] = &sqlEntry{sql: sql}
+		c.ring = append(c.ring, k)
+		return
+	}
+	// Give every recently read shape a second chance before evicting.
+	for c.entries[c.ring[c.hand]].used.Swap(false) {
+		c.hand = (c.hand + 1) % len(c.ring)
+	}
+	delete(c.entries, c.ring[c.hand])
+	c.entries[k] = &sqlEntry{sql: sql}
+	c.ring[c.hand] = k
+	c.hand = (c.hand + 1) % len(c.ring)
+}
+
+// buildSelectSQL returns the SELECT statement for s and its arguments, from
+// the cache when a query of the same shape was built before.
+func buildSelectSQL(s selectSpec) (string, []interface{}, error) {
+	if s.table == nil {
+		return generateSelectSQL(s)
+	}
+	w := newShapeWriter('S')
+	defer w.release()
+	w.table(s.table)
+	w.bool(s.distinct)
+	w.strs(s.columns)
+	w.str(s.sample)
+	w.bool(s.asOf != nil)
+	w.int(len(s.joins))
+	for _, join := range s.joins {
+		w.str(string(join.Type))
+		w.str(join.Table)
+		w.str(join.Condition)
+		w.int(len(join.Args))
+		w.bool(join.Lateral)
+	}
+	w.conditions(s.where)
+	w.strs(s.groupBy)
+	w.conditions(s.having)
+	w.int(len(s.orderBy))
+	for _, order := range s.orderBy {
+		w.str(order.Column)
+		w.str(string(order.Direction))
+		w.str(string(order.NullsPos))
+	}
+	w.intPtr(s.limit)
+	w.intPtr(s.offset)
+	w.bool(s.forUpdate)
+
+	if sql, ok := w.lookup(); ok {
+		n := len(s.colArgs) + countConditionArgs(s.where) + countConditionArgs(s.having)
+		for _, join := range s.joins {
+			n += len(join.Args)
+		}
+		if s.asOf != nil {
+			n++
+		}
+		if n == 0 {
+			return sql, nil, nil
+		}
+		args := make([]interface{}, 0, n)
+		args = append(args, s.colArgs...)
+		if s.asOf != nil {
+			args = append(args, *s.asOf)
+		}
+		for _, join := range s.joins {
+			args = append(args, join.Args...)
+		}
+		args = appendConditionArgs(args, s.where)
+		return sql, appendConditionArgs(args, s.having), nil
+	}
+	sql, args, err := generateSelectSQL(s)
+	w.store(sql, err)
+	return sql, args, err
+}
+
+// buildCountSQL returns the COUNT statement for table and where, from the
+// cache when possible.
+func buildCountSQL(table *schema.TableMetadata, where []Condition) (string, []interface{}, error) {
+	if table == nil {
+		return generateCountSQL(table, where)
+	}
+	w := newShapeWriter('C')
+	defer w.release()
+	w.table(table)
+	w.conditions(where)
+
+	if sql, ok := w.lookup(); ok {
+		return sql, conditionArgs(where), nil
+	}
+	sql, args, err := generateCountSQL(table, where)
+	w.store(sql, err)
+	return sql, args, err
+}
+
+// buildDeleteSQL returns the DELETE statement for s and its arguments, from
+// the cache when possible.
+func buildDeleteSQL(s deleteSpec) (string, []interface{}, error) {
+	if s.table == nil || checkWritable(s.table) != nil {
+		return generateDeleteSQL(s)
+	}
+	w := newShapeWriter('D')
+	defer w.release()
+	w.table(s.table)
+	w.conditions(s.where)
+	w.strs(s.returning)
+
+	if sql, ok := w.lookup(); ok {
+		return sql, conditionArgs(s.where), nil
+	}
+	sql, args, err := generateDeleteSQL(s)
+	w.store(sql, err)
+	return sql, args, err
+}
+
+// shapeWriter encodes a query shape as a cache key. Strings are length
+// prefixed so adjacent fields can't run together into the same key. Writers
+// are pooled, so building a key for a cached shape doesn't allocate.
+type shapeWriter struct {
+	buf     []byte
+	invalid bool // a condition the WHERE builder would reject
+}
+
+var shapeWriters = sync.Pool{New: func() any { return &shapeWriter{buf: make([]byte, 0, 256)} }}
+
+func newShapeWriter(kind byte) *shapeWriter {
+	w := shapeWriters.Get().(*shapeWriter)
+	w.buf = append(w.buf, kind)
+	return w
+}
+
+func (w *shapeWriter) release() {
+	w.buf = w.buf[:0]
+	w.invalid = false
+	shapeWriters.Put(w)
+}
+
+// lookup returns the cached SQL for the shape. Shapes the writer couldn't
+// describe are never cached.
+func (w *shapeWriter) lookup() (string, bool) {
+	if w.invalid {
+		return "", false
+	}
+	return statementCache.get(w.buf)
+}
+
+// store caches sql for the shape, unless generating it failed.
+func (w *shapeWriter) store(sql string, err error) {
+	if err == nil && !w.invalid {
+		statementCache.put(w.buf, sql)
+	}
+}
+
+func (w *shapeWriter) str(s string) {
+	w.int(len(s))
+	w.buf = append(w.buf, s...)
+}
+
+// table writes the table's name as QualifiedName has it, without building
+// the qualified string, and its column metadata, as the scan and value plan
+// caches key it: models mapped to one table can select different columns.
+func (w *shapeWriter) table(table *schema.TableMetadata) {
+	w.buf = strconv.AppendUint(w.buf, uint64(uintptr(unsafe.Pointer(unsafe.SliceData(table.Columns)))), 16)
+	w.buf = append(w.buf, ';')
+	if table.Schema == "" || table.Schema == schema.DefaultSchema {
+		w.str("")
+	} else {
+		w.str(table.Schema)
+	}
+	w.str(table.Name)
+}
+
+func (w *shapeWriter) strs(values []string) {
+	w.int(len(values))
+	for _, s := range values {
+		w.str(s)
+	}
+}
+
+func (w *shapeWriter) int(n int) {
+	w.buf = strconv.AppendInt(w.buf, int64(n), 10)
+	w.buf = append(w.buf, ';')
+}
+
+func (w *shapeWriter) intPtr(n *int) {
+	if n == nil {
+		w.buf = append(w.buf, '-')
+		return
+	}
+	w.int(*n)
+}
+
+func (w *shapeWriter) bool(b bool) {
+	if b {
+		w.buf = append(w.buf, 't')
+	} else {
+		w.buf = append(w.buf, 'f')
+	}
+}
+
+// conditions writes everything writeConditions puts in the SQL text.
+func (w *shapeWriter) conditions(conditions []Condition) {
+	w.int(len(conditions))
+	for _, cond := range conditions {
+		w.str(string(cond.Logic))
+		if len(cond.Group) > 0 {
+			w.buf = append(w.buf, '(')
+			w.conditions(cond.Group)
+			continue
+		}
+		w.str(cond.Column)
+		w.str(string(cond.Operator))
+		w.bool(cond.Not)
+		w.str(cond.ValueSQL)
+		switch {
+		case cond.Raw:
+			raw, ok := cond.Value.(string)
+			w.invalid = w.invalid || !ok
+			w.buf = append(w.buf, 'r')
+			w.str(raw)
+		case bindsList(cond.Operator):
+			values, ok := cond.Value.([]interface{})
+			w.invalid = w.invalid || !ok
+			w.int(len(values))
+		case cond.Operator == OpExists:
+			subquery, ok := cond.Value.(string)
+			w.invalid = w.invalid || !ok
+			w.str(subquery)
+		}
+	}
+}
+
+// conditionArgs returns the arguments writeConditions binds for conditions.
+func conditionArgs(conditions []Condition) []interface{} {
+	if len(conditions) == 0 {
+		return nil
+	}
+	return appendConditionArgs(make([]interface{}, 0, countConditionArgs(conditions)), conditions)
+}
+
+// appendConditionArgs appends the arguments writeConditions binds for
+// conditions, in placeholder order.
+func appendConditionArgs(args []interface{}, conditions []Condition) []interface{} {
+	for _, cond := range conditions {
+		switch {
+		case len(cond.Group) > 0:
+			args = appendConditionArgs(args, cond.Group)
+		case cond.Raw:
+			args = append(args, cond.Args...)
+		case bindsList(cond.Operator):
+			args = append(args, cond.Value.([]interface{})...)
+		case bindsNothing(cond.Operator):
+		default:
+			args = append(args, cond.Value)
+		}
+	}
+	return args
+}
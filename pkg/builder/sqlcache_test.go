@@ -0,0 +1,222 @@
+package builder
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/marshallshelly/pebble-orm/pkg/registry"
+)
+
+func TestSQLCache(t *testing.T) {
+	table, err := registry.GetOrRegister(TestUser{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	statementCache = &sqlCache{limit: 4096}
+	limit, offset := 10, 20
+
+	tests := []struct {
+		name string
+		spec selectSpec
+	}{
+		{"no where", selectSpec{table: table}},
+		{"eq", selectSpec{table: table, where: []Condition{Eq("id", "a")}}},
+		{"in", selectSpec{table: table, where: []Condition{In("age", 1, 2, 3)}}},
+		{"in, other length", selectSpec{table: table, where: []Condition{In("age", 1, 2)}}},
+		{"group and null", selectSpec{table: table, where: []Condition{
+			IsNull("email"),
+			Or(Group(Between("age", 18, 30), Not(Like("name", "a%")))),
+		}}},
+		{"not between and booleans", selectSpec{table: table, where: []Condition{
+			IsTrue("active"), NotBetween("age", 18, 30), IsDistinctFrom("email", "x"),
+		}}},
+		{"subquery", selectSpec{table: table, where: []Condition{
+			Eq("name", "x"),
+			InSubquery("id", NewSubquery("SELECT user_id FROM orders WHERE total > $1", 100)),
+		}}},
+		{"join, having, order and paging", selectSpec{
+			table:   table,
+			columns: []string{"name", "COUNT(*)"},
+			joins:   []Join{{Type: InnerJoin, Table: "orders", Condition: "orders.user_id = test_user.id AND orders.total > $1", Args: []interface{}{50}}},
+			where:   []Condition{Gt("age", 21)},
+			groupBy: []string{"name"},
+			having:  []Condition{Gt("COUNT(*)", 2)},
+			orderBy: []OrderBy{{Column: "name", Direction: Desc, NullsPos: NullsLast}},
+			limit:   &limit, offset: &offset, forUpdate: true,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wantSQL, wantArgs, err := generateSelectSQL(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, pass := range []string{"miss", "hit"} {
+				sql, args, err := buildSelectSQL(tt.spec)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if sql != wantSQL || !reflect.DeepEqual(args, wantArgs) {
+					t.Errorf("%s: got %q %v, want %q %v", pass, sql, args, wantSQL, wantArgs)
+				}
+			}
+		})
+	}
+
+	// Same shape, different values: the cached SQL with the new arguments.
+	sql, args, err := buildSelectSQL(selectSpec{table: table, where: []Condition{In("age", 7, 8, 9)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sql != "SELECT * FROM test_user WHERE age IN ($1, $2, $3)" || !reflect.DeepEqual(args, []interface{}{7, 8, 9}) {
+		t.Errorf("got %q %v", sql, args)
+	}
+
+	// Invalid conditions still fail.
+	if _, _, err := buildSelectSQL(selectSpec{table: table, where: []Condition{{Column: "age", Operator: OpIn, Value: 5}}}); err == nil {
+		t.Error("expected an error for an IN condition without a slice")
+	}
+
+	sql, args, err = buildCountSQL(table, []Condition{Eq("name", "n")})
+	if err != nil || sql != "SELECT COUNT(*) FROM test_user WHERE name = $1" || !reflect.DeepEqual(args, []interface{}{"n"}) {
+		t.Errorf("count: got %q %v %v", sql, args, err)
+	}
+	sql, args, err = buildDeleteSQL(deleteSpec{table: table, where: []Condition{Eq("id", "a")}, returning: []string{"*"}})
+	if err != nil || sql != "DELETE FROM test_user WHERE id = $1 RETURNING *" || !reflect.DeepEqual(args, []interface{}{"a"}) {
+		t.Errorf("delete: got %q %v %v", sql, args, err)
+	}
+}
+
+// TestAccountAdmin maps the test_account table without hiding password_hash.
+type TestAccountAdmin struct {
+	ID           int64  `po:"id,bigserial,primaryKey"`
+	PasswordHash string `po:"password_hash,text"`
+}
+
+func (TestAccountAdmin) TableName() string { return "test_account" }
+
+func TestSQLCacheModelsSharingTable(t *testing.T) {
+	statementCache = &sqlCache{limit: 4096}
+	db := New(nil)
+	sql, _, err := Select[TestAccount](db).ToSQL()
+	if err != nil || sql != "SELECT id, email, balance, name FROM test_account" {
+		t.Fatalf("got %q %v", sql, err)
+	}
+	sql, _, err = Select[TestAccountAdmin](db).ToSQL()
+	if err != nil || sql != "SELECT * FROM test_account" {
+		t.Errorf("second model got the first one's columns: %q %v", sql, err)
+	}
+}
+
+func TestSQLCacheLimit(t *testing.T) {
+	table, err := registry.GetOrRegister(TestUser{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	statementCache = &sqlCache{limit: 2}
+	defer func() { statementCache = &sqlCache{limit: 4096} }()
+
+	build := func(n int) {
+		t.Helper()
+		values := make([]interface{}, n)
+		if _, _, err := buildSelectSQL(selectSpec{table: table, where: []Condition{In("age", values...)}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A shape of n values is the only one whose IN list ends at $n.
+	cached := func(n int) bool {
+		for _, e := range statementCache.entries {
+			if strings.Contains(e.sql, fmt.Sprintf("$%d)", n)) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for i := 1; i <= 5; i++ {
+		build(i)
+	}
+	if n := len(statementCache.entries); n != 2 {
+		t.Errorf("expected the cache to hold 2 entries, got %d", n)
+	}
+	if !cached(5) {
+		t.Error("expected a new shape to be cached after the limit is reached")
+	}
+
+	// A shape read since the hand last passed it survives the next eviction.
+	statementCache = &sqlCache{limit: 2}
+	build(1)
+	build(2)
+	build(1)
+	build(3)
+	if !cached(1) || !cached(3) || cached(2) {
+		t.Errorf("expected shapes 1 and 3 to be cached and 2 evicted, got %d entries", len(statementCache.entries))
+	}
+}
+
+func TestSQLCacheAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("sync.Pool drops items at random under the race detector")
+	}
+	spec := benchmarkSpec(t)
+	if _, _, err := buildSelectSQL(spec); err != nil {
+		t.Fatal(err)
+	}
+	// Only the argument slice is allocated for a cached shape.
+	allocs := testing.AllocsPerRun(100, func() {
+		if _, _, err := buildSelectSQL(spec); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if allocs > 1 {
+		t.Errorf("expected at most 1 allocation for a cached shape, got %v", allocs)
+	}
+}
+
+func benchmarkSpec(b testing.TB) selectSpec {
+	table, err := registry.GetOrRegister(TestUser{})
+	if err != nil {
+		b.Fatal(err)
+	}
+	limit := 20
+	return selectSpec{
+		table:   table,
+		columns: []string{"id", "name", "email"},
+		where:   []Condition{Eq("name", "alice"), Gt("age", 18), In("id", "a", "b", "c"), Or(Group(IsNull("email"), Like("email", "%@example.com")))},
+		orderBy: []OrderBy{{Column: "name", Direction: Asc}},
+		limit:   &limit,
+	}
+}
+
+func BenchmarkSelectSQL(b *testing.B) {
+	spec := benchmarkSpec(b)
+	b.Run("generated", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, _, err := generateSelectSQL(spec); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("cached", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, _, err := buildSelectSQL(spec); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkSelectQueryToSQL(b *testing.B) {
+	db := &DB{}
+	b.ReportAllocs()
+	for i := 0; b.Loop(); i++ {
+		q := Select[TestUser](db).Where(Eq("name", "alice")).And(Gt("age", i)).OrderByAsc("name").Limit(20)
+		if _, _, err := q.ToSQL(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
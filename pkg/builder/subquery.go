@@ -211,12 +211,12 @@ func NewScalarSubquery(sql string, args ...interface{}) *ScalarSubquery {
 	}
 }
 
-// AsColumn returns the subquery as a column expression
-func (s *ScalarSubquery) AsColumn(alias string) string {
+// AsColumn returns the subquery as a column expression, for ColumnExpr
+func (s *ScalarSubquery) AsColumn(alias string) UnsafeExpr {
 	if alias != "" {
-		return fmt.Sprintf("(%s) AS %s", s.SQL, alias)
+		return UnsafeExpr(fmt.Sprintf("(%s) AS %s", s.SQL, alias))
 	}
-	return fmt.Sprintf("(%s)", s.SQL)
+	return UnsafeExpr(fmt.Sprintf("(%s)", s.SQL))
 }
 
 // Example usage:
@@ -242,5 +242,6 @@ func (s *ScalarSubquery) AsColumn(alias string) string {
 // Scalar subquery in SELECT:
 // orderCount := NewScalarSubquery("SELECT COUNT(*) FROM orders WHERE orders.user_id = users.id")
 // users := db.Select(User{}).
-//     Columns("id", "name", orderCount.AsColumn("order_count")).
+//     Columns("id", "name").
+//     ColumnExpr(orderCount.AsColumn("order_count")).
 //     All(ctx)
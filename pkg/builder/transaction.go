@@ -3,47 +3,78 @@ package builder
 import (
 	"context"
 	"fmt"
+	"maps"
+	"slices"
 
 	"github.com/jackc/pgx/v5"
-	"github.com/marshallshelly/pebble-orm/pkg/registry"
+	"github.com/marshallshelly/pebble-orm/pkg/cache"
 	"github.com/marshallshelly/pebble-orm/pkg/schema"
 )
 
 // Tx wraps a pgx transaction and provides query builder methods.
 type Tx struct {
-	tx  pgx.Tx
-	ctx context.Context
+	tx     pgx.Tx
+	ctx    context.Context
+	schema string  // default schema inherited from DB.WithSchema
+	config *Config // logging and exec mode inherited from DB.WithConfig
+	cache  cache.Cache
+	dirty  map[string]struct{} // cache tags of tables written, invalidated on Commit
+
+	readOnly bool          // begun by a read-only DB, see NewReadOnly
+	resolver TableResolver // inherited from DB.WithTableResolver
 }
 
 // Begin starts a new transaction.
 func (d *DB) Begin(ctx context.Context) (*Tx, error) {
-	tx, err := d.db.Begin(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to begin transaction: %w", err)
-	}
-	return &Tx{tx: tx, ctx: ctx}, nil
+	return d.BeginTx(ctx, pgx.TxOptions{})
 }
 
-// BeginTx starts a new transaction with custom options.
+// BeginTx starts a new transaction with custom options. A read-only DB's
+// transactions are always READ ONLY.
 func (d *DB) BeginTx(ctx context.Context, txOptions pgx.TxOptions) (*Tx, error) {
-	tx, err := d.db.BeginTx(ctx, txOptions)
+	if d.readOnly {
+		txOptions.AccessMode = pgx.ReadOnly
+	}
+	var b beginner = d.db
+	if d.db == nil {
+		var ok bool
+		if b, ok = d.executor.(beginner); !ok {
+			return nil, fmt.Errorf("failed to begin transaction: executor %T has no BeginTx method", d.executor)
+		}
+	}
+	tx, err := b.BeginTx(ctx, txOptions)
 	if err != nil {
 		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
-	return &Tx{tx: tx, ctx: ctx}, nil
+	return &Tx{tx: tx, ctx: ctx, schema: d.schema, config: d.config, cache: d.cache, readOnly: d.readOnly, resolver: d.resolver}, nil
 }
 
-// exec returns the transaction as a queryExecutor for the shared query core.
-func (t *Tx) exec() queryExecutor {
-	return txExecutor{t.tx}
+// beginner starts transactions; *runtime.DB is one.
+type beginner interface {
+	BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error)
 }
 
-// Commit commits the transaction.
+// WrapTx wraps a transaction begun elsewhere, such as the one passed to a Go
+// migration, so the query builders can run inside it. The caller owns the
+// transaction and is responsible for committing or rolling it back.
+func WrapTx(ctx context.Context, tx pgx.Tx) *Tx {
+	return &Tx{tx: tx, ctx: ctx}
+}
+
+// exec returns the transaction as an Executor for the shared query core.
+func (t *Tx) exec() Executor {
+	return withConfig(txExecutor{t.tx}, t.config)
+}
+
+// Commit commits the transaction, then invalidates the cached results of
+// the tables it wrote.
 func (t *Tx) Commit() error {
 	if err := t.tx.Commit(t.ctx); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+		return fmt.Errorf("failed to commit transaction: %w", driverErr(err))
 	}
-	return nil
+	tags := slices.Collect(maps.Keys(t.dirty))
+	t.dirty = nil
+	return invalidate(t.ctx, t.cache, tags...)
 }
 
 // Rollback rolls back the transaction.
@@ -86,18 +117,18 @@ func (t *Tx) ReleaseSavepoint(name string) error {
 func TxSelect[T any](t *Tx) *TxSelectQuery[T] {
 	var model T
 
-	table, err := registry.GetOrRegister(model)
+	table, err := t.modelTable(model)
 	if err != nil {
 		return &TxSelectQuery[T]{
 			tx:    t,
 			table: nil,
+			err:   err,
 		}
 	}
 
 	return &TxSelectQuery[T]{
 		tx:       t,
 		table:    table,
-		columns:  []string{"*"},
 		where:    make([]Condition, 0),
 		joins:    make([]Join, 0),
 		groupBy:  make([]string, 0),
@@ -114,18 +145,18 @@ func (t *Tx) Select(model interface{}) *TxSelectQuery[interface{}] {
 
 // SelectTyped creates a type-safe SELECT query within the transaction.
 func (t *Tx) SelectTyped(model interface{}) *TxSelectQuery[interface{}] {
-	table, err := registry.GetOrRegister(model)
+	table, err := t.modelTable(model)
 	if err != nil {
 		return &TxSelectQuery[interface{}]{
 			tx:    t,
 			table: nil,
+			err:   err,
 		}
 	}
 
 	return &TxSelectQuery[interface{}]{
 		tx:       t,
 		table:    table,
-		columns:  []string{"*"},
 		where:    make([]Condition, 0),
 		joins:    make([]Join, 0),
 		groupBy:  make([]string, 0),
@@ -140,11 +171,12 @@ func (t *Tx) SelectTyped(model interface{}) *TxSelectQuery[interface{}] {
 func TxInsert[T any](t *Tx) *TxInsertQuery[T] {
 	var model T
 
-	table, err := registry.GetOrRegister(model)
+	table, err := t.modelTable(model)
 	if err != nil {
 		return &TxInsertQuery[T]{
 			tx:    t,
 			table: nil,
+			err:   err,
 		}
 	}
 
@@ -163,11 +195,12 @@ func (t *Tx) Insert(model interface{}) *TxInsertQuery[interface{}] {
 
 // InsertTyped creates a type-safe INSERT query within the transaction.
 func (t *Tx) InsertTyped(model interface{}) *TxInsertQuery[interface{}] {
-	table, err := registry.GetOrRegister(model)
+	table, err := t.modelTable(model)
 	if err != nil {
 		return &TxInsertQuery[interface{}]{
 			tx:    t,
 			table: nil,
+			err:   err,
 		}
 	}
 
@@ -184,11 +217,12 @@ func (t *Tx) InsertTyped(model interface{}) *TxInsertQuery[interface{}] {
 func TxUpdate[T any](t *Tx) *TxUpdateQuery[T] {
 	var model T
 
-	table, err := registry.GetOrRegister(model)
+	table, err := t.modelTable(model)
 	if err != nil {
 		return &TxUpdateQuery[T]{
 			tx:    t,
 			table: nil,
+			err:   err,
 		}
 	}
 
@@ -208,11 +242,12 @@ func (t *Tx) Update(model interface{}) *TxUpdateQuery[interface{}] {
 
 // UpdateTyped creates a type-safe UPDATE query within the transaction.
 func (t *Tx) UpdateTyped(model interface{}) *TxUpdateQuery[interface{}] {
-	table, err := registry.GetOrRegister(model)
+	table, err := t.modelTable(model)
 	if err != nil {
 		return &TxUpdateQuery[interface{}]{
 			tx:    t,
 			table: nil,
+			err:   err,
 		}
 	}
 
@@ -230,11 +265,12 @@ func (t *Tx) UpdateTyped(model interface{}) *TxUpdateQuery[interface{}] {
 func TxDelete[T any](t *Tx) *TxDeleteQuery[T] {
 	var model T
 
-	table, err := registry.GetOrRegister(model)
+	table, err := t.modelTable(model)
 	if err != nil {
 		return &TxDeleteQuery[T]{
 			tx:    t,
 			table: nil,
+			err:   err,
 		}
 	}
 
@@ -253,11 +289,12 @@ func (t *Tx) Delete(model interface{}) *TxDeleteQuery[interface{}] {
 
 // DeleteTyped creates a type-safe DELETE query within the transaction.
 func (t *Tx) DeleteTyped(model interface{}) *TxDeleteQuery[interface{}] {
-	table, err := registry.GetOrRegister(model)
+	table, err := t.modelTable(model)
 	if err != nil {
 		return &TxDeleteQuery[interface{}]{
 			tx:    t,
 			table: nil,
+			err:   err,
 		}
 	}
 
@@ -274,6 +311,8 @@ type TxSelectQuery[T any] struct {
 	tx        *Tx
 	table     *schema.TableMetadata
 	columns   []string
+	colArgs   []interface{} // bound by SelectExpr columns
+	sample    string        // TABLESAMPLE clause, see TableSample
 	where     []Condition
 	joins     []Join
 	groupBy   []string
@@ -284,11 +323,62 @@ type TxSelectQuery[T any] struct {
 	distinct  bool
 	forUpdate bool
 	preloads  []string // Relationship fields to eagerly load
+	unscoped  bool     // skip the model's default scope, see Unscoped
+	err       error    // first invalid argument, returned by ToSQL
 }
 
-// Columns specifies which columns to select.
+// Columns specifies which columns to select. Each must be a column
+// reference, optionally with an alias; see ColumnExpr for other expressions.
 func (q *TxSelectQuery[T]) Columns(cols ...string) *TxSelectQuery[T] {
-	q.columns = cols
+	q.columns = quoteAll(cols, quoteSelectItem, &q.err)
+	q.colArgs = nil
+	return q
+}
+
+// Fields selects the columns tagged with any of the projection groups
+// (`po:"name,text,group(summary)"`); the other fields of T are left zero.
+// Usage: query.Fields("summary")
+func (q *TxSelectQuery[T]) Fields(groups ...string) *TxSelectQuery[T] {
+	columns, err := groupColumns(q.table, groups)
+	keepErr(&q.err, err)
+	q.columns = columns
+	q.colArgs = nil
+	return q
+}
+
+// SelectExpr adds a computed column to the select list, named alias so it
+// scans into the field of T with that column name.
+// Usage: query.Columns("id").SelectExpr(builder.Mul(builder.Column("price"), builder.Column("quantity")), "total")
+func (q *TxSelectQuery[T]) SelectExpr(expr Expr, alias string) *TxSelectQuery[T] {
+	sql, args, err := renderExpr(expr, len(q.colArgs)+1)
+	if err != nil {
+		keepErr(&q.err, err)
+		return q
+	}
+	quoted, err := quoteIdent(alias)
+	if err != nil {
+		keepErr(&q.err, err)
+		return q
+	}
+	q.columns = append(q.columns, sql+" AS "+quoted)
+	q.colArgs = append(q.colArgs, args...)
+	return q
+}
+
+// ColumnExpr adds expressions to the select list as written.
+// Usage: query.Columns("id").ColumnExpr("EXTRACT(YEAR FROM created_at) AS year")
+func (q *TxSelectQuery[T]) ColumnExpr(exprs ...UnsafeExpr) *TxSelectQuery[T] {
+	for _, expr := range exprs {
+		q.columns = append(q.columns, string(expr))
+	}
+	return q
+}
+
+// Unscoped drops the model's default scope (// default_scope: ...) from
+// the query, to reach rows it filters out such as soft-deleted ones.
+// Usage: builder.TxSelect[T](tx).Unscoped().Where(...)
+func (q *TxSelectQuery[T]) Unscoped() *TxSelectQuery[T] {
+	q.unscoped = true
 	return q
 }
 
@@ -312,11 +402,39 @@ func (q *TxSelectQuery[T]) Or(condition Condition) *TxSelectQuery[T] {
 
 // OrderBy adds an ORDER BY clause.
 func (q *TxSelectQuery[T]) OrderBy(column string, direction OrderDirection) *TxSelectQuery[T] {
-	q.orderBy = append(q.orderBy, OrderBy{
-		Column:    column,
-		Direction: direction,
-		NullsPos:  NullsDefault,
-	})
+	return q.OrderByNulls(column, direction, NullsDefault)
+}
+
+// OrderByNulls adds an ORDER BY clause that puts NULLs first or last.
+// Usage: query.OrderByNulls("last_login_at", builder.Desc, builder.NullsLast)
+func (q *TxSelectQuery[T]) OrderByNulls(column string, direction OrderDirection, nulls NullsPosition) *TxSelectQuery[T] {
+	quoted, err := quoteColumn(column)
+	if err != nil {
+		keepErr(&q.err, err)
+		return q
+	}
+	return q.orderByTerm(quoted, direction, nulls)
+}
+
+// OrderByExpr adds an ORDER BY clause on an expression, written as is.
+// Usage: query.OrderByExpr("similarity(name, 'pebble')", builder.Desc)
+func (q *TxSelectQuery[T]) OrderByExpr(expr UnsafeExpr, direction OrderDirection) *TxSelectQuery[T] {
+	return q.orderByTerm(string(expr), direction, NullsDefault)
+}
+
+// OrderByRandom orders the rows randomly. It reads and sorts every matching
+// row, so keep it to small tables or filtered sets.
+func (q *TxSelectQuery[T]) OrderByRandom() *TxSelectQuery[T] {
+	return q.orderByTerm("random()", Asc, NullsDefault)
+}
+
+func (q *TxSelectQuery[T]) orderByTerm(sql string, direction OrderDirection, nulls NullsPosition) *TxSelectQuery[T] {
+	order, err := newOrderBy(sql, direction, nulls)
+	if err != nil {
+		keepErr(&q.err, err)
+		return q
+	}
+	q.orderBy = append(q.orderBy, order)
 	return q
 }
 
@@ -338,6 +456,18 @@ func (q *TxSelectQuery[T]) Distinct() *TxSelectQuery[T] {
 	return q
 }
 
+// TableSample reads a random sample of the table instead of all of it.
+// Usage: query.TableSample(builder.System(0.5).Repeatable(42))
+func (q *TxSelectQuery[T]) TableSample(sample Sample) *TxSelectQuery[T] {
+	clause, err := sample.clause()
+	if err != nil {
+		keepErr(&q.err, err)
+		return q
+	}
+	q.sample = clause
+	return q
+}
+
 // ForUpdate adds FOR UPDATE lock.
 func (q *TxSelectQuery[T]) ForUpdate() *TxSelectQuery[T] {
 	q.forUpdate = true
@@ -353,52 +483,36 @@ func (q *TxSelectQuery[T]) Preload(relationships ...string) *TxSelectQuery[T] {
 }
 
 // InnerJoin adds an INNER JOIN.
-func (q *TxSelectQuery[T]) InnerJoin(table string, condition string, args ...interface{}) *TxSelectQuery[T] {
-	q.joins = append(q.joins, Join{
-		Type:      InnerJoin,
-		Table:     table,
-		Condition: condition,
-		Args:      args,
-	})
-	return q
+func (q *TxSelectQuery[T]) InnerJoin(table string, condition UnsafeExpr, args ...interface{}) *TxSelectQuery[T] {
+	return q.join(InnerJoin, table, condition, args)
 }
 
 // LeftJoin adds a LEFT JOIN.
-func (q *TxSelectQuery[T]) LeftJoin(table string, condition string, args ...interface{}) *TxSelectQuery[T] {
-	q.joins = append(q.joins, Join{
-		Type:      LeftJoin,
-		Table:     table,
-		Condition: condition,
-		Args:      args,
-	})
-	return q
+func (q *TxSelectQuery[T]) LeftJoin(table string, condition UnsafeExpr, args ...interface{}) *TxSelectQuery[T] {
+	return q.join(LeftJoin, table, condition, args)
 }
 
 // RightJoin adds a RIGHT JOIN.
-func (q *TxSelectQuery[T]) RightJoin(table string, condition string, args ...interface{}) *TxSelectQuery[T] {
-	q.joins = append(q.joins, Join{
-		Type:      RightJoin,
-		Table:     table,
-		Condition: condition,
-		Args:      args,
-	})
-	return q
+func (q *TxSelectQuery[T]) RightJoin(table string, condition UnsafeExpr, args ...interface{}) *TxSelectQuery[T] {
+	return q.join(RightJoin, table, condition, args)
 }
 
 // FullJoin adds a FULL OUTER JOIN.
-func (q *TxSelectQuery[T]) FullJoin(table string, condition string, args ...interface{}) *TxSelectQuery[T] {
-	q.joins = append(q.joins, Join{
-		Type:      FullJoin,
-		Table:     table,
-		Condition: condition,
-		Args:      args,
-	})
-	return q
+func (q *TxSelectQuery[T]) FullJoin(table string, condition UnsafeExpr, args ...interface{}) *TxSelectQuery[T] {
+	return q.join(FullJoin, table, condition, args)
 }
 
 // GroupBy adds a GROUP BY clause.
 func (q *TxSelectQuery[T]) GroupBy(columns ...string) *TxSelectQuery[T] {
-	q.groupBy = append(q.groupBy, columns...)
+	q.groupBy = append(q.groupBy, quoteAll(columns, quoteColumn, &q.err)...)
+	return q
+}
+
+// GroupByExpr adds GROUP BY expressions as written.
+func (q *TxSelectQuery[T]) GroupByExpr(exprs ...UnsafeExpr) *TxSelectQuery[T] {
+	for _, expr := range exprs {
+		q.groupBy = append(q.groupBy, string(expr))
+	}
 	return q
 }
 
@@ -408,13 +522,36 @@ func (q *TxSelectQuery[T]) Having(condition Condition) *TxSelectQuery[T] {
 	return q
 }
 
+// join adds a join on a table name, which may carry an alias.
+func (q *TxSelectQuery[T]) join(typ JoinType, table string, condition UnsafeExpr, args []interface{}) *TxSelectQuery[T] {
+	quoted, err := quoteTable(table)
+	if err != nil {
+		keepErr(&q.err, err)
+		return q
+	}
+	q.joins = append(q.joins, Join{
+		Type:      typ,
+		Table:     quoted,
+		Condition: string(condition),
+		Args:      args,
+	})
+	return q
+}
+
 // ToSQL generates the SQL query and arguments.
 func (q *TxSelectQuery[T]) ToSQL() (string, []interface{}, error) {
-	return buildSelectSQL(selectSpec{
-		table: q.table, distinct: q.distinct, columns: q.columns, joins: q.joins,
-		where: q.where, groupBy: q.groupBy, having: q.having, orderBy: q.orderBy,
+	if q.err != nil {
+		return "", nil, q.err
+	}
+	return buildSelectSQL(q.spec())
+}
+
+func (q *TxSelectQuery[T]) spec() selectSpec {
+	return selectSpec{
+		table: q.table, distinct: q.distinct, columns: q.columns, colArgs: q.colArgs, sample: q.sample, joins: q.joins,
+		where: scoped(q.table, q.where, q.unscoped), groupBy: q.groupBy, having: q.having, orderBy: q.orderBy,
 		limit: q.limit, offset: q.offset, forUpdate: q.forUpdate,
-	})
+	}
 }
 
 // All executes the query and returns all results.
@@ -423,7 +560,7 @@ func (q *TxSelectQuery[T]) All() ([]T, error) {
 	if err != nil {
 		return nil, err
 	}
-	return queryRows[T](q.tx.ctx, q.tx.exec(), q.table, sql, args, q.preloads)
+	return queryRows[T](q.tx.ctx, q.tx.exec(), q.table, sql, args, q.preloads, q.tx.relatedTables())
 }
 
 // First executes the query and returns the first result.
@@ -443,7 +580,10 @@ func (q *TxSelectQuery[T]) First() (T, error) {
 
 // Count executes a COUNT query.
 func (q *TxSelectQuery[T]) Count() (int64, error) {
-	sql, args, err := buildCountSQL(q.table, q.where)
+	if q.err != nil {
+		return 0, q.err
+	}
+	sql, args, err := buildPageCountSQL(selectSpec{table: q.table, sample: q.sample, where: scoped(q.table, q.where, q.unscoped)})
 	if err != nil {
 		return 0, err
 	}
@@ -461,11 +601,13 @@ func (q *TxSelectQuery[T]) Exists() (bool, error) {
 
 // TxInsertQuery represents an INSERT query within a transaction.
 type TxInsertQuery[T any] struct {
-	tx         *Tx
-	table      *schema.TableMetadata
-	values     []interface{}
-	returning  []string
-	onConflict *OnConflict
+	tx          *Tx
+	table       *schema.TableMetadata
+	values      []interface{}
+	returning   []string
+	onConflict  *OnConflict
+	useDefaults map[string]bool
+	err         error
 }
 
 // Values adds values to insert.
@@ -474,25 +616,36 @@ func (q *TxInsertQuery[T]) Values(values ...interface{}) *TxInsertQuery[T] {
 	return q
 }
 
+// UseDefaults leaves the named columns to their database defaults when their
+// fields are zero.
+func (q *TxInsertQuery[T]) UseDefaults(columns ...string) *TxInsertQuery[T] {
+	q.useDefaults = addUseDefaults(q.useDefaults, q.table, columns, &q.err)
+	return q
+}
+
 // Returning specifies columns to return.
 func (q *TxInsertQuery[T]) Returning(columns ...string) *TxInsertQuery[T] {
-	q.returning = append(q.returning, columns...)
+	q.returning = append(q.returning, quoteAll(columns, quoteSelectItem, &q.err)...)
 	return q
 }
 
 // OnConflictDoNothing adds ON CONFLICT DO NOTHING clause.
 func (q *TxInsertQuery[T]) OnConflictDoNothing(columns ...string) *TxInsertQuery[T] {
 	q.onConflict = &OnConflict{
-		Columns: columns,
+		Columns: quoteAll(columns, quoteColumn, &q.err),
 		Action:  DoNothing,
 	}
 	return q
 }
 
-// OnConflictDoUpdate adds ON CONFLICT DO UPDATE clause.
+// OnConflictDoUpdate adds ON CONFLICT DO UPDATE clause. With no updates,
+// every inserted column but columns is set to the row's new value.
 func (q *TxInsertQuery[T]) OnConflictDoUpdate(columns []string, updates map[string]interface{}) *TxInsertQuery[T] {
+	for col := range updates {
+		keepErr(&q.err, checkColumnName(col))
+	}
 	q.onConflict = &OnConflict{
-		Columns: columns,
+		Columns: quoteAll(columns, quoteColumn, &q.err),
 		Action:  DoUpdate,
 		Updates: updates,
 	}
@@ -501,11 +654,15 @@ func (q *TxInsertQuery[T]) OnConflictDoUpdate(columns []string, updates map[stri
 
 // ToSQL generates the INSERT SQL and arguments.
 func (q *TxInsertQuery[T]) ToSQL() (string, []interface{}, error) {
+	if q.err != nil {
+		return "", nil, q.err
+	}
 	return buildInsertSQL(insertSpec{
-		table:      q.table,
-		rows:       q.values,
-		returning:  q.returning,
-		onConflict: q.onConflict,
+		table:       q.table,
+		rows:        q.values,
+		returning:   q.returning,
+		onConflict:  q.onConflict,
+		useDefaults: q.useDefaults,
 	})
 }
 
@@ -515,7 +672,8 @@ func (q *TxInsertQuery[T]) Exec() (int64, error) {
 	if err != nil {
 		return 0, err
 	}
-	return execWrite(q.tx.ctx, q.tx.exec(), sql, args, len(q.returning) > 0)
+	q.tx.markDirty(q.table)
+	return execWrite(q.tx.ctx, q.tx.writeExec(), sql, args, len(q.returning) > 0)
 }
 
 // ExecReturning executes the INSERT and scans the RETURNING values.
@@ -527,7 +685,8 @@ func (q *TxInsertQuery[T]) ExecReturning() ([]T, error) {
 	if err != nil {
 		return nil, err
 	}
-	return queryRows[T](q.tx.ctx, q.tx.exec(), q.table, sql, args, nil)
+	q.tx.markDirty(q.table)
+	return queryRows[T](q.tx.ctx, q.tx.writeExec(), q.table, sql, args, nil, q.tx.relatedTables())
 }
 
 // TxUpdateQuery represents an UPDATE query within a transaction.
@@ -537,10 +696,13 @@ type TxUpdateQuery[T any] struct {
 	sets      map[string]interface{}
 	where     []Condition
 	returning []string
+	unscoped  bool
+	err       error
 }
 
 // Set sets a single column value.
 func (q *TxUpdateQuery[T]) Set(column string, value interface{}) *TxUpdateQuery[T] {
+	keepErr(&q.err, checkColumnName(column))
 	q.sets[column] = value
 	return q
 }
@@ -548,11 +710,20 @@ func (q *TxUpdateQuery[T]) Set(column string, value interface{}) *TxUpdateQuery[
 // SetMap sets multiple column values from a map.
 func (q *TxUpdateQuery[T]) SetMap(values map[string]interface{}) *TxUpdateQuery[T] {
 	for k, v := range values {
+		keepErr(&q.err, checkColumnName(k))
 		q.sets[k] = v
 	}
 	return q
 }
 
+// Unscoped drops the model's default scope (// default_scope: ...) from
+// the query, to reach rows it filters out such as soft-deleted ones.
+// Usage: builder.TxUpdate[T](tx).Unscoped().Where(...)
+func (q *TxUpdateQuery[T]) Unscoped() *TxUpdateQuery[T] {
+	q.unscoped = true
+	return q
+}
+
 // Where adds a WHERE condition.
 func (q *TxUpdateQuery[T]) Where(condition Condition) *TxUpdateQuery[T] {
 	q.where = append(q.where, condition)
@@ -573,16 +744,19 @@ func (q *TxUpdateQuery[T]) Or(condition Condition) *TxUpdateQuery[T] {
 
 // Returning specifies columns to return.
 func (q *TxUpdateQuery[T]) Returning(columns ...string) *TxUpdateQuery[T] {
-	q.returning = append(q.returning, columns...)
+	q.returning = append(q.returning, quoteAll(columns, quoteSelectItem, &q.err)...)
 	return q
 }
 
 // ToSQL generates the UPDATE SQL and arguments.
 func (q *TxUpdateQuery[T]) ToSQL() (string, []interface{}, error) {
+	if q.err != nil {
+		return "", nil, q.err
+	}
 	return buildUpdateSQL(updateSpec{
 		table:     q.table,
 		sets:      q.sets,
-		where:     q.where,
+		where:     scoped(q.table, q.where, q.unscoped),
 		returning: q.returning,
 	})
 }
@@ -593,7 +767,8 @@ func (q *TxUpdateQuery[T]) Exec() (int64, error) {
 	if err != nil {
 		return 0, err
 	}
-	return execWrite(q.tx.ctx, q.tx.exec(), sql, args, len(q.returning) > 0)
+	q.tx.markDirty(q.table)
+	return execWrite(q.tx.ctx, q.tx.writeExec(), sql, args, len(q.returning) > 0)
 }
 
 // ExecReturning executes the UPDATE and scans the RETURNING values.
@@ -605,7 +780,8 @@ func (q *TxUpdateQuery[T]) ExecReturning() ([]T, error) {
 	if err != nil {
 		return nil, err
 	}
-	return queryRows[T](q.tx.ctx, q.tx.exec(), q.table, sql, args, nil)
+	q.tx.markDirty(q.table)
+	return queryRows[T](q.tx.ctx, q.tx.writeExec(), q.table, sql, args, nil, q.tx.relatedTables())
 }
 
 // TxDeleteQuery represents a DELETE query within a transaction.
@@ -614,6 +790,16 @@ type TxDeleteQuery[T any] struct {
 	table     *schema.TableMetadata
 	where     []Condition
 	returning []string
+	unscoped  bool
+	err       error
+}
+
+// Unscoped drops the model's default scope (// default_scope: ...) from
+// the query, to reach rows it filters out such as soft-deleted ones.
+// Usage: builder.TxDelete[T](tx).Unscoped().Where(...)
+func (q *TxDeleteQuery[T]) Unscoped() *TxDeleteQuery[T] {
+	q.unscoped = true
+	return q
 }
 
 // Where adds a WHERE condition.
@@ -636,15 +822,18 @@ func (q *TxDeleteQuery[T]) Or(condition Condition) *TxDeleteQuery[T] {
 
 // Returning specifies columns to return.
 func (q *TxDeleteQuery[T]) Returning(columns ...string) *TxDeleteQuery[T] {
-	q.returning = append(q.returning, columns...)
+	q.returning = append(q.returning, quoteAll(columns, quoteSelectItem, &q.err)...)
 	return q
 }
 
 // ToSQL generates the DELETE SQL and arguments.
 func (q *TxDeleteQuery[T]) ToSQL() (string, []interface{}, error) {
+	if q.err != nil {
+		return "", nil, q.err
+	}
 	return buildDeleteSQL(deleteSpec{
 		table:     q.table,
-		where:     q.where,
+		where:     scoped(q.table, q.where, q.unscoped),
 		returning: q.returning,
 	})
 }
@@ -655,7 +844,8 @@ func (q *TxDeleteQuery[T]) Exec() (int64, error) {
 	if err != nil {
 		return 0, err
 	}
-	return execWrite(q.tx.ctx, q.tx.exec(), sql, args, len(q.returning) > 0)
+	q.tx.markDirty(q.table)
+	return execWrite(q.tx.ctx, q.tx.writeExec(), sql, args, len(q.returning) > 0)
 }
 
 // ExecReturning executes the DELETE and scans the RETURNING values.
@@ -667,5 +857,6 @@ func (q *TxDeleteQuery[T]) ExecReturning() ([]T, error) {
 	if err != nil {
 		return nil, err
 	}
-	return queryRows[T](q.tx.ctx, q.tx.exec(), q.table, sql, args, nil)
+	q.tx.markDirty(q.table)
+	return queryRows[T](q.tx.ctx, q.tx.writeExec(), q.table, sql, args, nil, q.tx.relatedTables())
 }
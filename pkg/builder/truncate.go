@@ -0,0 +1,115 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/marshallshelly/pebble-orm/pkg/registry"
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+)
+
+// ErrTruncateNotConfirmed is returned by TruncateQuery.Exec unless Confirm
+// named the table being truncated.
+var ErrTruncateNotConfirmed = errors.New("truncate not confirmed")
+
+// TruncateQuery represents a TRUNCATE statement, which empties a table at
+// once without scanning it.
+type TruncateQuery[T any] struct {
+	db              *DB
+	table           *schema.TableMetadata
+	restartIdentity bool
+	cascade         bool
+	confirmed       string
+	deadline        time.Time
+	err             error
+}
+
+// Truncate creates a TRUNCATE of T's table, for test cleanup and retention
+// jobs. It deletes every row and can't be rolled back once committed, so Exec
+// refuses to run until Confirm names the table.
+// Usage: builder.Truncate[Event](db).RestartIdentity().Confirm("events").Exec(ctx)
+func Truncate[T any](d *DB) *TruncateQuery[T] {
+	var model T
+	q := &TruncateQuery[T]{db: d}
+	table, err := registry.GetOrRegister(model)
+	if err != nil {
+		q.err = err
+		return q
+	}
+	q.table = inSchema(table, d.schema)
+	return q
+}
+
+// RestartIdentity resets the sequences of the table's serial and identity
+// columns.
+func (q *TruncateQuery[T]) RestartIdentity() *TruncateQuery[T] {
+	q.restartIdentity = true
+	return q
+}
+
+// Cascade also truncates every table with a foreign key to this one, and the
+// tables referencing those. Without it, TRUNCATE fails when such tables
+// exist. Cached results are invalidated for this table only.
+func (q *TruncateQuery[T]) Cascade() *TruncateQuery[T] {
+	q.cascade = true
+	return q
+}
+
+// Confirm acknowledges that every row is deleted. table must be the name of
+// the table being truncated, as in its model or schema-qualified.
+func (q *TruncateQuery[T]) Confirm(table string) *TruncateQuery[T] {
+	q.confirmed = table
+	return q
+}
+
+// ToSQL generates the TRUNCATE statement. It has no arguments.
+func (q *TruncateQuery[T]) ToSQL() (string, []interface{}, error) {
+	if q.err != nil {
+		return "", nil, q.err
+	}
+	if q.table == nil {
+		return "", nil, fmt.Errorf("table metadata not available")
+	}
+	if err := checkWritable(q.table); err != nil {
+		return "", nil, err
+	}
+	var sql strings.Builder
+	sql.WriteString("TRUNCATE TABLE ")
+	sql.WriteString(schema.QuoteQualifiedIdent(q.table.QualifiedName()))
+	if q.restartIdentity {
+		sql.WriteString(" RESTART IDENTITY")
+	}
+	if q.cascade {
+		sql.WriteString(" CASCADE")
+	}
+	return sql.String(), nil, nil
+}
+
+// Exec executes the TRUNCATE. It returns ErrTruncateNotConfirmed without
+// running anything unless Confirm named the table.
+func (q *TruncateQuery[T]) Exec(ctx context.Context) error {
+	if _, _, err := q.ToSQL(); err != nil {
+		return err
+	}
+	// Confirm names the model's table, whichever table it resolves to.
+	if q.confirmed != q.table.Name && q.confirmed != q.table.QualifiedName() {
+		return fmt.Errorf("%w: call Confirm(%q) to delete every row of %s",
+			ErrTruncateNotConfirmed, q.table.Name, q.table.QualifiedName())
+	}
+	q, err := q.resolve(ctx)
+	if err != nil {
+		return err
+	}
+	sql, _, err := q.ToSQL()
+	if err != nil {
+		return err
+	}
+	qctx, cancel := withDeadline(ctx, q.deadline)
+	defer cancel()
+	_, err = q.db.writeExec().Exec(qctx, sql)
+	_, err = afterWrite(ctx, q.db, q.table, struct{}{}, err)
+	return err
+}
@@ -0,0 +1,37 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestTruncate(t *testing.T) {
+	db := New(nil)
+	tests := []struct {
+		name  string
+		query *TruncateQuery[TestUser]
+		want  string
+	}{
+		{"plain", Truncate[TestUser](db), "TRUNCATE TABLE test_user"},
+		{"restart identity", Truncate[TestUser](db).RestartIdentity(), "TRUNCATE TABLE test_user RESTART IDENTITY"},
+		{"cascade", Truncate[TestUser](db).RestartIdentity().Cascade(), "TRUNCATE TABLE test_user RESTART IDENTITY CASCADE"},
+		{"schema", Truncate[TestUser](db.WithSchema("tenant_a")), "TRUNCATE TABLE tenant_a.test_user"},
+	}
+	for _, tt := range tests {
+		sql, _, err := tt.query.ToSQL()
+		if err != nil || sql != tt.want {
+			t.Errorf("%s: ToSQL() = %q, %v; want %q", tt.name, sql, err, tt.want)
+		}
+	}
+}
+
+func TestTruncateNeedsConfirmation(t *testing.T) {
+	db := New(nil)
+	for _, confirm := range []string{"", "users", "other.test_user"} {
+		err := Truncate[TestUser](db).Confirm(confirm).Exec(context.Background())
+		if !errors.Is(err, ErrTruncateNotConfirmed) {
+			t.Errorf("Confirm(%q): err = %v, want ErrTruncateNotConfirmed", confirm, err)
+		}
+	}
+}
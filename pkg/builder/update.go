@@ -6,6 +6,7 @@ import (
 
 // Set sets a column value for the UPDATE.
 func (q *UpdateQuery[T]) Set(column string, value interface{}) *UpdateQuery[T] {
+	keepErr(&q.err, checkColumnName(column))
 	q.sets[column] = value
 	return q
 }
@@ -13,11 +14,20 @@ func (q *UpdateQuery[T]) Set(column string, value interface{}) *UpdateQuery[T] {
 // SetMap sets multiple column values from a map.
 func (q *UpdateQuery[T]) SetMap(values map[string]interface{}) *UpdateQuery[T] {
 	for col, val := range values {
+		keepErr(&q.err, checkColumnName(col))
 		q.sets[col] = val
 	}
 	return q
 }
 
+// Unscoped drops the model's default scope (// default_scope: ...) from
+// the query, to reach rows it filters out such as soft-deleted ones.
+// Usage: builder.Update[T](qb).Unscoped().Where(...)
+func (q *UpdateQuery[T]) Unscoped() *UpdateQuery[T] {
+	q.unscoped = true
+	return q
+}
+
 // Where adds a WHERE condition.
 func (q *UpdateQuery[T]) Where(condition Condition) *UpdateQuery[T] {
 	q.where = append(q.where, condition)
@@ -36,33 +46,68 @@ func (q *UpdateQuery[T]) Or(condition Condition) *UpdateQuery[T] {
 	return q.Where(condition)
 }
 
+// RefreshModel has Exec write the updated row back into model (RETURNING
+// its readable columns), so it reflects triggers, defaults and the SET
+// values; writeOnly fields keep their values. When several rows match,
+// model gets the first.
+// Usage: builder.Update[User](qb).Set("name", "Ann").Where(builder.Eq("id", u.ID)).RefreshModel(&u).Exec(ctx)
+func (q *UpdateQuery[T]) RefreshModel(model *T) *UpdateQuery[T] {
+	q.refresh = model
+	return q
+}
+
 // Returning specifies columns to return after update.
 func (q *UpdateQuery[T]) Returning(columns ...string) *UpdateQuery[T] {
-	q.returning = columns
+	q.returning = quoteAll(columns, quoteSelectItem, &q.err)
 	return q
 }
 
 // ToSQL generates the UPDATE SQL and arguments.
 func (q *UpdateQuery[T]) ToSQL() (string, []interface{}, error) {
+	if q.err != nil {
+		return "", nil, q.err
+	}
 	return buildUpdateSQL(updateSpec{
 		table:     q.table,
 		sets:      q.sets,
-		where:     q.where,
+		where:     scoped(q.table, q.where, q.unscoped),
 		returning: q.returning,
 	})
 }
 
 // Exec executes the UPDATE query and returns the number of affected rows.
 func (q *UpdateQuery[T]) Exec(ctx context.Context) (int64, error) {
+	q, err := q.resolve(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if q.refresh != nil {
+		q.Returning("*")
+		rows, err := q.ExecReturning(ctx)
+		if err != nil {
+			return 0, err
+		}
+		if len(rows) > 0 {
+			refreshModel(q.table, q.refresh, rows[0])
+		}
+		return int64(len(rows)), nil
+	}
 	sql, args, err := q.ToSQL()
 	if err != nil {
 		return 0, err
 	}
-	return execWrite(ctx, q.db.db, sql, args, len(q.returning) > 0)
+	qctx, cancel := withDeadline(ctx, q.deadline)
+	defer cancel()
+	n, err := execWrite(qctx, q.db.writeExec(), sql, args, len(q.returning) > 0)
+	return afterWrite(ctx, q.db, q.table, n, err)
 }
 
 // ExecReturning executes the UPDATE and returns the updated rows.
 func (q *UpdateQuery[T]) ExecReturning(ctx context.Context) ([]T, error) {
+	q, err := q.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
 	if len(q.returning) == 0 {
 		q.Returning("*")
 	}
@@ -70,5 +115,30 @@ func (q *UpdateQuery[T]) ExecReturning(ctx context.Context) ([]T, error) {
 	if err != nil {
 		return nil, err
 	}
-	return queryRows[T](ctx, q.db.db, q.table, sql, args, nil)
+	qctx, cancel := withDeadline(ctx, q.deadline)
+	defer cancel()
+	rows, err := queryRows[T](qctx, q.db.writeExec(), q.table, sql, args, nil, q.db.relatedTables())
+	return afterWrite(ctx, q.db, q.table, rows, err)
+}
+
+// ExecReturningInto executes the UPDATE and scans the returned rows into
+// dest, a pointer to a slice of structs with some of T's fields or, with one
+// Returning column, of single values. It returns the number of rows.
+// Usage: var ids []int64; n, err := builder.Update[User](qb).Set("active", false).Where(...).Returning("id").ExecReturningInto(ctx, &ids)
+func (q *UpdateQuery[T]) ExecReturningInto(ctx context.Context, dest interface{}) (int64, error) {
+	q, err := q.resolve(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if len(q.returning) == 0 {
+		q.Returning("*")
+	}
+	sql, args, err := q.ToSQL()
+	if err != nil {
+		return 0, err
+	}
+	qctx, cancel := withDeadline(ctx, q.deadline)
+	defer cancel()
+	n, err := scanReturning(qctx, q.db.writeExec(), q.table, sql, args, dest)
+	return afterWrite(ctx, q.db, q.table, n, err)
 }
@@ -1,6 +1,7 @@
 package builder
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/marshallshelly/pebble-orm/pkg/registry"
@@ -191,3 +192,92 @@ func TestUpdateQuery_Methods(t *testing.T) {
 		}
 	})
 }
+
+func TestUpdateQuery_DeterministicSet(t *testing.T) {
+	if err := registry.Register(TestUser{}); err != nil {
+		t.Fatalf("Failed to register model: %v", err)
+	}
+	db := New(nil)
+
+	for range 20 {
+		sql, args, err := Update[TestUser](db).
+			SetMap(map[string]interface{}{"name": "n", "email": "e", "age": 3}).
+			Where(Eq("id", "1")).
+			ToSQL()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "UPDATE test_user SET age = $1, email = $2, name = $3 WHERE id = $4"; sql != want {
+			t.Fatalf("ToSQL() sql = %v, want %v", sql, want)
+		}
+		if args[0] != 3 || args[1] != "e" || args[2] != "n" {
+			t.Fatalf("ToSQL() args = %v, want them in column order", args)
+		}
+	}
+}
+
+type TestAccount struct {
+	ID           int64  `po:"id,bigserial,primaryKey"`
+	Email        string `po:"email,text,notNull,immutable"`
+	PasswordHash string `po:"password_hash,text,writeOnly"`
+	Balance      int    `po:"balance,integer,readOnly"`
+	Name         string `po:"name,text"`
+}
+
+func TestColumnPermissions(t *testing.T) {
+	if err := registry.Register(TestAccount{}); err != nil {
+		t.Fatalf("Failed to register model: %v", err)
+	}
+	db := New(nil)
+	account := TestAccount{Email: "a@b.c", PasswordHash: "x", Balance: 10, Name: "Ann"}
+
+	sql, _, err := Insert[TestAccount](db).Values(account).ToSQL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "INSERT INTO test_account (email, password_hash, name) VALUES ($1, $2, $3)"; sql != want {
+		t.Errorf("insert SQL = %s, want %s", sql, want)
+	}
+
+	sql, _, err = Select[TestAccount](db).Where(Eq("id", 1)).ToSQL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "SELECT id, email, balance, name FROM test_account WHERE id = $1"; sql != want {
+		t.Errorf("select SQL = %s, want %s", sql, want)
+	}
+	if sql, _, _ = Select[TestAccount](db).Columns("id", "password_hash").ToSQL(); sql != "SELECT id, password_hash FROM test_account" {
+		t.Errorf("named writeOnly column not selected: %s", sql)
+	}
+
+	for column, want := range map[string]string{
+		"email":   "column email is immutable and cannot be updated",
+		"balance": "column balance is readOnly and cannot be updated",
+	} {
+		if _, _, err := Update[TestAccount](db).Set(column, "v").ToSQL(); err == nil || err.Error() != want {
+			t.Errorf("Set(%q): got %v, want %q", column, err, want)
+		}
+		if _, _, err := Insert[TestAccount](db).Values(account).OnConflictDoUpdate([]string{"id"}, map[string]interface{}{column: "v"}).ToSQL(); err == nil {
+			t.Errorf("OnConflictDoUpdate(%q): expected an error", column)
+		}
+	}
+	if _, _, err := Update[TestAccount](db).Set("password_hash", "y").ToSQL(); err != nil {
+		t.Errorf("writeOnly column should be updatable: %v", err)
+	}
+
+	sql, _, err = BulkUpdate[TestAccount](db).Rows([]TestAccount{{ID: 1, Name: "Ann"}}).ToSQL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(sql, "UPDATE test_account AS t SET password_hash = v.password_hash, name = v.name FROM") {
+		t.Errorf("bulk update SQL = %s", sql)
+	}
+
+	// Immutable columns can match rows, just not be written
+	if _, _, err := BulkUpdate[TestAccount](db).Rows([]TestAccount{{Email: "a@b.c", Name: "Ann"}}).Key("email").Columns("name").ToSQL(); err != nil {
+		t.Errorf("immutable key column: %v", err)
+	}
+	if _, _, err := BulkUpdate[TestAccount](db).Rows([]TestAccount{{ID: 1}}).Columns("email").ToSQL(); err == nil {
+		t.Error("expected an error writing an immutable column")
+	}
+}
@@ -0,0 +1,175 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/marshallshelly/pebble-orm/pkg/registry"
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+)
+
+// DefaultUpsertChunkSize is the number of rows UpsertMany sends per INSERT.
+const DefaultUpsertChunkSize = 1000
+
+// UpsertOptions tunes UpsertMany.
+type UpsertOptions struct {
+	// ChunkSize is the most rows per INSERT. Zero means
+	// DefaultUpsertChunkSize; either way chunks are kept under PostgreSQL's
+	// limit of 65535 parameters.
+	ChunkSize int
+
+	// ConflictColumns is the ON CONFLICT target. Empty means the model's
+	// primary key when it is set by the application, else its only unique
+	// column, constraint or index. An auto-increment primary key named here
+	// is inserted from rows that set it and as DEFAULT from those that don't.
+	ConflictColumns []string
+
+	// UpdateColumns are the columns set to the new row's values on conflict.
+	// Empty means every inserted column but the conflict target.
+	UpdateColumns []string
+}
+
+// UpsertMany inserts rows, updating those that conflict on a unique key, in
+// INSERT ... ON CONFLICT DO UPDATE statements of ChunkSize rows. When there
+// is more than one chunk they run in a single transaction, so either every
+// row is written or none is. It returns the number of rows written.
+// Usage: n, err := builder.UpsertMany(ctx, db, users, builder.UpsertOptions{ChunkSize: 1000})
+func UpsertMany[T any](ctx context.Context, d *DB, rows []T, opts UpsertOptions) (int64, error) {
+	var model T
+	table, err := registry.GetOrRegister(model)
+	if err != nil {
+		return 0, err
+	}
+	table = inSchema(table, d.schema)
+	if len(rows) == 0 {
+		return 0, nil
+	}
+	onConflict, err := upsertConflict(table, opts)
+	if err != nil {
+		return 0, err
+	}
+	useDefaults := conflictKeys(table, opts.ConflictColumns)
+	chunks := slices.Collect(slices.Chunk(rows, upsertChunkSize(table, opts.ChunkSize)))
+
+	if len(chunks) == 1 {
+		q := Insert[T](d).Values(rows...)
+		q.onConflict, q.useDefaults = onConflict, useDefaults
+		return q.Exec(ctx)
+	}
+
+	tx, err := d.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+	var total int64
+	for _, chunk := range chunks {
+		q := TxInsert[T](tx).Values(toAnySlice(chunk)...)
+		q.onConflict, q.useDefaults = onConflict, useDefaults
+		n, err := q.Exec()
+		if err != nil {
+			return 0, err
+		}
+		total += n
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// upsertConflict returns the ON CONFLICT DO UPDATE clause of opts.
+func upsertConflict(table *schema.TableMetadata, opts UpsertOptions) (*OnConflict, error) {
+	target := opts.ConflictColumns
+	if len(target) == 0 {
+		var err error
+		if target, err = conflictTarget(table); err != nil {
+			return nil, err
+		}
+	}
+	var err error
+	for _, col := range slices.Concat(target, opts.UpdateColumns) {
+		if table.GetColumnByName(col) == nil {
+			keepErr(&err, fmt.Errorf("column %s not found in table %s", col, table.Name))
+		}
+	}
+	for _, col := range opts.UpdateColumns {
+		keepErr(&err, checkUpdatable(table, col))
+	}
+	columns := quoteAll(target, quoteColumn, &err)
+	if err != nil {
+		return nil, err
+	}
+	return &OnConflict{Columns: columns, Action: DoUpdate, Excluded: opts.UpdateColumns}, nil
+}
+
+// conflictKeys returns the auto-increment primary key columns named in
+// target. INSERTs leave such keys to the database, which would make an
+// ON CONFLICT on them never match; as UseDefaults columns they are sent
+// when set and DEFAULT when zero.
+func conflictKeys(table *schema.TableMetadata, target []string) map[string]bool {
+	var keys map[string]bool
+	for _, name := range target {
+		if col := table.GetColumnByName(name); col != nil && col.AutoIncrement && table.IsPrimaryKey(name) {
+			if keys == nil {
+				keys = make(map[string]bool)
+			}
+			keys[name] = true
+		}
+	}
+	return keys
+}
+
+// conflictTarget infers the unique key an upsert of table conflicts on: its
+// primary key, unless the database generates it, or else its one unique
+// column, constraint or non-partial unique index.
+func conflictTarget(table *schema.TableMetadata) ([]string, error) {
+	if pk := table.PrimaryKeyColumns(); len(pk) > 0 && !slices.ContainsFunc(pk, func(name string) bool {
+		col := table.GetColumnByName(name)
+		return col == nil || col.AutoIncrement || col.Identity != nil || col.Default != nil
+	}) {
+		return pk, nil
+	}
+
+	var keys [][]string
+	add := func(columns []string) {
+		if len(columns) > 0 && !slices.ContainsFunc(keys, func(k []string) bool { return slices.Equal(k, columns) }) {
+			keys = append(keys, columns)
+		}
+	}
+	for _, c := range table.Constraints {
+		if c.Type == schema.UniqueConstraint {
+			add(c.Columns)
+		}
+	}
+	for _, idx := range table.Indexes {
+		if idx.Unique && idx.Where == "" && idx.Expression == "" {
+			add(idx.Columns)
+		}
+	}
+	switch len(keys) {
+	case 0:
+		return nil, fmt.Errorf("table %s has no unique key to upsert on: set UpsertOptions.ConflictColumns", table.Name)
+	case 1:
+		return keys[0], nil
+	}
+	names := make([]string, len(keys))
+	for i, k := range keys {
+		names[i] = "(" + strings.Join(k, ", ") + ")"
+	}
+	return nil, fmt.Errorf("table %s has several unique keys %s: set UpsertOptions.ConflictColumns", table.Name, strings.Join(names, ", "))
+}
+
+// upsertChunkSize returns size, defaulted and lowered so a chunk's
+// parameters fit in one statement.
+func upsertChunkSize(table *schema.TableMetadata, size int) int {
+	if size <= 0 {
+		size = DefaultUpsertChunkSize
+	}
+	if n := len(table.Columns); n > 0 {
+		size = min(size, maxBindParams/n)
+	}
+	return max(size, 1)
+}
@@ -0,0 +1,183 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/marshallshelly/pebble-orm/pkg/registry"
+)
+
+type UpsertContact struct {
+	ID    int64  `po:"id,primaryKey,autoIncrement"`
+	Email string `po:"email,varchar(320),unique,notNull"`
+	Name  string `po:"name,varchar(255)"`
+	Score int    `po:"score,integer"`
+}
+
+type UpsertSetting struct {
+	Key   string `po:"key,primaryKey,varchar(64)"`
+	Value string `po:"value,text"`
+}
+
+type UpsertAmbiguous struct {
+	ID    int64  `po:"id,primaryKey,autoIncrement"`
+	Email string `po:"email,unique"`
+	Phone string `po:"phone,unique"`
+}
+
+// upsertExecutor records statements and begins transactions that record
+// theirs too.
+type upsertExecutor struct {
+	stubExecutor
+	sql    []string
+	closed bool // the transaction has ended
+}
+
+func (e *upsertExecutor) Exec(ctx context.Context, sql string, args ...interface{}) (int64, error) {
+	e.sql = append(e.sql, sql)
+	return int64(strings.Count(sql, "), (") + 1), nil
+}
+
+func (e *upsertExecutor) BeginTx(ctx context.Context, opts pgx.TxOptions) (pgx.Tx, error) {
+	e.sql = append(e.sql, "BEGIN")
+	return upsertTx{e}, nil
+}
+
+type upsertTx struct {
+	e *upsertExecutor
+}
+
+func (t upsertTx) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	n, _ := t.e.Exec(ctx, sql, args...)
+	return pgconn.NewCommandTag(fmt.Sprintf("INSERT 0 %d", n)), nil
+}
+
+func (t upsertTx) Commit(ctx context.Context) error {
+	t.e.sql = append(t.e.sql, "COMMIT")
+	t.e.closed = true
+	return nil
+}
+
+func (t upsertTx) Rollback(ctx context.Context) error {
+	if t.e.closed {
+		return pgx.ErrTxClosed
+	}
+	t.e.closed = true
+	t.e.sql = append(t.e.sql, "ROLLBACK")
+	return nil
+}
+
+func (t upsertTx) Begin(ctx context.Context) (pgx.Tx, error) { return nil, pgx.ErrTxClosed }
+func (t upsertTx) CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	return 0, nil
+}
+func (t upsertTx) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults { return nil }
+func (t upsertTx) LargeObjects() pgx.LargeObjects                               { return pgx.LargeObjects{} }
+func (t upsertTx) Prepare(ctx context.Context, name, sql string) (*pgconn.StatementDescription, error) {
+	return nil, nil
+}
+func (t upsertTx) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return nil, nil
+}
+func (t upsertTx) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row { return nil }
+func (t upsertTx) Conn() *pgx.Conn                                               { return nil }
+
+func TestUpsertMany(t *testing.T) {
+	for _, m := range []interface{}{UpsertContact{}, UpsertSetting{}, UpsertAmbiguous{}} {
+		if err := registry.Register(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx := context.Background()
+	contacts := []UpsertContact{{Email: "a@x.io", Name: "A"}, {Email: "b@x.io", Name: "B"}, {Email: "c@x.io", Name: "C"}}
+
+	t.Run("single chunk", func(t *testing.T) {
+		exec := &upsertExecutor{}
+		n, err := UpsertMany(ctx, NewWithExecutor(exec), contacts, UpsertOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := "INSERT INTO upsert_contact (email, name, score) VALUES ($1, $2, $3), ($4, $5, $6), ($7, $8, $9) " +
+			"ON CONFLICT (email) DO UPDATE SET name = EXCLUDED.name, score = EXCLUDED.score"
+		if n != 3 || len(exec.sql) != 1 || exec.sql[0] != want {
+			t.Errorf("n = %d, SQL = %q, want %s", n, exec.sql, want)
+		}
+	})
+
+	t.Run("chunks in one transaction", func(t *testing.T) {
+		exec := &upsertExecutor{}
+		n, err := UpsertMany(ctx, NewWithExecutor(exec), contacts, UpsertOptions{ChunkSize: 2, UpdateColumns: []string{"name"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != 3 || len(exec.sql) != 4 || exec.sql[0] != "BEGIN" || exec.sql[3] != "COMMIT" {
+			t.Fatalf("n = %d, SQL = %q", n, exec.sql)
+		}
+		want := "INSERT INTO upsert_contact (email, name, score) VALUES ($1, $2, $3) ON CONFLICT (email) DO UPDATE SET name = EXCLUDED.name"
+		if exec.sql[2] != want {
+			t.Errorf("last chunk = %s, want %s", exec.sql[2], want)
+		}
+	})
+
+	t.Run("application primary key", func(t *testing.T) {
+		exec := &upsertExecutor{}
+		if _, err := UpsertMany(ctx, NewWithExecutor(exec), []UpsertSetting{{Key: "theme", Value: "dark"}}, UpsertOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		want := "INSERT INTO upsert_setting (key, value) VALUES ($1, $2) ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value"
+		if exec.sql[0] != want {
+			t.Errorf("SQL = %s, want %s", exec.sql[0], want)
+		}
+	})
+
+	t.Run("ambiguous key", func(t *testing.T) {
+		_, err := UpsertMany(ctx, NewWithExecutor(&upsertExecutor{}), []UpsertAmbiguous{{Email: "a@x.io"}}, UpsertOptions{})
+		if err == nil || !strings.Contains(err.Error(), "ConflictColumns") {
+			t.Errorf("err = %v, want a request for ConflictColumns", err)
+		}
+		exec := &upsertExecutor{}
+		if _, err := UpsertMany(ctx, NewWithExecutor(exec), []UpsertAmbiguous{{Email: "a@x.io"}}, UpsertOptions{ConflictColumns: []string{"phone"}}); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasSuffix(exec.sql[0], "ON CONFLICT (phone) DO UPDATE SET email = EXCLUDED.email") {
+			t.Errorf("SQL = %s", exec.sql[0])
+		}
+	})
+
+	t.Run("auto-increment primary key target", func(t *testing.T) {
+		exec := &upsertExecutor{}
+		rows := []UpsertContact{{ID: 7, Email: "a@x.io", Name: "A"}, {Email: "b@x.io", Name: "B"}}
+		if _, err := UpsertMany(ctx, NewWithExecutor(exec), rows, UpsertOptions{ConflictColumns: []string{"id"}}); err != nil {
+			t.Fatal(err)
+		}
+		want := "INSERT INTO upsert_contact (id, email, name, score) VALUES ($1, $2, $3, $4), (DEFAULT, $5, $6, $7) " +
+			"ON CONFLICT (id) DO UPDATE SET email = EXCLUDED.email, name = EXCLUDED.name, score = EXCLUDED.score"
+		if exec.sql[0] != want {
+			t.Errorf("SQL = %s, want %s", exec.sql[0], want)
+		}
+	})
+
+	t.Run("unknown column", func(t *testing.T) {
+		_, err := UpsertMany(ctx, NewWithExecutor(&upsertExecutor{}), contacts, UpsertOptions{UpdateColumns: []string{"nope"}})
+		if err == nil {
+			t.Error("expected an error for an unknown update column")
+		}
+	})
+}
+
+func TestUpsertChunkSize(t *testing.T) {
+	table, err := registry.GetOrRegister(UpsertContact{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := upsertChunkSize(table, 0); got != DefaultUpsertChunkSize {
+		t.Errorf("default = %d", got)
+	}
+	if got := upsertChunkSize(table, 100000); got != maxBindParams/4 {
+		t.Errorf("capped = %d, want %d", got, maxBindParams/4)
+	}
+}
@@ -0,0 +1,384 @@
+package builder
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"net/mail"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+	"unsafe"
+
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+)
+
+// Validator is implemented by models with invariants the validate tag can't
+// express. Insert calls Validate on each row once its tag rules pass, before
+// anything is sent to the database.
+type Validator interface {
+	Validate() error
+}
+
+// FieldError is a field whose value breaks a rule of its validate tag.
+type FieldError struct {
+	Field  string // Go field name
+	Column string
+	Rule   string // as written in the tag, e.g. max=255
+}
+
+func (e FieldError) Error() string {
+	name, arg, _ := strings.Cut(e.Rule, "=")
+	switch name {
+	case "required":
+		return e.Field + " is required"
+	case "email":
+		return e.Field + " must be an email address"
+	case "min":
+		return fmt.Sprintf("%s must be at least %s", e.Field, arg)
+	case "max":
+		return fmt.Sprintf("%s must be at most %s", e.Field, arg)
+	case "oneof":
+		return fmt.Sprintf("%s must be one of %s", e.Field, strings.Join(strings.Fields(arg), ", "))
+	}
+	return fmt.Sprintf("%s fails %s", e.Field, e.Rule)
+}
+
+// ValidationErrors are the fields of a row that break their validate tags.
+// Insert and Update return them without executing anything; a multi-row
+// insert wraps them with the row's index.
+// Usage: var verrs builder.ValidationErrors; if errors.As(err, &verrs) { ... }
+type ValidationErrors []FieldError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Error()
+	}
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+// validateRule checks one rule of a validate tag against a non-nil value.
+type validateRule struct {
+	name  string // as written, e.g. max=255
+	check func(v reflect.Value) bool
+}
+
+type validateColumn struct {
+	field    string
+	column   string
+	index    []int
+	typ      reflect.Type
+	required bool
+	rules    []validateRule
+}
+
+type validatePlan struct {
+	columns []validateColumn
+	byName  map[string]int // column name -> index in columns
+	err     error          // invalid validate tag
+}
+
+var validatePlans sync.Map // valuePlanKey -> *validatePlan
+
+// validatePlanFor returns the validate tag rules of table's columns in typ,
+// compiling them the first time.
+func validatePlanFor(typ reflect.Type, table *schema.TableMetadata) *validatePlan {
+	key := valuePlanKey{typ: typ, columns: unsafe.SliceData(table.Columns)}
+	if plan, ok := validatePlans.Load(key); ok {
+		return plan.(*validatePlan)
+	}
+
+	plan := &validatePlan{byName: make(map[string]int)}
+	for _, col := range table.Columns {
+		index, fieldType, err := fieldIndex(typ, col.GoField)
+		if err != nil {
+			plan.err = err
+			break
+		}
+		if index == nil {
+			continue
+		}
+		tag, ok := typ.FieldByIndex(index).Tag.Lookup("validate")
+		if !ok || tag == "" {
+			continue
+		}
+		c := validateColumn{field: col.GoField, column: col.Name, index: index, typ: fieldType}
+		for rule := range strings.SplitSeq(tag, ",") {
+			rule = strings.TrimSpace(rule)
+			if rule == "required" {
+				c.required = true
+				continue
+			}
+			check, err := compileRule(rule, fieldType)
+			if err != nil {
+				plan.err = fmt.Errorf("invalid validate tag on %s.%s: %w", typ.Name(), col.GoField, err)
+				break
+			}
+			c.rules = append(c.rules, validateRule{name: rule, check: check})
+		}
+		plan.byName[col.Name] = len(plan.columns)
+		plan.columns = append(plan.columns, c)
+	}
+	validatePlans.Store(key, plan)
+	return plan
+}
+
+// compileRule returns the check for a rule other than required on fields of
+// typ.
+func compileRule(rule string, typ reflect.Type) (func(v reflect.Value) bool, error) {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	name, arg, hasArg := strings.Cut(rule, "=")
+	switch name {
+	case "email":
+		if typ.Kind() != reflect.String {
+			return nil, fmt.Errorf("email needs a string field")
+		}
+		return func(v reflect.Value) bool {
+			addr, err := mail.ParseAddress(v.String())
+			return err == nil && addr.Address == v.String()
+		}, nil
+	case "min", "max":
+		limit, err := strconv.ParseFloat(arg, 64)
+		if !hasArg || err != nil {
+			return nil, fmt.Errorf("%s needs a number, as in %s=10", name, name)
+		}
+		size, ok := sizeOf(typ)
+		if !ok {
+			return nil, fmt.Errorf("%s needs a string, slice, map or number field", name)
+		}
+		if name == "min" {
+			return func(v reflect.Value) bool { return size(v) >= limit }, nil
+		}
+		return func(v reflect.Value) bool { return size(v) <= limit }, nil
+	case "oneof":
+		options := strings.Fields(arg)
+		if len(options) == 0 {
+			return nil, fmt.Errorf("oneof needs space-separated values, as in oneof=draft published")
+		}
+		return func(v reflect.Value) bool {
+			return slices.Contains(options, fmt.Sprint(v.Interface()))
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown rule %q", rule)
+}
+
+// sizeOf returns what min and max compare for values of typ: the length of
+// strings (in characters), slices and maps, or the value of numbers.
+func sizeOf(typ reflect.Type) (func(v reflect.Value) float64, bool) {
+	switch typ.Kind() {
+	case reflect.String:
+		return func(v reflect.Value) float64 { return float64(utf8.RuneCountInString(v.String())) }, true
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return func(v reflect.Value) float64 { return float64(v.Len()) }, true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(v reflect.Value) float64 { return float64(v.Int()) }, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return func(v reflect.Value) float64 { return float64(v.Uint()) }, true
+	case reflect.Float32, reflect.Float64:
+		return func(v reflect.Value) float64 { return v.Float() }, true
+	}
+	return nil, false
+}
+
+// check returns the rules value breaks. A zero value breaks only required;
+// the other rules apply to values that are set.
+func (c *validateColumn) check(value reflect.Value) []FieldError {
+	if value.IsZero() {
+		if c.required {
+			return []FieldError{{Field: c.field, Column: c.column, Rule: "required"}}
+		}
+		return nil
+	}
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	var errs []FieldError
+	for _, rule := range c.rules {
+		if !rule.check(value) {
+			errs = append(errs, FieldError{Field: c.field, Column: c.column, Rule: rule.name})
+		}
+	}
+	return errs
+}
+
+// validateRow checks row against the validate tags of its fields, then calls
+// its Validate method if it has one.
+func validateRow(row interface{}, table *schema.TableMetadata) error {
+	modelValue := reflect.ValueOf(row)
+	if modelValue.Kind() == reflect.Pointer {
+		modelValue = modelValue.Elem()
+	}
+	if modelValue.Kind() != reflect.Struct {
+		return nil
+	}
+	plan := validatePlanFor(modelValue.Type(), table)
+	if plan.err != nil {
+		return plan.err
+	}
+	var errs ValidationErrors
+	for i := range plan.columns {
+		c := &plan.columns[i]
+		errs = append(errs, c.check(modelValue.FieldByIndex(c.index))...)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return callValidate(row, modelValue)
+}
+
+// callValidate calls the row's Validate method, declared on the model or
+// its pointer.
+func callValidate(row interface{}, modelValue reflect.Value) error {
+	if v, ok := row.(Validator); ok {
+		return v.Validate()
+	}
+	if modelValue.CanAddr() {
+		if v, ok := modelValue.Addr().Interface().(Validator); ok {
+			return v.Validate()
+		}
+	}
+	ptr := reflect.New(modelValue.Type())
+	ptr.Elem().Set(modelValue)
+	if v, ok := ptr.Interface().(Validator); ok {
+		return v.Validate()
+	}
+	return nil
+}
+
+// validateRows checks each row of an INSERT as validateRow does.
+func validateRows(rows []interface{}, table *schema.TableMetadata) error {
+	for i, row := range rows {
+		if err := validateRow(row, table); err != nil {
+			if len(rows) == 1 {
+				return err
+			}
+			return fmt.Errorf("row %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// validateSets checks the values of an UPDATE against the validate tags of
+// their columns.
+func validateSets(sets map[string]interface{}, table *schema.TableMetadata) error {
+	if table.GoType == nil {
+		return nil
+	}
+	plan := validatePlanFor(table.GoType, table)
+	if plan.err != nil {
+		return plan.err
+	}
+	var errs ValidationErrors
+	for _, col := range slices.Sorted(maps.Keys(sets)) {
+		i, ok := plan.byName[col]
+		if !ok {
+			continue
+		}
+		c := &plan.columns[i]
+		value, ok, err := setValue(sets[col], c.typ)
+		if err != nil {
+			return fmt.Errorf("column %s: %w", col, err)
+		}
+		if ok {
+			errs = append(errs, c.check(value)...)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// setValue returns an UPDATE value as a value of the field type typ. Values
+// of another kind, such as a string for a number column, aren't checked and
+// are left to the database. A number that the field type can't hold exactly,
+// such as 9.9 for an int or 300 for a uint8, is an error rather than being
+// truncated before the checks.
+func setValue(v interface{}, typ reflect.Type) (reflect.Value, bool, error) {
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Pointer && !value.IsNil() {
+		value = value.Elem()
+	}
+	if v == nil || value.Kind() == reflect.Pointer {
+		return reflect.Zero(typ), true, nil
+	}
+	base := typ
+	for base.Kind() == reflect.Pointer {
+		base = base.Elem()
+	}
+	switch {
+	case value.Type() == base:
+	case kindClass(value.Kind()) != reflect.Invalid && kindClass(value.Kind()) == kindClass(base.Kind()):
+		if !convertsExactly(value, base) {
+			return reflect.Value{}, false, fmt.Errorf("%v can't be stored in %s without loss", value, base)
+		}
+		value = value.Convert(base)
+	default:
+		return reflect.Value{}, false, nil
+	}
+	if typ.Kind() == reflect.Pointer {
+		// A set pointer field satisfies required even when it points to zero
+		ptr := reflect.New(base)
+		ptr.Elem().Set(value)
+		return ptr, true, nil
+	}
+	return value, true, nil
+}
+
+// convertsExactly reports whether value, of a kind in the same kindClass as
+// typ, converts to typ without being truncated or overflowing.
+func convertsExactly(value reflect.Value, typ reflect.Type) bool {
+	dst := reflect.New(typ).Elem()
+	switch {
+	case value.CanInt():
+		i := value.Int()
+		switch {
+		case dst.CanInt():
+			return !dst.OverflowInt(i)
+		case dst.CanUint():
+			return i >= 0 && !dst.OverflowUint(uint64(i))
+		}
+	case value.CanUint():
+		u := value.Uint()
+		switch {
+		case dst.CanInt():
+			return u <= math.MaxInt64 && !dst.OverflowInt(int64(u))
+		case dst.CanUint():
+			return !dst.OverflowUint(u)
+		}
+	case value.CanFloat():
+		f := value.Float()
+		switch {
+		case dst.CanInt():
+			return f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64 && !dst.OverflowInt(int64(f))
+		case dst.CanUint():
+			return f == math.Trunc(f) && f >= 0 && f < math.MaxUint64 && !dst.OverflowUint(uint64(f))
+		case dst.CanFloat():
+			return math.IsInf(f, 0) || math.IsNaN(f) || !dst.OverflowFloat(f)
+		}
+	}
+	return true
+}
+
+// kindClass groups the kinds setValue converts between: strings, and
+// numbers.
+func kindClass(k reflect.Kind) reflect.Kind {
+	switch k {
+	case reflect.String:
+		return reflect.String
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return reflect.Float64
+	}
+	return reflect.Invalid
+}
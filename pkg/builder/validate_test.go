@@ -0,0 +1,113 @@
+package builder
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+type validatedUser struct {
+	ID    int64  `po:"id,primaryKey,serial"`
+	Email string `po:"email,varchar(320)" validate:"required,email,max=320"`
+	Name  string `po:"name,text" validate:"max=5"`
+	Role  string `po:"role,text" validate:"oneof=admin member"`
+	Age   *int   `po:"age,integer" validate:"min=18"`
+}
+
+type hookedUser struct {
+	ID   int64  `po:"id,primaryKey,serial"`
+	Name string `po:"name,text" validate:"required"`
+}
+
+func (u *hookedUser) Validate() error {
+	if strings.ToLower(u.Name) == "root" {
+		return errors.New("name is reserved")
+	}
+	return nil
+}
+
+type badTagUser struct {
+	ID   int64 `po:"id,primaryKey,serial"`
+	Name int   `po:"name,integer" validate:"email"`
+}
+
+func TestInsertValidation(t *testing.T) {
+	db := New(nil)
+	young := 16
+	tests := []struct {
+		name  string
+		user  validatedUser
+		rules []string
+	}{
+		{"valid", validatedUser{Email: "ann@example.com", Name: "Ann", Role: "admin"}, nil},
+		{"zero values skip rules", validatedUser{Email: "ann@example.com"}, nil},
+		{"required", validatedUser{Name: "Ann"}, []string{"required"}},
+		{"email", validatedUser{Email: "Ann <ann@example.com>"}, []string{"email"}},
+		{"max counts characters", validatedUser{Email: "a@example.com", Name: "Zoë Ö"}, nil},
+		{"several", validatedUser{Email: "a@example.com", Name: "Annabel", Role: "owner", Age: &young}, []string{"max=5", "oneof=admin member", "min=18"}},
+	}
+	for _, tt := range tests {
+		_, _, err := Insert[validatedUser](db).Values(tt.user).ToSQL()
+		var verrs ValidationErrors
+		if errors.As(err, &verrs) {
+			var rules []string
+			for _, fe := range verrs {
+				rules = append(rules, fe.Rule)
+			}
+			if !slices.Equal(rules, tt.rules) {
+				t.Errorf("%s: broke %q, want %q", tt.name, rules, tt.rules)
+			}
+		} else if err != nil || tt.rules != nil {
+			t.Errorf("%s: err = %v, want rules %q", tt.name, err, tt.rules)
+		}
+	}
+
+	_, _, err := Insert[validatedUser](db).Values(validatedUser{Email: "a@example.com"}, validatedUser{}).ToSQL()
+	if err == nil || !strings.HasPrefix(err.Error(), "row 1: validation failed: Email is required") {
+		t.Errorf("multi-row: err = %v", err)
+	}
+}
+
+func TestInsertValidateHook(t *testing.T) {
+	db := New(nil)
+	if _, _, err := Insert[hookedUser](db).Values(hookedUser{Name: "Root"}).ToSQL(); err == nil || err.Error() != "name is reserved" {
+		t.Errorf("Validate not called: err = %v", err)
+	}
+	if _, _, err := Insert[hookedUser](db).Values(hookedUser{Name: "ann"}).ToSQL(); err != nil {
+		t.Errorf("valid row: %v", err)
+	}
+	if _, _, err := Insert[badTagUser](db).Values(badTagUser{Name: 1}).ToSQL(); err == nil || !strings.Contains(err.Error(), "email needs a string field") {
+		t.Errorf("bad tag: err = %v", err)
+	}
+}
+
+func TestUpdateValidation(t *testing.T) {
+	db := New(nil)
+	tests := []struct {
+		name string
+		sets map[string]interface{}
+		want string
+	}{
+		{"valid", map[string]interface{}{"email": "ann@example.com", "name": "Ann"}, ""},
+		{"unset columns aren't required", map[string]interface{}{"name": "Ann"}, ""},
+		{"cleared required column", map[string]interface{}{"email": ""}, "validation failed: Email is required"},
+		{"nil", map[string]interface{}{"email": nil}, "validation failed: Email is required"},
+		{"too young", map[string]interface{}{"age": 16}, "validation failed: Age must be at least 18"},
+		{"converted", map[string]interface{}{"age": int64(16)}, "validation failed: Age must be at least 18"},
+		{"other kinds aren't checked", map[string]interface{}{"age": "16"}, ""},
+		{"integral float", map[string]interface{}{"age": 18.0}, ""},
+		{"fractional float", map[string]interface{}{"age": 17.5}, "column age: 17.5 can't be stored in int without loss"},
+		{"overflow", map[string]interface{}{"age": uint64(1) << 63}, "column age: 9223372036854775808 can't be stored in int without loss"},
+	}
+	for _, tt := range tests {
+		_, _, err := Update[validatedUser](db).SetMap(tt.sets).Where(Eq("id", 1)).ToSQL()
+		got := ""
+		if err != nil {
+			got = err.Error()
+		}
+		if got != tt.want {
+			t.Errorf("%s: err = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package builder
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"unsafe"
+
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+)
+
+// valuePlan says how to read each column's value from a model type for
+// INSERTs: the field's index path and whether the value goes through a codec
+// or JSON. Plans are compiled on first use and cached, so multi-row inserts
+// don't look fields up by name for every row.
+//
+// Like scan plans, they look codecs up when compiled.
+type valuePlan struct {
+	columns []valueColumn  // in table.Columns order
+	byName  map[string]int // column name -> index in columns
+	err     error          // a column field the plan can't reach
+}
+
+type valueColumn struct {
+	col      schema.ColumnMetadata
+	index    []int // field index path; nil when the model has no such field
+	autoPK   bool  // auto-increment primary key, left out of INSERTs
+	omitZero bool  // database default or identity, left out when the field is zero
+	codec    *schema.Codec
+	viaPtr   bool // codec registered for the field's element type
+	jsonb    bool // JSONB column whose type isn't a driver.Valuer
+	hstore   bool // hstore column with a string map field
+	enums    bool // enum array column, bound as []string
+}
+
+type valuePlanKey struct {
+	typ     reflect.Type
+	columns *schema.ColumnMetadata // identifies the metadata; copies made by inSchema share it
+}
+
+var valuePlans sync.Map // valuePlanKey -> *valuePlan
+
+// valuePlanFor returns the plan for reading table's columns from values of
+// typ, compiling it the first time.
+func valuePlanFor(typ reflect.Type, table *schema.TableMetadata) *valuePlan {
+	key := valuePlanKey{typ: typ, columns: unsafe.SliceData(table.Columns)}
+	if plan, ok := valuePlans.Load(key); ok {
+		return plan.(*valuePlan)
+	}
+
+	plan := &valuePlan{
+		columns: make([]valueColumn, len(table.Columns)),
+		byName:  make(map[string]int, len(table.Columns)),
+	}
+	for i, col := range table.Columns {
+		c := valueColumn{
+			col:      col,
+			autoPK:   col.AutoIncrement && table.IsPrimaryKey(col.Name),
+			omitZero: col.Default != nil || col.Identity != nil,
+		}
+		index, fieldType, err := fieldIndex(typ, col.GoField)
+		if err != nil && plan.err == nil {
+			plan.err = err
+		}
+		if index != nil {
+			c.index = index
+			if codec, viaPtr, ok := codecFor(fieldType); ok {
+				c.codec, c.viaPtr = codec, viaPtr
+			} else {
+				c.jsonb = col.IsJSONB && !implementsValuer(fieldType)
+				c.hstore = isHstoreMap(col, fieldType) && !implementsValuer(fieldType)
+				c.enums = isEnumArray(col, fieldType) && !implementsValuer(fieldType)
+			}
+		}
+		plan.columns[i] = c
+		if _, dup := plan.byName[col.Name]; !dup {
+			plan.byName[col.Name] = i
+		}
+	}
+	valuePlans.Store(key, plan)
+	return plan
+}
+
+// fieldIndex returns the index path and type of the exported field at a
+// dotted path of embedded struct fields, as fieldByPath follows it. The index
+// is nil when typ has no such field. A field reached through a struct
+// embedded by pointer is an error: plans address fields by index and offset,
+// which can't step through a pointer that may be nil.
+func fieldIndex(typ reflect.Type, path string) ([]int, reflect.Type, error) {
+	root := typ
+	viaPointer := func() error {
+		return fmt.Errorf("field %s of %s is reached through a struct pointer; embed the struct by value", path, root)
+	}
+	var index []int
+	for name := range strings.SplitSeq(path, ".") {
+		if typ.Kind() == reflect.Pointer && typ.Elem().Kind() == reflect.Struct {
+			return nil, nil, viaPointer()
+		}
+		if typ.Kind() != reflect.Struct {
+			return nil, nil, nil
+		}
+		field, ok := typ.FieldByName(name)
+		if !ok || !field.IsExported() {
+			return nil, nil, nil
+		}
+		for _, i := range field.Index {
+			if typ.Kind() != reflect.Struct {
+				return nil, nil, viaPointer()
+			}
+			index = append(index, i)
+			typ = typ.Field(i).Type
+		}
+	}
+	return index, typ, nil
+}
+
+// value returns the value to bind for the column, encoding fields with a
+// registered codec, marshaling JSONB columns whose type does not
+// implement driver.Valuer and converting hstore maps and enum arrays.
+func (c *valueColumn) value(field reflect.Value) (interface{}, error) {
+	if c.codec != nil {
+		if c.viaPtr {
+			if field.IsNil() {
+				return nil, nil
+			}
+			field = field.Elem()
+		}
+		value, err := c.codec.Encode(field.Interface())
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode field %s: %w", c.col.GoField, err)
+		}
+		return value, nil
+	}
+
+	if c.hstore {
+		return hstoreValue(field), nil
+	}
+	if c.enums {
+		if field.IsNil() {
+			return nil, nil
+		}
+		return convertSlice(field, reflect.TypeFor[[]string]()).Interface(), nil
+	}
+	fieldValue := field.Interface()
+	if c.jsonb {
+		jsonBytes, err := marshalJSONB(fieldValue)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal JSONB field %s: %w", c.col.GoField, err)
+		}
+		return jsonBytes, nil
+	}
+	return fieldValue, nil
+}
@@ -0,0 +1,61 @@
+package builder
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/marshallshelly/pebble-orm/pkg/registry"
+)
+
+func TestValuePlan(t *testing.T) {
+	table, err := registry.GetOrRegister(ScanReading{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	typ := reflect.TypeFor[ScanReading]()
+
+	plan := valuePlanFor(typ, table)
+	if valuePlanFor(typ, inSchema(table, "tenant_a")) != plan {
+		t.Error("expected a schema-qualified copy of the metadata to share the plan")
+	}
+	id := plan.columns[plan.byName["id"]]
+	labels := plan.columns[plan.byName["labels"]]
+	version := plan.columns[plan.byName["version"]]
+	if !id.autoPK || !labels.jsonb || !reflect.DeepEqual(version.index, []int{4, 0}) {
+		t.Errorf("unexpected plan %+v", plan.columns)
+	}
+
+	rows := []interface{}{
+		ScanReading{Sensor: "t1", Value: 1.5, EmbedTimestamps: EmbedTimestamps{Version: 1}},
+		&ScanReading{Sensor: "t2", Labels: map[string]string{"room": "lab"}},
+	}
+	sql, args, err := buildInsertSQL(insertSpec{table: table, rows: rows})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "INSERT INTO scan_reading (sensor, value, labels, version) VALUES ($1, $2, $3, $4), ($5, $6, $7, $8)"; sql != want {
+		t.Errorf("sql = %v, want %v", sql, want)
+	}
+	want := []interface{}{"t1", 1.5, "null", 1, "t2", 0.0, `{"room":"lab"}`, 0}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("args = %#v, want %#v", args, want)
+	}
+}
+
+func BenchmarkInsertSQL(b *testing.B) {
+	table, err := registry.GetOrRegister(ScanReading{})
+	if err != nil {
+		b.Fatal(err)
+	}
+	rows := make([]interface{}, 500)
+	for i := range rows {
+		rows[i] = ScanReading{Sensor: "sensor", Value: float64(i), EmbedTimestamps: EmbedTimestamps{Version: i}}
+	}
+
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, _, err := buildInsertSQL(insertSpec{table: table, rows: rows}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
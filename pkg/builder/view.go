@@ -0,0 +1,64 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+)
+
+// RefreshOption modifies a REFRESH MATERIALIZED VIEW statement.
+type RefreshOption int
+
+const (
+	// Concurrently refreshes without locking out concurrent reads. The
+	// materialized view must have at least one unique index.
+	Concurrently RefreshOption = iota + 1
+	// WithNoData empties the view and marks it unscannable until the next refresh.
+	WithNoData
+)
+
+// RefreshMaterializedView re-runs a materialized view's query. Unqualified
+// names use the DB's WithSchema schema.
+// Usage: db.RefreshMaterializedView(ctx, "daily_sales", builder.Concurrently)
+func (d *DB) RefreshMaterializedView(ctx context.Context, name string, opts ...RefreshOption) error {
+	if !strings.Contains(name, ".") {
+		name = schema.QualifyTableName(d.schema, name)
+	}
+	sql, err := buildRefreshSQL(name, opts)
+	if err != nil {
+		return err
+	}
+	if _, err := d.writeExec().Exec(ctx, sql); err != nil {
+		return fmt.Errorf("failed to refresh materialized view %s: %w", name, err)
+	}
+	return invalidate(ctx, d.cache, schema.QualifyTableName(schema.SplitQualifiedName(name)))
+}
+
+// buildRefreshSQL assembles a REFRESH MATERIALIZED VIEW statement.
+func buildRefreshSQL(name string, opts []RefreshOption) (string, error) {
+	var concurrently, noData bool
+	for _, opt := range opts {
+		switch opt {
+		case Concurrently:
+			concurrently = true
+		case WithNoData:
+			noData = true
+		}
+	}
+	if concurrently && noData {
+		return "", fmt.Errorf("REFRESH MATERIALIZED VIEW cannot combine CONCURRENTLY and WITH NO DATA")
+	}
+
+	var sql strings.Builder
+	sql.WriteString("REFRESH MATERIALIZED VIEW ")
+	if concurrently {
+		sql.WriteString("CONCURRENTLY ")
+	}
+	sql.WriteString(schema.QuoteQualifiedIdent(name))
+	if noData {
+		sql.WriteString(" WITH NO DATA")
+	}
+	return sql.String(), nil
+}
@@ -0,0 +1,67 @@
+package builder
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/marshallshelly/pebble-orm/pkg/registry"
+)
+
+// materialized_view: SELECT date_trunc('day', created_at) AS day, count(*) AS orders FROM orders GROUP BY 1
+type DailyOrderStats struct {
+	Day    string `po:"day,date"`
+	Orders int    `po:"orders,bigint"`
+}
+
+func TestViewModels_ReadOnly(t *testing.T) {
+	if err := registry.Register(DailyOrderStats{}); err != nil {
+		t.Fatalf("Failed to register model: %v", err)
+	}
+	db := New(nil)
+
+	sql, _, err := Select[DailyOrderStats](db).ToSQL()
+	if err != nil || sql != "SELECT * FROM daily_order_stats" {
+		t.Errorf("Select ToSQL() = %q, %v", sql, err)
+	}
+
+	writes := map[string]func() (string, []interface{}, error){
+		"insert": Insert[DailyOrderStats](db).Values(DailyOrderStats{Orders: 1}).ToSQL,
+		"update": Update[DailyOrderStats](db).Set("orders", 2).ToSQL,
+		"delete": Delete[DailyOrderStats](db).ToSQL,
+	}
+	for name, toSQL := range writes {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := toSQL()
+			if err == nil || !strings.Contains(err.Error(), "is a view") {
+				t.Errorf("expected view write error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestBuildRefreshSQL(t *testing.T) {
+	tests := []struct {
+		name    string
+		view    string
+		opts    []RefreshOption
+		want    string
+		wantErr bool
+	}{
+		{"plain", "daily_sales", nil, "REFRESH MATERIALIZED VIEW daily_sales", false},
+		{"concurrently", "daily_sales", []RefreshOption{Concurrently}, "REFRESH MATERIALIZED VIEW CONCURRENTLY daily_sales", false},
+		{"with no data", "reporting.daily_sales", []RefreshOption{WithNoData}, "REFRESH MATERIALIZED VIEW reporting.daily_sales WITH NO DATA", false},
+		{"conflicting options", "daily_sales", []RefreshOption{Concurrently, WithNoData}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := buildRefreshSQL(tt.view, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("buildRefreshSQL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("buildRefreshSQL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
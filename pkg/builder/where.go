@@ -2,9 +2,13 @@ package builder
 
 import (
 	"fmt"
+	"reflect"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
 )
 
 // placeholderRe matches PostgreSQL positional parameter placeholders ($1, $2, …).
@@ -60,67 +64,120 @@ func (w *WhereBuilder) Build() (string, []interface{}, error) {
 		return "", nil, nil
 	}
 
-	var args []interface{}
-	sql, newArgs, err := w.buildConditions(w.conditions, w.paramStart)
+	var sql strings.Builder
+	args, err := writeClause(&sql, "WHERE", w.conditions, w.paramStart, nil)
 	if err != nil {
 		return "", nil, err
 	}
-
-	args = append(args, newArgs...)
-	return "WHERE " + sql, args, nil
+	return sql.String(), args, nil
 }
 
-// buildConditions recursively builds conditions.
-func (w *WhereBuilder) buildConditions(conditions []Condition, paramStart int) (string, []interface{}, error) {
+// writeClause writes keyword and conditions (a WHERE or HAVING clause) to
+// sql with placeholders numbered from paramStart, and appends their
+// arguments to args. It writes nothing for no conditions.
+func writeClause(sql *strings.Builder, keyword string, conditions []Condition, paramStart int, args []interface{}) ([]interface{}, error) {
 	if len(conditions) == 0 {
-		return "", nil, nil
+		return args, nil
+	}
+	sql.Grow(len(keyword) + 1 + 24*len(conditions))
+	if args == nil {
+		args = make([]interface{}, 0, countConditionArgs(conditions))
+	}
+	sql.WriteString(keyword)
+	sql.WriteByte(' ')
+	args, _, err := writeConditions(sql, conditions, paramStart, args)
+	return args, err
+}
+
+// countConditionArgs returns how many arguments conditions bind, to size
+// argument slices up front.
+func countConditionArgs(conditions []Condition) int {
+	n := 0
+	for _, cond := range conditions {
+		switch {
+		case len(cond.Group) > 0:
+			n += countConditionArgs(cond.Group)
+		case cond.Raw:
+			n += len(cond.Args)
+		case bindsList(cond.Operator):
+			values, _ := cond.Value.([]interface{})
+			n += len(values)
+		case bindsNothing(cond.Operator):
+		default:
+			n++
+		}
+	}
+	return n
+}
+
+// bindsList reports whether op binds each element of a []interface{} value.
+func bindsList(op Operator) bool {
+	switch op {
+	case OpIn, OpNotIn, OpBetween, OpNotBetween:
+		return true
 	}
+	return false
+}
 
-	var parts []string
-	var args []interface{}
-	paramNum := paramStart
+// bindsNothing reports whether op takes no value.
+func bindsNothing(op Operator) bool {
+	switch op {
+	case OpIsNull, OpIsNotNull, OpIsTrue, OpIsFalse, OpExists:
+		return true
+	}
+	return false
+}
 
+// writeConditions recursively writes conditions joined by their logic
+// operators, returning the arguments and the next parameter number.
+func writeConditions(sql *strings.Builder, conditions []Condition, paramNum int, args []interface{}) ([]interface{}, int, error) {
+	var err error
 	for i, cond := range conditions {
-		// Handle grouped conditions
-		if len(cond.Group) > 0 {
-			groupSQL, groupArgs, err := w.buildConditions(cond.Group, paramNum)
-			if err != nil {
-				return "", nil, err
-			}
-			parts = append(parts, "("+groupSQL+")")
-			args = append(args, groupArgs...)
-			paramNum += len(groupArgs)
-		} else {
-			// Build individual condition
-			condSQL, condArgs, err := w.buildCondition(cond, paramNum)
-			if err != nil {
-				return "", nil, err
+		if i > 0 {
+			logic := cond.Logic
+			if logic == "" {
+				logic = LogicAnd
 			}
+			sql.WriteByte(' ')
+			sql.WriteString(string(logic))
+			sql.WriteByte(' ')
+		}
 
-			if cond.Not {
-				condSQL = "NOT (" + condSQL + ")"
+		// Handle grouped conditions
+		if len(cond.Group) > 0 {
+			sql.WriteByte('(')
+			if args, paramNum, err = writeConditions(sql, cond.Group, paramNum, args); err != nil {
+				return nil, 0, err
 			}
-
-			parts = append(parts, condSQL)
-			args = append(args, condArgs...)
-			paramNum += len(condArgs)
+			sql.WriteByte(')')
+			continue
 		}
 
-		// Add logic operator between conditions
-		if i < len(conditions)-1 {
-			logic := conditions[i+1].Logic
-			if logic == "" {
-				logic = LogicAnd
-			}
-			parts[len(parts)-1] += " " + string(logic)
+		// Build individual condition
+		if cond.Not {
+			sql.WriteString("NOT (")
 		}
+		n := len(args)
+		if args, err = writeCondition(sql, cond, paramNum, args); err != nil {
+			return nil, 0, err
+		}
+		if cond.Not {
+			sql.WriteByte(')')
+		}
+		paramNum += len(args) - n
 	}
+	return args, paramNum, nil
+}
 
-	return strings.Join(parts, " "), args, nil
+// writePlaceholder writes $n without allocating.
+func writePlaceholder(sql *strings.Builder, n int) {
+	var buf [20]byte
+	sql.WriteByte('$')
+	sql.Write(strconv.AppendInt(buf[:0], int64(n), 10))
 }
 
-// buildCondition builds a single condition.
-func (w *WhereBuilder) buildCondition(cond Condition, paramNum int) (string, []interface{}, error) {
+// writeCondition writes a single condition and appends its arguments.
+func writeCondition(sql *strings.Builder, cond Condition, paramNum int, args []interface{}) ([]interface{}, error) {
 	column := cond.Column
 	operator := cond.Operator
 	value := cond.Value
@@ -128,81 +185,118 @@ func (w *WhereBuilder) buildCondition(cond Condition, paramNum int) (string, []i
 	// Raw conditions embed Value directly as SQL instead of parameterizing it.
 	// Used by the subquery helpers (InSubquery, ExistsSubquery, ...). Any $n
 	// placeholders inside the raw SQL are renumbered to start at paramNum, and
-	// cond.Args are returned so the outer builder appends them and advances the
-	// parameter counter.
+	// cond.Args are appended so the outer builder advances the parameter
+	// counter.
 	if cond.Raw {
 		raw, ok := value.(string)
 		if !ok {
-			return "", nil, fmt.Errorf("raw condition requires string value, got %T", value)
+			return nil, fmt.Errorf("raw condition requires string value, got %T", value)
+		}
+		if column != "" {
+			if err := writeColumn(sql, column); err != nil {
+				return nil, err
+			}
+			sql.WriteByte(' ')
 		}
-		raw = shiftPlaceholders(raw, paramNum-1)
-		if column == "" {
-			// EXISTS (subquery) / NOT EXISTS (subquery)
-			return fmt.Sprintf("%s %s", operator, raw), cond.Args, nil
+		// EXISTS (subquery) / NOT EXISTS (subquery) have no column, a
+		// default scope neither column nor operator
+		if operator != "" {
+			sql.WriteString(string(operator))
+			sql.WriteByte(' ')
 		}
-		return fmt.Sprintf("%s %s %s", column, operator, raw), cond.Args, nil
+		sql.WriteString(shiftPlaceholders(raw, paramNum-1))
+		return append(args, cond.Args...), nil
 	}
 
 	switch operator {
-	case OpEqual, OpNotEqual, OpGreaterThan, OpGreaterThanOrEqual, OpLessThan, OpLessThanOrEqual:
-		return fmt.Sprintf("%s %s $%d", column, operator, paramNum), []interface{}{value}, nil
-
-	case OpLike, OpILike, OpNotLike:
-		return fmt.Sprintf("%s %s $%d", column, operator, paramNum), []interface{}{value}, nil
-
 	case OpIn, OpNotIn:
 		// Handle IN/NOT IN with array values
 		values, ok := value.([]interface{})
 		if !ok {
-			return "", nil, fmt.Errorf("IN/NOT IN operator requires []interface{} value")
+			return nil, fmt.Errorf("IN/NOT IN operator requires []interface{} value")
 		}
 
-		placeholders := make([]string, len(values))
+		if err := writeColumn(sql, column); err != nil {
+			return nil, err
+		}
+		sql.WriteByte(' ')
+		sql.WriteString(string(operator))
+		sql.WriteString(" (")
 		for i := range values {
-			placeholders[i] = fmt.Sprintf("$%d", paramNum+i)
+			if i > 0 {
+				sql.WriteString(", ")
+			}
+			if cond.ValueSQL != "" {
+				var placeholder strings.Builder
+				writePlaceholder(&placeholder, paramNum+i)
+				fmt.Fprintf(sql, cond.ValueSQL, placeholder.String())
+			} else {
+				writePlaceholder(sql, paramNum+i)
+			}
 		}
+		sql.WriteByte(')')
+		return append(args, values...), nil
 
-		sql := fmt.Sprintf("%s %s (%s)", column, operator, strings.Join(placeholders, ", "))
-		return sql, values, nil
-
-	case OpIsNull:
-		return fmt.Sprintf("%s IS NULL", column), nil, nil
-
-	case OpIsNotNull:
-		return fmt.Sprintf("%s IS NOT NULL", column), nil, nil
+	case OpIsNull, OpIsNotNull, OpIsTrue, OpIsFalse:
+		if err := writeColumn(sql, column); err != nil {
+			return nil, err
+		}
+		sql.WriteByte(' ')
+		sql.WriteString(string(operator))
+		return args, nil
 
-	case OpBetween:
+	case OpBetween, OpNotBetween:
 		// Expect value to be [min, max]
 		values, ok := value.([]interface{})
 		if !ok || len(values) != 2 {
-			return "", nil, fmt.Errorf("BETWEEN operator requires [min, max] array")
+			return nil, fmt.Errorf("%s operator requires [min, max] array", operator)
 		}
 
-		sql := fmt.Sprintf("%s BETWEEN $%d AND $%d", column, paramNum, paramNum+1)
-		return sql, values, nil
+		if err := writeColumn(sql, column); err != nil {
+			return nil, err
+		}
+		sql.WriteByte(' ')
+		sql.WriteString(string(operator))
+		sql.WriteByte(' ')
+		writePlaceholder(sql, paramNum)
+		sql.WriteString(" AND ")
+		writePlaceholder(sql, paramNum+1)
+		return append(args, values...), nil
 
 	case OpExists:
 		// Expect value to be a subquery string
 		subquery, ok := value.(string)
 		if !ok {
-			return "", nil, fmt.Errorf("EXISTS operator requires subquery string")
+			return nil, fmt.Errorf("EXISTS operator requires subquery string")
 		}
 
-		return fmt.Sprintf("EXISTS (%s)", subquery), nil, nil
+		sql.WriteString("EXISTS (")
+		sql.WriteString(subquery)
+		sql.WriteByte(')')
+		return args, nil
+
+	case "":
+		return nil, fmt.Errorf("missing operator")
 
 	default:
-		// PostgreSQL-specific operators (@>, <@, ?, ?|, ?&, &&, ~, ~*, !~, @@, ...)
-		// parameterize like any comparison; PostgreSQL infers the parameter type
-		// from the operator context.
-		if operator == "" {
-			return "", nil, fmt.Errorf("missing operator")
+		// Comparisons, LIKE and PostgreSQL-specific operators (@>, <@, ?, ?|,
+		// ?&, &&, ~, ~*, !~, @@, ...) parameterize alike; PostgreSQL infers
+		// the parameter type from the operator context.
+		if err := writeColumn(sql, column); err != nil {
+			return nil, err
 		}
-		placeholder := fmt.Sprintf("$%d", paramNum)
-		if cond.ValueSQL != "" {
+		sql.WriteByte(' ')
+		sql.WriteString(string(operator))
+		sql.WriteByte(' ')
+		if cond.ValueSQL != "" && operator != OpLike && operator != OpILike && operator != OpNotLike && operator != OpNotILike {
 			// Wrap the placeholder in a SQL expression (e.g. to_tsquery($n)).
-			placeholder = fmt.Sprintf(cond.ValueSQL, placeholder)
+			var placeholder strings.Builder
+			writePlaceholder(&placeholder, paramNum)
+			fmt.Fprintf(sql, cond.ValueSQL, placeholder.String())
+		} else {
+			writePlaceholder(sql, paramNum)
 		}
-		return fmt.Sprintf("%s %s %s", column, operator, placeholder), []interface{}{value}, nil
+		return append(args, value), nil
 	}
 }
 
@@ -268,8 +362,18 @@ func Lte(column string, value interface{}) Condition {
 	}
 }
 
-// In creates an IN condition.
+// anyThreshold is the number of values above which In and NotIn bind one
+// array parameter instead of a placeholder per value.
+const anyThreshold = 100
+
+// In creates an IN condition. More than 100 values of one type are bound as
+// a single array, as InAny does.
 func In(column string, values ...interface{}) Condition {
+	if len(values) > anyThreshold {
+		if array, ok := typedSlice(values); ok {
+			return InAny(column, array)
+		}
+	}
 	return Condition{
 		Column:   column,
 		Operator: OpIn,
@@ -278,8 +382,14 @@ func In(column string, values ...interface{}) Condition {
 	}
 }
 
-// NotIn creates a NOT IN condition.
+// NotIn creates a NOT IN condition. More than 100 values of one type are
+// bound as a single array, as NotInAny does.
 func NotIn(column string, values ...interface{}) Condition {
+	if len(values) > anyThreshold {
+		if array, ok := typedSlice(values); ok {
+			return NotInAny(column, array)
+		}
+	}
 	return Condition{
 		Column:   column,
 		Operator: OpNotIn,
@@ -288,6 +398,52 @@ func NotIn(column string, values ...interface{}) Condition {
 	}
 }
 
+// InAny creates a column = ANY($1) condition, binding values (a slice such
+// as []int64) as one array parameter: any number of values, one
+// placeholder, and the same SQL whatever the count, so plans are reused.
+// Usage: builder.Select[User](qb).Where(builder.InAny("id", ids))
+func InAny(column string, values interface{}) Condition {
+	if list, ok := values.([]interface{}); ok {
+		if array, ok := typedSlice(list); ok {
+			values = array
+		}
+	}
+	return Condition{
+		Column:   column,
+		Operator: OpEqual,
+		Value:    values,
+		ValueSQL: "ANY(%s)",
+		Logic:    LogicAnd,
+	}
+}
+
+// NotInAny creates a column != ALL($1) condition, the NOT IN counterpart of
+// InAny.
+func NotInAny(column string, values interface{}) Condition {
+	cond := InAny(column, values)
+	cond.Operator = OpNotEqual
+	cond.ValueSQL = "ALL(%s)"
+	return cond
+}
+
+// typedSlice converts values to a slice of their common type ([]int64,
+// []string, ...) for binding as a PostgreSQL array. It fails for an empty
+// list, nils and mixed types.
+func typedSlice(values []interface{}) (interface{}, bool) {
+	if len(values) == 0 || values[0] == nil {
+		return nil, false
+	}
+	elemType := reflect.TypeOf(values[0])
+	array := reflect.MakeSlice(reflect.SliceOf(elemType), len(values), len(values))
+	for i, v := range values {
+		if v == nil || reflect.TypeOf(v) != elemType {
+			return nil, false
+		}
+		array.Index(i).Set(reflect.ValueOf(v))
+	}
+	return array.Interface(), true
+}
+
 // Like creates a LIKE condition.
 func Like(column string, pattern string) Condition {
 	return Condition{
@@ -308,6 +464,16 @@ func ILike(column string, pattern string) Condition {
 	}
 }
 
+// NotILike creates a NOT ILIKE condition (case-insensitive).
+func NotILike(column string, pattern string) Condition {
+	return Condition{
+		Column:   column,
+		Operator: OpNotILike,
+		Value:    pattern,
+		Logic:    LogicAnd,
+	}
+}
+
 // IsNull creates an IS NULL condition.
 func IsNull(column string) Condition {
 	return Condition{
@@ -336,6 +502,57 @@ func Between(column string, min, max interface{}) Condition {
 	}
 }
 
+// NotBetween creates a NOT BETWEEN condition.
+func NotBetween(column string, min, max interface{}) Condition {
+	return Condition{
+		Column:   column,
+		Operator: OpNotBetween,
+		Value:    []interface{}{min, max},
+		Logic:    LogicAnd,
+	}
+}
+
+// IsTrue creates an IS TRUE condition, which NULL doesn't satisfy.
+func IsTrue(column string) Condition {
+	return Condition{
+		Column:   column,
+		Operator: OpIsTrue,
+		Logic:    LogicAnd,
+	}
+}
+
+// IsFalse creates an IS FALSE condition, which NULL doesn't satisfy.
+func IsFalse(column string) Condition {
+	return Condition{
+		Column:   column,
+		Operator: OpIsFalse,
+		Logic:    LogicAnd,
+	}
+}
+
+// IsDistinctFrom creates an IS DISTINCT FROM condition: unlike NotEq, a NULL
+// column is distinct from a non-NULL value.
+// Usage: builder.IsDistinctFrom("manager_id", userID)
+func IsDistinctFrom(column string, value interface{}) Condition {
+	return Condition{
+		Column:   column,
+		Operator: OpIsDistinctFrom,
+		Value:    value,
+		Logic:    LogicAnd,
+	}
+}
+
+// IsNotDistinctFrom creates an IS NOT DISTINCT FROM condition, an equality
+// under which NULL matches NULL.
+func IsNotDistinctFrom(column string, value interface{}) Condition {
+	return Condition{
+		Column:   column,
+		Operator: OpIsNotDistinctFrom,
+		Value:    value,
+		Logic:    LogicAnd,
+	}
+}
+
 // Or sets the logic operator to OR for the next condition.
 func Or(cond Condition) Condition {
 	cond.Logic = LogicOr
@@ -355,3 +572,157 @@ func Group(conditions ...Condition) Condition {
 		Logic: LogicAnd,
 	}
 }
+
+// scoped returns where with the table's default scope (see
+// schema.ParseDefaultScopeFromComment) ANDed in front, unless unscoped, and
+// with comparisons on citext columns cast (see citextCasts).
+func scoped(table *schema.TableMetadata, where []Condition, unscoped bool) []Condition {
+	where = citextCasts(table, where)
+	if unscoped || table == nil || table.DefaultScope == "" {
+		return where
+	}
+	conditions := []Condition{{Raw: true, Value: "(" + qualifyScope(table, schema.QuoteReservedIdent(table.Name)) + ")"}}
+	if len(where) > 0 {
+		conditions = append(conditions, Group(where...))
+	}
+	return conditions
+}
+
+// citextCasts returns where with the values compared to the table's citext
+// columns cast to citext. A value bound as text (as pgx does outside the
+// default statement mode, or for the []string of InAny) would otherwise
+// compare as text, case-sensitively. Columns match by name, bare or
+// qualified by the table; where is copied only when a condition changes.
+func citextCasts(table *schema.TableMetadata, where []Condition) []Condition {
+	if table == nil || len(where) == 0 || !slices.ContainsFunc(table.Columns, isCitext) {
+		return where
+	}
+	var out []Condition
+	for i, cond := range where {
+		changed := true
+		switch {
+		case len(cond.Group) > 0:
+			group := citextCasts(table, cond.Group)
+			changed = &group[0] != &cond.Group[0]
+			cond.Group = group
+		case cond.Raw || !citextColumn(table, cond.Column):
+			changed = false
+		case cond.ValueSQL == "" && (cond.Operator == OpIn || cond.Operator == OpNotIn || isComparison(cond.Operator)):
+			cond.ValueSQL = "%s::citext"
+		case cond.ValueSQL == "ANY(%s)" || cond.ValueSQL == "ALL(%s)":
+			cond.ValueSQL = strings.TrimSuffix(cond.ValueSQL, ")") + "::citext[])"
+		default:
+			changed = false
+		}
+		if changed && out == nil {
+			out = slices.Clone(where)
+		}
+		if out != nil {
+			out[i] = cond
+		}
+	}
+	if out == nil {
+		return where
+	}
+	return out
+}
+
+// isComparison reports whether op compares two values of one type.
+func isComparison(op Operator) bool {
+	switch op {
+	case OpEqual, OpNotEqual, OpGreaterThan, OpGreaterThanOrEqual, OpLessThan, OpLessThanOrEqual,
+		OpIsDistinctFrom, OpIsNotDistinctFrom:
+		return true
+	}
+	return false
+}
+
+// isCitext reports whether col is a citext column.
+func isCitext(col schema.ColumnMetadata) bool {
+	return strings.EqualFold(col.SQLType, "citext")
+}
+
+// citextColumn reports whether column names a citext column of table.
+func citextColumn(table *schema.TableMetadata, column string) bool {
+	if qualifier, name, ok := strings.Cut(column, "."); ok {
+		if qualifier != table.Name {
+			return false
+		}
+		column = name
+	}
+	col := table.GetColumnByName(column)
+	return col != nil && isCitext(*col)
+}
+
+// scopeFilter returns the table's default scope, its columns qualified by
+// qualifier, as an AND suffix for the WHERE clauses of relationship and
+// session loaders.
+func scopeFilter(table *schema.TableMetadata, qualifier string) string {
+	if table.DefaultScope == "" {
+		return ""
+	}
+	return " AND (" + qualifyScope(table, qualifier) + ")"
+}
+
+// qualifyScope returns the table's default scope with each bare reference to
+// one of its columns qualified by qualifier, so the scope stays unambiguous
+// next to joined tables. String literals, quoted identifiers, qualified names,
+// casts and function calls are left alone.
+func qualifyScope(table *schema.TableMetadata, qualifier string) string {
+	scope := table.DefaultScope
+	var out strings.Builder
+	out.Grow(len(scope) + 2*len(qualifier))
+	for i := 0; i < len(scope); {
+		c := scope[i]
+		switch {
+		case c == '\'' || c == '"':
+			end := i + 1
+			for end < len(scope) {
+				if scope[end] == c {
+					if end+1 < len(scope) && scope[end+1] == c {
+						end += 2
+						continue
+					}
+					break
+				}
+				end++
+			}
+			end = min(end+1, len(scope))
+			out.WriteString(scope[i:end])
+			i = end
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+			end := i + 1
+			for end < len(scope) && isIdentByte(scope[end]) {
+				end++
+			}
+			word := scope[i:end]
+			before := strings.TrimRight(scope[:i], " \t\n")
+			after := strings.TrimLeft(scope[end:], " \t\n")
+			bare := !strings.HasSuffix(before, ".") && !strings.HasSuffix(before, ":") &&
+				!strings.HasPrefix(after, ".") && !strings.HasPrefix(after, "(")
+			if bare && table.GetColumnByName(word) != nil {
+				out.WriteString(qualifier)
+				out.WriteByte('.')
+			}
+			out.WriteString(word)
+			i = end
+		case c == '$' || c >= '0' && c <= '9':
+			// Placeholders and numbers, so $1 or 1e5 aren't read as names
+			end := i + 1
+			for end < len(scope) && isIdentByte(scope[end]) {
+				end++
+			}
+			out.WriteString(scope[i:end])
+			i = end
+		default:
+			out.WriteByte(c)
+			i++
+		}
+	}
+	return out.String()
+}
+
+// isIdentByte reports whether c may continue an unquoted identifier.
+func isIdentByte(c byte) bool {
+	return c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
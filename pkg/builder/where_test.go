@@ -87,6 +87,41 @@ func TestWhereBuilder_Build(t *testing.T) {
 			expectedSQL:    "WHERE age BETWEEN $1 AND $2",
 			expectedArgLen: 2,
 		},
+		{
+			name: "NOT BETWEEN condition",
+			conditions: []Condition{
+				NotBetween("age", 18, 65),
+			},
+			expectedSQL:    "WHERE age NOT BETWEEN $1 AND $2",
+			expectedArgLen: 2,
+		},
+		{
+			name: "boolean conditions",
+			conditions: []Condition{
+				IsTrue("active"),
+				IsFalse("banned"),
+			},
+			expectedSQL:    "WHERE active IS TRUE AND banned IS FALSE",
+			expectedArgLen: 0,
+		},
+		{
+			name: "IS DISTINCT FROM condition",
+			conditions: []Condition{
+				IsDistinctFrom("manager_id", 7),
+				IsNotDistinctFrom("team_id", nil),
+			},
+			expectedSQL:    "WHERE manager_id IS DISTINCT FROM $1 AND team_id IS NOT DISTINCT FROM $2",
+			expectedArgLen: 2,
+		},
+		{
+			name: "NOT ILIKE condition",
+			conditions: []Condition{
+				NotILike("email", "%@spam.example"),
+				Eq("status", "active"),
+			},
+			expectedSQL:    "WHERE email NOT ILIKE $1 AND status = $2",
+			expectedArgLen: 2,
+		},
 		{
 			name: "NOT condition",
 			conditions: []Condition{
@@ -383,3 +418,110 @@ func TestPlaceholderNumbering(t *testing.T) {
 		}
 	})
 }
+
+func benchmarkConditions() []Condition {
+	return []Condition{
+		Eq("tenant_id", 42),
+		Gt("created_at", "2024-01-01"),
+		In("status", "open", "pending", "review"),
+		Or(Group(IsNull("deleted_at"), Like("name", "a%"))),
+		Between("amount", 10, 100),
+	}
+}
+
+func BenchmarkWhereBuilder(b *testing.B) {
+	conditions := benchmarkConditions()
+	b.ReportAllocs()
+	for b.Loop() {
+		wb := NewWhereBuilder()
+		for _, cond := range conditions {
+			wb.Add(cond)
+		}
+		if _, _, err := wb.Build(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestInAny(t *testing.T) {
+	many := make([]interface{}, anyThreshold+1)
+	for i := range many {
+		many[i] = int64(i)
+	}
+	mixed := append([]interface{}{"a"}, many[1:]...)
+
+	tests := []struct {
+		name     string
+		cond     Condition
+		wantSQL  string
+		wantArgs int
+	}{
+		{"InAny", InAny("id", []int64{1, 2, 3}), "WHERE id = ANY($1)", 1},
+		{"NotInAny", NotInAny("id", []string{"a"}), "WHERE id != ALL($1)", 1},
+		{"InAny interface list", InAny("id", []interface{}{1, 2}), "WHERE id = ANY($1)", 1},
+		{"large In", In("id", many...), "WHERE id = ANY($1)", 1},
+		{"large NotIn", NotIn("id", many...), "WHERE id != ALL($1)", 1},
+		{"large mixed In", In("id", mixed...), "WHERE id IN ($1, $2", anyThreshold + 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wb := NewWhereBuilder()
+			wb.Add(tt.cond)
+			sql, args, err := wb.Build()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(sql, tt.wantSQL) {
+				t.Errorf("SQL = %s, want %s", sql, tt.wantSQL)
+			}
+			if len(args) != tt.wantArgs {
+				t.Errorf("got %d args, want %d", len(args), tt.wantArgs)
+			}
+		})
+	}
+
+	if cond := InAny("id", []interface{}{1, 2}); cond.Value.([]int)[1] != 2 {
+		t.Errorf("InAny should bind a typed slice, got %T", cond.Value)
+	}
+}
+
+// table_name: citext_account
+type CitextAccount struct {
+	ID         int64             `po:"id,primaryKey,bigserial"`
+	Email      string            `po:"email,citext,notNull"`
+	Name       string            `po:"name,text"`
+	Attributes map[string]string `po:"attributes"`
+}
+
+func TestCitextCasts(t *testing.T) {
+	inner := []Condition{In("citext_account.email", "a@x.io", "b@x.io"), Eq("name", "Ann")}
+	q := Select[CitextAccount](New(nil)).
+		Where(Eq("email", "Ann@X.io")).
+		Where(Eq("name", "Ann")).
+		Where(Group(inner...)).
+		Where(InAny("email", []string{"c@x.io"})).
+		Where(Like("email", "%@x.io"))
+
+	sql, args, err := q.ToSQL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "WHERE email = $1::citext AND name = $2 AND (citext_account.email IN ($3::citext, $4::citext) AND name = $5) " +
+		"AND email = ANY($6::citext[]) AND email LIKE $7"
+	if !strings.Contains(sql, want) {
+		t.Errorf("SQL = %s\nwant %s", sql, want)
+	}
+	if len(args) != 7 {
+		t.Errorf("got %d args, want 7", len(args))
+	}
+	if inner[0].ValueSQL != "" || q.where[0].ValueSQL != "" {
+		t.Error("casting must not modify the query's conditions")
+	}
+
+	// Tables without citext columns keep their conditions
+	where := []Condition{Eq("email", "x")}
+	table, _ := registry.GetOrRegister(User{})
+	if got := citextCasts(table, where); &got[0] != &where[0] {
+		t.Error("expected the conditions unchanged")
+	}
+}
@@ -0,0 +1,128 @@
+// Package cache stores query results for builder.SelectQuery.Cached. Entries
+// are tagged with the tables they were read from, and writes through the
+// builders invalidate a table's tag.
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// Cache stores encoded query results.
+type Cache interface {
+	// Get returns the value stored under key, if present and not expired.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores value under key for ttl, tagged with tags.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags ...string) error
+	// Invalidate removes every entry tagged with any of tags.
+	Invalidate(ctx context.Context, tags ...string) error
+}
+
+// Memory is an in-process Cache that evicts the least recently used entry
+// once full. It is safe for concurrent use.
+type Memory struct {
+	maxEntries int
+	now        func() time.Time
+
+	mu      sync.Mutex
+	order   *list.List // front is most recently used
+	entries map[string]*list.Element
+	tags    map[string]map[string]struct{} // tag -> keys
+}
+
+type memoryEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+	tags    []string
+}
+
+// NewMemory creates a Memory cache holding up to maxEntries results. Zero
+// means no limit.
+// Usage: qb := builder.New(db).WithCache(cache.NewMemory(10_000))
+func NewMemory(maxEntries int) *Memory {
+	return &Memory{
+		maxEntries: maxEntries,
+		now:        time.Now,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+		tags:       make(map[string]map[string]struct{}),
+	}
+}
+
+// Get implements Cache.
+func (m *Memory) Get(_ context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	elem, ok := m.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := elem.Value.(*memoryEntry)
+	if !m.now().Before(entry.expires) {
+		m.removeLocked(elem)
+		return nil, false, nil
+	}
+	m.order.MoveToFront(elem)
+	return entry.value, true, nil
+}
+
+// Set implements Cache.
+func (m *Memory) Set(_ context.Context, key string, value []byte, ttl time.Duration, tags ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if elem, ok := m.entries[key]; ok {
+		m.removeLocked(elem)
+	}
+	entry := &memoryEntry{key: key, value: value, expires: m.now().Add(ttl), tags: tags}
+	m.entries[key] = m.order.PushFront(entry)
+	for _, tag := range tags {
+		keys, ok := m.tags[tag]
+		if !ok {
+			keys = make(map[string]struct{})
+			m.tags[tag] = keys
+		}
+		keys[key] = struct{}{}
+	}
+	for m.maxEntries > 0 && m.order.Len() > m.maxEntries {
+		m.removeLocked(m.order.Back())
+	}
+	return nil
+}
+
+// Invalidate implements Cache.
+func (m *Memory) Invalidate(_ context.Context, tags ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, tag := range tags {
+		for key := range m.tags[tag] {
+			if elem, ok := m.entries[key]; ok {
+				m.removeLocked(elem)
+			}
+		}
+		delete(m.tags, tag)
+	}
+	return nil
+}
+
+// Len returns the number of entries, including expired ones not yet removed.
+func (m *Memory) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.order.Len()
+}
+
+func (m *Memory) removeLocked(elem *list.Element) {
+	entry := m.order.Remove(elem).(*memoryEntry)
+	delete(m.entries, entry.key)
+	for _, tag := range entry.tags {
+		if keys, ok := m.tags[tag]; ok {
+			delete(keys, entry.key)
+			if len(keys) == 0 {
+				delete(m.tags, tag)
+			}
+		}
+	}
+}
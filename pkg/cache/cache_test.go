@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryGetSet(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(0, 0)
+	m := NewMemory(0)
+	m.now = func() time.Time { return now }
+
+	if _, ok, _ := m.Get(ctx, "a"); ok {
+		t.Fatal("empty cache reported a hit")
+	}
+	_ = m.Set(ctx, "a", []byte("1"), time.Minute, "users")
+	if got, ok, _ := m.Get(ctx, "a"); !ok || string(got) != "1" {
+		t.Fatalf("Get = %q, %v; want 1, true", got, ok)
+	}
+
+	now = now.Add(time.Minute)
+	if _, ok, _ := m.Get(ctx, "a"); ok {
+		t.Error("expired entry reported a hit")
+	}
+	if m.Len() != 0 {
+		t.Errorf("expired entry not removed, Len = %d", m.Len())
+	}
+}
+
+func TestMemoryEvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory(2)
+	_ = m.Set(ctx, "a", []byte("1"), time.Minute)
+	_ = m.Set(ctx, "b", []byte("2"), time.Minute)
+	_, _, _ = m.Get(ctx, "a") // b is now least recently used
+	_ = m.Set(ctx, "c", []byte("3"), time.Minute)
+
+	for key, want := range map[string]bool{"a": true, "b": false, "c": true} {
+		if _, ok, _ := m.Get(ctx, key); ok != want {
+			t.Errorf("Get(%q) hit = %v, want %v", key, ok, want)
+		}
+	}
+}
+
+func TestMemoryInvalidate(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory(0)
+	_ = m.Set(ctx, "users:1", []byte("1"), time.Minute, "users")
+	_ = m.Set(ctx, "join", []byte("2"), time.Minute, "users", "orders")
+	_ = m.Set(ctx, "orders:1", []byte("3"), time.Minute, "orders")
+
+	_ = m.Invalidate(ctx, "users")
+	for key, want := range map[string]bool{"users:1": false, "join": false, "orders:1": true} {
+		if _, ok, _ := m.Get(ctx, key); ok != want {
+			t.Errorf("Get(%q) hit = %v, want %v", key, ok, want)
+		}
+	}
+	if len(m.tags["users"]) != 0 || len(m.tags["orders"]) != 1 {
+		t.Errorf("tag index not cleaned up: %v", m.tags)
+	}
+}
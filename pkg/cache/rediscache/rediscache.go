@@ -0,0 +1,69 @@
+// Package rediscache is a cache.Cache backed by Redis, kept apart from
+// package cache so only programs that use it depend on go-redis.
+package rediscache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Cache is a cache.Cache backed by Redis, shared by every process using the
+// same server and prefix. Each tag is a set of the keys stored under it. It
+// needs Redis 7 or later.
+type Cache struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// New creates a Redis cache whose keys start with prefix.
+// Usage: qb := builder.New(db).WithCache(rediscache.New(rdb, "orders-api:"))
+func New(client redis.UniversalClient, prefix string) *Cache {
+	return &Cache{client: client, prefix: prefix}
+}
+
+func (r *Cache) tagKey(tag string) string {
+	return r.prefix + "tag:" + tag
+}
+
+// Get implements cache.Cache.
+func (r *Cache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := r.client.Get(ctx, r.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// Set implements cache.Cache. A tag's set lives as long as its longest-lived key.
+func (r *Cache) Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags ...string) error {
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, r.prefix+key, value, ttl)
+		for _, tag := range tags {
+			pipe.SAdd(ctx, r.tagKey(tag), r.prefix+key)
+			pipe.ExpireNX(ctx, r.tagKey(tag), ttl)
+			pipe.ExpireGT(ctx, r.tagKey(tag), ttl)
+		}
+		return nil
+	})
+	return err
+}
+
+// Invalidate implements cache.Cache.
+func (r *Cache) Invalidate(ctx context.Context, tags ...string) error {
+	for _, tag := range tags {
+		keys, err := r.client.SMembers(ctx, r.tagKey(tag)).Result()
+		if err != nil {
+			return err
+		}
+		if err := r.client.Del(ctx, append(keys, r.tagKey(tag))...).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,237 @@
+// Package cdc streams typed row changes of models with a // cdc: true
+// directive, captured by the trigger the migration planner generates for them.
+package cdc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/marshallshelly/pebble-orm/pkg/migration"
+	"github.com/marshallshelly/pebble-orm/pkg/registry"
+	"github.com/marshallshelly/pebble-orm/pkg/runtime"
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+)
+
+// Operation is the kind of change an event records.
+type Operation string
+
+const (
+	Insert Operation = "INSERT"
+	Update Operation = "UPDATE"
+	Delete Operation = "DELETE"
+)
+
+// Event is one captured row change.
+type Event[T any] struct {
+	ID        int64
+	Operation Operation
+	Table     string // Schema-qualified, e.g. public.orders
+	Old       *T     // Row before the change; nil for inserts
+	New       *T     // Row after the change; nil for deletes
+	At        time.Time
+}
+
+// Handler processes an event. Returning an error stops the stream; the event
+// is delivered again when the consumer restarts.
+type Handler[T any] func(ctx context.Context, event Event[T]) error
+
+// Options configures a stream.
+type Options struct {
+	// Consumer names the stream's position in the event log, which is saved
+	// per consumer and table after each handled event. Required.
+	Consumer string
+
+	// BatchSize is the number of events read at a time. Default 100.
+	BatchSize int
+
+	// PollInterval is how often to check for events without a notification,
+	// e.g. ones held back by a long-running transaction. Default 5s.
+	PollInterval time.Duration
+}
+
+// Stream delivers the changes to T's table to fn, in commit order, starting
+// after the consumer's saved position. It blocks until ctx is done or fn
+// fails:
+//
+//	err := cdc.Stream[Order](ctx, db, cdc.Options{Consumer: "search-indexer"},
+//		func(ctx context.Context, e cdc.Event[Order]) error {
+//			if e.Operation == cdc.Delete {
+//				return index.Remove(e.Old.ID)
+//			}
+//			return index.Put(e.New)
+//		})
+//
+// Delivery is at least once: an event is redelivered if the process stops
+// between fn returning and the position being saved, so fn should be
+// idempotent. Events of a transaction are held back until every transaction
+// that started before it has finished.
+func Stream[T any](ctx context.Context, db *runtime.DB, opts Options, fn Handler[T]) error {
+	var model T
+	table, err := registry.GetOrRegister(model)
+	if err != nil {
+		return err
+	}
+	if !table.CDC {
+		return fmt.Errorf("cdc: %s has no // cdc: true directive", table.Name)
+	}
+	if opts.Consumer == "" {
+		return errors.New("cdc: Options.Consumer is required")
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = 5 * time.Second
+	}
+
+	s := &stream[T]{db: db, table: table, name: capturedName(table), opts: opts}
+	return s.run(ctx, fn)
+}
+
+// capturedName returns the table name as the trigger records it.
+func capturedName(table *schema.TableMetadata) string {
+	schemaName := table.Schema
+	if schemaName == "" {
+		schemaName = schema.DefaultSchema
+	}
+	return schemaName + "." + table.Name
+}
+
+type stream[T any] struct {
+	db    *runtime.DB
+	table *schema.TableMetadata
+	name  string
+	opts  Options
+
+	lastTxID, lastID int64
+}
+
+func (s *stream[T]) run(ctx context.Context, fn Handler[T]) error {
+	conn, err := s.db.Pool().Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("cdc: %w", err)
+	}
+	defer conn.Release()
+	if _, err := conn.Exec(ctx, "LISTEN "+migration.CDCChannel); err != nil {
+		return fmt.Errorf("cdc: failed to listen: %w", err)
+	}
+	defer func() { _, _ = conn.Exec(context.Background(), "UNLISTEN "+migration.CDCChannel) }()
+
+	if err := s.loadOffset(ctx); err != nil {
+		return err
+	}
+	for {
+		n, err := s.deliver(ctx, fn)
+		if err != nil {
+			return err
+		}
+		if n == s.opts.BatchSize {
+			continue // more may be waiting
+		}
+
+		waitCtx, cancel := context.WithTimeout(ctx, s.opts.PollInterval)
+		_, err = conn.Conn().WaitForNotification(waitCtx)
+		cancel()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+			return fmt.Errorf("cdc: %w", err)
+		}
+	}
+}
+
+// loadOffset reads the consumer's saved position, starting it at the
+// beginning of the log the first time.
+func (s *stream[T]) loadOffset(ctx context.Context) error {
+	if _, err := s.db.Exec(ctx,
+		"INSERT INTO "+migration.CDCOffsetsTable+" (consumer, table_name) VALUES ($1, $2) ON CONFLICT DO NOTHING",
+		s.opts.Consumer, s.name); err != nil {
+		return fmt.Errorf("cdc: failed to create offset: %w", err)
+	}
+	err := s.db.QueryRow(ctx,
+		"SELECT last_txid, last_id FROM "+migration.CDCOffsetsTable+" WHERE consumer = $1 AND table_name = $2",
+		s.opts.Consumer, s.name).Scan(&s.lastTxID, &s.lastID)
+	if err != nil {
+		return fmt.Errorf("cdc: failed to read offset: %w", err)
+	}
+	return nil
+}
+
+// deliver hands the next batch of events to fn, saving the position after
+// each, and returns how many it read.
+func (s *stream[T]) deliver(ctx context.Context, fn Handler[T]) (int, error) {
+	// Ordering by (txid, id) and reading only transactions older than every
+	// one in progress means no event can later appear behind the position.
+	rows, err := s.db.Query(ctx, `
+		SELECT id, txid, operation, old_row, new_row, created_at
+		FROM `+migration.CDCEventsTable+`
+		WHERE table_name = $1
+		  AND (txid, id) > ($2, $3)
+		  AND txid < pg_snapshot_xmin(pg_current_snapshot())::text::bigint
+		ORDER BY txid, id
+		LIMIT $4`, s.name, s.lastTxID, s.lastID, s.opts.BatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("cdc: failed to read events: %w", err)
+	}
+
+	type record struct {
+		id, txid       int64
+		operation      string
+		oldRow, newRow []byte
+		createdAt      time.Time
+	}
+	var records []record
+	for rows.Next() {
+		var r record
+		if err := rows.Scan(&r.id, &r.txid, &r.operation, &r.oldRow, &r.newRow, &r.createdAt); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("cdc: failed to read events: %w", err)
+		}
+		records = append(records, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("cdc: failed to read events: %w", err)
+	}
+
+	for _, r := range records {
+		event := Event[T]{ID: r.id, Operation: Operation(r.operation), Table: s.name, At: r.createdAt}
+		if event.Old, err = decodeRow[T](s.table, r.oldRow); err != nil {
+			return 0, fmt.Errorf("cdc: event %d: %w", r.id, err)
+		}
+		if event.New, err = decodeRow[T](s.table, r.newRow); err != nil {
+			return 0, fmt.Errorf("cdc: event %d: %w", r.id, err)
+		}
+		if err := fn(ctx, event); err != nil {
+			return 0, err
+		}
+		if err := s.saveOffset(ctx, r.txid, r.id); err != nil {
+			return 0, err
+		}
+	}
+	return len(records), nil
+}
+
+func (s *stream[T]) saveOffset(ctx context.Context, txid, id int64) error {
+	if _, err := s.db.Exec(ctx,
+		"UPDATE "+migration.CDCOffsetsTable+" SET last_txid = $3, last_id = $4, updated_at = now() WHERE consumer = $1 AND table_name = $2",
+		s.opts.Consumer, s.name, txid, id); err != nil {
+		return fmt.Errorf("cdc: failed to save offset: %w", err)
+	}
+	s.lastTxID, s.lastID = txid, id
+	return nil
+}
+
+// Prune deletes captured events older than age, returning how many it
+// deleted. Consumers that haven't read them yet miss them.
+func Prune(ctx context.Context, db *runtime.DB, age time.Duration) (int64, error) {
+	n, err := db.Exec(ctx,
+		"DELETE FROM "+migration.CDCEventsTable+" WHERE created_at < now() - $1::interval", age)
+	if err != nil {
+		return 0, fmt.Errorf("cdc: failed to prune events: %w", err)
+	}
+	return n, nil
+}
@@ -0,0 +1,128 @@
+package cdc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/marshallshelly/pebble-orm/pkg/builder"
+	"github.com/marshallshelly/pebble-orm/pkg/migration"
+	"github.com/marshallshelly/pebble-orm/pkg/pebbletest"
+	"github.com/marshallshelly/pebble-orm/pkg/registry"
+)
+
+// table_name: cdc_orders
+// cdc: true
+type Order struct {
+	ID        int64      `po:"id,primaryKey,bigserial"`
+	Status    string     `po:"status,text,notNull"`
+	Total     float64    `po:"total,numeric(10,2),notNull"`
+	Note      *string    `po:"note,text"`
+	Payload   []byte     `po:"payload,bytea"`
+	PlacedOn  time.Time  `po:"placed_on,date,notNull"`
+	ShippedAt *time.Time `po:"shipped_at,timestamptz"`
+}
+
+func TestDecodeRow(t *testing.T) {
+	table, err := registry.GetOrRegister(Order{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !table.CDC {
+		t.Fatal("expected the cdc directive to be parsed")
+	}
+	row := []byte(`{"id": 7, "status": "shipped", "total": 19.90, "note": null, "payload": "\\x0aff",
+		"placed_on": "2026-03-01", "shipped_at": "2026-03-02T10:30:00.5+00:00", "extra": 1}`)
+
+	order, err := decodeRow[Order](table, row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.ID != 7 || order.Status != "shipped" || order.Total != 19.90 || order.Note != nil {
+		t.Errorf("unexpected order %+v", order)
+	}
+	if len(order.Payload) != 2 || order.Payload[0] != 0x0a || order.Payload[1] != 0xff {
+		t.Errorf("expected bytea to decode from hex, got %v", order.Payload)
+	}
+	if !order.PlacedOn.Equal(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected date %v", order.PlacedOn)
+	}
+	if order.ShippedAt == nil || !order.ShippedAt.Equal(time.Date(2026, 3, 2, 10, 30, 0, 5e8, time.UTC)) {
+		t.Errorf("unexpected timestamp %v", order.ShippedAt)
+	}
+
+	if order, err := decodeRow[Order](table, nil); order != nil || err != nil {
+		t.Errorf("expected a nil row to decode to nil, got %v, %v", order, err)
+	}
+	if _, err := decodeRow[Order](table, []byte(`{"placed_on": "yesterday"}`)); err == nil {
+		t.Error("expected an error for an unparseable date")
+	}
+}
+
+func TestStream(t *testing.T) {
+	db := pebbletest.NewTestDB(t, Order{})
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if _, err := db.Exec(ctx, "TRUNCATE "+migration.CDCEventsTable+", "+migration.CDCOffsetsTable); err != nil {
+		t.Fatal(err)
+	}
+
+	qb := builder.New(db)
+	order, err := builder.Insert[Order](qb).Values(Order{Status: "placed", Total: 10, PlacedOn: time.Now()}).ExecReturning(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := order[0].ID
+	if _, err := builder.Update[Order](qb).Set("status", "shipped").Where(builder.Eq("id", id)).Exec(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := builder.Delete[Order](qb).Where(builder.Eq("id", id)).Exec(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	var events []Event[Order]
+	done := errors.New("done")
+	err = Stream[Order](ctx, db, Options{Consumer: "test", PollInterval: 100 * time.Millisecond},
+		func(_ context.Context, e Event[Order]) error {
+			events = append(events, e)
+			if len(events) == 3 {
+				return done
+			}
+			return nil
+		})
+	if !errors.Is(err, done) {
+		t.Fatalf("expected the stream to stop after 3 events, got %v", err)
+	}
+
+	if events[0].Operation != Insert || events[0].Old != nil || events[0].New.Status != "placed" {
+		t.Errorf("unexpected insert event %+v", events[0])
+	}
+	if events[1].Operation != Update || events[1].Old.Status != "placed" || events[1].New.Status != "shipped" {
+		t.Errorf("unexpected update event %+v", events[1])
+	}
+	if events[2].Operation != Delete || events[2].Old.ID != id || events[2].New != nil {
+		t.Errorf("unexpected delete event %+v", events[2])
+	}
+
+	// The third event's position wasn't saved, so it is delivered again.
+	err = Stream[Order](ctx, db, Options{Consumer: "test"}, func(_ context.Context, e Event[Order]) error {
+		if e.ID != events[2].ID {
+			t.Errorf("expected event %d to be redelivered, got %d", events[2].ID, e.ID)
+		}
+		return done
+	})
+	if !errors.Is(err, done) {
+		t.Fatalf("expected a redelivery, got %v", err)
+	}
+}
+
+func TestStreamRequiresCDC(t *testing.T) {
+	type Plain struct {
+		ID int64 `po:"id,primaryKey,bigserial"`
+	}
+	err := Stream[Plain](context.Background(), nil, Options{Consumer: "test"}, nil)
+	if err == nil {
+		t.Error("expected an error for a model without the cdc directive")
+	}
+}
@@ -0,0 +1,100 @@
+package cdc
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+)
+
+// decodeRow decodes a row captured with to_jsonb into a T, matching keys to
+// column names. A nil row decodes to nil.
+func decodeRow[T any](table *schema.TableMetadata, row []byte) (*T, error) {
+	if row == nil {
+		return nil, nil
+	}
+	var columns map[string]json.RawMessage
+	if err := json.Unmarshal(row, &columns); err != nil {
+		return nil, err
+	}
+
+	v := new(T)
+	rv := reflect.ValueOf(v).Elem()
+	for _, col := range table.Columns {
+		value, ok := columns[col.Name]
+		if !ok || string(value) == "null" {
+			continue
+		}
+		field := fieldByPath(rv, col.GoField)
+		if !field.IsValid() || !field.CanSet() {
+			continue
+		}
+		if err := decodeValue(field, value); err != nil {
+			return nil, fmt.Errorf("column %s: %w", col.Name, err)
+		}
+	}
+	return v, nil
+}
+
+// fieldByPath returns the field at a dotted path of embedded struct fields.
+func fieldByPath(v reflect.Value, path string) reflect.Value {
+	for name := range strings.SplitSeq(path, ".") {
+		v = v.FieldByName(name)
+		if !v.IsValid() {
+			return v
+		}
+	}
+	return v
+}
+
+var (
+	timeType  = reflect.TypeFor[time.Time]()
+	bytesType = reflect.TypeFor[[]byte]()
+)
+
+// timeLayouts are the JSON renderings of timestamptz, timestamp, date and time.
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999", "2006-01-02", "15:04:05.999999999"}
+
+// decodeValue sets field from a JSON value, handling the types whose JSON
+// form from PostgreSQL differs from encoding/json's.
+func decodeValue(field reflect.Value, value json.RawMessage) error {
+	if field.Kind() == reflect.Pointer {
+		p := reflect.New(field.Type().Elem())
+		if err := decodeValue(p.Elem(), value); err != nil {
+			return err
+		}
+		field.Set(p)
+		return nil
+	}
+
+	switch field.Type() {
+	case timeType:
+		var s string
+		if err := json.Unmarshal(value, &s); err != nil {
+			return err
+		}
+		for _, layout := range timeLayouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				field.Set(reflect.ValueOf(t))
+				return nil
+			}
+		}
+		return fmt.Errorf("cannot parse time %q", s)
+	case bytesType:
+		var s string
+		if err := json.Unmarshal(value, &s); err != nil {
+			return err
+		}
+		b, err := hex.DecodeString(strings.TrimPrefix(s, `\x`))
+		if err != nil {
+			return err
+		}
+		field.SetBytes(b)
+		return nil
+	}
+	return json.Unmarshal(value, field.Addr().Interface())
+}
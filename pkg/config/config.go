@@ -0,0 +1,227 @@
+// Package config reads pebble.yaml, the project configuration shared by the
+// pebble CLI and runtime.ConnectFromConfig:
+//
+//	default_environment: development
+//	environments:
+//	  development:
+//	    database_url: postgres://localhost/app_dev
+//	  production:
+//	    database_url: ${DATABASE_URL}
+//	    max_conns: 20
+//	migrations_dir: ./migrations
+//	lock_timeout: 30s
+//	models: ./internal/models
+//	naming:
+//	  tables: plural_snake_case
+//	  columns: camelCase
+//	planner:
+//	  disallow_drops: true
+//	  require_drop_confirmation: true
+//
+// ${VAR} references in database URLs are expanded from the environment, so
+// secrets stay out of the file. Relative paths are relative to the file.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the name of the configuration file.
+const FileName = "pebble.yaml"
+
+// ErrNotFound is returned by Find and LoadDefault when there is no
+// configuration file.
+var ErrNotFound = errors.New("no " + FileName + " found")
+
+// Config is the contents of a pebble.yaml file.
+type Config struct {
+	// DefaultEnvironment is used when no environment is named and PEBBLE_ENV
+	// is unset. Defaults to "development".
+	DefaultEnvironment string `yaml:"default_environment"`
+	// Environments holds the database of each environment by name.
+	Environments map[string]Environment `yaml:"environments"`
+	// MigrationsDir is the directory of migration files.
+	MigrationsDir string `yaml:"migrations_dir"`
+	// LockTimeout is how long migrations wait for another process holding
+	// the migration lock. Zero waits indefinitely.
+	LockTimeout time.Duration `yaml:"lock_timeout"`
+	// Models is the file or directory of model definitions.
+	Models string `yaml:"models"`
+	// Naming sets how struct and field names become table and column names.
+	Naming Naming `yaml:"naming"`
+	// Planner sets the safety options of generated migrations.
+	Planner Planner `yaml:"planner"`
+
+	// Path is the file the configuration was read from.
+	Path string `yaml:"-"`
+}
+
+// Environment is the database of one environment.
+type Environment struct {
+	DatabaseURL string `yaml:"database_url"`
+	MaxConns    int32  `yaml:"max_conns"`
+	MinConns    int32  `yaml:"min_conns"`
+}
+
+// Naming sets the naming conventions of models without explicit names:
+// snake_case (the default) or camelCase, and for tables also
+// plural_snake_case or plural_camelCase (User → users).
+type Naming struct {
+	Tables  string `yaml:"tables"`
+	Columns string `yaml:"columns"`
+}
+
+// namingStrategies maps the accepted values of the naming options to their
+// strategies.
+var namingStrategies = map[string]schema.NamingStrategy{
+	"":           schema.SnakeCase,
+	"snake_case": schema.SnakeCase,
+	"camelCase":  schema.CamelCase,
+}
+
+// pluralStrategies are the naming values accepted for tables only.
+var pluralStrategies = map[string]schema.NamingStrategy{
+	"plural_snake_case": schema.PluralSnakeCase,
+	"plural_camelCase":  schema.Pluralize(schema.CamelCase),
+}
+
+// IsSet reports whether the file sets either naming option, so programs
+// leave the current strategy alone unless it asks for one.
+func (n Naming) IsSet() bool {
+	return n.Tables != "" || n.Columns != ""
+}
+
+// Strategy returns the naming strategy to pass to schema.SetNamingStrategy,
+// which must happen before models are registered.
+// Usage: if cfg.Naming.IsSet() { schema.SetNamingStrategy(cfg.Naming.Strategy()) }
+func (n Naming) Strategy() schema.NamingStrategy {
+	tables, ok := pluralStrategies[n.Tables]
+	if !ok {
+		tables = namingStrategies[n.Tables]
+	}
+	return schema.Naming{Tables: tables, Columns: namingStrategies[n.Columns]}
+}
+
+// Planner sets the options of generated migrations, as the pebble generate
+// flags of the same names do.
+type Planner struct {
+	// IfNotExists adds IF NOT EXISTS to CREATE TABLE. Defaults to true.
+	IfNotExists             *bool `yaml:"if_not_exists"`
+	DisallowDrops           bool  `yaml:"disallow_drops"`
+	RequireDropConfirmation bool  `yaml:"require_drop_confirmation"`
+	BatchedTypeChanges      bool  `yaml:"batched_type_changes"`
+}
+
+// Load reads the configuration file at path.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	cfg.Path = path
+
+	for name, env := range cfg.Environments {
+		env.DatabaseURL = os.ExpandEnv(env.DatabaseURL)
+		cfg.Environments[name] = env
+	}
+	dir := filepath.Dir(path)
+	for _, p := range []*string{&cfg.MigrationsDir, &cfg.Models} {
+		if *p != "" && !filepath.IsAbs(*p) {
+			*p = filepath.Join(dir, *p)
+		}
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+func (c *Config) validate() error {
+	if namingStrategies[c.Naming.Tables] == nil && pluralStrategies[c.Naming.Tables] == nil {
+		return fmt.Errorf("naming.tables: unknown strategy %q", c.Naming.Tables)
+	}
+	if namingStrategies[c.Naming.Columns] == nil {
+		return fmt.Errorf("naming.columns: unknown strategy %q", c.Naming.Columns)
+	}
+	if c.DefaultEnvironment != "" {
+		if _, ok := c.Environments[c.DefaultEnvironment]; !ok {
+			return fmt.Errorf("default_environment: no environment %q", c.DefaultEnvironment)
+		}
+	}
+	return nil
+}
+
+// Find returns the path of the configuration file in dir or the nearest of
+// its parents.
+func Find(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		path := filepath.Join(dir, FileName)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", ErrNotFound
+		}
+		dir = parent
+	}
+}
+
+// LoadDefault loads the file named by PEBBLE_CONFIG, or else the one Find
+// finds from the working directory.
+func LoadDefault() (*Config, error) {
+	path := os.Getenv("PEBBLE_CONFIG")
+	if path == "" {
+		var err error
+		if path, err = Find("."); err != nil {
+			return nil, err
+		}
+	}
+	return Load(path)
+}
+
+// EnvironmentName resolves the environment to use: name if given, else
+// PEBBLE_ENV, else DefaultEnvironment, else "development".
+func (c *Config) EnvironmentName(name string) string {
+	switch {
+	case name != "":
+		return name
+	case os.Getenv("PEBBLE_ENV") != "":
+		return os.Getenv("PEBBLE_ENV")
+	case c.DefaultEnvironment != "":
+		return c.DefaultEnvironment
+	}
+	return "development"
+}
+
+// Environment returns the environment resolved by EnvironmentName.
+func (c *Config) Environment(name string) (Environment, error) {
+	name = c.EnvironmentName(name)
+	env, ok := c.Environments[name]
+	if !ok {
+		return Environment{}, fmt.Errorf("%s: no environment %q (have %v)", c.Path, name, slices.Sorted(maps.Keys(c.Environments)))
+	}
+	if env.DatabaseURL == "" {
+		return Environment{}, fmt.Errorf("%s: environment %q has no database_url", c.Path, name)
+	}
+	return env, nil
+}
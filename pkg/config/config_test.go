@@ -0,0 +1,132 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, dir, contents string) string {
+	t.Helper()
+	path := filepath.Join(dir, FileName)
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	path := writeConfig(t, dir, `
+default_environment: development
+environments:
+  development:
+    database_url: postgres://localhost/app_dev
+  production:
+    database_url: postgres://app:${DB_PASSWORD}@db/app
+    max_conns: 20
+migrations_dir: db/migrations
+lock_timeout: 1m
+models: ./internal/models
+naming:
+  tables: plural_snake_case
+  columns: camelCase
+planner:
+  if_not_exists: false
+  disallow_drops: true
+`)
+	t.Setenv("DB_PASSWORD", "s3cret")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.MigrationsDir != filepath.Join(dir, "db/migrations") || cfg.Models != filepath.Join(dir, "internal/models") {
+		t.Errorf("paths not relative to the file: %q, %q", cfg.MigrationsDir, cfg.Models)
+	}
+	if cfg.LockTimeout != time.Minute {
+		t.Errorf("lock timeout %v, want 1m", cfg.LockTimeout)
+	}
+	if ns := cfg.Naming.Strategy(); !cfg.Naming.IsSet() || ns.TableName("OrderItem") != "order_items" || ns.ColumnName("CreatedAt") != "createdAt" {
+		t.Errorf("unexpected naming %+v", cfg.Naming)
+	}
+	if (Naming{}).IsSet() {
+		t.Error("expected empty naming options to be unset")
+	}
+	if p := cfg.Planner; p.IfNotExists == nil || *p.IfNotExists || !p.DisallowDrops {
+		t.Errorf("unexpected planner options %+v", p)
+	}
+
+	env, err := cfg.Environment("production")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if env.DatabaseURL != "postgres://app:s3cret@db/app" || env.MaxConns != 20 {
+		t.Errorf("unexpected environment %+v", env)
+	}
+}
+
+func TestEnvironment(t *testing.T) {
+	cfg := &Config{Path: FileName, Environments: map[string]Environment{
+		"development": {DatabaseURL: "postgres://dev"},
+		"staging":     {DatabaseURL: "postgres://staging"},
+		"empty":       {},
+	}}
+
+	t.Setenv("PEBBLE_ENV", "")
+	if env, err := cfg.Environment(""); err != nil || env.DatabaseURL != "postgres://dev" {
+		t.Errorf("got %+v, %v; want development by default", env, err)
+	}
+	t.Setenv("PEBBLE_ENV", "staging")
+	if env, err := cfg.Environment(""); err != nil || env.DatabaseURL != "postgres://staging" {
+		t.Errorf("got %+v, %v; want PEBBLE_ENV", env, err)
+	}
+	if env, err := cfg.Environment("development"); err != nil || env.DatabaseURL != "postgres://dev" {
+		t.Errorf("got %+v, %v; want the named environment first", env, err)
+	}
+	if _, err := cfg.Environment("production"); err == nil || !strings.Contains(err.Error(), "have [development empty staging]") {
+		t.Errorf("got %v, want an unknown environment error listing the others", err)
+	}
+	if _, err := cfg.Environment("empty"); err == nil {
+		t.Error("expected an error for an environment without a database_url")
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	tests := map[string]string{
+		"naming":      "naming:\n  tables: kebab-case\n",
+		"plural":      "naming:\n  columns: plural_snake_case\n",
+		"default env": "default_environment: prod\n",
+		"yaml":        "environments: [\n",
+	}
+	for name, contents := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Load(writeConfig(t, t.TempDir(), contents)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestFind(t *testing.T) {
+	root := t.TempDir()
+	path := writeConfig(t, root, "models: .\n")
+	nested := filepath.Join(root, "cmd", "api")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Find(nested)
+	if err != nil || got != path {
+		t.Errorf("got %q, %v; want %q", got, err, path)
+	}
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Find(nested); !errors.Is(err, ErrNotFound) {
+		t.Errorf("got %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,354 @@
+// Package factory builds and inserts test data from per-model definitions.
+package factory
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/marshallshelly/pebble-orm/pkg/builder"
+	"github.com/marshallshelly/pebble-orm/pkg/registry"
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+)
+
+// definition is a registered factory for one model type.
+type definition struct {
+	mu    sync.Mutex
+	seq   int
+	build func(f *Faker) reflect.Value
+	// insert inserts a *T and returns the row as inserted, with generated values.
+	insert func(tx *builder.Tx, v reflect.Value) (reflect.Value, error)
+}
+
+var (
+	mu          sync.RWMutex
+	definitions = make(map[reflect.Type]*definition)
+)
+
+// Define registers the factory for model T. fn returns a new T for each
+// object built, using f for values that must differ between objects:
+//
+//	factory.Define[User](func(f *factory.Faker) User {
+//		return User{Name: f.Name(), Email: f.Email()}
+//	})
+//
+// Defining T again replaces its factory.
+func Define[T any](fn func(f *Faker) T) {
+	mu.Lock()
+	defer mu.Unlock()
+	definitions[reflect.TypeFor[T]()] = &definition{
+		build: func(f *Faker) reflect.Value {
+			v := reflect.New(reflect.TypeFor[T]())
+			v.Elem().Set(reflect.ValueOf(fn(f)))
+			return v
+		},
+		insert: func(tx *builder.Tx, v reflect.Value) (reflect.Value, error) {
+			rows, err := builder.TxInsert[T](tx).Values(*v.Interface().(*T)).ExecReturning()
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			inserted := reflect.New(reflect.TypeFor[T]())
+			inserted.Elem().Set(reflect.ValueOf(rows[0]))
+			return inserted, nil
+		},
+	}
+}
+
+// Option customizes the objects Build and Create produce.
+type Option interface {
+	apply(*options)
+}
+
+type options struct {
+	fields  Fields
+	funcs   []func(v reflect.Value)
+	hasMany []hasMany
+}
+
+func (o *options) merge(opts []Option) *options {
+	for _, opt := range opts {
+		opt.apply(o)
+	}
+	return o
+}
+
+// Fields overrides field values by Go field name:
+//
+//	factory.Create[User](ctx, db, factory.Fields{"Name": "Alice"})
+type Fields map[string]any
+
+func (f Fields) apply(o *options) {
+	if o.fields == nil {
+		o.fields = make(Fields, len(f))
+	}
+	for name, value := range f {
+		o.fields[name] = value
+	}
+}
+
+type withFunc[T any] func(*T)
+
+func (fn withFunc[T]) apply(o *options) {
+	o.funcs = append(o.funcs, func(v reflect.Value) {
+		if p, ok := v.Interface().(*T); ok {
+			fn(p)
+		}
+	})
+}
+
+// With modifies the object after it is built and Fields are applied.
+func With[T any](fn func(*T)) Option {
+	return withFunc[T](fn)
+}
+
+type hasMany struct {
+	field string
+	n     int
+	opts  []Option
+}
+
+func (h hasMany) apply(o *options) {
+	o.hasMany = append(o.hasMany, h)
+}
+
+// WithMany also creates n objects for a hasMany relationship field, from
+// the factory of its element type with its foreign key set to the new row,
+// and assigns them to the field:
+//
+//	author, err := factory.Create[Author](ctx, db, factory.WithMany("Books", 3))
+//
+// opts apply to each related object. WithMany is ignored by Build.
+func WithMany(field string, n int, opts ...Option) Option {
+	return hasMany{field: field, n: n, opts: opts}
+}
+
+// Build returns a new T from its factory without inserting it.
+func Build[T any](opts ...Option) (T, error) {
+	var zero T
+	v, err := build(reflect.TypeFor[T](), new(options).merge(opts))
+	if err != nil {
+		return zero, err
+	}
+	return *v.Interface().(*T), nil
+}
+
+// Create builds a T and inserts it, in a transaction with any related
+// objects it needs. A belongsTo relationship with a NOT NULL foreign key left
+// zero is filled by creating the parent from its factory. It returns the row
+// as inserted, including values the database generated.
+func Create[T any](ctx context.Context, db *builder.DB, opts ...Option) (T, error) {
+	created, err := CreateMany[T](ctx, db, 1, opts...)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return created[0], nil
+}
+
+// CreateMany creates n objects like Create, in one transaction.
+func CreateMany[T any](ctx context.Context, db *builder.DB, n int, opts ...Option) ([]T, error) {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	o := new(options).merge(opts)
+	created := make([]T, 0, n)
+	for range n {
+		v, err := create(tx, reflect.TypeFor[T](), o)
+		if err != nil {
+			return nil, err
+		}
+		created = append(created, *v.Interface().(*T))
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+func lookup(typ reflect.Type) (*definition, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	def, ok := definitions[typ]
+	if !ok {
+		return nil, fmt.Errorf("factory: no factory defined for %s", typ)
+	}
+	return def, nil
+}
+
+// build returns a pointer to a new object of type typ with opts applied.
+func build(typ reflect.Type, opts *options) (reflect.Value, error) {
+	def, err := lookup(typ)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	def.mu.Lock()
+	def.seq++
+	seq := def.seq
+	def.mu.Unlock()
+
+	v := def.build(newFaker(seq))
+	for name, value := range opts.fields {
+		field := v.Elem().FieldByName(name)
+		if !field.IsValid() {
+			return reflect.Value{}, fmt.Errorf("factory: %s has no field %s", typ, name)
+		}
+		if err := assign(field, value); err != nil {
+			return reflect.Value{}, fmt.Errorf("factory: %s.%s: %w", typ, name, err)
+		}
+	}
+	for _, fn := range opts.funcs {
+		fn(v)
+	}
+	return v, nil
+}
+
+// create builds an object, creates the parents it needs, inserts it and then
+// creates its WithMany children.
+func create(tx *builder.Tx, typ reflect.Type, opts *options) (reflect.Value, error) {
+	def, err := lookup(typ)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	table, err := registry.GetOrRegister(reflect.New(typ).Elem().Interface())
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	v, err := build(typ, opts)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+
+	for _, rel := range table.GetRelationshipsByType(schema.BelongsTo) {
+		if err := createParent(tx, table, rel, v.Elem()); err != nil {
+			return reflect.Value{}, err
+		}
+	}
+
+	inserted, err := def.insert(tx, v)
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("factory: failed to insert %s: %w", typ, err)
+	}
+	// Keep relationship fields, which aren't columns, from the built object.
+	for _, rel := range table.Relationships {
+		inserted.Elem().FieldByName(rel.SourceField).Set(v.Elem().FieldByName(rel.SourceField))
+	}
+
+	for _, spec := range opts.hasMany {
+		if err := createChildren(tx, table, spec, inserted.Elem()); err != nil {
+			return reflect.Value{}, err
+		}
+	}
+	return inserted, nil
+}
+
+// createParent creates the parent of a belongsTo relationship when the
+// object's foreign key is NOT NULL and zero, and sets the foreign key and
+// relationship field.
+func createParent(tx *builder.Tx, table *schema.TableMetadata, rel schema.RelationshipMetadata, v reflect.Value) error {
+	col := table.GetColumnByName(rel.ForeignKey)
+	if col == nil || col.Nullable {
+		return nil
+	}
+	fk := v.FieldByName(col.GoField)
+	if !fk.IsValid() || !fk.IsZero() || rel.TargetType == nil {
+		return nil
+	}
+	if _, err := lookup(rel.TargetType); err != nil {
+		return nil // no factory: leave the foreign key to the caller
+	}
+
+	parent, err := create(tx, rel.TargetType, new(options))
+	if err != nil {
+		return err
+	}
+	parentTable, err := registry.Get(rel.TargetType)
+	if err != nil {
+		return err
+	}
+	ref := parentTable.GetColumnByName(rel.References)
+	if ref == nil {
+		return fmt.Errorf("factory: %s has no column %s", rel.TargetType, rel.References)
+	}
+	if err := assign(fk, parent.Elem().FieldByName(ref.GoField).Interface()); err != nil {
+		return fmt.Errorf("factory: %s.%s: %w", table.Name, col.GoField, err)
+	}
+	return assign(v.FieldByName(rel.SourceField), parent.Interface())
+}
+
+// createChildren creates the objects of a WithMany relationship and assigns
+// them to the field.
+func createChildren(tx *builder.Tx, table *schema.TableMetadata, spec hasMany, v reflect.Value) error {
+	rel := table.GetRelationship(spec.field)
+	if rel == nil || rel.Type != schema.HasMany || rel.TargetType == nil {
+		return fmt.Errorf("factory: %s has no hasMany relationship %s", table.Name, spec.field)
+	}
+	ref := table.GetColumnByName(rel.References)
+	if ref == nil {
+		return fmt.Errorf("factory: %s has no column %s", table.Name, rel.References)
+	}
+	childTable, err := registry.GetOrRegister(reflect.New(rel.TargetType).Elem().Interface())
+	if err != nil {
+		return err
+	}
+	fk := childTable.GetColumnByName(rel.ForeignKey)
+	if fk == nil {
+		return fmt.Errorf("factory: %s has no column %s", childTable.Name, rel.ForeignKey)
+	}
+
+	opts := new(options).merge(spec.opts)
+	opts.merge([]Option{Fields{fk.GoField: v.FieldByName(ref.GoField).Interface()}})
+
+	field := v.FieldByName(spec.field)
+	children := reflect.MakeSlice(field.Type(), 0, spec.n)
+	for range spec.n {
+		child, err := create(tx, rel.TargetType, opts)
+		if err != nil {
+			return err
+		}
+		if field.Type().Elem().Kind() == reflect.Pointer {
+			children = reflect.Append(children, child)
+		} else {
+			children = reflect.Append(children, child.Elem())
+		}
+	}
+	field.Set(children)
+	return nil
+}
+
+// assign sets dst to value, converting between compatible types and taking
+// the address or dereferencing pointers as needed.
+func assign(dst reflect.Value, value any) error {
+	if value == nil {
+		dst.SetZero()
+		return nil
+	}
+	src := reflect.ValueOf(value)
+	for src.Kind() == reflect.Pointer && dst.Kind() != reflect.Pointer {
+		if src.IsNil() {
+			dst.SetZero()
+			return nil
+		}
+		src = src.Elem()
+	}
+	if dst.Kind() == reflect.Pointer && src.Kind() != reflect.Pointer {
+		p := reflect.New(dst.Type().Elem())
+		if err := assign(p.Elem(), src.Interface()); err != nil {
+			return err
+		}
+		dst.Set(p)
+		return nil
+	}
+	switch {
+	case src.Type().AssignableTo(dst.Type()):
+		dst.Set(src)
+	case src.Type().ConvertibleTo(dst.Type()) && (dst.Kind() == reflect.String) == (src.Kind() == reflect.String):
+		dst.Set(src.Convert(dst.Type()))
+	default:
+		return fmt.Errorf("cannot assign %s to %s", src.Type(), dst.Type())
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package factory
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/marshallshelly/pebble-orm/pkg/builder"
+	"github.com/marshallshelly/pebble-orm/pkg/pebbletest"
+)
+
+// table_name: factory_authors
+type Author struct {
+	ID    int64  `po:"id,primaryKey,bigserial"`
+	Name  string `po:"name,text,notNull"`
+	Email string `po:"email,text,unique,notNull"`
+	Books []Book `po:"-,hasMany,foreignKey(author_id),references(id)"`
+}
+
+// table_name: factory_books
+type Book struct {
+	ID       int64   `po:"id,primaryKey,bigserial"`
+	Title    string  `po:"title,text,notNull"`
+	Pages    int     `po:"pages,integer,notNull"`
+	AuthorID int64   `po:"author_id,bigint,notNull,fk:factory_authors(id)"`
+	Author   *Author `po:"-,belongsTo,foreignKey(author_id),references(id)"`
+}
+
+func init() {
+	Define[Author](func(f *Faker) Author {
+		return Author{Name: f.Name(), Email: f.Email()}
+	})
+	Define[Book](func(f *Faker) Book {
+		return Book{Title: f.Sentence(3), Pages: f.Int(50, 500)}
+	})
+}
+
+func TestBuild(t *testing.T) {
+	a, err := Build[Author]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Build[Author]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Name == "" || !strings.HasSuffix(a.Email, "@example.com") {
+		t.Errorf("expected generated values, got %+v", a)
+	}
+	if a.Email == b.Email {
+		t.Errorf("expected unique emails, both got %s", a.Email)
+	}
+
+	c, err := Build[Author](Fields{"Name": "Alice"}, With(func(a *Author) { a.Email = "alice@example.com" }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "Alice" || c.Email != "alice@example.com" {
+		t.Errorf("expected overrides to apply, got %+v", c)
+	}
+
+	book, err := Build[Book](Fields{"AuthorID": 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if book.AuthorID != 7 {
+		t.Errorf("expected an int override to convert to int64, got %d", book.AuthorID)
+	}
+}
+
+func TestBuildErrors(t *testing.T) {
+	type Undefined struct{ ID int }
+	if _, err := Build[Undefined](); err == nil || !strings.Contains(err.Error(), "no factory defined") {
+		t.Errorf("expected a missing factory error, got %v", err)
+	}
+	if _, err := Build[Author](Fields{"Nickname": "al"}); err == nil || !strings.Contains(err.Error(), "no field Nickname") {
+		t.Errorf("expected an unknown field error, got %v", err)
+	}
+	if _, err := Build[Author](Fields{"Name": 42}); err == nil {
+		t.Error("expected an error assigning an int to a string field")
+	}
+}
+
+func TestFakerRepeatable(t *testing.T) {
+	a, b := newFaker(3), newFaker(3)
+	if a.Name() != b.Name() || a.Int(0, 1000) != b.Int(0, 1000) {
+		t.Error("expected fakers with the same sequence number to generate the same values")
+	}
+	if got := newFaker(1).Sentence(2); !strings.HasSuffix(got, ".") || strings.ToUpper(got[:1]) != got[:1] {
+		t.Errorf("unexpected sentence %q", got)
+	}
+}
+
+func TestCreate(t *testing.T) {
+	db := builder.New(pebbletest.NewTestDB(t, Author{}, Book{}))
+	ctx := context.Background()
+
+	author, err := Create[Author](ctx, db, WithMany("Books", 3, Fields{"Pages": 100}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if author.ID == 0 || len(author.Books) != 3 {
+		t.Fatalf("expected an author with 3 books, got %+v", author)
+	}
+	for _, book := range author.Books {
+		if book.AuthorID != author.ID || book.Pages != 100 {
+			t.Errorf("unexpected book %+v", book)
+		}
+	}
+
+	book, err := Create[Book](ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if book.AuthorID == 0 || book.Author == nil || book.Author.ID != book.AuthorID {
+		t.Errorf("expected the book's author to be created, got %+v", book)
+	}
+}
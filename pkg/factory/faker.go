@@ -0,0 +1,92 @@
+package factory
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strings"
+)
+
+var (
+	firstNames = []string{"Alice", "Bob", "Carol", "Dave", "Erin", "Frank", "Grace", "Heidi", "Ivan", "Judy", "Mallory", "Niaj", "Olivia", "Peggy", "Rupert", "Sybil", "Trent", "Victor", "Walter", "Yara"}
+	lastNames  = []string{"Anderson", "Brown", "Clark", "Davis", "Evans", "Garcia", "Harris", "Jones", "King", "Lopez", "Miller", "Nguyen", "Patel", "Quinn", "Roberts", "Smith", "Taylor", "Walker", "Young", "Zhang"}
+	words      = []string{"amber", "basin", "cedar", "delta", "ember", "fable", "grove", "harbor", "island", "jasper", "kettle", "lantern", "meadow", "nectar", "orbit", "pebble", "quartz", "river", "summit", "timber", "umber", "valley", "willow", "zephyr"}
+)
+
+// Faker generates test values. Each object a factory builds gets its own
+// Faker, seeded by its sequence number, so values are repeatable across runs
+// and unique where they include Seq.
+type Faker struct {
+	seq  int
+	rand *rand.Rand
+}
+
+func newFaker(seq int) *Faker {
+	return &Faker{seq: seq, rand: rand.New(rand.NewPCG(uint64(seq), 0x9e3779b97f4a7c15))}
+}
+
+// Seq returns the object's sequence number: 1 for the first object built
+// from a definition, 2 for the second, and so on.
+func (f *Faker) Seq() int {
+	return f.seq
+}
+
+// FirstName returns a first name.
+func (f *Faker) FirstName() string {
+	return f.Pick(firstNames...)
+}
+
+// LastName returns a last name.
+func (f *Faker) LastName() string {
+	return f.Pick(lastNames...)
+}
+
+// Name returns a full name.
+func (f *Faker) Name() string {
+	return f.FirstName() + " " + f.LastName()
+}
+
+// Email returns an email address that is unique per sequence number, so it
+// can fill UNIQUE columns: "alice.smith3@example.com".
+func (f *Faker) Email() string {
+	return fmt.Sprintf("%s.%s%d@example.com",
+		strings.ToLower(f.FirstName()), strings.ToLower(f.LastName()), f.seq)
+}
+
+// Word returns a lowercase word.
+func (f *Faker) Word() string {
+	return f.Pick(words...)
+}
+
+// Sentence returns n words, capitalized and ending in a period.
+func (f *Faker) Sentence(n int) string {
+	parts := make([]string, n)
+	for i := range parts {
+		parts[i] = f.Word()
+	}
+	s := strings.Join(parts, " ")
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:] + "."
+}
+
+// Int returns an int in [min, max].
+func (f *Faker) Int(min, max int) int {
+	if max <= min {
+		return min
+	}
+	return min + f.rand.IntN(max-min+1)
+}
+
+// Bool returns true or false.
+func (f *Faker) Bool() bool {
+	return f.rand.IntN(2) == 1
+}
+
+// Pick returns one of the options.
+func (f *Faker) Pick(options ...string) string {
+	if len(options) == 0 {
+		return ""
+	}
+	return options[f.rand.IntN(len(options))]
+}
@@ -0,0 +1,82 @@
+package loader_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/marshallshelly/pebble-orm/pkg/loader"
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+)
+
+const embeddedModelsSource = `package models
+
+// table_name: customers
+type Customer struct {
+	ID       int     ` + "`po:\"id,primaryKey,serial\"`" + `
+	Billing  Address ` + "`po:\"embedded,prefix(billing_)\"`" + `
+	Shipping Address ` + "`po:\"embedded,prefix(shipping_)\"`" + `
+	AuditFields      ` + "`po:\"embedded\"`" + `
+}
+`
+
+// The embedded structs live in a separate file of the same package.
+const embeddedTypesSource = `package models
+
+import "time"
+
+type Address struct {
+	Street string ` + "`po:\"street,text,notNull\"`" + `
+	City   string ` + "`po:\"city,text\"`" + `
+}
+
+type AuditFields struct {
+	CreatedAt time.Time ` + "`po:\"created_at,timestamptz,default(NOW()),notNull\"`" + `
+}
+`
+
+func TestLoadModelsFromPath_EmbeddedStructs(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "customer.go"), []byte(embeddedModelsSource), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "types.go"), []byte(embeddedTypesSource), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cap := &captureRegistrar{tables: map[string]*schema.TableMetadata{}}
+	count, err := loader.LoadModelsFromPath(dir, cap)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	// Address and AuditFields are only embedded, so they are not tables.
+	if count != 1 || len(cap.tables) != 1 {
+		t.Fatalf("expected only the customers table, got %d: %v", count, cap.tables)
+	}
+
+	table := cap.tables["customers"]
+	if table == nil {
+		t.Fatal("customers table not loaded")
+	}
+	want := []struct{ name, goField string }{
+		{"id", "ID"},
+		{"billing_street", "Billing.Street"},
+		{"billing_city", "Billing.City"},
+		{"shipping_street", "Shipping.Street"},
+		{"shipping_city", "Shipping.City"},
+		{"created_at", "AuditFields.CreatedAt"},
+	}
+	if len(table.Columns) != len(want) {
+		t.Fatalf("expected %d columns, got %d", len(want), len(table.Columns))
+	}
+	for i, w := range want {
+		col := table.Columns[i]
+		if col.Name != w.name || col.GoField != w.goField {
+			t.Errorf("column %d: got (%s, %s), want (%s, %s)", i, col.Name, col.GoField, w.name, w.goField)
+		}
+	}
+	if col := table.GetColumnByName("created_at"); col == nil || col.SQLType != "timestamptz" || col.Nullable {
+		t.Errorf("created_at not flattened correctly: %+v", col)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/marshallshelly/pebble-orm/pkg/schema"
@@ -26,9 +27,67 @@ type ModelRegistrar interface {
 // - Directory (scans all .go files recursively)
 // - Custom table names from // table_name: comments
 func LoadModelsFromPath(path string, registrar ModelRegistrar) (int, error) {
+	models, err := ScanModels(path)
+	if err != nil {
+		return 0, err
+	}
+
+	modelsRegistered := 0
+
+	for _, model := range models {
+		if err := registrar.RegisterMetadata(model.Table); err != nil {
+			return modelsRegistered, fmt.Errorf("failed to load models from %s: failed to register %s: %w", model.File, model.StructName, err)
+		}
+		modelsRegistered++
+	}
+
+	return modelsRegistered, nil
+}
+
+// Model is a model struct found in Go source.
+type Model struct {
+	StructName string                // Go struct name
+	Package    string                // Go package name
+	File       string                // Path of the declaring file
+	Table      *schema.TableMetadata // Table metadata built from the struct
+}
+
+// ScanModels finds the models at path, like LoadModelsFromPath, without
+// registering them.
+func ScanModels(path string) ([]Model, error) {
+	files, err := parseModelFiles(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var models []Model
+	for _, file := range files {
+		for _, decl := range modelDecls(file) {
+			models = append(models, Model{
+				StructName: decl.structName,
+				Package:    file.node.Name.Name,
+				File:       file.path,
+				Table:      buildModelTable(decl, file.structs),
+			})
+		}
+	}
+	return models, nil
+}
+
+// modelFile is a parsed Go source file.
+type modelFile struct {
+	path    string
+	node    *ast.File
+	structs map[string]*ast.StructType // every struct of the file's package
+	methods map[string]string          // struct name -> table name from a TableName method, package-wide
+}
+
+// parseModelFiles parses the .go file at path, or every non-test .go file
+// under the directory at path.
+func parseModelFiles(path string) ([]modelFile, error) {
 	info, err := os.Stat(path)
 	if err != nil {
-		return 0, fmt.Errorf("failed to stat path: %w", err)
+		return nil, fmt.Errorf("failed to stat path: %w", err)
 	}
 
 	var filesToParse []string
@@ -45,43 +104,83 @@ func LoadModelsFromPath(path string, registrar ModelRegistrar) (int, error) {
 			return nil
 		})
 		if err != nil {
-			return 0, fmt.Errorf("failed to walk directory: %w", err)
+			return nil, fmt.Errorf("failed to walk directory: %w", err)
 		}
 	} else {
 		// Single file
 		if !strings.HasSuffix(path, ".go") {
-			return 0, fmt.Errorf("file must have .go extension")
+			return nil, fmt.Errorf("file must have .go extension")
 		}
 		filesToParse = append(filesToParse, path)
 	}
 
 	if len(filesToParse) == 0 {
-		return 0, fmt.Errorf("no .go files found in %s", path)
+		return nil, fmt.Errorf("no .go files found in %s", path)
 	}
 
-	// Parse all files and collect struct definitions
-	modelsRegistered := 0
-
+	// Parse all files up front so structs embedded from another file of the
+	// same package can be resolved.
+	files := make([]modelFile, 0, len(filesToParse))
+	packages := make(map[string]map[string]*ast.StructType) // dir -> struct name -> struct
+	methods := make(map[string]map[string]string)           // dir -> struct name -> table name
 	for _, file := range filesToParse {
-		count, err := loadModelsFromFile(file, registrar)
+		fset := token.NewFileSet()
+		node, err := parser.ParseFile(fset, file, nil, parser.ParseComments)
 		if err != nil {
-			return modelsRegistered, fmt.Errorf("failed to load models from %s: %w", file, err)
+			return nil, fmt.Errorf("failed to load models from %s: failed to parse file: %w", file, err)
 		}
-		modelsRegistered += count
-	}
 
-	return modelsRegistered, nil
+		dir := filepath.Dir(file)
+		if packages[dir] == nil {
+			packages[dir] = make(map[string]*ast.StructType)
+			methods[dir] = make(map[string]string)
+		}
+		for name, st := range structTypes(node) {
+			packages[dir][name] = st
+		}
+		for name, tableName := range tableNameMethods(node) {
+			methods[dir][name] = tableName
+		}
+		files = append(files, modelFile{path: file, node: node, structs: packages[dir], methods: methods[dir]})
+	}
+	return files, nil
 }
 
-// loadModelsFromFile parses a single Go file and registers structs with pebble tags
-func loadModelsFromFile(filename string, registrar ModelRegistrar) (int, error) {
-	fset := token.NewFileSet()
-	node, err := parser.ParseFile(fset, filename, nil, parser.ParseComments)
-	if err != nil {
-		return 0, fmt.Errorf("failed to parse file: %w", err)
+// structTypes returns the struct types declared in a file, by name.
+func structTypes(node *ast.File) map[string]*ast.StructType {
+	structs := make(map[string]*ast.StructType)
+	for _, decl := range node.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			if typeSpec, ok := spec.(*ast.TypeSpec); ok {
+				if structType, ok := typeSpec.Type.(*ast.StructType); ok {
+					structs[typeSpec.Name.Name] = structType
+				}
+			}
+		}
 	}
+	return structs
+}
 
-	modelsRegistered := 0
+// modelDecl is a struct declaration that maps to a table.
+type modelDecl struct {
+	structName string
+	tableName  string
+	genDecl    *ast.GenDecl
+	typeSpec   *ast.TypeSpec
+	structType *ast.StructType
+}
+
+// modelDecls returns the structs with pebble tags declared in a parsed file.
+// Structs that are only embedded in other models (and have no table_name
+// directive or TableName method) are not tables themselves.
+func modelDecls(file modelFile) []modelDecl {
+	node := file.node
+	embedded := embeddedStructNames(file.structs)
+	var models []modelDecl
 
 	// Iterate through declarations
 	for _, decl := range node.Decls {
@@ -109,65 +208,196 @@ func loadModelsFromFile(filename string, registrar ModelRegistrar) (int, error)
 			structName := typeSpec.Name.Name
 
 			// Extract custom table name from comment if present
-			tableName := toSnakeCase(structName) // Default
+			tableName := schema.CurrentNamingStrategy().TableName(structName) // Default
+			hasTableName := false
 			if genDecl.Doc != nil {
 				for _, comment := range genDecl.Doc.List {
 					if customName := schema.ParseTableNameFromComment(comment.Text); customName != "" {
 						tableName = customName
+						hasTableName = true
 						break
 					}
 				}
 			}
 
-			// Build TableMetadata directly from AST
-			table := buildTableMetadataFromAST(tableName, structType)
+			// A TableName method wins, as it does for the reflection parser.
+			if methodName, ok := file.methods[structName]; ok {
+				tableName = methodName
+				hasTableName = true
+			}
 
-			// Table-level index directives from the struct's comments.
-			for _, cg := range []*ast.CommentGroup{genDecl.Doc, typeSpec.Comment} {
-				if cg == nil {
-					continue
-				}
-				for _, comment := range cg.List {
-					if idx := schema.ParseIndexFromComment(comment.Text); idx != nil {
-						table.Indexes = append(table.Indexes, *idx)
-					}
-				}
+			if embedded[structName] && !hasTableName {
+				continue
 			}
 
-			if err := registrar.RegisterMetadata(table); err != nil {
-				return modelsRegistered, fmt.Errorf("failed to register %s: %w", structName, err)
+			models = append(models, modelDecl{
+				structName: structName,
+				tableName:  tableName,
+				genDecl:    genDecl,
+				typeSpec:   typeSpec,
+				structType: structType,
+			})
+		}
+	}
+	return models
+}
+
+// buildModelTable builds the table metadata of a model from its struct and
+// the table-level directives in its comments. structs holds every struct of
+// the model's package, used to flatten embedded structs.
+func buildModelTable(model modelDecl, structs map[string]*ast.StructType) *schema.TableMetadata {
+	// Build TableMetadata directly from AST
+	table := buildTableMetadataFromAST(model.tableName, model.structType, structs)
+
+	// Table-level schema, comment, rename, scope, view, extension, sequence, index and exclusion directives from the struct's comments.
+	var comments []string
+	for _, cg := range []*ast.CommentGroup{model.genDecl.Doc, model.typeSpec.Comment} {
+		if cg == nil {
+			continue
+		}
+		for _, comment := range cg.List {
+			comments = append(comments, comment.Text)
+			if tableComment := schema.ParseTableCommentFromComment(comment.Text); tableComment != "" && table.Comment == "" {
+				table.Comment = tableComment
+			}
+			if schemaName := schema.ParseSchemaFromComment(comment.Text); schemaName != "" && table.Schema == "" {
+				table.Schema = schemaName
 			}
+			if renamedFrom := schema.ParseRenamedFromComment(comment.Text); renamedFrom != "" && table.RenamedFrom == "" {
+				table.RenamedFrom = renamedFrom
+			}
+			if scope := schema.ParseDefaultScopeFromComment(comment.Text); scope != "" && table.DefaultScope == "" {
+				table.DefaultScope = scope
+			}
+			if idx := schema.ParseIndexFromComment(comment.Text); idx != nil {
+				table.Indexes = append(table.Indexes, *idx)
+			}
+			if c := schema.ParseExclusionFromComment(comment.Text); c != nil {
+				table.Constraints = append(table.Constraints, *c)
+			}
+			table.Extensions = append(table.Extensions, schema.ParseExtensionsFromComment(comment.Text)...)
+			table.CDC = table.CDC || schema.ParseCDCFromComment(comment.Text)
+			table.History = table.History || schema.ParseHistoryFromComment(comment.Text)
+		}
+	}
+
+	table.View = schema.ParseViewFromComments(comments)
+	table.Sequences = schema.SequencesFromComments(comments, table)
+	return table
+}
 
-			modelsRegistered++
+// tableNameMethods returns the table names of the structs in a file that
+// declare `func (T) TableName() string { return "name" }`. Methods that don't
+// return a string literal are skipped.
+func tableNameMethods(node *ast.File) map[string]string {
+	names := make(map[string]string)
+	for _, decl := range node.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv == nil || len(fn.Recv.List) == 0 || fn.Name.Name != "TableName" || fn.Body == nil || len(fn.Body.List) != 1 {
+			continue
+		}
+		recv := fn.Recv.List[0].Type
+		if star, ok := recv.(*ast.StarExpr); ok {
+			recv = star.X
+		}
+		ident, ok := recv.(*ast.Ident)
+		if !ok {
+			continue
+		}
+		ret, ok := fn.Body.List[0].(*ast.ReturnStmt)
+		if !ok || len(ret.Results) != 1 {
+			continue
+		}
+		if lit, ok := ret.Results[0].(*ast.BasicLit); ok && lit.Kind == token.STRING {
+			if name, err := strconv.Unquote(lit.Value); err == nil && name != "" {
+				names[ident.Name] = name
+			}
 		}
 	}
+	return names
+}
 
-	return modelsRegistered, nil
+// embeddedStructNames returns the names of structs that other structs in the
+// package flatten into themselves.
+func embeddedStructNames(structs map[string]*ast.StructType) map[string]bool {
+	names := make(map[string]bool)
+	for _, structType := range structs {
+		if structType.Fields == nil {
+			continue
+		}
+		for _, field := range structType.Fields.List {
+			if name, ok := embeddedStructName(field); ok {
+				names[name] = true
+			}
+		}
+	}
+	return names
 }
 
-// buildTableMetadataFromAST creates TableMetadata by parsing the AST struct
-// definition. Go-type facts are extracted from the AST expressions and the tag
-// is interpreted through the shared schema helpers, so the AST loader produces
-// the same metadata as the reflection parser (index/enum/generated/identity/FK
-// included).
-func buildTableMetadataFromAST(tableName string, structType *ast.StructType) *schema.TableMetadata {
-	table := &schema.TableMetadata{
-		Name:        tableName,
-		GoType:      nil, // No actual Go type available from AST
-		Columns:     make([]schema.ColumnMetadata, 0),
-		ForeignKeys: make([]schema.ForeignKeyMetadata, 0),
-		Indexes:     make([]schema.IndexMetadata, 0),
-		Constraints: make([]schema.ConstraintMetadata, 0),
+// embeddedStructName reports whether a field flattens a struct of the same
+// package into its parent, and returns the struct's name. Mirrors the
+// reflection parser: only struct fields tagged `po:"embedded"` are
+// flattened. Pointers and structs from other packages cannot be resolved
+// from source and are not flattened.
+func embeddedStructName(field *ast.Field) (string, bool) {
+	ident, ok := field.Type.(*ast.Ident)
+	if !ok {
+		return "", false
 	}
+	if field.Tag == nil {
+		return "", false
+	}
+	poTag := parseStructTag(strings.Trim(field.Tag.Value, "`")).Get("po")
+	if poTag == "" {
+		return "", false
+	}
+	opts, err := schema.ParseTag(poTag)
+	if err != nil || !schema.IsEmbeddedTag(opts) {
+		return "", false
+	}
+	return ident.Name, true
+}
 
+// astColumnField is a tagged column field of a struct, possibly reached
+// through embedded structs.
+type astColumnField struct {
+	name string // Go field name
+	path string // dotted Go field path from the model, e.g. "Billing.Street"
+	typ  ast.Expr
+	opts *schema.TagOptions // opts.Name carries any embedded column prefix
+}
+
+// collectASTColumnFields walks a struct's fields in declaration order and
+// returns its column fields, flattening embedded structs found in structs
+// (see the reflection parser's collectColumnFields).
+func collectASTColumnFields(structType *ast.StructType, structs map[string]*ast.StructType, prefix, path string) []astColumnField {
+	var fields []astColumnField
 	if structType.Fields == nil {
-		return table
+		return fields
 	}
 
-	position := 0
 	for _, field := range structType.Fields.List {
+		if name, ok := embeddedStructName(field); ok {
+			nested, found := structs[name]
+			if !found {
+				continue
+			}
+			fieldName := name
+			nestedPrefix := prefix
+			if len(field.Names) > 0 {
+				fieldName = field.Names[0].Name
+			}
+			if field.Tag != nil {
+				if opts, err := schema.ParseTag(parseStructTag(strings.Trim(field.Tag.Value, "`")).Get("po")); err == nil {
+					nestedPrefix += opts.Get("prefix")
+				}
+			}
+			fields = append(fields, collectASTColumnFields(nested, structs, nestedPrefix, joinPath(path, fieldName))...)
+			continue
+		}
+
 		if len(field.Names) == 0 || field.Tag == nil {
-			continue // Embedded or untagged field
+			continue // Untagged or unresolvable embedded field
 		}
 
 		poTag := parseStructTag(strings.Trim(field.Tag.Value, "`")).Get("po")
@@ -183,35 +413,74 @@ func buildTableMetadataFromAST(tableName string, structType *ast.StructType) *sc
 		}
 
 		for _, fieldName := range field.Names {
-			fm := schema.FieldMeta{
-				GoField:      fieldName.Name,
-				TypeName:     astTypeName(field.Type),
-				Nullable:     astNullable(field.Type),
-				InferredType: astInferPGType(field.Type),
-				Position:     position,
+			fieldOpts := *opts
+			if fieldOpts.Name == "" {
+				fieldOpts.Name = schema.CurrentNamingStrategy().ColumnName(fieldName.Name)
 			}
-			column := schema.BuildColumn(opts, fm)
+			fieldOpts.Name = prefix + fieldOpts.Name
+			fields = append(fields, astColumnField{
+				name: fieldName.Name,
+				path: joinPath(path, fieldName.Name),
+				typ:  field.Type,
+				opts: &fieldOpts,
+			})
+		}
+	}
+	return fields
+}
 
-			if opts.Has("primaryKey") {
-				if table.PrimaryKey == nil {
-					table.PrimaryKey = &schema.PrimaryKeyMetadata{
-						Columns: []string{column.Name},
-						Name:    tableName + "_pkey",
-					}
-				} else {
-					table.PrimaryKey.Columns = append(table.PrimaryKey.Columns, column.Name)
+// joinPath appends a field name to a dotted Go field path.
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// buildTableMetadataFromAST creates TableMetadata by parsing the AST struct
+// definition. Go-type facts are extracted from the AST expressions and the tag
+// is interpreted through the shared schema helpers, so the AST loader produces
+// the same metadata as the reflection parser (index/enum/generated/identity/FK
+// included). structs resolves embedded structs by name; it may be nil.
+func buildTableMetadataFromAST(tableName string, structType *ast.StructType, structs map[string]*ast.StructType) *schema.TableMetadata {
+	table := &schema.TableMetadata{
+		Name:        tableName,
+		GoType:      nil, // No actual Go type available from AST
+		Columns:     make([]schema.ColumnMetadata, 0),
+		ForeignKeys: make([]schema.ForeignKeyMetadata, 0),
+		Indexes:     make([]schema.IndexMetadata, 0),
+		Constraints: make([]schema.ConstraintMetadata, 0),
+	}
+
+	for position, field := range collectASTColumnFields(structType, structs, "", "") {
+		fm := schema.FieldMeta{
+			GoField:      field.path,
+			TypeName:     astTypeName(field.typ),
+			Nullable:     astNullable(field.typ),
+			InferredType: astInferPGType(field.typ),
+			IsSlice:      astIsSlice(field.typ),
+			Position:     position,
+		}
+		column := schema.BuildColumn(field.opts, fm)
+
+		if field.opts.Has("primaryKey") {
+			if table.PrimaryKey == nil {
+				table.PrimaryKey = &schema.PrimaryKeyMetadata{
+					Columns: []string{column.Name},
+					Name:    tableName + "_pkey",
 				}
+			} else {
+				table.PrimaryKey.Columns = append(table.PrimaryKey.Columns, column.Name)
 			}
+		}
 
-			table.Columns = append(table.Columns, column)
+		table.Columns = append(table.Columns, column)
 
-			if idx, ok := schema.ColumnIndex(opts, tableName); ok {
-				table.Indexes = append(table.Indexes, idx)
-			}
-			if fk, ok := schema.ColumnForeignKey(opts, tableName); ok {
-				table.ForeignKeys = append(table.ForeignKeys, fk)
-			}
-			position++
+		if idx, ok := schema.ColumnIndex(field.opts, tableName); ok {
+			table.Indexes = append(table.Indexes, idx)
+		}
+		if fk, ok := schema.ColumnForeignKey(field.opts, tableName); ok {
+			table.ForeignKeys = append(table.ForeignKeys, fk)
 		}
 	}
 
@@ -253,6 +522,20 @@ func astNullable(expr ast.Expr) bool {
 	return false
 }
 
+// astIsSlice reports whether an AST type expression is a slice other than
+// []byte, or a pointer to one.
+func astIsSlice(expr ast.Expr) bool {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	t, ok := expr.(*ast.ArrayType)
+	if !ok || t.Len != nil {
+		return false
+	}
+	id, ok := t.Elt.(*ast.Ident)
+	return !ok || (id.Name != "byte" && id.Name != "uint8")
+}
+
 // astInferPGType infers the PostgreSQL type for an AST type expression, matching
 // schema.DefaultTypeMapper for the common Go built-in and standard-library
 // types. Returns "" for named or unknown types (the shared BuildColumn then
@@ -274,6 +557,9 @@ func astInferPGType(expr ast.Expr) string {
 			if _, ok := t.Value.(*ast.InterfaceType); ok {
 				return "jsonb"
 			}
+			if v, ok := t.Value.(*ast.Ident); ok && v.Name == "string" {
+				return "hstore"
+			}
 		}
 		return ""
 	case *ast.SelectorExpr:
@@ -284,6 +570,19 @@ func astInferPGType(expr ast.Expr) string {
 		if x.Name == "time" && t.Sel.Name == "Time" {
 			return "timestamp with time zone"
 		}
+		if x.Name == "time" && t.Sel.Name == "Duration" {
+			return "interval"
+		}
+		if x.Name == "netip" || x.Name == "net" {
+			switch t.Sel.Name {
+			case "Addr", "IP":
+				return "inet"
+			case "Prefix", "IPNet":
+				return "cidr"
+			case "HardwareAddr":
+				return "macaddr"
+			}
+		}
 		if x.Name == "sql" {
 			switch t.Sel.Name {
 			case "NullString":
@@ -350,18 +649,6 @@ func (t *structTag) Get(key string) string {
 	return t.value[start : start+end]
 }
 
-// toSnakeCase converts PascalCase to snake_case
-func toSnakeCase(s string) string {
-	var result strings.Builder
-	for i, ch := range s {
-		if i > 0 && ch >= 'A' && ch <= 'Z' {
-			result.WriteRune('_')
-		}
-		result.WriteRune(ch)
-	}
-	return strings.ToLower(result.String())
-}
-
 // hasPebbleTags checks if a struct has any fields with pebble tags
 func hasPebbleTags(structType *ast.StructType) bool {
 	if structType.Fields == nil {
@@ -31,7 +31,7 @@ func TestBuildTableMetadataFromAST_UniqueConstraints(t *testing.T) {
 	}
 	structType := &ast.StructType{Fields: fields}
 
-	table := buildTableMetadataFromAST("users", structType)
+	table := buildTableMetadataFromAST("users", structType, nil)
 
 	// Verify the email column is marked unique
 	var emailCol *schema.ColumnMetadata
@@ -83,7 +83,7 @@ func TestBuildTableMetadataFromAST_NoUniqueNoConstraint(t *testing.T) {
 	}
 	structType := &ast.StructType{Fields: fields}
 
-	table := buildTableMetadataFromAST("items", structType)
+	table := buildTableMetadataFromAST("items", structType, nil)
 
 	if len(table.Constraints) != 0 {
 		t.Errorf("expected 0 constraints, got %d", len(table.Constraints))
@@ -212,7 +212,7 @@ func TestGetSQLTypeFromOptions_AllTypes(t *testing.T) {
 		"numeric", "decimal", "real", "double precision",
 		"boolean", "bool",
 		"timestamptz", "timestamp", "date", "time", "interval",
-		"jsonb", "json",
+		"jsonb", "json", "hstore", "citext",
 		"bytea",
 	}
 
@@ -322,3 +322,37 @@ func TestGetSQLTypeFromOptions_WithSize(t *testing.T) {
 		})
 	}
 }
+
+func TestAstInferPGType_NetworkTypes(t *testing.T) {
+	sel := func(pkg, name string) ast.Expr {
+		return &ast.SelectorExpr{X: &ast.Ident{Name: pkg}, Sel: &ast.Ident{Name: name}}
+	}
+
+	tests := []struct {
+		name     string
+		expr     ast.Expr
+		expected string
+	}{
+		{"netip.Addr", sel("netip", "Addr"), "inet"},
+		{"*netip.Addr", &ast.StarExpr{X: sel("netip", "Addr")}, "inet"},
+		{"netip.Prefix", sel("netip", "Prefix"), "cidr"},
+		{"net.IP", sel("net", "IP"), "inet"},
+		{"net.IPNet", sel("net", "IPNet"), "cidr"},
+		{"net.HardwareAddr", sel("net", "HardwareAddr"), "macaddr"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := astInferPGType(tt.expr); got != tt.expected {
+				t.Errorf("astInferPGType() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAstInferPGType_Duration(t *testing.T) {
+	expr := &ast.SelectorExpr{X: &ast.Ident{Name: "time"}, Sel: &ast.Ident{Name: "Duration"}}
+	if got := astInferPGType(expr); got != "interval" {
+		t.Errorf("astInferPGType(time.Duration) = %q, want %q", got, "interval")
+	}
+}
@@ -43,6 +43,26 @@ type Membership struct {
 }
 `
 
+// MembershipHistory has enum columns behind a pointer and in an array, whose
+// enum type the loader must take from the element type.
+type MembershipHistory struct {
+	ID           int64     `po:"id,primaryKey,identityAlways"`
+	PreviousRole *MemRole  `po:"previous_role,enum(owner,admin,member)"`
+	PastRoles    []MemRole `po:"past_roles,enum(owner,admin,member)"`
+}
+
+const enumParitySource = `package models
+
+type MemRole string
+
+// table_name: membership_history
+type MembershipHistory struct {
+	ID           int64     ` + "`po:\"id,primaryKey,identityAlways\"`" + `
+	PreviousRole *MemRole  ` + "`po:\"previous_role,enum(owner,admin,member)\"`" + `
+	PastRoles    []MemRole ` + "`po:\"past_roles,enum(owner,admin,member)\"`" + `
+}
+`
+
 type captureRegistrar struct {
 	tables map[string]*schema.TableMetadata
 }
@@ -57,31 +77,7 @@ func (c *captureRegistrar) RegisterMetadata(t *schema.TableMetadata) error {
 // can never silently drift (the class of bug that dropped index/enum/generated
 // from the CLI loader).
 func TestParserLoaderParity(t *testing.T) {
-	// Reflection path.
-	registry.Clear()
-	schema.RegisterTableName("Membership", "memberships")
-	t.Cleanup(func() { registry.Clear() })
-	if err := registry.Register(Membership{}); err != nil {
-		t.Fatalf("register: %v", err)
-	}
-	reflected, err := registry.GetByName("memberships")
-	if err != nil {
-		t.Fatalf("get reflected: %v", err)
-	}
-
-	// AST path.
-	dir := t.TempDir()
-	if err := os.WriteFile(filepath.Join(dir, "membership.go"), []byte(paritySource), 0644); err != nil {
-		t.Fatal(err)
-	}
-	cap := &captureRegistrar{tables: map[string]*schema.TableMetadata{}}
-	if _, err := loader.LoadModelsFromPath(dir, cap); err != nil {
-		t.Fatalf("load: %v", err)
-	}
-	loaded := cap.tables["memberships"]
-	if loaded == nil {
-		t.Fatal("AST loader did not produce the memberships table")
-	}
+	reflected, loaded := parityTables(t, Membership{}, "memberships", paritySource)
 
 	// Guard against a false pass where both sides are equally empty: the model
 	// deliberately has an FK, an index, an enum, a generated column and a
@@ -113,6 +109,51 @@ func TestParserLoaderParity(t *testing.T) {
 	assertEnumsEqual(t, reflected.EnumTypes, loaded.EnumTypes)
 }
 
+// TestParserLoaderEnumColumnParity checks the two paths agree on enum
+// columns held through a pointer or in an array.
+func TestParserLoaderEnumColumnParity(t *testing.T) {
+	reflected, loaded := parityTables(t, MembershipHistory{}, "membership_history", enumParitySource)
+	for _, name := range []string{"previous_role", "past_roles"} {
+		if col := reflected.GetColumnByName(name); col == nil || col.EnumType != "mem_role" {
+			t.Errorf("expected %s to use the mem_role enum from reflection, got %+v", name, col)
+		}
+	}
+	assertColumnsEqual(t, reflected.Columns, loaded.Columns)
+	assertEnumsEqual(t, reflected.EnumTypes, loaded.EnumTypes)
+}
+
+// parityTables returns the metadata of model from the reflection parser and
+// from the AST loader reading source, which declares it as table.
+func parityTables(t *testing.T, model interface{}, table, source string) (reflected, loaded *schema.TableMetadata) {
+	t.Helper()
+
+	// Reflection path.
+	registry.Clear()
+	schema.RegisterTableName(reflect.TypeOf(model).Name(), table)
+	t.Cleanup(func() { registry.Clear() })
+	if err := registry.Register(model); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	reflected, err := registry.GetByName(table)
+	if err != nil {
+		t.Fatalf("get reflected: %v", err)
+	}
+
+	// AST path.
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "models.go"), []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cap := &captureRegistrar{tables: map[string]*schema.TableMetadata{}}
+	if _, err := loader.LoadModelsFromPath(dir, cap); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if loaded = cap.tables[table]; loaded == nil {
+		t.Fatalf("AST loader did not produce the %s table", table)
+	}
+	return reflected, loaded
+}
+
 func assertColumnsEqual(t *testing.T, a, b []schema.ColumnMetadata) {
 	t.Helper()
 	if len(a) != len(b) {
@@ -0,0 +1,39 @@
+package loader_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/marshallshelly/pebble-orm/pkg/loader"
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+)
+
+const schemaModelsSource = `package models
+
+// table_name: invoices
+// schema: billing
+type Invoice struct {
+	ID int ` + "`po:\"id,primaryKey,serial\"`" + `
+}
+`
+
+func TestLoadModelsFromPath_SchemaDirective(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "invoice.go"), []byte(schemaModelsSource), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cap := &captureRegistrar{tables: map[string]*schema.TableMetadata{}}
+	if _, err := loader.LoadModelsFromPath(dir, cap); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	table := cap.tables["invoices"]
+	if table == nil {
+		t.Fatal("invoices table not loaded")
+	}
+	if table.Schema != "billing" || table.QualifiedName() != "billing.invoices" {
+		t.Errorf("expected billing.invoices, got schema %q name %q", table.Schema, table.QualifiedName())
+	}
+}
@@ -0,0 +1,52 @@
+package loader_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/marshallshelly/pebble-orm/pkg/loader"
+)
+
+const tableNameMethodSource = `package models
+
+// table_name: ignored
+type Post struct {
+	ID int64 ` + "`po:\"id,primaryKey,bigint\"`" + `
+}
+
+type Tag struct {
+	ID int64 ` + "`po:\"id,primaryKey,bigint\"`" + `
+}
+`
+
+const tableNameMethodsSource = `package models
+
+func (Post) TableName() string { return "articles" }
+
+func (t *Tag) TableName() string { return "labels" }
+`
+
+func TestScanModels_TableNameMethod(t *testing.T) {
+	dir := t.TempDir()
+	for name, src := range map[string]string{"models.go": tableNameMethodSource, "names.go": tableNameMethodsSource} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	models, err := loader.ScanModels(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]string)
+	for _, m := range models {
+		got[m.StructName] = m.Table.Name
+		if m.Package != "models" {
+			t.Errorf("%s: Package = %q", m.StructName, m.Package)
+		}
+	}
+	if got["Post"] != "articles" || got["Tag"] != "labels" {
+		t.Errorf("table names = %v", got)
+	}
+}
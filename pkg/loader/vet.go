@@ -0,0 +1,77 @@
+package loader
+
+import (
+	"fmt"
+	"go/ast"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+)
+
+// VetModelsFromPath loads the models at path (see LoadModelsFromPath) and
+// checks their struct tags: unknown options, SQL types the Go field can't
+// hold, missing primary keys, relationships to structs that aren't models,
+// and foreign keys to unknown tables or columns. It returns one error per
+// problem; the error result is for failures to read the models.
+func VetModelsFromPath(path string) ([]error, error) {
+	files, err := parseModelFiles(path)
+	if err != nil {
+		return nil, err
+	}
+
+	models := make(map[string]bool) // struct names of all models
+	tables := make(map[string]*schema.TableMetadata)
+	for _, file := range files {
+		for _, decl := range modelDecls(file) {
+			models[decl.structName] = true
+			table := buildModelTable(decl, file.structs)
+			tables[table.QualifiedName()] = table
+		}
+	}
+
+	var problems []error
+	for _, file := range files {
+		for _, decl := range modelDecls(file) {
+			problems = append(problems, vetModelTags(decl, file.structs, models)...)
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(tables)) {
+		problems = append(problems, schema.ValidateTable(tables[name])...)
+	}
+	problems = append(problems, schema.ValidateReferences(tables)...)
+	return problems, nil
+}
+
+// vetModelTags checks the column and relationship tags of a model. models
+// holds the struct names of every loaded model.
+func vetModelTags(model modelDecl, structs map[string]*ast.StructType, models map[string]bool) []error {
+	var problems []error
+	for _, field := range collectASTColumnFields(model.structType, structs, "", "") {
+		fm := schema.FieldMeta{InferredType: astInferPGType(field.typ)}
+		for _, problem := range schema.ValidateTag(field.opts, fm) {
+			problems = append(problems, &schema.ValidationError{Model: model.structName, Field: field.path, Message: problem})
+		}
+	}
+
+	for _, field := range model.structType.Fields.List {
+		if len(field.Names) == 0 || field.Tag == nil {
+			continue
+		}
+		opts, err := schema.ParseTag(parseStructTag(strings.Trim(field.Tag.Value, "`")).Get("po"))
+		if err != nil || !schema.IsRelationshipTag(opts) {
+			continue
+		}
+		fieldName := field.Names[0].Name
+		for _, problem := range schema.ValidateRelationshipTag(opts) {
+			problems = append(problems, &schema.ValidationError{Model: model.structName, Field: fieldName, Message: problem})
+		}
+		if target := astTypeName(field.Type); !models[target] {
+			problems = append(problems, &schema.ValidationError{Model: model.structName, Field: fieldName,
+				Message: fmt.Sprintf("relationship target %s is not a model", target)})
+		}
+	}
+	return problems
+}
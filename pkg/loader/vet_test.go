@@ -0,0 +1,70 @@
+package loader_test
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/marshallshelly/pebble-orm/pkg/loader"
+)
+
+const vetModelsSource = `package models
+
+// table_name: users
+type User struct {
+	ID    int    ` + "`po:\"id,primaryKey,uuid\"`" + `
+	Email string ` + "`po:\"email,varchar(255),uniqe\"`" + `
+	Posts []Post ` + "`po:\"-,hasMany,foreignKey(author_id)\"`" + `
+	Tags  []Tag  ` + "`po:\"-,manyToMany,through(user_tags)\"`" + `
+}
+
+// table_name: posts
+type Post struct {
+	ID       int ` + "`po:\"id,primaryKey,integer\"`" + `
+	AuthorID int ` + "`po:\"author_id,integer,fk:users(id),onDelete:CASCADE\"`" + `
+	OrgID    int ` + "`po:\"org_id,integer,fk:orgs(id)\"`" + `
+	EditorID int ` + "`po:\"editor_id,integer,fk:users(uid)\"`" + `
+}
+
+// table_name: audit_log
+type AuditLog struct {
+	Action string ` + "`po:\"action,text,notNull\"`" + `
+}
+
+type Tag struct{}
+`
+
+func TestVetModelsFromPath(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "models.go"), []byte(vetModelsSource), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	problems, err := loader.VetModelsFromPath(dir)
+	if err != nil {
+		t.Fatalf("vet: %v", err)
+	}
+	var got []string
+	for _, p := range problems {
+		got = append(got, p.Error())
+	}
+
+	want := []string{
+		"User.ID: Go type maps to integer but the tag declares uuid",
+		`User.Email: unknown tag option "uniqe"`,
+		`User.Tags: unknown relationship option "through"`,
+		"User.Tags: relationship target Tag is not a model",
+		"audit_log: table audit_log has no primary key",
+		"posts.OrgID: fk references unknown table orgs",
+		"posts.EditorID: fk references unknown column users.uid",
+	}
+	for _, w := range want {
+		if !slices.Contains(got, w) {
+			t.Errorf("missing problem %q", w)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("got %d problems, want %d:\n%v", len(got), len(want), got)
+	}
+}
@@ -0,0 +1,45 @@
+package loader_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/marshallshelly/pebble-orm/pkg/loader"
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+)
+
+const viewModelsSource = `package models
+
+// table_name: active_users
+// materialized_view: SELECT id, email
+//   FROM users
+//   WHERE active;
+type ActiveUser struct {
+	ID    int    ` + "`po:\"id,primaryKey,integer\"`" + `
+	Email string ` + "`po:\"email,text\"`" + `
+}
+`
+
+func TestLoadModelsFromPath_ViewDirective(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "active_user.go"), []byte(viewModelsSource), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cap := &captureRegistrar{tables: map[string]*schema.TableMetadata{}}
+	if _, err := loader.LoadModelsFromPath(dir, cap); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	table := cap.tables["active_users"]
+	if table == nil {
+		t.Fatal("active_users not loaded")
+	}
+	if !table.IsView() || !table.View.Materialized {
+		t.Fatalf("expected materialized view, got %+v", table.View)
+	}
+	if want := "SELECT id, email FROM users WHERE active"; table.View.Query != want {
+		t.Errorf("query = %q, want %q", table.View.Query, want)
+	}
+}
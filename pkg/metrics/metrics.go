@@ -0,0 +1,154 @@
+// Package metrics exposes Prometheus metrics for queries and connection pools.
+package metrics
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/marshallshelly/pebble-orm/pkg/builder"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "pebble"
+
+// Collector is a prometheus.Collector for query counts, durations and
+// errors by table and operation, and for the connection pool's health. It
+// records queries as a builder.Logger:
+//
+//	collector := metrics.NewCollector(db.Pool())
+//	prometheus.MustRegister(collector)
+//	qb := builder.New(db).WithConfig(builder.Config{Logger: collector})
+type Collector struct {
+	pool *pgxpool.Pool
+
+	queries  *prometheus.CounterVec
+	errors   *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+
+	acquires      *prometheus.Desc
+	emptyAcquires *prometheus.Desc
+	acquireWait   *prometheus.Desc
+	connections   *prometheus.Desc
+	maxConns      *prometheus.Desc
+}
+
+// NewCollector returns a Collector reporting the stats of pool, which may be
+// nil to collect query metrics only.
+func NewCollector(pool *pgxpool.Pool) *Collector {
+	labels := []string{"table", "operation"}
+	return &Collector{
+		pool: pool,
+		queries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Name: "queries_total",
+			Help: "Statements executed, by table and operation.",
+		}, labels),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Name: "query_errors_total",
+			Help: "Statements that failed, by table and operation.",
+		}, labels),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace, Name: "query_duration_seconds",
+			Help:    "Statement duration, by table and operation.",
+			Buckets: prometheus.DefBuckets,
+		}, labels),
+		acquires: prometheus.NewDesc(namespace+"_pool_acquires_total",
+			"Connections acquired from the pool.", nil, nil),
+		emptyAcquires: prometheus.NewDesc(namespace+"_pool_empty_acquires_total",
+			"Acquires that waited because the pool had no idle connection.", nil, nil),
+		acquireWait: prometheus.NewDesc(namespace+"_pool_acquire_wait_seconds_total",
+			"Time spent acquiring connections from the pool.", nil, nil),
+		connections: prometheus.NewDesc(namespace+"_pool_connections",
+			"Connections in the pool, by state.", []string{"state"}, nil),
+		maxConns: prometheus.NewDesc(namespace+"_pool_max_connections",
+			"Maximum size of the pool.", nil, nil),
+	}
+}
+
+// LogQuery records a statement, implementing builder.Logger.
+func (c *Collector) LogQuery(_ context.Context, entry builder.QueryLog) {
+	table, operation := QueryLabels(entry.SQL)
+	c.queries.WithLabelValues(table, operation).Inc()
+	c.duration.WithLabelValues(table, operation).Observe(entry.Duration.Seconds())
+	if entry.Err != nil {
+		c.errors.WithLabelValues(table, operation).Inc()
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.queries.Describe(ch)
+	c.errors.Describe(ch)
+	c.duration.Describe(ch)
+	if c.pool != nil {
+		ch <- c.acquires
+		ch <- c.emptyAcquires
+		ch <- c.acquireWait
+		ch <- c.connections
+		ch <- c.maxConns
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.queries.Collect(ch)
+	c.errors.Collect(ch)
+	c.duration.Collect(ch)
+	if c.pool == nil {
+		return
+	}
+
+	stat := c.pool.Stat()
+	ch <- prometheus.MustNewConstMetric(c.acquires, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.emptyAcquires, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.acquireWait, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(c.connections, prometheus.GaugeValue, float64(stat.IdleConns()), "idle")
+	ch <- prometheus.MustNewConstMetric(c.connections, prometheus.GaugeValue, float64(stat.AcquiredConns()), "active")
+	ch <- prometheus.MustNewConstMetric(c.connections, prometheus.GaugeValue, float64(stat.ConstructingConns()), "constructing")
+	ch <- prometheus.MustNewConstMetric(c.maxConns, prometheus.GaugeValue, float64(stat.MaxConns()))
+}
+
+var (
+	reOperation = regexp.MustCompile(`^\s*(\w+)`)
+	reTable     = regexp.MustCompile(`(?i)\b(?:FROM|INTO|UPDATE|MATERIALIZED VIEW)\s+((?:"[^"]+"|\w+)(?:\.(?:"[^"]+"|\w+))?)`)
+)
+
+// QueryLabels extracts the table and operation of a statement the builders
+// generated: "SELECT * FROM users WHERE ..." is ("users", "select"). A
+// statement with a WITH clause reports the operation of its main statement.
+// The table is empty when the statement names none.
+func QueryLabels(sql string) (table, operation string) {
+	main := sql
+	if m := reOperation.FindStringSubmatch(sql); m != nil && strings.EqualFold(m[1], "WITH") {
+		main = afterCTEs(sql)
+	}
+	if m := reOperation.FindStringSubmatch(main); m != nil {
+		operation = strings.ToLower(m[1])
+	}
+	if m := reTable.FindStringSubmatch(main); m != nil {
+		table = strings.ReplaceAll(m[1], `"`, "")
+	}
+	return table, operation
+}
+
+var reMainStatement = regexp.MustCompile(`(?i)^\s*(SELECT|INSERT|UPDATE|DELETE)\b`)
+
+// afterCTEs returns the main statement following a WITH clause: the text
+// after the first top-level closing parenthesis that is followed by SELECT,
+// INSERT, UPDATE or DELETE rather than by another CTE or AS.
+func afterCTEs(sql string) string {
+	depth := 0
+	for i, r := range sql {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 && reMainStatement.MatchString(sql[i+1:]) {
+				return sql[i+1:]
+			}
+		}
+	}
+	return sql
+}
@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/marshallshelly/pebble-orm/pkg/builder"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestQueryLabels(t *testing.T) {
+	tests := []struct {
+		sql       string
+		table     string
+		operation string
+	}{
+		{"SELECT id, name FROM users WHERE id = $1", "users", "select"},
+		{"INSERT INTO users (name) VALUES ($1) RETURNING id", "users", "insert"},
+		{"UPDATE users SET name = $1 WHERE id = $2", "users", "update"},
+		{"DELETE FROM users WHERE id = $1", "users", "delete"},
+		{`SELECT * FROM "tenant"."orders"`, "tenant.orders", "select"},
+		{"REFRESH MATERIALIZED VIEW user_stats", "user_stats", "refresh"},
+		{"WITH recent (id) AS (SELECT id FROM posts WHERE created_at > $1) DELETE FROM comments WHERE post_id IN (SELECT id FROM recent)", "comments", "delete"},
+		{"select 1", "", "select"},
+	}
+	for _, tt := range tests {
+		table, operation := QueryLabels(tt.sql)
+		if table != tt.table || operation != tt.operation {
+			t.Errorf("QueryLabels(%q) = (%q, %q), want (%q, %q)", tt.sql, table, operation, tt.table, tt.operation)
+		}
+	}
+}
+
+func TestCollector(t *testing.T) {
+	c := NewCollector(nil)
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(c)
+
+	ctx := context.Background()
+	c.LogQuery(ctx, builder.QueryLog{SQL: "SELECT * FROM users", Duration: time.Millisecond})
+	c.LogQuery(ctx, builder.QueryLog{SQL: "SELECT * FROM users WHERE id = $1", Duration: time.Millisecond})
+	c.LogQuery(ctx, builder.QueryLog{SQL: "INSERT INTO users (name) VALUES ($1)", Err: errors.New("boom")})
+
+	if got := testutil.ToFloat64(c.queries.WithLabelValues("users", "select")); got != 2 {
+		t.Errorf("expected 2 selects, got %v", got)
+	}
+	if got := testutil.ToFloat64(c.errors.WithLabelValues("users", "insert")); got != 1 {
+		t.Errorf("expected 1 failed insert, got %v", got)
+	}
+	if got := testutil.CollectAndCount(c, "pebble_query_duration_seconds"); got != 2 {
+		t.Errorf("expected 2 duration series, got %d", got)
+	}
+}
@@ -0,0 +1,162 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+)
+
+// Change data capture: models with a // cdc: true directive get a trigger
+// that records every row change in CDCEventsTable and notifies CDCChannel.
+// Package cdc streams the events.
+const (
+	// CDCEventsTable holds captured row changes.
+	CDCEventsTable = "pebble_cdc_events"
+	// CDCOffsetsTable holds each consumer's position in CDCEventsTable.
+	CDCOffsetsTable = "pebble_cdc_offsets"
+	// CDCChannel is the LISTEN/NOTIFY channel the trigger notifies, with the
+	// changed table as payload.
+	CDCChannel = "pebble_cdc"
+
+	cdcTrigger  = "pebble_cdc"
+	cdcFunction = "pebble_cdc_capture"
+)
+
+// cdcSetupSQL creates the events and offsets tables and the trigger function
+// shared by every captured table. Each event records the id of the
+// transaction that wrote it (txid) so consumers can skip past transactions
+// that are still in progress without losing their events.
+var cdcSetupSQL = []string{
+	`CREATE TABLE IF NOT EXISTS ` + CDCEventsTable + ` (
+    id bigserial PRIMARY KEY,
+    txid bigint NOT NULL DEFAULT pg_current_xact_id()::text::bigint,
+    table_name text NOT NULL,
+    operation text NOT NULL,
+    old_row jsonb,
+    new_row jsonb,
+    created_at timestamptz NOT NULL DEFAULT now()
+);`,
+	`CREATE INDEX IF NOT EXISTS ` + CDCEventsTable + `_table_txid_idx ON ` + CDCEventsTable + ` (table_name, txid, id);`,
+	`CREATE TABLE IF NOT EXISTS ` + CDCOffsetsTable + ` (
+    consumer text NOT NULL,
+    table_name text NOT NULL,
+    last_txid bigint NOT NULL DEFAULT 0,
+    last_id bigint NOT NULL DEFAULT 0,
+    updated_at timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (consumer, table_name)
+);`,
+	`CREATE OR REPLACE FUNCTION ` + cdcFunction + `() RETURNS trigger AS $$
+DECLARE
+    captured text := TG_TABLE_SCHEMA || '.' || TG_TABLE_NAME;
+BEGIN
+    INSERT INTO ` + CDCEventsTable + ` (table_name, operation, old_row, new_row)
+    VALUES (captured, TG_OP,
+            CASE WHEN TG_OP <> 'INSERT' THEN to_jsonb(OLD) END,
+            CASE WHEN TG_OP <> 'DELETE' THEN to_jsonb(NEW) END);
+    PERFORM pg_notify('` + CDCChannel + `', captured);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;`,
+}
+
+// CDCChange represents a table gaining or losing change data capture.
+type CDCChange struct {
+	Old bool // Captured in the database
+	New bool // Captured in code
+}
+
+// isCDCTable reports whether name is one of the CDC bookkeeping tables, which
+// aren't models and are left out of introspection and reconstruction.
+func isCDCTable(name string) bool {
+	return name == CDCEventsTable || name == CDCOffsetsTable
+}
+
+// generateCreateCDCTrigger generates the trigger that captures a table's changes.
+func (p *Planner) generateCreateCDCTrigger(tableName string) string {
+	return fmt.Sprintf("CREATE TRIGGER %s AFTER INSERT OR UPDATE OR DELETE ON %s FOR EACH ROW EXECUTE FUNCTION %s();",
+		cdcTrigger, schema.QuoteQualifiedIdent(tableName), cdcFunction)
+}
+
+// generateDropCDCTrigger generates the statement that stops capturing a table's changes.
+func (p *Planner) generateDropCDCTrigger(tableName string) string {
+	return fmt.Sprintf("DROP TRIGGER IF EXISTS %s ON %s;", cdcTrigger, schema.QuoteQualifiedIdent(tableName))
+}
+
+// generateCDCChanges returns the statements that add and remove CDC
+// triggers for new and modified tables. The shared setup runs first whenever
+// a trigger is created; it is idempotent and never dropped, since other
+// tables may still use it.
+func (p *Planner) generateCDCChanges(diff *SchemaDiff) (upSQL, downSQL []string) {
+	var created, restored []string
+	for _, table := range diff.TablesAdded {
+		if table.CDC {
+			created = append(created, table.QualifiedName())
+		}
+	}
+	for _, tableDiff := range diff.TablesModified {
+		switch c := tableDiff.CDCChanged; {
+		case c == nil:
+		case c.New:
+			created = append(created, tableDiff.TableName)
+			downSQL = append(downSQL, p.generateDropCDCTrigger(tableDiff.TableName))
+		default:
+			upSQL = append(upSQL, p.generateDropCDCTrigger(tableDiff.TableName))
+			restored = append(restored, tableDiff.TableName)
+		}
+	}
+
+	if len(created) > 0 {
+		upSQL = append(upSQL, cdcSetupSQL...)
+		for _, name := range created {
+			upSQL = append(upSQL, p.generateCreateCDCTrigger(name))
+		}
+	}
+	if len(restored) > 0 {
+		downSQL = append(downSQL, cdcSetupSQL...)
+		for _, name := range restored {
+			downSQL = append(downSQL, p.generateCreateCDCTrigger(name))
+		}
+	}
+	return upSQL, downSQL
+}
+
+// getCDCTrigger reports whether a table has the CDC trigger.
+func (i *Introspector) getCDCTrigger(ctx context.Context, schemaName, tableName string) (bool, error) {
+	rows, err := i.query(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM pg_trigger
+			WHERE tgrelid = format('%I.%I', $1::text, $2::text)::regclass AND tgname = $3
+		)`, schemaName, tableName, cdcTrigger)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	var exists bool
+	if rows.Next() {
+		if err := rows.Scan(&exists); err != nil {
+			return false, err
+		}
+	}
+	return exists, rows.Err()
+}
+
+var (
+	reCreateCDCTrigger = regexp.MustCompile(`(?is)^\s*CREATE\s+TRIGGER\s+` + cdcTrigger + `\s+.*?\bON\s+((?:"?\w+"?\.)?"?\w+"?)`)
+	reDropCDCTrigger   = regexp.MustCompile(`(?is)^\s*DROP\s+TRIGGER\s+(?:IF\s+EXISTS\s+)?` + cdcTrigger + `\s+ON\s+((?:"?\w+"?\.)?"?\w+"?)`)
+)
+
+// applyCDCTrigger applies a CDC trigger statement to the reconstructed schema.
+func applyCDCTrigger(tables map[string]*schema.TableMetadata, stmt string) {
+	if m := reCreateCDCTrigger.FindStringSubmatch(stmt); m != nil {
+		if table, ok := tables[reconstructTableName(m[1])]; ok {
+			table.CDC = true
+		}
+	} else if m := reDropCDCTrigger.FindStringSubmatch(stmt); m != nil {
+		if table, ok := tables[reconstructTableName(m[1])]; ok {
+			table.CDC = false
+		}
+	}
+}
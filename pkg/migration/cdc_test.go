@@ -0,0 +1,58 @@
+package migration
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+)
+
+func TestGenerateMigration_CDC(t *testing.T) {
+	orders := schema.TableMetadata{Name: "orders", CDC: true, Columns: []schema.ColumnMetadata{{Name: "id", SQLType: "bigint"}}}
+	up, down := NewPlanner().GenerateMigration(&SchemaDiff{TablesAdded: []schema.TableMetadata{orders}})
+
+	setup := strings.Index(up, "CREATE OR REPLACE FUNCTION pebble_cdc_capture()")
+	trigger := strings.Index(up, "CREATE TRIGGER pebble_cdc AFTER INSERT OR UPDATE OR DELETE ON orders FOR EACH ROW EXECUTE FUNCTION pebble_cdc_capture();")
+	if setup < 0 || trigger < setup || strings.Index(up, "CREATE TABLE IF NOT EXISTS orders") > trigger {
+		t.Errorf("expected the table, then the CDC setup, then the trigger, got\n%s", up)
+	}
+	if strings.Contains(down, "pebble_cdc") {
+		t.Errorf("expected dropping the table to drop its trigger, got\n%s", down)
+	}
+
+	// Replaying the migration reconstructs the flag but not the bookkeeping tables.
+	tables := make(map[string]*schema.TableMetadata)
+	applySQLToSchema(tables, up)
+	if len(tables) != 1 || tables["orders"] == nil || !tables["orders"].CDC {
+		t.Errorf("expected only orders, with CDC, got %v", tables)
+	}
+}
+
+func TestCompare_CDC(t *testing.T) {
+	code := map[string]*schema.TableMetadata{"orders": {Name: "orders", CDC: true}}
+	db := map[string]*schema.TableMetadata{"orders": {Name: "orders"}}
+
+	diff := NewDiffer().Compare(code, db)
+	if len(diff.TablesModified) != 1 || diff.TablesModified[0].CDCChanged == nil {
+		t.Fatalf("expected CDC to be enabled, got %+v", diff.TablesModified)
+	}
+	up, down := NewPlanner().GenerateMigration(diff)
+	if !strings.Contains(up, "CREATE TRIGGER pebble_cdc") || !strings.Contains(down, "DROP TRIGGER IF EXISTS pebble_cdc ON orders;") {
+		t.Errorf("unexpected migration\nup:\n%s\ndown:\n%s", up, down)
+	}
+	applySQLToSchema(db, up)
+	if diff := NewDiffer().Compare(code, db); diff.HasChanges() {
+		t.Errorf("expected no changes after replaying, got %+v", diff.TablesModified)
+	}
+
+	code["orders"].CDC = false
+	diff = NewDiffer().Compare(code, db)
+	up, down = NewPlanner().GenerateMigration(diff)
+	if up != "DROP TRIGGER IF EXISTS pebble_cdc ON orders;\n" || !strings.Contains(down, "CREATE TRIGGER pebble_cdc") {
+		t.Errorf("unexpected migration\nup:\n%s\ndown:\n%s", up, down)
+	}
+	applySQLToSchema(db, up)
+	if db["orders"].CDC {
+		t.Error("expected replaying the drop to disable CDC")
+	}
+}
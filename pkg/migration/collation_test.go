@@ -0,0 +1,55 @@
+package migration
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+)
+
+func TestGenerateColumnDefinition_Collation(t *testing.T) {
+	col := schema.ColumnMetadata{Name: "name", SQLType: "text", Collation: "en_US", Nullable: false}
+	if got, want := NewPlanner().generateColumnDefinition(col), `name text COLLATE "en_US" NOT NULL`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestCompare_CollationChange(t *testing.T) {
+	code := map[string]*schema.TableMetadata{"users": {Name: "users", Columns: []schema.ColumnMetadata{
+		{Name: "email", SQLType: "varchar(255)", Collation: "C", Nullable: true},
+	}}}
+	db := map[string]*schema.TableMetadata{"users": {Name: "users", Columns: []schema.ColumnMetadata{
+		{Name: "email", SQLType: "character varying(255)", Nullable: true},
+	}}}
+
+	diff := NewDiffer().Compare(code, db)
+	if len(diff.TablesModified) != 1 || !diff.TablesModified[0].ColumnsModified[0].TypeChanged {
+		t.Fatalf("expected collation change as a type change, got %+v", diff.TablesModified)
+	}
+
+	up, down := NewPlanner().GenerateMigration(diff)
+	if !strings.Contains(up, `ALTER TABLE users ALTER COLUMN email TYPE varchar(255) COLLATE "C";`) {
+		t.Errorf("unexpected up migration:\n%s", up)
+	}
+	if !strings.Contains(down, `ALTER TABLE users ALTER COLUMN email TYPE character varying(255);`) {
+		t.Errorf("unexpected down migration:\n%s", down)
+	}
+
+	// Replaying the up migration converges with the code.
+	applySQLToSchema(db, up)
+	if diff := NewDiffer().Compare(code, db); diff.HasChanges() {
+		t.Errorf("expected no changes after replay, got %+v", diff.TablesModified)
+	}
+}
+
+func TestApplySQLToSchema_Collation(t *testing.T) {
+	tables := make(map[string]*schema.TableMetadata)
+	applySQLToSchema(tables, "CREATE TABLE users (\n    name text COLLATE \"de_DE\" NOT NULL,\n    bio text\n);")
+
+	if col := tables["users"].GetColumnByName("name"); col.Collation != "de_DE" || col.SQLType != "text" || col.Nullable {
+		t.Errorf("unexpected column: %+v", col)
+	}
+	if col := tables["users"].GetColumnByName("bio"); col.Collation != "" {
+		t.Errorf("unexpected collation: %q", col.Collation)
+	}
+}
@@ -0,0 +1,88 @@
+package migration
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+)
+
+var reCommentOn = regexp.MustCompile(`(?is)^\s*COMMENT\s+ON\s+(TABLE|COLUMN)\s+((?:"?\w+"?\.){0,2}"?\w+"?)\s+IS\s+(NULL|'(?:[^']|'')*')\s*;?\s*$`)
+
+// CommentChange represents a changed table comment.
+type CommentChange struct {
+	Old string // Comment in the database
+	New string // Comment in code
+}
+
+// quoteLiteral quotes s as a SQL string literal.
+func quoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// commentValue renders a comment for COMMENT ON ... IS; empty removes it.
+func commentValue(comment string) string {
+	if comment == "" {
+		return "NULL"
+	}
+	return quoteLiteral(comment)
+}
+
+// generateTableComment generates a COMMENT ON TABLE statement.
+func (p *Planner) generateTableComment(tableName, comment string) string {
+	return fmt.Sprintf("COMMENT ON TABLE %s IS %s;", schema.QuoteQualifiedIdent(tableName), commentValue(comment))
+}
+
+// generateColumnComment generates a COMMENT ON COLUMN statement.
+func (p *Planner) generateColumnComment(tableName, columnName, comment string) string {
+	return fmt.Sprintf("COMMENT ON COLUMN %s.%s IS %s;",
+		schema.QuoteQualifiedIdent(tableName), schema.QuoteReservedIdent(columnName), commentValue(comment))
+}
+
+// generateCreateComments generates the comment statements for a new table.
+func (p *Planner) generateCreateComments(table *schema.TableMetadata) []string {
+	var statements []string
+	if table.Comment != "" {
+		statements = append(statements, p.generateTableComment(table.QualifiedName(), table.Comment))
+	}
+	for _, col := range table.Columns {
+		if col.Comment != "" {
+			statements = append(statements, p.generateColumnComment(table.QualifiedName(), col.Name, col.Comment))
+		}
+	}
+	return statements
+}
+
+// applyCommentOn applies a COMMENT ON TABLE/COLUMN statement to the schema.
+func applyCommentOn(tables map[string]*schema.TableMetadata, stmt string) {
+	m := reCommentOn.FindStringSubmatch(stmt)
+	if m == nil {
+		return
+	}
+	comment := ""
+	if !strings.EqualFold(m[3], "NULL") {
+		comment = strings.ReplaceAll(m[3][1:len(m[3])-1], "''", "'")
+	}
+
+	target := strings.ReplaceAll(m[2], `"`, "")
+	if strings.EqualFold(m[1], "TABLE") {
+		if comment == HistoryComment {
+			delete(tables, reconstructTableName(target))
+			return
+		}
+		if table, ok := tables[reconstructTableName(target)]; ok {
+			table.Comment = comment
+		}
+		return
+	}
+	i := strings.LastIndex(target, ".")
+	if i < 0 {
+		return
+	}
+	if table, ok := tables[reconstructTableName(target[:i])]; ok {
+		if col := table.GetColumnByName(strings.ToLower(target[i+1:])); col != nil {
+			col.Comment = comment
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package migration
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+)
+
+func TestGenerateCreateTable_Comments(t *testing.T) {
+	table := &schema.TableMetadata{
+		Name:    "user",
+		Comment: "Registered accounts",
+		Columns: []schema.ColumnMetadata{
+			{Name: "id", SQLType: "integer"},
+			{Name: "email", SQLType: "text", Comment: "Login address, user's primary"},
+		},
+	}
+
+	sql := NewPlanner().generateCreateTable(table)
+
+	for _, want := range []string{
+		`COMMENT ON TABLE "user" IS 'Registered accounts';`,
+		`COMMENT ON COLUMN "user".email IS 'Login address, user''s primary';`,
+	} {
+		if !strings.Contains(sql, want) {
+			t.Errorf("missing %q in:\n%s", want, sql)
+		}
+	}
+	if strings.Contains(sql, "COMMENT ON COLUMN \"user\".id") {
+		t.Errorf("columns without comments must not be commented:\n%s", sql)
+	}
+}
+
+func TestCompare_Comments(t *testing.T) {
+	code := map[string]*schema.TableMetadata{"invoices": {
+		Name:    "invoices",
+		Comment: "Billing documents",
+		Columns: []schema.ColumnMetadata{{Name: "total", SQLType: "numeric", Comment: "Gross amount"}},
+	}}
+	db := map[string]*schema.TableMetadata{"invoices": {
+		Name:    "invoices",
+		Columns: []schema.ColumnMetadata{{Name: "total", SQLType: "numeric", Comment: "Net amount"}},
+	}}
+
+	diff := NewDiffer().Compare(code, db)
+	if len(diff.TablesModified) != 1 {
+		t.Fatalf("expected 1 modified table, got %+v", diff)
+	}
+	up, down := NewPlanner().GenerateMigration(diff)
+
+	wantUp := "COMMENT ON COLUMN invoices.total IS 'Gross amount';\n\nCOMMENT ON TABLE invoices IS 'Billing documents';\n"
+	if up != wantUp {
+		t.Errorf("up =\n%s\nwant\n%s", up, wantUp)
+	}
+	wantDown := "COMMENT ON COLUMN invoices.total IS 'Net amount';\n\nCOMMENT ON TABLE invoices IS NULL;\n"
+	if down != wantDown {
+		t.Errorf("down =\n%s\nwant\n%s", down, wantDown)
+	}
+
+	// Replaying the generated SQL onto the old schema converges with the code.
+	applySQLToSchema(db, up)
+	if diff := NewDiffer().Compare(code, db); diff.HasChanges() {
+		t.Errorf("expected no changes after replaying comments, got %+v", diff.TablesModified)
+	}
+}
+
+func TestApplySQLToSchema_Comments(t *testing.T) {
+	tables := make(map[string]*schema.TableMetadata)
+	applySQLToSchema(tables, `CREATE TABLE billing.invoices (
+    id integer,
+    note text
+);
+
+COMMENT ON TABLE billing.invoices IS 'It''s billed; monthly';
+COMMENT ON COLUMN "billing"."invoices"."note" IS 'Free text';
+COMMENT ON COLUMN billing.invoices.note IS NULL;`)
+
+	table := tables["billing.invoices"]
+	if table == nil {
+		t.Fatal("table not reconstructed")
+	}
+	if table.Comment != "It's billed; monthly" {
+		t.Errorf("table comment = %q", table.Comment)
+	}
+	if col := table.GetColumnByName("note"); col == nil || col.Comment != "" {
+		t.Errorf("column comment should be cleared, got %+v", col)
+	}
+}
@@ -0,0 +1,78 @@
+package migration
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+)
+
+// DefaultMismatch is a column whose default in the model and in the database
+// disagree, so inserts behave differently than either side suggests. Inserts
+// leave a zero field out when the model declares a default, and send it
+// otherwise.
+type DefaultMismatch struct {
+	Table     string
+	Column    string
+	CodeValue *string // Default declared by the model, nil if none
+	DBValue   *string // Default in the database, nil if none
+	Effect    string  // What an insert of a zero value does
+}
+
+// String formats the mismatch as "users.status: <effect>".
+func (m DefaultMismatch) String() string {
+	return fmt.Sprintf("%s.%s: %s", m.Table, m.Column, m.Effect)
+}
+
+// CompareDefaults reports the columns present on both sides whose model and
+// database defaults differ, with what an insert of the field's zero value
+// does as a result. Identity, serial and generated columns are skipped;
+// their values never come from a default.
+func (d *Differ) CompareDefaults(codeSchema, dbSchema map[string]*schema.TableMetadata) []DefaultMismatch {
+	var mismatches []DefaultMismatch
+	for _, name := range slices.Sorted(maps.Keys(codeSchema)) {
+		dbTable, ok := dbSchema[name]
+		if !ok {
+			continue
+		}
+		for _, codeCol := range codeSchema[name].Columns {
+			dbCol := dbTable.GetColumnByName(codeCol.Name)
+			if dbCol == nil || codeCol.Identity != nil || codeCol.Generated != nil ||
+				d.isAutoIncrementColumn(codeCol) || d.isSameDefaultWithSerial(codeCol, *dbCol) {
+				continue
+			}
+			mismatches = append(mismatches, DefaultMismatch{
+				Table:     name,
+				Column:    codeCol.Name,
+				CodeValue: codeCol.Default,
+				DBValue:   dbCol.Default,
+				Effect:    defaultEffect(codeCol, *dbCol),
+			})
+		}
+	}
+	return mismatches
+}
+
+// defaultEffect describes what inserting a zero value does when the model
+// declares codeCol's default and the database has dbCol's.
+func defaultEffect(codeCol, dbCol schema.ColumnMetadata) string {
+	field := codeCol.GoField
+	if field == "" {
+		field = codeCol.Name
+	}
+	switch {
+	case codeCol.Default == nil:
+		return fmt.Sprintf("the model declares no default, so inserts send a zero %s and the database default %s never applies",
+			field, *dbCol.Default)
+	case dbCol.Default == nil && !dbCol.Nullable:
+		return fmt.Sprintf("inserts leave a zero %s out for default %s, which the database lacks, so they fail the NOT NULL constraint",
+			field, *codeCol.Default)
+	case dbCol.Default == nil:
+		return fmt.Sprintf("inserts leave a zero %s out for default %s, which the database lacks, so it is stored as NULL",
+			field, *codeCol.Default)
+	default:
+		return fmt.Sprintf("inserts leave a zero %s out expecting default %s, but the database uses %s",
+			field, *codeCol.Default, *dbCol.Default)
+	}
+}
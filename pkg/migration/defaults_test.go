@@ -0,0 +1,42 @@
+package migration
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+)
+
+func TestCompareDefaults(t *testing.T) {
+	str := func(s string) *string { return &s }
+	code := map[string]*schema.TableMetadata{"posts": {Name: "posts", Columns: []schema.ColumnMetadata{
+		{Name: "id", SQLType: "bigint", Identity: &schema.IdentityColumn{Generation: schema.IdentityAlways}},
+		{Name: "status", GoField: "Status", SQLType: "text"},
+		{Name: "views", GoField: "Views", SQLType: "integer", Default: str("0")},
+		{Name: "score", GoField: "Score", SQLType: "integer", Default: str("0"), Nullable: true},
+		{Name: "kind", GoField: "Kind", SQLType: "text", Default: str("'post'")},
+		{Name: "created_at", GoField: "CreatedAt", SQLType: "timestamptz", Default: str("now()")},
+	}}}
+	db := map[string]*schema.TableMetadata{"posts": {Name: "posts", Columns: []schema.ColumnMetadata{
+		{Name: "id", SQLType: "bigint", Identity: &schema.IdentityColumn{Generation: schema.IdentityAlways}},
+		{Name: "status", SQLType: "text", Default: str("'draft'::text")},
+		{Name: "views", SQLType: "integer"},
+		{Name: "score", SQLType: "integer", Nullable: true},
+		{Name: "kind", SQLType: "text", Default: str("'article'::text")},
+		{Name: "created_at", SQLType: "timestamptz", Default: str("now()")},
+	}}}
+
+	var got []string
+	for _, m := range NewDiffer().CompareDefaults(code, db) {
+		got = append(got, m.String())
+	}
+	want := []string{
+		"posts.status: the model declares no default, so inserts send a zero Status and the database default 'draft'::text never applies",
+		"posts.views: inserts leave a zero Views out for default 0, which the database lacks, so they fail the NOT NULL constraint",
+		"posts.score: inserts leave a zero Score out for default 0, which the database lacks, so it is stored as NULL",
+		"posts.kind: inserts leave a zero Kind out expecting default 'post', but the database uses 'article'::text",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("CompareDefaults() =\n%q\nwant\n%q", got, want)
+	}
+}
@@ -0,0 +1,100 @@
+package migration
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+)
+
+func nodesTable(fk schema.ForeignKeyMetadata) *schema.TableMetadata {
+	return &schema.TableMetadata{
+		Name: "nodes",
+		Columns: []schema.ColumnMetadata{
+			{Name: "id", SQLType: "integer"},
+			{Name: "parent_id", SQLType: "integer", Nullable: true},
+		},
+		ForeignKeys: []schema.ForeignKeyMetadata{fk},
+	}
+}
+
+func TestGenerateForeignKeyDefinition_Timing(t *testing.T) {
+	base := schema.ForeignKeyMetadata{Name: "fk_nodes_parent_id_nodes", Columns: []string{"parent_id"}, ReferencedTable: "nodes", ReferencedColumns: []string{"id"}, OnDelete: schema.Cascade}
+	tests := []struct {
+		name                          string
+		deferrable, initiallyDeferred bool
+		want                          string
+	}{
+		{"immediate", false, false, "ON DELETE CASCADE"},
+		{"deferrable", true, false, "ON DELETE CASCADE DEFERRABLE"},
+		{"initially deferred", true, true, "ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED"},
+	}
+	for _, tt := range tests {
+		fk := base
+		fk.Deferrable, fk.InitiallyDeferred = tt.deferrable, tt.initiallyDeferred
+		if got := NewPlanner().generateForeignKeyDefinition(fk); !strings.HasSuffix(got, tt.want) {
+			t.Errorf("%s: got %q, want suffix %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCompare_ForeignKeyTiming(t *testing.T) {
+	fk := schema.ForeignKeyMetadata{Name: "fk_nodes_parent_id_nodes", Columns: []string{"parent_id"}, ReferencedTable: "nodes", ReferencedColumns: []string{"id"}}
+	deferred := fk
+	deferred.Deferrable, deferred.InitiallyDeferred = true, true
+
+	code := map[string]*schema.TableMetadata{"nodes": nodesTable(deferred)}
+	db := map[string]*schema.TableMetadata{"nodes": nodesTable(fk)}
+
+	diff := NewDiffer().Compare(code, db)
+	if len(diff.TablesModified) != 1 {
+		t.Fatalf("expected a modified table, got %+v", diff)
+	}
+	td := diff.TablesModified[0]
+	if len(td.ForeignKeysDropped) != 1 || len(td.ForeignKeysAdded) != 1 {
+		t.Fatalf("expected the foreign key re-created, got added=%d dropped=%d", len(td.ForeignKeysAdded), len(td.ForeignKeysDropped))
+	}
+
+	up, _ := NewPlanner().GenerateMigration(diff)
+	if !strings.Contains(up, "REFERENCES nodes (id) DEFERRABLE INITIALLY DEFERRED;") {
+		t.Errorf("unexpected up migration:\n%s", up)
+	}
+
+	// Replaying the up migration converges with the code.
+	applySQLToSchema(db, up)
+	if diff := NewDiffer().Compare(code, db); diff.HasChanges() {
+		t.Errorf("expected no changes after replay, got %+v", diff.TablesModified)
+	}
+}
+
+func TestApplySQLToSchema_ForeignKeyTiming(t *testing.T) {
+	tables := make(map[string]*schema.TableMetadata)
+	applySQLToSchema(tables, `CREATE TABLE nodes (
+    id integer NOT NULL,
+    parent_id integer,
+    CONSTRAINT fk_parent FOREIGN KEY (parent_id) REFERENCES nodes (id) ON DELETE SET NULL ON UPDATE CASCADE DEFERRABLE
+);`)
+	fks := tables["nodes"].ForeignKeys
+	if len(fks) != 1 {
+		t.Fatalf("expected 1 foreign key, got %+v", fks)
+	}
+	if fk := fks[0]; fk.OnDelete != schema.SetNull || fk.OnUpdate != schema.Cascade || !fk.Deferrable || fk.InitiallyDeferred {
+		t.Errorf("unexpected foreign key: %+v", fk)
+	}
+}
+
+func TestGenerateMigration_ForeignKeyTimingDown(t *testing.T) {
+	fk := schema.ForeignKeyMetadata{Name: "fk_nodes_parent_id_nodes", Columns: []string{"parent_id"}, ReferencedTable: "nodes", ReferencedColumns: []string{"id"}}
+	deferred := fk
+	deferred.Deferrable = true
+
+	code := map[string]*schema.TableMetadata{"nodes": nodesTable(deferred)}
+	db := map[string]*schema.TableMetadata{"nodes": nodesTable(fk)}
+	_, down := NewPlanner().GenerateMigration(NewDiffer().Compare(code, db))
+
+	// Replaying the down migration restores the original foreign key.
+	applySQLToSchema(code, down)
+	if diff := NewDiffer().Compare(db, code); diff.HasChanges() {
+		t.Errorf("expected no changes after down replay, got %+v", diff.TablesModified)
+	}
+}
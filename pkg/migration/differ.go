@@ -2,20 +2,33 @@ package migration
 
 import (
 	"fmt"
+	"regexp"
 	"slices"
 	"strings"
+	"unicode"
 
 	"github.com/marshallshelly/pebble-orm/pkg/schema"
 )
 
 // Differ compares schemas and generates diffs.
-type Differ struct{}
+type Differ struct {
+	installedExtensions []string
+	sequences           []schema.SequenceMetadata
+}
 
 // NewDiffer creates a new schema differ.
 func NewDiffer() *Differ {
 	return &Differ{}
 }
 
+// WithInstalledExtensions sets the extensions already installed in the
+// database (see Introspector.IntrospectExtensions), so Compare only adds the
+// required extensions that are missing.
+func (d *Differ) WithInstalledExtensions(names []string) *Differ {
+	d.installedExtensions = names
+	return d
+}
+
 // Compare compares code schema (from structs) with database schema.
 // codeSchema: TableMetadata from parsing Go structs
 // dbSchema: TableMetadata from introspecting database
@@ -29,23 +42,44 @@ func (d *Differ) Compare(codeSchema, dbSchema map[string]*schema.TableMetadata)
 		EnumTypesModified: make([]EnumTypeDiff, 0),
 	}
 
+	// Renamed tables are compared against their old definition rather than
+	// dropped and recreated
+	diff.TablesRenamed = d.detectTableRenames(codeSchema, dbSchema)
+	renamedFrom := make(map[string]string)
+	renamedTo := make(map[string]bool)
+	for _, r := range diff.TablesRenamed {
+		renamedFrom[r.New] = r.Old
+		renamedTo[r.Old] = true
+	}
+	dbSchema = renameReferences(dbSchema, diff.TablesRenamed)
+
 	// Find tables that exist in code but not in DB (need to create)
 	for tableName, codeTable := range codeSchema {
-		if _, exists := dbSchema[tableName]; !exists {
+		if codeTable.IsView() || renamedFrom[tableName] != "" {
+			continue
+		}
+		if dbTable, exists := dbSchema[tableName]; !exists || dbTable.IsView() {
 			diff.TablesAdded = append(diff.TablesAdded, *codeTable)
 		}
 	}
 
 	// Find tables that exist in DB but not in code (need to drop)
 	for tableName, dbTable := range dbSchema {
-		if _, exists := codeSchema[tableName]; !exists {
+		if dbTable.IsView() || renamedTo[tableName] {
+			continue
+		}
+		if codeTable, exists := codeSchema[tableName]; !exists || codeTable.IsView() {
 			diff.TablesDropped = append(diff.TablesDropped, *dbTable)
 		}
 	}
 
 	// Find tables that exist in both (check for modifications)
 	for tableName, codeTable := range codeSchema {
-		if dbTable, exists := dbSchema[tableName]; exists {
+		dbName := tableName
+		if old := renamedFrom[tableName]; old != "" {
+			dbName = old
+		}
+		if dbTable, exists := dbSchema[dbName]; exists && !codeTable.IsView() && !dbTable.IsView() {
 			tableDiff := d.compareTable(codeTable, dbTable)
 			if tableDiff.HasChanges() {
 				diff.TablesModified = append(diff.TablesModified, tableDiff)
@@ -53,16 +87,157 @@ func (d *Differ) Compare(codeSchema, dbSchema map[string]*schema.TableMetadata)
 		}
 	}
 
+	// Compare views
+	d.compareViews(codeSchema, dbSchema, diff)
+
 	// Compare enum types across all tables
 	d.compareEnumTypes(codeSchema, dbSchema, diff)
 
+	// Declared sequences that are missing or changed
+	d.compareSequences(codeSchema, diff)
+
+	// Required extensions that aren't installed yet
+	diff.ExtensionsAdded = missingExtensions(requiredExtensionsFor(codeSchema), d.installedExtensions)
+
 	return diff
 }
 
+// compareViews finds views to create, drop or recreate. A view whose
+// database definition is unknown (introspected views carry no query, as
+// PostgreSQL stores a rewritten form) is only recreated when its kind changes.
+func (d *Differ) compareViews(codeSchema, dbSchema map[string]*schema.TableMetadata, diff *SchemaDiff) {
+	for name, codeView := range codeSchema {
+		if !codeView.IsView() {
+			continue
+		}
+		dbView, exists := dbSchema[name]
+		if !exists || !dbView.IsView() {
+			diff.ViewsAdded = append(diff.ViewsAdded, *codeView)
+			continue
+		}
+		kindChanged := codeView.View.Materialized != dbView.View.Materialized
+		queryChanged := dbView.View.Query != "" && normalizeViewQuery(codeView.View.Query) != normalizeViewQuery(dbView.View.Query)
+		if kindChanged || queryChanged {
+			diff.ViewsModified = append(diff.ViewsModified, ViewDiff{Name: name, Old: *dbView, New: *codeView})
+		}
+	}
+
+	for name, dbView := range dbSchema {
+		if !dbView.IsView() {
+			continue
+		}
+		if codeView, exists := codeSchema[name]; !exists || !codeView.IsView() {
+			diff.ViewsDropped = append(diff.ViewsDropped, *dbView)
+		}
+	}
+}
+
+// normalizeViewQuery reduces a view's query to the tokens that matter when
+// comparing a model's query with the definition pg_get_viewdef prints, so a
+// view is only recreated when its query changes. Keywords and unquoted names
+// are lowercased; whitespace, semicolons, parentheses, AS and INNER, column
+// and table qualifiers and casts are dropped, since PostgreSQL adds or
+// removes all of them when it stores a view.
+func normalizeViewQuery(query string) string {
+	tokens := viewQueryTokens(query)
+	out := make([]string, 0, len(tokens))
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		switch {
+		case tok == "(" || tok == ")" || tok == ";" || tok == "as" || tok == "inner":
+			continue
+		case tok == "::":
+			// Skip the type name, which may be several words, as in
+			// ::character varying or ::timestamp with time zone
+			if i+1 < len(tokens) && isViewIdent(tokens[i+1]) {
+				i++
+			}
+			for i+1 < len(tokens) && (slices.Contains(typeNameWords, tokens[i+1]) || tokens[i+1] == "[]") {
+				i++
+			}
+			continue
+		case isViewIdent(tok) && i+2 < len(tokens) && tokens[i+1] == "." && (isViewIdent(tokens[i+2]) || tokens[i+2] == "*"):
+			// Drop the qualifier of a qualified name
+			i++
+			continue
+		case tok == "!=":
+			tok = "<>"
+		}
+		out = append(out, tok)
+	}
+	return strings.Join(out, " ")
+}
+
+// typeNameWords are the words that continue a multi-word type name.
+var typeNameWords = []string{"varying", "precision", "with", "without", "time", "zone"}
+
+// operatorChars are the characters PostgreSQL operators are made of.
+const operatorChars = "+-*/<>=~!@#%^&|`?:"
+
+// viewQueryTokens splits a query into string literals, identifiers (unquoted
+// ones lowercased), numbers and operators.
+func viewQueryTokens(query string) []string {
+	var tokens []string
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case unicode.IsSpace(rune(c)):
+			i++
+		case c == '\'' || c == '"':
+			end := i + 1
+			for end < len(query) {
+				if query[end] == c {
+					if end+1 < len(query) && query[end+1] == c {
+						end += 2
+						continue
+					}
+					break
+				}
+				end++
+			}
+			end = min(end+1, len(query))
+			tokens = append(tokens, query[i:end])
+			i = end
+		case isViewIdentByte(c):
+			end := i + 1
+			for end < len(query) && isViewIdentByte(query[end]) {
+				end++
+			}
+			tokens = append(tokens, strings.ToLower(query[i:end]))
+			i = end
+		case c == '[' && i+1 < len(query) && query[i+1] == ']':
+			tokens = append(tokens, "[]")
+			i += 2
+		case strings.ContainsRune(operatorChars, rune(c)):
+			end := i + 1
+			for end < len(query) && strings.ContainsRune(operatorChars, rune(query[end])) {
+				end++
+			}
+			tokens = append(tokens, query[i:end])
+			i = end
+		default:
+			tokens = append(tokens, string(c))
+			i++
+		}
+	}
+	return tokens
+}
+
+// isViewIdentByte reports whether c may be part of an unquoted identifier,
+// keyword or number.
+func isViewIdentByte(c byte) bool {
+	return c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c >= 0x80
+}
+
+// isViewIdent reports whether tok is an identifier, quoted or not.
+func isViewIdent(tok string) bool {
+	return tok != "" && (tok[0] == '"' || tok[0] == '_' || tok[0] >= 'a' && tok[0] <= 'z' || tok[0] >= 0x80)
+}
+
 // compareTable compares two versions of the same table.
 func (d *Differ) compareTable(codeTable, dbTable *schema.TableMetadata) TableDiff {
 	diff := TableDiff{
-		TableName:          codeTable.Name,
+		TableName:          codeTable.QualifiedName(),
 		ColumnsAdded:       make([]schema.ColumnMetadata, 0),
 		ColumnsDropped:     make([]schema.ColumnMetadata, 0),
 		ColumnsModified:    make([]ColumnDiff, 0),
@@ -89,6 +264,21 @@ func (d *Differ) compareTable(codeTable, dbTable *schema.TableMetadata) TableDif
 	// Compare constraints
 	d.compareConstraints(codeTable, dbTable, &diff)
 
+	// Compare table comment
+	if codeTable.Comment != dbTable.Comment {
+		diff.CommentChanged = &CommentChange{Old: dbTable.Comment, New: codeTable.Comment}
+	}
+
+	// Compare change data capture
+	if codeTable.CDC != dbTable.CDC {
+		diff.CDCChanged = &CDCChange{Old: dbTable.CDC, New: codeTable.CDC}
+	}
+
+	// Compare history tables
+	if codeTable.History != dbTable.History {
+		diff.HistoryChanged = &HistoryChange{Old: dbTable.History, New: codeTable.History, Key: codeTable.PrimaryKeyColumns()}
+	}
+
 	return diff
 }
 
@@ -106,19 +296,30 @@ func (d *Differ) compareColumns(codeTable, dbTable *schema.TableMetadata, diff *
 	}
 
 	// Find columns to add (in code but not in DB)
-	for colName, codeCol := range codeColumns {
-		if _, exists := dbColumns[colName]; !exists {
+	for _, codeCol := range codeTable.Columns {
+		if _, exists := dbColumns[codeCol.Name]; !exists {
 			diff.ColumnsAdded = append(diff.ColumnsAdded, codeCol)
 		}
 	}
 
 	// Find columns to drop (in DB but not in code)
-	for colName, dbCol := range dbColumns {
-		if _, exists := codeColumns[colName]; !exists {
+	for _, dbCol := range dbTable.Columns {
+		if _, exists := codeColumns[dbCol.Name]; !exists {
 			diff.ColumnsDropped = append(diff.ColumnsDropped, dbCol)
 		}
 	}
 
+	// Renamed columns are neither added nor dropped; their definitions are
+	// compared under the old name
+	diff.ColumnsRenamed = d.detectColumnRenames(diff.ColumnsAdded, diff.ColumnsDropped)
+	for _, r := range diff.ColumnsRenamed {
+		diff.ColumnsAdded = slices.DeleteFunc(diff.ColumnsAdded, func(c schema.ColumnMetadata) bool { return c.Name == r.New })
+		diff.ColumnsDropped = slices.DeleteFunc(diff.ColumnsDropped, func(c schema.ColumnMetadata) bool { return c.Name == r.Old })
+		dbCol := dbColumns[r.Old]
+		dbCol.Name = r.New
+		dbColumns[r.New] = dbCol
+	}
+
 	// Find modified columns (exist in both but differ)
 	for colName, codeCol := range codeColumns {
 		if dbCol, exists := dbColumns[colName]; exists {
@@ -139,7 +340,8 @@ func (d *Differ) compareColumn(codeCol, dbCol schema.ColumnMetadata) ColumnDiff
 	}
 
 	// Compare SQL type (normalize for comparison)
-	diff.TypeChanged = !d.isSameType(codeCol.SQLType, dbCol.SQLType)
+	// A collation change is applied as a type change (ALTER COLUMN ... TYPE t COLLATE c).
+	diff.TypeChanged = !d.isSameType(codeCol.SQLType, dbCol.SQLType) || codeCol.Collation != dbCol.Collation
 
 	// Compare nullability
 	diff.NullChanged = (codeCol.Nullable != dbCol.Nullable)
@@ -147,12 +349,50 @@ func (d *Differ) compareColumn(codeCol, dbCol schema.ColumnMetadata) ColumnDiff
 	// Compare default value with special handling for serial/autoincrement columns
 	diff.DefaultChanged = !d.isSameDefaultWithSerial(codeCol, dbCol)
 
+	// Compare column comment
+	diff.CommentChanged = codeCol.Comment != dbCol.Comment
+
+	// Compare generation expression and kind
+	diff.GeneratedChanged = !isSameGenerated(codeCol.Generated, dbCol.Generated)
+
+	// Compare identity presence and generation
+	diff.IdentityChanged = (codeCol.Identity == nil) != (dbCol.Identity == nil) ||
+		(codeCol.Identity != nil && codeCol.Identity.Generation != dbCol.Identity.Generation)
+
+	// Compare identity sequence options set in code
+	if codeCol.Identity != nil && dbCol.Identity != nil {
+		diff.SequenceChanged = sequenceOptionsDiffer(identitySequence(codeCol), identitySequence(dbCol))
+	}
+
 	return diff
 }
 
 // hasChanges returns true if the column has any changes.
 func (c *ColumnDiff) hasChanges() bool {
-	return c.TypeChanged || c.NullChanged || c.DefaultChanged
+	return c.TypeChanged || c.NullChanged || c.DefaultChanged || c.SequenceChanged || c.CommentChanged ||
+		c.IdentityChanged || c.GeneratedChanged
+}
+
+// isSameGenerated reports whether two generated column definitions match.
+// Expressions are compared after normalizeGenerationExpression, since
+// PostgreSQL stores them with added casts and parentheses.
+func isSameGenerated(code, db *schema.GeneratedColumn) bool {
+	if code == nil || db == nil {
+		return code == nil && db == nil
+	}
+	return code.Type == db.Type &&
+		normalizeGenerationExpression(code.Expression) == normalizeGenerationExpression(db.Expression)
+}
+
+// reTypeCast matches the casts PostgreSQL adds when it deparses an
+// expression, such as ::text or ::character varying.
+var reTypeCast = regexp.MustCompile(`::(?:character varying|double precision|time(?:stamp)? with(?:out)? time zone|"?\w+"?)(?:\[\])*`)
+
+// normalizeGenerationExpression makes a generation expression written in a
+// tag comparable with the one information_schema reports: casts are dropped,
+// then case, whitespace and parentheses, as for constraints.
+func normalizeGenerationExpression(expr string) string {
+	return normalizeConstraintExpression(reTypeCast.ReplaceAllString(expr, ""))
 }
 
 // comparePrimaryKey compares primary keys.
@@ -183,44 +423,29 @@ func (d *Differ) comparePrimaryKey(codeTable, dbTable *schema.TableMetadata, dif
 	}
 }
 
-// compareIndexes compares indexes.
+// compareIndexes compares indexes by name, then by definition: an index
+// whose columns, predicate, INCLUDE list or method changed is recorded in
+// IndexesModified.
 func (d *Differ) compareIndexes(codeTable, dbTable *schema.TableMetadata, diff *TableDiff) {
-	// Build maps for easier lookup
-	codeIndexes := make(map[string]schema.IndexMetadata)
-	for _, idx := range codeTable.Indexes {
-		codeIndexes[idx.Name] = idx
-	}
-
 	dbIndexes := make(map[string]schema.IndexMetadata)
 	for _, idx := range dbTable.Indexes {
 		dbIndexes[idx.Name] = idx
 	}
+	codeIndexes := make(map[string]bool)
 
-	// Find indexes to add or replace (modified)
-	for idxName, codeIdx := range codeIndexes {
-		if dbIdx, exists := dbIndexes[idxName]; !exists {
-			// Index doesn't exist - add it
+	for _, codeIdx := range codeTable.Indexes {
+		codeIndexes[codeIdx.Name] = true
+		dbIdx, exists := dbIndexes[codeIdx.Name]
+		if !exists {
 			diff.IndexesAdded = append(diff.IndexesAdded, codeIdx)
-		} else {
-			// Index exists - check if it's different
-			if !d.isSameIndex(codeIdx, dbIdx) {
-				// Index is different - drop old and recreate with new definition
-				diff.IndexesDropped = append(diff.IndexesDropped, dbIdx)
-				diff.IndexesAdded = append(diff.IndexesAdded, codeIdx)
-			}
+		} else if !d.isSameIndex(codeIdx, dbIdx) {
+			diff.IndexesModified = append(diff.IndexesModified, IndexDiff{Old: dbIdx, New: codeIdx})
 		}
 	}
 
-	// Find indexes to drop (only those that don't exist in code and weren't already marked for drop)
-	for idxName, dbIdx := range dbIndexes {
-		if _, exists := codeIndexes[idxName]; !exists {
-			// Check if not already in IndexesDropped (from modification case)
-			alreadyDropped := slices.ContainsFunc(diff.IndexesDropped, func(idx schema.IndexMetadata) bool {
-				return idx.Name == idxName
-			})
-			if !alreadyDropped {
-				diff.IndexesDropped = append(diff.IndexesDropped, dbIdx)
-			}
+	for _, dbIdx := range dbTable.Indexes {
+		if !codeIndexes[dbIdx.Name] {
+			diff.IndexesDropped = append(diff.IndexesDropped, dbIdx)
 		}
 	}
 }
@@ -246,15 +471,13 @@ func (d *Differ) isSameIndex(idx1, idx2 schema.IndexMetadata) bool {
 		return false
 	}
 
-	// Compare expression indexes
-	if idx1.Expression != idx2.Expression {
+	// Compare expression indexes and WHERE clauses (partial indexes) the way
+	// constraint expressions are compared, since pg_get_indexdef adds
+	// parentheses the model usually omits
+	if normalizeConstraintExpression(idx1.Expression) != normalizeConstraintExpression(idx2.Expression) {
 		return false
 	}
-
-	// Compare WHERE clause (partial indexes)
-	where1 := strings.TrimSpace(idx1.Where)
-	where2 := strings.TrimSpace(idx2.Where)
-	if where1 != where2 {
+	if normalizeConstraintExpression(idx1.Where) != normalizeConstraintExpression(idx2.Where) {
 		return false
 	}
 
@@ -374,34 +597,60 @@ func (d *Differ) filterNonDefaultOrderings(orderings []schema.ColumnOrder) []sch
 	return nonDefault
 }
 
-// compareForeignKeys compares foreign keys.
+// compareForeignKeys compares foreign keys by name, then by definition. A
+// foreign key whose columns, referenced table, actions or timing changed is
+// dropped and re-added.
 func (d *Differ) compareForeignKeys(codeTable, dbTable *schema.TableMetadata, diff *TableDiff) {
-	// Build maps for easier lookup
-	codeFKs := make(map[string]schema.ForeignKeyMetadata)
-	for _, fk := range codeTable.ForeignKeys {
-		codeFKs[fk.Name] = fk
-	}
-
 	dbFKs := make(map[string]schema.ForeignKeyMetadata)
 	for _, fk := range dbTable.ForeignKeys {
 		dbFKs[fk.Name] = fk
 	}
+	codeFKs := make(map[string]bool)
 
-	// Find foreign keys to add
-	for fkName, codeFk := range codeFKs {
-		if _, exists := dbFKs[fkName]; !exists {
+	for _, codeFk := range codeTable.ForeignKeys {
+		codeFKs[codeFk.Name] = true
+		dbFk, exists := dbFKs[codeFk.Name]
+		if !exists {
+			diff.ForeignKeysAdded = append(diff.ForeignKeysAdded, codeFk)
+		} else if !d.isSameForeignKey(codeFk, dbFk) {
+			diff.ForeignKeysDropped = append(diff.ForeignKeysDropped, dbFk)
 			diff.ForeignKeysAdded = append(diff.ForeignKeysAdded, codeFk)
 		}
 	}
 
-	// Find foreign keys to drop
-	for fkName, dbFk := range dbFKs {
-		if _, exists := codeFKs[fkName]; !exists {
+	for _, dbFk := range dbTable.ForeignKeys {
+		if !codeFKs[dbFk.Name] {
 			diff.ForeignKeysDropped = append(diff.ForeignKeysDropped, dbFk)
 		}
 	}
 }
 
+// isSameForeignKey compares two foreign keys with the same name. A missing
+// action is NO ACTION, and a referenced table in public matches with or
+// without its schema.
+func (d *Differ) isSameForeignKey(fk1, fk2 schema.ForeignKeyMetadata) bool {
+	if !d.isSameStringSlice(fk1.Columns, fk2.Columns) ||
+		!d.isSameStringSlice(fk1.ReferencedColumns, fk2.ReferencedColumns) {
+		return false
+	}
+	if schema.QualifyTableName(schema.SplitQualifiedName(fk1.ReferencedTable)) !=
+		schema.QualifyTableName(schema.SplitQualifiedName(fk2.ReferencedTable)) {
+		return false
+	}
+
+	action := func(a schema.ReferenceAction) schema.ReferenceAction {
+		if a == "" {
+			return schema.NoAction
+		}
+		return a
+	}
+	if action(fk1.OnDelete) != action(fk2.OnDelete) || action(fk1.OnUpdate) != action(fk2.OnUpdate) {
+		return false
+	}
+
+	return fk1.Deferrable == fk2.Deferrable && fk1.InitiallyDeferred == fk2.InitiallyDeferred
+}
+
 // compareConstraints compares check and unique constraints.
 func (d *Differ) compareConstraints(codeTable, dbTable *schema.TableMetadata, diff *TableDiff) {
 	// Build maps for easier lookup
@@ -418,9 +667,17 @@ func (d *Differ) compareConstraints(codeTable, dbTable *schema.TableMetadata, di
 		dbConstraints[key] = c
 	}
 
-	// Find constraints to add (in code but not in DB)
+	// Find constraints to add (in code but not in DB). An exclusion
+	// constraint whose definition changed is dropped and re-added.
 	for key, codeC := range codeConstraints {
-		if _, exists := dbConstraints[key]; !exists {
+		dbC, exists := dbConstraints[key]
+		if !exists {
+			diff.ConstraintsAdded = append(diff.ConstraintsAdded, codeC)
+			continue
+		}
+		if codeC.Type == schema.ExclusionConstraint &&
+			normalizeConstraintExpression(codeC.Expression) != normalizeConstraintExpression(dbC.Expression) {
+			diff.ConstraintsDropped = append(diff.ConstraintsDropped, dbC)
 			diff.ConstraintsAdded = append(diff.ConstraintsAdded, codeC)
 		}
 	}
@@ -445,6 +702,17 @@ func (d *Differ) getConstraintKey(c schema.ConstraintMetadata) string {
 	return c.Name
 }
 
+// normalizeConstraintExpression strips case, whitespace and parentheses so a
+// constraint written in code compares equal to pg_get_constraintdef's form.
+func normalizeConstraintExpression(expr string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '(' || r == ')' || unicode.IsSpace(r) {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, expr)
+}
+
 // Helper functions
 
 // isSameType compares SQL types, normalizing for common variations.
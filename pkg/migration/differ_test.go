@@ -360,6 +360,18 @@ func TestComparePrimaryKey(t *testing.T) {
 			dbPK:     &schema.PrimaryKeyMetadata{Name: "pk", Columns: []string{"id"}},
 			expected: true,
 		},
+		{
+			name:     "same composite PK",
+			codePK:   &schema.PrimaryKeyMetadata{Name: "pk", Columns: []string{"tenant_id", "id"}},
+			dbPK:     &schema.PrimaryKeyMetadata{Name: "pk", Columns: []string{"tenant_id", "id"}},
+			expected: false,
+		},
+		{
+			name:     "composite PK column order changed",
+			codePK:   &schema.PrimaryKeyMetadata{Name: "pk", Columns: []string{"id", "tenant_id"}},
+			dbPK:     &schema.PrimaryKeyMetadata{Name: "pk", Columns: []string{"tenant_id", "id"}},
+			expected: true,
+		},
 	}
 
 	for _, test := range tests {
@@ -417,6 +429,54 @@ func TestCompareIndexes(t *testing.T) {
 	}
 }
 
+func TestCompareIndexesModified(t *testing.T) {
+	differ := NewDiffer()
+
+	codeTable := &schema.TableMetadata{
+		Name: "users",
+		Indexes: []schema.IndexMetadata{
+			{Name: "idx_active_email", Columns: []string{"email"}, Where: "deleted_at IS NULL"},
+			{Name: "idx_name", Columns: []string{"last_name", "first_name"}},
+			{Name: "idx_lower_email", Expression: "lower(email)"},
+		},
+	}
+
+	dbTable := &schema.TableMetadata{
+		Name: "users",
+		Indexes: []schema.IndexMetadata{
+			{Name: "idx_active_email", Columns: []string{"email"}, Where: "(deleted_at IS NULL)"},
+			{Name: "idx_name", Columns: []string{"last_name"}},
+			{Name: "idx_lower_email", Expression: "LOWER((email))"},
+		},
+	}
+
+	diff := &TableDiff{}
+	differ.compareIndexes(codeTable, dbTable, diff)
+
+	if len(diff.IndexesAdded) != 0 || len(diff.IndexesDropped) != 0 {
+		t.Errorf("Expected no plain adds or drops, got %d added and %d dropped", len(diff.IndexesAdded), len(diff.IndexesDropped))
+	}
+	if len(diff.IndexesModified) != 1 {
+		t.Fatalf("Expected 1 index modified, got %d", len(diff.IndexesModified))
+	}
+	if got := diff.IndexesModified[0]; got.Old.Name != "idx_name" || len(got.New.Columns) != 2 {
+		t.Errorf("Expected idx_name to be modified, got %+v", got)
+	}
+
+	planner := NewPlanner()
+	up, down := planner.generateAlterTable(TableDiff{TableName: "users", IndexesModified: diff.IndexesModified})
+	wantUp := []string{"DROP INDEX IF EXISTS idx_name;", "CREATE INDEX IF NOT EXISTS idx_name ON users (last_name, first_name);"}
+	wantDown := []string{"DROP INDEX IF EXISTS idx_name;", "CREATE INDEX IF NOT EXISTS idx_name ON users (last_name);"}
+	for i := range wantUp {
+		if i >= len(up) || up[i] != wantUp[i] {
+			t.Errorf("up[%d]: expected %q, got %v", i, wantUp[i], up)
+		}
+		if i >= len(down) || down[i] != wantDown[i] {
+			t.Errorf("down[%d]: expected %q, got %v", i, wantDown[i], down)
+		}
+	}
+}
+
 func TestCompareForeignKeys(t *testing.T) {
 	differ := NewDiffer()
 
@@ -462,6 +522,56 @@ func TestCompareForeignKeys(t *testing.T) {
 	}
 }
 
+func TestCompareForeignKeysModified(t *testing.T) {
+	differ := NewDiffer()
+
+	fk := schema.ForeignKeyMetadata{
+		Name:              "fk_posts_user_id_users",
+		Columns:           []string{"user_id"},
+		ReferencedTable:   "users",
+		ReferencedColumns: []string{"id"},
+		OnDelete:          schema.Restrict,
+	}
+	cascade := fk
+	cascade.OnDelete = schema.Cascade
+	qualified := fk
+	qualified.ReferencedTable = "public.users"
+	otherTable := fk
+	otherTable.ReferencedTable = "accounts"
+	noAction := fk
+	noAction.OnDelete = ""
+	explicitNoAction := fk
+	explicitNoAction.OnDelete = schema.NoAction
+
+	tests := []struct {
+		name    string
+		code    schema.ForeignKeyMetadata
+		db      schema.ForeignKeyMetadata
+		changed bool
+	}{
+		{"identical", fk, fk, false},
+		{"on delete changed", cascade, fk, true},
+		{"referenced table changed", otherTable, fk, true},
+		{"public schema is optional", qualified, fk, false},
+		{"missing action is no action", noAction, explicitNoAction, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := &TableDiff{}
+			differ.compareForeignKeys(
+				&schema.TableMetadata{Name: "posts", ForeignKeys: []schema.ForeignKeyMetadata{tt.code}},
+				&schema.TableMetadata{Name: "posts", ForeignKeys: []schema.ForeignKeyMetadata{tt.db}},
+				diff)
+
+			changed := len(diff.ForeignKeysAdded) == 1 && len(diff.ForeignKeysDropped) == 1
+			if changed != tt.changed || (!tt.changed && (len(diff.ForeignKeysAdded) > 0 || len(diff.ForeignKeysDropped) > 0)) {
+				t.Errorf("expected changed=%v, got %d added and %d dropped", tt.changed, len(diff.ForeignKeysAdded), len(diff.ForeignKeysDropped))
+			}
+		})
+	}
+}
+
 func TestHasChanges(t *testing.T) {
 	// SchemaDiff with no changes
 	diff1 := &SchemaDiff{}
@@ -0,0 +1,326 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/marshallshelly/pebble-orm/pkg/registry"
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+)
+
+// DriftChange classifies a difference between the models and the database.
+type DriftChange string
+
+const (
+	// DriftMissing means the models declare an object the database lacks.
+	DriftMissing DriftChange = "missing"
+	// DriftExtra means the database has an object the models don't declare.
+	DriftExtra DriftChange = "extra"
+	// DriftChanged means the object exists on both sides with different definitions.
+	DriftChanged DriftChange = "changed"
+)
+
+// DriftItem is one difference between the models and the database.
+type DriftItem struct {
+	Change DriftChange // missing, extra or changed
+	Object string      // table, column, index, foreign key, constraint, enum, view, extension or sequence
+	Name   string      // Qualified name, e.g. "users.email"
+	Detail string      // What differs, for changed objects
+}
+
+// String formats the item as "missing column users.email".
+func (i DriftItem) String() string {
+	s := fmt.Sprintf("%s %s %s", i.Change, i.Object, i.Name)
+	if i.Detail != "" {
+		s += " (" + i.Detail + ")"
+	}
+	return s
+}
+
+// DriftReport is the result of CheckDrift.
+type DriftReport struct {
+	Differences []DriftItem // Differences between the models and the database
+	Diff        *SchemaDiff // The full diff the differences were derived from
+
+	// Defaults whose model and database values disagree, filled when
+	// DriftOptions.CheckDefaults is set.
+	Defaults []DefaultMismatch
+
+	// Migration tracking, filled when migrations are given (see DriftOptions).
+	Pending  []string // Versions not yet applied
+	Failed   []string // Versions recorded as failed
+	Modified []string // Applied versions whose file changed since
+	Unknown  []string // Versions recorded in schema_migrations without a migration
+}
+
+// HasDrift reports whether the report found any difference.
+func (r DriftReport) HasDrift() bool {
+	return len(r.Differences) > 0 || len(r.Defaults) > 0 || len(r.Pending) > 0 || len(r.Failed) > 0 ||
+		len(r.Modified) > 0 || len(r.Unknown) > 0
+}
+
+// String summarizes the report, one line per finding, for logs and CI output.
+func (r DriftReport) String() string {
+	if !r.HasDrift() {
+		return "no schema drift"
+	}
+	var lines []string
+	for _, item := range r.Differences {
+		lines = append(lines, item.String())
+	}
+	for _, m := range r.Defaults {
+		lines = append(lines, "default "+m.String())
+	}
+	for _, group := range []struct {
+		label    string
+		versions []string
+	}{
+		{"pending migration", r.Pending},
+		{"failed migration", r.Failed},
+		{"modified migration", r.Modified},
+		{"unknown migration", r.Unknown},
+	} {
+		for _, v := range group.versions {
+			lines = append(lines, group.label+" "+v)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// DriftOptions configures CheckDriftWithOptions.
+type DriftOptions struct {
+	// Models to compare against; nil means every registered model.
+	Models map[string]*schema.TableMetadata
+
+	// Migrations to compare against schema_migrations; nil skips the check.
+	Migrations []Migration
+
+	// CheckDefaults explains each column whose model and database defaults
+	// disagree in DriftReport.Defaults: what inserting a zero value does.
+	CheckDefaults bool
+}
+
+// CheckDrift compares the live database to the registered models. It only
+// reads from the database, so it is safe to run at startup or in CI:
+//
+//	report, err := migration.CheckDrift(ctx, pool)
+//	if err != nil {
+//		return err
+//	}
+//	if report.HasDrift() {
+//		log.Printf("schema drift:\n%s", report)
+//	}
+func CheckDrift(ctx context.Context, db *pgxpool.Pool) (DriftReport, error) {
+	return CheckDriftWithOptions(ctx, db, DriftOptions{})
+}
+
+// CheckDriftWithOptions is CheckDrift with explicit models and, optionally,
+// the migrations to check schema_migrations against.
+func CheckDriftWithOptions(ctx context.Context, db *pgxpool.Pool, opts DriftOptions) (DriftReport, error) {
+	models := opts.Models
+	if models == nil {
+		models = registry.AllTables()
+	}
+
+	introspector := NewIntrospector(db).WithSchemas(SchemasOf(models)...)
+	dbSchema, err := introspector.IntrospectSchema(ctx)
+	if err != nil {
+		return DriftReport{}, fmt.Errorf("failed to introspect database: %w", err)
+	}
+	extensions, err := introspector.IntrospectExtensions(ctx)
+	if err != nil {
+		return DriftReport{}, fmt.Errorf("failed to introspect extensions: %w", err)
+	}
+	sequences, err := introspector.IntrospectSequences(ctx)
+	if err != nil {
+		return DriftReport{}, fmt.Errorf("failed to introspect sequences: %w", err)
+	}
+
+	differ := NewDiffer().WithInstalledExtensions(extensions).WithSequences(sequences)
+	diff := differ.Compare(models, dbSchema)
+	report := DriftReport{Differences: driftItems(diff), Diff: diff}
+	if opts.CheckDefaults {
+		report.Defaults = differ.CompareDefaults(models, dbSchema)
+	}
+
+	if opts.Migrations != nil {
+		var tracked bool
+		if err := db.QueryRow(ctx, "SELECT to_regclass('schema_migrations') IS NOT NULL").Scan(&tracked); err != nil {
+			return report, fmt.Errorf("failed to check for schema_migrations: %w", err)
+		}
+		var records []MigrationRecord
+		if tracked {
+			if records, err = NewExecutor(db, "").GetAllMigrations(ctx); err != nil {
+				return report, err
+			}
+		}
+		report.Pending, report.Failed, report.Modified, report.Unknown = migrationDrift(records, opts.Migrations)
+	}
+	return report, nil
+}
+
+// CheckDrift compares the database to the registered models and its
+// schema_migrations records to the runner's migrations. Unlike Status, it
+// doesn't create the tracking table.
+func (r *Runner) CheckDrift(ctx context.Context) (DriftReport, error) {
+	migrations, err := r.loadMigrations()
+	if err != nil {
+		return DriftReport{}, err
+	}
+	return CheckDriftWithOptions(ctx, r.executor.pool, DriftOptions{Migrations: migrations})
+}
+
+// migrationDrift sorts schema_migrations records against the known
+// migrations.
+func migrationDrift(records []MigrationRecord, migrations []Migration) (pending, failed, modified, unknown []string) {
+	for _, record := range migrationStatus(records, migrations) {
+		switch record.Status {
+		case StatusPending:
+			pending = append(pending, record.Version)
+		case StatusFailed:
+			failed = append(failed, record.Version)
+		case StatusModified:
+			modified = append(modified, record.Version)
+		}
+	}
+	for _, record := range records {
+		if !slices.ContainsFunc(migrations, func(m Migration) bool { return m.Version == record.Version }) {
+			unknown = append(unknown, record.Version)
+		}
+	}
+	return pending, failed, modified, unknown
+}
+
+// driftItems flattens a schema diff into drift items: objects the models
+// declare are "missing" from the database, objects only in the database are
+// "extra".
+func driftItems(diff *SchemaDiff) []DriftItem {
+	var items []DriftItem
+	add := func(change DriftChange, object, name, detail string) {
+		items = append(items, DriftItem{Change: change, Object: object, Name: name, Detail: detail})
+	}
+
+	for _, name := range diff.ExtensionsAdded {
+		add(DriftMissing, "extension", name, "")
+	}
+	for _, seq := range diff.SequencesAdded {
+		add(DriftMissing, "sequence", seq.Name, "")
+	}
+	for _, seqDiff := range diff.SequencesModified {
+		add(DriftChanged, "sequence", seqDiff.Name, "options")
+	}
+	for _, enum := range diff.EnumTypesAdded {
+		add(DriftMissing, "enum", enum.Name, "")
+	}
+	for _, enum := range diff.EnumTypesDropped {
+		add(DriftExtra, "enum", enum.Name, "")
+	}
+	for _, enumDiff := range diff.EnumTypesModified {
+		add(DriftChanged, "enum", enumDiff.Name, "values "+strings.Join(enumDiff.NewValues, ", ")+" missing")
+	}
+	for _, table := range diff.TablesAdded {
+		add(DriftMissing, "table", table.QualifiedName(), "")
+	}
+	for _, table := range diff.TablesDropped {
+		add(DriftExtra, "table", table.QualifiedName(), "")
+	}
+	for _, r := range diff.TablesRenamed {
+		add(DriftChanged, "table", r.New, "still named "+r.Old)
+	}
+	for _, tableDiff := range diff.TablesModified {
+		items = append(items, tableDriftItems(tableDiff)...)
+	}
+	for _, view := range diff.ViewsAdded {
+		add(DriftMissing, "view", view.QualifiedName(), "")
+	}
+	for _, view := range diff.ViewsDropped {
+		add(DriftExtra, "view", view.QualifiedName(), "")
+	}
+	for _, viewDiff := range diff.ViewsModified {
+		add(DriftChanged, "view", viewDiff.Name, "definition")
+	}
+	return items
+}
+
+// identityDescription describes a column's identity for a drift report.
+func identityDescription(col schema.ColumnMetadata) string {
+	if col.Identity == nil {
+		return "none"
+	}
+	return string(col.Identity.Generation)
+}
+
+// tableDriftItems flattens the changes to one table.
+func tableDriftItems(diff TableDiff) []DriftItem {
+	var items []DriftItem
+	add := func(change DriftChange, object, name, detail string) {
+		items = append(items, DriftItem{Change: change, Object: object, Name: diff.TableName + "." + name, Detail: detail})
+	}
+
+	for _, col := range diff.ColumnsAdded {
+		add(DriftMissing, "column", col.Name, "")
+	}
+	for _, col := range diff.ColumnsDropped {
+		add(DriftExtra, "column", col.Name, "")
+	}
+	for _, r := range diff.ColumnsRenamed {
+		add(DriftChanged, "column", r.New, "still named "+r.Old)
+	}
+	for _, colDiff := range diff.ColumnsModified {
+		var details []string
+		if colDiff.TypeChanged {
+			details = append(details, fmt.Sprintf("type %s, database has %s", colDiff.NewColumn.SQLType, colDiff.OldColumn.SQLType))
+		}
+		if colDiff.NullChanged {
+			details = append(details, fmt.Sprintf("nullable %v, database has %v", colDiff.NewColumn.Nullable, colDiff.OldColumn.Nullable))
+		}
+		if colDiff.DefaultChanged {
+			details = append(details, "default")
+		}
+		if colDiff.IdentityChanged {
+			details = append(details, fmt.Sprintf("identity %s, database has %s",
+				identityDescription(colDiff.NewColumn), identityDescription(colDiff.OldColumn)))
+		}
+		if colDiff.SequenceChanged {
+			details = append(details, "identity sequence")
+		}
+		if colDiff.CommentChanged {
+			details = append(details, "comment")
+		}
+		if colDiff.GeneratedChanged {
+			details = append(details, "generated expression")
+		}
+		add(DriftChanged, "column", colDiff.ColumnName, strings.Join(details, "; "))
+	}
+	for _, idx := range diff.IndexesAdded {
+		add(DriftMissing, "index", idx.Name, "")
+	}
+	for _, idx := range diff.IndexesDropped {
+		add(DriftExtra, "index", idx.Name, "")
+	}
+	for _, idxDiff := range diff.IndexesModified {
+		add(DriftChanged, "index", idxDiff.New.Name, "definition")
+	}
+	for _, fk := range diff.ForeignKeysAdded {
+		add(DriftMissing, "foreign key", fk.Name, "")
+	}
+	for _, fk := range diff.ForeignKeysDropped {
+		add(DriftExtra, "foreign key", fk.Name, "")
+	}
+	for _, c := range diff.ConstraintsAdded {
+		add(DriftMissing, "constraint", c.Name, "")
+	}
+	for _, c := range diff.ConstraintsDropped {
+		add(DriftExtra, "constraint", c.Name, "")
+	}
+	if diff.PrimaryKeyChanged != nil {
+		add(DriftChanged, "primary key", "", "columns")
+	}
+	if diff.CommentChanged != nil {
+		items = append(items, DriftItem{Change: DriftChanged, Object: "table", Name: diff.TableName, Detail: "comment"})
+	}
+	return items
+}
@@ -0,0 +1,87 @@
+package migration
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+)
+
+func TestDriftItems(t *testing.T) {
+	diff := &SchemaDiff{
+		TablesAdded:   []schema.TableMetadata{{Name: "posts"}},
+		TablesDropped: []schema.TableMetadata{{Name: "legacy"}},
+		TablesModified: []TableDiff{{
+			TableName:      "users",
+			ColumnsAdded:   []schema.ColumnMetadata{{Name: "email"}},
+			ColumnsDropped: []schema.ColumnMetadata{{Name: "nickname"}},
+			ColumnsModified: []ColumnDiff{{
+				ColumnName:  "age",
+				OldColumn:   schema.ColumnMetadata{Name: "age", SQLType: "text", Nullable: true},
+				NewColumn:   schema.ColumnMetadata{Name: "age", SQLType: "integer"},
+				TypeChanged: true,
+				NullChanged: true,
+			}},
+			IndexesAdded: []schema.IndexMetadata{{Name: "idx_users_email"}},
+		}},
+	}
+
+	var got []string
+	for _, item := range driftItems(diff) {
+		got = append(got, item.String())
+	}
+	want := []string{
+		"missing table posts",
+		"extra table legacy",
+		"missing column users.email",
+		"extra column users.nickname",
+		"changed column users.age (type integer, database has text; nullable false, database has true)",
+		"missing index users.idx_users_email",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("driftItems() =\n%q\nwant\n%q", got, want)
+	}
+
+	if items := driftItems(&SchemaDiff{}); len(items) != 0 {
+		t.Errorf("expected no items for an empty diff, got %v", items)
+	}
+}
+
+func TestMigrationDrift(t *testing.T) {
+	migrations := []Migration{
+		{Version: "20240101000000", Name: "create_users", UpSQL: "CREATE TABLE users (id integer);"},
+		{Version: "20240102000000", Name: "add_email", UpSQL: "ALTER TABLE users ADD COLUMN email text;"},
+		{Version: "20240103000000", Name: "create_posts", UpSQL: "CREATE TABLE posts (id integer);"},
+		{Version: "20240104000000", Name: "add_title", UpSQL: "ALTER TABLE posts ADD COLUMN title text;"},
+	}
+	checksum := migrations[0].Checksum()
+	stale := "stale"
+	records := []MigrationRecord{
+		{Version: "20240101000000", Name: "create_users", Status: StatusApplied, Checksum: &checksum},
+		{Version: "20240102000000", Name: "add_email", Status: StatusApplied, Checksum: &stale},
+		{Version: "20240103000000", Name: "create_posts", Status: StatusFailed},
+		{Version: "20231231000000", Name: "removed", Status: StatusApplied},
+	}
+
+	pending, failed, modified, unknown := migrationDrift(records, migrations)
+	if !slices.Equal(pending, []string{"20240104000000"}) {
+		t.Errorf("pending = %v", pending)
+	}
+	if !slices.Equal(failed, []string{"20240103000000"}) {
+		t.Errorf("failed = %v", failed)
+	}
+	if !slices.Equal(modified, []string{"20240102000000"}) {
+		t.Errorf("modified = %v", modified)
+	}
+	if !slices.Equal(unknown, []string{"20231231000000"}) {
+		t.Errorf("unknown = %v", unknown)
+	}
+
+	report := DriftReport{Pending: pending}
+	if !report.HasDrift() || report.String() != "pending migration 20240104000000" {
+		t.Errorf("unexpected report %q", report.String())
+	}
+	if (DriftReport{}).HasDrift() {
+		t.Error("expected an empty report to have no drift")
+	}
+}
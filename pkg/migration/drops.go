@@ -0,0 +1,95 @@
+package migration
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// DropConfirmationError is returned by GenerateMigrationChecked when a diff
+// drops tables or columns that haven't been confirmed with DropToken.
+type DropConfirmationError struct {
+	Drops []string // Dropped tables ("users") and columns ("users.email")
+	Token string   // Token that confirms exactly these drops
+}
+
+func (e *DropConfirmationError) Error() string {
+	return fmt.Sprintf("migration drops %s; confirm with token %s", strings.Join(e.Drops, ", "), e.Token)
+}
+
+// DroppedObjects lists the tables and columns a diff drops, sorted.
+func DroppedObjects(diff *SchemaDiff) []string {
+	var drops []string
+	for _, table := range diff.TablesDropped {
+		drops = append(drops, table.QualifiedName())
+	}
+	for _, tableDiff := range diff.TablesModified {
+		for _, col := range tableDiff.ColumnsDropped {
+			drops = append(drops, tableDiff.TableName+"."+col.Name)
+		}
+	}
+	slices.Sort(drops)
+	return drops
+}
+
+// DropToken returns a short token identifying the drops of a diff, or "" if
+// it drops nothing. The token changes whenever the set of drops does, so a
+// confirmation can't carry over to drops nobody reviewed.
+func DropToken(diff *SchemaDiff) string {
+	drops := DroppedObjects(diff)
+	if len(drops) == 0 {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(strings.Join(drops, "\n")))
+	return hex.EncodeToString(sum[:4])
+}
+
+// GenerateMigrationChecked is GenerateMigration that fails with a
+// *DropConfirmationError instead of holding back unconfirmed drops when
+// RequireConfirmToken is set.
+func (p *Planner) GenerateMigrationChecked(diff *SchemaDiff) (upSQL, downSQL string, err error) {
+	if err := p.checkDropConfirmation(diff); err != nil {
+		return "", "", err
+	}
+	upSQL, downSQL = p.GenerateMigration(diff)
+	return upSQL, downSQL, nil
+}
+
+// checkDropConfirmation returns a *DropConfirmationError if the planner
+// requires the diff's drops to be confirmed and they are not.
+func (p *Planner) checkDropConfirmation(diff *SchemaDiff) error {
+	if p.options.RequireConfirmToken && !p.options.DisallowDrops {
+		if token := DropToken(diff); token != "" && token != p.options.ConfirmToken {
+			return &DropConfirmationError{Drops: DroppedObjects(diff), Token: token}
+		}
+	}
+	return nil
+}
+
+// dropsAllowed reports whether the drop policy lets the diff's drops through.
+func (p *Planner) dropsAllowed(diff *SchemaDiff) bool {
+	if p.options.DisallowDrops {
+		return false
+	}
+	if p.options.RequireConfirmToken {
+		return p.options.ConfirmToken == DropToken(diff)
+	}
+	return true
+}
+
+// holdBackDrops comments out statements held back by the drop policy.
+func (p *Planner) holdBackDrops(statements []string, diff *SchemaDiff) string {
+	reason := "-- DESTRUCTIVE CHANGE HELD BACK (DisallowDrops): review and uncomment to apply"
+	if !p.options.DisallowDrops {
+		reason = fmt.Sprintf("-- DESTRUCTIVE CHANGE HELD BACK: regenerate with confirm token %s to apply", DropToken(diff))
+	}
+	lines := []string{reason}
+	for _, stmt := range statements {
+		for line := range strings.SplitSeq(stmt, "\n") {
+			lines = append(lines, "-- "+line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,98 @@
+package migration
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+)
+
+func dropDiff() *SchemaDiff {
+	return &SchemaDiff{
+		TablesDropped: []schema.TableMetadata{{
+			Name:    "legacy_users",
+			Columns: []schema.ColumnMetadata{{Name: "id", SQLType: "integer"}},
+		}},
+		TablesModified: []TableDiff{{
+			TableName:      "posts",
+			ColumnsAdded:   []schema.ColumnMetadata{{Name: "slug", SQLType: "text", Nullable: true}},
+			ColumnsDropped: []schema.ColumnMetadata{{Name: "permalink", SQLType: "text", Nullable: true}},
+		}},
+	}
+}
+
+func TestDropToken(t *testing.T) {
+	diff := dropDiff()
+	if got := DroppedObjects(diff); strings.Join(got, ",") != "legacy_users,posts.permalink" {
+		t.Errorf("unexpected dropped objects: %v", got)
+	}
+	token := DropToken(diff)
+	if len(token) != 8 {
+		t.Errorf("expected an 8-character token, got %q", token)
+	}
+
+	diff.TablesDropped = nil
+	if DropToken(diff) == token {
+		t.Error("token should change with the set of drops")
+	}
+	if DropToken(&SchemaDiff{}) != "" {
+		t.Error("expected no token for a diff without drops")
+	}
+}
+
+func TestPlannerDropPolicy(t *testing.T) {
+	t.Run("drops allowed by default", func(t *testing.T) {
+		up, _ := NewPlanner().GenerateMigration(dropDiff())
+		if !strings.Contains(up, "\nDROP TABLE IF EXISTS") && !strings.HasPrefix(up, "DROP TABLE IF EXISTS") {
+			t.Errorf("expected DROP TABLE, got:\n%s", up)
+		}
+	})
+
+	t.Run("DisallowDrops comments drops out", func(t *testing.T) {
+		up, down := NewPlannerWithOptions(PlannerOptions{DisallowDrops: true}).GenerateMigration(dropDiff())
+		for _, stmt := range splitSQLStatements(up) {
+			if strings.Contains(stmt, "DROP") {
+				t.Errorf("expected drops to be commented out, got statement: %s", stmt)
+			}
+		}
+		if !strings.Contains(up, `-- DROP TABLE IF EXISTS "legacy_users";`) || !strings.Contains(up, "-- ALTER TABLE posts DROP COLUMN IF EXISTS permalink;") {
+			t.Errorf("expected commented-out drops, got:\n%s", up)
+		}
+		if !strings.Contains(up, "ALTER TABLE posts ADD COLUMN slug text;") {
+			t.Errorf("expected non-destructive changes to be kept, got:\n%s", up)
+		}
+		for _, stmt := range splitSQLStatements(down) {
+			if strings.Contains(stmt, "CREATE TABLE") || strings.Contains(stmt, "ADD COLUMN permalink") {
+				t.Errorf("expected the down counterparts to be commented out, got statement: %s", stmt)
+			}
+		}
+	})
+
+	t.Run("RequireConfirmToken", func(t *testing.T) {
+		diff := dropDiff()
+		_, _, err := NewPlannerWithOptions(PlannerOptions{RequireConfirmToken: true}).GenerateMigrationChecked(diff)
+		var dropErr *DropConfirmationError
+		if !errors.As(err, &dropErr) || dropErr.Token != DropToken(diff) {
+			t.Fatalf("expected a DropConfirmationError, got %v", err)
+		}
+
+		up, _, err := NewPlannerWithOptions(PlannerOptions{RequireConfirmToken: true, ConfirmToken: "wrong"}).GenerateMigrationChecked(diff)
+		if err == nil {
+			t.Errorf("expected a wrong token to fail, got:\n%s", up)
+		}
+
+		up, _, err = NewPlannerWithOptions(PlannerOptions{RequireConfirmToken: true, ConfirmToken: dropErr.Token}).GenerateMigrationChecked(diff)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !strings.Contains(up, "ALTER TABLE posts DROP COLUMN IF EXISTS permalink;") || strings.Contains(up, "HELD BACK") {
+			t.Errorf("expected confirmed drops to be emitted, got:\n%s", up)
+		}
+
+		up, _ = NewPlannerWithOptions(PlannerOptions{RequireConfirmToken: true}).GenerateMigration(diff)
+		if !strings.Contains(up, "confirm token "+dropErr.Token) {
+			t.Errorf("expected unconfirmed drops to be held back with the token, got:\n%s", up)
+		}
+	})
+}
@@ -0,0 +1,85 @@
+package migration
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+)
+
+func bookingsTable(expr string) *schema.TableMetadata {
+	table := &schema.TableMetadata{Name: "bookings", Columns: []schema.ColumnMetadata{
+		{Name: "room_id", SQLType: "integer"},
+		{Name: "during", SQLType: "tstzrange"},
+	}}
+	if expr != "" {
+		table.Constraints = []schema.ConstraintMetadata{{Name: "no_overlap", Type: schema.ExclusionConstraint, Expression: expr}}
+	}
+	return table
+}
+
+func TestGenerateCreateTable_Exclusion(t *testing.T) {
+	sql := NewPlanner().generateCreateTable(bookingsTable("USING gist (room_id WITH =, during WITH &&)"))
+	if !strings.Contains(sql, "CONSTRAINT no_overlap EXCLUDE USING gist (room_id WITH =, during WITH &&)") {
+		t.Errorf("missing exclusion constraint:\n%s", sql)
+	}
+}
+
+func TestCompare_Exclusion(t *testing.T) {
+	const expr = "USING gist (room_id WITH =, during WITH &&)"
+	tests := []struct {
+		name        string
+		code, db    string
+		wantAdded   int
+		wantDropped int
+	}{
+		{"added", expr, "", 1, 0},
+		{"dropped", "", expr, 0, 1},
+		{"unchanged", expr, "USING gist (room_id WITH =, during WITH &&)", 0, 0},
+		{"different formatting", expr, "using GIST ( room_id with =, during with && )", 0, 0},
+		{"changed", expr, "USING gist (during WITH &&)", 1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := NewDiffer().Compare(
+				map[string]*schema.TableMetadata{"bookings": bookingsTable(tt.code)},
+				map[string]*schema.TableMetadata{"bookings": bookingsTable(tt.db)},
+			)
+			var added, dropped int
+			for _, td := range diff.TablesModified {
+				added += len(td.ConstraintsAdded)
+				dropped += len(td.ConstraintsDropped)
+			}
+			if added != tt.wantAdded || dropped != tt.wantDropped {
+				t.Errorf("added=%d dropped=%d, want %d/%d", added, dropped, tt.wantAdded, tt.wantDropped)
+			}
+		})
+	}
+}
+
+func TestApplySQLToSchema_Exclusion(t *testing.T) {
+	code := map[string]*schema.TableMetadata{"bookings": bookingsTable("USING gist (room_id WITH =, during WITH &&)")}
+	db := map[string]*schema.TableMetadata{"bookings": bookingsTable("")}
+
+	diff := NewDiffer().Compare(code, db)
+	up, down := NewPlanner().GenerateMigration(diff)
+	if !strings.Contains(up, "ALTER TABLE bookings ADD CONSTRAINT no_overlap EXCLUDE USING gist (room_id WITH =, during WITH &&);") {
+		t.Errorf("unexpected up migration:\n%s", up)
+	}
+
+	applySQLToSchema(db, up)
+	if diff := NewDiffer().Compare(code, db); diff.HasChanges() {
+		t.Errorf("expected no changes after replay, got %+v", diff.TablesModified)
+	}
+
+	applySQLToSchema(db, down)
+	if len(db["bookings"].Constraints) != 0 {
+		t.Errorf("expected constraint dropped, got %+v", db["bookings"].Constraints)
+	}
+
+	tables := make(map[string]*schema.TableMetadata)
+	applySQLToSchema(tables, NewPlanner().generateCreateTable(code["bookings"]))
+	if c := tables["bookings"].Constraints; len(c) != 1 || c[0].Type != schema.ExclusionConstraint || c[0].Name != "no_overlap" {
+		t.Errorf("unexpected constraints: %+v", c)
+	}
+}
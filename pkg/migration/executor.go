@@ -2,6 +2,7 @@ package migration
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -12,18 +13,28 @@ import (
 
 // Executor executes and tracks database migrations.
 type Executor struct {
-	pool          *pgxpool.Pool
-	migrationsDir string
-	lockID        int64         // PostgreSQL advisory lock ID
-	lockConn      *pgxpool.Conn // dedicated connection holding the session-scoped advisory lock
+	pool           *pgxpool.Pool
+	migrationsDir  string
+	lockID         int64         // PostgreSQL advisory lock ID
+	lockConn       *pgxpool.Conn // dedicated connection holding the session-scoped advisory lock
+	lockTimeout    time.Duration // how long Lock waits for the lock; 0 waits indefinitely
+	largeTableRows int64         // row estimate above which Plan flags blocking index builds
 }
 
+// ErrLockTimeout is returned by Lock when another process still holds the
+// migration lock after the configured timeout.
+var ErrLockTimeout = errors.New("timed out waiting for migration lock")
+
+// lockRetryInterval is how often Lock retries while waiting with a timeout.
+const lockRetryInterval = 250 * time.Millisecond
+
 // NewExecutor creates a new migration executor.
 func NewExecutor(pool *pgxpool.Pool, migrationsDir string) *Executor {
 	return &Executor{
-		pool:          pool,
-		migrationsDir: migrationsDir,
-		lockID:        1234567890, // Default lock ID
+		pool:           pool,
+		migrationsDir:  migrationsDir,
+		lockID:         1234567890, // Default lock ID
+		largeTableRows: DefaultLargeTableRows,
 	}
 }
 
@@ -33,6 +44,20 @@ func (e *Executor) WithLockID(lockID int64) *Executor {
 	return e
 }
 
+// WithLockTimeout bounds how long Lock waits for a lock held by another
+// process, such as a second replica migrating at the same time.
+func (e *Executor) WithLockTimeout(timeout time.Duration) *Executor {
+	e.lockTimeout = timeout
+	return e
+}
+
+// WithLargeTableThreshold sets the estimated row count above which Plan warns
+// about index builds that block writes.
+func (e *Executor) WithLargeTableThreshold(rows int64) *Executor {
+	e.largeTableRows = rows
+	return e
+}
+
 // Initialize creates the schema_migrations table if it doesn't exist.
 func (e *Executor) Initialize(ctx context.Context) error {
 	query := `
@@ -42,9 +67,12 @@ func (e *Executor) Initialize(ctx context.Context) error {
 			status VARCHAR(20) NOT NULL DEFAULT 'pending',
 			applied_at TIMESTAMP,
 			error TEXT,
+			checksum VARCHAR(64),
 			created_at TIMESTAMP NOT NULL DEFAULT NOW()
 		);
 
+		ALTER TABLE schema_migrations ADD COLUMN IF NOT EXISTS checksum VARCHAR(64);
+
 		CREATE INDEX IF NOT EXISTS idx_schema_migrations_status
 		ON schema_migrations(status);
 	`
@@ -67,10 +95,16 @@ func (e *Executor) Initialize(ctx context.Context) error {
 // migrations. The lock is held on a dedicated connection so that Unlock runs on
 // the same session — issuing lock and unlock through the pool would land on
 // different pooled connections, leaving the lock stuck on an idle one.
+//
+// With a lock timeout set, Lock polls for the lock and returns ErrLockTimeout
+// once the timeout elapses; otherwise it blocks until the lock is free.
 func (e *Executor) Lock(ctx context.Context) error {
 	if e.lockConn != nil {
 		return fmt.Errorf("migration lock already held")
 	}
+	if e.lockTimeout > 0 {
+		return e.lockWithTimeout(ctx)
+	}
 	conn, err := e.pool.Acquire(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to acquire connection for migration lock: %w", err)
@@ -83,6 +117,28 @@ func (e *Executor) Lock(ctx context.Context) error {
 	return nil
 }
 
+// lockWithTimeout retries TryLock until it succeeds or the lock timeout elapses.
+func (e *Executor) lockWithTimeout(ctx context.Context) error {
+	deadline := time.Now().Add(e.lockTimeout)
+	for {
+		acquired, err := e.TryLock(ctx)
+		if err != nil {
+			return err
+		}
+		if acquired {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%w after %s", ErrLockTimeout, e.lockTimeout)
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("failed to acquire migration lock: %w", ctx.Err())
+		case <-time.After(lockRetryInterval):
+		}
+	}
+}
+
 // Unlock releases the advisory lock on the connection that acquired it.
 func (e *Executor) Unlock(ctx context.Context) error {
 	if e.lockConn == nil {
@@ -128,7 +184,7 @@ func (e *Executor) TryLock(ctx context.Context) (bool, error) {
 // GetAppliedMigrations returns all migrations that have been applied.
 func (e *Executor) GetAppliedMigrations(ctx context.Context) ([]MigrationRecord, error) {
 	query := `
-		SELECT version, name, status, applied_at, error
+		SELECT version, name, status, applied_at, error, checksum
 		FROM schema_migrations
 		WHERE status = 'applied'
 		ORDER BY version ASC
@@ -143,7 +199,7 @@ func (e *Executor) GetAppliedMigrations(ctx context.Context) ([]MigrationRecord,
 	var records []MigrationRecord
 	for rows.Next() {
 		var record MigrationRecord
-		err := rows.Scan(&record.Version, &record.Name, &record.Status, &record.AppliedAt, &record.Error)
+		err := rows.Scan(&record.Version, &record.Name, &record.Status, &record.AppliedAt, &record.Error, &record.Checksum)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan migration record: %w", err)
 		}
@@ -156,7 +212,7 @@ func (e *Executor) GetAppliedMigrations(ctx context.Context) ([]MigrationRecord,
 // GetAllMigrations returns all migration records.
 func (e *Executor) GetAllMigrations(ctx context.Context) ([]MigrationRecord, error) {
 	query := `
-		SELECT version, name, status, applied_at, error
+		SELECT version, name, status, applied_at, error, checksum
 		FROM schema_migrations
 		ORDER BY version ASC
 	`
@@ -170,7 +226,7 @@ func (e *Executor) GetAllMigrations(ctx context.Context) ([]MigrationRecord, err
 	var records []MigrationRecord
 	for rows.Next() {
 		var record MigrationRecord
-		err := rows.Scan(&record.Version, &record.Name, &record.Status, &record.AppliedAt, &record.Error)
+		err := rows.Scan(&record.Version, &record.Name, &record.Status, &record.AppliedAt, &record.Error, &record.Checksum)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan migration record: %w", err)
 		}
@@ -193,7 +249,9 @@ func (e *Executor) IsMigrationApplied(ctx context.Context, version string) (bool
 	return count > 0, nil
 }
 
-// Apply executes a migration's up SQL.
+// Apply executes a migration's up SQL. The migration and its tracking row are
+// committed in one transaction unless the migration is not transactional (see
+// Migration.Transactional).
 func (e *Executor) Apply(ctx context.Context, migration Migration, dryRun bool) error {
 	// Check if already applied
 	applied, err := e.IsMigrationApplied(ctx, migration.Version)
@@ -209,6 +267,10 @@ func (e *Executor) Apply(ctx context.Context, migration Migration, dryRun bool)
 		return nil
 	}
 
+	if !migration.Transactional() {
+		return e.applyWithoutTransaction(ctx, migration)
+	}
+
 	// Start a transaction
 	tx, err := e.pool.Begin(ctx)
 	if err != nil {
@@ -225,6 +287,14 @@ func (e *Executor) Apply(ctx context.Context, migration Migration, dryRun bool)
 		return fmt.Errorf("failed to record migration: %w", err)
 	}
 
+	// Go migrations run their function in the transaction; a failure rolls
+	// everything back rather than committing a partial backfill
+	if migration.Up != nil {
+		if err := migration.Up(ctx, tx); err != nil {
+			return fmt.Errorf("migration failed: %w", err)
+		}
+	}
+
 	// Execute migration SQL using simple query protocol to avoid prepared statement caching
 	statements := splitSQLStatements(migration.UpSQL)
 	for i, stmt := range statements {
@@ -250,8 +320,8 @@ func (e *Executor) Apply(ctx context.Context, migration Migration, dryRun bool)
 	// Record success
 	now := time.Now()
 	_, err = tx.Exec(ctx,
-		"UPDATE schema_migrations SET status = 'applied', applied_at = $1, error = NULL WHERE version = $2",
-		now, migration.Version,
+		"UPDATE schema_migrations SET status = 'applied', applied_at = $1, error = NULL, checksum = $2 WHERE version = $3",
+		now, migration.Checksum(), migration.Version,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to update migration status: %w", err)
@@ -265,6 +335,45 @@ func (e *Executor) Apply(ctx context.Context, migration Migration, dryRun bool)
 	return nil
 }
 
+// applyWithoutTransaction executes a non-transactional migration statement by
+// statement on a single connection. A failure part-way leaves the earlier
+// statements applied and the migration recorded as failed.
+func (e *Executor) applyWithoutTransaction(ctx context.Context, migration Migration) error {
+	conn, err := e.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	_, err = conn.Exec(ctx,
+		"INSERT INTO schema_migrations (version, name, status) VALUES ($1, $2, 'pending') ON CONFLICT (version) DO UPDATE SET status = 'pending'",
+		migration.Version, migration.Name,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record migration: %w", err)
+	}
+
+	for i, stmt := range splitSQLStatements(migration.UpSQL) {
+		if _, err := conn.Exec(ctx, stmt, pgx.QueryExecModeExec); err != nil {
+			errMsg := fmt.Sprintf("Statement %d failed: %v", i+1, err)
+			_, _ = conn.Exec(ctx,
+				"UPDATE schema_migrations SET status = 'failed', error = $1, applied_at = $2 WHERE version = $3",
+				errMsg, time.Now(), migration.Version,
+			)
+			return fmt.Errorf("migration failed at statement %d: %w", i+1, err)
+		}
+	}
+
+	_, err = conn.Exec(ctx,
+		"UPDATE schema_migrations SET status = 'applied', applied_at = $1, error = NULL, checksum = $2 WHERE version = $3",
+		time.Now(), migration.Checksum(), migration.Version,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update migration status: %w", err)
+	}
+	return nil
+}
+
 // Rollback executes a migration's down SQL.
 func (e *Executor) Rollback(ctx context.Context, migration Migration, dryRun bool) error {
 	// Check if applied
@@ -281,6 +390,10 @@ func (e *Executor) Rollback(ctx context.Context, migration Migration, dryRun boo
 		return nil
 	}
 
+	if !migration.Transactional() {
+		return e.rollbackWithoutTransaction(ctx, migration)
+	}
+
 	// Start a transaction
 	tx, err := e.pool.Begin(ctx)
 	if err != nil {
@@ -288,6 +401,15 @@ func (e *Executor) Rollback(ctx context.Context, migration Migration, dryRun boo
 	}
 	defer tx.Rollback(ctx)
 
+	if migration.Up != nil {
+		if migration.Down == nil {
+			return fmt.Errorf("migration %s is a Go migration without a down function and cannot be rolled back", migration.Version)
+		}
+		if err := migration.Down(ctx, tx); err != nil {
+			return fmt.Errorf("rollback failed: %w", err)
+		}
+	}
+
 	// Execute rollback SQL using simple query protocol to avoid prepared statement caching
 	statements := splitSQLStatements(migration.DownSQL)
 	for i, stmt := range statements {
@@ -316,6 +438,61 @@ func (e *Executor) Rollback(ctx context.Context, migration Migration, dryRun boo
 	return nil
 }
 
+// rollbackWithoutTransaction executes a non-transactional migration's down SQL
+// statement by statement, removing the tracking row once all have succeeded.
+func (e *Executor) rollbackWithoutTransaction(ctx context.Context, migration Migration) error {
+	conn, err := e.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	for i, stmt := range splitSQLStatements(migration.DownSQL) {
+		if _, err := conn.Exec(ctx, stmt, pgx.QueryExecModeExec); err != nil {
+			return fmt.Errorf("rollback failed at statement %d: %w", i+1, err)
+		}
+	}
+
+	if _, err := conn.Exec(ctx, "DELETE FROM schema_migrations WHERE version = $1", migration.Version); err != nil {
+		return fmt.Errorf("failed to delete migration record: %w", err)
+	}
+	return nil
+}
+
+// VerifyChecksums checks that no applied migration's up SQL has changed since
+// it was applied. Records without a checksum (applied before checksums were
+// tracked) are skipped.
+func (e *Executor) VerifyChecksums(ctx context.Context, migrations []Migration) error {
+	applied, err := e.GetAppliedMigrations(ctx)
+	if err != nil {
+		return err
+	}
+	return verifyChecksums(applied, migrations)
+}
+
+// verifyChecksums compares the recorded checksums against the migration files.
+func verifyChecksums(applied []MigrationRecord, migrations []Migration) error {
+	byVersion := make(map[string]Migration, len(migrations))
+	for _, m := range migrations {
+		byVersion[m.Version] = m
+	}
+
+	var modified []string
+	for _, record := range applied {
+		m, ok := byVersion[record.Version]
+		if !ok || record.Checksum == nil || *record.Checksum == "" || unreconciledSquash(record, m) {
+			continue
+		}
+		if *record.Checksum != m.Checksum() {
+			modified = append(modified, record.Version)
+		}
+	}
+	if len(modified) > 0 {
+		return fmt.Errorf("migrations modified after being applied: %v", modified)
+	}
+	return nil
+}
+
 // ApplyAll applies all pending migrations.
 func (e *Executor) ApplyAll(ctx context.Context, migrations []Migration, dryRun bool) error {
 	// Get applied migrations
@@ -390,14 +567,19 @@ func (e *Executor) RollbackTo(ctx context.Context, targetVersion string, migrati
 	return nil
 }
 
-// GetStatus returns the status of all migrations.
+// GetStatus returns the status of all migrations. Applied migrations whose
+// file no longer matches the recorded checksum are reported as modified.
 func (e *Executor) GetStatus(ctx context.Context, migrations []Migration) ([]MigrationRecord, error) {
-	// Get applied migrations
-	appliedMap := make(map[string]MigrationRecord)
 	applied, err := e.GetAllMigrations(ctx)
 	if err != nil {
 		return nil, err
 	}
+	return migrationStatus(applied, migrations), nil
+}
+
+// migrationStatus merges the tracking records with the migration files.
+func migrationStatus(applied []MigrationRecord, migrations []Migration) []MigrationRecord {
+	appliedMap := make(map[string]MigrationRecord)
 	for _, m := range applied {
 		appliedMap[m.Version] = m
 	}
@@ -406,6 +588,10 @@ func (e *Executor) GetStatus(ctx context.Context, migrations []Migration) ([]Mig
 	var records []MigrationRecord
 	for _, migration := range migrations {
 		if record, exists := appliedMap[migration.Version]; exists {
+			if record.Status == StatusApplied && record.Checksum != nil && *record.Checksum != "" &&
+				*record.Checksum != migration.Checksum() && !unreconciledSquash(record, migration) {
+				record.Status = StatusModified
+			}
 			records = append(records, record)
 		} else {
 			records = append(records, MigrationRecord{
@@ -418,7 +604,7 @@ func (e *Executor) GetStatus(ctx context.Context, migrations []Migration) ([]Mig
 		}
 	}
 
-	return records, nil
+	return records
 }
 
 // Validate checks that all migrations in the database have corresponding files.
@@ -0,0 +1,240 @@
+package migration
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+)
+
+// Stages of an expand/contract migration, in the order they are applied.
+const (
+	StageExpand    = "expand"    // Add new columns and tables, all nullable
+	StageBackfill  = "backfill"  // Copy existing rows into the new columns in batches
+	StageConstrain = "constrain" // SET NOT NULL after validating a CHECK constraint
+	StageContract  = "contract"  // Swap in converted columns and drop removed ones
+)
+
+// MigrationStage is one migration of an expand/contract plan.
+type MigrationStage struct {
+	Name     string // One of the Stage constants
+	Guidance string // What must be true before the stage is applied
+	UpSQL    string
+	DownSQL  string
+}
+
+// stageGuidance describes when each stage is safe to apply.
+var stageGuidance = map[string]string{
+	StageExpand:    "Adds new columns as nullable and converted columns alongside the originals. Deploy code that writes both before the backfill.",
+	StageBackfill:  "Copies existing rows into the new columns in batches, committing after each, so no long lock is held.",
+	StageConstrain: "Validates a NOT VALID CHECK constraint before SET NOT NULL, so rows are scanned without blocking writes.",
+	StageContract:  "Swaps converted columns in and drops removed columns and tables. Apply once no deployed code reads them.",
+}
+
+// GenerateStages splits a diff into expand/contract stages for a migration
+// that must not block a live application:
+//
+//   - expand adds new NOT NULL columns as nullable, and adds a <column>_new
+//     column for each column whose type changed;
+//   - backfill fills the <column>_new columns in batches;
+//   - constrain sets NOT NULL through a validated CHECK constraint;
+//   - contract drops the old columns, renames the <column>_new columns into
+//     place, and drops the columns and tables removed from code.
+//
+// Stages with nothing to do are omitted; a diff without risky changes yields
+// a single expand stage. It fails with a *DropConfirmationError like
+// GenerateMigrationChecked.
+func (p *Planner) GenerateStages(diff *SchemaDiff) ([]MigrationStage, error) {
+	if err := p.checkDropConfirmation(diff); err != nil {
+		return nil, err
+	}
+
+	expand := *diff
+	expand.TablesModified = nil
+	expand.TablesDropped = nil
+	contract := &SchemaDiff{TablesDropped: diff.TablesDropped}
+
+	var backfillUp, constrainUp, constrainDown, swapUp, swapDown []string
+	for _, tableDiff := range diff.TablesModified {
+		tableName := schema.QuoteQualifiedIdent(tableDiff.TableName)
+		expanded := tableDiff
+		expanded.ColumnsAdded, expanded.ColumnsDropped, expanded.ColumnsModified = nil, nil, nil
+
+		if len(tableDiff.ColumnsDropped) > 0 {
+			contract.TablesModified = append(contract.TablesModified,
+				TableDiff{TableName: tableDiff.TableName, ColumnsDropped: tableDiff.ColumnsDropped})
+		}
+
+		for _, col := range tableDiff.ColumnsAdded {
+			if needsBackfill(col) {
+				col.Nullable = true
+				colName := schema.QuoteReservedIdent(col.Name)
+				backfillUp = append(backfillUp, fmt.Sprintf(
+					"-- MANUAL MIGRATION REQUIRED: Backfill %s.%s before the constrain stage, e.g.\n"+
+						"-- UPDATE %s SET %s = <value> WHERE ctid IN (SELECT ctid FROM %s WHERE %s IS NULL LIMIT %d);",
+					tableName, colName, tableName, colName, tableName, colName, typeChangeBatchSize))
+				up, down := generateSetNotNull(tableDiff.TableName, col.Name)
+				constrainUp, constrainDown = append(constrainUp, up...), append(constrainDown, down)
+			}
+			expanded.ColumnsAdded = append(expanded.ColumnsAdded, col)
+		}
+
+		for _, colDiff := range tableDiff.ColumnsModified {
+			switch {
+			case colDiff.GeneratedChanged:
+				// The column is re-created; there is nothing to backfill.
+				expanded.ColumnsModified = append(expanded.ColumnsModified, colDiff)
+			case colDiff.TypeChanged:
+				newCol := colDiff.NewColumn
+				newCol.Name = colDiff.ColumnName + "_new"
+				newCol.Nullable, newCol.Default, newCol.Using = true, nil, ""
+				expanded.ColumnsAdded = append(expanded.ColumnsAdded, newCol)
+
+				colName := schema.QuoteReservedIdent(colDiff.ColumnName)
+				expr, ok := typeChangeExpression(colName, colDiff.OldColumn.SQLType, colDiff.NewColumn.SQLType, colDiff.NewColumn.Using)
+				backfillUp = append(backfillUp, generateBackfill(tableName, colName, schema.QuoteReservedIdent(newCol.Name), expr, ok))
+				if !colDiff.NewColumn.Nullable {
+					up, down := generateSetNotNull(tableDiff.TableName, newCol.Name)
+					constrainUp, constrainDown = append(constrainUp, up...), append(constrainDown, down)
+				}
+
+				up, down := p.generateColumnSwap(tableName, colDiff)
+				swapUp, swapDown = append(swapUp, up...), append(down, swapDown...)
+			case colDiff.NullChanged && !colDiff.NewColumn.Nullable:
+				up, down := generateSetNotNull(tableDiff.TableName, colDiff.ColumnName)
+				constrainUp, constrainDown = append(constrainUp, up...), append(constrainDown, down)
+				colDiff.NullChanged = false
+				if colDiff.hasChanges() {
+					expanded.ColumnsModified = append(expanded.ColumnsModified, colDiff)
+				}
+			default:
+				expanded.ColumnsModified = append(expanded.ColumnsModified, colDiff)
+			}
+		}
+
+		if expanded.HasChanges() {
+			expand.TablesModified = append(expand.TablesModified, expanded)
+		}
+	}
+
+	var stages []MigrationStage
+	if expand.HasChanges() {
+		up, down := p.GenerateMigration(&expand)
+		stages = append(stages, MigrationStage{Name: StageExpand, UpSQL: up, DownSQL: down})
+	}
+	if len(backfillUp) > 0 {
+		stages = append(stages, MigrationStage{
+			Name:    StageBackfill,
+			UpSQL:   NoTransactionDirective + "\n\n" + strings.Join(backfillUp, "\n\n") + "\n",
+			DownSQL: "-- Backfilled values are left in place; the expand stage's down migration drops them\n",
+		})
+	}
+	if len(constrainUp) > 0 {
+		stages = append(stages, MigrationStage{
+			Name:    StageConstrain,
+			UpSQL:   NoTransactionDirective + "\n\n" + strings.Join(constrainUp, "\n\n") + "\n",
+			DownSQL: strings.Join(constrainDown, "\n\n") + "\n",
+		})
+	}
+	if len(swapUp) > 0 || contract.HasChanges() {
+		var up, down []string
+		if contract.HasChanges() {
+			dropUp, dropDown := p.GenerateMigration(contract)
+			up, down = []string{strings.TrimSpace(dropUp)}, []string{strings.TrimSpace(dropDown)}
+		}
+		stages = append(stages, MigrationStage{
+			Name:    StageContract,
+			UpSQL:   strings.Join(append(swapUp, up...), "\n\n") + "\n",
+			DownSQL: strings.Join(append(down, swapDown...), "\n\n") + "\n",
+		})
+	}
+
+	for i := range stages {
+		stages[i].Guidance = stageGuidance[stages[i].Name]
+		if len(stages) > 1 {
+			header := fmt.Sprintf("-- Stage %d of %d (%s): %s\n\n", i+1, len(stages), stages[i].Name, stages[i].Guidance)
+			stages[i].UpSQL = header + stages[i].UpSQL
+		}
+	}
+	return stages, nil
+}
+
+// needsBackfill reports whether an added column is NOT NULL with nothing to
+// fill existing rows, so adding it in one step would fail on a non-empty
+// table.
+func needsBackfill(col schema.ColumnMetadata) bool {
+	return !col.Nullable && col.Default == nil && col.Identity == nil &&
+		col.Generated == nil && !col.AutoIncrement
+}
+
+// generateBackfill generates a DO block that copies a column into another in
+// batches, committing after each so no lock is held for the whole table. It
+// runs outside a transaction (see NoTransactionDirective).
+func generateBackfill(tableName, from, to, expr string, ok bool) string {
+	if !ok {
+		return fmt.Sprintf("-- MANUAL MIGRATION REQUIRED: Cannot infer a cast for %s, e.g.\n-- %s",
+			from, backfillBatch(tableName, from, to, expr))
+	}
+	return fmt.Sprintf(`DO $$
+DECLARE
+	batch integer;
+BEGIN
+	LOOP
+		%s
+		GET DIAGNOSTICS batch = ROW_COUNT;
+		EXIT WHEN batch = 0;
+		COMMIT;
+	END LOOP;
+END $$;`, backfillBatch(tableName, from, to, expr))
+}
+
+// generateSetNotNull sets NOT NULL on a column without holding an ACCESS
+// EXCLUSIVE lock while the table is scanned: PostgreSQL 12+ skips the scan
+// when a validated CHECK (column IS NOT NULL) constraint proves it.
+func generateSetNotNull(table, column string) (upSQL []string, downSQL string) {
+	tableName := schema.QuoteQualifiedIdent(table)
+	colName := schema.QuoteReservedIdent(column)
+	_, bare := schema.SplitQualifiedName(table)
+	check := schema.QuoteReservedIdent(fmt.Sprintf("%s_%s_not_null", bare, column))
+	upSQL = []string{
+		fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s CHECK (%s IS NOT NULL) NOT VALID;", tableName, check, colName),
+		fmt.Sprintf("ALTER TABLE %s VALIDATE CONSTRAINT %s;", tableName, check),
+		fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET NOT NULL;", tableName, colName),
+		fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s;", tableName, check),
+	}
+	downSQL = fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP NOT NULL;", tableName, colName)
+	return upSQL, downSQL
+}
+
+// generateColumnSwap replaces a column with its converted <column>_new copy.
+// The down migration restores the original column from the converted one.
+func (p *Planner) generateColumnSwap(tableName string, colDiff ColumnDiff) (upSQL, downSQL []string) {
+	colName := schema.QuoteReservedIdent(colDiff.ColumnName)
+	newName := schema.QuoteReservedIdent(colDiff.ColumnName + "_new")
+
+	upSQL = []string{
+		fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s;", tableName, colName),
+		fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s;", tableName, newName, colName),
+	}
+	if colDiff.NewColumn.Default != nil {
+		upSQL = append(upSQL, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET DEFAULT %s;",
+			tableName, colName, *colDiff.NewColumn.Default))
+	}
+
+	oldCol := colDiff.OldColumn
+	oldCol.Nullable = true
+	downSQL = []string{
+		fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s;", tableName, colName, newName),
+		fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s;", tableName, p.generateColumnDefinition(oldCol)),
+	}
+	if expr, ok := typeChangeExpression(newName, colDiff.NewColumn.SQLType, colDiff.OldColumn.SQLType, ""); ok {
+		downSQL = append(downSQL, fmt.Sprintf("UPDATE %s SET %s = %s;", tableName, colName, expr))
+	} else {
+		downSQL = append(downSQL, fmt.Sprintf("-- MANUAL MIGRATION REQUIRED: Restore %s from %s\n-- UPDATE %s SET %s = %s;",
+			colName, newName, tableName, colName, expr))
+	}
+	if !colDiff.OldColumn.Nullable {
+		downSQL = append(downSQL, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET NOT NULL;", tableName, colName))
+	}
+	return upSQL, downSQL
+}
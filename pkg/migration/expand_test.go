@@ -0,0 +1,178 @@
+package migration
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+)
+
+func expandContractDiff() *SchemaDiff {
+	return &SchemaDiff{
+		TablesModified: []TableDiff{{
+			TableName: "orders",
+			ColumnsAdded: []schema.ColumnMetadata{
+				{Name: "status", SQLType: "text"},
+				{Name: "region", SQLType: "text", Nullable: false},
+			},
+			ColumnsDropped: []schema.ColumnMetadata{
+				{Name: "legacy_code", SQLType: "text", Nullable: true},
+			},
+			ColumnsModified: []ColumnDiff{
+				{
+					ColumnName:  "amount",
+					TypeChanged: true,
+					OldColumn:   schema.ColumnMetadata{Name: "amount", SQLType: "integer", Nullable: false},
+					NewColumn:   schema.ColumnMetadata{Name: "amount", SQLType: "bigint", Nullable: false},
+				},
+				{
+					ColumnName:  "customer_id",
+					NullChanged: true,
+					OldColumn:   schema.ColumnMetadata{Name: "customer_id", SQLType: "integer", Nullable: true},
+					NewColumn:   schema.ColumnMetadata{Name: "customer_id", SQLType: "integer", Nullable: false},
+				},
+			},
+		}},
+	}
+}
+
+func TestGenerateStages(t *testing.T) {
+	stages, err := NewPlanner().GenerateStages(expandContractDiff())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	byName := make(map[string]MigrationStage)
+	for _, stage := range stages {
+		names = append(names, stage.Name)
+		byName[stage.Name] = stage
+	}
+	if got := strings.Join(names, ","); got != "expand,backfill,constrain,contract" {
+		t.Fatalf("expected all four stages in order, got %s", got)
+	}
+
+	expand := byName[StageExpand].UpSQL
+	for _, want := range []string{
+		"ALTER TABLE orders ADD COLUMN region text;",
+		"ALTER TABLE orders ADD COLUMN amount_new bigint;",
+	} {
+		if !strings.Contains(expand, want) {
+			t.Errorf("expected expand to contain %q, got:\n%s", want, expand)
+		}
+	}
+	for _, notWant := range []string{"DROP COLUMN", "SET NOT NULL", "TYPE bigint"} {
+		if strings.Contains(expand, notWant) {
+			t.Errorf("expand should not contain %q, got:\n%s", notWant, expand)
+		}
+	}
+
+	backfill := byName[StageBackfill].UpSQL
+	if (Migration{UpSQL: backfill}).Transactional() {
+		t.Errorf("expected backfill to run outside a transaction, got:\n%s", backfill)
+	}
+	if !strings.Contains(backfill, "UPDATE orders SET amount_new = amount WHERE ctid IN (SELECT ctid FROM orders WHERE amount_new IS NULL AND amount IS NOT NULL LIMIT 10000);") {
+		t.Errorf("expected a batched backfill of amount_new, got:\n%s", backfill)
+	}
+	if !strings.Contains(backfill, "-- MANUAL MIGRATION REQUIRED: Backfill orders.region") {
+		t.Errorf("expected a manual backfill note for region, got:\n%s", backfill)
+	}
+
+	constrain := byName[StageConstrain].UpSQL
+	for _, col := range []string{"region", "amount_new", "customer_id"} {
+		for _, want := range []string{
+			"ALTER TABLE orders ADD CONSTRAINT orders_" + col + "_not_null CHECK (" + col + " IS NOT NULL) NOT VALID;",
+			"ALTER TABLE orders VALIDATE CONSTRAINT orders_" + col + "_not_null;",
+			"ALTER TABLE orders ALTER COLUMN " + col + " SET NOT NULL;",
+		} {
+			if !strings.Contains(constrain, want) {
+				t.Errorf("expected constrain to contain %q, got:\n%s", want, constrain)
+			}
+		}
+	}
+
+	contract := byName[StageContract]
+	for _, want := range []string{
+		"ALTER TABLE orders DROP COLUMN amount;",
+		"ALTER TABLE orders RENAME COLUMN amount_new TO amount;",
+		"ALTER TABLE orders DROP COLUMN IF EXISTS legacy_code;",
+	} {
+		if !strings.Contains(contract.UpSQL, want) {
+			t.Errorf("expected contract to contain %q, got:\n%s", want, contract.UpSQL)
+		}
+	}
+	if !strings.Contains(contract.DownSQL, "UPDATE orders SET amount = amount_new;") {
+		t.Errorf("expected the contract down migration to restore amount, got:\n%s", contract.DownSQL)
+	}
+}
+
+func TestGenerateStagesSafeDiff(t *testing.T) {
+	diff := &SchemaDiff{TablesModified: []TableDiff{{
+		TableName:    "orders",
+		ColumnsAdded: []schema.ColumnMetadata{{Name: "note", SQLType: "text", Nullable: true}},
+	}}}
+
+	stages, err := NewPlanner().GenerateStages(diff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stages) != 1 || stages[0].Name != StageExpand {
+		t.Fatalf("expected a single expand stage, got %+v", stages)
+	}
+	if strings.HasPrefix(stages[0].UpSQL, "-- Stage") {
+		t.Errorf("a single stage should not carry a stage header, got:\n%s", stages[0].UpSQL)
+	}
+}
+
+func TestGeneratorGenerateStaged(t *testing.T) {
+	dir := t.TempDir()
+	initial := "CREATE TABLE orders (id integer NOT NULL, amount integer NOT NULL, customer_id integer, legacy_code text);\n"
+	if err := os.WriteFile(filepath.Join(dir, "20200101000000_init.up.sql"), []byte(initial), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "20200101000000_init.down.sql"), []byte("DROP TABLE orders;\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := NewGenerator(dir).GenerateStaged("widen_amount", expandContractDiff())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 4 {
+		t.Fatalf("expected 4 migrations, got %d", len(files))
+	}
+	for i, f := range files {
+		if i > 0 && f.Version <= files[i-1].Version {
+			t.Errorf("expected increasing versions, got %s after %s", f.Version, files[i-1].Version)
+		}
+		if _, err := os.Stat(f.UpPath); err != nil {
+			t.Errorf("missing up file: %v", err)
+		}
+	}
+	if files[3].Name != "widen_amount_contract" || filepath.Dir(files[3].DownPath) != dir {
+		t.Errorf("unexpected contract migration %+v", files[3])
+	}
+
+	// Replaying every stage yields the target schema
+	tables, err := ReconstructSchemaFromMigrations(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	orders := tables["orders"]
+	if amount := orders.GetColumnByName("amount"); amount == nil || amount.SQLType != "bigint" || amount.Nullable {
+		t.Errorf("expected amount to be bigint NOT NULL, got %+v", amount)
+	}
+	for _, name := range []string{"customer_id", "region"} {
+		if col := orders.GetColumnByName(name); col == nil || col.Nullable {
+			t.Errorf("expected %s to be NOT NULL, got %+v", name, col)
+		}
+	}
+	if orders.GetColumnByName("legacy_code") != nil || orders.GetColumnByName("amount_new") != nil {
+		t.Errorf("expected legacy_code and amount_new to be gone, got %+v", orders.Columns)
+	}
+	if len(orders.Constraints) != 0 {
+		t.Errorf("expected the NOT NULL checks to be dropped, got %+v", orders.Constraints)
+	}
+}
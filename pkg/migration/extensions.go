@@ -0,0 +1,214 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+)
+
+var (
+	requiredExtensionsMu sync.Mutex
+	requiredExtensions   []string
+)
+
+// extensionDependencies lists the extensions another extension needs, so
+// they are created first even when only the dependent one is required.
+var extensionDependencies = map[string][]string{
+	"earthdistance":          {"cube"},
+	"postgis_raster":         {"postgis"},
+	"postgis_topology":       {"postgis"},
+	"postgis_sfcgal":         {"postgis"},
+	"postgis_tiger_geocoder": {"postgis", "fuzzystrmatch"},
+	"pgrouting":              {"postgis"},
+	"h3_postgis":             {"h3", "postgis"},
+}
+
+var (
+	reCreateExtension = regexp.MustCompile(`(?i)^\s*CREATE\s+EXTENSION\s+(?:IF\s+NOT\s+EXISTS\s+)?"?([\w-]+)"?`)
+	reDropExtension   = regexp.MustCompile(`(?i)^\s*DROP\s+EXTENSION\s+(?:IF\s+EXISTS\s+)?"?([\w-]+)"?`)
+)
+
+// RequireExtensions registers PostgreSQL extensions the schema needs. The
+// differ adds any that aren't installed to the migration, before the types
+// and tables that may use them. Models can also declare extensions with a
+// `// extensions: pg_trgm, btree_gin` comment directive.
+//
+// Example:
+//
+//	func init() {
+//	    migration.RequireExtensions("pg_trgm", "pgcrypto", "btree_gin")
+//	}
+func RequireExtensions(names ...string) {
+	requiredExtensionsMu.Lock()
+	defer requiredExtensionsMu.Unlock()
+	for _, name := range names {
+		if !slices.Contains(requiredExtensions, name) {
+			requiredExtensions = append(requiredExtensions, name)
+		}
+	}
+}
+
+// RequiredExtensions returns the extensions registered with RequireExtensions.
+func RequiredExtensions() []string {
+	requiredExtensionsMu.Lock()
+	defer requiredExtensionsMu.Unlock()
+	return slices.Clone(requiredExtensions)
+}
+
+// resetRequiredExtensions clears the registered extensions (for tests).
+func resetRequiredExtensions() {
+	requiredExtensionsMu.Lock()
+	defer requiredExtensionsMu.Unlock()
+	requiredExtensions = nil
+}
+
+// typeExtensions maps column types to the extension that provides them, so
+// a model using one needs no extensions directive.
+var typeExtensions = map[string]string{
+	"hstore": "hstore",
+	"citext": "citext",
+}
+
+// requiredExtensionsFor returns the registered extensions plus those declared
+// by the tables and those providing their column types.
+func requiredExtensionsFor(tables map[string]*schema.TableMetadata) []string {
+	names := RequiredExtensions()
+	add := func(name string) {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	for _, table := range tables {
+		for _, name := range table.Extensions {
+			add(name)
+		}
+		for _, col := range table.Columns {
+			if name, ok := typeExtensions[baseTypeName(col.SQLType)]; ok {
+				add(name)
+			}
+		}
+	}
+	return names
+}
+
+// baseTypeName returns a column type without array brackets or modifiers,
+// lowercased: "citext[]" is "citext".
+func baseTypeName(sqlType string) string {
+	t := strings.ToLower(strings.TrimSpace(sqlType))
+	if i := strings.IndexAny(t, "[("); i >= 0 {
+		t = strings.TrimSpace(t[:i])
+	}
+	return t
+}
+
+// SortExtensions returns names plus their known dependencies, ordered so
+// every extension comes after the ones it depends on. Independent extensions
+// are sorted by name for stable output.
+func SortExtensions(names []string) []string {
+	sorted := slices.Clone(names)
+	slices.Sort(sorted)
+
+	var ordered []string
+	visited := make(map[string]bool)
+	var visit func(name string)
+	visit = func(name string) {
+		if visited[name] {
+			return
+		}
+		visited[name] = true
+		for _, dep := range extensionDependencies[name] {
+			visit(dep)
+		}
+		ordered = append(ordered, name)
+	}
+	for _, name := range sorted {
+		visit(name)
+	}
+	return ordered
+}
+
+// missingExtensions returns the required extensions (and their dependencies)
+// that aren't installed, in creation order.
+func missingExtensions(required, installed []string) []string {
+	var missing []string
+	for _, name := range SortExtensions(required) {
+		if !slices.Contains(installed, name) {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// IntrospectExtensions returns the extensions installed in the database,
+// sorted by name.
+func (i *Introspector) IntrospectExtensions(ctx context.Context) ([]string, error) {
+	rows, err := i.query(ctx, `SELECT extname FROM pg_extension ORDER BY extname`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// ReconstructExtensionsFromMigrations replays the CREATE/DROP EXTENSION
+// statements of all *.up.sql files in dir, returning the extensions they
+// leave installed. It is the offline counterpart of IntrospectExtensions.
+func ReconstructExtensionsFromMigrations(dir string) ([]string, error) {
+	contents, err := readUpMigrations(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, sql := range contents {
+		names = applyExtensionSQL(names, sql)
+	}
+	return names, nil
+}
+
+// applyExtensionSQL applies the extension statements in sql to names.
+func applyExtensionSQL(names []string, sql string) []string {
+	for _, stmt := range splitSQLStatements(sql) {
+		if m := reCreateExtension.FindStringSubmatch(stmt); m != nil {
+			if !slices.Contains(names, m[1]) {
+				names = append(names, m[1])
+			}
+		} else if m := reDropExtension.FindStringSubmatch(stmt); m != nil {
+			names = slices.DeleteFunc(names, func(name string) bool { return name == m[1] })
+		}
+	}
+	return names
+}
+
+// generateCreateExtension generates a CREATE EXTENSION statement.
+func (p *Planner) generateCreateExtension(name string) string {
+	return fmt.Sprintf("CREATE EXTENSION IF NOT EXISTS %s;", quoteExtensionName(name))
+}
+
+// generateDropExtension generates a DROP EXTENSION statement.
+func (p *Planner) generateDropExtension(name string) string {
+	return fmt.Sprintf("DROP EXTENSION IF EXISTS %s;", quoteExtensionName(name))
+}
+
+// quoteExtensionName quotes extension names that aren't plain identifiers,
+// such as "uuid-ossp".
+func quoteExtensionName(name string) string {
+	if strings.Contains(name, "-") {
+		return `"` + name + `"`
+	}
+	return name
+}
@@ -0,0 +1,112 @@
+package migration
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+)
+
+func TestSortExtensions(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []string
+		want  []string
+	}{
+		{"sorted by name", []string{"pgcrypto", "btree_gin", "pg_trgm"}, []string{"btree_gin", "pg_trgm", "pgcrypto"}},
+		{"dependency first", []string{"earthdistance"}, []string{"cube", "earthdistance"}},
+		{"shared dependency once", []string{"postgis_topology", "postgis_raster", "postgis"}, []string{"postgis", "postgis_raster", "postgis_topology"}},
+		{"transitive dependencies", []string{"postgis_tiger_geocoder"}, []string{"postgis", "fuzzystrmatch", "postgis_tiger_geocoder"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SortExtensions(tt.input); !slices.Equal(got, tt.want) {
+				t.Errorf("SortExtensions(%v) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompare_Extensions(t *testing.T) {
+	RequireExtensions("pgcrypto", "pg_trgm", "pgcrypto")
+	defer resetRequiredExtensions()
+
+	code := map[string]*schema.TableMetadata{
+		"places": {Name: "places", Extensions: []string{"earthdistance", "pg_trgm"}},
+	}
+	db := map[string]*schema.TableMetadata{"places": {Name: "places"}}
+
+	diff := NewDiffer().WithInstalledExtensions([]string{"plpgsql", "pg_trgm"}).Compare(code, db)
+	if want := []string{"cube", "earthdistance", "pgcrypto"}; !slices.Equal(diff.ExtensionsAdded, want) {
+		t.Errorf("ExtensionsAdded = %v, want %v", diff.ExtensionsAdded, want)
+	}
+	if !diff.HasChanges() {
+		t.Error("missing extensions should count as a change")
+	}
+
+	diff = NewDiffer().WithInstalledExtensions([]string{"cube", "earthdistance", "pg_trgm", "pgcrypto"}).Compare(code, db)
+	if diff.HasChanges() {
+		t.Errorf("expected no changes once installed, got %v", diff.ExtensionsAdded)
+	}
+}
+
+func TestCompare_TypeExtensions(t *testing.T) {
+	code := map[string]*schema.TableMetadata{
+		"accounts": {Name: "accounts", Columns: []schema.ColumnMetadata{
+			{Name: "email", SQLType: "citext"},
+			{Name: "aliases", SQLType: "CITEXT[]"},
+			{Name: "attributes", SQLType: "hstore"},
+		}},
+	}
+	db := map[string]*schema.TableMetadata{"accounts": {Name: "accounts", Columns: code["accounts"].Columns}}
+
+	diff := NewDiffer().WithInstalledExtensions([]string{"plpgsql"}).Compare(code, db)
+	if want := []string{"citext", "hstore"}; !slices.Equal(diff.ExtensionsAdded, want) {
+		t.Errorf("ExtensionsAdded = %v, want %v", diff.ExtensionsAdded, want)
+	}
+}
+
+func TestGenerateMigration_Extensions(t *testing.T) {
+	diff := &SchemaDiff{
+		ExtensionsAdded: []string{"cube", "earthdistance", "uuid-ossp"},
+		TablesAdded:     []schema.TableMetadata{{Name: "places", Columns: []schema.ColumnMetadata{{Name: "id", SQLType: "uuid"}}}},
+		EnumTypesAdded:  []schema.EnumType{{Name: "kind", Values: []string{"a"}}},
+	}
+
+	up, down := NewPlanner().GenerateMigration(diff)
+
+	wantUpPrefix := "CREATE EXTENSION IF NOT EXISTS cube;\n\nCREATE EXTENSION IF NOT EXISTS earthdistance;\n\nCREATE EXTENSION IF NOT EXISTS \"uuid-ossp\";\n\nCREATE TYPE"
+	if !strings.HasPrefix(up, wantUpPrefix) {
+		t.Errorf("extensions must be created first, in order:\n%s", up)
+	}
+	wantDownSuffix := "DROP EXTENSION IF EXISTS \"uuid-ossp\";\n\nDROP EXTENSION IF EXISTS earthdistance;\n\nDROP EXTENSION IF EXISTS cube;\n"
+	if !strings.HasSuffix(down, wantDownSuffix) {
+		t.Errorf("extensions must be dropped last, dependents first:\n%s", down)
+	}
+}
+
+func TestReconstructExtensionsFromMigrations(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"20240101000000_init.up.sql":   "CREATE EXTENSION IF NOT EXISTS pg_trgm;\n\nCREATE EXTENSION IF NOT EXISTS \"uuid-ossp\";\n",
+		"20240102000000_swap.up.sql":   "DROP EXTENSION IF EXISTS pg_trgm;\n\nCREATE EXTENSION pgcrypto;\n",
+		"20240102000000_swap.down.sql": "CREATE EXTENSION IF NOT EXISTS hstore;\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := ReconstructExtensionsFromMigrations(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"uuid-ossp", "pgcrypto"}; !slices.Equal(got, want) {
+		t.Errorf("ReconstructExtensionsFromMigrations() = %v, want %v", got, want)
+	}
+}
@@ -176,3 +176,67 @@ func TestGeneratedColumnMigration(t *testing.T) {
 
 	t.Logf("Migration SQL:\n%s", upSQL)
 }
+
+func TestGeneratedColumnDiff(t *testing.T) {
+	stored := func(expr string) *schema.GeneratedColumn {
+		return &schema.GeneratedColumn{Expression: expr, Type: schema.GeneratedStored}
+	}
+	tests := []struct {
+		name     string
+		code, db *schema.GeneratedColumn
+		changed  bool
+	}{
+		{"as deparsed", stored("first_name || ' ' || last_name"),
+			stored("(((first_name)::text || ' '::text) || (last_name)::text)"), false},
+		{"varchar cast", stored("lower(email)"), stored("lower((email)::character varying)"), false},
+		{"expression changed", stored("price * 1.2"), stored("(price * 1.1)"), true},
+		{"kind changed", stored("a + b"), &schema.GeneratedColumn{Expression: "(a + b)", Type: schema.GeneratedVirtual}, true},
+		{"became generated", stored("a + b"), nil, true},
+		{"no longer generated", nil, stored("(a + b)"), true},
+		{"neither", nil, nil, false},
+	}
+	differ := NewDiffer()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := schema.ColumnMetadata{Name: "c", SQLType: "text", Nullable: true, Generated: tt.code}
+			db := schema.ColumnMetadata{Name: "c", SQLType: "text", Nullable: true, Generated: tt.db}
+			colDiff := differ.compareColumn(code, db)
+			if colDiff.GeneratedChanged != tt.changed || colDiff.hasChanges() != tt.changed {
+				t.Errorf("GeneratedChanged = %v, hasChanges = %v, want %v",
+					colDiff.GeneratedChanged, colDiff.hasChanges(), tt.changed)
+			}
+		})
+	}
+}
+
+func TestGeneratedColumnModification(t *testing.T) {
+	planner := NewPlanner()
+	regular := schema.ColumnMetadata{Name: "total", SQLType: "numeric", Nullable: true}
+	generated := regular
+	generated.Generated = &schema.GeneratedColumn{Expression: "price * qty", Type: schema.GeneratedStored}
+	changed := regular
+	changed.Generated = &schema.GeneratedColumn{Expression: "price * qty * 1.2", Type: schema.GeneratedStored}
+
+	up, down := planner.generateColumnModification("orders",
+		ColumnDiff{ColumnName: "total", OldColumn: generated, NewColumn: changed, GeneratedChanged: true})
+	wantUp := []string{
+		"ALTER TABLE orders DROP COLUMN IF EXISTS total;",
+		"ALTER TABLE orders ADD COLUMN total numeric GENERATED ALWAYS AS (price * qty * 1.2) STORED;",
+	}
+	wantDown := []string{
+		"ALTER TABLE orders DROP COLUMN IF EXISTS total;",
+		"ALTER TABLE orders ADD COLUMN total numeric GENERATED ALWAYS AS (price * qty) STORED;",
+	}
+	if strings.Join(up, "\n") != strings.Join(wantUp, "\n") || strings.Join(down, "\n") != strings.Join(wantDown, "\n") {
+		t.Errorf("expression change:\nup   %q\ndown %q", up, down)
+	}
+
+	up, down = planner.generateColumnModification("orders",
+		ColumnDiff{ColumnName: "total", OldColumn: generated, NewColumn: regular, GeneratedChanged: true})
+	if len(up) != 1 || up[0] != "ALTER TABLE orders ALTER COLUMN total DROP EXPRESSION;" {
+		t.Errorf("dropped generation: up %q", up)
+	}
+	if strings.Join(down, "\n") != strings.Join(wantDown, "\n") {
+		t.Errorf("dropped generation: down %q", down)
+	}
+}
@@ -1,15 +1,21 @@
 package migration
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
+	"time"
 )
 
 // Generator generates migration files.
 type Generator struct {
 	migrationsDir string
+	fsys          fs.FS    // read-only source of migrations; nil means the OS filesystem
+	planner       *Planner // planner for Generate; nil means NewPlanner()
 }
 
 // NewGenerator creates a new migration file generator.
@@ -19,8 +25,38 @@ func NewGenerator(migrationsDir string) *Generator {
 	}
 }
 
-// Generate creates migration files from a schema diff.
+// NewGeneratorFS creates a generator that reads migrations from dir inside
+// fsys, such as an embed.FS. It can list and read migrations but not write
+// them.
+//
+// Example:
+//
+//	//go:embed migrations/*.sql
+//	var migrationsFS embed.FS
+//
+//	generator := migration.NewGeneratorFS(migrationsFS, "migrations")
+func NewGeneratorFS(fsys fs.FS, dir string) *Generator {
+	return &Generator{
+		migrationsDir: dir,
+		fsys:          fsys,
+	}
+}
+
+// WithPlannerOptions sets the options of the planner used by Generate, such
+// as the drop policy.
+func (g *Generator) WithPlannerOptions(opts PlannerOptions) *Generator {
+	g.planner = NewPlannerWithOptions(opts)
+	return g
+}
+
+// Generate creates migration files from a schema diff. It fails with a
+// *DropConfirmationError if the planner requires unconfirmed drops to be
+// confirmed.
 func (g *Generator) Generate(name string, diff *SchemaDiff) (*MigrationFile, error) {
+	if g.fsys != nil {
+		return nil, errReadOnlyFS
+	}
+
 	// Ensure migrations directory exists
 	if err := os.MkdirAll(g.migrationsDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create migrations directory: %w", err)
@@ -30,8 +66,14 @@ func (g *Generator) Generate(name string, diff *SchemaDiff) (*MigrationFile, err
 	version := GenerateVersion()
 
 	// Generate SQL
-	planner := NewPlanner()
-	upSQL, downSQL := planner.GenerateMigration(diff)
+	planner := g.planner
+	if planner == nil {
+		planner = NewPlanner()
+	}
+	upSQL, downSQL, err := planner.GenerateMigrationChecked(diff)
+	if err != nil {
+		return nil, err
+	}
 
 	// Create migration file
 	migrationFile := &MigrationFile{
@@ -54,8 +96,55 @@ func (g *Generator) Generate(name string, diff *SchemaDiff) (*MigrationFile, err
 	return migrationFile, nil
 }
 
+// GenerateStaged creates one migration per expand/contract stage of a schema
+// diff (see Planner.GenerateStages), named {name}_{stage}. The stages get
+// consecutive versions so they apply in order.
+func (g *Generator) GenerateStaged(name string, diff *SchemaDiff) ([]MigrationFile, error) {
+	if g.fsys != nil {
+		return nil, errReadOnlyFS
+	}
+
+	planner := g.planner
+	if planner == nil {
+		planner = NewPlanner()
+	}
+	stages, err := planner.GenerateStages(diff)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(g.migrationsDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create migrations directory: %w", err)
+	}
+
+	start := time.Now()
+	files := make([]MigrationFile, 0, len(stages))
+	for i, stage := range stages {
+		version := start.Add(time.Duration(i) * time.Second).Format("20060102150405")
+		stageName := name + "_" + stage.Name
+		migrationFile := MigrationFile{
+			Version:  version,
+			Name:     stageName,
+			UpPath:   filepath.Join(g.migrationsDir, GenerateFileName(version, stageName, "up")),
+			DownPath: filepath.Join(g.migrationsDir, GenerateFileName(version, stageName, "down")),
+		}
+		if err := g.writeFile(migrationFile.UpPath, stage.UpSQL); err != nil {
+			return nil, fmt.Errorf("failed to write up migration: %w", err)
+		}
+		if err := g.writeFile(migrationFile.DownPath, stage.DownSQL); err != nil {
+			return nil, fmt.Errorf("failed to write down migration: %w", err)
+		}
+		files = append(files, migrationFile)
+	}
+	return files, nil
+}
+
 // GenerateEmpty creates empty migration files for manual editing.
 func (g *Generator) GenerateEmpty(name string) (*MigrationFile, error) {
+	if g.fsys != nil {
+		return nil, errReadOnlyFS
+	}
+
 	// Ensure migrations directory exists
 	if err := os.MkdirAll(g.migrationsDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create migrations directory: %w", err)
@@ -88,9 +177,9 @@ func (g *Generator) GenerateEmpty(name string) (*MigrationFile, error) {
 // ListMigrations lists all migration files in the migrations directory.
 func (g *Generator) ListMigrations() ([]MigrationFile, error) {
 	// Read directory
-	entries, err := os.ReadDir(g.migrationsDir)
+	entries, err := g.readDir()
 	if err != nil {
-		if os.IsNotExist(err) {
+		if errors.Is(err, fs.ErrNotExist) {
 			return []MigrationFile{}, nil
 		}
 		return nil, fmt.Errorf("failed to read migrations directory: %w", err)
@@ -121,13 +210,13 @@ func (g *Generator) ListMigrations() ([]MigrationFile, error) {
 			if _, exists := fileMap[version]; !exists {
 				fileMap[version] = &MigrationFile{Version: version, Name: name}
 			}
-			fileMap[version].UpPath = filepath.Join(g.migrationsDir, fileName)
+			fileMap[version].UpPath = g.join(fileName)
 		} else if before, ok := strings.CutSuffix(rest, ".down.sql"); ok {
 			name := before
 			if _, exists := fileMap[version]; !exists {
 				fileMap[version] = &MigrationFile{Version: version, Name: name}
 			}
-			fileMap[version].DownPath = filepath.Join(g.migrationsDir, fileName)
+			fileMap[version].DownPath = g.join(fileName)
 		}
 	}
 
@@ -177,9 +266,36 @@ func (g *Generator) writeFile(path, content string) error {
 	return os.WriteFile(path, []byte(content), 0644)
 }
 
+// errReadOnlyFS is returned when writing migrations through a generator
+// created with NewGeneratorFS.
+var errReadOnlyFS = errors.New("cannot write migrations to an fs.FS")
+
+// readDir lists the migrations directory.
+func (g *Generator) readDir() ([]fs.DirEntry, error) {
+	if g.fsys != nil {
+		return fs.ReadDir(g.fsys, g.migrationsDir)
+	}
+	return os.ReadDir(g.migrationsDir)
+}
+
+// join returns the path of a file in the migrations directory. fs.FS paths
+// are always slash-separated.
+func (g *Generator) join(fileName string) string {
+	if g.fsys != nil {
+		return path.Join(g.migrationsDir, fileName)
+	}
+	return filepath.Join(g.migrationsDir, fileName)
+}
+
 // readFile reads content from a file.
-func (g *Generator) readFile(path string) (string, error) {
-	data, err := os.ReadFile(path)
+func (g *Generator) readFile(name string) (string, error) {
+	var data []byte
+	var err error
+	if g.fsys != nil {
+		data, err = fs.ReadFile(g.fsys, name)
+	} else {
+		data, err = os.ReadFile(name)
+	}
 	if err != nil {
 		return "", err
 	}
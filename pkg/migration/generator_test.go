@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"testing/fstest"
 
 	"github.com/marshallshelly/pebble-orm/pkg/schema"
 )
@@ -346,3 +347,42 @@ func TestGeneratorReadMigration(t *testing.T) {
 		t.Errorf("Expected down SQL %s, got %s", downContent, migration.DownSQL)
 	}
 }
+
+func TestGeneratorFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"migrations/20240102000000_add_posts.up.sql":      {Data: []byte("CREATE TABLE posts (id serial);")},
+		"migrations/20240102000000_add_posts.down.sql":    {Data: []byte("DROP TABLE posts;")},
+		"migrations/20240101000000_create_users.up.sql":   {Data: []byte("CREATE TABLE users (id serial);")},
+		"migrations/20240101000000_create_users.down.sql": {Data: []byte("DROP TABLE users;")},
+		"migrations/README.md":                            {Data: []byte("not a migration")},
+	}
+	generator := NewGeneratorFS(fsys, "migrations")
+
+	files, err := generator.ListMigrations()
+	if err != nil {
+		t.Fatalf("ListMigrations failed: %v", err)
+	}
+	if len(files) != 2 || files[0].Version != "20240101000000" || files[1].Version != "20240102000000" {
+		t.Fatalf("unexpected migrations: %+v", files)
+	}
+	if files[0].UpPath != "migrations/20240101000000_create_users.up.sql" {
+		t.Errorf("expected a slash-separated fs path, got %s", files[0].UpPath)
+	}
+
+	m, err := generator.ReadMigration(files[0])
+	if err != nil {
+		t.Fatalf("ReadMigration failed: %v", err)
+	}
+	if m.UpSQL != "CREATE TABLE users (id serial);" || m.DownSQL != "DROP TABLE users;" {
+		t.Errorf("unexpected migration content: %+v", m)
+	}
+
+	if _, err := generator.GenerateEmpty("nope"); err == nil {
+		t.Error("expected writing to an fs.FS generator to fail")
+	}
+
+	missing, err := NewGeneratorFS(fsys, "absent").ListMigrations()
+	if err != nil || len(missing) != 0 {
+		t.Errorf("expected no migrations for a missing directory, got %v, %v", missing, err)
+	}
+}
@@ -0,0 +1,87 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// MigrationFunc is a migration written in Go. It runs inside the migration's
+// transaction; returning an error rolls it back.
+type MigrationFunc func(ctx context.Context, tx pgx.Tx) error
+
+var (
+	registeredMigrationsMu sync.Mutex
+	registeredMigrations   = make(map[string]Migration)
+)
+
+// Register adds a Go migration to the history applied by Runner. The id has
+// the same "{version}_{name}" form as migration file names, and the
+// migration runs in version order with the SQL files, so data backfills can
+// use the query builder between schema changes. Registered migrations cannot
+// be rolled back; use RegisterReversible to supply a down function.
+//
+// Example:
+//
+//	func init() {
+//	    migration.Register("20250101120000_backfill_slugs", func(ctx context.Context, tx pgx.Tx) error {
+//	        _, err := tx.Exec(ctx, "UPDATE posts SET slug = lower(title) WHERE slug IS NULL")
+//	        return err
+//	    })
+//	}
+func Register(id string, up MigrationFunc) {
+	RegisterReversible(id, up, nil)
+}
+
+// RegisterReversible adds a Go migration with a down function.
+// It panics if the version is already registered.
+func RegisterReversible(id string, up, down MigrationFunc) {
+	if up == nil {
+		panic(fmt.Sprintf("migration: Register %s with a nil up function", id))
+	}
+	version, name, _ := strings.Cut(id, "_")
+
+	registeredMigrationsMu.Lock()
+	defer registeredMigrationsMu.Unlock()
+	if _, exists := registeredMigrations[version]; exists {
+		panic(fmt.Sprintf("migration: version %s registered twice", version))
+	}
+	registeredMigrations[version] = Migration{Version: version, Name: name, Up: up, Down: down}
+}
+
+// RegisteredMigrations returns the Go migrations registered with Register,
+// sorted by version.
+func RegisteredMigrations() []Migration {
+	registeredMigrationsMu.Lock()
+	defer registeredMigrationsMu.Unlock()
+	migrations := make([]Migration, 0, len(registeredMigrations))
+	for _, m := range registeredMigrations {
+		migrations = append(migrations, m)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int { return strings.Compare(a.Version, b.Version) })
+	return migrations
+}
+
+// resetRegisteredMigrations clears the registered Go migrations (for tests).
+func resetRegisteredMigrations() {
+	registeredMigrationsMu.Lock()
+	defer registeredMigrationsMu.Unlock()
+	registeredMigrations = make(map[string]Migration)
+}
+
+// mergeMigrations interleaves SQL and Go migrations into one history ordered
+// by version. A version defined by both is an error.
+func mergeMigrations(files, funcs []Migration) ([]Migration, error) {
+	merged := slices.Concat(files, funcs)
+	slices.SortStableFunc(merged, func(a, b Migration) int { return strings.Compare(a.Version, b.Version) })
+	for i := 1; i < len(merged); i++ {
+		if merged[i].Version == merged[i-1].Version {
+			return nil, fmt.Errorf("migration version %s is defined more than once", merged[i].Version)
+		}
+	}
+	return merged, nil
+}
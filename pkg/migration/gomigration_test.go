@@ -0,0 +1,66 @@
+package migration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func TestRegisterMigrations(t *testing.T) {
+	resetRegisteredMigrations()
+	defer resetRegisteredMigrations()
+
+	noop := func(ctx context.Context, tx pgx.Tx) error { return nil }
+	Register("20240102000000_backfill_slugs", noop)
+	RegisterReversible("20240101000000_seed_roles", noop, noop)
+
+	migrations := RegisteredMigrations()
+	if len(migrations) != 2 {
+		t.Fatalf("expected 2 registered migrations, got %d", len(migrations))
+	}
+	if migrations[0].Version != "20240101000000" || migrations[0].Name != "seed_roles" || migrations[0].Down == nil {
+		t.Errorf("unexpected first migration: %+v", migrations[0])
+	}
+	if migrations[1].Version != "20240102000000" || migrations[1].Name != "backfill_slugs" || migrations[1].Down != nil {
+		t.Errorf("unexpected second migration: %+v", migrations[1])
+	}
+	if migrations[0].Checksum() != "" {
+		t.Error("Go migrations should not have a checksum")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected registering a duplicate version to panic")
+		}
+	}()
+	Register("20240101000000_again", noop)
+}
+
+func TestMergeMigrations(t *testing.T) {
+	noop := func(ctx context.Context, tx pgx.Tx) error { return nil }
+	files := []Migration{{Version: "20240101000000"}, {Version: "20240103000000"}}
+	funcs := []Migration{{Version: "20240102000000", Up: noop}}
+
+	merged, err := mergeMigrations(files, funcs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var versions []string
+	for _, m := range merged {
+		versions = append(versions, m.Version)
+	}
+	want := []string{"20240101000000", "20240102000000", "20240103000000"}
+	if len(versions) != len(want) {
+		t.Fatalf("got %v, want %v", versions, want)
+	}
+	for i := range want {
+		if versions[i] != want[i] {
+			t.Fatalf("got %v, want %v", versions, want)
+		}
+	}
+
+	if _, err := mergeMigrations(files, []Migration{{Version: "20240101000000", Up: noop}}); err == nil {
+		t.Error("expected a version defined by a file and a Go migration to fail")
+	}
+}
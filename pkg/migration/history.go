@@ -0,0 +1,184 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+)
+
+// History tables: models with a // history: true directive get a
+// <table>_history table and a trigger that copies each row's old version
+// there on UPDATE and DELETE, valid from its previous change (or -infinity)
+// until the change. Inserts are noted too, with no row, so a read as of an
+// earlier time knows the row didn't exist yet. builder.SelectQuery.AsOf
+// reads them back.
+const (
+	// HistoryComment marks history tables, which aren't models and are left
+	// out of introspection and reconstruction.
+	HistoryComment = "pebble history table"
+
+	historyTrigger  = "pebble_history"
+	historyFunction = "pebble_history_capture"
+)
+
+// historyFunctionSQL creates the trigger function shared by every history
+// table. The trigger passes the primary key columns as arguments; rows are
+// keyed in the history by them as a JSON object.
+const historyFunctionSQL = `CREATE OR REPLACE FUNCTION ` + historyFunction + `() RETURNS trigger AS $$
+DECLARE
+    history text := format('%I.%I', TG_TABLE_SCHEMA, TG_TABLE_NAME || '_history');
+    old_key jsonb := '{}';
+    new_key jsonb := '{}';
+    col text;
+    since timestamptz;
+BEGIN
+    FOREACH col IN ARRAY TG_ARGV LOOP
+        IF TG_OP <> 'INSERT' THEN
+            old_key := old_key || jsonb_build_object(col, to_jsonb(OLD) -> col);
+        END IF;
+        IF TG_OP <> 'DELETE' THEN
+            new_key := new_key || jsonb_build_object(col, to_jsonb(NEW) -> col);
+        END IF;
+    END LOOP;
+    IF TG_OP <> 'INSERT' THEN
+        EXECUTE format('SELECT max(valid_to) FROM %s WHERE row_key = $1', history) INTO since USING old_key;
+        EXECUTE format('INSERT INTO %s (row_key, row_data, operation, valid_from, valid_to) VALUES ($1, $2, $3, $4, now())', history)
+            USING old_key, to_jsonb(OLD), TG_OP, COALESCE(since, '-infinity');
+    END IF;
+    IF TG_OP = 'INSERT' OR (TG_OP = 'UPDATE' AND new_key <> old_key) THEN
+        EXECUTE format('INSERT INTO %s (row_key, operation, valid_from, valid_to) VALUES ($1, ''INSERT'', now(), now())', history)
+            USING new_key;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;`
+
+// HistoryChange represents a table starting or stopping to keep a history.
+type HistoryChange struct {
+	Old bool     // Kept in the database
+	New bool     // Kept in code
+	Key []string // Primary key columns the trigger keys rows by
+}
+
+// historyTable returns the qualified name of a table's history table.
+func historyTable(tableName string) string {
+	return tableName + "_history"
+}
+
+// generateCreateHistory generates the history table of a table and the
+// trigger that fills it, keying rows by the key columns.
+func (p *Planner) generateCreateHistory(tableName string, key []string) []string {
+	history := schema.QuoteQualifiedIdent(historyTable(tableName))
+	_, bareName := schema.SplitQualifiedName(tableName)
+	args := make([]string, len(key))
+	for i, col := range key {
+		args[i] = quoteLiteral(col)
+	}
+	return []string{
+		`CREATE TABLE IF NOT EXISTS ` + history + ` (
+    history_id bigserial PRIMARY KEY,
+    row_key jsonb NOT NULL,
+    row_data jsonb,
+    operation text NOT NULL,
+    valid_from timestamptz NOT NULL,
+    valid_to timestamptz NOT NULL
+);`,
+		p.generateTableComment(historyTable(tableName), HistoryComment),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (row_key, valid_to);",
+			schema.QuoteReservedIdent(bareName+"_history_key_idx"), history),
+		fmt.Sprintf("CREATE TRIGGER %s AFTER INSERT OR UPDATE OR DELETE ON %s FOR EACH ROW EXECUTE FUNCTION %s(%s);",
+			historyTrigger, schema.QuoteQualifiedIdent(tableName), historyFunction, strings.Join(args, ", ")),
+	}
+}
+
+// generateDropHistoryTrigger generates the statement that stops recording a
+// table's history. The history table is kept.
+func (p *Planner) generateDropHistoryTrigger(tableName string) string {
+	return fmt.Sprintf("DROP TRIGGER IF EXISTS %s ON %s;", historyTrigger, schema.QuoteQualifiedIdent(tableName))
+}
+
+// generateHistoryChanges returns the statements that start and stop keeping
+// the history of new and modified tables. Turning history off only drops
+// the trigger, so the versions recorded so far survive; the down migration
+// of turning it on drops the history table it created.
+func (p *Planner) generateHistoryChanges(diff *SchemaDiff) (upSQL, downSQL []string) {
+	type keyed struct {
+		table string
+		key   []string
+	}
+	var created, restored []keyed
+	for _, table := range diff.TablesAdded {
+		if table.History {
+			created = append(created, keyed{table.QualifiedName(), table.PrimaryKeyColumns()})
+		}
+	}
+	for _, tableDiff := range diff.TablesModified {
+		switch c := tableDiff.HistoryChanged; {
+		case c == nil:
+		case c.New:
+			created = append(created, keyed{tableDiff.TableName, c.Key})
+			downSQL = append(downSQL, p.generateDropHistoryTrigger(tableDiff.TableName))
+		default:
+			upSQL = append(upSQL, p.generateDropHistoryTrigger(tableDiff.TableName))
+			restored = append(restored, keyed{tableDiff.TableName, c.Key})
+		}
+	}
+
+	if len(created) > 0 {
+		upSQL = append(upSQL, historyFunctionSQL)
+		for _, t := range created {
+			upSQL = append(upSQL, p.generateCreateHistory(t.table, t.key)...)
+			downSQL = append(downSQL, p.generateDropTable(historyTable(t.table)))
+		}
+	}
+	if len(restored) > 0 {
+		downSQL = append(downSQL, historyFunctionSQL)
+		for _, t := range restored {
+			downSQL = append(downSQL, p.generateCreateHistory(t.table, t.key)...)
+		}
+	}
+	return upSQL, downSQL
+}
+
+// getHistoryTrigger reports whether a table has the history trigger.
+func (i *Introspector) getHistoryTrigger(ctx context.Context, schemaName, tableName string) (bool, error) {
+	rows, err := i.query(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM pg_trigger
+			WHERE tgrelid = format('%I.%I', $1::text, $2::text)::regclass AND tgname = $3
+		)`, schemaName, tableName, historyTrigger)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	var exists bool
+	if rows.Next() {
+		if err := rows.Scan(&exists); err != nil {
+			return false, err
+		}
+	}
+	return exists, rows.Err()
+}
+
+var (
+	reCreateHistoryTrigger = regexp.MustCompile(`(?is)^\s*CREATE\s+TRIGGER\s+` + historyTrigger + `\s+.*?\bON\s+((?:"?\w+"?\.)?"?\w+"?)`)
+	reDropHistoryTrigger   = regexp.MustCompile(`(?is)^\s*DROP\s+TRIGGER\s+(?:IF\s+EXISTS\s+)?` + historyTrigger + `\s+ON\s+((?:"?\w+"?\.)?"?\w+"?)`)
+)
+
+// applyHistoryTrigger applies a history trigger statement to the
+// reconstructed schema.
+func applyHistoryTrigger(tables map[string]*schema.TableMetadata, stmt string) {
+	if m := reCreateHistoryTrigger.FindStringSubmatch(stmt); m != nil {
+		if table, ok := tables[reconstructTableName(m[1])]; ok {
+			table.History = true
+		}
+	} else if m := reDropHistoryTrigger.FindStringSubmatch(stmt); m != nil {
+		if table, ok := tables[reconstructTableName(m[1])]; ok {
+			table.History = false
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package migration
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+)
+
+func TestGenerateMigration_History(t *testing.T) {
+	prices := schema.TableMetadata{
+		Name:       "prices",
+		History:    true,
+		Columns:    []schema.ColumnMetadata{{Name: "sku", SQLType: "text"}, {Name: "region", SQLType: "text"}, {Name: "amount", SQLType: "numeric"}},
+		PrimaryKey: &schema.PrimaryKeyMetadata{Columns: []string{"sku", "region"}},
+	}
+	up, down := NewPlanner().GenerateMigration(&SchemaDiff{TablesAdded: []schema.TableMetadata{prices}})
+
+	function := strings.Index(up, "CREATE OR REPLACE FUNCTION pebble_history_capture()")
+	history := strings.Index(up, "CREATE TABLE IF NOT EXISTS prices_history (")
+	trigger := strings.Index(up, "CREATE TRIGGER pebble_history AFTER INSERT OR UPDATE OR DELETE ON prices FOR EACH ROW EXECUTE FUNCTION pebble_history_capture('sku', 'region');")
+	if function < 0 || history < function || trigger < history || strings.Index(up, "CREATE TABLE IF NOT EXISTS prices (") > function {
+		t.Errorf("expected the table, then the function, the history table and the trigger, got\n%s", up)
+	}
+	if !strings.Contains(up, "COMMENT ON TABLE prices_history IS 'pebble history table';") {
+		t.Errorf("expected the history table to be marked, got\n%s", up)
+	}
+	if !strings.Contains(down, `DROP TABLE IF EXISTS "prices_history"`) {
+		t.Errorf("expected the down migration to drop the history table, got\n%s", down)
+	}
+
+	// Replaying the migration reconstructs the flag but not the history table.
+	tables := make(map[string]*schema.TableMetadata)
+	applySQLToSchema(tables, up)
+	if len(tables) != 1 || tables["prices"] == nil || !tables["prices"].History {
+		t.Errorf("expected only prices, with history, got %v", tables)
+	}
+}
+
+func TestCompare_History(t *testing.T) {
+	code := map[string]*schema.TableMetadata{"orders": {Name: "orders", History: true, PrimaryKey: &schema.PrimaryKeyMetadata{Columns: []string{"id"}}}}
+	db := map[string]*schema.TableMetadata{"orders": {Name: "orders", PrimaryKey: &schema.PrimaryKeyMetadata{Columns: []string{"id"}}}}
+
+	diff := NewDiffer().Compare(code, db)
+	if len(diff.TablesModified) != 1 || diff.TablesModified[0].HistoryChanged == nil {
+		t.Fatalf("expected history to be enabled, got %+v", diff.TablesModified)
+	}
+	up, down := NewPlanner().GenerateMigration(diff)
+	if !strings.Contains(up, "EXECUTE FUNCTION pebble_history_capture('id');") || !strings.Contains(down, "DROP TRIGGER IF EXISTS pebble_history ON orders;") {
+		t.Errorf("unexpected migration\nup:\n%s\ndown:\n%s", up, down)
+	}
+	applySQLToSchema(db, up)
+	if diff := NewDiffer().Compare(code, db); diff.HasChanges() {
+		t.Errorf("expected no changes after replaying, got %+v", diff.TablesModified)
+	}
+
+	// Turning history off keeps the recorded versions.
+	code["orders"].History = false
+	diff = NewDiffer().Compare(code, db)
+	up, down = NewPlanner().GenerateMigration(diff)
+	if up != "DROP TRIGGER IF EXISTS pebble_history ON orders;\n" || !strings.Contains(down, "CREATE TRIGGER pebble_history") {
+		t.Errorf("unexpected migration\nup:\n%s\ndown:\n%s", up, down)
+	}
+	applySQLToSchema(db, up)
+	if db["orders"].History {
+		t.Error("expected replaying the drop to disable history")
+	}
+}
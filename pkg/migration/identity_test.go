@@ -178,3 +178,77 @@ func TestIdentityVsSerial(t *testing.T) {
 		t.Errorf("Serial column should not contain GENERATED keyword")
 	}
 }
+
+func TestIdentityChange(t *testing.T) {
+	always := schema.ColumnMetadata{Name: "id", SQLType: "bigint", Identity: &schema.IdentityColumn{Generation: schema.IdentityAlways}}
+	byDefault := always
+	byDefault.Identity = &schema.IdentityColumn{Generation: schema.IdentityByDefault}
+	plain := schema.ColumnMetadata{Name: "id", SQLType: "bigint"}
+
+	migrate := func(codeCol, dbCol schema.ColumnMetadata) (up, down string) {
+		code := map[string]*schema.TableMetadata{"orders": {Name: "orders", Columns: []schema.ColumnMetadata{codeCol}}}
+		db := map[string]*schema.TableMetadata{"orders": {Name: "orders", Columns: []schema.ColumnMetadata{dbCol}}}
+		diff := NewDiffer().Compare(code, db)
+		if len(diff.TablesModified) != 1 || !diff.TablesModified[0].ColumnsModified[0].IdentityChanged {
+			t.Fatalf("expected an identity change, got %+v", diff.TablesModified)
+		}
+		return NewPlanner().GenerateMigration(diff)
+	}
+
+	t.Run("generation", func(t *testing.T) {
+		up, down := migrate(byDefault, always)
+		if !strings.Contains(up, "ALTER TABLE orders ALTER COLUMN id SET GENERATED BY DEFAULT;") {
+			t.Errorf("unexpected up migration:\n%s", up)
+		}
+		if !strings.Contains(down, "ALTER TABLE orders ALTER COLUMN id SET GENERATED ALWAYS;") {
+			t.Errorf("unexpected down migration:\n%s", down)
+		}
+	})
+
+	t.Run("added to a nullable column", func(t *testing.T) {
+		nullable := plain
+		nullable.Nullable = true
+		up, down := migrate(always, nullable)
+		wantUp := "ALTER TABLE orders ALTER COLUMN id SET NOT NULL;\n\n" +
+			"ALTER TABLE orders ALTER COLUMN id ADD GENERATED ALWAYS AS IDENTITY;\n\n" +
+			"SELECT setval(pg_get_serial_sequence('orders', 'id'), COALESCE(MAX(id), 0) + 1, false) FROM orders;\n"
+		if up != wantUp {
+			t.Errorf("up migration:\n%s\nwant:\n%s", up, wantUp)
+		}
+		wantDown := "ALTER TABLE orders ALTER COLUMN id DROP IDENTITY IF EXISTS;\n\n" +
+			"ALTER TABLE orders ALTER COLUMN id DROP NOT NULL;\n"
+		if down != wantDown {
+			t.Errorf("down migration:\n%s\nwant:\n%s", down, wantDown)
+		}
+	})
+
+	t.Run("dropped", func(t *testing.T) {
+		up, down := migrate(plain, always)
+		if want := "ALTER TABLE orders ALTER COLUMN id DROP IDENTITY IF EXISTS;\n"; up != want {
+			t.Errorf("up migration:\n%s\nwant:\n%s", up, want)
+		}
+		if want := "ALTER TABLE orders ALTER COLUMN id ADD GENERATED ALWAYS AS IDENTITY;\n"; down != want {
+			t.Errorf("down migration:\n%s\nwant:\n%s", down, want)
+		}
+	})
+
+	t.Run("reconstructed from migrations", func(t *testing.T) {
+		tables := make(map[string]*schema.TableMetadata)
+		applySQLToSchema(tables, "CREATE TABLE orders (\n    id bigint,\n    ref bigint GENERATED ALWAYS AS IDENTITY\n);\n\n"+
+			"ALTER TABLE orders ALTER COLUMN id ADD GENERATED BY DEFAULT AS IDENTITY (START WITH 100);\n\n"+
+			"ALTER TABLE orders ALTER COLUMN ref DROP IDENTITY IF EXISTS;")
+		id, ref := tables["orders"].GetColumnByName("id"), tables["orders"].GetColumnByName("ref")
+		if id.Identity == nil || id.Identity.Generation != schema.IdentityByDefault ||
+			id.Identity.Sequence == nil || *id.Identity.Sequence.Start != 100 || id.Nullable {
+			t.Errorf("id identity not reconstructed: %+v", id)
+		}
+		if ref.Identity != nil {
+			t.Errorf("ref identity not dropped: %+v", ref.Identity)
+		}
+
+		applySQLToSchema(tables, "ALTER TABLE orders ALTER COLUMN id SET GENERATED ALWAYS;")
+		if id := tables["orders"].GetColumnByName("id"); id.Identity.Generation != schema.IdentityAlways {
+			t.Errorf("generation = %s, want ALWAYS", id.Identity.Generation)
+		}
+	})
+}
@@ -3,6 +3,7 @@ package migration
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/jackc/pgx/v5"
@@ -12,7 +13,8 @@ import (
 
 // Introspector inspects database schema.
 type Introspector struct {
-	pool *pgxpool.Pool
+	pool    *pgxpool.Pool
+	schemas []string
 }
 
 // NewIntrospector creates a new database introspector.
@@ -20,6 +22,34 @@ func NewIntrospector(pool *pgxpool.Pool) *Introspector {
 	return &Introspector{pool: pool}
 }
 
+// WithSchemas sets the PostgreSQL schemas IntrospectSchema reads tables from
+// (default: public). Tables outside public are keyed "schema.table".
+func (i *Introspector) WithSchemas(schemas ...string) *Introspector {
+	i.schemas = schemas
+	return i
+}
+
+// SchemasOf returns public plus every other schema the tables live in, for
+// passing to WithSchemas when diffing code models against the database.
+func SchemasOf(tables map[string]*schema.TableMetadata) []string {
+	schemas := []string{schema.DefaultSchema}
+	for _, t := range tables {
+		if t.Schema != "" && !slices.Contains(schemas, t.Schema) {
+			schemas = append(schemas, t.Schema)
+		}
+	}
+	slices.Sort(schemas[1:])
+	return schemas
+}
+
+// schemaNames returns the schemas to introspect.
+func (i *Introspector) schemaNames() []string {
+	if len(i.schemas) == 0 {
+		return []string{schema.DefaultSchema}
+	}
+	return i.schemas
+}
+
 // query executes a query using simple query protocol to avoid prepared statement caching.
 func (i *Introspector) query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
 	conn, err := i.pool.Acquire(ctx)
@@ -68,11 +98,71 @@ func (i *Introspector) IntrospectSchema(ctx context.Context) (map[string]*schema
 		tables[tableName] = table
 	}
 
+	views, err := i.getViews(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get views: %w", err)
+	}
+	for _, view := range views {
+		tables[view.QualifiedName()] = view
+	}
+
 	return tables, nil
 }
 
-// IntrospectTable introspects a single table.
+// getViews retrieves the views and materialized views in the introspected
+// schemas, with the defining query as pg_get_viewdef prints it. That is a
+// rewritten form of the model's text; the differ compares the two after
+// normalizeViewQuery.
+func (i *Introspector) getViews(ctx context.Context) ([]*schema.TableMetadata, error) {
+	query := `
+		SELECT schemaname, viewname, false AS materialized, definition
+		FROM pg_views
+		WHERE schemaname = ANY($1)
+		UNION ALL
+		SELECT schemaname, matviewname, true AS materialized, definition
+		FROM pg_matviews
+		WHERE schemaname = ANY($1)
+		ORDER BY 1, 2
+	`
+
+	rows, err := i.query(ctx, query, i.schemaNames())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var views []*schema.TableMetadata
+	for rows.Next() {
+		var schemaName, viewName, definition string
+		var materialized bool
+		if err := rows.Scan(&schemaName, &viewName, &materialized, &definition); err != nil {
+			return nil, err
+		}
+		// definition is pg_get_viewdef's, indented and ending in a semicolon
+		view := &schema.TableMetadata{
+			Name: viewName,
+			View: &schema.ViewMetadata{
+				Query:        strings.TrimSuffix(strings.TrimSpace(definition), ";"),
+				Materialized: materialized,
+			},
+		}
+		if schemaName != schema.DefaultSchema {
+			view.Schema = schemaName
+		}
+		views = append(views, view)
+	}
+
+	return views, rows.Err()
+}
+
+// IntrospectTable introspects a single table. tableName may be schema-qualified
+// ("billing.invoices"); unqualified names are looked up in public.
 func (i *Introspector) IntrospectTable(ctx context.Context, tableName string) (*schema.TableMetadata, error) {
+	schemaName, tableName := schema.SplitQualifiedName(tableName)
+	if schemaName == "" {
+		schemaName = schema.DefaultSchema
+	}
+
 	table := &schema.TableMetadata{
 		Name:        tableName,
 		Columns:     make([]schema.ColumnMetadata, 0),
@@ -81,44 +171,68 @@ func (i *Introspector) IntrospectTable(ctx context.Context, tableName string) (*
 		Constraints: make([]schema.ConstraintMetadata, 0),
 		EnumTypes:   make([]schema.EnumType, 0),
 	}
+	if schemaName != schema.DefaultSchema {
+		table.Schema = schemaName
+	}
+
+	// Get table comment
+	comment, err := i.getTableComment(ctx, schemaName, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get table comment: %w", err)
+	}
+	table.Comment = comment
+
+	// Get change data capture trigger
+	cdc, err := i.getCDCTrigger(ctx, schemaName, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get CDC trigger: %w", err)
+	}
+	table.CDC = cdc
+
+	// Get history trigger
+	history, err := i.getHistoryTrigger(ctx, schemaName, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get history trigger: %w", err)
+	}
+	table.History = history
 
 	// Get columns
-	columns, err := i.getColumns(ctx, tableName)
+	columns, err := i.getColumns(ctx, schemaName, tableName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get columns: %w", err)
 	}
 	table.Columns = columns
 
 	// Get primary key
-	pk, err := i.getPrimaryKey(ctx, tableName)
+	pk, err := i.getPrimaryKey(ctx, schemaName, tableName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get primary key: %w", err)
 	}
 	table.PrimaryKey = pk
 
 	// Get foreign keys
-	fks, err := i.getForeignKeys(ctx, tableName)
+	fks, err := i.getForeignKeys(ctx, schemaName, tableName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get foreign keys: %w", err)
 	}
 	table.ForeignKeys = fks
 
 	// Get indexes
-	indexes, err := i.getIndexes(ctx, tableName)
+	indexes, err := i.getIndexes(ctx, schemaName, tableName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get indexes: %w", err)
 	}
 	table.Indexes = indexes
 
 	// Get check constraints
-	constraints, err := i.getConstraints(ctx, tableName)
+	constraints, err := i.getConstraints(ctx, schemaName, tableName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get constraints: %w", err)
 	}
 	table.Constraints = constraints
 
 	// Get enum types used by this table
-	enumTypes, err := i.getEnumTypes(ctx, tableName)
+	enumTypes, err := i.getEnumTypes(ctx, schemaName, tableName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get enum types: %w", err)
 	}
@@ -127,18 +241,20 @@ func (i *Introspector) IntrospectTable(ctx context.Context, tableName string) (*
 	return table, nil
 }
 
-// getTableNames retrieves all table names in the public schema.
+// getTableNames retrieves all table names in the introspected schemas,
+// qualified with their schema outside public.
 func (i *Introspector) getTableNames(ctx context.Context) ([]string, error) {
 	query := `
-		SELECT table_name
+		SELECT table_schema, table_name
 		FROM information_schema.tables
-		WHERE table_schema = 'public'
+		WHERE table_schema = ANY($1)
 		  AND table_type = 'BASE TABLE'
-		  AND table_name != 'schema_migrations'
-		ORDER BY table_name
+		  AND table_name NOT IN ('schema_migrations', 'pebble_cdc_events', 'pebble_cdc_offsets', 'pebble_jobs', 'pebble_schedule')
+		  AND obj_description(format('%I.%I', table_schema, table_name)::regclass, 'pg_class') IS DISTINCT FROM '` + HistoryComment + `'
+		ORDER BY table_schema, table_name
 	`
 
-	rows, err := i.query(ctx, query)
+	rows, err := i.query(ctx, query, i.schemaNames())
 	if err != nil {
 		return nil, err
 	}
@@ -146,18 +262,35 @@ func (i *Introspector) getTableNames(ctx context.Context) ([]string, error) {
 
 	var tables []string
 	for rows.Next() {
-		var tableName string
-		if err := rows.Scan(&tableName); err != nil {
+		var schemaName, tableName string
+		if err := rows.Scan(&schemaName, &tableName); err != nil {
 			return nil, err
 		}
-		tables = append(tables, tableName)
+		tables = append(tables, schema.QualifyTableName(schemaName, tableName))
 	}
 
 	return tables, rows.Err()
 }
 
+// getTableComment retrieves a table's comment ("" if it has none).
+func (i *Introspector) getTableComment(ctx context.Context, schemaName, tableName string) (string, error) {
+	rows, err := i.query(ctx, `SELECT COALESCE(obj_description(format('%I.%I', $2::text, $1::text)::regclass, 'pg_class'), '')`, tableName, schemaName)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	var comment string
+	if rows.Next() {
+		if err := rows.Scan(&comment); err != nil {
+			return "", err
+		}
+	}
+	return comment, rows.Err()
+}
+
 // getColumns retrieves column information for a table.
-func (i *Introspector) getColumns(ctx context.Context, tableName string) ([]schema.ColumnMetadata, error) {
+func (i *Introspector) getColumns(ctx context.Context, schemaName, tableName string) ([]schema.ColumnMetadata, error) {
 	query := `
 		SELECT
 			column_name,
@@ -168,13 +301,33 @@ func (i *Introspector) getColumns(ctx context.Context, tableName string) ([]sche
 			numeric_scale,
 			is_nullable,
 			column_default,
-			ordinal_position
+			ordinal_position,
+			CASE WHEN is_identity = 'YES' THEN identity_generation END,
+			identity_start::bigint,
+			identity_increment::bigint,
+			identity_minimum::bigint,
+			identity_maximum::bigint,
+			identity_cycle = 'YES',
+			(
+				SELECT s.cache_size FROM pg_sequences s
+				WHERE is_identity = 'YES'
+				  AND format('%I.%I', s.schemaname, s.sequencename) =
+				      pg_get_serial_sequence(format('%I.%I', table_schema, table_name), column_name)
+			),
+			COALESCE(col_description(format('%I.%I', table_schema, table_name)::regclass, ordinal_position::int), ''),
+			COALESCE(collation_name, ''),
+			COALESCE((
+				SELECT a.attgenerated::text FROM pg_attribute a
+				WHERE a.attrelid = format('%I.%I', table_schema, table_name)::regclass
+				  AND a.attname = column_name
+			), ''),
+			COALESCE(generation_expression, '')
 		FROM information_schema.columns
-		WHERE table_schema = 'public' AND table_name = $1
+		WHERE table_schema = $2 AND table_name = $1
 		ORDER BY ordinal_position
 	`
 
-	rows, err := i.query(ctx, query, tableName)
+	rows, err := i.query(ctx, query, tableName, schemaName)
 	if err != nil {
 		return nil, err
 	}
@@ -188,6 +341,9 @@ func (i *Introspector) getColumns(ctx context.Context, tableName string) ([]sche
 		var isNullable string
 		var defaultVal *string
 		var position int
+		var identityGeneration *string
+		var seq schema.SequenceOptions
+		var generatedKind, generationExpr string
 
 		err := rows.Scan(
 			&col.Name,
@@ -199,6 +355,17 @@ func (i *Introspector) getColumns(ctx context.Context, tableName string) ([]sche
 			&isNullable,
 			&defaultVal,
 			&position,
+			&identityGeneration,
+			&seq.Start,
+			&seq.Increment,
+			&seq.MinValue,
+			&seq.MaxValue,
+			&seq.Cycle,
+			&seq.Cache,
+			&col.Comment,
+			&col.Collation,
+			&generatedKind,
+			&generationExpr,
 		)
 		if err != nil {
 			return nil, err
@@ -210,6 +377,22 @@ func (i *Introspector) getColumns(ctx context.Context, tableName string) ([]sche
 		col.Default = defaultVal
 		col.Position = position - 1 // Zero-indexed
 
+		if identityGeneration != nil {
+			col.Identity = &schema.IdentityColumn{
+				Generation: schema.IdentityGeneration(*identityGeneration),
+				Sequence:   &seq,
+			}
+		}
+
+		// pg_attribute.attgenerated is 's' for stored and 'v' for virtual
+		// generated columns.
+		switch generatedKind {
+		case "s":
+			col.Generated = &schema.GeneratedColumn{Expression: generationExpr, Type: schema.GeneratedStored}
+		case "v":
+			col.Generated = &schema.GeneratedColumn{Expression: generationExpr, Type: schema.GeneratedVirtual}
+		}
+
 		// Check if auto-increment (serial)
 		if defaultVal != nil && strings.Contains(*defaultVal, "nextval") {
 			col.AutoIncrement = true
@@ -228,7 +411,7 @@ func (i *Introspector) getColumns(ctx context.Context, tableName string) ([]sche
 }
 
 // getPrimaryKey retrieves primary key information.
-func (i *Introspector) getPrimaryKey(ctx context.Context, tableName string) (*schema.PrimaryKeyMetadata, error) {
+func (i *Introspector) getPrimaryKey(ctx context.Context, schemaName, tableName string) (*schema.PrimaryKeyMetadata, error) {
 	query := `
 		SELECT
 			tc.constraint_name,
@@ -237,13 +420,13 @@ func (i *Introspector) getPrimaryKey(ctx context.Context, tableName string) (*sc
 		JOIN information_schema.key_column_usage kcu
 			ON tc.constraint_name = kcu.constraint_name
 			AND tc.table_schema = kcu.table_schema
-		WHERE tc.table_schema = 'public'
+		WHERE tc.table_schema = $2
 			AND tc.table_name = $1
 			AND tc.constraint_type = 'PRIMARY KEY'
 		GROUP BY tc.constraint_name
 	`
 
-	rows, err := i.query(ctx, query, tableName)
+	rows, err := i.query(ctx, query, tableName, schemaName)
 	if err != nil {
 		return nil, err
 	}
@@ -268,15 +451,18 @@ func (i *Introspector) getPrimaryKey(ctx context.Context, tableName string) (*sc
 }
 
 // getForeignKeys retrieves foreign key information.
-func (i *Introspector) getForeignKeys(ctx context.Context, tableName string) ([]schema.ForeignKeyMetadata, error) {
+func (i *Introspector) getForeignKeys(ctx context.Context, schemaName, tableName string) ([]schema.ForeignKeyMetadata, error) {
 	query := `
 		SELECT
 			tc.constraint_name,
 			array_agg(DISTINCT kcu.column_name) as columns,
+			ccu.table_schema as foreign_schema,
 			ccu.table_name as foreign_table,
 			array_agg(DISTINCT ccu.column_name) as foreign_columns,
 			rc.update_rule,
-			rc.delete_rule
+			rc.delete_rule,
+			tc.is_deferrable = 'YES',
+			tc.initially_deferred = 'YES'
 		FROM information_schema.table_constraints tc
 		JOIN information_schema.key_column_usage kcu
 			ON tc.constraint_name = kcu.constraint_name
@@ -284,13 +470,14 @@ func (i *Introspector) getForeignKeys(ctx context.Context, tableName string) ([]
 			ON ccu.constraint_name = tc.constraint_name
 		JOIN information_schema.referential_constraints rc
 			ON rc.constraint_name = tc.constraint_name
-		WHERE tc.table_schema = 'public'
+		WHERE tc.table_schema = $2
 			AND tc.table_name = $1
 			AND tc.constraint_type = 'FOREIGN KEY'
-		GROUP BY tc.constraint_name, ccu.table_name, rc.update_rule, rc.delete_rule
+		GROUP BY tc.constraint_name, ccu.table_schema, ccu.table_name, rc.update_rule, rc.delete_rule,
+			tc.is_deferrable, tc.initially_deferred
 	`
 
-	rows, err := i.query(ctx, query, tableName)
+	rows, err := i.query(ctx, query, tableName, schemaName)
 	if err != nil {
 		return nil, err
 	}
@@ -299,20 +486,24 @@ func (i *Introspector) getForeignKeys(ctx context.Context, tableName string) ([]
 	var foreignKeys []schema.ForeignKeyMetadata
 	for rows.Next() {
 		var fk schema.ForeignKeyMetadata
-		var updateRule, deleteRule string
+		var refSchema, updateRule, deleteRule string
 
 		err := rows.Scan(
 			&fk.Name,
 			&fk.Columns,
+			&refSchema,
 			&fk.ReferencedTable,
 			&fk.ReferencedColumns,
 			&updateRule,
 			&deleteRule,
+			&fk.Deferrable,
+			&fk.InitiallyDeferred,
 		)
 		if err != nil {
 			return nil, err
 		}
 
+		fk.ReferencedTable = schema.QualifyTableName(refSchema, fk.ReferencedTable)
 		fk.OnUpdate = parseReferenceAction(updateRule)
 		fk.OnDelete = parseReferenceAction(deleteRule)
 
@@ -326,7 +517,7 @@ func (i *Introspector) getForeignKeys(ctx context.Context, tableName string) ([]
 // NOTE: This only returns standalone indexes. Indexes that back constraints
 // (UNIQUE, PRIMARY KEY) are managed through the constraints themselves and
 // should not be dropped with DROP INDEX.
-func (i *Introspector) getIndexes(ctx context.Context, tableName string) ([]schema.IndexMetadata, error) {
+func (i *Introspector) getIndexes(ctx context.Context, schemaName, tableName string) ([]schema.IndexMetadata, error) {
 	// This query retrieves comprehensive index information including:
 	// - Expression indexes
 	// - Partial indexes (WHERE clause)
@@ -347,13 +538,13 @@ func (i *Introspector) getIndexes(ctx context.Context, tableName string) ([]sche
 		JOIN pg_am am ON i.relam = am.oid
 		LEFT JOIN pg_constraint c ON c.conindid = ix.indexrelid
 		WHERE t.relname = $1
-			AND t.relnamespace = (SELECT oid FROM pg_namespace WHERE nspname = 'public')
+			AND t.relnamespace = (SELECT oid FROM pg_namespace WHERE nspname = $2)
 			AND NOT ix.indisprimary
 			AND c.conindid IS NULL  -- Exclude constraint-backed indexes
 		ORDER BY i.relname
 	`
 
-	rows, err := i.query(ctx, query, tableName)
+	rows, err := i.query(ctx, query, tableName, schemaName)
 	if err != nil {
 		return nil, err
 	}
@@ -400,7 +591,7 @@ func (i *Introspector) getIndexes(ctx context.Context, tableName string) ([]sche
 }
 
 // getConstraints retrieves CHECK and UNIQUE constraint information.
-func (i *Introspector) getConstraints(ctx context.Context, tableName string) ([]schema.ConstraintMetadata, error) {
+func (i *Introspector) getConstraints(ctx context.Context, schemaName, tableName string) ([]schema.ConstraintMetadata, error) {
 	query := `
 		SELECT
 			con.conname as constraint_name,
@@ -415,12 +606,12 @@ func (i *Introspector) getConstraints(ctx context.Context, tableName string) ([]
 		FROM pg_constraint con
 		JOIN pg_class rel ON rel.oid = con.conrelid
 		JOIN pg_namespace nsp ON nsp.oid = connamespace
-		WHERE nsp.nspname = 'public'
+		WHERE nsp.nspname = $2
 			AND rel.relname = $1
-			AND con.contype IN ('c', 'u')
+			AND con.contype IN ('c', 'u', 'x')
 	`
 
-	rows, err := i.query(ctx, query, tableName)
+	rows, err := i.query(ctx, query, tableName, schemaName)
 	if err != nil {
 		return nil, err
 	}
@@ -444,6 +635,10 @@ func (i *Introspector) getConstraints(ctx context.Context, tableName string) ([]
 		case 'u':
 			c.Type = schema.UniqueConstraint
 			c.Columns = columnNames
+		case 'x':
+			c.Type = schema.ExclusionConstraint
+			c.Expression = strings.TrimPrefix(c.Expression, "EXCLUDE ")
+			c.Columns = columnNames
 		}
 
 		constraints = append(constraints, c)
@@ -502,8 +697,9 @@ func parseReferenceAction(rule string) schema.ReferenceAction {
 	}
 }
 
-// getEnumTypes retrieves enum types used by columns in this table.
-func (i *Introspector) getEnumTypes(ctx context.Context, tableName string) ([]schema.EnumType, error) {
+// getEnumTypes retrieves enum types used by columns in this table, directly
+// or as the element type of an array column.
+func (i *Introspector) getEnumTypes(ctx context.Context, schemaName, tableName string) ([]schema.EnumType, error) {
 	query := `
 		SELECT
 			t.typname as enum_name,
@@ -511,17 +707,17 @@ func (i *Introspector) getEnumTypes(ctx context.Context, tableName string) ([]sc
 		FROM pg_type t
 		JOIN pg_enum e ON t.oid = e.enumtypid
 		WHERE t.typname IN (
-			SELECT udt_name
+			SELECT CASE WHEN data_type = 'ARRAY' THEN substr(udt_name, 2) ELSE udt_name END
 			FROM information_schema.columns
-			WHERE table_schema = 'public'
+			WHERE table_schema = $2
 			  AND table_name = $1
-			  AND data_type = 'USER-DEFINED'
+			  AND data_type IN ('USER-DEFINED', 'ARRAY')
 		)
 		GROUP BY t.typname
 		ORDER BY t.typname
 	`
 
-	rows, err := i.query(ctx, query, tableName)
+	rows, err := i.query(ctx, query, tableName, schemaName)
 	if err != nil {
 		return nil, err
 	}
@@ -560,7 +756,11 @@ func (i *Introspector) parseIndexDefinition(tableName, indexName, indexType stri
 	if tableNamePos == -1 {
 		// Try without schema qualifier
 		tableNamePos = strings.Index(indexDef, " ON "+tableName)
-		if tableNamePos == -1 {
+	}
+	if tableNamePos == -1 {
+		// Try any other schema qualifier (e.g. "ON billing.invoices")
+		tableNamePos = strings.Index(indexDef, "."+tableName+" ")
+		if tableNamePos == -1 || !strings.Contains(indexDef[:tableNamePos], " ON ") {
 			return idx, nil // Fallback to basic index
 		}
 	}
@@ -61,6 +61,16 @@ func TestParseIndexDefinitionWithSchemaQualifier(t *testing.T) {
 			isExpression: false,
 			expectedCols: []string{"expires_at"},
 		},
+		{
+			name:         "non-public schema qualifier (billing.invoices)",
+			indexDef:     "CREATE INDEX idx_invoices_customer ON billing.invoices USING btree (customer_id)",
+			tableName:    "invoices",
+			indexName:    "idx_invoices_customer",
+			indexType:    "btree",
+			isUnique:     false,
+			isExpression: false,
+			expectedCols: []string{"customer_id"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -9,11 +9,13 @@ import (
 
 // Migration represents a database migration.
 type Migration struct {
-	Version   string    // Version/timestamp (e.g., "20240101120000")
-	Name      string    // Migration name (e.g., "create_users_table")
-	UpSQL     string    // SQL for applying the migration
-	DownSQL   string    // SQL for rolling back the migration
-	AppliedAt time.Time // When the migration was applied
+	Version   string        // Version/timestamp (e.g., "20240101120000")
+	Name      string        // Migration name (e.g., "create_users_table")
+	UpSQL     string        // SQL for applying the migration
+	DownSQL   string        // SQL for rolling back the migration
+	AppliedAt time.Time     // When the migration was applied
+	Up        MigrationFunc // Go code run instead of UpSQL (registered migrations)
+	Down      MigrationFunc // Go code run instead of DownSQL; nil if irreversible
 }
 
 // MigrationFile represents a migration file on disk.
@@ -26,12 +28,19 @@ type MigrationFile struct {
 
 // SchemaDiff represents differences between two schemas.
 type SchemaDiff struct {
-	TablesAdded       []schema.TableMetadata // Tables to create
-	TablesDropped     []schema.TableMetadata // Tables to drop (full metadata for down migration)
-	TablesModified    []TableDiff            // Tables with changes
-	EnumTypesAdded    []schema.EnumType      // Enum types to create
-	EnumTypesDropped  []schema.EnumType      // Enum types to drop (full metadata for down migration)
-	EnumTypesModified []EnumTypeDiff         // Enum types with new values
+	TablesAdded       []schema.TableMetadata    // Tables to create
+	TablesDropped     []schema.TableMetadata    // Tables to drop (full metadata for down migration)
+	TablesRenamed     []TableRename             // Tables to rename, before other table changes
+	TablesModified    []TableDiff               // Tables with changes
+	EnumTypesAdded    []schema.EnumType         // Enum types to create
+	EnumTypesDropped  []schema.EnumType         // Enum types to drop (full metadata for down migration)
+	EnumTypesModified []EnumTypeDiff            // Enum types with new values
+	ViewsAdded        []schema.TableMetadata    // Views to create
+	ViewsDropped      []schema.TableMetadata    // Views to drop (full metadata for down migration)
+	ViewsModified     []ViewDiff                // Views whose definition changed
+	ExtensionsAdded   []string                  // Extensions to create, in dependency order
+	SequencesAdded    []schema.SequenceMetadata // Standalone sequences to create
+	SequencesModified []SequenceDiff            // Standalone sequences whose options changed
 }
 
 // TableDiff represents changes to a single table.
@@ -39,24 +48,37 @@ type TableDiff struct {
 	TableName          string                      // Name of the table
 	ColumnsAdded       []schema.ColumnMetadata     // Columns to add
 	ColumnsDropped     []schema.ColumnMetadata     // Columns to drop (full metadata for down migration)
+	ColumnsRenamed     []ColumnRename              // Columns to rename, before other column changes
 	ColumnsModified    []ColumnDiff                // Columns with changes
 	IndexesAdded       []schema.IndexMetadata      // Indexes to create
 	IndexesDropped     []schema.IndexMetadata      // Indexes to drop (full metadata for down migration)
+	IndexesModified    []IndexDiff                 // Indexes whose definition changed under the same name
 	ForeignKeysAdded   []schema.ForeignKeyMetadata // Foreign keys to add
 	ForeignKeysDropped []schema.ForeignKeyMetadata // Foreign keys to drop (full metadata for down migration)
 	ConstraintsAdded   []schema.ConstraintMetadata // Constraints to add
 	ConstraintsDropped []schema.ConstraintMetadata // Constraints to drop (full metadata for down migration)
 	PrimaryKeyChanged  *PrimaryKeyChange           // Primary key modification
+	CommentChanged     *CommentChange              // Table comment modification
+	CDCChanged         *CDCChange                  // Change data capture enabled or disabled
+	HistoryChanged     *HistoryChange              // History table enabled or disabled
 }
 
 // ColumnDiff represents changes to a single column.
 type ColumnDiff struct {
-	ColumnName     string // Name of the column
-	OldColumn      schema.ColumnMetadata
-	NewColumn      schema.ColumnMetadata
-	TypeChanged    bool // SQL type changed
-	NullChanged    bool // Nullability changed
-	DefaultChanged bool // Default value changed
+	ColumnName      string // Name of the column
+	OldColumn       schema.ColumnMetadata
+	NewColumn       schema.ColumnMetadata
+	TypeChanged     bool // SQL type changed
+	NullChanged     bool // Nullability changed
+	DefaultChanged  bool // Default value changed
+	SequenceChanged bool // Identity sequence options changed
+	CommentChanged  bool // Column comment changed
+	// IdentityChanged is set when a column became or stopped being an
+	// identity, or changed between ALWAYS and BY DEFAULT.
+	IdentityChanged bool
+	// GeneratedChanged is set when a column became or stopped being
+	// generated, or its generation expression or kind changed.
+	GeneratedChanged bool
 }
 
 // PrimaryKeyChange represents a change to the primary key.
@@ -72,6 +94,21 @@ type EnumTypeDiff struct {
 	NewValues []string // New values to add
 }
 
+// ViewDiff represents a changed view definition. Views are dropped and
+// recreated rather than altered.
+type ViewDiff struct {
+	Name string               // Qualified view name
+	Old  schema.TableMetadata // Definition in the database
+	New  schema.TableMetadata // Definition in code
+}
+
+// IndexDiff represents an index whose definition changed. It is dropped and
+// re-created, since PostgreSQL cannot alter an index's columns or predicate.
+type IndexDiff struct {
+	Old schema.IndexMetadata // Definition in the database
+	New schema.IndexMetadata // Definition in code
+}
+
 // MigrationStatus represents the status of a migration.
 type MigrationStatus string
 
@@ -82,6 +119,8 @@ const (
 	StatusApplied MigrationStatus = "applied"
 	// StatusFailed means the migration failed to apply.
 	StatusFailed MigrationStatus = "failed"
+	// StatusModified means the migration was applied but its file has changed since.
+	StatusModified MigrationStatus = "modified"
 )
 
 // MigrationRecord represents a migration in the tracking table.
@@ -91,6 +130,7 @@ type MigrationRecord struct {
 	Status    MigrationStatus // Current status
 	AppliedAt *time.Time      // When applied (nil if not applied)
 	Error     *string         // Error message if failed
+	Checksum  *string         // SHA-256 of the up SQL when applied (nil for legacy records)
 }
 
 // MigrationPlan represents a plan for applying migrations.
@@ -103,24 +143,36 @@ type MigrationPlan struct {
 func (d *SchemaDiff) HasChanges() bool {
 	return len(d.TablesAdded) > 0 ||
 		len(d.TablesDropped) > 0 ||
+		len(d.TablesRenamed) > 0 ||
 		len(d.TablesModified) > 0 ||
 		len(d.EnumTypesAdded) > 0 ||
 		len(d.EnumTypesDropped) > 0 ||
-		len(d.EnumTypesModified) > 0
+		len(d.EnumTypesModified) > 0 ||
+		len(d.ViewsAdded) > 0 ||
+		len(d.ViewsDropped) > 0 ||
+		len(d.ViewsModified) > 0 ||
+		len(d.ExtensionsAdded) > 0 ||
+		len(d.SequencesAdded) > 0 ||
+		len(d.SequencesModified) > 0
 }
 
 // HasChanges returns true if the table has any changes.
 func (t *TableDiff) HasChanges() bool {
 	return len(t.ColumnsAdded) > 0 ||
 		len(t.ColumnsDropped) > 0 ||
+		len(t.ColumnsRenamed) > 0 ||
 		len(t.ColumnsModified) > 0 ||
 		len(t.IndexesAdded) > 0 ||
 		len(t.IndexesDropped) > 0 ||
+		len(t.IndexesModified) > 0 ||
 		len(t.ForeignKeysAdded) > 0 ||
 		len(t.ForeignKeysDropped) > 0 ||
 		len(t.ConstraintsAdded) > 0 ||
 		len(t.ConstraintsDropped) > 0 ||
-		t.PrimaryKeyChanged != nil
+		t.PrimaryKeyChanged != nil ||
+		t.CommentChanged != nil ||
+		t.CDCChanged != nil ||
+		t.HistoryChanged != nil
 }
 
 // GenerateVersion generates a timestamp-based version string.
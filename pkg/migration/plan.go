@@ -0,0 +1,287 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// LockLevel is the PostgreSQL table lock mode a statement takes.
+type LockLevel string
+
+const (
+	// LockNone means the statement locks no existing table.
+	LockNone LockLevel = ""
+	// LockRowExclusive is taken by INSERT, UPDATE and DELETE; it doesn't block reads or writes.
+	LockRowExclusive LockLevel = "ROW EXCLUSIVE"
+	// LockShareUpdateExclusive is taken by concurrent index builds and VALIDATE CONSTRAINT.
+	LockShareUpdateExclusive LockLevel = "SHARE UPDATE EXCLUSIVE"
+	// LockShare is taken by CREATE INDEX; it blocks writes.
+	LockShare LockLevel = "SHARE"
+	// LockShareRowExclusive is taken when adding a foreign key; it blocks writes.
+	LockShareRowExclusive LockLevel = "SHARE ROW EXCLUSIVE"
+	// LockAccessExclusive is taken by most ALTER TABLE forms; it blocks reads and writes.
+	LockAccessExclusive LockLevel = "ACCESS EXCLUSIVE"
+)
+
+// lockRank orders lock levels from weakest to strongest.
+var lockRank = map[LockLevel]int{
+	LockNone:                 0,
+	LockRowExclusive:         1,
+	LockShareUpdateExclusive: 2,
+	LockShare:                3,
+	LockShareRowExclusive:    4,
+	LockAccessExclusive:      5,
+}
+
+// DefaultLargeTableRows is the estimated row count above which a blocking
+// index build is flagged by Plan.
+const DefaultLargeTableRows = 1_000_000
+
+// PlannedStatement is one statement of a pending migration with its impact.
+type PlannedStatement struct {
+	SQL      string    // Statement as it will run
+	Table    string    // Existing table the statement locks (empty if none)
+	Lock     LockLevel // Strongest table lock the statement takes
+	Warnings []string  // Destructive or blocking operations
+}
+
+// PlannedMigration is a pending migration with the statements it would run.
+type PlannedMigration struct {
+	Version       string             // Migration version
+	Name          string             // Migration name
+	Transactional bool               // Runs in a single transaction
+	Statements    []PlannedStatement // Statements in execution order
+	Warnings      []string           // Warnings about the migration as a whole
+}
+
+// HasWarnings reports whether the migration or any of its statements has warnings.
+func (p PlannedMigration) HasWarnings() bool {
+	if len(p.Warnings) > 0 {
+		return true
+	}
+	for _, stmt := range p.Statements {
+		if len(stmt.Warnings) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+const identPattern = `(?:"[^"]+"|\w+)(?:\.(?:"[^"]+"|\w+))?`
+
+var (
+	rePlanAlterTable  = regexp.MustCompile(`(?is)^\s*ALTER\s+TABLE\s+(?:IF\s+EXISTS\s+)?(?:ONLY\s+)?(` + identPattern + `)\s+(.*)$`)
+	rePlanDropTable   = regexp.MustCompile(`(?is)^\s*DROP\s+TABLE\s+(?:IF\s+EXISTS\s+)?(` + identPattern + `)`)
+	rePlanTruncate    = regexp.MustCompile(`(?is)^\s*TRUNCATE\s+(?:TABLE\s+)?(?:ONLY\s+)?(` + identPattern + `)`)
+	rePlanCreateIndex = regexp.MustCompile(`(?is)^\s*CREATE\s+(?:UNIQUE\s+)?INDEX\s+(CONCURRENTLY\s+)?(?:IF\s+NOT\s+EXISTS\s+)?(?:` + identPattern + `\s+)?ON\s+(?:ONLY\s+)?(` + identPattern + `)`)
+	rePlanDropIndex   = regexp.MustCompile(`(?is)^\s*DROP\s+INDEX\s+(CONCURRENTLY\s+)?`)
+	rePlanDML         = regexp.MustCompile(`(?is)^\s*(?:INSERT\s+INTO|UPDATE|DELETE\s+FROM)\s+(?:ONLY\s+)?(` + identPattern + `)`)
+
+	reActionDropColumn = regexp.MustCompile(`(?i)^DROP\s+(?:COLUMN\s+)?(?:IF\s+EXISTS\s+)?(` + identPattern + `)`)
+	reActionDropOther  = regexp.MustCompile(`(?i)^DROP\s+(?:CONSTRAINT|DEFAULT|NOT\s+NULL|IDENTITY|EXPRESSION)\b`)
+	reActionType       = regexp.MustCompile(`(?i)^ALTER\s+(?:COLUMN\s+)?(` + identPattern + `)\s+(?:SET\s+DATA\s+)?TYPE\b`)
+	reActionSetNotNull = regexp.MustCompile(`(?i)^ALTER\s+(?:COLUMN\s+)?(` + identPattern + `)\s+SET\s+NOT\s+NULL\b`)
+	reActionForeignKey = regexp.MustCompile(`(?i)^ADD\s+(?:CONSTRAINT\s+` + identPattern + `\s+)?FOREIGN\s+KEY\b`)
+	reActionCheck      = regexp.MustCompile(`(?i)^ADD\s+(?:CONSTRAINT\s+` + identPattern + `\s+)?(?:CHECK|UNIQUE|PRIMARY\s+KEY|EXCLUDE)\b`)
+	reActionValidate   = regexp.MustCompile(`(?i)^VALIDATE\s+CONSTRAINT\b`)
+	reActionNotValid   = regexp.MustCompile(`(?i)\bNOT\s+VALID\s*$`)
+)
+
+// PlanMigrations analyses the statements of migrations without running them.
+// Go migrations are listed without statements, since their SQL is only known
+// when they run.
+func PlanMigrations(migrations []Migration) []PlannedMigration {
+	plans := make([]PlannedMigration, 0, len(migrations))
+	for _, m := range migrations {
+		plan := PlannedMigration{
+			Version:       m.Version,
+			Name:          m.Name,
+			Transactional: m.Transactional(),
+		}
+		if m.Up != nil {
+			plan.Warnings = append(plan.Warnings, "Go migration: statements are not known until it runs")
+		}
+		for _, stmt := range splitSQLStatements(m.UpSQL) {
+			plan.Statements = append(plan.Statements, AnalyzeStatement(stmt))
+		}
+		plans = append(plans, plan)
+	}
+	return plans
+}
+
+// AnalyzeStatement estimates the table lock a statement takes and flags
+// destructive or blocking operations.
+func AnalyzeStatement(stmt string) PlannedStatement {
+	ps := PlannedStatement{SQL: strings.TrimSpace(stmt)}
+
+	switch {
+	case rePlanAlterTable.MatchString(stmt):
+		m := rePlanAlterTable.FindStringSubmatch(stmt)
+		ps.Table = unquoteIdent(m[1])
+		for _, action := range splitTopLevel(m[2]) {
+			lock, warning := analyzeAlterAction(ps.Table, action)
+			if lockRank[lock] > lockRank[ps.Lock] {
+				ps.Lock = lock
+			}
+			if warning != "" {
+				ps.Warnings = append(ps.Warnings, warning)
+			}
+		}
+	case rePlanDropTable.MatchString(stmt):
+		ps.Table = unquoteIdent(rePlanDropTable.FindStringSubmatch(stmt)[1])
+		ps.Lock = LockAccessExclusive
+		ps.Warnings = append(ps.Warnings, fmt.Sprintf("drops table %s and all its data", ps.Table))
+	case rePlanTruncate.MatchString(stmt):
+		ps.Table = unquoteIdent(rePlanTruncate.FindStringSubmatch(stmt)[1])
+		ps.Lock = LockAccessExclusive
+		ps.Warnings = append(ps.Warnings, fmt.Sprintf("deletes all rows of %s", ps.Table))
+	case rePlanCreateIndex.MatchString(stmt):
+		m := rePlanCreateIndex.FindStringSubmatch(stmt)
+		ps.Table = unquoteIdent(m[2])
+		ps.Lock = LockShare
+		if m[1] != "" {
+			ps.Lock = LockShareUpdateExclusive
+		}
+	case rePlanDropIndex.MatchString(stmt):
+		ps.Lock = LockAccessExclusive
+		if rePlanDropIndex.FindStringSubmatch(stmt)[1] != "" {
+			ps.Lock = LockShareUpdateExclusive
+		}
+	case rePlanDML.MatchString(stmt):
+		ps.Table = unquoteIdent(rePlanDML.FindStringSubmatch(stmt)[1])
+		ps.Lock = LockRowExclusive
+	}
+	return ps
+}
+
+// analyzeAlterAction returns the lock and warning for one ALTER TABLE action.
+func analyzeAlterAction(table, action string) (LockLevel, string) {
+	action = strings.TrimSpace(action)
+	switch {
+	case reActionDropOther.MatchString(action):
+		return LockAccessExclusive, ""
+	case reActionDropColumn.MatchString(action):
+		col := unquoteIdent(reActionDropColumn.FindStringSubmatch(action)[1])
+		return LockAccessExclusive, fmt.Sprintf("drops column %s.%s and its data", table, col)
+	case reActionType.MatchString(action):
+		col := unquoteIdent(reActionType.FindStringSubmatch(action)[1])
+		return LockAccessExclusive, fmt.Sprintf("changes the type of %s.%s, which may rewrite the table and its indexes", table, col)
+	case reActionSetNotNull.MatchString(action):
+		col := unquoteIdent(reActionSetNotNull.FindStringSubmatch(action)[1])
+		return LockAccessExclusive, fmt.Sprintf("SET NOT NULL on %s.%s scans the whole table", table, col)
+	case reActionForeignKey.MatchString(action):
+		if reActionNotValid.MatchString(action) {
+			return LockShareRowExclusive, ""
+		}
+		return LockShareRowExclusive, fmt.Sprintf("adding a foreign key validates every row of %s; consider NOT VALID and a later VALIDATE CONSTRAINT", table)
+	case reActionCheck.MatchString(action):
+		return LockAccessExclusive, ""
+	case reActionValidate.MatchString(action):
+		return LockShareUpdateExclusive, ""
+	}
+	return LockAccessExclusive, ""
+}
+
+// splitTopLevel splits ALTER TABLE actions on commas outside parentheses and
+// string literals.
+func splitTopLevel(s string) []string {
+	var parts []string
+	depth, start := 0, 0
+	inString := false
+	for i, r := range s {
+		switch {
+		case r == '\'':
+			inString = !inString
+		case inString:
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+		case r == ',' && depth == 0:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// unquoteIdent strips double quotes from a possibly qualified identifier.
+func unquoteIdent(name string) string {
+	return strings.ReplaceAll(name, `"`, "")
+}
+
+// Plan analyses migrations and flags non-concurrent index builds on tables
+// whose estimated row count exceeds the large-table threshold.
+func (e *Executor) Plan(ctx context.Context, migrations []Migration) ([]PlannedMigration, error) {
+	plans := PlanMigrations(migrations)
+	estimates := make(map[string]int64)
+	for i := range plans {
+		for j := range plans[i].Statements {
+			stmt := &plans[i].Statements[j]
+			if stmt.Lock != LockShare || stmt.Table == "" {
+				continue
+			}
+			rows, ok := estimates[stmt.Table]
+			if !ok {
+				var err error
+				rows, err = e.estimateRows(ctx, stmt.Table)
+				if err != nil {
+					return nil, err
+				}
+				estimates[stmt.Table] = rows
+			}
+			if rows >= e.largeTableRows {
+				stmt.Warnings = append(stmt.Warnings, fmt.Sprintf(
+					"builds an index on %s (~%d rows) without CONCURRENTLY, blocking writes until it finishes", stmt.Table, rows))
+			}
+		}
+	}
+	return plans, nil
+}
+
+// estimateRows returns the planner's row estimate for a table, or -1 if the
+// table doesn't exist yet or has never been analysed.
+func (e *Executor) estimateRows(ctx context.Context, table string) (int64, error) {
+	var rows *int64
+	err := e.pool.QueryRow(ctx,
+		"SELECT reltuples::bigint FROM pg_class WHERE oid = to_regclass($1)",
+		table,
+	).Scan(&rows)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return 0, fmt.Errorf("failed to estimate rows of %s: %w", table, err)
+	}
+	if rows == nil {
+		return -1, nil
+	}
+	return *rows, nil
+}
+
+// Plan returns the pending migrations with the statements they would run,
+// their lock levels and warnings, without applying anything.
+func (r *Runner) Plan(ctx context.Context) ([]PlannedMigration, error) {
+	migrations, err := r.prepare(ctx)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := r.executor.GetAppliedMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	appliedMap := make(map[string]bool)
+	for _, record := range applied {
+		appliedMap[record.Version] = true
+	}
+
+	var pending []Migration
+	for _, m := range migrations {
+		if !appliedMap[m.Version] {
+			pending = append(pending, m)
+		}
+	}
+	return r.executor.Plan(ctx, pending)
+}
@@ -0,0 +1,74 @@
+package migration
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAnalyzeStatement(t *testing.T) {
+	tests := []struct {
+		name    string
+		sql     string
+		table   string
+		lock    LockLevel
+		warning string
+	}{
+		{"create table", "CREATE TABLE users (id integer)", "", LockNone, ""},
+		{"add column", "ALTER TABLE users ADD COLUMN IF NOT EXISTS age integer", "users", LockAccessExclusive, ""},
+		{"drop column", `ALTER TABLE "users" DROP COLUMN IF EXISTS "email"`, "users", LockAccessExclusive, "drops column users.email"},
+		{"drop constraint is not a column", "ALTER TABLE users DROP CONSTRAINT users_email_key", "users", LockAccessExclusive, ""},
+		{"type change", "ALTER TABLE users ALTER COLUMN age TYPE bigint USING age::bigint", "users", LockAccessExclusive, "may rewrite the table"},
+		{"set not null", "ALTER TABLE billing.invoices ALTER COLUMN total SET NOT NULL", "billing.invoices", LockAccessExclusive, "scans the whole table"},
+		{"foreign key", "ALTER TABLE posts ADD CONSTRAINT fk_posts_user FOREIGN KEY (user_id) REFERENCES users (id)", "posts", LockShareRowExclusive, "NOT VALID"},
+		{"foreign key not valid", "ALTER TABLE posts ADD CONSTRAINT fk FOREIGN KEY (user_id) REFERENCES users (id) NOT VALID", "posts", LockShareRowExclusive, ""},
+		{"validate constraint", "ALTER TABLE posts VALIDATE CONSTRAINT fk", "posts", LockShareUpdateExclusive, ""},
+		{"strongest action wins", "ALTER TABLE posts VALIDATE CONSTRAINT fk, ADD COLUMN x numeric(10,2)", "posts", LockAccessExclusive, ""},
+		{"create index", "CREATE INDEX IF NOT EXISTS idx_users_email ON users (email)", "users", LockShare, ""},
+		{"create index concurrently", "CREATE UNIQUE INDEX CONCURRENTLY idx ON public.users USING btree (email)", "public.users", LockShareUpdateExclusive, ""},
+		{"drop index", "DROP INDEX IF EXISTS idx_users_email", "", LockAccessExclusive, ""},
+		{"drop index concurrently", "DROP INDEX CONCURRENTLY idx_users_email", "", LockShareUpdateExclusive, ""},
+		{"drop table", "DROP TABLE IF EXISTS users CASCADE", "users", LockAccessExclusive, "drops table users"},
+		{"truncate", "TRUNCATE TABLE sessions", "sessions", LockAccessExclusive, "deletes all rows"},
+		{"update", "UPDATE users SET active = true", "users", LockRowExclusive, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := AnalyzeStatement(tt.sql)
+			if got.Table != tt.table {
+				t.Errorf("Table = %q, want %q", got.Table, tt.table)
+			}
+			if got.Lock != tt.lock {
+				t.Errorf("Lock = %q, want %q", got.Lock, tt.lock)
+			}
+			warnings := strings.Join(got.Warnings, "; ")
+			if tt.warning == "" && warnings != "" {
+				t.Errorf("unexpected warnings: %s", warnings)
+			}
+			if tt.warning != "" && !strings.Contains(warnings, tt.warning) {
+				t.Errorf("warnings %q do not mention %q", warnings, tt.warning)
+			}
+		})
+	}
+}
+
+func TestPlanMigrations(t *testing.T) {
+	plans := PlanMigrations([]Migration{
+		{Version: "1", Name: "create_users", UpSQL: "CREATE TABLE users (id integer);\nCREATE INDEX idx ON users (id);"},
+		{Version: "2", Name: "drop_legacy", UpSQL: "-- cleanup\nDROP TABLE legacy;"},
+		{Version: "3", Name: "index_emails", UpSQL: "CREATE INDEX CONCURRENTLY idx_email ON users (email);"},
+	})
+
+	if len(plans) != 3 {
+		t.Fatalf("expected 3 plans, got %d", len(plans))
+	}
+	if len(plans[0].Statements) != 2 || plans[0].HasWarnings() {
+		t.Errorf("unexpected plan for create_users: %+v", plans[0])
+	}
+	if !plans[1].HasWarnings() {
+		t.Error("expected DROP TABLE to be flagged")
+	}
+	if plans[2].Transactional {
+		t.Error("expected the concurrent index migration to run outside a transaction")
+	}
+}
@@ -2,14 +2,19 @@ package migration
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/marshallshelly/pebble-orm/pkg/schema"
 )
 
 // quoteIdent quotes a PostgreSQL identifier (table name, column name, etc.)
-// to handle reserved keywords and special characters.
+// to handle reserved keywords and special characters. Schema-qualified names
+// are quoted part by part ("billing"."invoices").
 func quoteIdent(name string) string {
+	if schemaName, table := schema.SplitQualifiedName(name); schemaName != "" {
+		return fmt.Sprintf(`"%s"."%s"`, schemaName, table)
+	}
 	return fmt.Sprintf(`"%s"`, name)
 }
 
@@ -19,6 +24,25 @@ type PlannerOptions struct {
 	// This makes migrations idempotent and safe to run multiple times.
 	// Default: true (safe by default)
 	IfNotExists bool
+
+	// DisallowDrops comments out DROP TABLE and DROP COLUMN statements (and
+	// their down counterparts) instead of emitting them, so a renamed model
+	// can't silently generate a DROP TABLE. Uncomment them to apply.
+	DisallowDrops bool
+
+	// RequireConfirmToken holds drops back until they are confirmed:
+	// GenerateMigrationChecked returns a *DropConfirmationError and
+	// GenerateMigration comments them out unless ConfirmToken equals
+	// DropToken(diff).
+	RequireConfirmToken bool
+
+	// ConfirmToken confirms the drops of a diff (see RequireConfirmToken).
+	ConfirmToken string
+
+	// BatchedTypeChanges writes column type changes as a commented template
+	// that backfills a new column in batches, for tables too large to rewrite
+	// under ALTER COLUMN ... TYPE's ACCESS EXCLUSIVE lock.
+	BatchedTypeChanges bool
 }
 
 // Planner generates SQL migration statements from schema diffs.
@@ -51,6 +75,24 @@ func (p *Planner) GenerateMigration(diff *SchemaDiff) (upSQL, downSQL string) {
 	// and dropped AFTER tables that use them are dropped.
 
 	// UP migration order:
+	// 0. CREATE SCHEMA for non-public schemas of new tables and views. Schemas
+	// may hold objects outside this diff, so the down migration leaves them.
+	for _, schemaName := range newTableSchemas(slices.Concat(diff.TablesAdded, diff.ViewsAdded)) {
+		upStatements = append(upStatements, fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s;", schema.QuoteReservedIdent(schemaName)))
+	}
+
+	// Extensions come before the types, tables and indexes that use them
+	// (gen_random_uuid(), gin_trgm_ops, ...) and are dropped last on the way
+	// down, dependents first.
+	for _, name := range diff.ExtensionsAdded {
+		upStatements = append(upStatements, p.generateCreateExtension(name))
+	}
+
+	// Sequences are created before the tables whose defaults may use them;
+	// ownership and option changes are applied once the tables exist.
+	upSequences, upSequenceAlters, downSequences := p.generateSequenceChanges(diff)
+	upStatements = append(upStatements, upSequences...)
+
 	// 1. CREATE TYPE for new enum types
 	for _, enumType := range diff.EnumTypesAdded {
 		upStatements = append(upStatements, p.generateCreateEnumType(enumType))
@@ -65,6 +107,23 @@ func (p *Planner) GenerateMigration(diff *SchemaDiff) (upSQL, downSQL string) {
 		downStatements = append(downStatements, fmt.Sprintf("-- NOTE: Cannot automatically remove enum values from type %s (PostgreSQL limitation)", enumDiff.Name))
 	}
 
+	// Views depend on tables, so changed and removed views are dropped before
+	// any table changes, and new views are created after them. In the down
+	// migration they are dropped first and restored last.
+	var downViewDrops, downViewCreates []string
+	for _, viewDiff := range diff.ViewsModified {
+		upStatements = append(upStatements, p.generateDropView(&viewDiff.Old))
+		downViewDrops = append(downViewDrops, p.generateDropView(&viewDiff.New))
+	}
+	for _, view := range diff.ViewsDropped {
+		upStatements = append(upStatements, p.generateDropView(&view))
+	}
+
+	// Renamed tables take their new names before anything refers to them
+	for _, r := range diff.TablesRenamed {
+		upStatements = append(upStatements, p.generateRenameTable(r.Old, r.New, r.Heuristic))
+	}
+
 	// 3. CREATE TABLE statements — sorted so referenced tables are created first.
 	sorted := topoSortTables(diff.TablesAdded)
 	for _, table := range sorted {
@@ -72,29 +131,88 @@ func (p *Planner) GenerateMigration(diff *SchemaDiff) (upSQL, downSQL string) {
 	}
 	// DOWN drops in reverse creation order (dependents before dependencies).
 	for i := len(sorted) - 1; i >= 0; i-- {
-		downStatements = append(downStatements, p.generateDropTable(sorted[i].Name))
+		downStatements = append(downStatements, p.generateDropTable(sorted[i].QualifiedName()))
 	}
 
-	// 4. ALTER TABLE statements for table modifications
+	// 4. ALTER TABLE statements for table modifications. Drops held back by
+	// the drop policy are emitted commented out.
+	allowDrops := p.dropsAllowed(diff)
 	for _, tableDiff := range diff.TablesModified {
+		var heldUp, heldDown []string
+		if !allowDrops && len(tableDiff.ColumnsDropped) > 0 {
+			heldUp, heldDown = p.generateDropColumns(tableDiff)
+			tableDiff.ColumnsDropped = nil
+		}
 		upAlter, downAlter := p.generateAlterTable(tableDiff)
 		upStatements = append(upStatements, upAlter...)
 		downStatements = append(downStatements, downAlter...)
+		if len(heldUp) > 0 {
+			upStatements = append(upStatements, p.holdBackDrops(heldUp, diff))
+			downStatements = append(downStatements, p.holdBackDrops(heldDown, diff))
+		}
 	}
 
+	// CDC triggers, once their tables exist
+	upCDC, downCDC := p.generateCDCChanges(diff)
+	upStatements = append(upStatements, upCDC...)
+	downStatements = append(downStatements, downCDC...)
+
+	// History tables and triggers, likewise
+	upHistory, downHistory := p.generateHistoryChanges(diff)
+	upStatements = append(upStatements, upHistory...)
+	downStatements = append(downStatements, downHistory...)
+
 	// 5. DROP TABLE statements
 	for _, table := range diff.TablesDropped {
-		upStatements = append(upStatements, p.generateDropTable(table.Name))
-		downStatements = append(downStatements, p.generateCreateTable(&table))
+		up, down := p.generateDropTable(table.QualifiedName()), p.generateCreateTable(&table)
+		if !allowDrops {
+			up, down = p.holdBackDrops([]string{up}, diff), p.holdBackDrops([]string{down}, diff)
+		}
+		upStatements = append(upStatements, up)
+		downStatements = append(downStatements, down)
+	}
+	for i := len(diff.TablesRenamed) - 1; i >= 0; i-- {
+		r := diff.TablesRenamed[i]
+		downStatements = append(downStatements, p.generateRenameTable(r.New, r.Old, false))
+	}
+
+	upStatements = append(upStatements, upSequenceAlters...)
+
+	// 6. CREATE VIEW for new and changed views
+	for _, view := range diff.ViewsAdded {
+		upStatements = append(upStatements, p.generateCreateView(&view))
+		downViewDrops = append(downViewDrops, p.generateDropView(&view))
 	}
+	for _, viewDiff := range diff.ViewsModified {
+		upStatements = append(upStatements, p.generateCreateView(&viewDiff.New))
+		if viewDiff.Old.View.Query != "" {
+			downViewCreates = append(downViewCreates, p.generateCreateView(&viewDiff.Old))
+		} else {
+			downViewCreates = append(downViewCreates, fmt.Sprintf("-- MANUAL MIGRATION REQUIRED: Restore the previous definition of view %s", viewDiff.Name))
+		}
+	}
+	for _, view := range diff.ViewsDropped {
+		if view.View.Query != "" {
+			downViewCreates = append(downViewCreates, p.generateCreateView(&view))
+		} else {
+			downViewCreates = append(downViewCreates, fmt.Sprintf("-- MANUAL MIGRATION REQUIRED: Restore the definition of view %s", view.QualifiedName()))
+		}
+	}
+	downStatements = append(append(downViewDrops, downStatements...), downViewCreates...)
 
-	// 6. DROP TYPE for enum types that are no longer used
+	// 7. DROP TYPE for enum types that are no longer used
 	// This should come AFTER dropping tables that use them
 	for _, enumType := range diff.EnumTypesDropped {
 		upStatements = append(upStatements, p.generateDropEnumType(enumType.Name))
 		downStatements = append(downStatements, p.generateCreateEnumType(enumType))
 	}
 
+	downStatements = append(downStatements, downSequences...)
+
+	for i := len(diff.ExtensionsAdded) - 1; i >= 0; i-- {
+		downStatements = append(downStatements, p.generateDropExtension(diff.ExtensionsAdded[i]))
+	}
+
 	// Join statements
 	up := strings.Join(upStatements, "\n\n") + "\n"
 	down := strings.Join(downStatements, "\n\n") + "\n"
@@ -140,6 +258,8 @@ func (p *Planner) generateCreateTable(table *schema.TableMetadata) string {
 		switch constraint.Type {
 		case schema.CheckConstraint:
 			parts = append(parts, fmt.Sprintf("    CONSTRAINT %s CHECK %s", constraint.Name, constraint.Expression))
+		case schema.ExclusionConstraint:
+			parts = append(parts, fmt.Sprintf("    CONSTRAINT %s EXCLUDE %s", constraint.Name, constraint.Expression))
 		case schema.UniqueConstraint:
 			// Only add UNIQUE constraint if it's not already handled by inline column UNIQUE
 			// Multi-column UNIQUE constraints or explicit UNIQUE constraints go here
@@ -155,24 +275,75 @@ func (p *Planner) generateCreateTable(table *schema.TableMetadata) string {
 	if p.options.IfNotExists {
 		createClause = "CREATE TABLE IF NOT EXISTS"
 	}
-	sql := fmt.Sprintf("%s %s (\n%s\n);", createClause, schema.QuoteReservedIdent(table.Name), strings.Join(parts, ",\n"))
+	sql := fmt.Sprintf("%s %s (\n%s\n);", createClause, schema.QuoteQualifiedIdent(table.QualifiedName()), strings.Join(parts, ",\n"))
 
 	// Indexes (separate statements)
 	var indexStatements []string
 	for _, idx := range table.Indexes {
-		indexStatements = append(indexStatements, p.generateCreateIndex(table.Name, idx))
+		indexStatements = append(indexStatements, p.generateCreateIndex(table.QualifiedName(), idx))
 	}
 
 	if len(indexStatements) > 0 {
 		sql += "\n\n" + strings.Join(indexStatements, "\n")
 	}
 
+	// Table and column comments (separate statements)
+	if comments := p.generateCreateComments(table); len(comments) > 0 {
+		sql += "\n\n" + strings.Join(comments, "\n")
+	}
+
+	return sql
+}
+
+// generateCreateView generates a CREATE VIEW or CREATE MATERIALIZED VIEW
+// statement, followed by any indexes on a materialized view.
+func (p *Planner) generateCreateView(view *schema.TableMetadata) string {
+	name := schema.QuoteQualifiedIdent(view.QualifiedName())
+	if !view.View.Materialized {
+		return fmt.Sprintf("CREATE OR REPLACE VIEW %s AS\n%s;", name, view.View.Query)
+	}
+
+	createClause := "CREATE MATERIALIZED VIEW"
+	if p.options.IfNotExists {
+		createClause = "CREATE MATERIALIZED VIEW IF NOT EXISTS"
+	}
+	sql := fmt.Sprintf("%s %s AS\n%s;", createClause, name, view.View.Query)
+	for _, idx := range view.Indexes {
+		sql += "\n\n" + p.generateCreateIndex(view.QualifiedName(), idx)
+	}
 	return sql
 }
 
+// generateDropView generates a DROP VIEW or DROP MATERIALIZED VIEW statement.
+func (p *Planner) generateDropView(view *schema.TableMetadata) string {
+	kind := "VIEW"
+	if view.View.Materialized {
+		kind = "MATERIALIZED VIEW"
+	}
+	return fmt.Sprintf("DROP %s IF EXISTS %s;", kind, schema.QuoteQualifiedIdent(view.QualifiedName()))
+}
+
+// columnType returns the column's SQL type with its COLLATE clause, if any.
+func columnType(col schema.ColumnMetadata) string {
+	if col.Collation == "" {
+		return col.SQLType
+	}
+	return fmt.Sprintf(`%s COLLATE "%s"`, col.SQLType, col.Collation)
+}
+
+// identityClause returns the GENERATED ... AS IDENTITY clause of an identity
+// column, with its sequence options.
+func identityClause(col schema.ColumnMetadata) string {
+	clause := fmt.Sprintf("GENERATED %s AS IDENTITY", col.Identity.Generation)
+	if clauses := sequenceClauses(identitySequence(col)); len(clauses) > 0 {
+		clause += " (" + strings.Join(clauses, " ") + ")"
+	}
+	return clause
+}
+
 // generateColumnDefinition generates a column definition.
 func (p *Planner) generateColumnDefinition(col schema.ColumnMetadata) string {
-	parts := []string{schema.QuoteReservedIdent(col.Name), col.SQLType}
+	parts := []string{schema.QuoteReservedIdent(col.Name), columnType(col)}
 
 	// Generated columns cannot have NOT NULL, DEFAULT, or UNIQUE constraints
 	// as they are computed from other columns
@@ -188,8 +359,7 @@ func (p *Planner) generateColumnDefinition(col schema.ColumnMetadata) string {
 	// Identity columns (PostgreSQL 10+, SQL Standard)
 	// GENERATED { ALWAYS | BY DEFAULT } AS IDENTITY
 	if col.Identity != nil {
-		identityClause := fmt.Sprintf("GENERATED %s AS IDENTITY", col.Identity.Generation)
-		parts = append(parts, identityClause)
+		parts = append(parts, identityClause(col))
 		// Identity columns are automatically NOT NULL, no need to add it explicitly
 		return strings.Join(parts, " ")
 	}
@@ -216,7 +386,7 @@ func (p *Planner) generateForeignKeyDefinition(fk schema.ForeignKeyMetadata) str
 
 	parts := []string{
 		fmt.Sprintf("CONSTRAINT %s FOREIGN KEY (%s)", fk.Name, localCols),
-		fmt.Sprintf("REFERENCES %s (%s)", schema.QuoteReservedIdent(fk.ReferencedTable), refCols),
+		fmt.Sprintf("REFERENCES %s (%s)", schema.QuoteQualifiedIdent(fk.ReferencedTable), refCols),
 	}
 
 	if fk.OnDelete != schema.NoAction && fk.OnDelete != "" {
@@ -227,6 +397,12 @@ func (p *Planner) generateForeignKeyDefinition(fk schema.ForeignKeyMetadata) str
 		parts = append(parts, "ON UPDATE "+string(fk.OnUpdate))
 	}
 
+	if fk.InitiallyDeferred {
+		parts = append(parts, "DEFERRABLE INITIALLY DEFERRED")
+	} else if fk.Deferrable {
+		parts = append(parts, "DEFERRABLE")
+	}
+
 	return strings.Join(parts, " ")
 }
 
@@ -260,7 +436,7 @@ func (p *Planner) generateCreateIndex(tableName string, idx schema.IndexMetadata
 	parts = append(parts, idx.Name)
 
 	// ON table
-	parts = append(parts, "ON", schema.QuoteReservedIdent(tableName))
+	parts = append(parts, "ON", schema.QuoteQualifiedIdent(tableName))
 
 	// [USING method]
 	if idx.Type != "" && idx.Type != "btree" {
@@ -346,31 +522,69 @@ func (p *Planner) generateDropTable(tableName string) string {
 	return fmt.Sprintf("DROP TABLE IF EXISTS %s;", quoteIdent(tableName))
 }
 
+// generateDropColumns generates the DROP COLUMN statements of a table diff
+// and the statements restoring the columns.
+func (p *Planner) generateDropColumns(diff TableDiff) (upSQL, downSQL []string) {
+	tableName := schema.QuoteQualifiedIdent(diff.TableName)
+	for _, col := range diff.ColumnsDropped {
+		upSQL = append(upSQL, fmt.Sprintf("ALTER TABLE %s DROP COLUMN IF EXISTS %s;",
+			tableName, schema.QuoteReservedIdent(col.Name)))
+		downSQL = append(downSQL, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s;",
+			tableName, p.generateColumnDefinition(col)))
+		if col.Comment != "" {
+			downSQL = append(downSQL, p.generateColumnComment(diff.TableName, col.Name, col.Comment))
+		}
+	}
+	return upSQL, downSQL
+}
+
 // generateAlterTable generates ALTER TABLE statements for table modifications.
 func (p *Planner) generateAlterTable(diff TableDiff) (upSQL, downSQL []string) {
-	tableName := schema.QuoteReservedIdent(diff.TableName)
+	tableName := schema.QuoteQualifiedIdent(diff.TableName)
+
+	// Indexes live in their table's schema, so DROP INDEX must be qualified too.
+	indexName := func(name string) string {
+		schemaName, _ := schema.SplitQualifiedName(diff.TableName)
+		return schema.QualifyTableName(schemaName, name)
+	}
+
+	// Rename columns first so later statements use the new names; the down
+	// migration renames them back last
+	for _, r := range diff.ColumnsRenamed {
+		upSQL = append(upSQL, p.generateRenameColumn(diff.TableName, r.Old, r.New, r.Heuristic))
+	}
 
 	// Add columns
 	for _, col := range diff.ColumnsAdded {
 		upSQL = append(upSQL, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s;",
 			tableName, p.generateColumnDefinition(col)))
+		if col.Comment != "" {
+			upSQL = append(upSQL, p.generateColumnComment(diff.TableName, col.Name, col.Comment))
+		}
 		downSQL = append(downSQL, fmt.Sprintf("ALTER TABLE %s DROP COLUMN IF EXISTS %s;",
 			tableName, schema.QuoteReservedIdent(col.Name)))
 	}
 
 	// Drop columns
-	for _, col := range diff.ColumnsDropped {
-		upSQL = append(upSQL, fmt.Sprintf("ALTER TABLE %s DROP COLUMN IF EXISTS %s;",
-			tableName, schema.QuoteReservedIdent(col.Name)))
-		downSQL = append(downSQL, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s;",
-			tableName, p.generateColumnDefinition(col)))
-	}
+	dropUp, dropDown := p.generateDropColumns(diff)
+	upSQL = append(upSQL, dropUp...)
+	downSQL = append(downSQL, dropDown...)
 
 	// Modify columns
 	for _, colDiff := range diff.ColumnsModified {
 		alterUp, alterDown := p.generateColumnModification(tableName, colDiff)
 		upSQL = append(upSQL, alterUp...)
 		downSQL = append(downSQL, alterDown...)
+		if colDiff.CommentChanged {
+			upSQL = append(upSQL, p.generateColumnComment(diff.TableName, colDiff.ColumnName, colDiff.NewColumn.Comment))
+			downSQL = append(downSQL, p.generateColumnComment(diff.TableName, colDiff.ColumnName, colDiff.OldColumn.Comment))
+		}
+	}
+
+	// Table comment change
+	if diff.CommentChanged != nil {
+		upSQL = append(upSQL, p.generateTableComment(diff.TableName, diff.CommentChanged.New))
+		downSQL = append(downSQL, p.generateTableComment(diff.TableName, diff.CommentChanged.Old))
 	}
 
 	// Primary key changes
@@ -383,45 +597,61 @@ func (p *Planner) generateAlterTable(diff TableDiff) (upSQL, downSQL []string) {
 	// Add indexes
 	for _, idx := range diff.IndexesAdded {
 		upSQL = append(upSQL, p.generateCreateIndex(tableName, idx))
-		downSQL = append(downSQL, fmt.Sprintf("DROP INDEX IF EXISTS %s;", idx.Name))
+		downSQL = append(downSQL, fmt.Sprintf("DROP INDEX IF EXISTS %s;", indexName(idx.Name)))
 	}
 
 	// Drop indexes
 	for _, idx := range diff.IndexesDropped {
-		upSQL = append(upSQL, fmt.Sprintf("DROP INDEX IF EXISTS %s;", idx.Name))
+		upSQL = append(upSQL, fmt.Sprintf("DROP INDEX IF EXISTS %s;", indexName(idx.Name)))
 		downSQL = append(downSQL, p.generateCreateIndex(tableName, idx))
 	}
 
-	// Add foreign keys
+	// Re-create changed indexes; the old definition is dropped first since
+	// both share a name
+	for _, idx := range diff.IndexesModified {
+		upSQL = append(upSQL,
+			fmt.Sprintf("DROP INDEX IF EXISTS %s;", indexName(idx.Old.Name)),
+			p.generateCreateIndex(tableName, idx.New))
+		downSQL = append(downSQL,
+			fmt.Sprintf("DROP INDEX IF EXISTS %s;", indexName(idx.New.Name)),
+			p.generateCreateIndex(tableName, idx.Old))
+	}
+
+	// Foreign keys and constraints are dropped before they are added (in both
+	// directions) so a changed one can be re-created under the same name.
+	for _, fk := range diff.ForeignKeysDropped {
+		upSQL = append(upSQL, fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT IF EXISTS %s;",
+			tableName, fk.Name))
+	}
 	for _, fk := range diff.ForeignKeysAdded {
 		upSQL = append(upSQL, fmt.Sprintf("ALTER TABLE %s ADD %s;",
 			tableName, p.generateForeignKeyDefinition(fk)))
 		downSQL = append(downSQL, fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT IF EXISTS %s;",
 			tableName, fk.Name))
 	}
-
-	// Drop foreign keys
 	for _, fk := range diff.ForeignKeysDropped {
-		upSQL = append(upSQL, fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT IF EXISTS %s;",
-			tableName, fk.Name))
 		downSQL = append(downSQL, fmt.Sprintf("ALTER TABLE %s ADD %s;",
 			tableName, p.generateForeignKeyDefinition(fk)))
 	}
 
-	// Add constraints
+	for _, c := range diff.ConstraintsDropped {
+		upSQL = append(upSQL, fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT IF EXISTS %s;",
+			tableName, c.Name))
+	}
 	for _, c := range diff.ConstraintsAdded {
 		upSQL = append(upSQL, p.generateAddConstraintSQL(tableName, c))
 		downSQL = append(downSQL, fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT IF EXISTS %s;",
 			tableName, c.Name))
 	}
-
-	// Drop constraints
 	for _, c := range diff.ConstraintsDropped {
-		upSQL = append(upSQL, fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT IF EXISTS %s;",
-			tableName, c.Name))
 		downSQL = append(downSQL, p.generateAddConstraintSQL(tableName, c))
 	}
 
+	for i := len(diff.ColumnsRenamed) - 1; i >= 0; i-- {
+		r := diff.ColumnsRenamed[i]
+		downSQL = append(downSQL, p.generateRenameColumn(diff.TableName, r.New, r.Old, false))
+	}
+
 	return upSQL, downSQL
 }
 
@@ -429,43 +659,28 @@ func (p *Planner) generateAlterTable(diff TableDiff) (upSQL, downSQL []string) {
 func (p *Planner) generateColumnModification(tableName string, colDiff ColumnDiff) (upSQL, downSQL []string) {
 	colName := schema.QuoteReservedIdent(colDiff.ColumnName)
 
+	// Generation change; a column that is generated afterwards is re-created,
+	// which covers any other change to it
+	if colDiff.GeneratedChanged {
+		if colDiff.NewColumn.Generated != nil {
+			return p.generateRecreateColumn(tableName, colDiff)
+		}
+		upSQL = append(upSQL, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP EXPRESSION;",
+			tableName, colName))
+	}
+
+	// An identity is dropped before the nullability and default change, and
+	// added after them: an identity column is NOT NULL and has no default
+	oldIdentity, newIdentity := colDiff.OldColumn.Identity, colDiff.NewColumn.Identity
+	if colDiff.IdentityChanged && newIdentity == nil {
+		upSQL = append(upSQL, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP IDENTITY IF EXISTS;",
+			tableName, colName))
+	}
+
 	// Type change
 	if colDiff.TypeChanged {
-		// Check if this type conversion requires explicit USING clause
-		if requiresUsingClause(colDiff.OldColumn.SQLType, colDiff.NewColumn.SQLType) {
-			// Try to generate automatic USING clause
-			usingClause := generateUsingClause(colName, colDiff.OldColumn.SQLType, colDiff.NewColumn.SQLType)
-
-			if usingClause != "" {
-				// We have a safe conversion
-				upSQL = append(upSQL, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s %s;",
-					tableName, colName, colDiff.NewColumn.SQLType, usingClause))
-			} else {
-				// No safe automatic conversion - require manual intervention
-				upSQL = append(upSQL, fmt.Sprintf("-- MANUAL MIGRATION REQUIRED: Cannot auto-convert %s from %s to %s",
-					colName, colDiff.OldColumn.SQLType, colDiff.NewColumn.SQLType))
-				upSQL = append(upSQL, "-- Please review and uncomment/modify the following statement:")
-				upSQL = append(upSQL, fmt.Sprintf("-- ALTER TABLE %s ALTER COLUMN %s TYPE %s USING <expression>;",
-					tableName, colName, colDiff.NewColumn.SQLType))
-			}
-
-			// Down migration (reverse)
-			usingClauseDown := generateUsingClause(colName, colDiff.NewColumn.SQLType, colDiff.OldColumn.SQLType)
-			if usingClauseDown != "" {
-				downSQL = append(downSQL, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s %s;",
-					tableName, colName, colDiff.OldColumn.SQLType, usingClauseDown))
-			} else {
-				downSQL = append(downSQL, fmt.Sprintf("-- MANUAL MIGRATION REQUIRED: Reverse type conversion for %s", colName))
-				downSQL = append(downSQL, fmt.Sprintf("-- ALTER TABLE %s ALTER COLUMN %s TYPE %s USING <expression>;",
-					tableName, colName, colDiff.OldColumn.SQLType))
-			}
-		} else {
-			// Simple type conversion (implicit cast available)
-			upSQL = append(upSQL, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s;",
-				tableName, colName, colDiff.NewColumn.SQLType))
-			downSQL = append(downSQL, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s;",
-				tableName, colName, colDiff.OldColumn.SQLType))
-		}
+		up, down := p.generateTypeChange(tableName, colDiff)
+		upSQL, downSQL = append(upSQL, up...), append(downSQL, down...)
 	}
 
 	// Nullability change
@@ -502,12 +717,64 @@ func (p *Planner) generateColumnModification(tableName string, colDiff ColumnDif
 		}
 	}
 
+	// Identity added or its generation changed
+	if colDiff.IdentityChanged && newIdentity != nil {
+		if oldIdentity == nil {
+			// The new sequence starts past the values already in the column
+			upSQL = append(upSQL,
+				fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s ADD %s;", tableName, colName, identityClause(colDiff.NewColumn)),
+				fmt.Sprintf("SELECT setval(pg_get_serial_sequence('%s', '%s'), COALESCE(MAX(%s), 0) + 1, false) FROM %s;",
+					strings.ReplaceAll(tableName, "'", "''"), strings.ReplaceAll(colDiff.ColumnName, "'", "''"), colName, tableName))
+			downSQL = append([]string{fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP IDENTITY IF EXISTS;",
+				tableName, colName)}, downSQL...)
+		} else {
+			upSQL = append(upSQL, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET GENERATED %s;",
+				tableName, colName, newIdentity.Generation))
+			downSQL = append(downSQL, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET GENERATED %s;",
+				tableName, colName, oldIdentity.Generation))
+		}
+	}
+
+	// Identity sequence option change
+	if colDiff.SequenceChanged {
+		up, down := p.generateIdentitySequenceChange(tableName, colName, colDiff)
+		upSQL = append(upSQL, up...)
+		downSQL = append(downSQL, down...)
+	}
+
+	// A dropped identity is restored once the column is NOT NULL again
+	if colDiff.IdentityChanged && newIdentity == nil {
+		downSQL = append(downSQL, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s ADD %s;",
+			tableName, colName, identityClause(colDiff.OldColumn)))
+	}
+
+	// Restoring a dropped generation re-creates the column, which undoes the
+	// other changes as well
+	if colDiff.GeneratedChanged {
+		_, downSQL = p.generateRecreateColumn(tableName, colDiff)
+	}
+
+	return upSQL, downSQL
+}
+
+// generateRecreateColumn drops and re-adds a column whose generation
+// changed. PostgreSQL 14 can't alter a generation expression in place, and a
+// generated column's values are derived, so re-adding it loses nothing;
+// indexes on the column must be re-created, though. Re-creating a column that
+// wasn't generated before discards its values.
+func (p *Planner) generateRecreateColumn(tableName string, colDiff ColumnDiff) (upSQL, downSQL []string) {
+	colName := schema.QuoteReservedIdent(colDiff.ColumnName)
+	drop := fmt.Sprintf("ALTER TABLE %s DROP COLUMN IF EXISTS %s;", tableName, colName)
+	upSQL = []string{drop, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s;",
+		tableName, p.generateColumnDefinition(colDiff.NewColumn))}
+	downSQL = []string{drop, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s;",
+		tableName, p.generateColumnDefinition(colDiff.OldColumn))}
 	return upSQL, downSQL
 }
 
 // generatePrimaryKeyChange generates ALTER statements for primary key changes.
 func (p *Planner) generatePrimaryKeyChange(tableName string, pkChange *PrimaryKeyChange) (upSQL, downSQL []string) {
-	tableName = schema.QuoteReservedIdent(tableName)
+	tableName = schema.QuoteQualifiedIdent(tableName)
 	// Drop old primary key
 	if pkChange.Old != nil {
 		upSQL = append(upSQL, fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT IF EXISTS %s;",
@@ -640,6 +907,9 @@ func (p *Planner) generateAddConstraintSQL(tableName string, c schema.Constraint
 	case schema.CheckConstraint:
 		return fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s CHECK %s;",
 			tableName, c.Name, c.Expression)
+	case schema.ExclusionConstraint:
+		return fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s EXCLUDE %s;",
+			tableName, c.Name, c.Expression)
 	default:
 		return fmt.Sprintf("-- Unknown constraint type: %s", c.Type)
 	}
@@ -679,6 +949,18 @@ func (p *Planner) generateAlterEnumType(enumDiff EnumTypeDiff) []string {
 	return statements
 }
 
+// newTableSchemas returns the sorted, distinct non-public schemas of tables.
+func newTableSchemas(tables []schema.TableMetadata) []string {
+	var schemas []string
+	for _, t := range tables {
+		if t.Schema != "" && t.Schema != schema.DefaultSchema && !slices.Contains(schemas, t.Schema) {
+			schemas = append(schemas, t.Schema)
+		}
+	}
+	slices.Sort(schemas)
+	return schemas
+}
+
 // topoSortTables returns tables in dependency order so that referenced tables are
 // created before the tables that reference them via foreign keys.
 // Only intra-set dependencies are considered — references to tables already in the
@@ -691,13 +973,13 @@ func topoSortTables(tables []schema.TableMetadata) []schema.TableMetadata {
 	// Build a set of table names in this batch for quick lookup.
 	inSet := make(map[string]bool, len(tables))
 	for _, t := range tables {
-		inSet[t.Name] = true
+		inSet[t.QualifiedName()] = true
 	}
 
 	// index maps table name → position in tables slice.
 	index := make(map[string]int, len(tables))
 	for i, t := range tables {
-		index[t.Name] = i
+		index[t.QualifiedName()] = i
 	}
 
 	// Kahn's algorithm: compute in-degrees and adjacency list.
@@ -712,7 +994,7 @@ func topoSortTables(tables []schema.TableMetadata) []schema.TableMetadata {
 			if !inSet[ref] {
 				continue // dependency is on an already-existing table; ignore
 			}
-			if ref == t.Name {
+			if ref == t.QualifiedName() {
 				continue // self-reference; ignore
 			}
 			refIdx := index[ref]
@@ -5,22 +5,65 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"sort"
 	"strings"
 
 	"github.com/marshallshelly/pebble-orm/pkg/schema"
 )
 
+// fkActionsPattern matches the optional clauses after REFERENCES: ON DELETE,
+// ON UPDATE and the constraint timing.
+const fkActionsPattern = `(?:\s+ON\s+DELETE\s+(SET\s+NULL|SET\s+DEFAULT|NO\s+ACTION|\w+))?(?:\s+ON\s+UPDATE\s+(SET\s+NULL|SET\s+DEFAULT|NO\s+ACTION|\w+))?(?:\s+(NOT\s+)?(DEFERRABLE))?(?:\s+INITIALLY\s+(DEFERRED|IMMEDIATE))?`
+
 var (
-	reCreateTableName = regexp.MustCompile(`(?i)^\s*CREATE\s+TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?"?(\w+)"?`)
-	reDropTableName   = regexp.MustCompile(`(?i)^\s*DROP\s+TABLE\s+(?:IF\s+EXISTS\s+)?"?(\w+)"?`)
-	reAlterTableParts = regexp.MustCompile(`(?i)^\s*ALTER\s+TABLE\s+"?(\w+)"?\s+(.+)`)
-	reAlterColType    = regexp.MustCompile(`(?i)^ALTER\s+COLUMN\s+"?(\w+)"?\s+TYPE\s+(.+)$`)
-	reAddConstraint   = regexp.MustCompile(`(?i)^ADD\s+CONSTRAINT\s+\w+\s+UNIQUE\s*\("?(\w+)"?\)$`)
-	reFKConstraint    = regexp.MustCompile(`(?i)CONSTRAINT\s+(\w+)\s+FOREIGN\s+KEY\s*\(([^)]+)\)\s+REFERENCES\s+"?(\w+)"?\s*\(([^)]+)\)(?:\s+ON\s+DELETE\s+([\w\s]+?))?$`)
-	reAddFKConstraint = regexp.MustCompile(`(?i)^ADD\s+CONSTRAINT\s+(\w+)\s+FOREIGN\s+KEY\s*\(([^)]+)\)\s+REFERENCES\s+"?(\w+)"?\s*\(([^)]+)\)(?:\s+ON\s+DELETE\s+([\w\s]+?))?$`)
+	reCreateTableName   = regexp.MustCompile(`(?i)^\s*CREATE\s+TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?((?:"?\w+"?\.)?"?\w+"?)`)
+	reDropTableName     = regexp.MustCompile(`(?i)^\s*DROP\s+TABLE\s+(?:IF\s+EXISTS\s+)?((?:"?\w+"?\.)?"?\w+"?)`)
+	reAlterTableParts   = regexp.MustCompile(`(?i)^\s*ALTER\s+TABLE\s+((?:"?\w+"?\.)?"?\w+"?)\s+(.+)`)
+	reAlterColType      = regexp.MustCompile(`(?i)^ALTER\s+COLUMN\s+"?(\w+)"?\s+TYPE\s+(.+)$`)
+	reAlterColNull      = regexp.MustCompile(`(?i)^ALTER\s+COLUMN\s+"?(\w+)"?\s+(SET|DROP)\s+NOT\s+NULL\s*;?$`)
+	reAlterColIdentity  = regexp.MustCompile(`(?i)^ALTER\s+COLUMN\s+"?(\w+)"?\s+(ADD|SET)\s+GENERATED\s+(ALWAYS|BY\s+DEFAULT)\b(.*)$`)
+	reDropColIdentity   = regexp.MustCompile(`(?i)^ALTER\s+COLUMN\s+"?(\w+)"?\s+DROP\s+IDENTITY\b`)
+	reColumnCollate     = regexp.MustCompile(`(?i)\bCOLLATE\s+"?([^"\s]+)"?`)
+	reTypeClauseEnd     = regexp.MustCompile(`(?i)\s+(COLLATE|USING)\s`)
+	reExcludeConstraint = regexp.MustCompile(`(?is)^CONSTRAINT\s+"?(\w+)"?\s+EXCLUDE\s+(.+?)\s*;?$`)
+	reDropConstraint    = regexp.MustCompile(`(?i)^DROP\s+CONSTRAINT\s+(?:IF\s+EXISTS\s+)?"?(\w+)"?`)
+	reAddConstraint     = regexp.MustCompile(`(?i)^ADD\s+CONSTRAINT\s+\w+\s+UNIQUE\s*\("?(\w+)"?\)$`)
+	reFKConstraint      = regexp.MustCompile(`(?i)CONSTRAINT\s+(\w+)\s+FOREIGN\s+KEY\s*\(([^)]+)\)\s+REFERENCES\s+((?:"?\w+"?\.)?"?\w+"?)\s*\(([^)]+)\)` + fkActionsPattern + `$`)
+	reCreateView        = regexp.MustCompile(`(?is)^\s*CREATE\s+(?:OR\s+REPLACE\s+)?(MATERIALIZED\s+)?VIEW\s+(?:IF\s+NOT\s+EXISTS\s+)?((?:"?\w+"?\.)?"?\w+"?)\s+AS\s+(.+)$`)
+	reDropView          = regexp.MustCompile(`(?i)^\s*DROP\s+(?:MATERIALIZED\s+)?VIEW\s+(?:IF\s+EXISTS\s+)?((?:"?\w+"?\.)?"?\w+"?)`)
+	reAddFKConstraint   = regexp.MustCompile(`(?i)^ADD\s+CONSTRAINT\s+(\w+)\s+FOREIGN\s+KEY\s*\(([^)]+)\)\s+REFERENCES\s+((?:"?\w+"?\.)?"?\w+"?)\s*\(([^)]+)\)` + fkActionsPattern + `$`)
 )
 
+// applyIdentityChange applies ALTER COLUMN ... ADD GENERATED ... AS IDENTITY
+// or SET GENERATED, matched by reAlterColIdentity.
+func applyIdentityChange(table *schema.TableMetadata, m []string) {
+	col := table.GetColumnByName(strings.ToLower(m[1]))
+	if col == nil {
+		return
+	}
+	generation := schema.IdentityAlways
+	if !strings.EqualFold(m[3], "ALWAYS") {
+		generation = schema.IdentityByDefault
+	}
+	if strings.EqualFold(m[2], "SET") {
+		if col.Identity != nil {
+			col.Identity.Generation = generation
+		}
+		return
+	}
+	col.Identity = &schema.IdentityColumn{Generation: generation}
+	if rest := strings.TrimSpace(m[4]); strings.HasPrefix(strings.ToUpper(rest), "AS IDENTITY") {
+		if rest = strings.TrimSpace(rest[len("AS IDENTITY"):]); strings.HasPrefix(rest, "(") {
+			if end := findMatchingCloseParen(rest, 0); end > 0 {
+				opts, _ := parseSequenceClauses(rest[1:end])
+				col.Identity.Sequence = &opts
+			}
+		}
+	}
+	col.Nullable, col.Default, col.AutoIncrement = false, nil, false
+}
+
 // HasMigrationFiles reports whether any *.up.sql files exist in dir.
 func HasMigrationFiles(dir string) (bool, error) {
 	matches, err := filepath.Glob(filepath.Join(dir, "*.up.sql"))
@@ -31,24 +74,36 @@ func HasMigrationFiles(dir string) (bool, error) {
 // version order to reconstruct the current schema state. It is used as the
 // offline baseline when no database connection is provided.
 func ReconstructSchemaFromMigrations(dir string) (map[string]*schema.TableMetadata, error) {
+	contents, err := readUpMigrations(dir)
+	if err != nil || len(contents) == 0 {
+		return nil, err
+	}
+
+	tables := make(map[string]*schema.TableMetadata)
+	for _, sql := range contents {
+		applySQLToSchema(tables, sql)
+	}
+	return tables, nil
+}
+
+// readUpMigrations returns the contents of all *.up.sql files in dir in
+// version order.
+func readUpMigrations(dir string) ([]string, error) {
 	files, err := filepath.Glob(filepath.Join(dir, "*.up.sql"))
 	if err != nil {
 		return nil, err
 	}
-	if len(files) == 0 {
-		return nil, nil
-	}
 	sort.Strings(files) // timestamps make lexicographic order == chronological order
 
-	tables := make(map[string]*schema.TableMetadata)
+	contents := make([]string, 0, len(files))
 	for _, f := range files {
 		data, err := os.ReadFile(f)
 		if err != nil {
 			return nil, fmt.Errorf("reading %s: %w", filepath.Base(f), err)
 		}
-		applySQLToSchema(tables, string(data))
+		contents = append(contents, string(data))
 	}
-	return tables, nil
+	return contents, nil
 }
 
 // applySQLToSchema applies DDL statements from sql to the in-memory schema map.
@@ -62,10 +117,22 @@ func applySQLToSchema(tables map[string]*schema.TableMetadata, sql string) {
 		switch {
 		case reCreateTableName.MatchString(stmt):
 			applyCreateTable(tables, stmt)
+		case reCreateView.MatchString(stmt):
+			applyCreateView(tables, stmt)
+		case reDropView.MatchString(stmt):
+			if m := reDropView.FindStringSubmatch(stmt); m != nil {
+				delete(tables, reconstructTableName(m[1]))
+			}
 		case strings.HasPrefix(upper, "DROP TABLE"):
 			applyDropTable(tables, stmt)
 		case strings.HasPrefix(upper, "ALTER TABLE"):
 			applyAlterTable(tables, stmt)
+		case reCommentOn.MatchString(stmt):
+			applyCommentOn(tables, stmt)
+		case reCreateCDCTrigger.MatchString(stmt), reDropCDCTrigger.MatchString(stmt):
+			applyCDCTrigger(tables, stmt)
+		case reCreateHistoryTrigger.MatchString(stmt), reDropHistoryTrigger.MatchString(stmt):
+			applyHistoryTrigger(tables, stmt)
 		}
 	}
 }
@@ -75,7 +142,11 @@ func applyCreateTable(tables map[string]*schema.TableMetadata, stmt string) {
 	if m == nil {
 		return
 	}
-	tableName := strings.ToLower(m[1])
+	tableName := reconstructTableName(m[1])
+	if isCDCTable(tableName) {
+		return
+	}
+	schemaName, bareName := schema.SplitQualifiedName(tableName)
 
 	open := strings.Index(stmt, "(")
 	if open < 0 {
@@ -86,18 +157,25 @@ func applyCreateTable(tables map[string]*schema.TableMetadata, stmt string) {
 		return
 	}
 
-	cols, pkCols, fks := parseColumnList(stmt[open+1 : close])
+	body := stmt[open+1 : close]
+	cols, pkCols, fks := parseColumnList(body)
 
 	table := &schema.TableMetadata{
-		Name:        tableName,
+		Name:        bareName,
+		Schema:      schemaName,
 		Columns:     cols,
 		ForeignKeys: fks,
 		Constraints: make([]schema.ConstraintMetadata, 0),
 	}
+	for _, part := range splitTopLevelCommas(body) {
+		if m := reExcludeConstraint.FindStringSubmatch(strings.TrimSpace(part)); m != nil {
+			table.Constraints = append(table.Constraints, exclusionConstraint(m))
+		}
+	}
 	if len(pkCols) > 0 {
 		table.PrimaryKey = &schema.PrimaryKeyMetadata{
 			Columns: pkCols,
-			Name:    tableName + "_pkey",
+			Name:    bareName + "_pkey",
 		}
 	}
 	// Unique constraints from column-level UNIQUE attribute.
@@ -106,7 +184,7 @@ func applyCreateTable(tables map[string]*schema.TableMetadata, stmt string) {
 			table.Constraints = append(table.Constraints, schema.ConstraintMetadata{
 				Type:    schema.UniqueConstraint,
 				Columns: []string{col.Name},
-				Name:    tableName + "_" + col.Name + "_key",
+				Name:    bareName + "_" + col.Name + "_key",
 			})
 		}
 	}
@@ -114,10 +192,34 @@ func applyCreateTable(tables map[string]*schema.TableMetadata, stmt string) {
 	tables[tableName] = table
 }
 
+// reconstructTableName normalizes a possibly quoted, schema-qualified table
+// name from DDL to the registry's key form: public tables are unqualified.
+func reconstructTableName(raw string) string {
+	name := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(raw), `"`, ""))
+	return schema.QualifyTableName(schema.SplitQualifiedName(name))
+}
+
+func applyCreateView(tables map[string]*schema.TableMetadata, stmt string) {
+	m := reCreateView.FindStringSubmatch(stmt)
+	if m == nil {
+		return
+	}
+	name := reconstructTableName(m[2])
+	schemaName, bareName := schema.SplitQualifiedName(name)
+	tables[name] = &schema.TableMetadata{
+		Name:   bareName,
+		Schema: schemaName,
+		View: &schema.ViewMetadata{
+			Query:        strings.TrimSuffix(strings.TrimSpace(m[3]), ";"),
+			Materialized: m[1] != "",
+		},
+	}
+}
+
 func applyDropTable(tables map[string]*schema.TableMetadata, stmt string) {
 	m := reDropTableName.FindStringSubmatch(stmt)
 	if m != nil {
-		delete(tables, strings.ToLower(m[1]))
+		delete(tables, reconstructTableName(m[1]))
 	}
 }
 
@@ -126,7 +228,7 @@ func applyAlterTable(tables map[string]*schema.TableMetadata, stmt string) {
 	if m == nil {
 		return
 	}
-	tableName := strings.ToLower(m[1])
+	tableName := reconstructTableName(m[1])
 	rest := strings.TrimSpace(m[2])
 	upper := strings.ToUpper(rest)
 
@@ -136,6 +238,13 @@ func applyAlterTable(tables map[string]*schema.TableMetadata, stmt string) {
 	}
 
 	switch {
+	case reRenameTable.MatchString(rest):
+		applyRenameTable(tables, tableName, reRenameTable.FindStringSubmatch(rest)[1])
+
+	case reRenameColumn.MatchString(rest):
+		rm := reRenameColumn.FindStringSubmatch(rest)
+		applyRenameColumn(table, rm[1], rm[2])
+
 	case strings.HasPrefix(upper, "ADD COLUMN"):
 		colDef := strings.TrimSpace(rest[len("ADD COLUMN"):])
 		col := parseColDef(colDef, len(table.Columns))
@@ -158,31 +267,65 @@ func applyAlterTable(tables map[string]*schema.TableMetadata, stmt string) {
 		}
 
 	case strings.HasPrefix(upper, "ALTER COLUMN"):
+		if im := reAlterColIdentity.FindStringSubmatch(rest); im != nil {
+			applyIdentityChange(table, im)
+			return
+		}
+		if dm := reDropColIdentity.FindStringSubmatch(rest); dm != nil {
+			if col := table.GetColumnByName(strings.ToLower(dm[1])); col != nil {
+				col.Identity = nil
+			}
+			return
+		}
+		if sm := reAlterColSequence.FindStringSubmatch(rest); sm != nil {
+			col := table.GetColumnByName(strings.ToLower(sm[1]))
+			if col != nil && col.Identity != nil {
+				opts, _ := parseSequenceClauses(sm[2])
+				if col.Identity.Sequence == nil {
+					col.Identity.Sequence = &schema.SequenceOptions{}
+				}
+				mergeSequenceOptions(col.Identity.Sequence, opts)
+			}
+		}
+		if nm := reAlterColNull.FindStringSubmatch(rest); nm != nil {
+			if col := table.GetColumnByName(strings.ToLower(nm[1])); col != nil {
+				col.Nullable = strings.EqualFold(nm[2], "DROP")
+			}
+		}
 		am := reAlterColType.FindStringSubmatch(rest)
 		if am != nil {
 			colName := strings.ToLower(am[1])
 			newType := strings.TrimSpace(am[2])
+			collation := ""
+			if m := reColumnCollate.FindStringSubmatch(newType); m != nil {
+				collation = m[1]
+			}
+			if idx := reTypeClauseEnd.FindStringIndex(newType); idx != nil {
+				newType = strings.TrimSpace(newType[:idx[0]])
+			}
 			for i, col := range table.Columns {
 				if col.Name == colName {
 					table.Columns[i].SQLType = newType
+					table.Columns[i].Collation = collation
 					break
 				}
 			}
 		}
 
+	case strings.HasPrefix(upper, "DROP CONSTRAINT"):
+		if dm := reDropConstraint.FindStringSubmatch(rest); dm != nil {
+			name := strings.ToLower(dm[1])
+			table.Constraints = slices.DeleteFunc(table.Constraints, func(c schema.ConstraintMetadata) bool { return strings.ToLower(c.Name) == name })
+			table.ForeignKeys = slices.DeleteFunc(table.ForeignKeys, func(fk schema.ForeignKeyMetadata) bool { return strings.ToLower(fk.Name) == name })
+		}
+
 	case strings.HasPrefix(upper, "ADD CONSTRAINT"):
-		if strings.Contains(upper, "FOREIGN KEY") {
+		if em := reExcludeConstraint.FindStringSubmatch(strings.TrimSpace(rest[len("ADD"):])); em != nil {
+			table.Constraints = append(table.Constraints, exclusionConstraint(em))
+		} else if strings.Contains(upper, "FOREIGN KEY") {
 			// ADD CONSTRAINT name FOREIGN KEY (cols) REFERENCES table (cols) [ON DELETE action]
-			fkm := reAddFKConstraint.FindStringSubmatch(rest)
-			if fkm != nil {
-				fk := schema.ForeignKeyMetadata{
-					Name:              fkm[1],
-					Columns:           splitCSV(fkm[2]),
-					ReferencedTable:   strings.ToLower(strings.TrimSpace(fkm[3])),
-					ReferencedColumns: splitCSV(fkm[4]),
-					OnDelete:          reconstructParseReferenceAction(strings.TrimSpace(fkm[5])),
-				}
-				table.ForeignKeys = append(table.ForeignKeys, fk)
+			if fkm := reAddFKConstraint.FindStringSubmatch(rest); fkm != nil {
+				table.ForeignKeys = append(table.ForeignKeys, fkFromMatch(fkm))
 			}
 		} else {
 			cm := reAddConstraint.FindStringSubmatch(rest)
@@ -206,6 +349,11 @@ func applyAlterTable(tables map[string]*schema.TableMetadata, stmt string) {
 	}
 }
 
+// exclusionConstraint builds an exclusion constraint from a reExcludeConstraint match.
+func exclusionConstraint(m []string) schema.ConstraintMetadata {
+	return schema.ConstraintMetadata{Name: m[1], Type: schema.ExclusionConstraint, Expression: m[2]}
+}
+
 // removeColumn returns cols without the named column.
 func removeColumn(cols []schema.ColumnMetadata, name string) []schema.ColumnMetadata {
 	out := make([]schema.ColumnMetadata, 0, len(cols))
@@ -293,12 +441,22 @@ func parseReconstructFKConstraint(s string) *schema.ForeignKeyMetadata {
 	if m == nil {
 		return nil
 	}
-	return &schema.ForeignKeyMetadata{
+	fk := fkFromMatch(m)
+	return &fk
+}
+
+// fkFromMatch builds a foreign key from a reFKConstraint or reAddFKConstraint match.
+func fkFromMatch(m []string) schema.ForeignKeyMetadata {
+	initiallyDeferred := strings.EqualFold(m[9], "DEFERRED")
+	return schema.ForeignKeyMetadata{
 		Name:              m[1],
 		Columns:           splitCSV(m[2]),
-		ReferencedTable:   strings.ToLower(strings.TrimSpace(m[3])),
+		ReferencedTable:   reconstructTableName(m[3]),
 		ReferencedColumns: splitCSV(m[4]),
-		OnDelete:          reconstructParseReferenceAction(strings.TrimSpace(m[5])),
+		OnDelete:          reconstructParseReferenceAction(strings.Join(strings.Fields(m[5]), " ")),
+		OnUpdate:          reconstructParseReferenceAction(strings.Join(strings.Fields(m[6]), " ")),
+		Deferrable:        initiallyDeferred || (m[8] != "" && m[7] == ""),
+		InitiallyDeferred: initiallyDeferred,
 	}
 }
 
@@ -397,6 +555,10 @@ func parseColDef(def string, position int) schema.ColumnMetadata {
 	remainder := strings.TrimSpace(rest[typeEnd:])
 	remainderUpper := strings.ToUpper(remainder)
 
+	if m := reColumnCollate.FindStringSubmatch(remainder); m != nil {
+		col.Collation = m[1]
+	}
+
 	col.Nullable = !strings.Contains(restUpper, "NOT NULL")
 	col.Unique = strings.Contains(restUpper, " UNIQUE") || strings.HasSuffix(restUpper, "UNIQUE")
 
@@ -406,6 +568,21 @@ func parseColDef(def string, position int) schema.ColumnMetadata {
 		col.Nullable = false
 	}
 
+	if idx := strings.Index(remainderUpper, "AS IDENTITY"); idx >= 0 {
+		col.Identity = &schema.IdentityColumn{Generation: schema.IdentityAlways}
+		if strings.Contains(remainderUpper, "BY DEFAULT") {
+			col.Identity.Generation = schema.IdentityByDefault
+		}
+		if rest := strings.TrimSpace(remainder[idx+len("AS IDENTITY"):]); strings.HasPrefix(rest, "(") {
+			if end := findMatchingCloseParen(rest, 0); end > 0 {
+				opts, _ := parseSequenceClauses(rest[1:end])
+				col.Identity.Sequence = &opts
+			}
+		}
+		col.Nullable = false
+		return col
+	}
+
 	if idx := strings.Index(remainderUpper, "DEFAULT"); idx >= 0 {
 		afterDefault := strings.TrimSpace(remainder[idx+7:])
 		defVal := extractDefaultValue(afterDefault)
@@ -0,0 +1,260 @@
+package migration
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+)
+
+// TableRename represents a table renamed in code.
+type TableRename struct {
+	Old       string // Qualified name in the database
+	New       string // Qualified name in code
+	Heuristic bool   // Inferred from identical columns rather than a renamed_from directive
+}
+
+// ColumnRename represents a column renamed in code.
+type ColumnRename struct {
+	Old       string // Name in the database
+	New       string // Name in code
+	Heuristic bool   // Inferred from a matching definition rather than a renamedFrom tag
+}
+
+var (
+	reRenameTable  = regexp.MustCompile(`(?i)^RENAME\s+TO\s+"?(\w+)"?\s*;?$`)
+	reRenameColumn = regexp.MustCompile(`(?i)^RENAME\s+(?:COLUMN\s+)?"?(\w+)"?\s+TO\s+"?(\w+)"?\s*;?$`)
+)
+
+// detectTableRenames pairs tables that only exist in code with tables that
+// only exist in the database. A renamed_from directive is taken as is; without
+// one, an added and a dropped table in the same schema with identical columns
+// are paired when neither matches any other table.
+func (d *Differ) detectTableRenames(codeSchema, dbSchema map[string]*schema.TableMetadata) []TableRename {
+	isTable := func(tables map[string]*schema.TableMetadata, name string) bool {
+		t, ok := tables[name]
+		return ok && !t.IsView()
+	}
+
+	var added, dropped []string
+	for name, table := range codeSchema {
+		if !table.IsView() && !isTable(dbSchema, name) {
+			added = append(added, name)
+		}
+	}
+	for name, table := range dbSchema {
+		if !table.IsView() && !isTable(codeSchema, name) {
+			dropped = append(dropped, name)
+		}
+	}
+	slices.Sort(added)
+	slices.Sort(dropped)
+
+	var renames []TableRename
+	claimed := make(map[string]bool)
+	for _, name := range added {
+		table := codeSchema[name]
+		if table.RenamedFrom == "" {
+			continue
+		}
+		old := reconstructTableName(table.RenamedFrom)
+		if !strings.Contains(table.RenamedFrom, ".") {
+			old = schema.QualifyTableName(table.Schema, table.RenamedFrom)
+		}
+		if slices.Contains(dropped, old) && !claimed[old] && dbSchema[old].Schema == table.Schema {
+			renames = append(renames, TableRename{Old: old, New: name})
+			claimed[old], claimed[name] = true, true
+		}
+	}
+
+	matches := func(codeTable, dbTable *schema.TableMetadata) bool {
+		return codeTable.Schema == dbTable.Schema && d.sameColumns(codeTable.Columns, dbTable.Columns)
+	}
+	for _, name := range added {
+		if claimed[name] {
+			continue
+		}
+		var candidates []string
+		for _, old := range dropped {
+			if !claimed[old] && matches(codeSchema[name], dbSchema[old]) {
+				candidates = append(candidates, old)
+			}
+		}
+		if len(candidates) != 1 {
+			continue
+		}
+		unique := true
+		for _, other := range added {
+			if other != name && !claimed[other] && matches(codeSchema[other], dbSchema[candidates[0]]) {
+				unique = false
+				break
+			}
+		}
+		if unique {
+			renames = append(renames, TableRename{Old: candidates[0], New: name, Heuristic: true})
+			claimed[candidates[0]], claimed[name] = true, true
+		}
+	}
+	return renames
+}
+
+// sameColumns reports whether two column lists have the same names, types
+// and nullability.
+func (d *Differ) sameColumns(a, b []schema.ColumnMetadata) bool {
+	if len(a) == 0 || len(a) != len(b) {
+		return false
+	}
+	byName := make(map[string]schema.ColumnMetadata, len(b))
+	for _, col := range b {
+		byName[col.Name] = col
+	}
+	for _, col := range a {
+		other, ok := byName[col.Name]
+		if !ok || !d.sameColumnDefinition(col, other) {
+			return false
+		}
+	}
+	return true
+}
+
+// sameColumnDefinition reports whether two columns have the same type and
+// nullability, ignoring their names.
+func (d *Differ) sameColumnDefinition(codeCol, dbCol schema.ColumnMetadata) bool {
+	colDiff := d.compareColumn(codeCol, dbCol)
+	return !colDiff.TypeChanged && !colDiff.NullChanged
+}
+
+// detectColumnRenames pairs the columns added to a table with the columns
+// dropped from it. A renamedFrom tag option is taken as is; without one, a
+// single added and a single dropped column with the same type and
+// nullability are paired.
+func (d *Differ) detectColumnRenames(added, dropped []schema.ColumnMetadata) []ColumnRename {
+	var renames []ColumnRename
+	claimed := make(map[string]bool)
+	for _, col := range added {
+		if col.RenamedFrom == "" {
+			continue
+		}
+		if slices.ContainsFunc(dropped, func(c schema.ColumnMetadata) bool { return c.Name == col.RenamedFrom }) && !claimed[col.RenamedFrom] {
+			renames = append(renames, ColumnRename{Old: col.RenamedFrom, New: col.Name})
+			claimed[col.RenamedFrom], claimed["+"+col.Name] = true, true
+		}
+	}
+
+	var remainingAdded, remainingDropped []schema.ColumnMetadata
+	for _, col := range added {
+		if !claimed["+"+col.Name] {
+			remainingAdded = append(remainingAdded, col)
+		}
+	}
+	for _, col := range dropped {
+		if !claimed[col.Name] {
+			remainingDropped = append(remainingDropped, col)
+		}
+	}
+	if len(remainingAdded) == 1 && len(remainingDropped) == 1 && d.sameColumnDefinition(remainingAdded[0], remainingDropped[0]) {
+		renames = append(renames, ColumnRename{Old: remainingDropped[0].Name, New: remainingAdded[0].Name, Heuristic: true})
+	}
+	return renames
+}
+
+// renameReferences returns dbSchema with foreign keys pointing at renamed
+// tables updated to the new names, as PostgreSQL does on RENAME. Tables are
+// copied before they are changed.
+func renameReferences(dbSchema map[string]*schema.TableMetadata, renames []TableRename) map[string]*schema.TableMetadata {
+	if len(renames) == 0 {
+		return dbSchema
+	}
+	newName := make(map[string]string, len(renames))
+	for _, r := range renames {
+		newName[r.Old] = r.New
+	}
+	result := make(map[string]*schema.TableMetadata, len(dbSchema))
+	for name, table := range dbSchema {
+		if !slices.ContainsFunc(table.ForeignKeys, func(fk schema.ForeignKeyMetadata) bool { return newName[fk.ReferencedTable] != "" }) {
+			result[name] = table
+			continue
+		}
+		copied := *table
+		copied.ForeignKeys = slices.Clone(table.ForeignKeys)
+		for i, fk := range copied.ForeignKeys {
+			if renamed := newName[fk.ReferencedTable]; renamed != "" {
+				copied.ForeignKeys[i].ReferencedTable = renamed
+			}
+		}
+		result[name] = &copied
+	}
+	return result
+}
+
+// heuristicRenameNote precedes renames inferred without a hint.
+const heuristicRenameNote = "-- Detected rename (no renamed_from hint): verify before applying"
+
+// generateRenameTable generates ALTER TABLE ... RENAME TO from one qualified
+// name to another in the same schema.
+func (p *Planner) generateRenameTable(from, to string, heuristic bool) string {
+	_, bare := schema.SplitQualifiedName(to)
+	stmt := fmt.Sprintf("ALTER TABLE %s RENAME TO %s;", schema.QuoteQualifiedIdent(from), schema.QuoteReservedIdent(bare))
+	if heuristic {
+		return heuristicRenameNote + "\n" + stmt
+	}
+	return stmt
+}
+
+// generateRenameColumn generates ALTER TABLE ... RENAME COLUMN.
+func (p *Planner) generateRenameColumn(tableName, from, to string, heuristic bool) string {
+	stmt := fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s;",
+		schema.QuoteQualifiedIdent(tableName), schema.QuoteReservedIdent(from), schema.QuoteReservedIdent(to))
+	if heuristic {
+		return heuristicRenameNote + "\n" + stmt
+	}
+	return stmt
+}
+
+// applyRenameTable applies ALTER TABLE ... RENAME TO to the reconstructed
+// schema, updating foreign keys that reference the table.
+func applyRenameTable(tables map[string]*schema.TableMetadata, tableName, newBare string) {
+	table := tables[tableName]
+	newBare = strings.ToLower(newBare)
+	newName := schema.QualifyTableName(table.Schema, newBare)
+	delete(tables, tableName)
+	table.Name = newBare
+	tables[newName] = table
+	for _, other := range tables {
+		for i := range other.ForeignKeys {
+			if other.ForeignKeys[i].ReferencedTable == tableName {
+				other.ForeignKeys[i].ReferencedTable = newName
+			}
+		}
+	}
+}
+
+// applyRenameColumn applies ALTER TABLE ... RENAME COLUMN to a reconstructed
+// table, including the key and constraint column lists that name it.
+func applyRenameColumn(table *schema.TableMetadata, from, to string) {
+	from, to = strings.ToLower(from), strings.ToLower(to)
+	rename := func(cols []string) {
+		for i, col := range cols {
+			if col == from {
+				cols[i] = to
+			}
+		}
+	}
+	if col := table.GetColumnByName(from); col != nil {
+		col.Name = to
+	}
+	if table.PrimaryKey != nil {
+		rename(table.PrimaryKey.Columns)
+	}
+	for i := range table.ForeignKeys {
+		rename(table.ForeignKeys[i].Columns)
+	}
+	for i := range table.Constraints {
+		rename(table.Constraints[i].Columns)
+	}
+	for i := range table.Indexes {
+		rename(table.Indexes[i].Columns)
+	}
+}
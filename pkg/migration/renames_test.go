@@ -0,0 +1,155 @@
+package migration
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+)
+
+func renameTestColumns(names ...string) []schema.ColumnMetadata {
+	cols := []schema.ColumnMetadata{{Name: "id", SQLType: "integer"}}
+	for _, name := range names {
+		cols = append(cols, schema.ColumnMetadata{Name: name, SQLType: "text", Nullable: true})
+	}
+	return cols
+}
+
+func TestDifferRenamesTable(t *testing.T) {
+	db := map[string]*schema.TableMetadata{
+		"people": {Name: "people", Columns: renameTestColumns("email")},
+		"posts": {Name: "posts", Columns: []schema.ColumnMetadata{{Name: "author_id", SQLType: "integer"}},
+			ForeignKeys: []schema.ForeignKeyMetadata{{Name: "fk_posts_author", Columns: []string{"author_id"}, ReferencedTable: "people", ReferencedColumns: []string{"id"}}}},
+	}
+	code := map[string]*schema.TableMetadata{
+		"users": {Name: "users", RenamedFrom: "people", Columns: renameTestColumns("email", "name")},
+		"posts": {Name: "posts", Columns: []schema.ColumnMetadata{{Name: "author_id", SQLType: "integer"}},
+			ForeignKeys: []schema.ForeignKeyMetadata{{Name: "fk_posts_author", Columns: []string{"author_id"}, ReferencedTable: "users", ReferencedColumns: []string{"id"}}}},
+	}
+
+	diff := NewDiffer().Compare(code, db)
+	if len(diff.TablesRenamed) != 1 || diff.TablesRenamed[0] != (TableRename{Old: "people", New: "users"}) {
+		t.Fatalf("expected people to be renamed to users, got %+v", diff.TablesRenamed)
+	}
+	if len(diff.TablesAdded) != 0 || len(diff.TablesDropped) != 0 {
+		t.Errorf("expected no added or dropped tables, got %d added, %d dropped", len(diff.TablesAdded), len(diff.TablesDropped))
+	}
+	if len(diff.TablesModified) != 1 || diff.TablesModified[0].TableName != "users" || len(diff.TablesModified[0].ColumnsAdded) != 1 {
+		t.Errorf("expected the new column to be added to users, got %+v", diff.TablesModified)
+	}
+
+	up, down := NewPlanner().GenerateMigration(diff)
+	rename := strings.Index(up, "ALTER TABLE people RENAME TO users;")
+	addColumn := strings.Index(up, "ALTER TABLE users ADD COLUMN name text;")
+	if rename < 0 || addColumn < rename {
+		t.Errorf("expected the rename before the new column, got:\n%s", up)
+	}
+	if !strings.Contains(down, "ALTER TABLE users RENAME TO people;") {
+		t.Errorf("expected the down migration to rename back, got:\n%s", down)
+	}
+}
+
+func TestDifferRenameHeuristics(t *testing.T) {
+	t.Run("table with identical columns", func(t *testing.T) {
+		db := map[string]*schema.TableMetadata{"people": {Name: "people", Columns: renameTestColumns("email")}}
+		code := map[string]*schema.TableMetadata{"users": {Name: "users", Columns: renameTestColumns("email")}}
+		diff := NewDiffer().Compare(code, db)
+		if len(diff.TablesRenamed) != 1 || !diff.TablesRenamed[0].Heuristic {
+			t.Fatalf("expected a heuristic rename, got %+v", diff.TablesRenamed)
+		}
+		up, _ := NewPlanner().GenerateMigration(diff)
+		if !strings.Contains(up, heuristicRenameNote) {
+			t.Errorf("expected heuristic renames to be flagged, got:\n%s", up)
+		}
+	})
+
+	t.Run("ambiguous tables are not renamed", func(t *testing.T) {
+		db := map[string]*schema.TableMetadata{"people": {Name: "people", Columns: renameTestColumns("email")}}
+		code := map[string]*schema.TableMetadata{
+			"users":   {Name: "users", Columns: renameTestColumns("email")},
+			"members": {Name: "members", Columns: renameTestColumns("email")},
+		}
+		diff := NewDiffer().Compare(code, db)
+		if len(diff.TablesRenamed) != 0 || len(diff.TablesDropped) != 1 {
+			t.Errorf("expected drop and create, got %+v", diff.TablesRenamed)
+		}
+	})
+
+	t.Run("single column with the same definition", func(t *testing.T) {
+		db := map[string]*schema.TableMetadata{"users": {Name: "users", Columns: renameTestColumns("mail")}}
+		code := map[string]*schema.TableMetadata{"users": {Name: "users", Columns: renameTestColumns("email")}}
+		diff := NewDiffer().Compare(code, db)
+		td := diff.TablesModified[0]
+		if len(td.ColumnsRenamed) != 1 || td.ColumnsRenamed[0] != (ColumnRename{Old: "mail", New: "email", Heuristic: true}) {
+			t.Fatalf("expected mail to be renamed to email, got %+v", td.ColumnsRenamed)
+		}
+		if len(td.ColumnsAdded) != 0 || len(td.ColumnsDropped) != 0 {
+			t.Errorf("expected no added or dropped columns, got %+v", td)
+		}
+	})
+
+	t.Run("different types are not renamed", func(t *testing.T) {
+		db := map[string]*schema.TableMetadata{"users": {Name: "users", Columns: renameTestColumns("mail")}}
+		code := map[string]*schema.TableMetadata{"users": {Name: "users", Columns: append(renameTestColumns(), schema.ColumnMetadata{Name: "age", SQLType: "integer"})}}
+		diff := NewDiffer().Compare(code, db)
+		if len(diff.TablesModified[0].ColumnsRenamed) != 0 {
+			t.Errorf("expected no rename, got %+v", diff.TablesModified[0].ColumnsRenamed)
+		}
+	})
+}
+
+func TestDifferRenamesColumnWithTag(t *testing.T) {
+	db := map[string]*schema.TableMetadata{"users": {Name: "users", Columns: renameTestColumns("mail", "nick")}}
+	codeCols := renameTestColumns("handle")
+	codeCols = append(codeCols, schema.ColumnMetadata{Name: "email", SQLType: "varchar(255)", Nullable: false, RenamedFrom: "mail"})
+	code := map[string]*schema.TableMetadata{"users": {Name: "users", Columns: codeCols}}
+
+	td := NewDiffer().Compare(code, db).TablesModified[0]
+	if len(td.ColumnsRenamed) != 2 {
+		t.Fatalf("expected the tagged and the heuristic rename, got %+v", td.ColumnsRenamed)
+	}
+	if td.ColumnsRenamed[0] != (ColumnRename{Old: "mail", New: "email"}) {
+		t.Errorf("unexpected tagged rename: %+v", td.ColumnsRenamed[0])
+	}
+	if len(td.ColumnsModified) != 1 || td.ColumnsModified[0].ColumnName != "email" || !td.ColumnsModified[0].TypeChanged || !td.ColumnsModified[0].NullChanged {
+		t.Errorf("expected email's type and nullability to change after the rename, got %+v", td.ColumnsModified)
+	}
+
+	up, down := NewPlanner().GenerateMigration(&SchemaDiff{TablesModified: []TableDiff{td}})
+	if strings.Index(up, "RENAME COLUMN mail TO email") > strings.Index(up, "ALTER COLUMN email TYPE") {
+		t.Errorf("expected the rename before the type change, got:\n%s", up)
+	}
+	if strings.Index(down, "RENAME COLUMN email TO mail") < strings.Index(down, "ALTER COLUMN email TYPE") {
+		t.Errorf("expected the down rename after reverting the type change, got:\n%s", down)
+	}
+}
+
+func TestReconstructRenames(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, sql string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(sql), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("20240101000000_init.up.sql", `CREATE TABLE people (id integer PRIMARY KEY, mail text);
+CREATE TABLE posts (id integer PRIMARY KEY, author_id integer, CONSTRAINT fk_posts_author FOREIGN KEY (author_id) REFERENCES people (id));`)
+	write("20240102000000_rename.up.sql", `ALTER TABLE people RENAME TO users;
+ALTER TABLE users RENAME COLUMN mail TO email;`)
+
+	tables, err := ReconstructSchemaFromMigrations(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	users, ok := tables["users"]
+	if !ok || tables["people"] != nil {
+		t.Fatalf("expected people to be renamed to users, got %v", tables)
+	}
+	if users.GetColumnByName("email") == nil || users.GetColumnByName("mail") != nil {
+		t.Errorf("expected mail to be renamed to email, got %+v", users.Columns)
+	}
+	if fk := tables["posts"].ForeignKeys; len(fk) != 1 || fk[0].ReferencedTable != "users" {
+		t.Errorf("expected the foreign key to follow the rename, got %+v", fk)
+	}
+}
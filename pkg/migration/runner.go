@@ -0,0 +1,232 @@
+package migration
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// NoTransactionDirective marks a migration file that must run outside a
+// transaction. Migrations containing CREATE/DROP INDEX CONCURRENTLY or
+// REINDEX CONCURRENTLY are detected automatically.
+const NoTransactionDirective = "-- pebble:no-transaction"
+
+var (
+	reNoTransaction = regexp.MustCompile(`(?im)^\s*--\s*pebble:no-transaction\s*$`)
+	reConcurrently  = regexp.MustCompile(`(?i)^\s*(?:CREATE\s+(?:UNIQUE\s+)?INDEX|DROP\s+INDEX|REINDEX\s+\w+)\s+CONCURRENTLY\b`)
+)
+
+// Checksum returns the hex SHA-256 of the migration's up SQL. It is recorded
+// in schema_migrations so edits to an applied migration can be detected. Go
+// migrations have no checksum.
+func (m Migration) Checksum() string {
+	if m.Up != nil {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(m.UpSQL))
+	return hex.EncodeToString(sum[:])
+}
+
+// Transactional reports whether the migration can run inside a transaction.
+// It returns false when either file carries the NoTransactionDirective or
+// contains a statement PostgreSQL refuses to run in a transaction block.
+func (m Migration) Transactional() bool {
+	return sqlTransactional(m.UpSQL) && sqlTransactional(m.DownSQL)
+}
+
+// sqlTransactional reports whether a migration script can run in a transaction.
+func sqlTransactional(sql string) bool {
+	if reNoTransaction.MatchString(sql) {
+		return false
+	}
+	for _, stmt := range splitSQLStatements(sql) {
+		if reConcurrently.MatchString(stmt) {
+			return false
+		}
+	}
+	return true
+}
+
+// Runner applies and rolls back the migrations in a directory. Each migration
+// runs in its own transaction (unless it is not Transactional) and is tracked
+// with its checksum in schema_migrations.
+//
+// Up and Down hold a PostgreSQL advisory lock for their whole run, so replicas
+// that start at the same time apply migrations one after another: the second
+// waits for the lock, then finds nothing pending.
+//
+// Example:
+//
+//	runner := migration.NewRunner(pool, "./migrations")
+//	applied, err := runner.Up(ctx)
+type Runner struct {
+	executor  *Executor
+	generator *Generator
+}
+
+// NewRunner creates a runner for the migrations in dir.
+func NewRunner(db *pgxpool.Pool, dir string) *Runner {
+	return &Runner{
+		executor:  NewExecutor(db, dir),
+		generator: NewGenerator(dir),
+	}
+}
+
+// NewRunnerFS creates a runner for the migrations in dir inside fsys, so a
+// binary can embed its migrations and apply them at startup.
+//
+// Example:
+//
+//	//go:embed migrations/*.sql
+//	var migrationsFS embed.FS
+//
+//	runner := migration.NewRunnerFS(pool, migrationsFS, "migrations")
+func NewRunnerFS(db *pgxpool.Pool, fsys fs.FS, dir string) *Runner {
+	return &Runner{
+		executor:  NewExecutor(db, dir),
+		generator: NewGeneratorFS(fsys, dir),
+	}
+}
+
+// WithLockID sets a custom advisory lock ID.
+func (r *Runner) WithLockID(lockID int64) *Runner {
+	r.executor.WithLockID(lockID)
+	return r
+}
+
+// WithLockTimeout bounds how long Up and Down wait for the migration lock;
+// they return ErrLockTimeout once it elapses. By default they wait
+// indefinitely.
+func (r *Runner) WithLockTimeout(timeout time.Duration) *Runner {
+	r.executor.WithLockTimeout(timeout)
+	return r
+}
+
+// WithLargeTableThreshold sets the estimated row count above which Plan warns
+// about index builds that block writes.
+func (r *Runner) WithLargeTableThreshold(rows int64) *Runner {
+	r.executor.WithLargeTableThreshold(rows)
+	return r
+}
+
+// Up applies all pending migrations in version order and returns the ones it
+// applied. It refuses to run if an applied migration has been modified.
+func (r *Runner) Up(ctx context.Context) ([]Migration, error) {
+	if err := r.executor.Lock(ctx); err != nil {
+		return nil, err
+	}
+	defer func() { _ = r.executor.Unlock(ctx) }()
+
+	migrations, err := r.prepare(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.executor.ReconcileSquashed(ctx, migrations); err != nil {
+		return nil, err
+	}
+
+	applied, err := r.executor.GetAppliedMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyChecksums(applied, migrations); err != nil {
+		return nil, err
+	}
+	appliedMap := make(map[string]bool)
+	for _, record := range applied {
+		appliedMap[record.Version] = true
+	}
+
+	var done []Migration
+	for _, m := range migrations {
+		if appliedMap[m.Version] {
+			continue
+		}
+		if err := r.executor.Apply(ctx, m, false); err != nil {
+			return done, fmt.Errorf("failed to apply migration %s: %w", m.Version, err)
+		}
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// Down rolls back the last steps applied migrations, newest first, and
+// returns the ones it rolled back.
+func (r *Runner) Down(ctx context.Context, steps int) ([]Migration, error) {
+	if steps <= 0 {
+		return nil, fmt.Errorf("steps must be positive, got %d", steps)
+	}
+	if err := r.executor.Lock(ctx); err != nil {
+		return nil, err
+	}
+	defer func() { _ = r.executor.Unlock(ctx) }()
+
+	migrations, err := r.prepare(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := r.executor.GetAppliedMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[string]Migration, len(migrations))
+	for _, m := range migrations {
+		byVersion[m.Version] = m
+	}
+
+	var done []Migration
+	for i := len(applied) - 1; i >= 0 && len(done) < steps; i-- {
+		m, ok := byVersion[applied[i].Version]
+		if !ok {
+			return done, fmt.Errorf("migration file not found for version %s", applied[i].Version)
+		}
+		if err := r.executor.Rollback(ctx, m, false); err != nil {
+			return done, fmt.Errorf("failed to rollback migration %s: %w", m.Version, err)
+		}
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// Status returns every migration in the directory with its tracking record.
+func (r *Runner) Status(ctx context.Context) ([]MigrationRecord, error) {
+	migrations, err := r.prepare(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return r.executor.GetStatus(ctx, migrations)
+}
+
+// prepare creates the tracking table and loads the migration files, merged
+// with the registered Go migrations.
+func (r *Runner) prepare(ctx context.Context) ([]Migration, error) {
+	if err := r.executor.Initialize(ctx); err != nil {
+		return nil, err
+	}
+	return r.loadMigrations()
+}
+
+// loadMigrations loads the migration files, merged with the registered Go
+// migrations.
+func (r *Runner) loadMigrations() ([]Migration, error) {
+	files, err := r.generator.ListMigrations()
+	if err != nil {
+		return nil, err
+	}
+	migrations := make([]Migration, 0, len(files))
+	for _, file := range files {
+		m, err := r.generator.ReadMigration(file)
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, *m)
+	}
+	return mergeMigrations(migrations, RegisteredMigrations())
+}
@@ -0,0 +1,80 @@
+package migration
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMigrationTransactional(t *testing.T) {
+	tests := []struct {
+		name string
+		up   string
+		down string
+		want bool
+	}{
+		{"plain DDL", "CREATE TABLE users (id integer);", "DROP TABLE users;", true},
+		{"create index concurrently", "CREATE INDEX CONCURRENTLY idx_users_email ON users (email);", "DROP INDEX idx_users_email;", false},
+		{"unique index concurrently", "create unique index concurrently idx ON users (email);", "", false},
+		{"drop index concurrently in down", "CREATE INDEX idx ON users (email);", "DROP INDEX CONCURRENTLY idx;", false},
+		{"reindex concurrently", "REINDEX TABLE CONCURRENTLY users;", "", false},
+		{"directive", NoTransactionDirective + "\nALTER TYPE mood ADD VALUE 'meh';", "", false},
+		{"refresh concurrently is transactional", "REFRESH MATERIALIZED VIEW CONCURRENTLY stats;", "", true},
+		{"concurrently in a string", "INSERT INTO notes VALUES ('CREATE INDEX CONCURRENTLY');", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := Migration{UpSQL: tt.up, DownSQL: tt.down}
+			if got := m.Transactional(); got != tt.want {
+				t.Errorf("Transactional() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMigrationChecksum(t *testing.T) {
+	a := Migration{UpSQL: "CREATE TABLE users (id integer);"}
+	b := Migration{UpSQL: "CREATE TABLE users (id bigint);"}
+
+	if len(a.Checksum()) != 64 {
+		t.Errorf("expected a hex SHA-256, got %q", a.Checksum())
+	}
+	if a.Checksum() != (Migration{UpSQL: a.UpSQL, DownSQL: "DROP TABLE users;"}).Checksum() {
+		t.Error("checksum should only cover the up SQL")
+	}
+	if a.Checksum() == b.Checksum() {
+		t.Error("different up SQL should produce different checksums")
+	}
+}
+
+func TestVerifyChecksums(t *testing.T) {
+	m1 := Migration{Version: "1", UpSQL: "CREATE TABLE a (id integer);"}
+	m2 := Migration{Version: "2", UpSQL: "CREATE TABLE b (id integer);"}
+	sum1, stale := m1.Checksum(), "stale"
+
+	if err := verifyChecksums([]MigrationRecord{{Version: "1", Checksum: &sum1}, {Version: "2"}}, []Migration{m1, m2}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	err := verifyChecksums([]MigrationRecord{{Version: "1", Checksum: &stale}}, []Migration{m1, m2})
+	if err == nil || !strings.Contains(err.Error(), "[1]") {
+		t.Errorf("expected modified migration 1 to be reported, got %v", err)
+	}
+}
+
+func TestMigrationStatus(t *testing.T) {
+	m1 := Migration{Version: "1", Name: "a", UpSQL: "CREATE TABLE a (id integer);"}
+	m2 := Migration{Version: "2", Name: "b", UpSQL: "CREATE TABLE b (id integer);"}
+	stale := "stale"
+
+	records := migrationStatus([]MigrationRecord{{Version: "1", Name: "a", Status: StatusApplied, Checksum: &stale}}, []Migration{m1, m2})
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+	if records[0].Status != StatusModified {
+		t.Errorf("expected migration 1 to be modified, got %s", records[0].Status)
+	}
+	if records[1].Status != StatusPending {
+		t.Errorf("expected migration 2 to be pending, got %s", records[1].Status)
+	}
+}
@@ -0,0 +1,119 @@
+package migration
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+)
+
+func billingInvoicesTable() schema.TableMetadata {
+	return schema.TableMetadata{
+		Name:   "invoices",
+		Schema: "billing",
+		Columns: []schema.ColumnMetadata{
+			{Name: "id", SQLType: "serial"},
+			{Name: "customer_id", SQLType: "integer"},
+		},
+		PrimaryKey: &schema.PrimaryKeyMetadata{Name: "invoices_pkey", Columns: []string{"id"}},
+		ForeignKeys: []schema.ForeignKeyMetadata{{
+			Name:              "fk_invoices_customer_id_customers",
+			Columns:           []string{"customer_id"},
+			ReferencedTable:   "billing.customers",
+			ReferencedColumns: []string{"id"},
+		}},
+		Indexes: []schema.IndexMetadata{{Name: "idx_invoices_customer", Columns: []string{"customer_id"}}},
+	}
+}
+
+func TestGenerateMigration_NonPublicSchema(t *testing.T) {
+	customers := schema.TableMetadata{
+		Name:       "customers",
+		Schema:     "billing",
+		Columns:    []schema.ColumnMetadata{{Name: "id", SQLType: "serial"}},
+		PrimaryKey: &schema.PrimaryKeyMetadata{Name: "customers_pkey", Columns: []string{"id"}},
+	}
+	diff := &SchemaDiff{TablesAdded: []schema.TableMetadata{billingInvoicesTable(), customers}}
+
+	up, down := NewPlanner().GenerateMigration(diff)
+
+	for _, want := range []string{
+		"CREATE SCHEMA IF NOT EXISTS billing;",
+		"CREATE TABLE IF NOT EXISTS billing.invoices (",
+		"REFERENCES billing.customers (id)",
+		"CREATE INDEX IF NOT EXISTS idx_invoices_customer ON billing.invoices (customer_id);",
+	} {
+		if !strings.Contains(up, want) {
+			t.Errorf("up migration missing %q:\n%s", want, up)
+		}
+	}
+	if !strings.HasPrefix(up, "CREATE SCHEMA") {
+		t.Errorf("schema must be created first:\n%s", up)
+	}
+	// Referenced table is created first despite both living in billing.
+	if strings.Index(up, "billing.customers (\n") > strings.Index(up, "billing.invoices (\n") {
+		t.Errorf("billing.customers must be created before billing.invoices:\n%s", up)
+	}
+
+	if !strings.Contains(down, `DROP TABLE IF EXISTS "billing"."invoices";`) {
+		t.Errorf("down migration missing qualified DROP TABLE:\n%s", down)
+	}
+	if strings.Contains(down, "DROP SCHEMA") {
+		t.Errorf("down migration must not drop the schema:\n%s", down)
+	}
+}
+
+func TestGenerateAlterTable_NonPublicSchema(t *testing.T) {
+	table := billingInvoicesTable()
+	dbTable := billingInvoicesTable()
+	dbTable.Indexes = nil
+
+	tableDiff := NewDiffer().compareTable(&table, &dbTable)
+	if tableDiff.TableName != "billing.invoices" {
+		t.Fatalf("TableName = %q, want billing.invoices", tableDiff.TableName)
+	}
+
+	up, down := NewPlanner().generateAlterTable(tableDiff)
+	if len(up) != 1 || !strings.Contains(up[0], "ON billing.invoices") {
+		t.Errorf("unexpected up SQL: %v", up)
+	}
+	if len(down) != 1 || down[0] != "DROP INDEX IF EXISTS billing.idx_invoices_customer;" {
+		t.Errorf("unexpected down SQL: %v", down)
+	}
+}
+
+func TestApplySQLToSchema_NonPublicSchema(t *testing.T) {
+	tables := make(map[string]*schema.TableMetadata)
+	up, _ := NewPlanner().GenerateMigration(&SchemaDiff{TablesAdded: []schema.TableMetadata{billingInvoicesTable()}})
+	applySQLToSchema(tables, up)
+
+	table := tables["billing.invoices"]
+	if table == nil {
+		t.Fatalf("billing.invoices not reconstructed, got %v", tables)
+	}
+	if table.Name != "invoices" || table.Schema != "billing" {
+		t.Errorf("got name %q schema %q", table.Name, table.Schema)
+	}
+	if len(table.ForeignKeys) != 1 || table.ForeignKeys[0].ReferencedTable != "billing.customers" {
+		t.Errorf("foreign keys not reconstructed: %+v", table.ForeignKeys)
+	}
+
+	applySQLToSchema(tables, `DROP TABLE IF EXISTS "billing"."invoices";`)
+	if _, ok := tables["billing.invoices"]; ok {
+		t.Error("billing.invoices should be dropped")
+	}
+}
+
+func TestSchemasOf(t *testing.T) {
+	tables := map[string]*schema.TableMetadata{
+		"users":            {Name: "users"},
+		"billing.invoices": {Name: "invoices", Schema: "billing"},
+		"analytics.events": {Name: "events", Schema: "analytics"},
+		"billing.payments": {Name: "payments", Schema: "billing"},
+	}
+	got := SchemasOf(tables)
+	want := []string{"public", "analytics", "billing"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("SchemasOf() = %v, want %v", got, want)
+	}
+}
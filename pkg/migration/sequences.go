@@ -0,0 +1,423 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+)
+
+var (
+	reCreateSequence   = regexp.MustCompile(`(?is)^\s*CREATE\s+SEQUENCE\s+(?:IF\s+NOT\s+EXISTS\s+)?((?:"?\w+"?\.)?"?\w+"?)(.*)$`)
+	reAlterSequence    = regexp.MustCompile(`(?is)^\s*ALTER\s+SEQUENCE\s+(?:IF\s+EXISTS\s+)?((?:"?\w+"?\.)?"?\w+"?)(.*)$`)
+	reDropSequence     = regexp.MustCompile(`(?i)^\s*DROP\s+SEQUENCE\s+(?:IF\s+EXISTS\s+)?((?:"?\w+"?\.)?"?\w+"?)`)
+	reAlterColSequence = regexp.MustCompile(`(?is)^ALTER\s+COLUMN\s+"?(\w+)"?\s+(SET\s+.+)$`)
+)
+
+// SequenceDiff represents changed options of a standalone sequence.
+type SequenceDiff struct {
+	Name string                  // Sequence name
+	Old  schema.SequenceMetadata // Definition in the database
+	New  schema.SequenceMetadata // Definition in code
+}
+
+// WithSequences sets the standalone sequences in the database (see
+// Introspector.IntrospectSequences), so Compare creates only the declared
+// sequences that are missing and alters those whose options changed.
+// Sequences in the database that no model declares are left alone: SERIAL
+// columns and other tools create them too.
+func (d *Differ) WithSequences(sequences []schema.SequenceMetadata) *Differ {
+	d.sequences = sequences
+	return d
+}
+
+// compareSequences finds declared sequences to create or alter.
+func (d *Differ) compareSequences(codeSchema map[string]*schema.TableMetadata, diff *SchemaDiff) {
+	seen := make(map[string]bool)
+	for _, tableName := range slices.Sorted(maps.Keys(codeSchema)) {
+		for _, seq := range codeSchema[tableName].Sequences {
+			name := normalizeSequenceName(seq.Name)
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+
+			dbSeq := d.findSequence(name)
+			if dbSeq == nil {
+				diff.SequencesAdded = append(diff.SequencesAdded, seq)
+				continue
+			}
+			ownerChanged := seq.OwnedBy != "" && seq.OwnedBy != dbSeq.OwnedBy
+			if sequenceOptionsDiffer(seq.Options, dbSeq.Options) || ownerChanged {
+				diff.SequencesModified = append(diff.SequencesModified, SequenceDiff{Name: seq.Name, Old: *dbSeq, New: seq})
+			}
+		}
+	}
+}
+
+// findSequence returns the database sequence with the given normalized name.
+func (d *Differ) findSequence(name string) *schema.SequenceMetadata {
+	for i := range d.sequences {
+		if normalizeSequenceName(d.sequences[i].Name) == name {
+			return &d.sequences[i]
+		}
+	}
+	return nil
+}
+
+// normalizeSequenceName strips quotes and the public schema from a name.
+func normalizeSequenceName(name string) string {
+	return reconstructTableName(name)
+}
+
+// sequenceOptionsDiffer reports whether any option set in code differs from
+// the database. Options left unset in code are not compared.
+func sequenceOptionsDiffer(code, db schema.SequenceOptions) bool {
+	newOpts, _ := changedSequenceOptions(code, db)
+	return len(sequenceClauses(newOpts)) > 0
+}
+
+// changedSequenceOptions returns the options set in code that differ from the
+// database, as new and old values.
+func changedSequenceOptions(code, db schema.SequenceOptions) (newOpts, oldOpts schema.SequenceOptions) {
+	pairs := []struct{ code, db, newVal, oldVal **int64 }{
+		{&code.Start, &db.Start, &newOpts.Start, &oldOpts.Start},
+		{&code.Increment, &db.Increment, &newOpts.Increment, &oldOpts.Increment},
+		{&code.MinValue, &db.MinValue, &newOpts.MinValue, &oldOpts.MinValue},
+		{&code.MaxValue, &db.MaxValue, &newOpts.MaxValue, &oldOpts.MaxValue},
+		{&code.Cache, &db.Cache, &newOpts.Cache, &oldOpts.Cache},
+	}
+	for _, p := range pairs {
+		if *p.code != nil && (*p.db == nil || **p.code != **p.db) {
+			*p.newVal, *p.oldVal = *p.code, *p.db
+		}
+	}
+	if code.Cycle != nil && (db.Cycle == nil || *code.Cycle != *db.Cycle) {
+		newOpts.Cycle, oldOpts.Cycle = code.Cycle, db.Cycle
+	}
+	return newOpts, oldOpts
+}
+
+// sequenceClauses renders the options that are set as SQL clauses
+// ("START WITH 1000", "INCREMENT BY 10", ...).
+func sequenceClauses(opts schema.SequenceOptions) []string {
+	var clauses []string
+	if opts.Start != nil {
+		clauses = append(clauses, fmt.Sprintf("START WITH %d", *opts.Start))
+	}
+	if opts.Increment != nil {
+		clauses = append(clauses, fmt.Sprintf("INCREMENT BY %d", *opts.Increment))
+	}
+	if opts.MinValue != nil {
+		clauses = append(clauses, fmt.Sprintf("MINVALUE %d", *opts.MinValue))
+	}
+	if opts.MaxValue != nil {
+		clauses = append(clauses, fmt.Sprintf("MAXVALUE %d", *opts.MaxValue))
+	}
+	if opts.Cache != nil {
+		clauses = append(clauses, fmt.Sprintf("CACHE %d", *opts.Cache))
+	}
+	if opts.Cycle != nil {
+		if *opts.Cycle {
+			clauses = append(clauses, "CYCLE")
+		} else {
+			clauses = append(clauses, "NO CYCLE")
+		}
+	}
+	return clauses
+}
+
+// parseSequenceClauses parses SQL sequence options, as written after CREATE
+// SEQUENCE, ALTER SEQUENCE, AS IDENTITY or ALTER COLUMN ... SET. ownedBy is
+// "" when no OWNED BY clause is present and "NONE" for OWNED BY NONE.
+func parseSequenceClauses(s string) (opts schema.SequenceOptions, ownedBy string) {
+	tokens := strings.Fields(strings.NewReplacer("(", " ", ")", " ", ";", " ").Replace(s))
+	number := func(i int) (*int64, int) {
+		if i < len(tokens) {
+			if n, err := strconv.ParseInt(tokens[i], 10, 64); err == nil {
+				return &n, i
+			}
+		}
+		return nil, i - 1
+	}
+	for i := 0; i < len(tokens); i++ {
+		switch strings.ToUpper(tokens[i]) {
+		case "START":
+			if i+1 < len(tokens) && strings.EqualFold(tokens[i+1], "WITH") {
+				i++
+			}
+			opts.Start, i = number(i + 1)
+		case "INCREMENT":
+			if i+1 < len(tokens) && strings.EqualFold(tokens[i+1], "BY") {
+				i++
+			}
+			opts.Increment, i = number(i + 1)
+		case "MINVALUE":
+			opts.MinValue, i = number(i + 1)
+		case "MAXVALUE":
+			opts.MaxValue, i = number(i + 1)
+		case "CACHE":
+			opts.Cache, i = number(i + 1)
+		case "CYCLE":
+			cycle := i == 0 || !strings.EqualFold(tokens[i-1], "NO")
+			opts.Cycle = &cycle
+		case "OWNED":
+			if i+2 < len(tokens) && strings.EqualFold(tokens[i+1], "BY") {
+				ownedBy = strings.ReplaceAll(tokens[i+2], `"`, "")
+				i += 2
+			}
+		case "AS":
+			i++ // data type
+		}
+	}
+	return opts, ownedBy
+}
+
+// generateCreateSequence generates a CREATE SEQUENCE statement.
+func (p *Planner) generateCreateSequence(seq schema.SequenceMetadata) string {
+	sql := "CREATE SEQUENCE IF NOT EXISTS " + schema.QuoteQualifiedIdent(seq.Name)
+	if clauses := sequenceClauses(seq.Options); len(clauses) > 0 {
+		sql += " " + strings.Join(clauses, " ")
+	}
+	return sql + ";"
+}
+
+// generateAlterSequence generates an ALTER SEQUENCE statement for the given
+// options and owner; it returns "" when there is nothing to change.
+func (p *Planner) generateAlterSequence(name string, opts schema.SequenceOptions, ownedBy string) string {
+	clauses := sequenceClauses(opts)
+	if ownedBy != "" {
+		clauses = append(clauses, "OWNED BY "+ownedByRef(ownedBy))
+	}
+	if len(clauses) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("ALTER SEQUENCE %s %s;", schema.QuoteQualifiedIdent(name), strings.Join(clauses, " "))
+}
+
+// generateDropSequence generates a DROP SEQUENCE statement.
+func (p *Planner) generateDropSequence(name string) string {
+	return fmt.Sprintf("DROP SEQUENCE IF EXISTS %s;", schema.QuoteQualifiedIdent(name))
+}
+
+// ownedByRef quotes an OWNED BY target ("table.column" or NONE).
+func ownedByRef(ownedBy string) string {
+	if strings.EqualFold(ownedBy, "NONE") {
+		return "NONE"
+	}
+	i := strings.LastIndex(ownedBy, ".")
+	if i < 0 {
+		return ownedBy
+	}
+	return schema.QuoteQualifiedIdent(ownedBy[:i]) + "." + schema.QuoteReservedIdent(ownedBy[i+1:])
+}
+
+// generateSequenceChanges generates the up and down statements for sequences
+// to create and alter. Creation comes before tables (column defaults may call
+// nextval), ownership and option changes after them.
+func (p *Planner) generateSequenceChanges(diff *SchemaDiff) (upCreate, upAlter, down []string) {
+	for _, seq := range diff.SequencesAdded {
+		upCreate = append(upCreate, p.generateCreateSequence(seq))
+		if seq.OwnedBy != "" {
+			upAlter = append(upAlter, p.generateAlterSequence(seq.Name, schema.SequenceOptions{}, seq.OwnedBy))
+		}
+		down = append(down, p.generateDropSequence(seq.Name))
+	}
+	for _, seqDiff := range diff.SequencesModified {
+		newOpts, oldOpts := changedSequenceOptions(seqDiff.New.Options, seqDiff.Old.Options)
+		var newOwner, oldOwner string
+		if seqDiff.New.OwnedBy != "" && seqDiff.New.OwnedBy != seqDiff.Old.OwnedBy {
+			newOwner, oldOwner = seqDiff.New.OwnedBy, seqDiff.Old.OwnedBy
+			if oldOwner == "" {
+				oldOwner = "NONE"
+			}
+		}
+		if sql := p.generateAlterSequence(seqDiff.Name, newOpts, newOwner); sql != "" {
+			upAlter = append(upAlter, sql)
+		}
+		if sql := p.generateAlterSequence(seqDiff.Name, oldOpts, oldOwner); sql != "" {
+			down = append(down, sql)
+		}
+	}
+	return upCreate, upAlter, down
+}
+
+// generateIdentitySequenceChange generates ALTER COLUMN ... SET statements
+// for changed identity sequence options.
+func (p *Planner) generateIdentitySequenceChange(tableName, colName string, colDiff ColumnDiff) (upSQL, downSQL []string) {
+	newOpts, oldOpts := changedSequenceOptions(identitySequence(colDiff.NewColumn), identitySequence(colDiff.OldColumn))
+	set := func(opts schema.SequenceOptions) string {
+		clauses := sequenceClauses(opts)
+		for i := range clauses {
+			clauses[i] = "SET " + clauses[i]
+		}
+		return strings.Join(clauses, " ")
+	}
+	if clauses := set(newOpts); clauses != "" {
+		upSQL = append(upSQL, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s %s;", tableName, colName, clauses))
+	}
+	if clauses := set(oldOpts); clauses != "" {
+		downSQL = append(downSQL, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s %s;", tableName, colName, clauses))
+	}
+	return upSQL, downSQL
+}
+
+// identitySequence returns the identity sequence options of col, if any.
+func identitySequence(col schema.ColumnMetadata) schema.SequenceOptions {
+	if col.Identity == nil || col.Identity.Sequence == nil {
+		return schema.SequenceOptions{}
+	}
+	return *col.Identity.Sequence
+}
+
+// IntrospectSequences returns the standalone sequences in the introspected
+// schemas. Sequences backing identity columns are excluded.
+func (i *Introspector) IntrospectSequences(ctx context.Context) ([]schema.SequenceMetadata, error) {
+	query := `
+		SELECT
+			s.schemaname, s.sequencename,
+			s.start_value, s.increment_by, s.min_value, s.max_value, s.cache_size, s.cycle,
+			owner.ref
+		FROM pg_sequences s
+		JOIN pg_namespace n ON n.nspname = s.schemaname
+		JOIN pg_class c ON c.relname = s.sequencename AND c.relnamespace = n.oid
+		LEFT JOIN LATERAL (
+			SELECT tn.nspname || '.' || t.relname || '.' || a.attname AS ref
+			FROM pg_depend d
+			JOIN pg_class t ON t.oid = d.refobjid
+			JOIN pg_namespace tn ON tn.oid = t.relnamespace
+			JOIN pg_attribute a ON a.attrelid = d.refobjid AND a.attnum = d.refobjsubid
+			WHERE d.classid = 'pg_class'::regclass AND d.objid = c.oid AND d.deptype = 'a'
+		) owner ON true
+		WHERE s.schemaname = ANY($1)
+		  AND NOT EXISTS (
+			SELECT 1 FROM pg_depend d
+			WHERE d.classid = 'pg_class'::regclass AND d.objid = c.oid AND d.deptype = 'i'
+		  )
+		ORDER BY s.schemaname, s.sequencename
+	`
+
+	rows, err := i.query(ctx, query, i.schemaNames())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sequences []schema.SequenceMetadata
+	for rows.Next() {
+		var schemaName, name string
+		var start, increment, minValue, maxValue, cache int64
+		var cycle bool
+		var owner *string
+		if err := rows.Scan(&schemaName, &name, &start, &increment, &minValue, &maxValue, &cache, &cycle, &owner); err != nil {
+			return nil, err
+		}
+		seq := schema.SequenceMetadata{
+			Name: schema.QualifyTableName(schemaName, name),
+			Options: schema.SequenceOptions{
+				Start: &start, Increment: &increment, MinValue: &minValue,
+				MaxValue: &maxValue, Cache: &cache, Cycle: &cycle,
+			},
+		}
+		if owner != nil {
+			seq.OwnedBy = strings.TrimPrefix(*owner, schema.DefaultSchema+".")
+		}
+		sequences = append(sequences, seq)
+	}
+	return sequences, rows.Err()
+}
+
+// ReconstructSequencesFromMigrations replays the CREATE/ALTER/DROP SEQUENCE
+// statements of all *.up.sql files in dir, returning the sequences they leave
+// in place. It is the offline counterpart of IntrospectSequences.
+func ReconstructSequencesFromMigrations(dir string) ([]schema.SequenceMetadata, error) {
+	contents, err := readUpMigrations(dir)
+	if err != nil {
+		return nil, err
+	}
+	var sequences []schema.SequenceMetadata
+	for _, sql := range contents {
+		sequences = applySequenceSQL(sequences, sql)
+	}
+	return sequences, nil
+}
+
+// applySequenceSQL applies the sequence statements in sql to sequences.
+func applySequenceSQL(sequences []schema.SequenceMetadata, sql string) []schema.SequenceMetadata {
+	find := func(name string) int {
+		for i := range sequences {
+			if sequences[i].Name == name {
+				return i
+			}
+		}
+		return -1
+	}
+	for _, stmt := range splitSQLStatements(sql) {
+		if m := reCreateSequence.FindStringSubmatch(stmt); m != nil {
+			name := normalizeSequenceName(m[1])
+			if find(name) >= 0 {
+				continue
+			}
+			opts, ownedBy := parseSequenceClauses(m[2])
+			seq := schema.SequenceMetadata{Name: name, Options: opts}
+			if ownedBy != "" && !strings.EqualFold(ownedBy, "NONE") {
+				seq.OwnedBy = reconstructOwnedBy(ownedBy)
+			}
+			sequences = append(sequences, seq)
+		} else if m := reAlterSequence.FindStringSubmatch(stmt); m != nil {
+			i := find(normalizeSequenceName(m[1]))
+			if i < 0 {
+				continue
+			}
+			opts, ownedBy := parseSequenceClauses(m[2])
+			mergeSequenceOptions(&sequences[i].Options, opts)
+			switch {
+			case strings.EqualFold(ownedBy, "NONE"):
+				sequences[i].OwnedBy = ""
+			case ownedBy != "":
+				sequences[i].OwnedBy = reconstructOwnedBy(ownedBy)
+			}
+		} else if m := reDropSequence.FindStringSubmatch(stmt); m != nil {
+			if i := find(normalizeSequenceName(m[1])); i >= 0 {
+				sequences = append(sequences[:i], sequences[i+1:]...)
+			}
+		}
+	}
+	return sequences
+}
+
+// reconstructOwnedBy normalizes an OWNED BY target to "table.column".
+func reconstructOwnedBy(ref string) string {
+	i := strings.LastIndex(ref, ".")
+	if i < 0 {
+		return ref
+	}
+	return reconstructTableName(ref[:i]) + "." + strings.ToLower(ref[i+1:])
+}
+
+// mergeSequenceOptions overwrites dst with the options set in src.
+func mergeSequenceOptions(dst *schema.SequenceOptions, src schema.SequenceOptions) {
+	if src.Start != nil {
+		dst.Start = src.Start
+	}
+	if src.Increment != nil {
+		dst.Increment = src.Increment
+	}
+	if src.MinValue != nil {
+		dst.MinValue = src.MinValue
+	}
+	if src.MaxValue != nil {
+		dst.MaxValue = src.MaxValue
+	}
+	if src.Cache != nil {
+		dst.Cache = src.Cache
+	}
+	if src.Cycle != nil {
+		dst.Cycle = src.Cycle
+	}
+}
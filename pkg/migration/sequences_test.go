@@ -0,0 +1,157 @@
+package migration
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+)
+
+func seqInt(n int64) *int64 { return &n }
+
+func TestCompare_Sequences(t *testing.T) {
+	code := map[string]*schema.TableMetadata{
+		"invoices": {Name: "invoices", Sequences: []schema.SequenceMetadata{
+			{Name: "invoice_number_seq", Options: schema.SequenceOptions{Start: seqInt(1000), Increment: seqInt(1)}, OwnedBy: "invoices.number"},
+			{Name: "ticket_seq", Options: schema.SequenceOptions{Cache: seqInt(20)}},
+		}},
+	}
+	db := map[string]*schema.TableMetadata{"invoices": {Name: "invoices"}}
+
+	t.Run("missing sequences are added", func(t *testing.T) {
+		diff := NewDiffer().Compare(code, db)
+		if len(diff.SequencesAdded) != 2 || len(diff.SequencesModified) != 0 {
+			t.Fatalf("expected 2 added sequences, got %+v", diff)
+		}
+	})
+
+	t.Run("only options set in code are compared", func(t *testing.T) {
+		installed := []schema.SequenceMetadata{
+			{Name: "invoice_number_seq", Options: schema.SequenceOptions{Start: seqInt(1000), Increment: seqInt(1), Cache: seqInt(1)}, OwnedBy: "invoices.number"},
+			{Name: "public.ticket_seq", Options: schema.SequenceOptions{Start: seqInt(1), Cache: seqInt(20)}},
+			{Name: "users_id_seq"},
+		}
+		if diff := NewDiffer().WithSequences(installed).Compare(code, db); diff.HasChanges() {
+			t.Errorf("expected no changes, got %+v", diff)
+		}
+	})
+
+	t.Run("changed options and owner are altered", func(t *testing.T) {
+		installed := []schema.SequenceMetadata{
+			{Name: "invoice_number_seq", Options: schema.SequenceOptions{Start: seqInt(1), Increment: seqInt(1)}},
+			{Name: "ticket_seq", Options: schema.SequenceOptions{Cache: seqInt(20)}},
+		}
+		diff := NewDiffer().WithSequences(installed).Compare(code, db)
+		if len(diff.SequencesModified) != 1 || diff.SequencesModified[0].Name != "invoice_number_seq" {
+			t.Fatalf("expected invoice_number_seq modified, got %+v", diff.SequencesModified)
+		}
+
+		up, down := NewPlanner().GenerateMigration(diff)
+		if want := "ALTER SEQUENCE invoice_number_seq START WITH 1000 OWNED BY invoices.number;\n"; up != want {
+			t.Errorf("up = %q, want %q", up, want)
+		}
+		if want := "ALTER SEQUENCE invoice_number_seq START WITH 1 OWNED BY NONE;\n"; down != want {
+			t.Errorf("down = %q, want %q", down, want)
+		}
+	})
+}
+
+func TestGenerateMigration_Sequences(t *testing.T) {
+	diff := &SchemaDiff{
+		SequencesAdded: []schema.SequenceMetadata{
+			{Name: "billing.invoice_number_seq", Options: schema.SequenceOptions{Start: seqInt(1000), Cache: seqInt(20)}, OwnedBy: "billing.invoices.number"},
+		},
+		TablesAdded: []schema.TableMetadata{{Name: "invoices", Schema: "billing", Columns: []schema.ColumnMetadata{{Name: "number", SQLType: "bigint"}}}},
+	}
+
+	up, down := NewPlanner().GenerateMigration(diff)
+
+	create := strings.Index(up, "CREATE SEQUENCE IF NOT EXISTS billing.invoice_number_seq START WITH 1000 CACHE 20;")
+	table := strings.Index(up, "CREATE TABLE")
+	owner := strings.Index(up, "ALTER SEQUENCE billing.invoice_number_seq OWNED BY billing.invoices.number;")
+	if create < 0 || table < 0 || owner < 0 || create > table || table > owner {
+		t.Errorf("expected sequence, then table, then ownership:\n%s", up)
+	}
+	if !strings.HasSuffix(down, "DROP SEQUENCE IF EXISTS billing.invoice_number_seq;\n") {
+		t.Errorf("down migration must drop the sequence after the table:\n%s", down)
+	}
+}
+
+func TestIdentitySequenceOptions(t *testing.T) {
+	codeCol := schema.ColumnMetadata{Name: "id", SQLType: "bigint", Identity: &schema.IdentityColumn{
+		Generation: schema.IdentityAlways,
+		Sequence:   &schema.SequenceOptions{Start: seqInt(1000), Increment: seqInt(10)},
+	}}
+
+	t.Run("column definition", func(t *testing.T) {
+		got := NewPlanner().generateColumnDefinition(codeCol)
+		if want := "id bigint GENERATED ALWAYS AS IDENTITY (START WITH 1000 INCREMENT BY 10)"; got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+
+	t.Run("option change alters the column", func(t *testing.T) {
+		dbCol := codeCol
+		dbCol.Identity = &schema.IdentityColumn{
+			Generation: schema.IdentityAlways,
+			Sequence:   &schema.SequenceOptions{Start: seqInt(1000), Increment: seqInt(1), Cache: seqInt(1)},
+		}
+		code := map[string]*schema.TableMetadata{"orders": {Name: "orders", Columns: []schema.ColumnMetadata{codeCol}}}
+		db := map[string]*schema.TableMetadata{"orders": {Name: "orders", Columns: []schema.ColumnMetadata{dbCol}}}
+
+		diff := NewDiffer().Compare(code, db)
+		if len(diff.TablesModified) != 1 || !diff.TablesModified[0].ColumnsModified[0].SequenceChanged {
+			t.Fatalf("expected identity sequence change, got %+v", diff.TablesModified)
+		}
+		up, down := NewPlanner().GenerateMigration(diff)
+		if !strings.Contains(up, "ALTER TABLE orders ALTER COLUMN id SET INCREMENT BY 10;") {
+			t.Errorf("unexpected up migration:\n%s", up)
+		}
+		if !strings.Contains(down, "ALTER TABLE orders ALTER COLUMN id SET INCREMENT BY 1;") {
+			t.Errorf("unexpected down migration:\n%s", down)
+		}
+	})
+
+	t.Run("reconstructed from migrations", func(t *testing.T) {
+		tables := make(map[string]*schema.TableMetadata)
+		applySQLToSchema(tables, "CREATE TABLE orders (\n    id bigint GENERATED ALWAYS AS IDENTITY (START WITH 1000 INCREMENT BY 1)\n);\n\nALTER TABLE orders ALTER COLUMN id SET INCREMENT BY 10;")
+		col := tables["orders"].GetColumnByName("id")
+		if col == nil || col.Identity == nil || col.Identity.Sequence == nil {
+			t.Fatalf("identity not reconstructed: %+v", col)
+		}
+		want := &schema.SequenceOptions{Start: seqInt(1000), Increment: seqInt(10)}
+		if !reflect.DeepEqual(col.Identity.Sequence, want) {
+			t.Errorf("sequence = %+v, want %+v", col.Identity.Sequence, want)
+		}
+	})
+}
+
+func TestReconstructSequencesFromMigrations(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"20240101000000_init.up.sql":  "CREATE SEQUENCE IF NOT EXISTS invoice_number_seq START WITH 1000 CACHE 20;\n\nCREATE SEQUENCE old_seq;\n\nALTER SEQUENCE invoice_number_seq OWNED BY invoices.number;\n",
+		"20240102000000_alter.up.sql": "ALTER SEQUENCE invoice_number_seq INCREMENT BY 5 NO CYCLE;\n\nDROP SEQUENCE IF EXISTS old_seq;\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := ReconstructSequencesFromMigrations(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	noCycle := false
+	want := []schema.SequenceMetadata{{
+		Name:    "invoice_number_seq",
+		Options: schema.SequenceOptions{Start: seqInt(1000), Increment: seqInt(5), Cache: seqInt(20), Cycle: &noCycle},
+		OwnedBy: "invoices.number",
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReconstructSequencesFromMigrations() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,179 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// SquashDirective heads a baseline migration written by Generator.Squash,
+// followed by the range of versions it replaces: "-- pebble:squashed
+// 20240101120000..20240301090000".
+const SquashDirective = "-- pebble:squashed"
+
+// SquashedName is the name given to baseline migrations.
+const SquashedName = "squashed_baseline"
+
+var reSquashed = regexp.MustCompile(`(?m)^\s*--\s*pebble:squashed\s+(\w+)\.\.(\w+)\s*$`)
+
+// ErrPartiallySquashed is returned when a database applied some, but not
+// all, of the migrations a baseline replaces, so neither skipping nor
+// applying the baseline would leave it in the right state.
+var ErrPartiallySquashed = errors.New("database applied only part of a squashed migration range")
+
+// SquashedRange returns the first and last version a baseline migration
+// replaces. It reports false for ordinary migrations.
+func (m Migration) SquashedRange() (first, last string, ok bool) {
+	sm := reSquashed.FindStringSubmatch(m.UpSQL)
+	if sm == nil {
+		return "", "", false
+	}
+	return sm[1], sm[2], true
+}
+
+// Squash collapses the migrations up to and including version upTo (all of
+// them when upTo is empty) into a single baseline migration and deletes the
+// originals. The baseline replays their SQL verbatim, so it builds exactly the
+// schema they did; its down migration runs theirs in reverse.
+//
+// The baseline takes the version of the last migration it replaces, so a
+// database that already applied it skips the baseline; Executor.ReconcileSquashed
+// then rewrites schema_migrations to match. Only squash migrations that every
+// database has applied.
+func (g *Generator) Squash(upTo string) (*MigrationFile, error) {
+	if g.fsys != nil {
+		return nil, errReadOnlyFS
+	}
+
+	files, err := g.ListMigrations()
+	if err != nil {
+		return nil, err
+	}
+	var squashed []Migration
+	var paths []string
+	for _, file := range files {
+		if upTo != "" && file.Version > upTo {
+			break
+		}
+		m, err := g.ReadMigration(file)
+		if err != nil {
+			return nil, err
+		}
+		squashed = append(squashed, *m)
+		paths = append(paths, file.UpPath, file.DownPath)
+	}
+	if len(squashed) < 2 {
+		return nil, fmt.Errorf("nothing to squash: %d migration(s) up to %q", len(squashed), upTo)
+	}
+
+	first, last := squashed[0], squashed[len(squashed)-1]
+	if f, _, ok := first.SquashedRange(); ok {
+		first.Version = f
+	}
+
+	up := []string{fmt.Sprintf("%s %s..%s\n-- Replaces %d migrations; the SQL below is theirs, in order.",
+		SquashDirective, first.Version, last.Version, len(squashed))}
+	var down []string
+	for i, m := range squashed {
+		upSQL := strings.TrimSpace(reSquashed.ReplaceAllString(m.UpSQL, ""))
+		up = append(up, fmt.Sprintf("-- %s_%s\n%s", m.Version, m.Name, upSQL))
+		r := squashed[len(squashed)-1-i]
+		down = append(down, fmt.Sprintf("-- %s_%s\n%s", r.Version, r.Name, strings.TrimSpace(r.DownSQL)))
+	}
+
+	baseline := &MigrationFile{
+		Version:  last.Version,
+		Name:     SquashedName,
+		UpPath:   filepath.Join(g.migrationsDir, GenerateFileName(last.Version, SquashedName, "up")),
+		DownPath: filepath.Join(g.migrationsDir, GenerateFileName(last.Version, SquashedName, "down")),
+	}
+	if err := g.writeFile(baseline.UpPath, strings.Join(up, "\n\n")+"\n"); err != nil {
+		return nil, fmt.Errorf("failed to write up migration: %w", err)
+	}
+	if err := g.writeFile(baseline.DownPath, strings.Join(down, "\n\n")+"\n"); err != nil {
+		return nil, fmt.Errorf("failed to write down migration: %w", err)
+	}
+	for _, path := range paths {
+		if path == baseline.UpPath || path == baseline.DownPath {
+			continue // re-squashing an earlier baseline
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove squashed migration: %w", err)
+		}
+	}
+	return baseline, nil
+}
+
+// ReconcileSquashed updates schema_migrations after a squash: when a
+// database applied the last migration a baseline replaces, the records of
+// the earlier ones are deleted and the last one takes the baseline's name and
+// checksum. It returns ErrPartiallySquashed if the database applied only some
+// of the replaced migrations.
+func (e *Executor) ReconcileSquashed(ctx context.Context, migrations []Migration) error {
+	applied, err := e.GetAppliedMigrations(ctx)
+	if err != nil {
+		return err
+	}
+	baselines, err := squashedToReconcile(applied, migrations)
+	if err != nil || len(baselines) == 0 {
+		return err
+	}
+
+	tx, err := e.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	for _, m := range baselines {
+		first, last, _ := m.SquashedRange()
+		if _, err := tx.Exec(ctx,
+			"DELETE FROM schema_migrations WHERE version >= $1 AND version < $2",
+			first, last,
+		); err != nil {
+			return fmt.Errorf("failed to remove squashed migration records: %w", err)
+		}
+		if _, err := tx.Exec(ctx,
+			"UPDATE schema_migrations SET name = $1, checksum = $2 WHERE version = $3",
+			m.Name, m.Checksum(), m.Version,
+		); err != nil {
+			return fmt.Errorf("failed to record squashed migration: %w", err)
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// squashedToReconcile returns the baselines whose records still describe the
+// migrations they replaced.
+func squashedToReconcile(applied []MigrationRecord, migrations []Migration) ([]Migration, error) {
+	var baselines []Migration
+	for _, m := range migrations {
+		first, last, ok := m.SquashedRange()
+		if !ok {
+			continue
+		}
+		i := slices.IndexFunc(applied, func(r MigrationRecord) bool { return r.Version == m.Version })
+		if i >= 0 {
+			if applied[i].Name != m.Name {
+				baselines = append(baselines, m)
+			}
+			continue
+		}
+		if slices.ContainsFunc(applied, func(r MigrationRecord) bool { return r.Version >= first && r.Version < last }) {
+			return nil, fmt.Errorf("%w %s..%s: restore and apply the original migrations, then squash again", ErrPartiallySquashed, first, last)
+		}
+	}
+	return baselines, nil
+}
+
+// unreconciledSquash reports whether a record still describes the last
+// migration a baseline replaced, whose checksum differs from the baseline's.
+func unreconciledSquash(record MigrationRecord, m Migration) bool {
+	_, _, ok := m.SquashedRange()
+	return ok && record.Name != m.Name
+}
@@ -0,0 +1,112 @@
+package migration
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeMigrationPair(t *testing.T, dir, version, name, up, down string) {
+	t.Helper()
+	for direction, sql := range map[string]string{"up": up, "down": down} {
+		if err := os.WriteFile(filepath.Join(dir, GenerateFileName(version, name, direction)), []byte(sql), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestGeneratorSquash(t *testing.T) {
+	dir := t.TempDir()
+	writeMigrationPair(t, dir, "20240101000000", "create_users", "CREATE TABLE users (id integer);\n", "DROP TABLE users;\n")
+	writeMigrationPair(t, dir, "20240102000000", "add_email", "ALTER TABLE users ADD COLUMN email text;\n", "ALTER TABLE users DROP COLUMN email;\n")
+	writeMigrationPair(t, dir, "20240103000000", "create_posts", "CREATE TABLE posts (id integer);\n", "DROP TABLE posts;\n")
+
+	generator := NewGenerator(dir)
+	baseline, err := generator.Squash("20240102000000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if baseline.Version != "20240102000000" || baseline.Name != SquashedName {
+		t.Errorf("expected the baseline to take the last squashed version, got %+v", baseline)
+	}
+
+	files, err := generator.ListMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 || files[0].Name != SquashedName || files[1].Name != "create_posts" {
+		t.Fatalf("expected the baseline and create_posts, got %+v", files)
+	}
+
+	m, err := generator.ReadMigration(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, last, ok := m.SquashedRange()
+	if !ok || first != "20240101000000" || last != "20240102000000" {
+		t.Errorf("unexpected squashed range %q..%q (%v)", first, last, ok)
+	}
+	if strings.Index(m.UpSQL, "CREATE TABLE users") > strings.Index(m.UpSQL, "ADD COLUMN email") {
+		t.Errorf("expected up SQL in version order, got:\n%s", m.UpSQL)
+	}
+	if strings.Index(m.DownSQL, "DROP COLUMN email") > strings.Index(m.DownSQL, "DROP TABLE users") {
+		t.Errorf("expected down SQL in reverse order, got:\n%s", m.DownSQL)
+	}
+
+	// Squashing again folds the earlier baseline in and keeps its range start
+	if _, err := generator.Squash(""); err != nil {
+		t.Fatal(err)
+	}
+	files, _ = generator.ListMigrations()
+	if len(files) != 1 {
+		t.Fatalf("expected a single baseline, got %+v", files)
+	}
+	m, _ = generator.ReadMigration(files[0])
+	if first, last, _ := m.SquashedRange(); first != "20240101000000" || last != "20240103000000" {
+		t.Errorf("unexpected squashed range %q..%q", first, last)
+	}
+	if strings.Count(m.UpSQL, SquashDirective) != 1 {
+		t.Errorf("expected a single squash directive, got:\n%s", m.UpSQL)
+	}
+}
+
+func TestSquashedToReconcile(t *testing.T) {
+	baseline := Migration{
+		Version: "3",
+		Name:    SquashedName,
+		UpSQL:   SquashDirective + " 1..3\nCREATE TABLE a (id integer);",
+	}
+	stale := "stale"
+
+	tests := []struct {
+		name    string
+		applied []MigrationRecord
+		want    int
+		wantErr bool
+	}{
+		{"fresh database", nil, 0, false},
+		{"applied before the squash", []MigrationRecord{{Version: "1", Name: "a"}, {Version: "2", Name: "b"}, {Version: "3", Name: "c", Checksum: &stale}}, 1, false},
+		{"already reconciled", []MigrationRecord{{Version: "3", Name: SquashedName}}, 0, false},
+		{"partially applied", []MigrationRecord{{Version: "1", Name: "a"}}, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := squashedToReconcile(tt.applied, []Migration{baseline})
+			if tt.wantErr {
+				if !errors.Is(err, ErrPartiallySquashed) {
+					t.Errorf("expected ErrPartiallySquashed, got %v", err)
+				}
+				return
+			}
+			if err != nil || len(got) != tt.want {
+				t.Errorf("expected %d baseline(s) to reconcile, got %d (%v)", tt.want, len(got), err)
+			}
+			if err := verifyChecksums(tt.applied, []Migration{baseline}); err != nil {
+				t.Errorf("an unreconciled baseline should not count as modified: %v", err)
+			}
+		})
+	}
+}
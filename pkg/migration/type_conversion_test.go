@@ -219,3 +219,69 @@ func TestRequiresUsingClause(t *testing.T) {
 		})
 	}
 }
+
+func TestTypeConversionUserSuppliedUsing(t *testing.T) {
+	opts, err := schema.ParseTag("price_cents,bigint,using(round(price_cents * 100)::bigint)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	newCol := schema.BuildColumn(opts, schema.FieldMeta{GoField: "PriceCents"})
+	if problems := schema.ValidateTag(opts, schema.FieldMeta{GoField: "PriceCents"}); len(problems) != 0 {
+		t.Errorf("expected using to be a known option, got %v", problems)
+	}
+
+	diff := TableDiff{
+		TableName: "products",
+		ColumnsModified: []ColumnDiff{{
+			ColumnName:  "price_cents",
+			TypeChanged: true,
+			OldColumn:   schema.ColumnMetadata{Name: "price_cents", SQLType: "numeric"},
+			NewColumn:   newCol,
+		}},
+	}
+
+	upSQL, downSQL := NewPlanner().generateAlterTable(diff)
+	want := "ALTER TABLE products ALTER COLUMN price_cents TYPE bigint USING round(price_cents * 100)::bigint;"
+	if len(upSQL) != 1 || upSQL[0] != want {
+		t.Errorf("expected %q, got %v", want, upSQL)
+	}
+	if len(downSQL) != 1 || downSQL[0] != "ALTER TABLE products ALTER COLUMN price_cents TYPE numeric;" {
+		t.Errorf("expected an implicit cast back to numeric, got %v", downSQL)
+	}
+}
+
+func TestTypeConversionBatched(t *testing.T) {
+	planner := NewPlannerWithOptions(PlannerOptions{BatchedTypeChanges: true})
+
+	diff := TableDiff{
+		TableName: "events",
+		ColumnsModified: []ColumnDiff{{
+			ColumnName:  "payload",
+			TypeChanged: true,
+			OldColumn:   schema.ColumnMetadata{Name: "payload", SQLType: "text"},
+			NewColumn:   schema.ColumnMetadata{Name: "payload", SQLType: "jsonb"},
+		}},
+	}
+
+	upSQL, downSQL := planner.generateAlterTable(diff)
+	up := strings.Join(upSQL, "\n")
+
+	for _, line := range upSQL {
+		if !strings.HasPrefix(line, "--") {
+			t.Errorf("expected the batched template to be commented out, got %q", line)
+		}
+	}
+	for _, want := range []string{
+		"-- ALTER TABLE events ADD COLUMN payload_new jsonb;",
+		"-- UPDATE events SET payload_new = CASE WHEN payload IS NULL THEN NULL WHEN payload = '' THEN '{}'::jsonb ELSE payload::jsonb END WHERE ctid IN (SELECT ctid FROM events WHERE payload_new IS NULL AND payload IS NOT NULL LIMIT 10000);",
+		"-- ALTER TABLE events DROP COLUMN payload;",
+		"-- ALTER TABLE events RENAME COLUMN payload_new TO payload;",
+	} {
+		if !strings.Contains(up, want) {
+			t.Errorf("expected up template to contain %q, got:\n%s", want, up)
+		}
+	}
+	if !strings.Contains(strings.Join(downSQL, "\n"), "-- UPDATE events SET payload_new = payload WHERE") {
+		t.Errorf("expected the down template to cast implicitly, got %v", downSQL)
+	}
+}
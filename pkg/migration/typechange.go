@@ -0,0 +1,100 @@
+package migration
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+)
+
+// typeChangeBatchSize is the batch size used in batched type change templates.
+const typeChangeBatchSize = 10000
+
+// generateTypeChange generates ALTER COLUMN ... TYPE for a changed column
+// type. The cast comes from the column's using(expr) tag option, or is
+// inferred for common conversions; without either, a conversion PostgreSQL
+// can't do implicitly is left as a MANUAL MIGRATION REQUIRED comment. With
+// BatchedTypeChanges, the change is written as a commented template that
+// backfills a new column in batches instead of rewriting the table under an
+// ACCESS EXCLUSIVE lock.
+func (p *Planner) generateTypeChange(tableName string, colDiff ColumnDiff) (upSQL, downSQL []string) {
+	colName := schema.QuoteReservedIdent(colDiff.ColumnName)
+	oldCol, newCol := colDiff.OldColumn, colDiff.NewColumn
+
+	upExpr, upOK := typeChangeExpression(colName, oldCol.SQLType, newCol.SQLType, newCol.Using)
+	downExpr, downOK := typeChangeExpression(colName, newCol.SQLType, oldCol.SQLType, "")
+
+	if p.options.BatchedTypeChanges {
+		upSQL = batchedTypeChange(tableName, colDiff.ColumnName, oldCol.SQLType, columnType(newCol), upExpr)
+		downSQL = batchedTypeChange(tableName, colDiff.ColumnName, newCol.SQLType, columnType(oldCol), downExpr)
+		return upSQL, downSQL
+	}
+
+	if upOK {
+		upSQL = append(upSQL, alterColumnType(tableName, colName, columnType(newCol), upExpr))
+	} else {
+		upSQL = append(upSQL,
+			fmt.Sprintf("-- MANUAL MIGRATION REQUIRED: Cannot auto-convert %s from %s to %s",
+				colName, oldCol.SQLType, newCol.SQLType),
+			"-- Please review and uncomment/modify the following statement:",
+			"-- "+alterColumnType(tableName, colName, columnType(newCol), upExpr))
+	}
+
+	if downOK {
+		downSQL = append(downSQL, alterColumnType(tableName, colName, columnType(oldCol), downExpr))
+	} else {
+		downSQL = append(downSQL,
+			fmt.Sprintf("-- MANUAL MIGRATION REQUIRED: Reverse type conversion for %s", colName),
+			"-- "+alterColumnType(tableName, colName, columnType(oldCol), downExpr))
+	}
+	return upSQL, downSQL
+}
+
+// typeChangeExpression returns the expression converting column values from
+// one type to another: the user-supplied cast when there is one, the column
+// itself when PostgreSQL can cast implicitly, or an inferred safe cast. It
+// reports false, with a placeholder expression, when none applies.
+func typeChangeExpression(colName, fromType, toType, using string) (string, bool) {
+	if using != "" {
+		return using, true
+	}
+	if !requiresUsingClause(fromType, toType) {
+		return colName, true
+	}
+	if clause := generateUsingClause(colName, fromType, toType); clause != "" {
+		return strings.TrimPrefix(clause, "USING "), true
+	}
+	return "<expression>", false
+}
+
+// alterColumnType generates ALTER COLUMN ... TYPE, with a USING clause unless
+// expr is the column itself.
+func alterColumnType(tableName, colName, sqlType, expr string) string {
+	if expr == colName {
+		return fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s;", tableName, colName, sqlType)
+	}
+	return fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s USING %s;", tableName, colName, sqlType, expr)
+}
+
+// batchedTypeChange generates a commented template that changes a column's
+// type by adding a new column, backfilling it in batches and swapping it in.
+func batchedTypeChange(tableName, column, fromType, toType, expr string) []string {
+	colName := schema.QuoteReservedIdent(column)
+	newName := schema.QuoteReservedIdent(column + "_new")
+	return []string{
+		fmt.Sprintf("-- MANUAL MIGRATION REQUIRED: Batched type change of %s from %s to %s", colName, fromType, toType),
+		"-- Run outside a transaction, repeating the UPDATE until it updates no rows, then",
+		fmt.Sprintf("-- re-create any NOT NULL, default, index or constraint on %s:", colName),
+		fmt.Sprintf("-- ALTER TABLE %s ADD COLUMN %s %s;", tableName, newName, toType),
+		"-- " + backfillBatch(tableName, colName, newName, expr),
+		fmt.Sprintf("-- ALTER TABLE %s DROP COLUMN %s;", tableName, colName),
+		fmt.Sprintf("-- ALTER TABLE %s RENAME COLUMN %s TO %s;", tableName, newName, colName),
+	}
+}
+
+// backfillBatch generates an UPDATE that copies the next batch of values from
+// one column into another, converted by expr.
+func backfillBatch(tableName, from, to, expr string) string {
+	return fmt.Sprintf("UPDATE %s SET %s = %s WHERE ctid IN (SELECT ctid FROM %s WHERE %s IS NULL AND %s IS NOT NULL LIMIT %d);",
+		tableName, to, expr, tableName, to, from, typeChangeBatchSize)
+}
@@ -0,0 +1,166 @@
+package migration
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+)
+
+func activeUsersView(query string) *schema.TableMetadata {
+	return &schema.TableMetadata{
+		Name: "active_users",
+		View: &schema.ViewMetadata{Query: query},
+	}
+}
+
+func TestCompareViews(t *testing.T) {
+	users := &schema.TableMetadata{Name: "users", Columns: []schema.ColumnMetadata{{Name: "id", SQLType: "integer"}}}
+
+	t.Run("new view is added, not created as a table", func(t *testing.T) {
+		code := map[string]*schema.TableMetadata{"users": users, "active_users": activeUsersView("SELECT id FROM users")}
+		db := map[string]*schema.TableMetadata{"users": users}
+
+		diff := NewDiffer().Compare(code, db)
+		if len(diff.TablesAdded) != 0 || len(diff.ViewsAdded) != 1 {
+			t.Fatalf("expected only a view to add, got tables %d views %d", len(diff.TablesAdded), len(diff.ViewsAdded))
+		}
+	})
+
+	t.Run("introspected view without a query is unchanged", func(t *testing.T) {
+		code := map[string]*schema.TableMetadata{"active_users": activeUsersView("SELECT id FROM users")}
+		db := map[string]*schema.TableMetadata{"active_users": activeUsersView("")}
+
+		if diff := NewDiffer().Compare(code, db); diff.HasChanges() {
+			t.Errorf("expected no changes, got %+v", diff)
+		}
+	})
+
+	t.Run("changed query is recreated, whitespace is ignored", func(t *testing.T) {
+		code := map[string]*schema.TableMetadata{"active_users": activeUsersView("SELECT id\n  FROM users")}
+		db := map[string]*schema.TableMetadata{"active_users": activeUsersView("SELECT id FROM users;")}
+		if diff := NewDiffer().Compare(code, db); diff.HasChanges() {
+			t.Errorf("expected no changes, got %+v", diff)
+		}
+
+		code["active_users"] = activeUsersView("SELECT id FROM users WHERE active")
+		if diff := NewDiffer().Compare(code, db); len(diff.ViewsModified) != 1 {
+			t.Errorf("expected 1 modified view, got %+v", diff)
+		}
+	})
+
+	t.Run("introspected definition matches the model's query", func(t *testing.T) {
+		code := map[string]*schema.TableMetadata{"active_users": activeUsersView(
+			"SELECT id, name FROM users WHERE active AND status != 'closed' ORDER BY name")}
+		db := map[string]*schema.TableMetadata{"active_users": activeUsersView(
+			" SELECT users.id,\n    users.name\n   FROM users\n  WHERE (users.active AND ((users.status)::text <> 'closed'::text))\n  ORDER BY users.name")}
+		if diff := NewDiffer().Compare(code, db); diff.HasChanges() {
+			t.Errorf("expected no changes, got %+v", diff)
+		}
+
+		code["active_users"] = activeUsersView("SELECT id, name FROM users WHERE active AND status != 'open' ORDER BY name")
+		if diff := NewDiffer().Compare(code, db); len(diff.ViewsModified) != 1 {
+			t.Errorf("expected the changed literal to modify the view, got %+v", diff)
+		}
+	})
+
+	t.Run("removed view is dropped", func(t *testing.T) {
+		db := map[string]*schema.TableMetadata{"active_users": activeUsersView("")}
+		diff := NewDiffer().Compare(map[string]*schema.TableMetadata{}, db)
+		if len(diff.ViewsDropped) != 1 || len(diff.TablesDropped) != 0 {
+			t.Errorf("expected only a view to drop, got %+v", diff)
+		}
+	})
+}
+
+func TestNormalizeViewQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"SELECT count(*) n FROM orders o INNER JOIN users u ON (u.id = o.user_id)", "select count * n from orders o join users u on id = user_id"},
+		{"SELECT o.* FROM orders o WHERE o.placed_at > '2024-01-01'::timestamp with time zone;", "select * from orders o where placed_at > '2024-01-01'"},
+		{"SELECT \"Name\" FROM users WHERE tags = '{a}'::character varying[]", "select \"Name\" from users where tags = '{a}'"},
+	}
+	for _, tt := range tests {
+		if got := normalizeViewQuery(tt.query); got != tt.want {
+			t.Errorf("normalizeViewQuery(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestGenerateMigration_Views(t *testing.T) {
+	users := schema.TableMetadata{Name: "users", Columns: []schema.ColumnMetadata{{Name: "id", SQLType: "integer"}}}
+	stats := schema.TableMetadata{
+		Name:    "daily_stats",
+		Schema:  "reporting",
+		View:    &schema.ViewMetadata{Query: "SELECT count(*) AS n FROM users", Materialized: true},
+		Indexes: []schema.IndexMetadata{{Name: "idx_daily_stats_n", Columns: []string{"n"}, Unique: true}},
+	}
+	diff := &SchemaDiff{
+		TablesAdded: []schema.TableMetadata{users},
+		ViewsAdded:  []schema.TableMetadata{*activeUsersView("SELECT id FROM users"), stats},
+	}
+
+	up, down := NewPlanner().GenerateMigration(diff)
+
+	for _, want := range []string{
+		"CREATE SCHEMA IF NOT EXISTS reporting;",
+		"CREATE OR REPLACE VIEW active_users AS\nSELECT id FROM users;",
+		"CREATE MATERIALIZED VIEW IF NOT EXISTS reporting.daily_stats AS\nSELECT count(*) AS n FROM users;",
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_daily_stats_n ON reporting.daily_stats (n);",
+	} {
+		if !strings.Contains(up, want) {
+			t.Errorf("up migration missing %q:\n%s", want, up)
+		}
+	}
+	if strings.Index(up, "CREATE TABLE") > strings.Index(up, "CREATE OR REPLACE VIEW") {
+		t.Errorf("tables must be created before views:\n%s", up)
+	}
+	if strings.Contains(up, "CREATE TABLE IF NOT EXISTS active_users") {
+		t.Errorf("view must not be created as a table:\n%s", up)
+	}
+
+	if !strings.HasPrefix(down, "DROP VIEW IF EXISTS active_users;") {
+		t.Errorf("down migration must drop views first:\n%s", down)
+	}
+	if !strings.Contains(down, `DROP MATERIALIZED VIEW IF EXISTS reporting.daily_stats;`) {
+		t.Errorf("down migration missing materialized view drop:\n%s", down)
+	}
+}
+
+func TestGenerateMigration_ModifiedView(t *testing.T) {
+	diff := &SchemaDiff{ViewsModified: []ViewDiff{{
+		Name: "active_users",
+		Old:  *activeUsersView("SELECT id FROM users"),
+		New:  *activeUsersView("SELECT id FROM users WHERE active"),
+	}}}
+
+	up, down := NewPlanner().GenerateMigration(diff)
+	wantUp := "DROP VIEW IF EXISTS active_users;\n\nCREATE OR REPLACE VIEW active_users AS\nSELECT id FROM users WHERE active;\n"
+	if up != wantUp {
+		t.Errorf("up =\n%s\nwant\n%s", up, wantUp)
+	}
+	wantDown := "DROP VIEW IF EXISTS active_users;\n\nCREATE OR REPLACE VIEW active_users AS\nSELECT id FROM users;\n"
+	if down != wantDown {
+		t.Errorf("down =\n%s\nwant\n%s", down, wantDown)
+	}
+}
+
+func TestApplySQLToSchema_Views(t *testing.T) {
+	tables := make(map[string]*schema.TableMetadata)
+	applySQLToSchema(tables, "CREATE MATERIALIZED VIEW IF NOT EXISTS reporting.daily_stats AS\nSELECT count(*) AS n FROM users;")
+
+	view := tables["reporting.daily_stats"]
+	if view == nil || !view.IsView() {
+		t.Fatalf("materialized view not reconstructed: %v", tables)
+	}
+	if !view.View.Materialized || view.View.Query != "SELECT count(*) AS n FROM users" {
+		t.Errorf("unexpected view: %+v", view.View)
+	}
+
+	applySQLToSchema(tables, "DROP MATERIALIZED VIEW IF EXISTS reporting.daily_stats;")
+	if len(tables) != 0 {
+		t.Errorf("expected view to be dropped, got %v", tables)
+	}
+}
@@ -0,0 +1,344 @@
+// Package pebbletest provides helpers for integration tests of pebble-orm models.
+package pebbletest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/marshallshelly/pebble-orm/pkg/registry"
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+	"gopkg.in/yaml.v3"
+)
+
+// Querier runs the INSERT statements of LoadFixtures. *runtime.DB,
+// *pgxpool.Pool, *pgx.Conn and pgx.Tx all satisfy it, so fixtures can be
+// loaded inside a transaction the test rolls back.
+type Querier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// Fixtures holds the rows inserted by LoadFixtures, by table and label.
+type Fixtures struct {
+	rows map[string]map[string]map[string]any
+}
+
+// Get returns the inserted row with the given label, keyed by column name,
+// including values filled in by the database such as generated IDs. It
+// returns nil if there is no such fixture.
+func (f *Fixtures) Get(table, label string) map[string]any {
+	return f.rows[table][label]
+}
+
+// ID returns the primary key of the inserted row with the given label; for a
+// composite key, the value of its first column.
+func (f *Fixtures) ID(table, label string) any {
+	row := f.rows[table][label]
+	if row == nil {
+		return nil
+	}
+	meta, err := registry.GetByName(table)
+	if err != nil || len(meta.PrimaryKeyColumns()) == 0 {
+		return nil
+	}
+	return row[meta.PrimaryKeyColumns()[0]]
+}
+
+// fixture is one labeled row of a fixture file.
+type fixture struct {
+	table  *schema.TableMetadata
+	label  string
+	fields map[string]any
+}
+
+// LoadFixtures inserts the rows described by the YAML (or JSON) files in dir
+// of fsys. Each file is named after a registered table and maps row labels
+// to field values; keys are column names or Go field names:
+//
+//	# fixtures/users.yml
+//	alice:
+//	  name: Alice
+//	  email: alice@example.com
+//
+//	# fixtures/posts.yml
+//	hello:
+//	  title: Hello, world
+//	  author: alice
+//
+// A belongsTo relationship field (Author above) takes the label of a row in
+// the target table and sets the foreign key to that row's referenced column,
+// including values the database generates. Tables are inserted in foreign
+// key dependency order, and referenced rows before the rows referencing them.
+func LoadFixtures(ctx context.Context, db Querier, fsys fs.FS, dir string) (*Fixtures, error) {
+	fixtures, err := readFixtures(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	loaded := &Fixtures{rows: make(map[string]map[string]map[string]any)}
+	loading := make(map[*fixture]bool)
+
+	var insert func(f *fixture) error
+	insert = func(f *fixture) error {
+		table := f.table.QualifiedName()
+		if _, done := loaded.rows[table][f.label]; done {
+			return nil
+		}
+		if loading[f] {
+			return fmt.Errorf("fixture %s.%s: circular reference", table, f.label)
+		}
+		loading[f] = true
+
+		values := make(map[string]any, len(f.fields))
+		for key, value := range f.fields {
+			if col := column(f.table, key); col != nil {
+				values[col.Name] = columnValue(col, value)
+				continue
+			}
+			rel := belongsTo(f.table, key)
+			if rel == nil {
+				return fmt.Errorf("fixture %s.%s: %s is neither a column nor a belongsTo relationship", table, f.label, key)
+			}
+			target, err := relationshipTarget(rel)
+			if err != nil {
+				return fmt.Errorf("fixture %s.%s: %w", table, f.label, err)
+			}
+			label, ok := value.(string)
+			ref := fixtures[target.QualifiedName()][label]
+			if !ok || ref == nil {
+				return fmt.Errorf("fixture %s.%s: %s references unknown %s fixture %v", table, f.label, key, target.QualifiedName(), value)
+			}
+			if err := insert(ref); err != nil {
+				return err
+			}
+			values[rel.ForeignKey] = loaded.rows[target.QualifiedName()][label][rel.References]
+		}
+
+		row, err := insertRow(ctx, db, f.table, values)
+		if err != nil {
+			return fmt.Errorf("fixture %s.%s: %w", table, f.label, err)
+		}
+		if loaded.rows[table] == nil {
+			loaded.rows[table] = make(map[string]map[string]any)
+		}
+		loaded.rows[table][f.label] = row
+		return nil
+	}
+
+	for _, table := range dependencyOrder(fixtures) {
+		for _, f := range fixtures[table].ordered() {
+			if err := insert(f); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return loaded, nil
+}
+
+// tableFixtures holds the fixtures of one table by label.
+type tableFixtures map[string]*fixture
+
+// ordered returns the fixtures sorted by label, so inserts are repeatable.
+func (t tableFixtures) ordered() []*fixture {
+	out := make([]*fixture, 0, len(t))
+	for _, f := range t {
+		out = append(out, f)
+	}
+	slices.SortFunc(out, func(a, b *fixture) int { return strings.Compare(a.label, b.label) })
+	return out
+}
+
+// readFixtures parses every .yml, .yaml and .json file in dir, keyed by
+// qualified table name.
+func readFixtures(fsys fs.FS, dir string) (map[string]tableFixtures, error) {
+	dir = path.Clean(strings.TrimSuffix(dir, "/"))
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixtures directory: %w", err)
+	}
+
+	fixtures := make(map[string]tableFixtures)
+	for _, entry := range entries {
+		ext := path.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".yml" && ext != ".yaml" && ext != ".json") {
+			continue
+		}
+		table, err := registry.GetByName(strings.TrimSuffix(entry.Name(), ext))
+		if err != nil {
+			return nil, fmt.Errorf("fixture file %s: %w", entry.Name(), err)
+		}
+		if table.IsView() {
+			return nil, fmt.Errorf("fixture file %s: %s is a view", entry.Name(), table.QualifiedName())
+		}
+
+		data, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fixture file: %w", err)
+		}
+		var rows map[string]map[string]any
+		if err := yaml.Unmarshal(data, &rows); err != nil {
+			return nil, fmt.Errorf("failed to parse fixture file %s: %w", entry.Name(), err)
+		}
+
+		name := table.QualifiedName()
+		if fixtures[name] == nil {
+			fixtures[name] = make(tableFixtures)
+		}
+		for label, fields := range rows {
+			if _, dup := fixtures[name][label]; dup {
+				return nil, fmt.Errorf("fixture %s.%s is defined twice", name, label)
+			}
+			fixtures[name][label] = &fixture{table: table, label: label, fields: fields}
+		}
+	}
+	return fixtures, nil
+}
+
+// dependencyOrder sorts the fixture tables so referenced tables come first.
+// Tables in a foreign key cycle keep alphabetical order; rows that reference
+// each other through relationship fields are still ordered by LoadFixtures.
+func dependencyOrder(fixtures map[string]tableFixtures) []string {
+	names := make([]string, 0, len(fixtures))
+	for name := range fixtures {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var order []string
+	visited := make(map[string]bool)
+	var visit func(name string)
+	visit = func(name string) {
+		if visited[name] {
+			return
+		}
+		visited[name] = true
+		for _, dep := range dependencies(fixtures[name]) {
+			if _, ok := fixtures[dep]; ok && dep != name {
+				visit(dep)
+			}
+		}
+		order = append(order, name)
+	}
+	for _, name := range names {
+		visit(name)
+	}
+	return order
+}
+
+// dependencies returns the tables a fixture table references through foreign
+// keys and belongsTo relationships.
+func dependencies(fixtures tableFixtures) []string {
+	var table *schema.TableMetadata
+	for _, f := range fixtures {
+		table = f.table
+		break
+	}
+	if table == nil {
+		return nil
+	}
+
+	var deps []string
+	for _, fk := range table.ForeignKeys {
+		if ref, err := registry.GetByName(fk.ReferencedTable); err == nil {
+			deps = append(deps, ref.QualifiedName())
+		}
+	}
+	for i := range table.Relationships {
+		if table.Relationships[i].Type != schema.BelongsTo {
+			continue
+		}
+		if target, err := relationshipTarget(&table.Relationships[i]); err == nil {
+			deps = append(deps, target.QualifiedName())
+		}
+	}
+	slices.Sort(deps)
+	return slices.Compact(deps)
+}
+
+// column finds a column by database name or Go field name.
+func column(table *schema.TableMetadata, key string) *schema.ColumnMetadata {
+	if col := table.GetColumnByName(key); col != nil {
+		return col
+	}
+	return table.GetColumnByField(key)
+}
+
+// belongsTo finds a belongsTo relationship by Go field name, ignoring case.
+func belongsTo(table *schema.TableMetadata, key string) *schema.RelationshipMetadata {
+	for i, rel := range table.Relationships {
+		if rel.Type == schema.BelongsTo && strings.EqualFold(rel.SourceField, key) {
+			return &table.Relationships[i]
+		}
+	}
+	return nil
+}
+
+// relationshipTarget resolves the table a relationship points to.
+func relationshipTarget(rel *schema.RelationshipMetadata) (*schema.TableMetadata, error) {
+	if rel.TargetType != nil {
+		return registry.Get(rel.TargetType)
+	}
+	return registry.GetByName(rel.TargetTable)
+}
+
+// columnValue converts a decoded fixture value for a column: maps and lists
+// are encoded as JSON for json and jsonb columns.
+func columnValue(col *schema.ColumnMetadata, value any) any {
+	switch value.(type) {
+	case map[string]any, []any:
+		if col.IsJSONB || strings.HasPrefix(col.SQLType, "json") {
+			if data, err := json.Marshal(value); err == nil {
+				return string(data)
+			}
+		}
+	}
+	return value
+}
+
+// insertRow inserts a row and returns every column of the inserted row, so
+// database defaults are available to referencing fixtures.
+func insertRow(ctx context.Context, db Querier, table *schema.TableMetadata, values map[string]any) (map[string]any, error) {
+	columns := make([]string, 0, len(values))
+	for name := range values {
+		columns = append(columns, name)
+	}
+	slices.Sort(columns)
+
+	quoted := make([]string, len(columns))
+	placeholders := make([]string, len(columns))
+	args := make([]any, len(columns))
+	for i, name := range columns {
+		quoted[i] = schema.QuoteReservedIdent(name)
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = values[name]
+	}
+
+	returning := make([]string, len(table.Columns))
+	dest := make([]any, len(table.Columns))
+	scanned := make([]any, len(table.Columns))
+	for i, col := range table.Columns {
+		returning[i] = schema.QuoteReservedIdent(col.Name)
+		dest[i] = &scanned[i]
+	}
+
+	sql := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) RETURNING %s",
+		schema.QuoteQualifiedIdent(table.QualifiedName()),
+		strings.Join(quoted, ", "), strings.Join(placeholders, ", "), strings.Join(returning, ", "))
+	if len(columns) == 0 {
+		sql = fmt.Sprintf("INSERT INTO %s DEFAULT VALUES RETURNING %s",
+			schema.QuoteQualifiedIdent(table.QualifiedName()), strings.Join(returning, ", "))
+	}
+	if err := db.QueryRow(ctx, sql, args...).Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	row := make(map[string]any, len(table.Columns))
+	for i, col := range table.Columns {
+		row[col.Name] = scanned[i]
+	}
+	return row, nil
+}
@@ -0,0 +1,147 @@
+package pebbletest
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/marshallshelly/pebble-orm/pkg/registry"
+)
+
+// table_name: users
+type User struct {
+	ID    int64  `po:"id,primaryKey,bigserial"`
+	Name  string `po:"name,text,notNull"`
+	Email string `po:"email,text,notNull"`
+}
+
+// table_name: posts
+type Post struct {
+	ID       int64          `po:"id,primaryKey,bigserial"`
+	Title    string         `po:"title,text,notNull"`
+	AuthorID int64          `po:"author_id,bigint,notNull"`
+	Meta     map[string]any `po:"meta,jsonb"`
+	Author   *User          `po:"-,belongsTo,foreignKey(author_id),references(id)"`
+}
+
+// fakeDB records INSERTs and returns rows with sequential IDs.
+type fakeDB struct {
+	sql  []string
+	args [][]any
+}
+
+func (db *fakeDB) QueryRow(_ context.Context, sql string, args ...any) pgx.Row {
+	db.sql = append(db.sql, sql)
+	db.args = append(db.args, args)
+	return fakeRow{id: int64(len(db.sql)), args: args}
+}
+
+type fakeRow struct {
+	id   int64
+	args []any
+}
+
+// Scan sets the first column (id) to the row's sequence number and the rest
+// to the inserted values, in order.
+func (r fakeRow) Scan(dest ...any) error {
+	*dest[0].(*any) = r.id
+	for i := 1; i < len(dest) && i-1 < len(r.args); i++ {
+		*dest[i].(*any) = r.args[i-1]
+	}
+	return nil
+}
+
+func registerFixtureModels(t *testing.T) {
+	t.Helper()
+	registry.Clear()
+	t.Cleanup(registry.Clear)
+	for _, model := range []any{User{}, Post{}} {
+		if err := registry.Register(model); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLoadFixtures(t *testing.T) {
+	registerFixtureModels(t)
+	fsys := fstest.MapFS{
+		"fixtures/posts.yml": {Data: []byte(`
+hello:
+  title: Hello
+  author: alice
+  meta: {tags: [intro]}
+`)},
+		"fixtures/users.yml": {Data: []byte(`
+alice:
+  name: Alice
+  Email: alice@example.com
+`)},
+		"fixtures/README.md": {Data: []byte("ignored")},
+	}
+
+	db := &fakeDB{}
+	fixtures, err := LoadFixtures(context.Background(), db, fsys, "fixtures/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(db.sql) != 2 {
+		t.Fatalf("expected 2 inserts, got %d: %v", len(db.sql), db.sql)
+	}
+	if !strings.HasPrefix(db.sql[0], "INSERT INTO users (email, name)") {
+		t.Errorf("expected users to be inserted first, got %s", db.sql[0])
+	}
+	if !strings.HasPrefix(db.sql[1], "INSERT INTO posts (author_id, meta, title)") {
+		t.Errorf("unexpected posts insert: %s", db.sql[1])
+	}
+	if got := db.args[1][0]; got != int64(1) {
+		t.Errorf("expected author_id to be alice's generated id 1, got %v", got)
+	}
+	if got := db.args[1][1]; got != `{"tags":["intro"]}` {
+		t.Errorf("expected meta to be encoded as JSON, got %v", got)
+	}
+	if got := fixtures.ID("posts", "hello"); got != int64(2) {
+		t.Errorf("expected post id 2, got %v", got)
+	}
+	if fixtures.Get("users", "alice") == nil {
+		t.Error("expected alice to be loaded")
+	}
+}
+
+func TestLoadFixturesErrors(t *testing.T) {
+	registerFixtureModels(t)
+	tests := []struct {
+		name  string
+		files fstest.MapFS
+		want  string
+	}{
+		{
+			name:  "unknown table",
+			files: fstest.MapFS{"fixtures/comments.yml": {Data: []byte("a: {body: x}")}},
+			want:  "comments not registered",
+		},
+		{
+			name:  "unknown field",
+			files: fstest.MapFS{"fixtures/users.yml": {Data: []byte("alice: {nickname: al}")}},
+			want:  "nickname is neither a column nor a belongsTo relationship",
+		},
+		{
+			name: "unknown label",
+			files: fstest.MapFS{
+				"fixtures/users.yml": {Data: []byte("alice: {name: Alice}")},
+				"fixtures/posts.yml": {Data: []byte("hello: {title: Hello, author: bob}")},
+			},
+			want: "references unknown users fixture bob",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadFixtures(context.Background(), &fakeDB{}, tt.files, "fixtures")
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
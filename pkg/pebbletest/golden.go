@@ -0,0 +1,100 @@
+package pebbletest
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// update is namespaced so it doesn't clash with an -update flag of the
+// package under test.
+var update = flag.Bool("pebbletest.update", false, "pebbletest: rewrite the golden files of AssertSQL")
+
+// updating reports whether AssertSQL should rewrite golden files: with
+// -pebbletest.update, or with PEBBLE_UPDATE_GOLDEN set for go test runs
+// across packages that don't all link pebbletest.
+func updating() bool {
+	return *update || os.Getenv("PEBBLE_UPDATE_GOLDEN") != ""
+}
+
+// SQLer is a query that renders its SQL, such as a *builder.SelectQuery or
+// *builder.UpdateQuery.
+type SQLer interface {
+	ToSQL() (string, []any, error)
+}
+
+// AssertSQL fails the test unless the SQL and arguments of query match the
+// golden file, so changes to a query show up in review:
+//
+//	q := builder.Select[User](db).Where(builder.Eq("active", true)).OrderByDesc("created_at")
+//	pebbletest.AssertSQL(t, q, "testdata/list_users.sql")
+//
+// Run the tests with -pebbletest.update, or PEBBLE_UPDATE_GOLDEN=1, to write
+// the golden files. The builder renders
+// SET and ON CONFLICT columns in name order and INSERT columns in model
+// order, so the same query always produces the same file.
+func AssertSQL(t testing.TB, query SQLer, golden string) {
+	t.Helper()
+	sql, args, err := query.ToSQL()
+	if err != nil {
+		t.Errorf("pebbletest: %v", err)
+		return
+	}
+	got := renderSQL(sql, args)
+
+	if updating() {
+		if err := os.MkdirAll(filepath.Dir(golden), 0o755); err != nil {
+			t.Errorf("pebbletest: %v", err)
+			return
+		}
+		if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+			t.Errorf("pebbletest: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(golden)
+	if errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("pebbletest: golden file %s does not exist; run the test with -pebbletest.update to create it", golden)
+		return
+	}
+	if err != nil {
+		t.Errorf("pebbletest: %v", err)
+		return
+	}
+	if got != string(want) {
+		t.Errorf("pebbletest: SQL does not match %s (run with -pebbletest.update to accept it)\ngot:\n%swant:\n%s", golden, got, want)
+	}
+}
+
+// renderSQL formats a statement for a golden file: the SQL, then a comment
+// line with the type and value of each argument.
+func renderSQL(sql string, args []any) string {
+	var b strings.Builder
+	b.WriteString(sql)
+	b.WriteString("\n")
+	for i, arg := range args {
+		fmt.Fprintf(&b, "-- $%d %s\n", i+1, formatArg(arg))
+	}
+	return b.String()
+}
+
+func formatArg(arg any) string {
+	switch v := arg.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return fmt.Sprintf("string %q", v)
+	case []byte:
+		return fmt.Sprintf("[]byte %q", v)
+	case time.Time:
+		return "time.Time " + v.Format(time.RFC3339Nano)
+	}
+	return fmt.Sprintf("%T %v", arg, arg)
+}
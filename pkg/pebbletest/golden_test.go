@@ -0,0 +1,81 @@
+package pebbletest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marshallshelly/pebble-orm/pkg/builder"
+)
+
+func TestAssertSQL(t *testing.T) {
+	registerFixtureModels(t)
+	golden := filepath.Join(t.TempDir(), "testdata", "update_user.sql")
+	query := builder.Update[User](builder.NewWithExecutor(NewMockDB())).
+		SetMap(map[string]any{"name": "Alice", "email": "alice@example.com"}).
+		Where(builder.Eq("id", int64(1)))
+
+	// Missing golden files fail and say how to create them
+	r := &recorder{TB: t}
+	AssertSQL(r, query, golden)
+	if len(r.errors) != 1 || !strings.Contains(r.errors[0], "-pebbletest.update") {
+		t.Fatalf("expected a failure suggesting -pebbletest.update, got %v", r.errors)
+	}
+
+	*update = true
+	AssertSQL(t, query, golden)
+	*update = false
+	data, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `UPDATE users SET email = $1, name = $2 WHERE id = $3
+-- $1 string "alice@example.com"
+-- $2 string "Alice"
+-- $3 int64 1
+`
+	if string(data) != want {
+		t.Errorf("golden file is\n%s\nwant\n%s", data, want)
+	}
+
+	AssertSQL(t, query, golden)
+
+	r = &recorder{TB: t}
+	AssertSQL(r, query.Where(builder.Eq("name", "Bob")), golden)
+	if len(r.errors) != 1 || !strings.Contains(r.errors[0], "does not match") {
+		t.Errorf("expected a mismatch, got %v", r.errors)
+	}
+}
+
+func TestAssertSQLUpdateEnv(t *testing.T) {
+	registerFixtureModels(t)
+	golden := filepath.Join(t.TempDir(), "delete_user.sql")
+	query := builder.Delete[User](builder.NewWithExecutor(NewMockDB())).Where(builder.Eq("id", int64(1)))
+
+	t.Setenv("PEBBLE_UPDATE_GOLDEN", "1")
+	AssertSQL(t, query, golden)
+	if _, err := os.Stat(golden); err != nil {
+		t.Fatalf("expected PEBBLE_UPDATE_GOLDEN to write the golden file: %v", err)
+	}
+}
+
+func TestFormatArg(t *testing.T) {
+	tests := []struct {
+		arg  any
+		want string
+	}{
+		{nil, "NULL"},
+		{"a\nb", `string "a\nb"`},
+		{[]byte("x"), `[]byte "x"`},
+		{int32(5), "int32 5"},
+		{time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), "time.Time 2024-01-02T03:04:05Z"},
+		{[]string{"a", "b"}, "[]string [a b]"},
+	}
+	for _, tt := range tests {
+		if got := formatArg(tt.arg); got != tt.want {
+			t.Errorf("formatArg(%#v) = %s, want %s", tt.arg, got, tt.want)
+		}
+	}
+}
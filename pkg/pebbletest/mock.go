@@ -0,0 +1,453 @@
+package pebbletest
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/marshallshelly/pebble-orm/pkg/builder"
+	"github.com/marshallshelly/pebble-orm/pkg/registry"
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+)
+
+// Call is a statement run on a MockDB.
+type Call struct {
+	SQL  string
+	Args []any
+}
+
+// MockDB is a builder.Executor for unit tests that need no database. It
+// records every statement and answers them with results queued by
+// ReturnRows, ReturnModels, ReturnRowsAffected and ReturnError, one result
+// per statement in order:
+//
+//	mock := pebbletest.NewMockDB()
+//	mock.ReturnModels([]User{{ID: 1, Name: "Alice"}})
+//	users, err := builder.Select[User](mock.Builder()).Where(builder.Eq("name", "Alice")).All(ctx)
+//	// mock.LastCall().SQL is the SELECT, mock.LastCall().Args is ["Alice"]
+//
+// Once the queue is empty, queries return no rows, QueryRow returns
+// pgx.ErrNoRows and Exec affects no rows. Transactions record BEGIN, COMMIT
+// and ROLLBACK and share the queue. A MockDB is safe for concurrent use, but
+// the order concurrent statements take results in is not defined.
+type MockDB struct {
+	mu      sync.Mutex
+	calls   []Call
+	results []mockResult
+}
+
+type mockResult struct {
+	columns      []string
+	rows         [][]any
+	rowsAffected int64
+	err          error
+}
+
+// NewMockDB returns a MockDB with nothing queued.
+func NewMockDB() *MockDB {
+	return &MockDB{}
+}
+
+// Builder returns a query builder DB running its statements on m.
+func (m *MockDB) Builder() *builder.DB {
+	return builder.NewWithExecutor(m)
+}
+
+// ReturnRows queues a result set with the named columns. Values are scanned
+// as a driver would return them; a nil value is NULL.
+func (m *MockDB) ReturnRows(columns []string, rows ...[]any) *MockDB {
+	return m.push(mockResult{columns: columns, rows: rows, rowsAffected: int64(len(rows))})
+}
+
+// ReturnModels queues a result set of a model, a pointer to one or a slice
+// of them, with a column for each column of the model's table.
+func (m *MockDB) ReturnModels(models any) *MockDB {
+	v := reflect.ValueOf(models)
+	for v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		v = reflect.Append(reflect.MakeSlice(reflect.SliceOf(v.Type()), 0, 1), v)
+	}
+	elemType := v.Type().Elem()
+	for elemType.Kind() == reflect.Pointer {
+		elemType = elemType.Elem()
+	}
+	table, err := registry.GetOrRegister(reflect.New(elemType).Elem().Interface())
+	if err != nil {
+		return m.ReturnError(fmt.Errorf("pebbletest: ReturnModels: %w", err))
+	}
+
+	var columns []*schema.ColumnMetadata
+	for i := range table.Columns {
+		if table.Columns[i].GoField != "" {
+			columns = append(columns, &table.Columns[i])
+		}
+	}
+	names := make([]string, len(columns))
+	for i, col := range columns {
+		names[i] = col.Name
+	}
+	rows := make([][]any, v.Len())
+	for r := range rows {
+		model := reflect.Indirect(v.Index(r))
+		row := make([]any, len(columns))
+		for i, col := range columns {
+			value, err := driverValue(fieldByPath(model, col.GoField))
+			if err != nil {
+				return m.ReturnError(fmt.Errorf("pebbletest: ReturnModels: column %s: %w", col.Name, err))
+			}
+			row[i] = value
+		}
+		rows[r] = row
+	}
+	return m.ReturnRows(names, rows...)
+}
+
+// ReturnRowsAffected queues the result of a statement that affects n rows
+// and returns none.
+func (m *MockDB) ReturnRowsAffected(n int64) *MockDB {
+	return m.push(mockResult{rowsAffected: n})
+}
+
+// ReturnError queues err as the result of a statement, such as a
+// *pgconn.PgError to test how constraint violations are handled.
+func (m *MockDB) ReturnError(err error) *MockDB {
+	return m.push(mockResult{err: err})
+}
+
+func (m *MockDB) push(r mockResult) *MockDB {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.results = append(m.results, r)
+	return m
+}
+
+// Calls returns the statements run so far, in order.
+func (m *MockDB) Calls() []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Call(nil), m.calls...)
+}
+
+// LastCall returns the last statement run, or a zero Call if there is none.
+func (m *MockDB) LastCall() Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.calls) == 0 {
+		return Call{}
+	}
+	return m.calls[len(m.calls)-1]
+}
+
+// Reset forgets the recorded statements and any queued results.
+func (m *MockDB) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls, m.results = nil, nil
+}
+
+// next records a statement and takes the result it gets.
+func (m *MockDB) next(sql string, args []any) mockResult {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, Call{SQL: sql, Args: append([]any(nil), args...)})
+	if len(m.results) == 0 {
+		return mockResult{}
+	}
+	r := m.results[0]
+	m.results = m.results[1:]
+	return r
+}
+
+// Query records sql and returns the next queued result's rows.
+func (m *MockDB) Query(_ context.Context, sql string, args ...any) (pgx.Rows, error) {
+	r := m.next(sql, args)
+	if r.err != nil {
+		return nil, r.err
+	}
+	return newMockRows(r), nil
+}
+
+// QueryRow records sql and returns the first row of the next queued result,
+// or pgx.ErrNoRows if it has none.
+func (m *MockDB) QueryRow(_ context.Context, sql string, args ...any) pgx.Row {
+	r := m.next(sql, args)
+	return mockRow{r}
+}
+
+// Exec records sql and returns the next queued result's rows affected.
+func (m *MockDB) Exec(_ context.Context, sql string, args ...any) (int64, error) {
+	r := m.next(sql, args)
+	return r.rowsAffected, r.err
+}
+
+// BeginTx records BEGIN and returns a transaction running its statements
+// on m.
+func (m *MockDB) BeginTx(_ context.Context, _ pgx.TxOptions) (pgx.Tx, error) {
+	if r := m.next("BEGIN", nil); r.err != nil {
+		return nil, r.err
+	}
+	return &mockTx{db: m}, nil
+}
+
+// mockTx is a pgx.Tx on a MockDB. CopyFrom, SendBatch, LargeObjects, Prepare
+// and Conn are not supported and panic.
+type mockTx struct {
+	pgx.Tx
+	db     *MockDB
+	depth  int // savepoint nesting, 0 for the transaction itself
+	closed bool
+}
+
+func (t *mockTx) Begin(_ context.Context) (pgx.Tx, error) {
+	if t.closed {
+		return nil, pgx.ErrTxClosed
+	}
+	if r := t.db.next(savepoint("SAVEPOINT", t.depth+1), nil); r.err != nil {
+		return nil, r.err
+	}
+	return &mockTx{db: t.db, depth: t.depth + 1}, nil
+}
+
+func (t *mockTx) Commit(_ context.Context) error {
+	if t.closed {
+		return pgx.ErrTxClosed
+	}
+	t.closed = true
+	if t.depth > 0 {
+		return t.db.next(savepoint("RELEASE SAVEPOINT", t.depth), nil).err
+	}
+	return t.db.next("COMMIT", nil).err
+}
+
+func (t *mockTx) Rollback(_ context.Context) error {
+	if t.closed {
+		return pgx.ErrTxClosed
+	}
+	t.closed = true
+	if t.depth > 0 {
+		return t.db.next(savepoint("ROLLBACK TO SAVEPOINT", t.depth), nil).err
+	}
+	return t.db.next("ROLLBACK", nil).err
+}
+
+func savepoint(verb string, depth int) string {
+	return fmt.Sprintf("%s sp_%d", verb, depth)
+}
+
+func (t *mockTx) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	n, err := t.db.Exec(ctx, sql, args...)
+	if err != nil {
+		return pgconn.CommandTag{}, err
+	}
+	return pgconn.NewCommandTag(fmt.Sprintf("%s %d", commandOf(sql), n)), nil
+}
+
+func (t *mockTx) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return t.db.Query(ctx, sql, args...)
+}
+
+func (t *mockTx) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return t.db.QueryRow(ctx, sql, args...)
+}
+
+// commandOf returns the command of a command tag for sql, such as UPDATE.
+func commandOf(sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return "SELECT"
+	}
+	cmd := strings.ToUpper(fields[0])
+	if cmd == "INSERT" {
+		return "INSERT 0" // the tag carries an OID first
+	}
+	return cmd
+}
+
+// mockRows serves the rows of a queued result.
+type mockRows struct {
+	result mockResult
+	fields []pgconn.FieldDescription
+	i      int
+	err    error
+}
+
+func newMockRows(r mockResult) *mockRows {
+	fields := make([]pgconn.FieldDescription, len(r.columns))
+	for i, name := range r.columns {
+		fields[i].Name = name
+	}
+	return &mockRows{result: r, fields: fields}
+}
+
+func (r *mockRows) Close()     { r.i = len(r.result.rows) + 1 }
+func (r *mockRows) Err() error { return r.err }
+func (r *mockRows) Conn() *pgx.Conn {
+	return nil
+}
+
+func (r *mockRows) CommandTag() pgconn.CommandTag {
+	return pgconn.NewCommandTag(fmt.Sprintf("SELECT %d", r.result.rowsAffected))
+}
+
+func (r *mockRows) FieldDescriptions() []pgconn.FieldDescription { return r.fields }
+
+func (r *mockRows) Next() bool {
+	if r.err != nil || r.i > len(r.result.rows) {
+		return false
+	}
+	r.i++
+	return r.i <= len(r.result.rows)
+}
+
+func (r *mockRows) Scan(dest ...any) error {
+	if r.i < 1 || r.i > len(r.result.rows) {
+		return fmt.Errorf("pebbletest: Scan called without a row")
+	}
+	if err := scanRow(r.result.rows[r.i-1], dest); err != nil {
+		r.err = err
+		r.Close()
+		return err
+	}
+	return nil
+}
+
+func (r *mockRows) Values() ([]any, error) {
+	if r.i < 1 || r.i > len(r.result.rows) {
+		return nil, fmt.Errorf("pebbletest: Values called without a row")
+	}
+	return append([]any(nil), r.result.rows[r.i-1]...), nil
+}
+
+func (r *mockRows) RawValues() [][]byte { return nil }
+
+// mockRow is the first row of a queued result, for QueryRow.
+type mockRow struct{ result mockResult }
+
+func (r mockRow) Scan(dest ...any) error {
+	if r.result.err != nil {
+		return r.result.err
+	}
+	if len(r.result.rows) == 0 {
+		return pgx.ErrNoRows
+	}
+	return scanRow(r.result.rows[0], dest)
+}
+
+// scanRow assigns the values of row to dest as pgx does: nil targets are
+// skipped, sql.Scanners scan the value, and other targets must be pointers
+// to a type the value converts to.
+func scanRow(row []any, dest []any) error {
+	if len(dest) != len(row) {
+		return fmt.Errorf("pebbletest: %d scan targets for %d columns", len(dest), len(row))
+	}
+	for i, target := range dest {
+		if target == nil {
+			continue
+		}
+		if scanner, ok := target.(sql.Scanner); ok {
+			if err := scanner.Scan(row[i]); err != nil {
+				return fmt.Errorf("pebbletest: column %d: %w", i, err)
+			}
+			continue
+		}
+		ptr := reflect.ValueOf(target)
+		if ptr.Kind() != reflect.Pointer || ptr.IsNil() {
+			return fmt.Errorf("pebbletest: column %d: scan target %T is not a pointer", i, target)
+		}
+		if err := assign(ptr.Elem(), row[i]); err != nil {
+			return fmt.Errorf("pebbletest: column %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// assign sets dst to src, converting between numeric types, between strings
+// and byte slices, and to pointers. A nil src sets the zero value.
+func assign(dst reflect.Value, src any) error {
+	if src == nil {
+		dst.SetZero()
+		return nil
+	}
+	v := reflect.ValueOf(src)
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			dst.SetZero()
+			return nil
+		}
+		if v.Type().AssignableTo(dst.Type()) {
+			break
+		}
+		v = v.Elem()
+	}
+	switch {
+	case v.Type().AssignableTo(dst.Type()):
+		dst.Set(v)
+	case dst.Kind() == reflect.Pointer:
+		elem := reflect.New(dst.Type().Elem())
+		if err := assign(elem.Elem(), v.Interface()); err != nil {
+			return err
+		}
+		dst.Set(elem)
+	case convertible(v.Type(), dst.Type()):
+		dst.Set(v.Convert(dst.Type()))
+	default:
+		return fmt.Errorf("cannot scan %T into %s", src, dst.Type())
+	}
+	return nil
+}
+
+// convertible reports whether assign converts from to to: Go converts
+// integers to strings too, as runes, which a driver never does.
+func convertible(from, to reflect.Type) bool {
+	if !from.ConvertibleTo(to) {
+		return false
+	}
+	return isNumber(from.Kind()) == isNumber(to.Kind())
+}
+
+func isNumber(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// fieldByPath returns the field at a dotted path of embedded struct fields,
+// as ColumnMetadata.GoField names it.
+func fieldByPath(v reflect.Value, path string) reflect.Value {
+	for name := range strings.SplitSeq(path, ".") {
+		v = v.FieldByName(name)
+	}
+	return v
+}
+
+// driverValue returns the value a driver would scan for a field: the
+// encoding of its codec or driver.Valuer, or the field's value.
+func driverValue(field reflect.Value) (any, error) {
+	if codec, ok := schema.LookupCodec(field.Type()); ok {
+		return codec.Encode(field.Interface())
+	}
+	if field.Kind() == reflect.Pointer {
+		if field.IsNil() {
+			return nil, nil
+		}
+		if codec, ok := schema.LookupCodec(field.Type().Elem()); ok {
+			return codec.Encode(field.Elem().Interface())
+		}
+	}
+	if valuer, ok := field.Interface().(driver.Valuer); ok {
+		return valuer.Value()
+	}
+	return field.Interface(), nil
+}
@@ -0,0 +1,144 @@
+package pebbletest
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/marshallshelly/pebble-orm/pkg/builder"
+)
+
+func TestMockDBSelect(t *testing.T) {
+	registerFixtureModels(t)
+	ctx := context.Background()
+	mock := NewMockDB()
+	mock.ReturnModels([]Post{
+		{ID: 1, Title: "Hello", AuthorID: 7, Meta: map[string]any{"draft": true}},
+		{ID: 2, Title: "Again", AuthorID: 7},
+	})
+
+	posts, err := builder.Select[Post](mock.Builder()).Where(builder.Eq("author_id", int64(7))).All(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Post{
+		{ID: 1, Title: "Hello", AuthorID: 7, Meta: map[string]any{"draft": true}},
+		{ID: 2, Title: "Again", AuthorID: 7},
+	}
+	if !reflect.DeepEqual(posts, want) {
+		t.Errorf("got %+v, want %+v", posts, want)
+	}
+
+	call := mock.LastCall()
+	if !strings.Contains(call.SQL, "WHERE author_id = $1") {
+		t.Errorf("unexpected SQL %q", call.SQL)
+	}
+	if !reflect.DeepEqual(call.Args, []any{int64(7)}) {
+		t.Errorf("got args %v, want [7]", call.Args)
+	}
+
+	// With nothing queued, queries find no rows
+	if _, err := builder.Select[Post](mock.Builder()).First(ctx); err == nil {
+		t.Error("expected an error from First with no rows")
+	}
+	if n := len(mock.Calls()); n != 2 {
+		t.Errorf("got %d calls, want 2", n)
+	}
+}
+
+func TestMockDBWrites(t *testing.T) {
+	registerFixtureModels(t)
+	ctx := context.Background()
+	mock := NewMockDB()
+	qb := mock.Builder()
+
+	mock.ReturnRowsAffected(1)
+	n, err := builder.Insert[User](qb).Values(User{Name: "Alice", Email: "alice@example.com"}).Exec(ctx)
+	if err != nil || n != 1 {
+		t.Fatalf("got %d, %v; want 1 row", n, err)
+	}
+	if sql := mock.LastCall().SQL; !strings.HasPrefix(sql, "INSERT INTO users") {
+		t.Errorf("unexpected SQL %q", sql)
+	}
+
+	mock.ReturnError(&pgconn.PgError{Code: "23505", ConstraintName: "users_email_key"})
+	_, err = builder.Insert[User](qb).Values(User{Name: "Alice", Email: "alice@example.com"}).Exec(ctx)
+	if !builder.IsUniqueViolation(err, "users_email_key") {
+		t.Errorf("expected a unique violation, got %v", err)
+	}
+
+	mock.ReturnRows([]string{"id", "name", "email"}, []any{int32(5), "Bob", nil})
+	users, err := builder.Insert[User](qb).Values(User{Name: "Bob"}).ExecReturning(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []User{{ID: 5, Name: "Bob"}}; !reflect.DeepEqual(users, want) {
+		t.Errorf("got %+v, want %+v", users, want)
+	}
+}
+
+func TestMockDBTransaction(t *testing.T) {
+	registerFixtureModels(t)
+	ctx := context.Background()
+	mock := NewMockDB()
+
+	tx, err := mock.Builder().Begin(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock.ReturnRowsAffected(3)
+	n, err := builder.TxUpdate[User](tx).Set("name", "x").Where(builder.Eq("id", 1)).Exec()
+	if err != nil || n != 3 {
+		t.Fatalf("got %d, %v; want 3 rows", n, err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Rollback(); !errors.Is(err, pgx.ErrTxClosed) {
+		t.Errorf("got %v from Rollback after Commit, want ErrTxClosed", err)
+	}
+
+	var got []string
+	for _, call := range mock.Calls() {
+		got = append(got, strings.Fields(call.SQL)[0])
+	}
+	if want := []string{"BEGIN", "UPDATE", "COMMIT"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got statements %v, want %v", got, want)
+	}
+}
+
+func TestScanRow(t *testing.T) {
+	var (
+		i64  int64
+		name string
+		ptr  *int32
+		raw  []byte
+		anyV any
+	)
+	row := []any{int32(4), "n", int64(9), "bytes", 1.5}
+	if err := scanRow(row, []any{&i64, &name, &ptr, &raw, &anyV}); err != nil {
+		t.Fatal(err)
+	}
+	if i64 != 4 || name != "n" || ptr == nil || *ptr != 9 || string(raw) != "bytes" || anyV != 1.5 {
+		t.Errorf("got %v %q %v %q %v", i64, name, ptr, raw, anyV)
+	}
+
+	if err := scanRow([]any{nil, nil}, []any{&i64, &ptr}); err != nil {
+		t.Fatal(err)
+	}
+	if i64 != 0 || ptr != nil {
+		t.Errorf("NULL should set zero values, got %v %v", i64, ptr)
+	}
+
+	// Drivers never turn numbers into strings
+	if err := scanRow([]any{int64(65)}, []any{&name}); err == nil {
+		t.Error("expected an error scanning a number into a string")
+	}
+	if err := scanRow([]any{1, 2}, []any{&i64}); err == nil {
+		t.Error("expected an error for a target count mismatch")
+	}
+}
@@ -0,0 +1,81 @@
+package pebbletest
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/marshallshelly/pebble-orm/pkg/builder"
+)
+
+// Explainer is a query that can report its plan, such as a
+// *builder.SelectQuery.
+type Explainer interface {
+	Explain(ctx context.Context, opts builder.ExplainOptions) (*builder.ExplainResult, error)
+}
+
+// AssertIndexScan fails the test unless the plan of query scans index:
+//
+//	q := builder.Select[Order](db).Where(builder.Eq("customer_id", id)).And(builder.Eq("status", "open"))
+//	pebbletest.AssertIndexScan(t, q, "idx_orders_customer_status")
+//
+// The query is planned, not executed. PostgreSQL prefers sequential scans of
+// small tables, so insert enough rows (factory.CreateMany) and ANALYZE the
+// table first.
+func AssertIndexScan(t testing.TB, query Explainer, index string) {
+	t.Helper()
+	plan := explain(t, query)
+	if plan != nil && !plan.UsesIndex(index) {
+		t.Errorf("pebbletest: expected the query to use index %s, got plan:\n%s", index, formatPlan(plan))
+	}
+}
+
+// AssertNoSeqScan fails the test if the plan of query reads any of tables,
+// or any table when none are given, with a sequential scan.
+func AssertNoSeqScan(t testing.TB, query Explainer, tables ...string) {
+	t.Helper()
+	plan := explain(t, query)
+	if plan == nil {
+		return
+	}
+	for _, table := range plan.SeqScans() {
+		if len(tables) == 0 || slices.Contains(tables, table) {
+			t.Errorf("pebbletest: expected no sequential scan of %s, got plan:\n%s", table, formatPlan(plan))
+		}
+	}
+}
+
+func explain(t testing.TB, query Explainer) *builder.ExplainResult {
+	t.Helper()
+	plan, err := query.Explain(context.Background(), builder.ExplainOptions{})
+	if err != nil {
+		t.Errorf("pebbletest: %v", err)
+		return nil
+	}
+	return plan
+}
+
+// formatPlan renders a plan as an indented tree of nodes.
+func formatPlan(plan *builder.ExplainResult) string {
+	var b strings.Builder
+	var write func(node *builder.PlanNode, depth int)
+	write = func(node *builder.PlanNode, depth int) {
+		b.WriteString(strings.Repeat("  ", depth) + node.NodeType)
+		if node.IndexName != "" {
+			fmt.Fprintf(&b, " using %s", node.IndexName)
+		}
+		if node.RelationName != "" {
+			fmt.Fprintf(&b, " on %s", node.RelationName)
+		}
+		b.WriteString("\n")
+		for i := range node.Plans {
+			write(&node.Plans[i], depth+1)
+		}
+	}
+	if plan.Plan != nil {
+		write(plan.Plan, 1)
+	}
+	return b.String()
+}
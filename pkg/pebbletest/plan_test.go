@@ -0,0 +1,62 @@
+package pebbletest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/marshallshelly/pebble-orm/pkg/builder"
+)
+
+// planQuery is an Explainer returning a canned plan.
+type planQuery struct{ plan *builder.PlanNode }
+
+func (q planQuery) Explain(context.Context, builder.ExplainOptions) (*builder.ExplainResult, error) {
+	return &builder.ExplainResult{Plan: q.plan}, nil
+}
+
+// recorder is a testing.TB that records failures instead of reporting them.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestPlanAssertions(t *testing.T) {
+	indexScan := planQuery{&builder.PlanNode{
+		NodeType: "Nested Loop",
+		Plans: []builder.PlanNode{
+			{NodeType: "Index Scan", RelationName: "orders", IndexName: "idx_orders_customer_status"},
+			{NodeType: "Seq Scan", RelationName: "customers"},
+		},
+	}}
+
+	tests := []struct {
+		name   string
+		assert func(t testing.TB)
+		fail   string
+	}{
+		{"index used", func(t testing.TB) { AssertIndexScan(t, indexScan, "idx_orders_customer_status") }, ""},
+		{"index not used", func(t testing.TB) { AssertIndexScan(t, indexScan, "idx_orders_created_at") }, "Seq Scan on customers"},
+		{"no seq scan of orders", func(t testing.TB) { AssertNoSeqScan(t, indexScan, "orders") }, ""},
+		{"seq scan of any table", func(t testing.TB) { AssertNoSeqScan(t, indexScan) }, "sequential scan of customers"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &recorder{TB: t}
+			tt.assert(r)
+			switch {
+			case tt.fail == "" && len(r.errors) > 0:
+				t.Errorf("expected no failure, got %v", r.errors)
+			case tt.fail != "" && (len(r.errors) != 1 || !strings.Contains(r.errors[0], tt.fail)):
+				t.Errorf("expected a failure mentioning %q, got %v", tt.fail, r.errors)
+			}
+		})
+	}
+}
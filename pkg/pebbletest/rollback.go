@@ -0,0 +1,37 @@
+package pebbletest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/marshallshelly/pebble-orm/pkg/builder"
+)
+
+// WithRollback runs fn inside a transaction that is always rolled back, even
+// when fn fails the test, so tests can share a database without truncating
+// it between them:
+//
+//	db := builder.New(pebbletest.NewTestDB(t, User{}))
+//	pebbletest.WithRollback(t, db, func(tx *builder.Tx) {
+//		_, err := builder.TxInsert[User](tx).Values(user).Exec()
+//		...
+//	})
+//
+// fn must not commit the transaction; WithRollback fails the test if it did.
+func WithRollback(t testing.TB, db *builder.DB, fn func(tx *builder.Tx)) {
+	t.Helper()
+	tx, err := db.Begin(context.Background())
+	if err != nil {
+		t.Fatalf("pebbletest: %v", err)
+	}
+	defer func() {
+		if err := tx.Rollback(); errors.Is(err, pgx.ErrTxClosed) {
+			t.Errorf("pebbletest: transaction was committed or rolled back inside WithRollback")
+		} else if err != nil {
+			t.Errorf("pebbletest: %v", err)
+		}
+	}()
+	fn(tx)
+}
@@ -0,0 +1,33 @@
+package pebbletest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/marshallshelly/pebble-orm/pkg/builder"
+)
+
+func TestWithRollback(t *testing.T) {
+	db := builder.New(NewTestDB(t, User{}))
+
+	WithRollback(t, db, func(tx *builder.Tx) {
+		if _, err := builder.TxInsert[User](tx).Values(User{Name: "Alice", Email: "alice@example.com"}).Exec(); err != nil {
+			t.Fatal(err)
+		}
+		count, err := builder.TxSelect[User](tx).Count()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != 1 {
+			t.Errorf("expected the insert to be visible inside the transaction, got %d rows", count)
+		}
+	})
+
+	count, err := builder.Select[User](db).Count(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Errorf("expected the insert to be rolled back, got %d rows", count)
+	}
+}
@@ -0,0 +1,167 @@
+package pebbletest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/marshallshelly/pebble-orm/pkg/migration"
+	"github.com/marshallshelly/pebble-orm/pkg/registry"
+	"github.com/marshallshelly/pebble-orm/pkg/runtime"
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// DatabaseURLEnv names the environment variable with a connection string for
+// NewTestDB to use instead of starting a container.
+const DatabaseURLEnv = "DATABASE_URL"
+
+var (
+	containerOnce sync.Once
+	containerURL  string
+	containerErr  error
+
+	// schemaMu serializes table creation by tests in the same binary.
+	schemaMu sync.Mutex
+)
+
+// NewTestDB returns a connection to a PostgreSQL database with a table for
+// each model, emptied of rows, for an integration test:
+//
+//	func TestCreateUser(t *testing.T) {
+//		db := pebbletest.NewTestDB(t, User{}, Post{})
+//		qb := builder.New(db)
+//		...
+//	}
+//
+// It connects to DATABASE_URL when set; otherwise it starts a postgres:alpine
+// container, once per test binary, which is removed when the binary exits.
+// The models are registered, and missing tables are created from their
+// metadata with the migration planner; existing tables are truncated, so
+// tests that share models must not run in parallel. The connection is closed
+// when the test ends. The test is skipped in -short mode.
+func NewTestDB(t testing.TB, models ...any) *runtime.DB {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("pebbletest: skipping database test in short mode")
+	}
+	ctx := context.Background()
+
+	tables := make(map[string]*schema.TableMetadata, len(models))
+	for _, model := range models {
+		table, err := registry.GetOrRegister(model)
+		if err != nil {
+			t.Fatalf("pebbletest: failed to register %T: %v", model, err)
+		}
+		tables[table.QualifiedName()] = table
+	}
+
+	url, err := databaseURL(ctx)
+	if err != nil {
+		t.Fatalf("pebbletest: %v", err)
+	}
+	db, err := runtime.ConnectWithURL(ctx, url)
+	if err != nil {
+		t.Fatalf("pebbletest: failed to connect to database: %v", err)
+	}
+	t.Cleanup(db.Close)
+
+	schemaMu.Lock()
+	defer schemaMu.Unlock()
+	if err := createTables(ctx, db.Pool(), tables); err != nil {
+		t.Fatalf("pebbletest: %v", err)
+	}
+	if err := truncateTables(ctx, db, tables); err != nil {
+		t.Fatalf("pebbletest: %v", err)
+	}
+	return db
+}
+
+// databaseURL returns DATABASE_URL, or the URL of the shared container,
+// starting it on first use.
+func databaseURL(ctx context.Context) (string, error) {
+	if url := os.Getenv(DatabaseURLEnv); url != "" {
+		return url, nil
+	}
+	containerOnce.Do(func() {
+		container, err := postgres.Run(ctx,
+			"postgres:alpine",
+			postgres.WithDatabase("testdb"),
+			postgres.WithUsername("testuser"),
+			postgres.WithPassword("testpass"),
+			testcontainers.WithWaitStrategy(
+				wait.ForLog("database system is ready to accept connections").
+					WithOccurrence(2).
+					WithStartupTimeout(60*time.Second)),
+		)
+		if err != nil {
+			containerErr = fmt.Errorf("failed to start postgres container: %w", err)
+			return
+		}
+		containerURL, err = container.ConnectionString(ctx, "sslmode=disable")
+		if err != nil {
+			containerErr = fmt.Errorf("failed to get connection string: %w", err)
+		}
+	})
+	return containerURL, containerErr
+}
+
+// createTables creates the tables, and the enums, extensions and sequences
+// they need, that are missing from the database. Nothing is altered or
+// dropped.
+func createTables(ctx context.Context, pool *pgxpool.Pool, tables map[string]*schema.TableMetadata) error {
+	introspector := migration.NewIntrospector(pool).WithSchemas(migration.SchemasOf(tables)...)
+	dbSchema, err := introspector.IntrospectSchema(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to introspect database: %w", err)
+	}
+	extensions, err := introspector.IntrospectExtensions(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to introspect extensions: %w", err)
+	}
+	sequences, err := introspector.IntrospectSequences(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to introspect sequences: %w", err)
+	}
+
+	diff := migration.NewDiffer().WithInstalledExtensions(extensions).WithSequences(sequences).Compare(tables, dbSchema)
+	missing := &migration.SchemaDiff{
+		TablesAdded:     diff.TablesAdded,
+		EnumTypesAdded:  diff.EnumTypesAdded,
+		ViewsAdded:      diff.ViewsAdded,
+		ExtensionsAdded: diff.ExtensionsAdded,
+		SequencesAdded:  diff.SequencesAdded,
+	}
+	if !missing.HasChanges() {
+		return nil
+	}
+	upSQL, _ := migration.NewPlanner().GenerateMigration(missing)
+	if _, err := pool.Exec(ctx, upSQL); err != nil {
+		return fmt.Errorf("failed to create tables: %w\n%s", err, upSQL)
+	}
+	return nil
+}
+
+// truncateTables deletes every row of the tables and resets their sequences.
+func truncateTables(ctx context.Context, db *runtime.DB, tables map[string]*schema.TableMetadata) error {
+	var names []string
+	for _, table := range tables {
+		if !table.IsView() {
+			names = append(names, schema.QuoteQualifiedIdent(table.QualifiedName()))
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	if _, err := db.Exec(ctx, "TRUNCATE "+strings.Join(names, ", ")+" RESTART IDENTITY CASCADE"); err != nil {
+		return fmt.Errorf("failed to truncate tables: %w", err)
+	}
+	return nil
+}
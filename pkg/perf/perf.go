@@ -0,0 +1,142 @@
+// Package perf reports the statements that cost the database the most time,
+// from pg_stat_statements, attributed to the registered models and the
+// sqlcommenter tags (see builder.Config.SQLComments) that sent them.
+package perf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/marshallshelly/pebble-orm/pkg/metrics"
+	"github.com/marshallshelly/pebble-orm/pkg/registry"
+)
+
+// DefaultLimit is the number of statements Report returns.
+const DefaultLimit = 20
+
+// ErrUnavailable is returned when the database has no pg_stat_statements
+// view: the extension must be in shared_preload_libraries and created with
+// CREATE EXTENSION pg_stat_statements.
+var ErrUnavailable = errors.New("pg_stat_statements is not available")
+
+// Querier runs a query, such as *runtime.DB or pebbletest.MockDB.
+type Querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// Statement is one normalized statement's totals since the statistics were
+// last reset.
+type Statement struct {
+	Query     string // as pg_stat_statements normalized it, e.g. "... WHERE id = $1"
+	Calls     int64
+	TotalTime time.Duration
+	MeanTime  time.Duration
+	Rows      int64 // rows returned or affected, over all calls
+
+	Table     string            // the table it reads or writes, if one could be found
+	Operation string            // select, insert, update, delete, ...
+	Model     string            // Go type registered for Table, e.g. "models.User"
+	Tags      map[string]string // from its sqlcommenter comment, if any
+}
+
+// Report returns the DefaultLimit statements of the current database with
+// the most total execution time.
+// Usage: top, err := perf.Report(ctx, db)
+func Report(ctx context.Context, db Querier) ([]Statement, error) {
+	return Top(ctx, db, DefaultLimit)
+}
+
+// Top returns the n statements of the current database with the most total
+// execution time, most expensive first.
+// Usage: top, err := perf.Top(ctx, db, 5)
+func Top(ctx context.Context, db Querier, n int) ([]Statement, error) {
+	rows, err := db.Query(ctx, `SELECT query, calls, total_exec_time, mean_exec_time, rows
+FROM pg_stat_statements
+WHERE dbid = (SELECT oid FROM pg_database WHERE datname = current_database())
+ORDER BY total_exec_time DESC
+LIMIT $1`, n)
+	if err != nil {
+		return nil, unavailable(err)
+	}
+	defer rows.Close()
+
+	var report []Statement
+	for rows.Next() {
+		var s Statement
+		var total, mean float64
+		if err := rows.Scan(&s.Query, &s.Calls, &total, &mean, &s.Rows); err != nil {
+			return nil, err
+		}
+		s.TotalTime = milliseconds(total)
+		s.MeanTime = milliseconds(mean)
+		attribute(&s)
+		report = append(report, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, unavailable(err)
+	}
+	return report, nil
+}
+
+// unavailable reports a missing pg_stat_statements view as ErrUnavailable.
+func unavailable(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && (pgErr.Code == "42P01" || pgErr.Code == "55000") {
+		return fmt.Errorf("%w: %s", ErrUnavailable, pgErr.Message)
+	}
+	return err
+}
+
+// milliseconds converts pg_stat_statements' fractional milliseconds.
+func milliseconds(ms float64) time.Duration {
+	return time.Duration(ms * float64(time.Millisecond))
+}
+
+// attribute fills in what s's query says about where it came from.
+func attribute(s *Statement) {
+	s.Table, s.Operation = metrics.QueryLabels(s.Query)
+	if s.Table != "" {
+		if table, err := registry.GetByName(s.Table); err == nil && table.GoType != nil {
+			s.Model = table.GoType.String()
+		}
+	}
+	s.Tags = commentTags(s.Query)
+}
+
+var reSQLComment = regexp.MustCompile(`/\*([^*]*)\*/\s*;?\s*$`)
+
+// commentTags parses the sqlcommenter comment ending query, if any:
+// key='value' pairs separated by commas, URL-encoded.
+func commentTags(query string) map[string]string {
+	m := reSQLComment.FindStringSubmatch(query)
+	if m == nil {
+		return nil
+	}
+	tags := make(map[string]string)
+	for pair := range strings.SplitSeq(m[1], ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || len(value) < 2 || value[0] != '\'' || value[len(value)-1] != '\'' {
+			continue
+		}
+		k, err := url.QueryUnescape(key)
+		if err != nil {
+			continue
+		}
+		v, err := url.QueryUnescape(value[1 : len(value)-1])
+		if err != nil {
+			continue
+		}
+		tags[k] = v
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+	return tags
+}
@@ -0,0 +1,58 @@
+package perf
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/marshallshelly/pebble-orm/pkg/pebbletest"
+	"github.com/marshallshelly/pebble-orm/pkg/registry"
+)
+
+type Invoice struct {
+	ID    int64 `po:"id,primaryKey,bigint"`
+	Total int64 `po:"total,bigint"`
+}
+
+func TestReport(t *testing.T) {
+	if err := registry.Register(Invoice{}); err != nil {
+		t.Fatal(err)
+	}
+	mock := pebbletest.NewMockDB()
+	mock.ReturnRows([]string{"query", "calls", "total_exec_time", "mean_exec_time", "rows"},
+		[]any{"SELECT * FROM invoice WHERE id = $1 /*app='api',route='%2Finvoices%2F%7Bid%7D'*/", int64(400), 1500.5, 3.75, int64(400)},
+		[]any{"VACUUM", int64(1), 20.0, 20.0, int64(0)},
+	)
+
+	report, err := Report(context.Background(), mock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := mock.LastCall().Args; !reflect.DeepEqual(got, []any{DefaultLimit}) {
+		t.Errorf("args = %v", got)
+	}
+	if len(report) != 2 {
+		t.Fatalf("got %d statements", len(report))
+	}
+	s := report[0]
+	if s.Calls != 400 || s.TotalTime != 1500500*time.Microsecond || s.MeanTime != 3750*time.Microsecond || s.Rows != 400 {
+		t.Errorf("totals = %+v", s)
+	}
+	if s.Table != "invoice" || s.Operation != "select" || s.Model != "perf.Invoice" {
+		t.Errorf("attribution = %q %q %q", s.Table, s.Operation, s.Model)
+	}
+	if want := map[string]string{"app": "api", "route": "/invoices/{id}"}; !reflect.DeepEqual(s.Tags, want) {
+		t.Errorf("tags = %v, want %v", s.Tags, want)
+	}
+	if s := report[1]; s.Table != "" || s.Model != "" || s.Tags != nil {
+		t.Errorf("unattributable statement = %+v", s)
+	}
+
+	mock.ReturnError(&pgconn.PgError{Code: "42P01", Message: `relation "pg_stat_statements" does not exist`})
+	if _, err := Report(context.Background(), mock); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("err = %v, want ErrUnavailable", err)
+	}
+}
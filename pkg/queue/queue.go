@@ -0,0 +1,346 @@
+// Package queue is a job queue kept in a PostgreSQL table. Workers claim
+// jobs with SELECT ... FOR UPDATE SKIP LOCKED, so any number of them can
+// share a queue without two taking the same job, and a job can be enqueued
+// in the same transaction as the rows it is about (see InTx).
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Table holds the jobs of every queue. Setup creates it.
+const Table = "pebble_jobs"
+
+var (
+	// ErrEmpty is returned by Dequeue when no job is ready.
+	ErrEmpty = errors.New("queue: no job ready")
+
+	// ErrLost is returned by Complete, Fail and Extend when the job's
+	// visibility timeout ran out and another worker claimed it again.
+	ErrLost = errors.New("queue: job was claimed again after its visibility timeout")
+)
+
+// setupSQL creates the jobs table and the index workers claim jobs by.
+var setupSQL = []string{
+	`CREATE TABLE IF NOT EXISTS ` + Table + ` (
+    id bigserial PRIMARY KEY,
+    queue text NOT NULL,
+    payload jsonb NOT NULL,
+    status text NOT NULL DEFAULT 'pending',
+    attempts integer NOT NULL DEFAULT 0,
+    max_attempts integer NOT NULL,
+    run_at timestamptz NOT NULL DEFAULT now(),
+    locked_until timestamptz,
+    last_error text,
+    created_at timestamptz NOT NULL DEFAULT now()
+);`,
+	`CREATE INDEX IF NOT EXISTS ` + Table + `_ready_idx ON ` + Table + ` (queue, run_at, id) WHERE status <> 'dead';`,
+}
+
+// Querier runs statements, such as *runtime.DB or pebbletest.MockDB.
+type Querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (int64, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// InTx returns a Querier running on tx, so a job is enqueued with the rows
+// it is about: workers only see it once tx commits.
+// Usage: _, err := queue.New[ExportJob](queue.InTx(tx), "exports").Enqueue(ctx, job)
+func InTx(tx pgx.Tx) Querier {
+	return txQuerier{tx}
+}
+
+type txQuerier struct{ tx pgx.Tx }
+
+func (t txQuerier) Exec(ctx context.Context, sql string, args ...any) (int64, error) {
+	tag, err := t.tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (t txQuerier) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return t.tx.Query(ctx, sql, args...)
+}
+
+func (t txQuerier) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return t.tx.QueryRow(ctx, sql, args...)
+}
+
+// Setup creates the jobs table if it doesn't exist. Migrations leave it
+// alone.
+// Usage: err := queue.Setup(ctx, db)
+func Setup(ctx context.Context, db Querier) error {
+	for _, stmt := range setupSQL {
+		if _, err := db.Exec(ctx, stmt); err != nil {
+			return fmt.Errorf("queue: setup failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// Options tunes a queue.
+type Options struct {
+	// VisibilityTimeout is how long a worker has a job before another may
+	// claim it, as when the first one crashed. Default 5m.
+	VisibilityTimeout time.Duration
+
+	// MaxAttempts is the number of tries before a job is dead. Default 5.
+	MaxAttempts int
+
+	// Backoff is the wait before the second try, doubled before each one
+	// after up to MaxBackoff. Default 10s.
+	Backoff    time.Duration
+	MaxBackoff time.Duration // Default 1h.
+
+	// PollInterval is how often Work checks an empty queue. Default 1s.
+	PollInterval time.Duration
+}
+
+// Job is a claimed job.
+type Job[T any] struct {
+	ID          int64
+	Queue       string
+	Payload     T
+	Attempts    int // this one included
+	MaxAttempts int
+	LastError   string // of the previous attempt, if it failed
+	CreatedAt   time.Time
+}
+
+// Handler processes a job. Returning an error fails the attempt; the job is
+// retried after a backoff until it runs out of attempts.
+type Handler[T any] func(ctx context.Context, job *Job[T]) error
+
+// Queue is a named queue of jobs with payloads of type T, stored as JSON.
+type Queue[T any] struct {
+	db   Querier
+	name string
+	opts Options
+}
+
+// New returns the queue called name on db.
+// Usage: exports := queue.New[ExportJob](db, "exports", queue.Options{MaxAttempts: 3})
+func New[T any](db Querier, name string, opts ...Options) *Queue[T] {
+	var o Options
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if o.VisibilityTimeout <= 0 {
+		o.VisibilityTimeout = 5 * time.Minute
+	}
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 5
+	}
+	if o.Backoff <= 0 {
+		o.Backoff = 10 * time.Second
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = time.Hour
+	}
+	if o.PollInterval <= 0 {
+		o.PollInterval = time.Second
+	}
+	return &Queue[T]{db: db, name: name, opts: o}
+}
+
+// Enqueue adds a job to run now and returns its ID.
+// Usage: id, err := exports.Enqueue(ctx, ExportJob{RequestID: id})
+func (q *Queue[T]) Enqueue(ctx context.Context, payload T) (int64, error) {
+	return q.EnqueueAt(ctx, payload, time.Time{})
+}
+
+// EnqueueAt adds a job to run once at has passed; the zero time means now.
+func (q *Queue[T]) EnqueueAt(ctx context.Context, payload T, at time.Time) (int64, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return 0, fmt.Errorf("queue: failed to encode payload: %w", err)
+	}
+	var runAt any
+	if !at.IsZero() {
+		runAt = at
+	}
+	var id int64
+	err = q.db.QueryRow(ctx,
+		"INSERT INTO "+Table+" (queue, payload, max_attempts, run_at) VALUES ($1, $2, $3, COALESCE($4, now())) RETURNING id",
+		q.name, data, q.opts.MaxAttempts, runAt).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("queue: failed to enqueue: %w", err)
+	}
+	return id, nil
+}
+
+// Dequeue claims the oldest ready job for the visibility timeout, or
+// returns ErrEmpty. Jobs whose worker let the timeout run out on their last
+// attempt are moved to the dead letters first.
+func (q *Queue[T]) Dequeue(ctx context.Context) (*Job[T], error) {
+	if _, err := q.db.Exec(ctx,
+		"UPDATE "+Table+" SET status = 'dead', locked_until = NULL, last_error = 'visibility timeout expired' "+
+			"WHERE queue = $1 AND status = 'running' AND locked_until < now() AND attempts >= max_attempts",
+		q.name); err != nil {
+		return nil, fmt.Errorf("queue: failed to expire jobs: %w", err)
+	}
+
+	job := &Job[T]{Queue: q.name}
+	var payload []byte
+	err := q.db.QueryRow(ctx, `UPDATE `+Table+` SET status = 'running', attempts = attempts + 1, locked_until = now() + $2::interval
+WHERE id = (
+    SELECT id FROM `+Table+`
+    WHERE queue = $1 AND run_at <= now()
+      AND (status = 'pending' OR (status = 'running' AND locked_until < now()))
+    ORDER BY run_at, id
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, payload, attempts, max_attempts, COALESCE(last_error, ''), created_at`,
+		q.name, q.opts.VisibilityTimeout).Scan(&job.ID, &payload, &job.Attempts, &job.MaxAttempts, &job.LastError, &job.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrEmpty
+	}
+	if err != nil {
+		return nil, fmt.Errorf("queue: failed to dequeue: %w", err)
+	}
+	if err := json.Unmarshal(payload, &job.Payload); err != nil {
+		return nil, fmt.Errorf("queue: job %d: failed to decode payload: %w", job.ID, err)
+	}
+	return job, nil
+}
+
+// Complete deletes a job that succeeded.
+func (q *Queue[T]) Complete(ctx context.Context, job *Job[T]) error {
+	return q.claimed(q.db.Exec(ctx,
+		"DELETE FROM "+Table+" WHERE id = $1 AND status = 'running' AND attempts = $2", job.ID, job.Attempts))
+}
+
+// Fail records a failed attempt: the job runs again after a backoff, or is
+// dead once out of attempts.
+func (q *Queue[T]) Fail(ctx context.Context, job *Job[T], cause error) error {
+	if job.Attempts >= job.MaxAttempts {
+		return q.claimed(q.db.Exec(ctx,
+			"UPDATE "+Table+" SET status = 'dead', locked_until = NULL, last_error = $3 WHERE id = $1 AND status = 'running' AND attempts = $2",
+			job.ID, job.Attempts, cause.Error()))
+	}
+	return q.claimed(q.db.Exec(ctx,
+		"UPDATE "+Table+" SET status = 'pending', locked_until = NULL, last_error = $3, run_at = now() + $4::interval "+
+			"WHERE id = $1 AND status = 'running' AND attempts = $2",
+		job.ID, job.Attempts, cause.Error(), q.backoff(job.Attempts)))
+}
+
+// Extend gives the worker another visibility timeout for a long job.
+func (q *Queue[T]) Extend(ctx context.Context, job *Job[T]) error {
+	return q.claimed(q.db.Exec(ctx,
+		"UPDATE "+Table+" SET locked_until = now() + $3::interval WHERE id = $1 AND status = 'running' AND attempts = $2",
+		job.ID, job.Attempts, q.opts.VisibilityTimeout))
+}
+
+// claimed turns a statement on a claimed job that affected no row into
+// ErrLost.
+func (q *Queue[T]) claimed(n int64, err error) error {
+	if err != nil {
+		return fmt.Errorf("queue: %w", err)
+	}
+	if n == 0 {
+		return ErrLost
+	}
+	return nil
+}
+
+// backoff returns the wait after the given failed attempt.
+func (q *Queue[T]) backoff(attempt int) time.Duration {
+	wait := q.opts.Backoff
+	for i := 1; i < attempt && wait < q.opts.MaxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, q.opts.MaxBackoff)
+}
+
+// Work runs fn on each job as it becomes ready, completing the jobs it
+// returns nil for and failing the others, until ctx is done. Each job gets a
+// context ending with its visibility timeout. Run Work in as many
+// goroutines or processes as jobs should run at once.
+// Usage: go exports.Work(ctx, func(ctx context.Context, job *queue.Job[ExportJob]) error { ... })
+func (q *Queue[T]) Work(ctx context.Context, fn Handler[T]) error {
+	for {
+		job, err := q.Dequeue(ctx)
+		if errors.Is(err, ErrEmpty) {
+			timer := time.NewTimer(q.opts.PollInterval)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+			continue
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+
+		jobCtx, cancel := context.WithTimeout(ctx, q.opts.VisibilityTimeout)
+		err = fn(jobCtx, job)
+		cancel()
+		if ctx.Err() != nil {
+			return ctx.Err() // the job is claimed again after its timeout
+		}
+		if err != nil {
+			err = q.Fail(ctx, job, err)
+		} else {
+			err = q.Complete(ctx, job)
+		}
+		if err != nil && !errors.Is(err, ErrLost) {
+			return err
+		}
+	}
+}
+
+// DeadJobs returns up to limit dead jobs, most recent first.
+func (q *Queue[T]) DeadJobs(ctx context.Context, limit int) ([]Job[T], error) {
+	rows, err := q.db.Query(ctx,
+		"SELECT id, payload, attempts, max_attempts, COALESCE(last_error, ''), created_at FROM "+Table+
+			" WHERE queue = $1 AND status = 'dead' ORDER BY id DESC LIMIT $2",
+		q.name, limit)
+	if err != nil {
+		return nil, fmt.Errorf("queue: failed to read dead jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []Job[T]
+	for rows.Next() {
+		job := Job[T]{Queue: q.name}
+		var payload []byte
+		if err := rows.Scan(&job.ID, &payload, &job.Attempts, &job.MaxAttempts, &job.LastError, &job.CreatedAt); err != nil {
+			return nil, fmt.Errorf("queue: failed to read dead jobs: %w", err)
+		}
+		if err := json.Unmarshal(payload, &job.Payload); err != nil {
+			return nil, fmt.Errorf("queue: job %d: failed to decode payload: %w", job.ID, err)
+		}
+		jobs = append(jobs, job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("queue: failed to read dead jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+// Retry gives a dead job a fresh set of attempts, to run now. It reports
+// whether the job was dead.
+func (q *Queue[T]) Retry(ctx context.Context, id int64) (bool, error) {
+	n, err := q.db.Exec(ctx,
+		"UPDATE "+Table+" SET status = 'pending', attempts = 0, run_at = now() WHERE id = $1 AND queue = $2 AND status = 'dead'",
+		id, q.name)
+	if err != nil {
+		return false, fmt.Errorf("queue: failed to retry job %d: %w", id, err)
+	}
+	return n > 0, nil
+}
@@ -0,0 +1,122 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marshallshelly/pebble-orm/pkg/pebbletest"
+)
+
+type exportJob struct {
+	RequestID string `json:"request_id"`
+}
+
+var jobColumns = []string{"id", "payload", "attempts", "max_attempts", "last_error", "created_at"}
+
+func TestEnqueue(t *testing.T) {
+	mock := pebbletest.NewMockDB()
+	mock.ReturnRows([]string{"id"}, []any{int64(7)})
+
+	id, err := New[exportJob](mock, "exports").Enqueue(context.Background(), exportJob{RequestID: "r1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	call := mock.LastCall()
+	if id != 7 || !strings.HasPrefix(call.SQL, "INSERT INTO pebble_jobs") {
+		t.Fatalf("id = %d, SQL = %s", id, call.SQL)
+	}
+	if call.Args[0] != "exports" || string(call.Args[1].([]byte)) != `{"request_id":"r1"}` || call.Args[2] != 5 || call.Args[3] != nil {
+		t.Errorf("args = %v", call.Args)
+	}
+}
+
+func TestDequeue(t *testing.T) {
+	ctx := context.Background()
+	mock := pebbletest.NewMockDB()
+	q := New[exportJob](mock, "exports", Options{VisibilityTimeout: time.Minute})
+
+	if _, err := q.Dequeue(ctx); !errors.Is(err, ErrEmpty) {
+		t.Fatalf("err = %v, want ErrEmpty", err)
+	}
+
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	mock.Reset()
+	mock.ReturnRowsAffected(0).
+		ReturnRows(jobColumns, []any{int64(3), []byte(`{"request_id":"r1"}`), int64(2), int64(5), "timeout", created})
+	job, err := q.Dequeue(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Job[exportJob]{ID: 3, Queue: "exports", Payload: exportJob{RequestID: "r1"}, Attempts: 2, MaxAttempts: 5, LastError: "timeout", CreatedAt: created}
+	if *job != want {
+		t.Errorf("job = %+v, want %+v", *job, want)
+	}
+	claim := mock.LastCall()
+	if !strings.Contains(claim.SQL, "FOR UPDATE SKIP LOCKED") || claim.Args[1] != time.Minute {
+		t.Errorf("claim = %s %v", claim.SQL, claim.Args)
+	}
+}
+
+func TestFail(t *testing.T) {
+	ctx := context.Background()
+	mock := pebbletest.NewMockDB()
+	q := New[exportJob](mock, "exports", Options{Backoff: time.Second})
+
+	mock.ReturnRowsAffected(1)
+	if err := q.Fail(ctx, &Job[exportJob]{ID: 1, Attempts: 3, MaxAttempts: 5}, errors.New("boom")); err != nil {
+		t.Fatal(err)
+	}
+	if call := mock.LastCall(); !strings.Contains(call.SQL, "status = 'pending'") || call.Args[2] != "boom" || call.Args[3] != 4*time.Second {
+		t.Errorf("retry = %s %v", call.SQL, call.Args)
+	}
+
+	mock.ReturnRowsAffected(1)
+	if err := q.Fail(ctx, &Job[exportJob]{ID: 1, Attempts: 5, MaxAttempts: 5}, errors.New("boom")); err != nil {
+		t.Fatal(err)
+	}
+	if call := mock.LastCall(); !strings.Contains(call.SQL, "status = 'dead'") {
+		t.Errorf("dead letter = %s", call.SQL)
+	}
+
+	// Nothing updated: another worker has the job.
+	if err := q.Complete(ctx, &Job[exportJob]{ID: 1, Attempts: 1}); !errors.Is(err, ErrLost) {
+		t.Errorf("err = %v, want ErrLost", err)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	q := New[exportJob](nil, "exports", Options{Backoff: time.Second, MaxBackoff: 5 * time.Second})
+	for attempt, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second, 20: 5 * time.Second} {
+		if got := q.backoff(attempt); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+}
+
+func TestWork(t *testing.T) {
+	mock := pebbletest.NewMockDB()
+	mock.ReturnRowsAffected(0).
+		ReturnRows(jobColumns, []any{int64(1), []byte(`{"request_id":"r1"}`), int64(1), int64(5), "", time.Now()}).
+		ReturnRowsAffected(1)
+	q := New[exportJob](mock, "exports", Options{PollInterval: time.Millisecond})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	var handled []string
+	err := q.Work(ctx, func(ctx context.Context, job *Job[exportJob]) error {
+		handled = append(handled, job.Payload.RequestID)
+		return nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want the context's", err)
+	}
+	if len(handled) != 1 || handled[0] != "r1" {
+		t.Errorf("handled = %v", handled)
+	}
+	if calls := mock.Calls(); !strings.HasPrefix(calls[2].SQL, "DELETE FROM pebble_jobs") {
+		t.Errorf("expected the job to be completed, got %s", calls[2].SQL)
+	}
+}
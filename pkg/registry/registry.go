@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"maps"
 	"reflect"
+	"strings"
 	"sync"
 
 	"github.com/marshallshelly/pebble-orm/pkg/schema"
@@ -56,7 +57,7 @@ func (r *Registry) Register(model any) error {
 
 	// Store in registry
 	r.tables[modelType] = table
-	r.names[table.Name] = table
+	r.names[table.QualifiedName()] = table
 
 	return nil
 }
@@ -68,7 +69,7 @@ func (r *Registry) RegisterMetadata(table *schema.TableMetadata) error {
 	defer r.mu.Unlock()
 
 	// Check if already registered by name
-	if _, ok := r.names[table.Name]; ok {
+	if _, ok := r.names[table.QualifiedName()]; ok {
 		return nil // Already registered
 	}
 
@@ -76,7 +77,7 @@ func (r *Registry) RegisterMetadata(table *schema.TableMetadata) error {
 	if table.GoType != nil {
 		r.tables[table.GoType] = table
 	}
-	r.names[table.Name] = table
+	r.names[table.QualifiedName()] = table
 
 	return nil
 }
@@ -99,11 +100,26 @@ func (r *Registry) Get(modelType reflect.Type) (*schema.TableMetadata, error) {
 	return table, nil
 }
 
-// GetByName retrieves TableMetadata by table name.
+// GetByName retrieves TableMetadata by table name. Tables outside the public
+// schema are keyed as "schema.table"; a bare name also matches them when it
+// is unambiguous.
 func (r *Registry) GetByName(tableName string) (*schema.TableMetadata, error) {
 	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	table, ok := r.names[tableName]
-	r.mu.RUnlock()
+	if !ok && !strings.Contains(tableName, ".") {
+		for _, t := range r.names {
+			if t.Name != tableName {
+				continue
+			}
+			if table != nil {
+				return nil, fmt.Errorf("table %s is ambiguous across schemas", tableName)
+			}
+			table = t
+		}
+		ok = table != nil
+	}
 
 	if !ok {
 		return nil, fmt.Errorf("table %s not registered", tableName)
@@ -240,8 +256,8 @@ func Clear() {
 	globalRegistry.Clear()
 }
 
-// GetAllTables returns all registered tables as a map[tableName]*TableMetadata.
-// This is useful for migration generation.
+// GetAllTables returns all registered tables as a map[tableName]*TableMetadata,
+// keyed by schema-qualified name for tables outside public. This is useful for migration generation.
 func (r *Registry) GetAllTables() map[string]*schema.TableMetadata {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -3,6 +3,8 @@ package registry
 import (
 	"reflect"
 	"testing"
+
+	"github.com/marshallshelly/pebble-orm/pkg/schema"
 )
 
 type User struct {
@@ -127,6 +129,29 @@ func TestRegistry_GetByName(t *testing.T) {
 			t.Error("expected error for non-existent table")
 		}
 	})
+
+	t.Run("schema-qualified tables", func(t *testing.T) {
+		if err := registry.RegisterMetadata(&schema.TableMetadata{Name: "invoices", Schema: "billing"}); err != nil {
+			t.Fatalf("RegisterMetadata failed: %v", err)
+		}
+
+		for _, name := range []string{"billing.invoices", "invoices"} {
+			table, err := registry.GetByName(name)
+			if err != nil {
+				t.Fatalf("GetByName(%q) failed: %v", name, err)
+			}
+			if table.Schema != "billing" {
+				t.Errorf("GetByName(%q) schema = %q, want billing", name, table.Schema)
+			}
+		}
+
+		if err := registry.RegisterMetadata(&schema.TableMetadata{Name: "invoices", Schema: "archive"}); err != nil {
+			t.Fatalf("RegisterMetadata failed: %v", err)
+		}
+		if _, err := registry.GetByName("invoices"); err == nil {
+			t.Error("expected error for a bare name matching tables in two schemas")
+		}
+	})
 }
 
 func TestRegistry_GetOrRegister(t *testing.T) {
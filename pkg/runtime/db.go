@@ -3,9 +3,13 @@ package runtime
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgconn/ctxwatch"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/marshallshelly/pebble-orm/pkg/config"
 )
 
 // DB represents a database connection.
@@ -34,8 +38,112 @@ func NewDB(pool *pgxpool.Pool) *DB {
 	}
 }
 
+// ConnectOptions tunes the connection pool beyond what the DSN or Config
+// set. Zero fields keep the pgxpool defaults (or the DSN's pool_* values).
+type ConnectOptions struct {
+	MaxConns int32
+	MinConns int32
+
+	// MaxConnLifetime closes connections older than this, plus up to
+	// MaxConnLifetimeJitter so they don't all reconnect at once.
+	MaxConnLifetime       time.Duration
+	MaxConnLifetimeJitter time.Duration
+	// MaxConnIdleTime closes connections idle for longer than this.
+	MaxConnIdleTime time.Duration
+	// HealthCheckPeriod is how often idle connections are checked.
+	HealthCheckPeriod time.Duration
+
+	// LazyConnect skips pinging the database on connect, so the first
+	// query opens the first connection.
+	LazyConnect bool
+
+	// AfterConnect runs on every new connection, e.g. to register codecs for
+	// custom types.
+	AfterConnect func(ctx context.Context, conn *pgx.Conn) error
+
+	// QueryExecMode sets how statements are sent. Behind PgBouncer in
+	// transaction mode use pgx.QueryExecModeExec or
+	// pgx.QueryExecModeSimpleProtocol, which don't rely on a prepared
+	// statement outliving the transaction. Zero keeps
+	// pgx.QueryExecModeCacheStatement.
+	QueryExecMode pgx.QueryExecMode
+	// StatementCacheCapacity and DescriptionCacheCapacity size each
+	// connection's cache of prepared statements and statement descriptions.
+	StatementCacheCapacity   int
+	DescriptionCacheCapacity int
+
+	// Prepare lists statements to prepare on every new connection, after
+	// AfterConnect, e.g. those from builder.HotStatements.
+	Prepare []string
+
+	// ReadOnly sets default_transaction_read_only on every connection, so
+	// PostgreSQL rejects writes however they are sent. Pair it with
+	// builder.NewReadOnly for reporting services and replica pools.
+	ReadOnly bool
+}
+
+// apply sets the non-zero options on poolConfig.
+func (o ConnectOptions) apply(poolConfig *pgxpool.Config) {
+	if o.MaxConns > 0 {
+		poolConfig.MaxConns = o.MaxConns
+	}
+	if o.MinConns > 0 {
+		poolConfig.MinConns = o.MinConns
+	}
+	if o.MaxConnLifetime > 0 {
+		poolConfig.MaxConnLifetime = o.MaxConnLifetime
+	}
+	if o.MaxConnLifetimeJitter > 0 {
+		poolConfig.MaxConnLifetimeJitter = o.MaxConnLifetimeJitter
+	}
+	if o.MaxConnIdleTime > 0 {
+		poolConfig.MaxConnIdleTime = o.MaxConnIdleTime
+	}
+	if o.HealthCheckPeriod > 0 {
+		poolConfig.HealthCheckPeriod = o.HealthCheckPeriod
+	}
+	if o.AfterConnect != nil {
+		poolConfig.AfterConnect = o.AfterConnect
+	}
+	if o.QueryExecMode != 0 {
+		poolConfig.ConnConfig.DefaultQueryExecMode = o.QueryExecMode
+	}
+	if o.StatementCacheCapacity > 0 {
+		poolConfig.ConnConfig.StatementCacheCapacity = o.StatementCacheCapacity
+	}
+	if o.DescriptionCacheCapacity > 0 {
+		poolConfig.ConnConfig.DescriptionCacheCapacity = o.DescriptionCacheCapacity
+	}
+	if o.ReadOnly {
+		poolConfig.ConnConfig.RuntimeParams["default_transaction_read_only"] = "on"
+	}
+	if len(o.Prepare) > 0 {
+		next, statements := poolConfig.AfterConnect, o.Prepare
+		poolConfig.AfterConnect = func(ctx context.Context, conn *pgx.Conn) error {
+			if next != nil {
+				if err := next(ctx, conn); err != nil {
+					return err
+				}
+			}
+			return Prepare(ctx, conn, statements)
+		}
+	}
+}
+
+// Prepare prepares statements on conn, each named by its SQL so queries with
+// the same text use it instead of preparing their own.
+func Prepare(ctx context.Context, conn *pgx.Conn, statements []string) error {
+	for _, sql := range statements {
+		if _, err := conn.Prepare(ctx, sql, sql); err != nil {
+			return &QueryError{Query: sql, Err: err}
+		}
+	}
+	return nil
+}
+
 // Connect creates a new DB instance by connecting to PostgreSQL.
-func Connect(ctx context.Context, config *Config) (*DB, error) {
+// Usage: db, err := runtime.Connect(ctx, config, runtime.ConnectOptions{MaxConnIdleTime: 5 * time.Minute})
+func Connect(ctx context.Context, config *Config, opts ...ConnectOptions) (*DB, error) {
 	connString := buildConnectionString(config)
 
 	poolConfig, err := pgxpool.ParseConfig(connString)
@@ -51,47 +159,79 @@ func Connect(ctx context.Context, config *Config) (*DB, error) {
 		poolConfig.MinConns = config.MinConns
 	}
 
-	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create connection pool: %w", err)
-	}
-
-	// Test the connection
-	if err := pool.Ping(ctx); err != nil {
-		pool.Close()
-		return nil, fmt.Errorf("failed to ping database: %w", err)
-	}
-
-	return &DB{
-		pool:   pool,
-		config: config,
-	}, nil
+	return connect(ctx, poolConfig, config, opts)
 }
 
 // ConnectWithURL creates a new DB instance using a connection URL.
-func ConnectWithURL(ctx context.Context, url string) (*DB, error) {
+func ConnectWithURL(ctx context.Context, url string, opts ...ConnectOptions) (*DB, error) {
 	poolConfig, err := pgxpool.ParseConfig(url)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse connection URL: %w", err)
 	}
 
+	return connect(ctx, poolConfig, &Config{}, opts)
+}
+
+// ConnectFromConfig connects to the database of env in pebble.yaml (see
+// package config), found from the working directory or named by
+// PEBBLE_CONFIG. An empty env uses PEBBLE_ENV, then the file's
+// default_environment, then "development". opts apply after the
+// environment's pool settings. The file's naming strategy is not applied:
+// call schema.SetNamingStrategy(cfg.Naming.Strategy()) before registering
+// models, as package-level state shouldn't change on connect.
+// Usage: db, err := runtime.ConnectFromConfig(ctx, "production")
+func ConnectFromConfig(ctx context.Context, env string, opts ...ConnectOptions) (*DB, error) {
+	cfg, err := config.LoadDefault()
+	if err != nil {
+		return nil, err
+	}
+	e, err := cfg.Environment(env)
+	if err != nil {
+		return nil, err
+	}
+	opts = append([]ConnectOptions{{MaxConns: e.MaxConns, MinConns: e.MinConns}}, opts...)
+	return ConnectWithURL(ctx, e.DatabaseURL, opts...)
+}
+
+// connect applies opts to poolConfig, in order, and opens the pool.
+func connect(ctx context.Context, poolConfig *pgxpool.Config, config *Config, opts []ConnectOptions) (*DB, error) {
+	poolConfig.ConnConfig.BuildContextWatcherHandler = cancelOnServer
+	lazy := false
+	for _, o := range opts {
+		o.apply(poolConfig)
+		lazy = lazy || o.LazyConnect
+	}
+
 	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create connection pool: %w", err)
 	}
 
 	// Test the connection
-	if err := pool.Ping(ctx); err != nil {
-		pool.Close()
-		return nil, fmt.Errorf("failed to ping database: %w", err)
+	if !lazy {
+		if err := pool.Ping(ctx); err != nil {
+			pool.Close()
+			return nil, fmt.Errorf("failed to ping database: %w", err)
+		}
 	}
 
 	return &DB{
 		pool:   pool,
-		config: &Config{},
+		config: config,
 	}, nil
 }
 
+// cancelDeadline is how long a canceled statement may take to stop before
+// its connection is closed.
+const cancelDeadline = 5 * time.Second
+
+// cancelOnServer makes a canceled context send the server a cancel request,
+// as pg_cancel_backend would, instead of closing the connection and leaving
+// the statement running on the backend.
+func cancelOnServer(conn *pgconn.PgConn) ctxwatch.Handler {
+	return &pgconn.CancelRequestContextWatcherHandler{Conn: conn, DeadlineDelay: cancelDeadline}
+}
+
 // Pool returns the underlying pgxpool.Pool.
 func (db *DB) Pool() *pgxpool.Pool {
 	return db.pool
@@ -137,6 +277,24 @@ func (db *DB) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, err
 	return rows, nil
 }
 
+// Prepare prepares statements on every idle connection of the pool and
+// returns how many connections it prepared them on. Connections opened later
+// don't have them; use ConnectOptions.Prepare for those.
+func (db *DB) Prepare(ctx context.Context, statements []string) (int, error) {
+	conns := db.pool.AcquireAllIdle(ctx)
+	defer func() {
+		for _, conn := range conns {
+			conn.Release()
+		}
+	}()
+	for _, conn := range conns {
+		if err := Prepare(ctx, conn.Conn(), statements); err != nil {
+			return 0, err
+		}
+	}
+	return len(conns), nil
+}
+
 // QueryRow executes a query that returns at most one row.
 func (db *DB) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
 	return db.pool.QueryRow(ctx, sql, args...)
@@ -0,0 +1,93 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestConnectOptionsApply(t *testing.T) {
+	poolConfig, err := pgxpool.ParseConfig("postgres://localhost/app?pool_max_conns=20&pool_max_conn_idle_time=1m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hooked := false
+
+	ConnectOptions{
+		MinConns:          2,
+		MaxConnLifetime:   time.Hour,
+		HealthCheckPeriod: 30 * time.Second,
+		AfterConnect:      func(context.Context, *pgx.Conn) error { hooked = true; return nil },
+	}.apply(poolConfig)
+
+	if poolConfig.MaxConns != 20 || poolConfig.MaxConnIdleTime != time.Minute {
+		t.Errorf("expected DSN settings to be kept, got max conns %d, idle time %v", poolConfig.MaxConns, poolConfig.MaxConnIdleTime)
+	}
+	if poolConfig.MinConns != 2 || poolConfig.MaxConnLifetime != time.Hour || poolConfig.HealthCheckPeriod != 30*time.Second {
+		t.Errorf("expected options to be applied, got %+v", poolConfig)
+	}
+	if poolConfig.AfterConnect == nil || poolConfig.AfterConnect(context.Background(), nil) != nil || !hooked {
+		t.Error("expected the AfterConnect hook to be installed")
+	}
+}
+
+func TestConnectLazy(t *testing.T) {
+	// Nothing listens on port 1; a lazy connect must not try to reach it.
+	db, err := ConnectWithURL(context.Background(), "postgres://localhost:1/app", ConnectOptions{LazyConnect: true})
+	if err != nil {
+		t.Fatalf("expected a lazy connect to succeed, got %v", err)
+	}
+	db.Close()
+}
+
+func TestConnectCancelsOnServer(t *testing.T) {
+	db, err := ConnectWithURL(context.Background(), "postgres://localhost:1/app", ConnectOptions{LazyConnect: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	build := db.Pool().Config().ConnConfig.BuildContextWatcherHandler
+	if build == nil {
+		t.Fatal("expected a context watcher handler")
+	}
+	if _, ok := build(nil).(*pgconn.CancelRequestContextWatcherHandler); !ok {
+		t.Errorf("expected a canceled context to send a cancel request, got %T", build(nil))
+	}
+}
+
+func TestConnectOptionsStatementCache(t *testing.T) {
+	poolConfig, err := pgxpool.ParseConfig("postgres://localhost/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hookErr := errors.New("codec registration failed")
+
+	ConnectOptions{
+		AfterConnect:           func(context.Context, *pgx.Conn) error { return hookErr },
+		QueryExecMode:          pgx.QueryExecModeExec,
+		StatementCacheCapacity: 64,
+		Prepare:                []string{"SELECT 1"},
+		ReadOnly:               true,
+	}.apply(poolConfig)
+
+	if poolConfig.ConnConfig.DefaultQueryExecMode != pgx.QueryExecModeExec {
+		t.Errorf("expected exec mode %v, got %v", pgx.QueryExecModeExec, poolConfig.ConnConfig.DefaultQueryExecMode)
+	}
+	if poolConfig.ConnConfig.StatementCacheCapacity != 64 || poolConfig.ConnConfig.DescriptionCacheCapacity == 0 {
+		t.Errorf("expected statement cache 64 and the default description cache, got %d and %d",
+			poolConfig.ConnConfig.StatementCacheCapacity, poolConfig.ConnConfig.DescriptionCacheCapacity)
+	}
+	if got := poolConfig.ConnConfig.RuntimeParams["default_transaction_read_only"]; got != "on" {
+		t.Errorf("expected default_transaction_read_only on, got %q", got)
+	}
+	// AfterConnect runs first; its failure stops the statements being prepared.
+	if err := poolConfig.AfterConnect(context.Background(), nil); !errors.Is(err, hookErr) {
+		t.Errorf("expected the AfterConnect error, got %v", err)
+	}
+}
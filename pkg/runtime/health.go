@@ -0,0 +1,162 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// HealthStatus is the overall state of a database in a HealthReport.
+type HealthStatus string
+
+const (
+	// HealthOK means the database answers and has capacity to spare.
+	HealthOK HealthStatus = "ok"
+	// HealthDegraded means it answers but its pool is saturated or, for a
+	// replica, it lags too far behind.
+	HealthDegraded HealthStatus = "degraded"
+	// HealthDown means it doesn't answer.
+	HealthDown HealthStatus = "down"
+)
+
+// HealthOptions tunes what Health counts as degraded.
+type HealthOptions struct {
+	// SaturationThreshold is the share of the pool's connections in use
+	// from which it is saturated. Zero means 0.9.
+	SaturationThreshold float64
+
+	// MaxReplicationLag is the replay lag from which a replica is degraded.
+	// Zero skips the lag check.
+	MaxReplicationLag time.Duration
+
+	// AcquireTimeout bounds the ping when every connection of the pool is
+	// in use, so a saturated pool reports degraded instead of holding the
+	// probe until a connection frees up. Zero means 1s.
+	AcquireTimeout time.Duration
+}
+
+// HealthReport describes a database's health, for health and readiness
+// endpoints. It marshals to JSON as is.
+type HealthReport struct {
+	Status  HealthStatus  `json:"status"`
+	Latency time.Duration `json:"latency_ns"` // of the ping
+	Error   string        `json:"error,omitempty"`
+
+	Pool *PoolHealth `json:"pool,omitempty"`
+
+	// ReplicationLag is how far a replica's replay is behind, when checked.
+	ReplicationLag time.Duration `json:"replication_lag_ns,omitempty"`
+
+	Replicas []HealthReport `json:"replicas,omitempty"`
+}
+
+// PoolHealth describes the connection pool in a HealthReport.
+type PoolHealth struct {
+	Acquired   int32   `json:"acquired"`
+	Idle       int32   `json:"idle"`
+	Max        int32   `json:"max"`
+	Saturation float64 `json:"saturation"` // Acquired / Max
+}
+
+// OK reports whether the database is usable, degraded or not, so a
+// readiness probe can pass traffic to it.
+func (r HealthReport) OK() bool {
+	return r.Status != HealthDown
+}
+
+// Health checks its pool's saturation, pings the database, and checks the
+// replication lag when opts set MaxReplicationLag. It never fails: problems
+// are reported in the result.
+// Usage: report := db.Health(ctx)
+func (db *DB) Health(ctx context.Context, opts ...HealthOptions) HealthReport {
+	var o HealthOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	report := HealthReport{Status: HealthOK}
+	stat := db.pool.Stat()
+	report.Pool = &PoolHealth{Acquired: stat.AcquiredConns(), Idle: stat.IdleConns(), Max: stat.MaxConns()}
+	if report.Pool.Max > 0 {
+		report.Pool.Saturation = float64(report.Pool.Acquired) / float64(report.Pool.Max)
+	}
+	if report.Pool.Saturation >= o.saturationThreshold() {
+		report.Status = HealthDegraded
+	}
+
+	// With every connection in use the ping would wait for one to free up.
+	pingCtx := ctx
+	exhausted := stat.IdleConns() == 0 && stat.TotalConns() >= stat.MaxConns()
+	if exhausted {
+		var cancel context.CancelFunc
+		pingCtx, cancel = context.WithTimeout(ctx, o.acquireTimeout())
+		defer cancel()
+	}
+	start := time.Now()
+	err := db.Ping(pingCtx)
+	report.Latency = time.Since(start)
+	switch {
+	case err != nil && exhausted && ctx.Err() == nil && errors.Is(err, context.DeadlineExceeded):
+		report.Status = HealthDegraded
+		report.Error = "no connection free within " + o.acquireTimeout().String()
+		return report
+	case err != nil:
+		report.Status = HealthDown
+		report.Error = err.Error()
+		return report
+	}
+
+	if o.MaxReplicationLag > 0 {
+		lag, err := db.ReplicationLag(ctx)
+		switch {
+		case err != nil:
+			report.Status = HealthDegraded
+			report.Error = err.Error()
+		case lag >= o.MaxReplicationLag:
+			report.Status = HealthDegraded
+		}
+		report.ReplicationLag = lag
+	}
+	return report
+}
+
+func (o HealthOptions) acquireTimeout() time.Duration {
+	if o.AcquireTimeout == 0 {
+		return time.Second
+	}
+	return o.AcquireTimeout
+}
+
+func (o HealthOptions) saturationThreshold() float64 {
+	if o.SaturationThreshold == 0 {
+		return 0.9
+	}
+	return o.SaturationThreshold
+}
+
+// ReplicationLag returns how far a standby's replay is behind the last
+// transaction it received, or zero on a primary or on a standby that has
+// replayed all it received: with no writes on the primary, the last replayed
+// transaction only gets older.
+func (db *DB) ReplicationLag(ctx context.Context) (time.Duration, error) {
+	var seconds float64
+	err := db.QueryRow(ctx, `SELECT CASE
+	WHEN NOT pg_is_in_recovery() THEN 0
+	WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+	ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0) END`).Scan(&seconds)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// CombineHealth returns primary with replicas attached and its status
+// lowered to degraded when a replica isn't OK, since reads then fall short.
+func CombineHealth(primary HealthReport, replicas []HealthReport) HealthReport {
+	primary.Replicas = replicas
+	for _, r := range replicas {
+		if r.Status != HealthOK && primary.Status == HealthOK {
+			primary.Status = HealthDegraded
+		}
+	}
+	return primary
+}
@@ -0,0 +1,47 @@
+package runtime
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCombineHealth(t *testing.T) {
+	tests := []struct {
+		name     string
+		primary  HealthStatus
+		replicas []HealthStatus
+		want     HealthStatus
+	}{
+		{"all ok", HealthOK, []HealthStatus{HealthOK}, HealthOK},
+		{"lagging replica", HealthOK, []HealthStatus{HealthOK, HealthDegraded}, HealthDegraded},
+		{"replica down", HealthOK, []HealthStatus{HealthDown}, HealthDegraded},
+		{"primary down", HealthDown, []HealthStatus{HealthOK}, HealthDown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var replicas []HealthReport
+			for _, s := range tt.replicas {
+				replicas = append(replicas, HealthReport{Status: s})
+			}
+			got := CombineHealth(HealthReport{Status: tt.primary}, replicas)
+			if got.Status != tt.want || len(got.Replicas) != len(replicas) {
+				t.Errorf("got %+v, want status %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHealthUnreachable(t *testing.T) {
+	// Nothing listens on port 1; the pool is empty, not exhausted, so the
+	// ping is tried and fails.
+	db, err := ConnectWithURL(context.Background(), "postgres://localhost:1/app", ConnectOptions{LazyConnect: true, MaxConns: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	report := db.Health(context.Background())
+	if report.Status != HealthDown || report.Error == "" || report.Pool == nil || report.Pool.Max != 1 {
+		t.Errorf("got %+v, want a down report with pool stats", report)
+	}
+}
@@ -0,0 +1,210 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrManagerClosed is returned by Manager.DB after Close.
+var ErrManagerClosed = errors.New("manager closed")
+
+// ManagerOptions configures a Manager.
+type ManagerOptions struct {
+	// DSN returns the connection string of a tenant's database. Required.
+	DSN func(ctx context.Context, tenantID string) (string, error)
+
+	// Connect tunes each tenant's pool. Keep MaxConns low: every open
+	// tenant holds its own pool.
+	Connect ConnectOptions
+
+	// Setup runs once on each new pool before it is handed out, e.g. to apply
+	// the tenant's pending migrations. A failed Setup closes the pool, and the
+	// next DB call for the tenant tries again.
+	Setup func(ctx context.Context, tenantID string, db *DB) error
+
+	// MaxOpen closes the least recently used pool when more are open. Zero
+	// means no limit.
+	MaxOpen int
+
+	// IdleTimeout closes pools that haven't been used for this long. Zero
+	// keeps them open.
+	IdleTimeout time.Duration
+}
+
+// Manager keeps a connection pool per tenant for database-per-tenant
+// deployments. Pools are opened on first use and closed when evicted.
+type Manager struct {
+	opts ManagerOptions
+	now  func() time.Time
+
+	mu      sync.Mutex
+	tenants map[string]*tenantPool
+	closed  bool
+}
+
+type tenantPool struct {
+	ready    chan struct{} // closed once db or err is set
+	db       *DB
+	err      error
+	lastUsed time.Time
+}
+
+// NewManager creates a Manager. It opens no connections until DB is called.
+//
+//	tenants := runtime.NewManager(runtime.ManagerOptions{
+//		DSN: func(ctx context.Context, id string) (string, error) {
+//			return fmt.Sprintf("postgres://app@db/tenant_%s", id), nil
+//		},
+//		Setup: func(ctx context.Context, id string, db *runtime.DB) error {
+//			_, err := migration.NewRunner(db.Pool(), "migrations").Up(ctx)
+//			return err
+//		},
+//		MaxOpen:     100,
+//		IdleTimeout: 10 * time.Minute,
+//	})
+func NewManager(opts ManagerOptions) *Manager {
+	return &Manager{
+		opts:    opts,
+		now:     time.Now,
+		tenants: make(map[string]*tenantPool),
+	}
+}
+
+// DB returns the pool of tenantID's database, opening it and running Setup
+// if it isn't open. Concurrent calls for the same tenant share one pool.
+func (m *Manager) DB(ctx context.Context, tenantID string) (*DB, error) {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return nil, ErrManagerClosed
+	}
+	t, ok := m.tenants[tenantID]
+	if !ok {
+		t = &tenantPool{ready: make(chan struct{})}
+		m.tenants[tenantID] = t
+	}
+	t.lastUsed = m.now()
+	m.evictLocked()
+	m.mu.Unlock()
+
+	if !ok {
+		t.db, t.err = m.open(ctx, tenantID)
+		m.mu.Lock()
+		if t.err == nil && m.closed {
+			t.db.Close()
+			t.db, t.err = nil, ErrManagerClosed
+		}
+		if t.err != nil && m.tenants[tenantID] == t {
+			delete(m.tenants, tenantID)
+		}
+		close(t.ready)
+		m.mu.Unlock()
+	}
+
+	select {
+	case <-t.ready:
+		return t.db, t.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (m *Manager) open(ctx context.Context, tenantID string) (*DB, error) {
+	if m.opts.DSN == nil {
+		return nil, fmt.Errorf("tenant %s: no DSN function configured", tenantID)
+	}
+	dsn, err := m.opts.DSN(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("tenant %s: %w", tenantID, err)
+	}
+	db, err := ConnectWithURL(ctx, dsn, m.opts.Connect)
+	if err != nil {
+		return nil, fmt.Errorf("tenant %s: %w", tenantID, err)
+	}
+	if m.opts.Setup != nil {
+		if err := m.opts.Setup(ctx, tenantID, db); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("tenant %s: setup failed: %w", tenantID, err)
+		}
+	}
+	return db, nil
+}
+
+// evictLocked closes idle pools and, over MaxOpen, the least recently used
+// ones. Pools still opening are left alone.
+func (m *Manager) evictLocked() {
+	if m.opts.IdleTimeout > 0 {
+		cutoff := m.now().Add(-m.opts.IdleTimeout)
+		for id, t := range m.tenants {
+			if t.lastUsed.Before(cutoff) {
+				m.closeLocked(id)
+			}
+		}
+	}
+	if m.opts.MaxOpen <= 0 || len(m.tenants) <= m.opts.MaxOpen {
+		return
+	}
+	ids := make([]string, 0, len(m.tenants))
+	for id := range m.tenants {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return m.tenants[ids[i]].lastUsed.Before(m.tenants[ids[j]].lastUsed)
+	})
+	for _, id := range ids {
+		if len(m.tenants) <= m.opts.MaxOpen {
+			break
+		}
+		m.closeLocked(id)
+	}
+}
+
+// closeLocked removes a tenant's pool and closes it in the background, since
+// Close waits for connections in use to be released.
+func (m *Manager) closeLocked(tenantID string) {
+	t := m.tenants[tenantID]
+	select {
+	case <-t.ready:
+	default:
+		return // still opening
+	}
+	delete(m.tenants, tenantID)
+	if t.db != nil {
+		go t.db.Close()
+	}
+}
+
+// Evict closes tenantID's pool, if open. The next DB call opens a new one.
+func (m *Manager) Evict(tenantID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.tenants[tenantID]; ok {
+		m.closeLocked(tenantID)
+	}
+}
+
+// Tenants returns the IDs of the tenants with an open pool, sorted.
+func (m *Manager) Tenants() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ids := make([]string, 0, len(m.tenants))
+	for id := range m.tenants {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Close closes every pool. DB fails afterwards.
+func (m *Manager) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closed = true
+	for id := range m.tenants {
+		m.closeLocked(id)
+	}
+}
@@ -0,0 +1,117 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestManager returns a Manager whose pools connect lazily to an address
+// nothing listens on, so no database is needed, and a clock to advance.
+func newTestManager(opts ManagerOptions) (*Manager, *time.Time) {
+	opts.DSN = func(_ context.Context, id string) (string, error) {
+		return fmt.Sprintf("postgres://localhost:1/tenant_%s", id), nil
+	}
+	opts.Connect.LazyConnect = true
+	m := NewManager(opts)
+	now := time.Unix(0, 0)
+	m.now = func() time.Time { return now }
+	return m, &now
+}
+
+func TestManagerOpensOncePerTenant(t *testing.T) {
+	var setups atomic.Int32
+	m, _ := newTestManager(ManagerOptions{
+		Setup: func(context.Context, string, *DB) error { setups.Add(1); return nil },
+	})
+	defer m.Close()
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	dbs := make([]*DB, 10)
+	for i := range dbs {
+		wg.Go(func() {
+			db, err := m.DB(ctx, "acme")
+			if err != nil {
+				t.Error(err)
+			}
+			dbs[i] = db
+		})
+	}
+	wg.Wait()
+
+	if setups.Load() != 1 {
+		t.Errorf("expected setup to run once, ran %d times", setups.Load())
+	}
+	for _, db := range dbs {
+		if db != dbs[0] {
+			t.Fatal("expected concurrent calls to share one pool")
+		}
+	}
+	if other, _ := m.DB(ctx, "globex"); other == dbs[0] {
+		t.Error("expected another tenant to get its own pool")
+	}
+}
+
+func TestManagerEviction(t *testing.T) {
+	ctx := context.Background()
+
+	m, now := newTestManager(ManagerOptions{MaxOpen: 2, IdleTimeout: time.Minute})
+	defer m.Close()
+	for _, id := range []string{"a", "b", "a", "c"} {
+		*now = now.Add(time.Second)
+		if _, err := m.DB(ctx, id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := m.Tenants(); !slices.Equal(got, []string{"a", "c"}) {
+		t.Errorf("expected the least recently used tenant to be evicted, got %v", got)
+	}
+
+	*now = now.Add(2 * time.Minute)
+	if _, err := m.DB(ctx, "d"); err != nil {
+		t.Fatal(err)
+	}
+	if got := m.Tenants(); !slices.Equal(got, []string{"d"}) {
+		t.Errorf("expected idle tenants to be evicted, got %v", got)
+	}
+
+	m.Evict("d")
+	if got := m.Tenants(); len(got) != 0 {
+		t.Errorf("expected no open tenants, got %v", got)
+	}
+}
+
+func TestManagerSetupFailure(t *testing.T) {
+	fail := true
+	m, _ := newTestManager(ManagerOptions{
+		Setup: func(context.Context, string, *DB) error {
+			if fail {
+				return errors.New("migration failed")
+			}
+			return nil
+		},
+	})
+	ctx := context.Background()
+
+	if _, err := m.DB(ctx, "acme"); err == nil {
+		t.Fatal("expected the setup error")
+	}
+	if got := m.Tenants(); len(got) != 0 {
+		t.Errorf("expected the failed pool to be dropped, got %v", got)
+	}
+	fail = false
+	if _, err := m.DB(ctx, "acme"); err != nil {
+		t.Errorf("expected a retry to succeed, got %v", err)
+	}
+
+	m.Close()
+	if _, err := m.DB(ctx, "acme"); !errors.Is(err, ErrManagerClosed) {
+		t.Errorf("expected ErrManagerClosed, got %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+// Package schedule runs periodic jobs, such as retention sweeps or
+// materialized view refreshes, on exactly one of the instances sharing a
+// database: an advisory lock keeps two from running a job at once, and the
+// last run recorded in Table keeps the others from running it again before
+// its interval is up.
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/marshallshelly/pebble-orm/pkg/runtime"
+)
+
+// Table records each job's last successful run. Setup creates it.
+const Table = "pebble_schedule"
+
+const setupSQL = `CREATE TABLE IF NOT EXISTS ` + Table + ` (
+    name text PRIMARY KEY,
+    last_run timestamptz,
+    last_error text,
+    updated_at timestamptz NOT NULL DEFAULT now()
+)`
+
+// Setup creates Table if it doesn't exist. Call it once, at deploy or
+// startup, before running jobs; migrations leave the table alone.
+// Usage: err := schedule.Setup(ctx, db)
+func Setup(ctx context.Context, db *runtime.DB) error {
+	return setup(ctx, db.Pool())
+}
+
+func setup(ctx context.Context, conn session) error {
+	if _, err := conn.Exec(ctx, setupSQL); err != nil {
+		return fmt.Errorf("schedule: failed to create %s: %w", Table, err)
+	}
+	return nil
+}
+
+// Options tunes RunExclusive.
+type Options struct {
+	// CheckInterval is how often to check whether the job is due. Default
+	// a tenth of the interval, between 1s and 1m.
+	CheckInterval time.Duration
+
+	// OnError is called with each failed run or check. RunExclusive keeps
+	// going either way; a failed run is retried at the next check.
+	OnError func(err error)
+}
+
+// RunExclusive runs fn every interval on one instance at a time until ctx
+// is done, then returns ctx's error. The first run is as soon as the job is
+// due, which, after a restart, may be right away.
+// Usage: go schedule.RunExclusive(ctx, db, "retention-sweep", time.Hour, sweep)
+func RunExclusive(ctx context.Context, db *runtime.DB, name string, interval time.Duration, fn func(ctx context.Context) error, opts ...Options) error {
+	var o Options
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	check := o.CheckInterval
+	if check <= 0 {
+		check = min(max(interval/10, time.Second), time.Minute)
+	}
+
+	ticker := time.NewTicker(check)
+	defer ticker.Stop()
+	for {
+		if _, err := Run(ctx, db, name, interval, fn); err != nil && ctx.Err() == nil && o.OnError != nil {
+			o.OnError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Run runs fn once if no other instance is running the job and its last
+// successful run is at least interval ago, reporting whether it ran. A
+// failed run is recorded but doesn't count, so the job stays due. Table
+// must exist; see Setup.
+// Usage: ran, err := schedule.Run(ctx, db, "refresh-daily-sales", 15*time.Minute, refresh)
+func Run(ctx context.Context, db *runtime.DB, name string, interval time.Duration, fn func(ctx context.Context) error) (bool, error) {
+	// The advisory lock belongs to a session, so it is taken, checked and
+	// released on one connection.
+	conn, err := db.Pool().Acquire(ctx)
+	if err != nil {
+		return false, fmt.Errorf("schedule: %s: %w", name, err)
+	}
+	defer conn.Release()
+	return run(ctx, conn, name, interval, fn)
+}
+
+// session is the connection a run holds its lock on, a *pgxpool.Conn, or
+// the pool Setup creates the table with.
+type session interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+func run(ctx context.Context, conn session, name string, interval time.Duration, fn func(ctx context.Context) error) (bool, error) {
+	key := Table + ":" + name
+	var locked bool
+	if err := conn.QueryRow(ctx, "SELECT pg_try_advisory_lock(hashtextextended($1, 0))", key).Scan(&locked); err != nil {
+		return false, fmt.Errorf("schedule: %s: failed to lock: %w", name, err)
+	}
+	if !locked {
+		return false, nil // another instance is running it
+	}
+	defer func() {
+		_, _ = conn.Exec(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock(hashtextextended($1, 0))", key)
+	}()
+
+	var started time.Time
+	var lastRun *time.Time
+	if err := conn.QueryRow(ctx, "SELECT now(), (SELECT last_run FROM "+Table+" WHERE name = $1)", name).Scan(&started, &lastRun); err != nil {
+		return false, fmt.Errorf("schedule: %s: failed to read last run: %w", name, err)
+	}
+	if lastRun != nil && started.Sub(*lastRun) < interval {
+		return false, nil
+	}
+
+	if err := fn(ctx); err != nil {
+		_, _ = conn.Exec(context.WithoutCancel(ctx),
+			"INSERT INTO "+Table+" (name, last_error) VALUES ($1, $2) ON CONFLICT (name) DO UPDATE SET last_error = $2, updated_at = now()",
+			name, err.Error())
+		return true, fmt.Errorf("schedule: %s: %w", name, err)
+	}
+	// Runs are spaced from start to start, whatever they take.
+	if _, err := conn.Exec(ctx,
+		"INSERT INTO "+Table+" (name, last_run) VALUES ($1, $2) ON CONFLICT (name) DO UPDATE SET last_run = $2, last_error = NULL, updated_at = now()",
+		name, started); err != nil {
+		return true, fmt.Errorf("schedule: %s: failed to record run: %w", name, err)
+	}
+	return true, nil
+}
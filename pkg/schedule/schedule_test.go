@@ -0,0 +1,108 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// fakeSession answers the lock with locked and the last run query with
+// now and lastRun, recording the statements it runs.
+type fakeSession struct {
+	locked  bool
+	now     time.Time
+	lastRun *time.Time
+	sql     []string
+	args    [][]any
+}
+
+func (s *fakeSession) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	s.sql = append(s.sql, sql)
+	s.args = append(s.args, args)
+	return pgconn.NewCommandTag("SELECT 1"), nil
+}
+
+func (s *fakeSession) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	s.sql = append(s.sql, sql)
+	s.args = append(s.args, args)
+	return fakeRow(func(dest ...any) error {
+		if strings.Contains(sql, "pg_try_advisory_lock") {
+			*dest[0].(*bool) = s.locked
+			return nil
+		}
+		*dest[0].(*time.Time) = s.now
+		*dest[1].(**time.Time) = s.lastRun
+		return nil
+	})
+}
+
+type fakeRow func(dest ...any) error
+
+func (r fakeRow) Scan(dest ...any) error { return r(dest...) }
+
+func TestSetup(t *testing.T) {
+	s := &fakeSession{}
+	if err := setup(context.Background(), s); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.sql) != 1 || !strings.HasPrefix(s.sql[0], "CREATE TABLE IF NOT EXISTS "+Table) {
+		t.Errorf("expected the table to be created, got %q", s.sql)
+	}
+}
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	recent, old := now.Add(-30*time.Minute), now.Add(-2*time.Hour)
+
+	tests := []struct {
+		name    string
+		locked  bool
+		lastRun *time.Time
+		fail    error
+		wantRan bool
+	}{
+		{"locked elsewhere", false, nil, nil, false},
+		{"never run", true, nil, nil, true},
+		{"ran recently", true, &recent, nil, false},
+		{"due", true, &old, nil, true},
+		{"failed", true, &old, errors.New("boom"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &fakeSession{locked: tt.locked, now: now, lastRun: tt.lastRun}
+			calls := 0
+			ran, err := run(ctx, s, "sweep", time.Hour, func(ctx context.Context) error {
+				calls++
+				return tt.fail
+			})
+			if ran != tt.wantRan || calls != map[bool]int{true: 1}[tt.wantRan] {
+				t.Fatalf("ran = %v after %d calls, want %v", ran, calls, tt.wantRan)
+			}
+			if !errors.Is(err, tt.fail) || (tt.fail == nil) != (err == nil) {
+				t.Errorf("err = %v, want %v", err, tt.fail)
+			}
+			if strings.Contains(strings.Join(s.sql, "\n"), "CREATE TABLE") {
+				t.Errorf("expected Run to leave creating the table to Setup, got %q", s.sql)
+			}
+			if tt.locked && !strings.Contains(s.sql[len(s.sql)-1], "pg_advisory_unlock") {
+				t.Errorf("expected the lock to be released last, got %q", s.sql)
+			}
+			if !tt.wantRan {
+				return
+			}
+			record := s.sql[len(s.sql)-2]
+			switch {
+			case tt.fail == nil && (!strings.Contains(record, "last_run = $2") || s.args[len(s.args)-2][1] != now):
+				t.Errorf("expected the run to be recorded from its start, got %s %v", record, s.args[len(s.args)-2])
+			case tt.fail != nil && !strings.Contains(record, "last_error = $2"):
+				t.Errorf("expected the failure to be recorded, got %s", record)
+			}
+		})
+	}
+}
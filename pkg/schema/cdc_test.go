@@ -0,0 +1,23 @@
+package schema
+
+import "testing"
+
+func TestParseCDCFromComment(t *testing.T) {
+	tests := []struct {
+		comment string
+		want    bool
+	}{
+		{"// cdc: true", true},
+		{"//cdc:true", true},
+		{"// cdc: false", false},
+		{"// Enables cdc: true", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.comment, func(t *testing.T) {
+			if got := ParseCDCFromComment(tt.comment); got != tt.want {
+				t.Errorf("ParseCDCFromComment(%q) = %v, want %v", tt.comment, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package schema
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Codec converts a Go type to and from a value the PostgreSQL driver can bind
+// and decode. Codecs are an alternative to implementing driver.Valuer and
+// sql.Scanner on the type itself, which is not possible for types from other
+// packages (money types, encrypted blobs, third-party enums, ...).
+type Codec struct {
+	encode func(any) (any, error)
+	decode func(any) (any, error)
+}
+
+// Encode converts a Go value into a driver value for binding.
+func (c *Codec) Encode(v any) (any, error) {
+	return c.encode(v)
+}
+
+// Decode converts a driver value (int64, float64, bool, []byte, string or
+// time.Time, as in database/sql) into the Go value. src is never nil; NULL
+// leaves the field at its zero value.
+func (c *Codec) Decode(src any) (any, error) {
+	return c.decode(src)
+}
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = make(map[reflect.Type]*Codec)
+)
+
+// RegisterCodec registers encode/decode functions for the Go type T. The
+// builders use them when binding insert values and scanning rows, for fields
+// of type T and *T. The column's SQL type still comes from the struct tag
+// (e.g. `po:"price,numeric(12,2)"`) or TypeMapper.RegisterType.
+//
+// Example:
+//
+//	schema.RegisterCodec(
+//	    func(m money.Money) (any, error) { return m.String(), nil },
+//	    func(src any) (money.Money, error) { return money.Parse(src.(string)) },
+//	)
+func RegisterCodec[T any](encode func(T) (any, error), decode func(any) (T, error)) {
+	codec := &Codec{
+		encode: func(v any) (any, error) {
+			t, ok := v.(T)
+			if !ok {
+				return nil, fmt.Errorf("codec for %s: cannot encode %T", reflect.TypeFor[T](), v)
+			}
+			return encode(t)
+		},
+		decode: func(src any) (any, error) {
+			return decode(src)
+		},
+	}
+
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	codecs[reflect.TypeFor[T]()] = codec
+}
+
+// LookupCodec returns the codec registered for t, if any.
+func LookupCodec(t reflect.Type) (*Codec, bool) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	codec, ok := codecs[t]
+	return codec, ok
+}
+
+// UnregisterCodec removes the codec registered for T.
+func UnregisterCodec[T any]() {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	delete(codecs, reflect.TypeFor[T]())
+}
@@ -0,0 +1,63 @@
+package schema
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+type codecCents int64
+
+func TestRegisterCodec(t *testing.T) {
+	RegisterCodec(
+		func(c codecCents) (any, error) { return fmt.Sprintf("%d.%02d", c/100, c%100), nil },
+		func(src any) (codecCents, error) {
+			s, ok := src.(string)
+			if !ok {
+				return 0, fmt.Errorf("unexpected %T", src)
+			}
+			f, err := strconv.ParseFloat(s, 64)
+			return codecCents(f*100 + 0.5), err
+		},
+	)
+	defer UnregisterCodec[codecCents]()
+
+	codec, ok := LookupCodec(reflect.TypeFor[codecCents]())
+	if !ok {
+		t.Fatal("expected codec to be registered")
+	}
+
+	t.Run("encode", func(t *testing.T) {
+		got, err := codec.Encode(codecCents(1999))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != "19.99" {
+			t.Errorf("expected %q, got %v", "19.99", got)
+		}
+	})
+
+	t.Run("decode", func(t *testing.T) {
+		got, err := codec.Decode("19.99")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != codecCents(1999) {
+			t.Errorf("expected 1999, got %v", got)
+		}
+	})
+
+	t.Run("encode wrong type", func(t *testing.T) {
+		if _, err := codec.Encode("not cents"); err == nil {
+			t.Error("expected an error encoding a value of the wrong type")
+		}
+	})
+
+	t.Run("unregister", func(t *testing.T) {
+		UnregisterCodec[codecCents]()
+		if _, ok := LookupCodec(reflect.TypeFor[codecCents]()); ok {
+			t.Error("expected codec to be removed")
+		}
+	})
+}
@@ -0,0 +1,24 @@
+package schema
+
+import "testing"
+
+func TestBuildColumn_Collate(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want string
+	}{
+		{`name,text,collate("en_US")`, "en_US"},
+		{`name,text,collate(C)`, "C"},
+		{`name,text,collate:und-x-icu`, "und-x-icu"},
+		{`name,text`, ""},
+	}
+	for _, tt := range tests {
+		opts, err := ParseTag(tt.tag)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := BuildColumn(opts, FieldMeta{}).Collation; got != tt.want {
+			t.Errorf("%s: Collation = %q, want %q", tt.tag, got, tt.want)
+		}
+	}
+}
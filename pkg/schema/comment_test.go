@@ -0,0 +1,37 @@
+package schema
+
+import "testing"
+
+func TestParseTableCommentFromComment(t *testing.T) {
+	tests := []struct {
+		comment  string
+		expected string
+	}{
+		{"// table_comment: Customer invoices, one per period", "Customer invoices, one per period"},
+		{"//table_comment:Audit trail  ", "Audit trail"},
+		{"// table_name: invoices", ""},
+		{"// See table_comment: elsewhere", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.comment, func(t *testing.T) {
+			if got := ParseTableCommentFromComment(tt.comment); got != tt.expected {
+				t.Errorf("ParseTableCommentFromComment(%q) = %q, want %q", tt.comment, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBuildColumn_Comment(t *testing.T) {
+	opts, err := ParseTag("total,numeric(12,2),comment(Gross amount, incl. tax)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	col := BuildColumn(opts, FieldMeta{GoField: "Total"})
+	if col.Comment != "Gross amount, incl. tax" {
+		t.Errorf("Comment = %q", col.Comment)
+	}
+	if col.SQLType != "numeric(12,2)" {
+		t.Errorf("SQLType = %q", col.SQLType)
+	}
+}
@@ -0,0 +1,52 @@
+package schema
+
+import "strings"
+
+// defaultShorthands expand a bare word in default(...) to a function call,
+// per type family: default(uuid) on a uuid column, default(now) on a date
+// or time column.
+var defaultShorthands = map[string]map[string]string{
+	"uuid":      {"uuid": "gen_random_uuid()"},
+	"timestamp": {"now": "now()", "today": "CURRENT_DATE"},
+}
+
+// defaultKeywords are the SQL keywords a default may name without quotes.
+var defaultKeywords = map[string]bool{
+	"NULL": true, "TRUE": true, "FALSE": true,
+	"CURRENT_TIMESTAMP": true, "CURRENT_TIME": true, "CURRENT_DATE": true,
+	"LOCALTIMESTAMP": true, "LOCALTIME": true,
+	"CURRENT_USER": true, "SESSION_USER": true, "CURRENT_SCHEMA": true,
+}
+
+// DefaultExpression returns the SQL for a default(value) tag option on col.
+// Shorthands are expanded (uuid on a uuid column, now on a timestamp one),
+// and a bare literal on a text, enum or JSON column is quoted, so
+// default(draft) means DEFAULT 'draft' rather than a column reference.
+// Quoted literals, function calls, casts, numbers and SQL keywords are
+// returned unchanged.
+func DefaultExpression(value string, col ColumnMetadata) string {
+	value = strings.TrimSpace(value)
+	family := typeFamily(col.SQLType)
+	if expansion, ok := defaultShorthands[family][strings.ToLower(value)]; ok {
+		return expansion
+	}
+	if family != "text" && family != "json" && col.EnumValues == nil {
+		return value
+	}
+	if value == "" || strings.ContainsAny(value, "'(") || strings.Contains(value, "::") ||
+		defaultKeywords[strings.ToUpper(value)] {
+		return value
+	}
+	return "'" + value + "'"
+}
+
+// isDefaultShorthand reports whether value is a default shorthand for any
+// column type.
+func isDefaultShorthand(value string) bool {
+	for _, shorthands := range defaultShorthands {
+		if _, ok := shorthands[strings.ToLower(strings.TrimSpace(value))]; ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDefaultExpression(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		sqlType string
+		enum    []string
+		want    string
+	}{
+		{"uuid shorthand", "uuid", "uuid", nil, "gen_random_uuid()"},
+		{"now shorthand", "now", "timestamptz", nil, "now()"},
+		{"today shorthand", "today", "date", nil, "CURRENT_DATE"},
+		{"function call", "now()", "timestamptz", nil, "now()"},
+		{"bare text", "draft", "text", nil, "'draft'"},
+		{"bare varchar", "in review", "varchar(20)", nil, "'in review'"},
+		{"quoted text", "'draft'", "text", nil, "'draft'"},
+		{"text number", "0", "text", nil, "'0'"},
+		{"text keyword", "NULL", "text", nil, "NULL"},
+		{"text function", "current_setting('app.user')", "text", nil, "current_setting('app.user')"},
+		{"text cast", "'x'::text", "text", nil, "'x'::text"},
+		{"uuid on text", "uuid", "text", nil, "'uuid'"},
+		{"enum", "pending", "order_status", []string{"pending", "shipped"}, "'pending'"},
+		{"jsonb", "{}", "jsonb", nil, "'{}'"},
+		{"number", "0", "integer", nil, "0"},
+		{"boolean", "true", "boolean", nil, "true"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			col := ColumnMetadata{SQLType: tt.sqlType, EnumValues: tt.enum}
+			if got := DefaultExpression(tt.value, col); got != tt.want {
+				t.Errorf("DefaultExpression(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultShorthandTags(t *testing.T) {
+	type post struct {
+		ID        string `po:"id,primaryKey,uuid,default(uuid)"`
+		Status    string `po:"status,text,default(draft)"`
+		CreatedAt string `po:"created_at,timestamptz,default(now)"`
+	}
+	table, err := NewParser().Parse(reflect.TypeFor[post]())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for col, want := range map[string]string{"id": "gen_random_uuid()", "status": "'draft'", "created_at": "now()"} {
+		if c := table.GetColumnByName(col); c == nil || c.Default == nil || *c.Default != want {
+			t.Errorf("%s: default = %v, want %s", col, c, want)
+		}
+	}
+	opts, _ := ParseTag("id,uuid,default(uuid)")
+	if problems := ValidateTag(opts, FieldMeta{InferredType: "uuid"}); len(problems) != 0 {
+		t.Errorf("default(uuid): unexpected problems %v", problems)
+	}
+}
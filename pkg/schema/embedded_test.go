@@ -0,0 +1,123 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type EmbedAuditFields struct {
+	CreatedAt time.Time  `po:"created_at,timestamptz,default(NOW()),notNull"`
+	DeletedAt *time.Time `po:"deleted_at,timestamptz,index"`
+}
+
+type embedAddress struct {
+	Street  string `po:"street,text,notNull"`
+	City    string `po:"city,text"`
+	Country string `po:"country,varchar(2),fk:countries(code)"`
+}
+
+type embedCustomer struct {
+	ID               int          `po:"id,primaryKey,serial"`
+	Billing          embedAddress `po:"embedded,prefix(billing_)"`
+	Shipping         embedAddress `po:"embedded,prefix(shipping_)"`
+	EmbedAuditFields `po:"embedded"`
+}
+
+type embedNamedColumn struct {
+	ID       int    `po:"id,primaryKey,serial"`
+	Embedded string `po:"embedded,text,notNull"`
+}
+
+type embedUntagged struct {
+	ID int `po:"id,primaryKey,serial"`
+	EmbedAuditFields
+}
+
+type embedBadField struct {
+	ID   int    `po:"id,primaryKey,serial"`
+	Name string `po:"embedded"`
+}
+
+func TestParser_EmbeddedStructs(t *testing.T) {
+	table, err := NewParser().Parse(reflect.TypeFor[embedCustomer]())
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	t.Run("columns are flattened with prefixes", func(t *testing.T) {
+		want := []struct{ name, goField string }{
+			{"id", "ID"},
+			{"billing_street", "Billing.Street"},
+			{"billing_city", "Billing.City"},
+			{"billing_country", "Billing.Country"},
+			{"shipping_street", "Shipping.Street"},
+			{"shipping_city", "Shipping.City"},
+			{"shipping_country", "Shipping.Country"},
+			{"created_at", "EmbedAuditFields.CreatedAt"},
+			{"deleted_at", "EmbedAuditFields.DeletedAt"},
+		}
+		if len(table.Columns) != len(want) {
+			t.Fatalf("expected %d columns, got %d: %+v", len(want), len(table.Columns), table.Columns)
+		}
+		for i, w := range want {
+			col := table.Columns[i]
+			if col.Name != w.name || col.GoField != w.goField {
+				t.Errorf("column %d: got (%s, %s), want (%s, %s)", i, col.Name, col.GoField, w.name, w.goField)
+			}
+			if col.Position != i {
+				t.Errorf("column %s: position %d, want %d", col.Name, col.Position, i)
+			}
+		}
+	})
+
+	t.Run("indexes and foreign keys use prefixed names", func(t *testing.T) {
+		if len(table.ForeignKeys) != 2 {
+			t.Fatalf("expected 2 foreign keys, got %d", len(table.ForeignKeys))
+		}
+		if got := table.ForeignKeys[0].Columns; len(got) != 1 || got[0] != "billing_country" {
+			t.Errorf("expected FK on billing_country, got %v", got)
+		}
+		if len(table.Indexes) != 1 || table.Indexes[0].Columns[0] != "deleted_at" {
+			t.Errorf("expected index on deleted_at, got %+v", table.Indexes)
+		}
+	})
+
+	t.Run("promoted field lookup", func(t *testing.T) {
+		if col := table.GetColumnByField("CreatedAt"); col == nil || col.Name != "created_at" {
+			t.Errorf("expected CreatedAt to resolve to created_at, got %+v", col)
+		}
+		if col := table.GetColumnByField("Street"); col != nil {
+			t.Errorf("expected ambiguous Street to resolve to nil, got %s", col.Name)
+		}
+		if col := table.GetColumnByField("Shipping.Street"); col == nil || col.Name != "shipping_street" {
+			t.Errorf("expected Shipping.Street to resolve to shipping_street, got %+v", col)
+		}
+	})
+
+	t.Run("column named embedded", func(t *testing.T) {
+		table, err := NewParser().Parse(reflect.TypeFor[embedNamedColumn]())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if col := table.GetColumnByName("embedded"); col == nil || col.SQLType != "text" || col.Nullable {
+			t.Errorf("expected a text column named embedded, got %+v", table.Columns)
+		}
+	})
+
+	t.Run("untagged embedded struct is skipped", func(t *testing.T) {
+		table, err := NewParser().Parse(reflect.TypeFor[embedUntagged]())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(table.Columns) != 1 || table.Columns[0].Name != "id" {
+			t.Errorf("expected only the id column, got %+v", table.Columns)
+		}
+	})
+
+	t.Run("embedded tag on non-struct field", func(t *testing.T) {
+		if _, err := NewParser().Parse(reflect.TypeFor[embedBadField]()); err == nil {
+			t.Error("expected an error for po:\"embedded\" on a string field")
+		}
+	})
+}
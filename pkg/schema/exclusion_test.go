@@ -0,0 +1,28 @@
+package schema
+
+import "testing"
+
+func TestParseExclusionFromComment(t *testing.T) {
+	tests := []struct {
+		comment  string
+		wantName string
+		wantExpr string
+	}{
+		{"// exclude: no_overlap USING gist (room_id WITH =, during WITH &&)", "no_overlap", "USING gist (room_id WITH =, during WITH &&)"},
+		{"//exclude: one_active USING btree (user_id WITH =) WHERE (active);", "one_active", "USING btree (user_id WITH =) WHERE (active)"},
+		{"// exclude: no_overlap", "", ""},
+		{"// index: idx_x ON (x)", "", ""},
+	}
+	for _, tt := range tests {
+		c := ParseExclusionFromComment(tt.comment)
+		if tt.wantName == "" {
+			if c != nil {
+				t.Errorf("%s: expected nil, got %+v", tt.comment, c)
+			}
+			continue
+		}
+		if c == nil || c.Name != tt.wantName || c.Expression != tt.wantExpr || c.Type != ExclusionConstraint {
+			t.Errorf("%s: got %+v", tt.comment, c)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package schema
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseExtensionsFromComment(t *testing.T) {
+	tests := []struct {
+		comment string
+		want    []string
+	}{
+		{"// extensions: pg_trgm", []string{"pg_trgm"}},
+		{"// extensions: pg_trgm, btree_gin ,uuid-ossp", []string{"pg_trgm", "btree_gin", "uuid-ossp"}},
+		{"//extensions:pgcrypto", []string{"pgcrypto"}},
+		{"// table_name: extensions", nil},
+		{"// Needs extensions: pg_trgm", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.comment, func(t *testing.T) {
+			if got := ParseExtensionsFromComment(tt.comment); !slices.Equal(got, tt.want) {
+				t.Errorf("ParseExtensionsFromComment(%q) = %v, want %v", tt.comment, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package schema
+
+import "testing"
+
+func TestColumnForeignKey_Timing(t *testing.T) {
+	tests := []struct {
+		tag                   string
+		wantDeferrable        bool
+		wantInitiallyDeferred bool
+	}{
+		{"parent_id,fk:nodes(id)", false, false},
+		{"parent_id,fk:nodes(id),deferrable", true, false},
+		{"parent_id,fk:nodes(id),initiallyDeferred", true, true},
+		{"parent_id,fk:nodes(id),onDelete:CASCADE,deferrable,initiallyDeferred", true, true},
+	}
+	for _, tt := range tests {
+		opts, err := ParseTag(tt.tag)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fk, ok := ColumnForeignKey(opts, "nodes")
+		if !ok {
+			t.Fatalf("%s: expected a foreign key", tt.tag)
+		}
+		if fk.Deferrable != tt.wantDeferrable || fk.InitiallyDeferred != tt.wantInitiallyDeferred {
+			t.Errorf("%s: Deferrable=%v InitiallyDeferred=%v, want %v/%v",
+				tt.tag, fk.Deferrable, fk.InitiallyDeferred, tt.wantDeferrable, tt.wantInitiallyDeferred)
+		}
+	}
+}
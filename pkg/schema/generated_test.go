@@ -2,6 +2,7 @@ package schema
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -127,3 +128,35 @@ func findColumn(columns []ColumnMetadata, name string) *ColumnMetadata {
 	}
 	return nil
 }
+
+func TestValidateGeneratedTag(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want string
+	}{
+		{"total,generated:price * (1 + tax_rate),stored", ""},
+		{"label,generated:first_name || '; ' || last_name", ""},
+		{"total,generated:(price * 2", "unmatched ("},
+		{"total,generated:price) * (2", "unmatched )"},
+		{"total,generated:price; DROP TABLE orders", "single expression"},
+		{"label,generated:'open", "unterminated string"},
+		{"total,generated:price * 2,default:0", "can't also have default"},
+		{"id,generated:a + b,primaryKey", "can't also have primaryKey"},
+		{"total,generated:price * 2,identity", "can't also have identity"},
+		{"total,generated:price * 2,stored,virtual", "both stored and virtual"},
+		{"total,generated(),stored", "needs an expression, generated(expr)"},
+	}
+	for _, tt := range tests {
+		opts, err := ParseTag(tt.tag)
+		if err != nil {
+			t.Fatal(err)
+		}
+		problems := strings.Join(ValidateTag(opts, FieldMeta{}), "; ")
+		if tt.want == "" && problems != "" {
+			t.Errorf("%s: unexpected problems: %s", tt.tag, problems)
+		}
+		if tt.want != "" && !strings.Contains(problems, tt.want) {
+			t.Errorf("%s: problems %q, want %q", tt.tag, problems, tt.want)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package schema
+
+import "testing"
+
+func TestParseHistoryFromComment(t *testing.T) {
+	tests := []struct {
+		comment string
+		want    bool
+	}{
+		{"// history: true", true},
+		{"//history:true", true},
+		{"// history: false", false},
+		{"// Keeps history: true", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.comment, func(t *testing.T) {
+			if got := ParseHistoryFromComment(tt.comment); got != tt.want {
+				t.Errorf("ParseHistoryFromComment(%q) = %v, want %v", tt.comment, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateTable_HistoryOnView(t *testing.T) {
+	view := &TableMetadata{Name: "active_users", History: true, View: &ViewMetadata{Query: "SELECT 1"}}
+	if errs := ValidateTable(view); len(errs) != 1 {
+		t.Errorf("expected a history view to be rejected, got %v", errs)
+	}
+}
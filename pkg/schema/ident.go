@@ -37,12 +37,29 @@ var reservedIdents = map[string]bool{
 // generated SQL. Non-reserved names are returned unchanged, which preserves
 // PostgreSQL's case-folding semantics for everything that already works.
 func QuoteReservedIdent(name string) string {
-	if reservedIdents[strings.ToLower(name)] {
+	if IsReservedIdent(name) {
 		return `"` + name + `"`
 	}
 	return name
 }
 
+// IsReservedIdent reports whether name is a PostgreSQL reserved keyword,
+// ignoring case. It doesn't allocate.
+func IsReservedIdent(name string) bool {
+	var buf [24]byte // longer than any keyword
+	if len(name) > len(buf) {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if 'A' <= c && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		buf[i] = c
+	}
+	return reservedIdents[string(buf[:len(name)])]
+}
+
 // QuoteReservedIdents applies QuoteReservedIdent to every name in the slice,
 // returning a new slice.
 func QuoteReservedIdents(names []string) []string {
@@ -52,3 +69,35 @@ func QuoteReservedIdents(names []string) []string {
 	}
 	return out
 }
+
+// DefaultSchema is the PostgreSQL schema unqualified tables resolve to.
+const DefaultSchema = "public"
+
+// QualifyTableName joins a schema and table name as "schema.table". An empty
+// or public schema returns the bare table name, so SQL generated for models
+// in the default schema is unchanged.
+func QualifyTableName(schemaName, table string) string {
+	if schemaName == "" || schemaName == DefaultSchema {
+		return table
+	}
+	return schemaName + "." + table
+}
+
+// SplitQualifiedName splits a possibly schema-qualified table name into its
+// schema and table parts. Unqualified names return an empty schema.
+func SplitQualifiedName(name string) (schemaName, table string) {
+	if before, after, ok := strings.Cut(name, "."); ok {
+		return before, after
+	}
+	return "", name
+}
+
+// QuoteQualifiedIdent applies QuoteReservedIdent to each part of a possibly
+// schema-qualified name, e.g. billing.user becomes billing."user".
+func QuoteQualifiedIdent(name string) string {
+	schemaName, table := SplitQualifiedName(name)
+	if schemaName == "" {
+		return QuoteReservedIdent(table)
+	}
+	return QuoteReservedIdent(schemaName) + "." + QuoteReservedIdent(table)
+}
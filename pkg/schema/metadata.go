@@ -5,6 +5,8 @@ import "slices"
 
 import "reflect"
 
+import "strings"
+
 // EnumType represents a PostgreSQL ENUM type.
 type EnumType struct {
 	Name   string   // PostgreSQL enum type name (e.g., "order_status")
@@ -14,6 +16,7 @@ type EnumType struct {
 // TableMetadata represents a database table with all its metadata.
 type TableMetadata struct {
 	Name          string                 // Table name in database
+	Schema        string                 // PostgreSQL schema (namespace); empty means public
 	GoType        reflect.Type           // Go struct type
 	Columns       []ColumnMetadata       // Table columns
 	PrimaryKey    *PrimaryKeyMetadata    // Primary key definition
@@ -23,12 +26,26 @@ type TableMetadata struct {
 	Relationships []RelationshipMetadata // Relationships to other tables
 	EnumTypes     []EnumType             // Enum types used by this table
 	Comment       string                 // Table comment
+	View          *ViewMetadata          // View definition (nil for base tables)
+	Extensions    []string               // PostgreSQL extensions the table needs (pg_trgm, pgcrypto, ...)
+	Sequences     []SequenceMetadata     // Standalone sequences declared by the model
+	RenamedFrom   string                 // Previous table name, from a // renamed_from: directive
+	CDC           bool                   // Capture row changes for package cdc, from a // cdc: true directive
+	History       bool                   // Keep past row versions in <table>_history, from a // history: true directive
+	DefaultScope  string                 // Filter ANDed into every SELECT, UPDATE and DELETE, from a // default_scope: directive
+}
+
+// ViewMetadata describes a model backed by a view instead of a table.
+// Views are read-only: the query builders refuse to insert, update or delete.
+type ViewMetadata struct {
+	Query        string // Defining SELECT query
+	Materialized bool   // MATERIALIZED VIEW rather than a plain VIEW
 }
 
 // ColumnMetadata represents a single column in a table.
 type ColumnMetadata struct {
 	Name          string           // Column name in database
-	GoField       string           // Go struct field name
+	GoField       string           // Go struct field name (dotted path for embedded struct fields)
 	GoType        reflect.Type     // Go field type
 	SQLType       string           // PostgreSQL type (e.g., "varchar(255)", "uuid")
 	Nullable      bool             // Can column be NULL
@@ -42,6 +59,25 @@ type ColumnMetadata struct {
 	EnumType      string           // PostgreSQL enum type name (e.g., "order_status"), empty if not enum
 	EnumValues    []string         // Enum values for this column (if enum type)
 	IsJSONB       bool             // Column is JSONB type (for automatic marshaling)
+	Collation     string           // Column collation (e.g., "en_US", "C"), empty for the database default
+	RenamedFrom   string           // Previous column name, from a renamedFrom(old) tag option
+	Using         string           // Cast expression for type changes, from a using(expr) tag option
+	Immutable     bool             // Set on insert, never updated (immutable tag option)
+	ReadOnly      bool             // Never written, e.g. filled by a trigger (readOnly tag option)
+	WriteOnly     bool             // Left out of SELECT * column lists, e.g. secrets (writeOnly tag option)
+	Groups        []string         // Projection groups selected together, from a group(a,b) tag option
+}
+
+// Insertable reports whether inserts may write the column: it is neither
+// readOnly nor generated.
+func (c *ColumnMetadata) Insertable() bool {
+	return !c.ReadOnly && c.Generated == nil
+}
+
+// Updatable reports whether updates may write the column: it is
+// insertable and not immutable.
+func (c *ColumnMetadata) Updatable() bool {
+	return c.Insertable() && !c.Immutable
 }
 
 // IdentityColumn represents a PostgreSQL identity column (GENERATED AS IDENTITY).
@@ -49,6 +85,7 @@ type ColumnMetadata struct {
 // Introduced in PostgreSQL 10, recommended over SERIAL.
 type IdentityColumn struct {
 	Generation IdentityGeneration // ALWAYS or BY DEFAULT
+	Sequence   *SequenceOptions   // Options of the backing sequence (nil for defaults)
 }
 
 // IdentityGeneration specifies when identity values are generated.
@@ -64,6 +101,24 @@ const (
 	IdentityByDefault IdentityGeneration = "BY DEFAULT"
 )
 
+// SequenceOptions holds sequence parameters. Nil fields are left at
+// PostgreSQL's defaults and are not compared when diffing.
+type SequenceOptions struct {
+	Start     *int64 // START WITH
+	Increment *int64 // INCREMENT BY
+	MinValue  *int64 // MINVALUE
+	MaxValue  *int64 // MAXVALUE
+	Cache     *int64 // CACHE
+	Cycle     *bool  // CYCLE / NO CYCLE
+}
+
+// SequenceMetadata represents a standalone sequence (CREATE SEQUENCE).
+type SequenceMetadata struct {
+	Name    string          // Sequence name, optionally schema-qualified
+	Options SequenceOptions // Sequence parameters
+	OwnedBy string          // Owning column as "table.column" (empty if not owned)
+}
+
 // GeneratedColumn represents a PostgreSQL generated column.
 type GeneratedColumn struct {
 	Expression string         // SQL expression for the generated value
@@ -96,6 +151,8 @@ type ForeignKeyMetadata struct {
 	ReferencedColumns []string        // Referenced column names
 	OnDelete          ReferenceAction // ON DELETE action
 	OnUpdate          ReferenceAction // ON UPDATE action
+	Deferrable        bool            // DEFERRABLE: the check can be postponed to commit
+	InitiallyDeferred bool            // INITIALLY DEFERRED (implies Deferrable)
 }
 
 // IndexMetadata represents a database index.
@@ -206,6 +263,23 @@ const (
 	ExclusionConstraint ConstraintType = "EXCLUDE"
 )
 
+// QualifiedName returns the schema-qualified table name ("billing.invoices").
+// Tables in the public schema return their bare name.
+func (t *TableMetadata) QualifiedName() string {
+	return QualifyTableName(t.Schema, t.Name)
+}
+
+// HistoryTable returns the qualified name of the table holding a history
+// table's past row versions ("billing.invoices_history").
+func (t *TableMetadata) HistoryTable() string {
+	return t.QualifiedName() + "_history"
+}
+
+// IsView reports whether the model is backed by a view or materialized view.
+func (t *TableMetadata) IsView() bool {
+	return t.View != nil
+}
+
 // GetColumnByName returns a column by its database name.
 func (t *TableMetadata) GetColumnByName(name string) *ColumnMetadata {
 	for i := range t.Columns {
@@ -216,14 +290,26 @@ func (t *TableMetadata) GetColumnByName(name string) *ColumnMetadata {
 	return nil
 }
 
-// GetColumnByField returns a column by its Go field name.
+// GetColumnByField returns a column by its Go field name. Columns flattened
+// from embedded structs have a dotted path (e.g. "Billing.Street"); they also
+// match on the bare field name when that is unambiguous, as with Go's
+// promoted fields.
 func (t *TableMetadata) GetColumnByField(field string) *ColumnMetadata {
 	for i := range t.Columns {
 		if t.Columns[i].GoField == field {
 			return &t.Columns[i]
 		}
 	}
-	return nil
+	var match *ColumnMetadata
+	for i := range t.Columns {
+		if strings.HasSuffix(t.Columns[i].GoField, "."+field) {
+			if match != nil {
+				return nil // ambiguous
+			}
+			match = &t.Columns[i]
+		}
+	}
+	return match
 }
 
 // PrimaryKeyColumns returns the names of primary key columns.
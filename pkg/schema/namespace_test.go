@@ -0,0 +1,100 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+)
+
+// schema: billing
+type BillingInvoice struct {
+	ID         int `po:"id,primaryKey,serial"`
+	CustomerID int `po:"customer_id,integer,notNull,fk:billing.customers(id)"`
+}
+
+func TestParseSchemaFromComment(t *testing.T) {
+	tests := []struct {
+		comment  string
+		expected string
+	}{
+		{"// schema: billing", "billing"},
+		{"//schema:analytics", "analytics"},
+		{"// schema:   audit_log", "audit_log"},
+		{"// table_name: invoices", ""},
+		{"// This struct mirrors the billing schema: see docs", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.comment, func(t *testing.T) {
+			if got := ParseSchemaFromComment(tt.comment); got != tt.expected {
+				t.Errorf("ParseSchemaFromComment(%q) = %q, want %q", tt.comment, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestQualifiedNames(t *testing.T) {
+	tests := []struct {
+		schema, table string
+		qualified     string
+		quoted        string
+	}{
+		{"", "users", "users", "users"},
+		{"public", "users", "users", "users"},
+		{"billing", "invoices", "billing.invoices", "billing.invoices"},
+		{"billing", "user", "billing.user", `billing."user"`},
+		{"", "order", "order", `"order"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.qualified, func(t *testing.T) {
+			table := &TableMetadata{Schema: tt.schema, Name: tt.table}
+			if got := table.QualifiedName(); got != tt.qualified {
+				t.Errorf("QualifiedName() = %q, want %q", got, tt.qualified)
+			}
+			if got := QuoteQualifiedIdent(table.QualifiedName()); got != tt.quoted {
+				t.Errorf("QuoteQualifiedIdent() = %q, want %q", got, tt.quoted)
+			}
+		})
+	}
+}
+
+func TestParse_SchemaDirective(t *testing.T) {
+	table, err := NewParser().Parse(reflect.TypeFor[BillingInvoice]())
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if table.Schema != "billing" {
+		t.Errorf("Schema = %q, want %q", table.Schema, "billing")
+	}
+	if table.QualifiedName() != "billing.billing_invoice" {
+		t.Errorf("QualifiedName() = %q, want %q", table.QualifiedName(), "billing.billing_invoice")
+	}
+
+	if len(table.ForeignKeys) != 1 {
+		t.Fatalf("expected 1 foreign key, got %d", len(table.ForeignKeys))
+	}
+	fk := table.ForeignKeys[0]
+	if fk.ReferencedTable != "billing.customers" {
+		t.Errorf("ReferencedTable = %q, want %q", fk.ReferencedTable, "billing.customers")
+	}
+	if fk.Name != "fk_billing_invoice_customer_id_customers" {
+		t.Errorf("foreign key name = %q", fk.Name)
+	}
+}
+
+func TestRegisterTableSchema(t *testing.T) {
+	type RegisteredSchemaModel struct {
+		ID int `po:"id,primaryKey,serial"`
+	}
+	RegisterTableSchema("RegisteredSchemaModel", "analytics")
+	defer delete(customTableSchemas, "RegisteredSchemaModel")
+
+	table, err := NewParser().Parse(reflect.TypeFor[RegisteredSchemaModel]())
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if table.Schema != "analytics" {
+		t.Errorf("Schema = %q, want %q", table.Schema, "analytics")
+	}
+}
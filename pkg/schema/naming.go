@@ -0,0 +1,114 @@
+package schema
+
+import (
+	"sync"
+	"unicode"
+)
+
+// NamingStrategy derives database names from Go names wherever a model
+// doesn't spell them out: table names of structs without a TableName method
+// or table_name directive, column names of fields tagged without one
+// (`po:",text"`), and the foreign key columns inferred for relationships.
+type NamingStrategy interface {
+	// TableName returns the table of a model struct (OrderItem → order_item).
+	TableName(structName string) string
+	// ColumnName returns the column of a struct field (CreatedAt → created_at).
+	ColumnName(fieldName string) string
+	// ForeignKey returns the column referencing a model's table (User → user_id).
+	ForeignKey(structName string) string
+}
+
+// SnakeCase is the default strategy: OrderItem → order_item, CreatedAt →
+// created_at, User → user_id.
+var SnakeCase NamingStrategy = snakeCaseNaming{}
+
+// CamelCase names tables and columns in lowerCamelCase, as some legacy
+// databases do: OrderItem → orderItem, CreatedAt → createdAt, User → userId.
+var CamelCase NamingStrategy = camelCaseNaming{}
+
+// PluralSnakeCase is SnakeCase with plural table names: User → users,
+// Category → categories, OrderItem → order_items, Person → people.
+var PluralSnakeCase = Pluralize(SnakeCase)
+
+// Naming combines one strategy for tables with another for columns and
+// foreign keys. A nil strategy falls back to SnakeCase.
+// Usage: schema.SetNamingStrategy(schema.Naming{Columns: schema.CamelCase})
+type Naming struct {
+	Tables  NamingStrategy
+	Columns NamingStrategy
+}
+
+// TableName implements NamingStrategy.
+func (n Naming) TableName(structName string) string {
+	return orSnakeCase(n.Tables).TableName(structName)
+}
+
+// ColumnName implements NamingStrategy.
+func (n Naming) ColumnName(fieldName string) string {
+	return orSnakeCase(n.Columns).ColumnName(fieldName)
+}
+
+// ForeignKey implements NamingStrategy.
+func (n Naming) ForeignKey(structName string) string {
+	return orSnakeCase(n.Columns).ForeignKey(structName)
+}
+
+func orSnakeCase(ns NamingStrategy) NamingStrategy {
+	if ns == nil {
+		return SnakeCase
+	}
+	return ns
+}
+
+var (
+	namingMu       sync.RWMutex
+	namingStrategy = SnakeCase
+)
+
+// SetNamingStrategy replaces the naming strategy used by the parser, the
+// model loader, relationship loading and so the migration planner. Set it
+// once at startup, before models are registered or parsed: metadata already
+// parsed keeps the names it was given. nil restores SnakeCase.
+// Usage: schema.SetNamingStrategy(schema.CamelCase)
+func SetNamingStrategy(ns NamingStrategy) {
+	namingMu.Lock()
+	defer namingMu.Unlock()
+	namingStrategy = orSnakeCase(ns)
+}
+
+// CurrentNamingStrategy returns the strategy set by SetNamingStrategy.
+func CurrentNamingStrategy() NamingStrategy {
+	namingMu.RLock()
+	defer namingMu.RUnlock()
+	return namingStrategy
+}
+
+type snakeCaseNaming struct{}
+
+func (snakeCaseNaming) TableName(structName string) string  { return toSnakeCase(structName) }
+func (snakeCaseNaming) ColumnName(fieldName string) string  { return toSnakeCase(fieldName) }
+func (snakeCaseNaming) ForeignKey(structName string) string { return toSnakeCase(structName) + "_id" }
+
+type camelCaseNaming struct{}
+
+func (camelCaseNaming) TableName(structName string) string { return toLowerCamelCase(structName) }
+func (camelCaseNaming) ColumnName(fieldName string) string { return toLowerCamelCase(fieldName) }
+func (camelCaseNaming) ForeignKey(structName string) string {
+	return toLowerCamelCase(structName) + "Id"
+}
+
+// toLowerCamelCase lowercases the leading word of a Go name, treating a run
+// of capitals as one initialism: UserID → userID, APIKey → apiKey.
+func toLowerCamelCase(s string) string {
+	r := []rune(s)
+	for i := range r {
+		if !unicode.IsUpper(r[i]) {
+			break
+		}
+		if i > 0 && i+1 < len(r) && unicode.IsLower(r[i+1]) {
+			break
+		}
+		r[i] = unicode.ToLower(r[i])
+	}
+	return string(r)
+}
@@ -0,0 +1,113 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNamingStrategies(t *testing.T) {
+	tests := []struct {
+		ns                      NamingStrategy
+		name, table, column, fk string
+	}{
+		{SnakeCase, "OrderItem", "order_item", "order_item", "order_item_id"},
+		{CamelCase, "OrderItem", "orderItem", "orderItem", "orderItemId"},
+		{CamelCase, "UserID", "userID", "userID", "userIDId"},
+		{CamelCase, "APIKey", "apiKey", "apiKey", "apiKeyId"},
+		{Naming{Columns: CamelCase}, "OrderItem", "order_item", "orderItem", "orderItemId"},
+	}
+	for _, tt := range tests {
+		if got := tt.ns.TableName(tt.name); got != tt.table {
+			t.Errorf("%T.TableName(%q) = %q, want %q", tt.ns, tt.name, got, tt.table)
+		}
+		if got := tt.ns.ColumnName(tt.name); got != tt.column {
+			t.Errorf("%T.ColumnName(%q) = %q, want %q", tt.ns, tt.name, got, tt.column)
+		}
+		if got := tt.ns.ForeignKey(tt.name); got != tt.fk {
+			t.Errorf("%T.ForeignKey(%q) = %q, want %q", tt.ns, tt.name, got, tt.fk)
+		}
+	}
+}
+
+type LegacyAccount struct {
+	ID        int64  `po:"id,primaryKey"`
+	FullName  string `po:",text"`
+	CreatedAt string `po:"created,text"`
+}
+
+type LegacyInvoice struct {
+	ID            int64          `po:"id,primaryKey"`
+	LegacyAccount *LegacyAccount `po:"-,belongsTo"`
+}
+
+func TestSetNamingStrategy(t *testing.T) {
+	SetNamingStrategy(CamelCase)
+	defer SetNamingStrategy(nil)
+
+	parser := NewParser()
+	table, err := parser.Parse(reflect.TypeFor[LegacyAccount]())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.Name != "legacyAccount" {
+		t.Errorf("table name %q, want legacyAccount", table.Name)
+	}
+	if table.GetColumnByName("fullName") == nil || table.GetColumnByName("created") == nil {
+		t.Errorf("unexpected columns %+v", table.Columns)
+	}
+
+	invoice, err := parser.Parse(reflect.TypeFor[LegacyInvoice]())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(invoice.Relationships) != 1 || invoice.Relationships[0].ForeignKey != "legacyAccountId" {
+		t.Errorf("unexpected relationships %+v", invoice.Relationships)
+	}
+
+	SetNamingStrategy(nil)
+	if CurrentNamingStrategy() != SnakeCase {
+		t.Error("nil did not restore SnakeCase")
+	}
+}
+
+func TestPluralize(t *testing.T) {
+	tests := []struct {
+		ns         NamingStrategy
+		name, want string
+	}{
+		{PluralSnakeCase, "User", "users"},
+		{PluralSnakeCase, "Category", "categories"},
+		{PluralSnakeCase, "Day", "days"},
+		{PluralSnakeCase, "Address", "addresses"},
+		{PluralSnakeCase, "Box", "boxes"},
+		{PluralSnakeCase, "Batch", "batches"},
+		{PluralSnakeCase, "OrderItem", "order_items"},
+		{PluralSnakeCase, "Person", "people"},
+		{PluralSnakeCase, "SalesPerson", "sales_people"},
+		{PluralSnakeCase, "Metadata", "metadata"},
+		{PluralSnakeCase, "Analysis", "analyses"},
+		{Pluralize(CamelCase), "OrderItem", "orderItems"},
+		{Pluralize(CamelCase), "TeamPerson", "teamPeople"},
+	}
+	for _, tt := range tests {
+		if got := tt.ns.TableName(tt.name); got != tt.want {
+			t.Errorf("TableName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+	if got := PluralSnakeCase.ColumnName("CreatedAt"); got != "created_at" {
+		t.Errorf("columns should not be pluralized, got %q", got)
+	}
+	if got := PluralSnakeCase.ForeignKey("Category"); got != "category_id" {
+		t.Errorf("foreign keys should stay singular, got %q", got)
+	}
+
+	RegisterPlural("Moose", "moose")
+	defer func() {
+		pluralsMu.Lock()
+		delete(plurals, "moose")
+		pluralsMu.Unlock()
+	}()
+	if got := PluralSnakeCase.TableName("Moose"); got != "moose" {
+		t.Errorf("TableName(Moose) = %q, want moose", got)
+	}
+}
@@ -10,6 +10,8 @@ import (
 	"reflect"
 	"regexp"
 	"strings"
+	"sync"
+	"sync/atomic"
 )
 
 const (
@@ -48,6 +50,68 @@ func RegisterTableName(structName, tableName string) {
 	customTableNames[structName] = tableName
 }
 
+// Global schema registry, populated by generated code alongside table names.
+var customTableSchemas = make(map[string]string) // Struct name → schema name
+
+// RegisterTableSchema registers the PostgreSQL schema a struct's table lives
+// in, as declared by a `// schema: billing` comment directive.
+func RegisterTableSchema(structName, schemaName string) {
+	customTableSchemas[structName] = schemaName
+}
+
+// Global struct comment registry, populated by generated code so table-level
+// directives are known without the model's source files.
+var (
+	customStructCommentsMu sync.RWMutex
+	customStructComments   = make(map[string][]string) // Struct name → comment lines
+)
+
+// RegisterStructComments registers the comment lines of a model struct's
+// declaration, as `pebble generate metadata` does for every model. Directives
+// are then read from these comments instead of the struct's source file,
+// which production builds usually don't ship. Registering no comments marks
+// a struct as having none.
+func RegisterStructComments(structName string, comments ...string) {
+	customStructCommentsMu.Lock()
+	defer customStructCommentsMu.Unlock()
+	customStructComments[structName] = comments
+}
+
+// registeredStructComments returns the comments registered for a struct.
+func registeredStructComments(structName string) ([]string, bool) {
+	customStructCommentsMu.RLock()
+	defer customStructCommentsMu.RUnlock()
+	comments, ok := customStructComments[structName]
+	return comments, ok
+}
+
+var (
+	sourceScanning atomic.Bool // whether directives may be read from source files
+	sourceComments sync.Map    // reflect.Type → []string, comments read from source
+)
+
+func init() {
+	sourceScanning.Store(true)
+}
+
+// SetSourceScanning enables or disables reading directives such as
+// // table_name: from model source files at runtime. It is enabled by
+// default for development; disable it in production so models rely only on
+// generated metadata (pebble generate metadata) and TableName methods, with
+// no filesystem access.
+func SetSourceScanning(enabled bool) {
+	sourceScanning.Store(enabled)
+}
+
+// Global view registry, populated by generated code for view models.
+var customViews = make(map[string]*ViewMetadata) // Struct name → view definition
+
+// RegisterView registers a struct as a read-only view model, as declared by a
+// `// view: SELECT ...` or `// materialized_view: SELECT ...` directive.
+func RegisterView(structName, query string, materialized bool) {
+	customViews[structName] = &ViewMetadata{Query: query, Materialized: materialized}
+}
+
 // Parse extracts TableMetadata from a Go struct type.
 func (p *Parser) Parse(modelType reflect.Type) (*TableMetadata, error) {
 	// Dereference pointer types
@@ -62,39 +126,32 @@ func (p *Parser) Parse(modelType reflect.Type) (*TableMetadata, error) {
 		return cached, nil
 	}
 	table := &TableMetadata{
-		Name:        p.extractTableName(modelType),
-		GoType:      modelType,
-		Columns:     make([]ColumnMetadata, 0),
-		ForeignKeys: make([]ForeignKeyMetadata, 0),
-		Indexes:     make([]IndexMetadata, 0),
-		Constraints: make([]ConstraintMetadata, 0),
-	}
-	// Parse fields
-	for field := range modelType.Fields() {
-		// Skip unexported fields
-		if !field.IsExported() {
-			continue
-		}
-		// Get tag value
-		tagValue := field.Tag.Get(StructTagKey)
-		if tagValue == "" {
-			// Skip fields without po tag
-			continue
-		}
-		// Parse tag
-		tagOpts, err := p.parseTag(tagValue)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse tag for field %s: %w", field.Name, err)
-		}
-		// Check if this is a relationship field
-		if p.isRelationshipTag(tagOpts) {
-			// Handle relationships separately (will be implemented in relationships.go)
-			continue
-		}
+		Name:         p.extractTableName(modelType),
+		Schema:       p.extractTableSchema(modelType),
+		View:         p.extractView(modelType),
+		Extensions:   splitExtensionList(p.extractDirectiveFromSource(modelType, extensionListFromComment)),
+		Comment:      p.extractDirectiveFromSource(modelType, ParseTableCommentFromComment),
+		RenamedFrom:  p.extractDirectiveFromSource(modelType, ParseRenamedFromComment),
+		CDC:          p.extractDirectiveFromSource(modelType, cdcFlagFromComment) != "",
+		History:      p.extractDirectiveFromSource(modelType, historyFlagFromComment) != "",
+		DefaultScope: p.extractDirectiveFromSource(modelType, ParseDefaultScopeFromComment),
+		GoType:       modelType,
+		Columns:      make([]ColumnMetadata, 0),
+		ForeignKeys:  make([]ForeignKeyMetadata, 0),
+		Indexes:      make([]IndexMetadata, 0),
+		Constraints:  make([]ConstraintMetadata, 0),
+	}
+	table.Sequences = SequencesFromComments(p.structCommentsFromSource(modelType), table)
+	// Parse fields, flattening embedded structs into the table
+	fields, err := p.collectColumnFields(modelType, "", "")
+	if err != nil {
+		return nil, err
+	}
+	for position, cf := range fields {
 		// Create column metadata
-		column := p.createColumnMetadata(field, tagOpts, field.Index[0])
+		column := p.createColumnMetadata(cf, position)
 		// Handle primary key
-		if tagOpts.Has("primaryKey") {
+		if cf.opts.Has("primaryKey") {
 			if table.PrimaryKey == nil {
 				table.PrimaryKey = &PrimaryKeyMetadata{
 					Columns: []string{column.Name},
@@ -114,17 +171,13 @@ func (p *Parser) Parse(modelType reflect.Type) (*TableMetadata, error) {
 	table.Constraints = append(table.Constraints, UniqueConstraintsFor(table.Name, table.Columns)...)
 
 	// Parse column-level indexes from tags
-	if err := p.parseColumnIndexes(modelType, table); err != nil {
-		return nil, fmt.Errorf("failed to parse column indexes: %w", err)
-	}
+	p.parseColumnIndexes(fields, table)
 
 	// Collect enum types used by this table.
 	table.EnumTypes = CollectEnumTypes(table.Columns)
 
 	// Parse foreign keys from tags
-	if err := p.parseForeignKeys(modelType, table); err != nil {
-		return nil, fmt.Errorf("failed to parse foreign keys: %w", err)
-	}
+	p.parseForeignKeys(fields, table)
 
 	// Parse relationships
 	if err := p.ParseRelationships(modelType, table); err != nil {
@@ -136,54 +189,115 @@ func (p *Parser) Parse(modelType reflect.Type) (*TableMetadata, error) {
 		return nil, fmt.Errorf("failed to parse table indexes: %w", err)
 	}
 
+	// Parse exclusion constraints from source comments
+	for _, comment := range p.structCommentsFromSource(modelType) {
+		if c := ParseExclusionFromComment(comment); c != nil {
+			table.Constraints = append(table.Constraints, *c)
+		}
+	}
+
 	// Cache the result
 	p.cache[modelType] = table
 	return table, nil
 }
 
+// TableNamer is implemented by models that name their own table, such as
+// those with a TableName method generated by `pebble generate columns`. It
+// takes precedence over table_name directives and needs no source lookup.
+type TableNamer interface {
+	TableName() string
+}
+
 // extractTableName extracts the table name from struct type.
 // Priority order:
-// 1. Global registry (populated by generated code from `pebble generate metadata`)
-// 2. Comment directive (development only, when source files exist)
-// 3. snake_case conversion (default fallback)
+// 1. TableName method (see TableNamer)
+// 2. Global registry (populated by generated code from `pebble generate metadata`)
+// 3. Comment directive (development only, when source files exist)
+// 4. The naming strategy (snake_case by default, see SetNamingStrategy)
 func (p *Parser) extractTableName(modelType reflect.Type) string {
 	structName := modelType.Name()
 
-	// Priority 1: Check global registry (from generated code)
+	// Priority 1: The model names its own table (TableName method)
+	if namer, ok := reflect.New(modelType).Interface().(TableNamer); ok {
+		if tableName := namer.TableName(); tableName != "" {
+			return tableName
+		}
+	}
+
+	// Priority 2: Check global registry (from generated code)
 	if tableName, ok := customTableNames[structName]; ok {
 		return tableName
 	}
 
-	// Priority 2: Try to extract from source file comments (development only)
+	// Priority 3: Try to extract from source file comments (development only)
 	if customName := p.extractTableNameFromSource(modelType); customName != "" {
 		return customName
 	}
 
-	// Priority 3: Default to struct name converted to snake_case
-	return toSnakeCase(structName)
+	// Priority 4: Derive it from the struct name
+	return CurrentNamingStrategy().TableName(structName)
 }
 
-// extractTableNameFromSource attempts to extract table name from source file comments.
-// It looks for a comment directive like: // table_name: custom_table_name
-func (p *Parser) extractTableNameFromSource(modelType reflect.Type) string {
-	// Get the package path and struct name
+// extractTableSchema returns the schema a model's table lives in, from the
+// global registry or a `// schema: name` comment directive. Empty means public.
+func (p *Parser) extractTableSchema(modelType reflect.Type) string {
+	if schemaName, ok := customTableSchemas[modelType.Name()]; ok {
+		return schemaName
+	}
+	return p.extractDirectiveFromSource(modelType, ParseSchemaFromComment)
+}
+
+// extractView returns the view definition of a view model, from the global
+// registry or a `// view:` / `// materialized_view:` directive; nil for tables.
+func (p *Parser) extractView(modelType reflect.Type) *ViewMetadata {
+	if view, ok := customViews[modelType.Name()]; ok {
+		return view
+	}
+	return ParseViewFromComments(p.structCommentsFromSource(modelType))
+}
+
+// structCommentsFromSource returns the comment lines of modelType's
+// declaration: those registered by generated code, else those read from its
+// source file (once per type, unless source scanning is disabled). Returns nil
+// if neither is available.
+func (p *Parser) structCommentsFromSource(modelType reflect.Type) []string {
 	pkgPath := modelType.PkgPath()
 	structName := modelType.Name()
 	if pkgPath == "" || structName == "" {
-		return ""
+		return nil
 	}
-	// Find the source file
-	// Use runtime.Caller to get potential source file locations
-	sourceFile, err := findSourceFile(pkgPath, structName)
-	if err != nil {
-		return "" // Silently fail - not critical
+	if comments, ok := registeredStructComments(structName); ok {
+		return comments
 	}
-	// Parse the source file
-	tableName, err := extractTableNameFromFile(sourceFile, structName)
-	if err != nil {
-		return "" // Silently fail - not critical
+	if !sourceScanning.Load() {
+		return nil
+	}
+	if cached, ok := sourceComments.Load(modelType); ok {
+		return cached.([]string)
+	}
+	var comments []string
+	if sourceFile, err := findSourceFile(pkgPath, structName); err == nil {
+		comments, _ = structCommentsFromFile(sourceFile, structName) // Silently fail - not critical
+	}
+	sourceComments.Store(modelType, comments)
+	return comments
+}
+
+// extractTableNameFromSource attempts to extract table name from source file comments.
+// It looks for a comment directive like: // table_name: custom_table_name
+func (p *Parser) extractTableNameFromSource(modelType reflect.Type) string {
+	return p.extractDirectiveFromSource(modelType, ParseTableNameFromComment)
+}
+
+// extractDirectiveFromSource returns the first non-empty value parse extracts
+// from the comments of modelType's declaration.
+func (p *Parser) extractDirectiveFromSource(modelType reflect.Type, parse func(string) string) string {
+	for _, comment := range p.structCommentsFromSource(modelType) {
+		if value := parse(comment); value != "" {
+			return value
+		}
 	}
-	return tableName
+	return ""
 }
 
 // findSourceFile attempts to locate the source file containing the struct definition.
@@ -234,13 +348,14 @@ func findSourceFile(pkgPath, structName string) (string, error) {
 	return "", fmt.Errorf("source file not found for %s.%s", pkgPath, structName)
 }
 
-// extractTableNameFromFile parses a Go source file and extracts the table name from comments.
-func extractTableNameFromFile(filename, structName string) (string, error) {
+// structCommentsFromFile parses a Go source file and returns the doc and line
+// comments of the named struct declaration, in source order.
+func structCommentsFromFile(filename, structName string) ([]string, error) {
 	fset := token.NewFileSet()
 	// Parse the file
 	file, err := parser.ParseFile(fset, filename, nil, parser.ParseComments)
 	if err != nil {
-		return "", fmt.Errorf("failed to parse file: %w", err)
+		return nil, fmt.Errorf("failed to parse file: %w", err)
 	}
 	// Find the struct declaration
 	for _, decl := range file.Decls {
@@ -258,45 +373,117 @@ func extractTableNameFromFile(filename, structName string) (string, error) {
 			if _, ok := typeSpec.Type.(*ast.StructType); !ok {
 				continue
 			}
-			// Found the struct! Now check for table_name comment
-			if genDecl.Doc != nil {
-				for _, comment := range genDecl.Doc.List {
-					tableName := ParseTableNameFromComment(comment.Text)
-					if tableName != "" {
-						return tableName, nil
-					}
+			// Found the struct! Collect doc comments, then line comments
+			var comments []string
+			for _, cg := range []*ast.CommentGroup{genDecl.Doc, typeSpec.Comment} {
+				if cg == nil {
+					continue
 				}
-			}
-			// Also check line comments
-			if typeSpec.Comment != nil {
-				for _, comment := range typeSpec.Comment.List {
-					tableName := ParseTableNameFromComment(comment.Text)
-					if tableName != "" {
-						return tableName, nil
-					}
+				for _, comment := range cg.List {
+					comments = append(comments, comment.Text)
 				}
 			}
+			return comments, nil
 		}
 	}
-	return "", nil // No custom table name found
+	return nil, nil
+}
+
+// columnField is a tagged column field of a model, possibly reached through
+// embedded structs.
+type columnField struct {
+	field reflect.StructField
+	opts  *TagOptions // opts.Name carries any embedded column prefix
+	path  string      // dotted Go field path from the model, e.g. "Billing.Street"
+}
+
+// collectColumnFields walks the fields of t in declaration order and returns
+// its column fields, flattening embedded structs into the parent:
+//   - `po:"embedded"` / `po:"embedded,prefix(billing_)"` on a struct field
+//     (anonymous or named) adds its columns, with the prefix prepended
+//   - an untagged anonymous struct is flattened without a prefix, mirroring
+//     Go's field promotion
+//
+// Relationship fields are not columns and are skipped.
+func (p *Parser) collectColumnFields(t reflect.Type, prefix, path string) ([]columnField, error) {
+	var fields []columnField
+	for field := range t.Fields() {
+		// Skip unexported fields
+		if !field.IsExported() {
+			continue
+		}
+		fieldPath := field.Name
+		if path != "" {
+			fieldPath = path + "." + field.Name
+		}
+		tagValue := field.Tag.Get(StructTagKey)
+		if tagValue == "" {
+			// Skip fields without po tag
+			continue
+		}
+		tagOpts, err := p.parseTag(tagValue)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse tag for field %s: %w", fieldPath, err)
+		}
+		if IsEmbeddedTag(tagOpts) {
+			if field.Type.Kind() != reflect.Struct {
+				return nil, fmt.Errorf("embedded field %s must be a struct, got %s", fieldPath, field.Type.Kind())
+			}
+			nested, err := p.collectColumnFields(field.Type, prefix+tagOpts.Get("prefix"), fieldPath)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, nested...)
+			continue
+		}
+		// Relationships are handled separately in relationships.go
+		if tagValue == "-" || p.isRelationshipTag(tagOpts) {
+			continue
+		}
+		if tagOpts.Name == "" {
+			tagOpts.Name = CurrentNamingStrategy().ColumnName(field.Name)
+		}
+		tagOpts.Name = prefix + tagOpts.Name
+		fields = append(fields, columnField{field: field, opts: tagOpts, path: fieldPath})
+	}
+	return fields, nil
 }
 
 // createColumnMetadata creates a ColumnMetadata from a struct field, deriving
 // the Go-type facts via reflection and interpreting the tag through the shared
 // BuildColumn (shared with the AST loader).
-func (p *Parser) createColumnMetadata(field reflect.StructField, opts *TagOptions, position int) ColumnMetadata {
+func (p *Parser) createColumnMetadata(cf columnField, position int) ColumnMetadata {
 	fm := FieldMeta{
-		GoField:      field.Name,
-		TypeName:     field.Type.Name(),
-		Nullable:     IsNullable(field.Type),
-		InferredType: p.typeMapper.GoTypeToPostgreSQL(field.Type),
+		GoField:      cf.path,
+		TypeName:     baseType(cf.field.Type).Name(),
+		Nullable:     IsNullable(cf.field.Type),
+		InferredType: p.typeMapper.GoTypeToPostgreSQL(cf.field.Type),
+		IsSlice:      isSlice(cf.field.Type),
 		Position:     position,
 	}
-	column := BuildColumn(opts, fm)
-	column.GoType = field.Type
+	column := BuildColumn(cf.opts, fm)
+	column.GoType = cf.field.Type
 	return column
 }
 
+// baseType returns t without pointers and slices, as astTypeName unwraps
+// them: *OrderStatus and []OrderStatus are OrderStatus.
+func baseType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	return t
+}
+
+// isSlice reports whether t, or the type it points to, is a slice other
+// than []byte.
+func isSlice(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8
+}
+
 // isRelationshipTag checks if tag options indicate a relationship field.
 func (p *Parser) isRelationshipTag(opts *TagOptions) bool {
 	return IsRelationshipTag(opts)
@@ -324,23 +511,24 @@ func (t *TagOptions) Get(key string) string {
 	return t.Options[key]
 }
 
+// tagSQLTypes are the PostgreSQL types recognised as tag options.
+var tagSQLTypes = []string{
+	"uuid", "varchar", "text", "char", "citext",
+	"smallint", "integer", "bigint", "serial", "bigserial",
+	"numeric", "decimal", "real", "double precision",
+	"boolean", "bool",
+	"date", "time", "timestamp", "timestamptz", "interval",
+	"json", "jsonb", "hstore",
+	"bytea",
+	"inet", "cidr", "macaddr",
+	"point", "line", "lseg", "box", "path", "polygon", "circle",
+	"tsvector", "tsquery",
+}
+
 // GetSQLType returns the SQL type from tag options.
 // Checks for: uuid, varchar(n), text, numeric(p,s), smallint, integer, bigint, etc.
 func (t *TagOptions) GetSQLType() string {
-	// Check common PostgreSQL types
-	pgTypes := []string{
-		"uuid", "varchar", "text", "char",
-		"smallint", "integer", "bigint", "serial", "bigserial",
-		"numeric", "decimal", "real", "double precision",
-		"boolean", "bool",
-		"date", "time", "timestamp", "timestamptz", "interval",
-		"json", "jsonb",
-		"bytea",
-		"inet", "cidr", "macaddr",
-		"point", "line", "lseg", "box", "path", "polygon", "circle",
-		"tsvector", "tsquery",
-	}
-	for _, pgType := range pgTypes {
+	for _, pgType := range tagSQLTypes {
 		if t.Has(pgType) {
 			// If the type has a parameter (e.g., varchar(255))
 			if value := t.Get(pgType); value != "" {
@@ -409,6 +597,168 @@ func ParseTableNameFromComment(comment string) string {
 	return ""
 }
 
+var (
+	viewDirectivePattern = regexp.MustCompile(`^//\s*(view|materialized_view):\s*(.*)$`)
+	anyDirectivePattern  = regexp.MustCompile(`^//\s*[a-z_]+:(\s|$)`)
+)
+
+// ParseViewFromComments extracts a view definition from a struct's comment
+// lines. Format: // view: SELECT ... or // materialized_view: SELECT ...
+// The query may continue on the following comment lines, up to the end of the
+// comment block or the next directive. Returns nil if there is no directive.
+func ParseViewFromComments(comments []string) *ViewMetadata {
+	var view *ViewMetadata
+	var query []string
+	for _, comment := range comments {
+		if view == nil {
+			matches := viewDirectivePattern.FindStringSubmatch(strings.TrimSpace(comment))
+			if matches == nil {
+				continue
+			}
+			view = &ViewMetadata{Materialized: matches[1] == "materialized_view"}
+			query = append(query, strings.TrimSpace(matches[2]))
+			continue
+		}
+		if anyDirectivePattern.MatchString(comment) || !strings.HasPrefix(comment, "//") {
+			break
+		}
+		if line := strings.TrimSpace(strings.TrimPrefix(comment, "//")); line != "" {
+			query = append(query, line)
+		}
+	}
+	if view == nil {
+		return nil
+	}
+	view.Query = strings.TrimSuffix(strings.TrimSpace(strings.Join(query, " ")), ";")
+	return view
+}
+
+var tableCommentDirectivePattern = regexp.MustCompile(`^//\s*table_comment:\s*(.*?)\s*$`)
+
+// ParseTableCommentFromComment extracts the table comment from a comment.
+// Format: // table_comment: Customer invoices, one row per billing period
+func ParseTableCommentFromComment(comment string) string {
+	matches := tableCommentDirectivePattern.FindStringSubmatch(strings.TrimSpace(comment))
+	if len(matches) > 1 {
+		return matches[1]
+	}
+	return ""
+}
+
+var renamedFromDirectivePattern = regexp.MustCompile(`^//\s*renamed_from:\s*([a-zA-Z0-9_.]+)\s*$`)
+
+// ParseRenamedFromComment extracts a model's previous table name from a
+// comment, so migrations rename the table instead of dropping it.
+// Format: // renamed_from: old_table_name
+func ParseRenamedFromComment(comment string) string {
+	matches := renamedFromDirectivePattern.FindStringSubmatch(strings.TrimSpace(comment))
+	if len(matches) > 1 {
+		return matches[1]
+	}
+	return ""
+}
+
+var excludeDirectivePattern = regexp.MustCompile(`^//\s*exclude:\s*(\w+)\s+(USING\s+.+?)\s*;?$`)
+
+// ParseExclusionFromComment extracts an exclusion constraint from a comment.
+// Format: // exclude: name USING method (element WITH operator, ...) [WHERE (predicate)]
+// Example: // exclude: no_overlap USING gist (room_id WITH =, during WITH &&)
+func ParseExclusionFromComment(comment string) *ConstraintMetadata {
+	matches := excludeDirectivePattern.FindStringSubmatch(strings.TrimSpace(comment))
+	if matches == nil {
+		return nil
+	}
+	return &ConstraintMetadata{
+		Name:       matches[1],
+		Type:       ExclusionConstraint,
+		Expression: matches[2],
+	}
+}
+
+var extensionsDirectivePattern = regexp.MustCompile(`^//\s*extensions:\s*([a-zA-Z0-9_,\s-]+)$`)
+
+// ParseExtensionsFromComment extracts the PostgreSQL extensions a model needs
+// from a comment. Format: // extensions: pg_trgm, btree_gin
+func ParseExtensionsFromComment(comment string) []string {
+	return splitExtensionList(extensionListFromComment(comment))
+}
+
+// extensionListFromComment returns the raw extension list of an extensions directive.
+func extensionListFromComment(comment string) string {
+	matches := extensionsDirectivePattern.FindStringSubmatch(strings.TrimSpace(comment))
+	if len(matches) > 1 {
+		return matches[1]
+	}
+	return ""
+}
+
+// splitExtensionList splits a comma-separated extension list.
+func splitExtensionList(list string) []string {
+	var names []string
+	for name := range strings.SplitSeq(list, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+var cdcDirectivePattern = regexp.MustCompile(`^//\s*cdc:\s*true\s*$`)
+
+// ParseCDCFromComment reports whether a comment enables change data capture
+// for a model. Format: // cdc: true
+func ParseCDCFromComment(comment string) bool {
+	return cdcDirectivePattern.MatchString(strings.TrimSpace(comment))
+}
+
+// cdcFlagFromComment returns "true" for a cdc directive, for extractDirectiveFromSource.
+func cdcFlagFromComment(comment string) string {
+	if ParseCDCFromComment(comment) {
+		return "true"
+	}
+	return ""
+}
+
+var historyDirectivePattern = regexp.MustCompile(`^//\s*history:\s*true\s*$`)
+
+// ParseHistoryFromComment reports whether a comment keeps a model's past row
+// versions in a history table. Format: // history: true
+func ParseHistoryFromComment(comment string) bool {
+	return historyDirectivePattern.MatchString(strings.TrimSpace(comment))
+}
+
+// historyFlagFromComment returns "true" for a history directive, for extractDirectiveFromSource.
+func historyFlagFromComment(comment string) string {
+	if ParseHistoryFromComment(comment) {
+		return "true"
+	}
+	return ""
+}
+
+var defaultScopeDirectivePattern = regexp.MustCompile(`^//\s*default_scope:\s*(.+?)\s*$`)
+
+// ParseDefaultScopeFromComment extracts the condition a model's queries are
+// filtered by unless they are Unscoped.
+// Format: // default_scope: deleted_at IS NULL AND status <> 'archived'
+func ParseDefaultScopeFromComment(comment string) string {
+	matches := defaultScopeDirectivePattern.FindStringSubmatch(strings.TrimSpace(comment))
+	if len(matches) > 1 {
+		return matches[1]
+	}
+	return ""
+}
+
+// ParseSchemaFromComment extracts the PostgreSQL schema from a comment.
+// Format: // schema: billing
+func ParseSchemaFromComment(comment string) string {
+	re := regexp.MustCompile(`^//\s*schema:\s*([a-zA-Z0-9_]+)`)
+	matches := re.FindStringSubmatch(comment)
+	if len(matches) > 1 {
+		return matches[1]
+	}
+	return ""
+}
+
 // ParseIndexFromComment extracts index definition from a comment.
 // Format: // index: idx_name ON (columns) [USING type] [INCLUDE (cols)] [WHERE condition]
 // Examples:
@@ -647,21 +997,12 @@ func extractBalancedParens(s string) (content, remaining string) {
 
 // parseForeignKeys extracts foreign key constraints from struct tags via the
 // shared ColumnForeignKey.
-func (p *Parser) parseForeignKeys(modelType reflect.Type, table *TableMetadata) error {
-	for field := range modelType.Fields() {
-		tag := field.Tag.Get(StructTagKey)
-		if tag == "" || tag == "-" {
-			continue
-		}
-		opts, err := ParseTag(tag)
-		if err != nil {
-			continue
-		}
-		if fk, ok := ColumnForeignKey(opts, table.Name); ok {
+func (p *Parser) parseForeignKeys(fields []columnField, table *TableMetadata) {
+	for _, cf := range fields {
+		if fk, ok := ColumnForeignKey(cf.opts, table.Name); ok {
 			table.ForeignKeys = append(table.ForeignKeys, fk)
 		}
 	}
-	return nil
 }
 
 // parseColumnIndexes extracts index definitions from column tags.
@@ -670,24 +1011,12 @@ func (p *Parser) parseForeignKeys(modelType reflect.Type, table *TableMetadata)
 //   - `po:"column,type,index(name)"` - named index
 //   - `po:"column,type,index(name,gin)"` - named index with type
 //   - `po:"column,type,index(name,btree,desc)"` - with ordering
-func (p *Parser) parseColumnIndexes(modelType reflect.Type, table *TableMetadata) error {
-	for field := range modelType.Fields() {
-		if !field.IsExported() {
-			continue
-		}
-		tagValue := field.Tag.Get(StructTagKey)
-		if tagValue == "" {
-			continue
-		}
-		opts, err := ParseTag(tagValue)
-		if err != nil {
-			continue
-		}
-		if index, ok := ColumnIndex(opts, table.Name); ok {
+func (p *Parser) parseColumnIndexes(fields []columnField, table *TableMetadata) {
+	for _, cf := range fields {
+		if index, ok := ColumnIndex(cf.opts, table.Name); ok {
 			table.Indexes = append(table.Indexes, index)
 		}
 	}
-	return nil
 }
 
 // parseIndexParameters parses the index tag value and returns name, type, and direction.
@@ -733,82 +1062,10 @@ func parseIndexParameters(value, tableName, columnName string) (name, indexType,
 // parseTableIndexes extracts index definitions from struct-level comments.
 // It looks for comments like: // index: idx_name ON (columns) ...
 func (p *Parser) parseTableIndexes(modelType reflect.Type, table *TableMetadata) error {
-	// Get the package path and struct name
-	pkgPath := modelType.PkgPath()
-	structName := modelType.Name()
-	if pkgPath == "" || structName == "" {
-		return nil // Not an error, just no source file available
-	}
-
-	// Find the source file
-	sourceFile, err := findSourceFile(pkgPath, structName)
-	if err != nil {
-		return nil // Silently fail - not critical
-	}
-
-	// Parse the source file and extract indexes
-	indexes, err := extractIndexesFromFile(sourceFile, structName)
-	if err != nil {
-		return nil // Silently fail - not critical
-	}
-
-	// Add indexes to table
-	table.Indexes = append(table.Indexes, indexes...)
-
-	return nil
-}
-
-// extractIndexesFromFile parses a Go source file and extracts index definitions from comments.
-func extractIndexesFromFile(filename, structName string) ([]IndexMetadata, error) {
-	fset := token.NewFileSet()
-	// Parse the file
-	file, err := parser.ParseFile(fset, filename, nil, parser.ParseComments)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse file: %w", err)
-	}
-
-	var indexes []IndexMetadata
-
-	// Find the struct declaration
-	for _, decl := range file.Decls {
-		genDecl, ok := decl.(*ast.GenDecl)
-		if !ok {
-			continue
-		}
-
-		// Check each spec in the declaration
-		for _, spec := range genDecl.Specs {
-			typeSpec, ok := spec.(*ast.TypeSpec)
-			if !ok || typeSpec.Name.Name != structName {
-				continue
-			}
-
-			// Check if it's a struct type
-			if _, ok := typeSpec.Type.(*ast.StructType); !ok {
-				continue
-			}
-
-			// Found the struct! Now check for index comments
-			if genDecl.Doc != nil {
-				for _, comment := range genDecl.Doc.List {
-					index := ParseIndexFromComment(comment.Text)
-					if index != nil {
-						indexes = append(indexes, *index)
-					}
-				}
-			}
-
-			// Also check line comments
-			if typeSpec.Comment != nil {
-				for _, comment := range typeSpec.Comment.List {
-					index := ParseIndexFromComment(comment.Text)
-					if index != nil {
-						indexes = append(indexes, *index)
-					}
-				}
-			}
+	for _, comment := range p.structCommentsFromSource(modelType) {
+		if index := ParseIndexFromComment(comment); index != nil {
+			table.Indexes = append(table.Indexes, *index)
 		}
 	}
-
-	return indexes, nil
+	return nil
 }
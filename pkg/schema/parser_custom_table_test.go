@@ -16,6 +16,13 @@ type DefaultTableTest struct {
 	ID int `po:"id,primaryKey,serial"`
 }
 
+// table_name: ignored_directive
+type MethodTableTest struct {
+	ID int `po:"id,primaryKey,serial"`
+}
+
+func (MethodTableTest) TableName() string { return "method_table" }
+
 func TestExtractTableNameFromComment(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -32,6 +39,11 @@ func TestExtractTableNameFromComment(t *testing.T) {
 			model:    DefaultTableTest{},
 			expected: "default_table_test",
 		},
+		{
+			name:     "TableName method wins over comment",
+			model:    MethodTableTest{},
+			expected: "method_table",
+		},
 	}
 
 	parser := NewParser()
@@ -316,6 +316,28 @@ func TestEnumParsing(t *testing.T) {
 		Status OrderStatus `po:"status,enum(pending,active,completed),notNull"`
 	}
 
+	type TestOrderHistory struct {
+		ID       int           `po:"id,primaryKey,serial"`
+		Previous *OrderStatus  `po:"previous,enum(pending,active,completed)"`
+		History  []OrderStatus `po:"history,enum(pending,active,completed)"`
+	}
+
+	t.Run("enum pointer and array columns", func(t *testing.T) {
+		table, err := parser.Parse(reflect.TypeFor[TestOrderHistory]())
+		if err != nil {
+			t.Fatalf("Parse failed: %v", err)
+		}
+		for name, want := range map[string]string{"previous": "order_status", "history": "order_status[]"} {
+			col := table.GetColumnByName(name)
+			if col.EnumType != "order_status" || col.SQLType != want {
+				t.Errorf("%s: EnumType %q, SQLType %q, want order_status and %s", name, col.EnumType, col.SQLType, want)
+			}
+		}
+		if len(table.EnumTypes) != 1 {
+			t.Errorf("expected 1 enum type, got %v", table.EnumTypes)
+		}
+	})
+
 	t.Run("enum column parsing", func(t *testing.T) {
 		table, err := parser.Parse(reflect.TypeFor[TestOrder]())
 		if err != nil {
@@ -0,0 +1,88 @@
+package schema
+
+import (
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Pluralize returns ns with the last word of its table names made plural,
+// using English rules and the irregular plurals (see RegisterPlural).
+// Usage: schema.SetNamingStrategy(schema.Pluralize(schema.CamelCase))
+func Pluralize(ns NamingStrategy) NamingStrategy {
+	return pluralNaming{orSnakeCase(ns)}
+}
+
+type pluralNaming struct {
+	NamingStrategy
+}
+
+func (p pluralNaming) TableName(structName string) string {
+	name := p.NamingStrategy.TableName(structName)
+	// The last word starts after the last underscore or at the last capital.
+	start := strings.LastIndexByte(name, '_') + 1
+	if i := strings.LastIndexFunc(name[start:], unicode.IsUpper); i > 0 {
+		start += i
+	}
+	return name[:start] + plural(name[start:])
+}
+
+var (
+	pluralsMu sync.RWMutex
+	// plurals holds the irregular and uncountable words that the suffix
+	// rules would get wrong.
+	plurals = map[string]string{
+		"person": "people", "man": "men", "woman": "women", "child": "children",
+		"mouse": "mice", "goose": "geese", "foot": "feet", "tooth": "teeth",
+		"ox": "oxen", "leaf": "leaves", "life": "lives", "knife": "knives",
+		"wife": "wives", "half": "halves", "shelf": "shelves", "wolf": "wolves",
+		"thief": "thieves", "datum": "data", "medium": "media",
+		"criterion": "criteria", "analysis": "analyses", "crisis": "crises",
+		"thesis": "theses", "axis": "axes", "index": "indexes", "matrix": "matrices",
+		"vertex": "vertices", "quiz": "quizzes", "cactus": "cacti", "focus": "foci",
+		"alumnus": "alumni", "syllabus": "syllabi", "appendix": "appendices",
+		"sheep": "sheep", "fish": "fish", "deer": "deer", "series": "series",
+		"species": "species", "news": "news", "data": "data", "metadata": "metadata",
+		"equipment": "equipment", "information": "information", "feedback": "feedback",
+		"software": "software", "money": "money", "staff": "staff", "audio": "audio",
+		"settings": "settings",
+	}
+)
+
+// RegisterPlural adds an irregular plural, or with plural equal to singular
+// an uncountable word, to the dictionary Pluralize uses. Register before
+// models are parsed.
+// Usage: schema.RegisterPlural("cafe", "cafes")
+func RegisterPlural(singular, plural string) {
+	pluralsMu.Lock()
+	defer pluralsMu.Unlock()
+	plurals[strings.ToLower(singular)] = strings.ToLower(plural)
+}
+
+// plural returns the plural of an English word, keeping a leading capital.
+func plural(word string) string {
+	if word == "" {
+		return word
+	}
+	lower := strings.ToLower(word)
+	pluralsMu.RLock()
+	irregular, ok := plurals[lower]
+	pluralsMu.RUnlock()
+
+	var result string
+	switch {
+	case ok:
+		result = irregular
+	case strings.HasSuffix(lower, "y") && len(lower) > 1 && !strings.ContainsRune("aeiou", rune(lower[len(lower)-2])):
+		result = lower[:len(lower)-1] + "ies"
+	case strings.HasSuffix(lower, "s"), strings.HasSuffix(lower, "x"), strings.HasSuffix(lower, "z"),
+		strings.HasSuffix(lower, "ch"), strings.HasSuffix(lower, "sh"):
+		result = lower + "es"
+	default:
+		result = lower + "s"
+	}
+	if unicode.IsUpper(rune(word[0])) {
+		result = strings.ToUpper(result[:1]) + result[1:]
+	}
+	return result
+}
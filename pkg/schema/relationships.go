@@ -73,9 +73,11 @@ func (p *Parser) parseRelationship(field reflect.StructField, opts *TagOptions,
 		return nil, fmt.Errorf("unknown relationship type")
 	}
 
-	// Get foreign key
+	// Get foreign key; fk(...) is shorthand for foreignKey(...)
 	if foreignKey := opts.Get("foreignKey"); foreignKey != "" {
 		rel.ForeignKey = foreignKey
+	} else if fk := opts.Get("fk"); fk != "" {
+		rel.ForeignKey = fk
 	}
 
 	// Get references
@@ -97,8 +99,8 @@ func (p *Parser) parseRelationship(field reflect.StructField, opts *TagOptions,
 	}
 
 	if fieldType.Kind() == reflect.Struct {
-		rel.TargetType = fieldType                      // Store the actual Go type for accurate table name lookup
-		rel.TargetTable = toSnakeCase(fieldType.Name()) // Fallback (may be incorrect with custom table names)
+		rel.TargetType = fieldType                                            // Store the actual Go type for accurate table name lookup
+		rel.TargetTable = CurrentNamingStrategy().TableName(fieldType.Name()) // Fallback (may be incorrect with custom table names)
 		rel.TargetField = fieldType.Name()
 	}
 
@@ -120,11 +122,11 @@ func (p *Parser) parseRelationship(field reflect.StructField, opts *TagOptions,
 		case BelongsTo:
 			// For belongsTo, foreign key is on the source table
 			// e.g., user_id
-			rel.ForeignKey = toSnakeCase(rel.TargetField) + "_id"
+			rel.ForeignKey = CurrentNamingStrategy().ForeignKey(rel.TargetField)
 		case HasOne, HasMany:
 			// For hasOne/hasMany, foreign key is on the target table
 			// e.g., user_id
-			rel.ForeignKey = toSnakeCase(sourceTable.GoType.Name()) + "_id"
+			rel.ForeignKey = CurrentNamingStrategy().ForeignKey(sourceTable.GoType.Name())
 		}
 	}
 
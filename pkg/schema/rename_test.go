@@ -0,0 +1,37 @@
+package schema
+
+import "testing"
+
+func TestParseRenamedFromComment(t *testing.T) {
+	tests := []struct {
+		comment  string
+		expected string
+	}{
+		{"// renamed_from: people", "people"},
+		{"//renamed_from:billing.old_invoices  ", "billing.old_invoices"},
+		{"// renamed_from: two words", ""},
+		{"// table_name: people", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.comment, func(t *testing.T) {
+			if got := ParseRenamedFromComment(tt.comment); got != tt.expected {
+				t.Errorf("ParseRenamedFromComment(%q) = %q, want %q", tt.comment, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBuildColumn_RenamedFrom(t *testing.T) {
+	opts, err := ParseTag("email,varchar(255),notNull,renamedFrom(mail)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	col := BuildColumn(opts, FieldMeta{GoField: "Email"})
+	if col.RenamedFrom != "mail" {
+		t.Errorf("RenamedFrom = %q, want mail", col.RenamedFrom)
+	}
+	if problems := ValidateTag(opts, FieldMeta{GoField: "Email"}); len(problems) != 0 {
+		t.Errorf("expected renamedFrom to be a known option, got %v", problems)
+	}
+}
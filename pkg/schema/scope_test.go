@@ -0,0 +1,42 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDefaultScopeFromComment(t *testing.T) {
+	tests := []struct {
+		comment string
+		want    string
+	}{
+		{"// default_scope: deleted_at IS NULL", "deleted_at IS NULL"},
+		{"//default_scope:deleted_at IS NULL AND status <> 'archived'  ", "deleted_at IS NULL AND status <> 'archived'"},
+		{"// default_scope:", ""},
+		{"// The default_scope: is documented elsewhere", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.comment, func(t *testing.T) {
+			if got := ParseDefaultScopeFromComment(tt.comment); got != tt.want {
+				t.Errorf("ParseDefaultScopeFromComment(%q) = %q, want %q", tt.comment, got, tt.want)
+			}
+		})
+	}
+}
+
+// default_scope: deleted_at IS NULL
+type scopedNote struct {
+	ID        int64   `po:"id,bigserial,primaryKey"`
+	DeletedAt *string `po:"deleted_at,timestamptz"`
+}
+
+func TestParser_DefaultScope(t *testing.T) {
+	table, err := NewParser().Parse(reflect.TypeFor[scopedNote]())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.DefaultScope != "deleted_at IS NULL" {
+		t.Errorf("DefaultScope = %q, want deleted_at IS NULL", table.DefaultScope)
+	}
+}
@@ -0,0 +1,109 @@
+package schema
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ParseSequenceOptions parses sequence parameters as written in identity tags,
+// e.g. `po:"id,identity(start=1000, increment=10, cache=20)"`. Parameters are
+// separated by commas or spaces. Supported keys: start, increment, minvalue,
+// maxvalue, cache and cycle (cycle, cycle=false or nocycle).
+func ParseSequenceOptions(params string) (*SequenceOptions, error) {
+	opts, ownedBy, err := parseSequenceParams(params)
+	if err != nil {
+		return nil, err
+	}
+	if ownedBy != "" {
+		return nil, fmt.Errorf("owned_by is only valid for sequence directives")
+	}
+	return opts, nil
+}
+
+var sequenceDirectivePattern = regexp.MustCompile(`^//\s*sequence:\s*([a-zA-Z0-9_.]+)\s*(.*)$`)
+
+// ParseSequenceFromComment extracts a standalone sequence from a comment.
+// Format: // sequence: name [start=N] [increment=N] [minvalue=N] [maxvalue=N] [cache=N] [cycle] [owned_by=column]
+// Returns nil if the comment is not a valid sequence directive.
+func ParseSequenceFromComment(comment string) *SequenceMetadata {
+	matches := sequenceDirectivePattern.FindStringSubmatch(strings.TrimSpace(comment))
+	if matches == nil {
+		return nil
+	}
+	opts, ownedBy, err := parseSequenceParams(matches[2])
+	if err != nil {
+		return nil
+	}
+	return &SequenceMetadata{Name: matches[1], Options: *opts, OwnedBy: ownedBy}
+}
+
+// SequencesFromComments extracts the sequence directives from a struct's
+// comments. An owned_by column without a table is qualified with table.
+func SequencesFromComments(comments []string, table *TableMetadata) []SequenceMetadata {
+	var sequences []SequenceMetadata
+	for _, comment := range comments {
+		seq := ParseSequenceFromComment(comment)
+		if seq == nil {
+			continue
+		}
+		if seq.OwnedBy != "" && !strings.Contains(seq.OwnedBy, ".") {
+			seq.OwnedBy = table.QualifiedName() + "." + seq.OwnedBy
+		}
+		sequences = append(sequences, *seq)
+	}
+	return sequences
+}
+
+// parseSequenceParams parses key=value sequence parameters plus owned_by.
+func parseSequenceParams(params string) (*SequenceOptions, string, error) {
+	opts := &SequenceOptions{}
+	var ownedBy string
+	fields := strings.FieldsFunc(params, func(r rune) bool { return r == ',' || unicode.IsSpace(r) })
+	for _, field := range fields {
+		key, value, hasValue := strings.Cut(field, "=")
+		key = strings.ToLower(key)
+
+		switch key {
+		case "cycle", "nocycle":
+			cycle := key == "cycle"
+			if hasValue {
+				b, err := strconv.ParseBool(value)
+				if err != nil {
+					return nil, "", fmt.Errorf("invalid sequence option %s: %w", field, err)
+				}
+				cycle = cycle == b
+			}
+			opts.Cycle = &cycle
+			continue
+		case "owned_by":
+			if value == "" {
+				return nil, "", fmt.Errorf("owned_by requires a column")
+			}
+			ownedBy = value
+			continue
+		}
+
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid sequence option %s: %w", field, err)
+		}
+		switch key {
+		case "start":
+			opts.Start = &n
+		case "increment":
+			opts.Increment = &n
+		case "minvalue", "min":
+			opts.MinValue = &n
+		case "maxvalue", "max":
+			opts.MaxValue = &n
+		case "cache":
+			opts.Cache = &n
+		default:
+			return nil, "", fmt.Errorf("unknown sequence option %q", key)
+		}
+	}
+	return opts, ownedBy, nil
+}
@@ -0,0 +1,75 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+)
+
+func int64Ptr(n int64) *int64 { return &n }
+func boolPtr(b bool) *bool    { return &b }
+
+func TestParseSequenceOptions(t *testing.T) {
+	tests := []struct {
+		params  string
+		want    *SequenceOptions
+		wantErr bool
+	}{
+		{"start=1000, increment=10, cache=20", &SequenceOptions{Start: int64Ptr(1000), Increment: int64Ptr(10), Cache: int64Ptr(20)}, false},
+		{"minvalue=1 maxvalue=99999 cycle", &SequenceOptions{MinValue: int64Ptr(1), MaxValue: int64Ptr(99999), Cycle: boolPtr(true)}, false},
+		{"nocycle", &SequenceOptions{Cycle: boolPtr(false)}, false},
+		{"cycle=false", &SequenceOptions{Cycle: boolPtr(false)}, false},
+		{"start=abc", nil, true},
+		{"step=2", nil, true},
+		{"owned_by=id", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.params, func(t *testing.T) {
+			got, err := ParseSequenceOptions(tt.params)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSequenceOptions(%q) error = %v, wantErr %v", tt.params, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseSequenceOptions(%q) = %+v, want %+v", tt.params, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSequencesFromComments(t *testing.T) {
+	table := &TableMetadata{Name: "invoices", Schema: "billing"}
+	comments := []string{
+		"// Invoice is a billing document.",
+		"// sequence: billing.invoice_number_seq start=1000 increment=1 owned_by=number",
+		"// sequence: shared_seq cache=50 owned_by=orders.id",
+		"// sequence: broken start=x",
+	}
+
+	got := SequencesFromComments(comments, table)
+	want := []SequenceMetadata{
+		{Name: "billing.invoice_number_seq", Options: SequenceOptions{Start: int64Ptr(1000), Increment: int64Ptr(1)}, OwnedBy: "billing.invoices.number"},
+		{Name: "shared_seq", Options: SequenceOptions{Cache: int64Ptr(50)}, OwnedBy: "orders.id"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SequencesFromComments() = %+v, want %+v", got, want)
+	}
+}
+
+func TestBuildColumn_IdentitySequenceOptions(t *testing.T) {
+	opts, err := ParseTag("id,primaryKey,identityByDefault(start=1000, increment=10, cache=20)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	col := BuildColumn(opts, FieldMeta{GoField: "ID", InferredType: "bigint"})
+
+	if col.Identity == nil || col.Identity.Generation != IdentityByDefault {
+		t.Fatalf("expected BY DEFAULT identity, got %+v", col.Identity)
+	}
+	want := &SequenceOptions{Start: int64Ptr(1000), Increment: int64Ptr(10), Cache: int64Ptr(20)}
+	if !reflect.DeepEqual(col.Identity.Sequence, want) {
+		t.Errorf("sequence = %+v, want %+v", col.Identity.Sequence, want)
+	}
+	if col.SQLType != "bigint" {
+		t.Errorf("SQLType = %q, want bigint", col.SQLType)
+	}
+}
@@ -0,0 +1,55 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+)
+
+// table_name: scanned_models
+// index: idx_scanned_name ON (name)
+type ScannedModel struct {
+	ID   int    `po:"id,primaryKey,serial"`
+	Name string `po:"name,text"`
+}
+
+type RegisteredCommentsModel struct {
+	ID int `po:"id,primaryKey,serial"`
+}
+
+func TestSetSourceScanning(t *testing.T) {
+	SetSourceScanning(false)
+	defer SetSourceScanning(true)
+
+	table, err := NewParser().Parse(reflect.TypeFor[ScannedModel]())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.Name != "scanned_model" {
+		t.Errorf("Name = %q, want the snake_case fallback without source scanning", table.Name)
+	}
+	if len(table.Indexes) != 0 {
+		t.Errorf("expected no directive indexes, got %+v", table.Indexes)
+	}
+}
+
+func TestRegisterStructComments(t *testing.T) {
+	RegisterStructComments("RegisteredCommentsModel", "// table_name: registered_models", "// index: idx_registered_id ON (id)")
+	defer func() {
+		customStructCommentsMu.Lock()
+		delete(customStructComments, "RegisteredCommentsModel")
+		customStructCommentsMu.Unlock()
+	}()
+	SetSourceScanning(false)
+	defer SetSourceScanning(true)
+
+	table, err := NewParser().Parse(reflect.TypeFor[RegisteredCommentsModel]())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.Name != "registered_models" {
+		t.Errorf("Name = %q, want registered_models", table.Name)
+	}
+	if len(table.Indexes) != 1 || table.Indexes[0].Name != "idx_registered_id" {
+		t.Errorf("unexpected indexes: %+v", table.Indexes)
+	}
+}
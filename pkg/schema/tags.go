@@ -17,6 +17,7 @@ type FieldMeta struct {
 	TypeName     string // base Go type name (pointer/slice deref'd), for enum type naming
 	Nullable     bool   // Go type is a pointer or a sql.Null* type
 	InferredType string // PostgreSQL type inferred from the Go type ("" if unknown)
+	IsSlice      bool   // Go type is a slice other than []byte (an enum tag makes an enum array)
 	IsJSONBHint  bool   // Go type implies JSONB (e.g. map[string]any)
 	Position     int
 }
@@ -62,6 +63,25 @@ func IsRelationshipTag(opts *TagOptions) bool {
 		opts.Has("hasMany") || opts.Has("manyToMany")
 }
 
+// IsEmbeddedTag reports whether the tag flattens a struct field into the
+// parent table (`po:"embedded"`, optionally with prefix(p) to prepend p to
+// each of its column names). A tag naming a column embedded, such as
+// `po:"embedded,text"`, carries other options and is a column.
+func IsEmbeddedTag(opts *TagOptions) bool {
+	if opts.Has("embedded") {
+		return true
+	}
+	if opts.Name != "embedded" {
+		return false
+	}
+	for key := range opts.Options {
+		if key != "prefix" {
+			return false
+		}
+	}
+	return true
+}
+
 // BuildColumn interprets a parsed po tag against Go-type facts to produce a
 // column definition. This is the single source of truth for how a tag becomes
 // a ColumnMetadata, covering types, constraints, identity, generated, enum and
@@ -89,22 +109,40 @@ func BuildColumn(opts *TagOptions, fm FieldMeta) ColumnMetadata {
 		column.Nullable = true
 	}
 
-	if defaultVal := opts.Get("default"); defaultVal != "" {
-		column.Default = &defaultVal
-	}
-
 	column.Unique = opts.Has("unique")
+	column.Comment = opts.Get("comment")
+	column.Collation = strings.Trim(opts.Get("collate"), `"'`)
+	column.RenamedFrom = opts.Get("renamedFrom")
+	column.Using = opts.Get("using")
+	column.Immutable = opts.Has("immutable")
+	column.ReadOnly = opts.Has("readOnly")
+	column.WriteOnly = opts.Has("writeOnly")
+	if groups := opts.Get("group"); groups != "" {
+		for group := range strings.SplitSeq(groups, ",") {
+			column.Groups = append(column.Groups, strings.TrimSpace(group))
+		}
+	}
 	column.AutoIncrement = opts.Has("autoIncrement") || opts.Has("serial") ||
 		opts.Has("bigserial") || opts.Has("smallserial")
 
-	// Identity columns (PostgreSQL 10+). Implicitly NOT NULL.
-	if opts.Has("identity") || opts.Has("identityAlways") {
+	// Identity columns (PostgreSQL 10+). Implicitly NOT NULL. Sequence
+	// options may be given in parens: identity(start=1000, increment=10).
+	for _, key := range []string{"identity", "identityAlways", "identityByDefault"} {
+		if !opts.Has(key) {
+			continue
+		}
 		column.Identity = &IdentityColumn{Generation: IdentityAlways}
-	} else if opts.Has("identityByDefault") {
-		column.Identity = &IdentityColumn{Generation: IdentityByDefault}
-	}
-	if column.Identity != nil {
+		if key == "identityByDefault" {
+			column.Identity.Generation = IdentityByDefault
+		}
+		// Malformed options are reported by ValidateTag
+		if params := opts.Get(key); params != "" {
+			if seq, err := ParseSequenceOptions(params); err == nil {
+				column.Identity.Sequence = seq
+			}
+		}
 		column.Nullable = false
+		break
 	}
 
 	// Generated columns.
@@ -125,6 +163,9 @@ func BuildColumn(opts *TagOptions, fm FieldMeta) ColumnMetadata {
 		column.EnumValues = values
 		column.EnumType = toSnakeCase(fm.TypeName)
 		column.SQLType = column.EnumType
+		if fm.IsSlice {
+			column.SQLType += "[]"
+		}
 	}
 
 	// JSONB detection.
@@ -132,6 +173,13 @@ func BuildColumn(opts *TagOptions, fm FieldMeta) ColumnMetadata {
 	column.IsJSONB = opts.Has("jsonb") || opts.Has("json") ||
 		sqlTypeLower == "jsonb" || sqlTypeLower == "json" || fm.IsJSONBHint
 
+	// Defaults, once the type is known: shorthands expand and bare literals
+	// are quoted per type.
+	if defaultVal := opts.Get("default"); defaultVal != "" {
+		defaultVal = DefaultExpression(defaultVal, column)
+		column.Default = &defaultVal
+	}
+
 	return column
 }
 
@@ -162,7 +210,9 @@ func ColumnIndex(opts *TagOptions, tableName string) (IndexMetadata, bool) {
 
 // ColumnForeignKey builds a foreign key from an fk tag option, or returns
 // ok=false if there is none. Supports fk:table(column) and fk:table.column
-// (and the parenthesised option form), with optional onDelete/onUpdate.
+// (and the parenthesised option form), with optional onDelete/onUpdate and
+// deferrable/initiallyDeferred. The parenthesised form accepts a
+// schema-qualified table: fk:billing.customers(id).
 func ColumnForeignKey(opts *TagOptions, tableName string) (ForeignKeyMetadata, bool) {
 	fkStr := opts.Get("fk")
 	if fkStr == "" {
@@ -170,26 +220,30 @@ func ColumnForeignKey(opts *TagOptions, tableName string) (ForeignKeyMetadata, b
 	}
 
 	var refTable, refColumn string
-	if strings.Contains(fkStr, ".") {
+	if idx := strings.Index(fkStr, "("); idx > 0 && strings.HasSuffix(fkStr, ")") {
+		refTable = fkStr[:idx]
+		refColumn = fkStr[idx+1 : len(fkStr)-1]
+	} else if strings.Contains(fkStr, ".") {
 		if parts := strings.SplitN(fkStr, ".", 2); len(parts) == 2 {
 			refTable, refColumn = parts[0], parts[1]
 		}
-	} else if idx := strings.Index(fkStr, "("); idx > 0 && strings.HasSuffix(fkStr, ")") {
-		refTable = fkStr[:idx]
-		refColumn = fkStr[idx+1 : len(fkStr)-1]
 	}
 	if refTable == "" || refColumn == "" {
 		return ForeignKeyMetadata{}, false
 	}
 
 	columnName := opts.Name
+	_, refName := SplitQualifiedName(refTable)
+	initiallyDeferred := opts.Has("initiallyDeferred")
 	return ForeignKeyMetadata{
-		Name:              fmt.Sprintf("fk_%s_%s_%s", tableName, columnName, refTable),
+		Name:              fmt.Sprintf("fk_%s_%s_%s", tableName, columnName, refName),
 		Columns:           []string{columnName},
 		ReferencedTable:   refTable,
 		ReferencedColumns: []string{refColumn},
 		OnDelete:          ParseReferenceAction(opts.Get("onDelete")),
 		OnUpdate:          ParseReferenceAction(opts.Get("onUpdate")),
+		Deferrable:        initiallyDeferred || opts.Has("deferrable"),
+		InitiallyDeferred: initiallyDeferred,
 	}, true
 }
 
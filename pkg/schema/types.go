@@ -2,6 +2,8 @@ package schema
 
 import (
 	"database/sql"
+	"net"
+	"net/netip"
 	"reflect"
 	"time"
 )
@@ -36,6 +38,22 @@ func (tm *TypeMapper) GoTypeToPostgreSQL(t reflect.Type) string {
 		t = t.Elem()
 	}
 
+	// Network address types. net.IP and net.HardwareAddr are byte slices, so
+	// they must be matched before the kind-based []byte -> bytea mapping.
+	switch t {
+	case reflect.TypeFor[netip.Addr](), reflect.TypeFor[net.IP]():
+		return "inet"
+	case reflect.TypeFor[netip.Prefix](), reflect.TypeFor[net.IPNet]():
+		return "cidr"
+	case reflect.TypeFor[net.HardwareAddr]():
+		return "macaddr"
+	}
+
+	// time.Duration is an int64 kind; pgx encodes it as interval natively.
+	if t == reflect.TypeFor[time.Duration]() {
+		return "interval"
+	}
+
 	// Standard type mappings
 	switch t.Kind() {
 	case reflect.Bool:
@@ -77,6 +95,10 @@ func (tm *TypeMapper) GoTypeToPostgreSQL(t reflect.Type) string {
 		if t.Key().Kind() == reflect.String && t.Elem().Kind() == reflect.Interface {
 			return "jsonb"
 		}
+		// map[string]string is hstore's natural Go form
+		if t.Key().Kind() == reflect.String && t.Elem().Kind() == reflect.String {
+			return "hstore"
+		}
 	}
 
 	// Handle special types
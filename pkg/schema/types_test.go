@@ -2,6 +2,8 @@ package schema
 
 import (
 	"database/sql"
+	"net"
+	"net/netip"
 	"reflect"
 	"testing"
 	"time"
@@ -33,6 +35,16 @@ func TestTypeMapper_GoTypeToPostgreSQL(t *testing.T) {
 		// Special types
 		{"time.Time", reflect.TypeFor[time.Time](), "timestamp with time zone"},
 		{"[]byte", reflect.TypeFor[[]byte](), "bytea"},
+		{"time.Duration", reflect.TypeFor[time.Duration](), "interval"},
+		{"*time.Duration", reflect.TypeFor[*time.Duration](), "interval"},
+
+		// Network address types
+		{"netip.Addr", reflect.TypeFor[netip.Addr](), "inet"},
+		{"*netip.Addr", reflect.TypeFor[*netip.Addr](), "inet"},
+		{"netip.Prefix", reflect.TypeFor[netip.Prefix](), "cidr"},
+		{"net.IP", reflect.TypeFor[net.IP](), "inet"},
+		{"net.IPNet", reflect.TypeFor[net.IPNet](), "cidr"},
+		{"net.HardwareAddr", reflect.TypeFor[net.HardwareAddr](), "macaddr"},
 
 		// Nullable types
 		{"sql.NullString", reflect.TypeFor[sql.NullString](), "text"},
@@ -51,6 +63,10 @@ func TestTypeMapper_GoTypeToPostgreSQL(t *testing.T) {
 		{"[]string", reflect.TypeFor[[]string](), "text[]"},
 		{"[]int", reflect.TypeFor[[]int](), "integer[]"},
 		{"[]bool", reflect.TypeFor[[]bool](), "boolean[]"},
+
+		// Map types
+		{"map[string]interface{}", reflect.TypeFor[map[string]interface{}](), "jsonb"},
+		{"map[string]string", reflect.TypeFor[map[string]string](), "hstore"},
 	}
 
 	for _, tt := range tests {
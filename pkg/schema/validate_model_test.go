@@ -0,0 +1,109 @@
+package schema
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type vetAuthor struct {
+	ID     int64      `po:"id,primaryKey,bigint"`
+	Name   string     `po:"name,text,notNull"`
+	Books  []vetBook  `po:"-,hasMany,foreignKey(author_id)"`
+	Notes  []vetNote  `po:"-,hasMany"`
+	Drafts []vetBook  `po:"-,hasMany,fk(author_id),references(uuid)"`
+	Fans   []struct{} `po:"-,manyToMany,via(fans)"`
+}
+
+type vetBook struct {
+	ID       int64 `po:"id,primaryKey,bigint"`
+	AuthorID int64 `po:"author_id,bigint,fk:vet_authors(id)"`
+}
+
+type vetNote struct {
+	Text string `po:"text,text"`
+}
+
+type vetBadTags struct {
+	ID      int      `po:"id,primaryKey,uuid"`
+	Price   float64  `po:"price,numeric(10,2),notnull"`
+	Active  bool     `po:"active,integer"`
+	OwnerID int      `po:"owner_id,integer,fk:owners,onDelete:EXPLODE"`
+	Labels  []string `po:"labels,uuid[]"`
+	Meta    int      `po:"meta,jsonb"`
+	Serial  int64    `po:"serial_no,bigint,identity(start=ten)"`
+}
+
+func TestValidateModel(t *testing.T) {
+	if err := ValidateModel[vetBook](); err != nil {
+		t.Errorf("vetBook: unexpected error: %v", err)
+	}
+
+	err := ValidateModel[vetBadTags]()
+	if err == nil {
+		t.Fatal("vetBadTags: expected errors")
+	}
+	for _, want := range []string{
+		"vetBadTags.ID: Go type maps to integer but the tag declares uuid",
+		`vetBadTags.Price: unknown tag option "notnull"`,
+		"vetBadTags.Active: Go type maps to boolean but the tag declares integer",
+		`vetBadTags.OwnerID: invalid fk reference "owners"`,
+		`vetBadTags.OwnerID: invalid onDelete action "EXPLODE"`,
+		`vetBadTags.Serial: invalid identity options "start=ten"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("missing %q in:\n%v", want, err)
+		}
+	}
+	for _, unwanted := range []string{"Labels", "Meta"} {
+		if strings.Contains(err.Error(), unwanted) {
+			t.Errorf("unexpected problem for %s in:\n%v", unwanted, err)
+		}
+	}
+
+	var verr *ValidationError
+	if !errors.As(err, &verr) || verr.Model != "vetBadTags" {
+		t.Errorf("expected *ValidationError, got %T", err)
+	}
+}
+
+func TestValidateModel_Relationships(t *testing.T) {
+	err := ValidateModel[vetAuthor]()
+	if err == nil {
+		t.Fatal("expected errors")
+	}
+	for _, want := range []string{
+		`vetAuthor.Fans: unknown relationship option "via"`,
+		"vetAuthor.Fans: relationship target struct {} is not a model",
+		"vetAuthor.Notes: foreign key column vet_author_id not found on vet_note",
+		"vetAuthor.Drafts: referenced column uuid not found",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("missing %q in:\n%v", want, err)
+		}
+	}
+	if strings.Contains(err.Error(), "Books") {
+		t.Errorf("unexpected problem for Books in:\n%v", err)
+	}
+}
+
+func TestValidateReferences(t *testing.T) {
+	tables := map[string]*TableMetadata{
+		"users": {Name: "users", Columns: []ColumnMetadata{{Name: "id"}}},
+		"posts": {Name: "posts", Columns: []ColumnMetadata{{Name: "user_id", GoField: "UserID"}}, ForeignKeys: []ForeignKeyMetadata{
+			{Name: "fk_ok", Columns: []string{"user_id"}, ReferencedTable: "users", ReferencedColumns: []string{"id"}},
+			{Name: "fk_col", Columns: []string{"user_id"}, ReferencedTable: "users", ReferencedColumns: []string{"uid"}},
+			{Name: "fk_table", Columns: []string{"user_id"}, ReferencedTable: "accounts", ReferencedColumns: []string{"id"}},
+		}},
+	}
+	errs := ValidateReferences(tables)
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 errors, got %v", errs)
+	}
+	if got := errs[0].Error(); got != "posts.UserID: fk references unknown column users.uid" {
+		t.Errorf("errs[0] = %q", got)
+	}
+	if got := errs[1].Error(); got != "posts.UserID: fk references unknown table accounts" {
+		t.Errorf("errs[1] = %q", got)
+	}
+}
@@ -1,7 +1,11 @@
 package schema
 
 import (
+	"errors"
 	"fmt"
+	"maps"
+	"reflect"
+	"slices"
 	"strings"
 )
 
@@ -9,6 +13,9 @@ import (
 // Returns an error with helpful suggestions if issues are detected.
 func ValidateDefaultValue(defaultVal string) error {
 	trimmed := strings.TrimSpace(defaultVal)
+	if isDefaultShorthand(trimmed) {
+		return nil
+	}
 
 	// Convert to uppercase for case-insensitive comparison
 	upperVal := strings.ToUpper(trimmed)
@@ -79,3 +86,346 @@ func isNumeric(s string) bool {
 	}
 	return true
 }
+
+// ValidationError describes a problem in a model definition.
+type ValidationError struct {
+	Model   string // Go struct name
+	Field   string // Go field name; empty for model-level problems
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("%s: %s", e.Model, e.Message)
+	}
+	return fmt.Sprintf("%s.%s: %s", e.Model, e.Field, e.Message)
+}
+
+// columnTagOptions are the options a column tag may carry besides its SQL type.
+var columnTagOptions = map[string]bool{
+	"primaryKey": true, "notNull": true, "unique": true, "default": true,
+	"autoIncrement": true, "serial": true, "bigserial": true, "smallserial": true,
+	"identity": true, "identityAlways": true, "identityByDefault": true,
+	"fk": true, "onDelete": true, "onUpdate": true, "deferrable": true, "initiallyDeferred": true,
+	"index": true, "generated": true, "stored": true, "virtual": true, "enum": true,
+	"json": true, "jsonb": true, "comment": true, "collate": true, "renamedFrom": true, "using": true,
+	"immutable": true, "readOnly": true, "writeOnly": true, "group": true,
+}
+
+// relationshipTagOptions are the options a relationship tag may carry.
+var relationshipTagOptions = map[string]bool{
+	"belongsTo": true, "hasOne": true, "hasMany": true, "manyToMany": true,
+	"foreignKey": true, "fk": true, "references": true, "joinTable": true, "inverse": true,
+}
+
+// ValidateTag checks a column tag against the Go field it annotates and
+// returns a description of each problem: unknown options, malformed option
+// values, and an explicit SQL type the Go type can't hold (e.g. an int field
+// tagged uuid).
+func ValidateTag(opts *TagOptions, fm FieldMeta) []string {
+	var problems []string
+	for _, key := range sortedOptionKeys(opts) {
+		if columnTagOptions[key] || isTagSQLType(key) {
+			continue
+		}
+		problems = append(problems, fmt.Sprintf("unknown tag option %q", key))
+	}
+	if fk := opts.Get("fk"); opts.Has("fk") {
+		if _, ok := ColumnForeignKey(opts, ""); !ok {
+			problems = append(problems, fmt.Sprintf("invalid fk reference %q, want fk:table(column)", fk))
+		}
+	}
+	for _, key := range []string{"onDelete", "onUpdate"} {
+		if action := opts.Get(key); opts.Has(key) && !isReferenceAction(action) {
+			problems = append(problems, fmt.Sprintf("invalid %s action %q", key, action))
+		}
+	}
+	if defaultVal := opts.Get("default"); defaultVal != "" {
+		if err := ValidateDefaultValue(defaultVal); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	for _, key := range []string{"identity", "identityAlways", "identityByDefault"} {
+		if params := opts.Get(key); params != "" {
+			if _, err := ParseSequenceOptions(params); err != nil {
+				problems = append(problems, fmt.Sprintf("invalid %s options %q: %v", key, params, err))
+			}
+		}
+	}
+
+	if opts.Has("generated") {
+		problems = append(problems, validateGenerated(opts)...)
+	}
+
+	if sqlType := opts.GetSQLType(); sqlType != "" && fm.InferredType != "" && !opts.Has("enum") {
+		if !compatibleTypes(fm.InferredType, sqlType) {
+			problems = append(problems, fmt.Sprintf("Go type maps to %s but the tag declares %s", fm.InferredType, sqlType))
+		}
+	}
+	return problems
+}
+
+// validateGenerated checks a generated(expr) option. The expression must be
+// a single balanced expression, and a generated column can't also have a
+// default, an identity or be a primary key.
+func validateGenerated(opts *TagOptions) []string {
+	var problems []string
+	expr := opts.Get("generated")
+	if err := ValidateGeneratedExpression(expr); err != nil {
+		problems = append(problems, err.Error())
+	}
+	for _, key := range []string{"default", "identity", "identityAlways", "identityByDefault", "autoIncrement", "serial", "bigserial", "smallserial", "primaryKey"} {
+		if opts.Has(key) {
+			problems = append(problems, fmt.Sprintf("generated column can't also have %s", key))
+		}
+	}
+	if opts.Has("stored") && opts.Has("virtual") {
+		problems = append(problems, "generated column can't be both stored and virtual")
+	}
+	return problems
+}
+
+// ValidateGeneratedExpression checks the expression of a generated column:
+// it must be non-empty, with balanced parentheses and quotes, and hold a
+// single expression (no semicolons outside string literals).
+func ValidateGeneratedExpression(expr string) error {
+	if strings.TrimSpace(expr) == "" {
+		return fmt.Errorf("generated column needs an expression, generated(expr)")
+	}
+	depth := 0
+	inString := false
+	for _, r := range expr {
+		switch {
+		case r == '\'':
+			inString = !inString
+		case inString:
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+			if depth < 0 {
+				return fmt.Errorf("generated expression %q has an unmatched )", expr)
+			}
+		case r == ';':
+			return fmt.Errorf("generated expression %q must be a single expression", expr)
+		}
+	}
+	if inString {
+		return fmt.Errorf("generated expression %q has an unterminated string", expr)
+	}
+	if depth != 0 {
+		return fmt.Errorf("generated expression %q has an unmatched (", expr)
+	}
+	return nil
+}
+
+// compatibleTypes reports whether a Go type inferred as goType can hold
+// values of the tag's sqlType. Strings and byte slices scan from any text
+// representation, and anything can be stored as JSON.
+func compatibleTypes(goType, sqlType string) bool {
+	goFamily, tagFamily := typeFamily(goType), typeFamily(sqlType)
+	if goFamily == "" || tagFamily == "" || tagFamily == "json" || goFamily == "bytea" {
+		return true
+	}
+	if strings.HasSuffix(goFamily, "[]") != strings.HasSuffix(tagFamily, "[]") {
+		return false
+	}
+	goFamily, tagFamily = strings.TrimSuffix(goFamily, "[]"), strings.TrimSuffix(tagFamily, "[]")
+	return goFamily == tagFamily || goFamily == "text"
+}
+
+// ValidateRelationshipTag returns a description of each unknown option in a
+// relationship tag.
+func ValidateRelationshipTag(opts *TagOptions) []string {
+	var problems []string
+	for _, key := range sortedOptionKeys(opts) {
+		if !relationshipTagOptions[key] {
+			problems = append(problems, fmt.Sprintf("unknown relationship option %q", key))
+		}
+	}
+	return problems
+}
+
+// ValidateTable checks table-level rules: every table (but not view) has a
+// primary key, and only tables keep a history.
+func ValidateTable(table *TableMetadata) []error {
+	if table.IsView() {
+		if table.History {
+			return []error{&ValidationError{Model: modelName(table), Message: fmt.Sprintf("view %s cannot keep a history", table.QualifiedName())}}
+		}
+		return nil
+	}
+	if table.PrimaryKey != nil {
+		return nil
+	}
+	return []error{&ValidationError{Model: modelName(table), Message: fmt.Sprintf("table %s has no primary key", table.QualifiedName())}}
+}
+
+// modelName names a table's model in validation errors: its Go struct when
+// known, else the table name.
+func modelName(table *TableMetadata) string {
+	if table.GoType != nil {
+		return table.GoType.Name()
+	}
+	return table.QualifiedName()
+}
+
+// ValidateReferences checks the foreign keys of tables against each other:
+// every referenced table and column must exist. tables is keyed by
+// qualified table name.
+func ValidateReferences(tables map[string]*TableMetadata) []error {
+	var errs []error
+	for _, name := range slices.Sorted(maps.Keys(tables)) {
+		table := tables[name]
+		model := modelName(table)
+		for _, fk := range table.ForeignKeys {
+			field := fk.Name
+			if col := table.GetColumnByName(fk.Columns[0]); col != nil && col.GoField != "" {
+				field = col.GoField
+			}
+			ref, ok := tables[fk.ReferencedTable]
+			if !ok {
+				errs = append(errs, &ValidationError{Model: model, Field: field,
+					Message: fmt.Sprintf("fk references unknown table %s", fk.ReferencedTable)})
+				continue
+			}
+			for _, col := range fk.ReferencedColumns {
+				if ref.GetColumnByName(col) == nil {
+					errs = append(errs, &ValidationError{Model: model, Field: field,
+						Message: fmt.Sprintf("fk references unknown column %s.%s", fk.ReferencedTable, col)})
+				}
+			}
+		}
+	}
+	return errs
+}
+
+// ValidateModel checks model T's struct tags and returns every problem found,
+// joined, or nil if the model is valid. Relationship targets must be models
+// themselves. Foreign keys to other tables are checked by ValidateReferences
+// (and `pebble vet`), which sees every model.
+func ValidateModel[T any]() error {
+	modelType := reflect.TypeFor[T]()
+	for modelType.Kind() == reflect.Pointer {
+		modelType = modelType.Elem()
+	}
+	model := modelType.Name()
+
+	p := NewParser()
+	table, err := p.Parse(modelType)
+	if err != nil {
+		return &ValidationError{Model: model, Message: err.Error()}
+	}
+
+	var errs []error
+	fields, err := p.collectColumnFields(modelType, "", "")
+	if err != nil {
+		return &ValidationError{Model: model, Message: err.Error()}
+	}
+	for _, cf := range fields {
+		fm := FieldMeta{InferredType: p.typeMapper.GoTypeToPostgreSQL(cf.field.Type)}
+		for _, problem := range ValidateTag(cf.opts, fm) {
+			errs = append(errs, &ValidationError{Model: model, Field: cf.path, Message: problem})
+		}
+	}
+
+	for field := range modelType.Fields() {
+		opts, err := ParseTag(field.Tag.Get(StructTagKey))
+		if err != nil || !IsRelationshipTag(opts) {
+			continue
+		}
+		for _, problem := range ValidateRelationshipTag(opts) {
+			errs = append(errs, &ValidationError{Model: model, Field: field.Name, Message: problem})
+		}
+	}
+	for _, rel := range table.Relationships {
+		if rel.TargetType == nil {
+			errs = append(errs, &ValidationError{Model: model, Field: rel.SourceField, Message: "relationship target is not a struct"})
+			continue
+		}
+		target, err := p.Parse(rel.TargetType)
+		if err != nil || len(target.Columns) == 0 {
+			errs = append(errs, &ValidationError{Model: model, Field: rel.SourceField,
+				Message: fmt.Sprintf("relationship target %s is not a model", rel.TargetType)})
+			continue
+		}
+		if rel.Type == BelongsTo && table.GetColumnByName(rel.ForeignKey) == nil {
+			errs = append(errs, &ValidationError{Model: model, Field: rel.SourceField,
+				Message: fmt.Sprintf("belongsTo foreign key column %s not found", rel.ForeignKey)})
+		}
+		if (rel.Type == HasOne || rel.Type == HasMany) && target.GetColumnByName(rel.ForeignKey) == nil {
+			errs = append(errs, &ValidationError{Model: model, Field: rel.SourceField,
+				Message: fmt.Sprintf("foreign key column %s not found on %s", rel.ForeignKey, target.Name)})
+		}
+		if rel.Type == BelongsTo && target.GetColumnByName(rel.References) == nil {
+			errs = append(errs, &ValidationError{Model: model, Field: rel.SourceField,
+				Message: fmt.Sprintf("referenced column %s not found on %s", rel.References, target.Name)})
+		}
+		if (rel.Type == HasOne || rel.Type == HasMany) && table.GetColumnByName(rel.References) == nil {
+			errs = append(errs, &ValidationError{Model: model, Field: rel.SourceField,
+				Message: fmt.Sprintf("referenced column %s not found", rel.References)})
+		}
+	}
+
+	errs = append(errs, ValidateTable(table)...)
+	return errors.Join(errs...)
+}
+
+// sortedOptionKeys returns the tag's option names in a stable order.
+func sortedOptionKeys(opts *TagOptions) []string {
+	return slices.Sorted(maps.Keys(opts.Options))
+}
+
+// isTagSQLType reports whether a tag option names a SQL type (or its array).
+func isTagSQLType(key string) bool {
+	return slices.Contains(tagSQLTypes, strings.TrimSuffix(key, "[]"))
+}
+
+// isReferenceAction reports whether s is a valid onDelete/onUpdate value.
+func isReferenceAction(s string) bool {
+	switch strings.ToUpper(strings.ReplaceAll(s, "_", " ")) {
+	case "CASCADE", "RESTRICT", "SET NULL", "SETNULL", "SET DEFAULT", "SETDEFAULT", "NO ACTION", "NOACTION":
+		return true
+	}
+	return false
+}
+
+// typeFamily groups SQL types into families a Go type can hold
+// interchangeably. Returns "" for types that aren't checked; arrays map to
+// their element family plus "[]".
+func typeFamily(sqlType string) string {
+	t := strings.ToLower(strings.TrimSpace(sqlType))
+	if strings.HasSuffix(t, "[]") {
+		if elem := typeFamily(strings.TrimSuffix(t, "[]")); elem != "" {
+			return elem + "[]"
+		}
+		return ""
+	}
+	if i := strings.Index(t, "("); i >= 0 {
+		t = strings.TrimSpace(t[:i])
+	}
+	switch t {
+	case "smallint", "integer", "bigint", "int", "int2", "int4", "int8",
+		"serial", "bigserial", "smallserial",
+		"numeric", "decimal", "real", "double precision", "float4", "float8":
+		return "number"
+	case "boolean", "bool":
+		return "boolean"
+	case "uuid":
+		return "uuid"
+	case "text", "varchar", "char", "character varying", "character", "citext":
+		return "text"
+	case "date", "time", "timestamp", "timestamptz", "timestamp with time zone", "timestamp without time zone":
+		return "timestamp"
+	case "interval":
+		return "interval"
+	case "json", "jsonb":
+		return "json"
+	case "bytea":
+		return "bytea"
+	case "inet", "cidr", "macaddr":
+		return "network"
+	}
+	return ""
+}
@@ -0,0 +1,89 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+)
+
+// view: SELECT id, email
+//
+//	FROM users
+//	WHERE active
+type ActiveUserView struct {
+	ID    int    `po:"id,integer"`
+	Email string `po:"email,text"`
+}
+
+func TestParseViewFromComments(t *testing.T) {
+	tests := []struct {
+		name     string
+		comments []string
+		want     *ViewMetadata
+	}{
+		{
+			name:     "single line view",
+			comments: []string{"// view: SELECT id FROM users;"},
+			want:     &ViewMetadata{Query: "SELECT id FROM users"},
+		},
+		{
+			name:     "materialized view",
+			comments: []string{"// materialized_view: SELECT day, sum(total) FROM orders GROUP BY day"},
+			want:     &ViewMetadata{Query: "SELECT day, sum(total) FROM orders GROUP BY day", Materialized: true},
+		},
+		{
+			name: "query continues until the next directive",
+			comments: []string{
+				"// Sales is a reporting view.",
+				"// view: SELECT id, amount::numeric AS total",
+				"//   FROM orders",
+				"// schema: reporting",
+			},
+			want: &ViewMetadata{Query: "SELECT id, amount::numeric AS total FROM orders"},
+		},
+		{
+			name:     "no directive",
+			comments: []string{"// table_name: users"},
+			want:     nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseViewFromComments(tt.comments); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseViewFromComments() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_ViewDirective(t *testing.T) {
+	table, err := NewParser().Parse(reflect.TypeFor[ActiveUserView]())
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if !table.IsView() {
+		t.Fatal("expected a view model")
+	}
+	if table.View.Query != "SELECT id, email FROM users WHERE active" {
+		t.Errorf("Query = %q", table.View.Query)
+	}
+	if table.View.Materialized {
+		t.Error("expected a plain view")
+	}
+}
+
+func TestRegisterView(t *testing.T) {
+	type RegisteredSalesView struct {
+		Day string `po:"day,date"`
+	}
+	RegisterView("RegisteredSalesView", "SELECT day FROM sales", true)
+	defer delete(customViews, "RegisteredSalesView")
+
+	table, err := NewParser().Parse(reflect.TypeFor[RegisteredSalesView]())
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if !table.IsView() || !table.View.Materialized || table.View.Query != "SELECT day FROM sales" {
+		t.Errorf("unexpected view metadata: %+v", table.View)
+	}
+}